@@ -21,12 +21,19 @@ import (
 	"os"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/klog/v2/klogr"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/profiler"
+	"kpt.dev/configsync/pkg/reconcilermanager"
+	"kpt.dev/configsync/pkg/util"
 	"kpt.dev/configsync/pkg/util/log"
 	"kpt.dev/configsync/pkg/webhook"
 	"kpt.dev/configsync/pkg/webhook/configuration"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 )
@@ -38,6 +45,8 @@ var (
 	healthProbeBindAddress  string
 	gracefulShutdownTimeout time.Duration
 	cacheSyncTimeout        time.Duration
+	metricsExporter         string
+	prometheusMetricsAddr   string
 )
 
 func main() {
@@ -46,12 +55,39 @@ func main() {
 	flag.StringVar(&healthProbeBindAddress, "health-probe-bind-addr", fmt.Sprintf(":%d", configuration.HealthProbePort), "The address the healthz & readyz probes bind to.")
 	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", configuration.GracefulShutdownTimeout, "The duration of time to wait while shutting down for all controllers to stop.")
 	flag.DurationVar(&cacheSyncTimeout, "cache-sync-timeout", configuration.CacheSyncTimeout, "The duration of time to wait while informers synchronize.")
+	flag.StringVar(&metricsExporter, "metrics-exporter", util.EnvString(reconcilermanager.MetricsExporter, ""),
+		fmt.Sprintf("The exporter used to export metrics. Only %s is supported. If unset, only the controller-runtime metrics are served.", metrics.PrometheusExporterType))
+	flag.StringVar(&prometheusMetricsAddr, "prometheus-metrics-addr", fmt.Sprintf(":%d", metrics.PrometheusPort),
+		"The address the Prometheus metrics endpoint binds to, if the prometheus metrics exporter is used.")
 
 	log.Setup()
 
 	profiler.Service()
 	ctrl.SetLogger(klogr.New())
 
+	// The webhook does not run the otel-agent sidecar, so only the Prometheus
+	// exporter is supported.
+	if metricsExporter == metrics.PrometheusExporterType {
+		podName, _ := os.Hostname()
+		pe, err := metrics.RegisterPrometheusExporter(prometheusMetricsAddr, configuration.ShortName,
+			map[string]string{
+				metrics.ResourceKeyDeploymentName.Name(): configuration.ShortName,
+				metrics.ResourceKeyPodName.Name():        podName,
+			})
+		if err != nil {
+			setupLog.Error(err, "failed to register the Prometheus metrics exporter")
+			os.Exit(1)
+		}
+		defer func() {
+			if err := pe.Stop(); err != nil {
+				setupLog.Error(err, "unable to stop the Prometheus metrics exporter")
+			}
+		}()
+	} else if metricsExporter != "" {
+		setupLog.Error(fmt.Errorf("unsupported metrics exporter %q", metricsExporter), "invalid flag value")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Port:    configuration.ContainerPort,
@@ -62,6 +98,18 @@ func main() {
 		Controller: v1alpha1.ControllerConfigurationSpec{
 			CacheSyncTimeout: &cacheSyncTimeout,
 		},
+		// The validator only reads the policy ConfigMap, so don't cache the
+		// other ConfigMaps on the cluster.
+		NewCache: cache.BuilderWithOptions(cache.Options{
+			SelectorsByObject: cache.SelectorsByObject{
+				&corev1.ConfigMap{}: {
+					Field: fields.SelectorFromSet(fields.Set{
+						"metadata.namespace": configsync.ControllerNamespace,
+						"metadata.name":      configuration.PolicyName,
+					}),
+				},
+			},
+		}),
 	})
 	if err != nil {
 		setupLog.Error(err, "starting manager")
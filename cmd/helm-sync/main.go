@@ -104,6 +104,9 @@ func main() {
 		}
 	}
 
+	// The reconciler requests a fetch with the RefreshSignal when a resync is
+	// requested.
+	refresh := util.NotifyRefresh()
 	initialSync := true
 	failCount := 0
 	for {
@@ -142,7 +145,7 @@ func main() {
 			log.Error(err, "unexpected error rendering chart, will retry")
 			log.Info("waiting before retrying", "waitTime", util.WaitTime(*flWait))
 			cancel()
-			time.Sleep(util.WaitTime(*flWait))
+			util.WaitOrRefresh(util.WaitTime(*flWait), refresh)
 			continue
 		}
 
@@ -158,6 +161,6 @@ func main() {
 		log.DeleteErrorFile()
 		log.Info("next sync", "wait_time", util.WaitTime(*flWait))
 		cancel()
-		time.Sleep(util.WaitTime(*flWait))
+		util.WaitOrRefresh(util.WaitTime(*flWait), refresh)
 	}
 }
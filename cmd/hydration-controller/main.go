@@ -17,8 +17,10 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
@@ -27,9 +29,12 @@ import (
 	"kpt.dev/configsync/pkg/hydrate"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/kmetrics"
+	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/profiler"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/reconcilermanager/controllers"
+	"kpt.dev/configsync/pkg/tracing"
+	"kpt.dev/configsync/pkg/util"
 	"kpt.dev/configsync/pkg/util/log"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -56,6 +61,12 @@ var (
 	syncDir = flag.String("sync-dir", os.Getenv(reconcilermanager.SyncDirKey),
 		"Relative path of the root directory within the repo.")
 
+	syncInclude = flag.String("sync-include", os.Getenv(reconcilermanager.SyncIncludeKey),
+		"Comma-separated list of patterns of the files to render from the sync directory. If empty, all the files are rendered.")
+
+	syncExclude = flag.String("sync-exclude", os.Getenv(reconcilermanager.SyncExcludeKey),
+		"Comma-separated list of patterns of the files not to render from the sync directory.")
+
 	pollingPeriod = flag.Duration("polling-period",
 		controllers.PollingPeriod(reconcilermanager.HydrationPollingPeriod, configsync.DefaultHydrationPollingPeriod),
 		"Period of time between checking the filesystem for source updates to render.")
@@ -64,12 +75,34 @@ var (
 	// periodically when errors happen. It retries on both transient errors and permanent errors.
 	// Other ways to trigger the hydration process are:
 	// - push a new commit
-	// - delete the done file from the hydration-controller.
+	// - delete the done file from the hydration-controller
+	// - request a resync, which makes the reconciler send the util.RefreshSignal.
 	rehydratePeriod = flag.Duration("rehydrate-period", configsync.DefaultHydrationRetryPeriod,
 		"Period of time between rehydrating on errors.")
 
 	reconcilerName = flag.String("reconciler-name", os.Getenv(reconcilermanager.ReconcilerNameKey),
 		"Name of the reconciler Deployment.")
+
+	metricsExporter = flag.String("metrics-exporter", util.EnvString(reconcilermanager.MetricsExporter, metrics.OtelAgentExporter),
+		fmt.Sprintf("The exporter used to export metrics. Must be %s or %s.", metrics.OtelAgentExporter, metrics.PrometheusExporterType))
+
+	prometheusMetricsAddr = flag.String("prometheus-metrics-addr", fmt.Sprintf(":%d", metrics.HydrationControllerPrometheusPort),
+		"The address the Prometheus metrics endpoint binds to, if the prometheus metrics exporter is used.")
+
+	allowedExecFunctions = flag.String("allowed-exec-functions", os.Getenv(reconcilermanager.HydrationAllowedExecFunctions),
+		"Comma-separated list of the executables allowed to run as exec functions of the Kptfile pipeline.")
+
+	renderCacheDir = flag.String("render-cache", "render-cache",
+		"the name of the render cache directory under --repo-root.")
+
+	renderCacheSize = flag.Int("render-cache-size", 5,
+		"The maximum number of rendered directories kept in the render cache. Set to 0 to disable the render cache.")
+
+	renderCacheRemoteTTL = flag.Duration("render-cache-remote-ttl", time.Hour,
+		"Period of time after which the configs rendered from remote bases or Helm charts are rendered again, even if the source configs are unchanged.")
+
+	traceSamplingProbability = flag.Float64("trace-sampling-probability", util.EnvFloat(reconcilermanager.TraceSamplingProbability, 0),
+		"The probability of recording the trace of a rendering, between 0 and 1.")
 )
 
 func main() {
@@ -82,24 +115,44 @@ func main() {
 		klog.Fatalf("Failed to register OpenCensus views: %v", err)
 	}
 
-	// Register the OC Agent exporter
-	oce, err := kmetrics.RegisterOCAgentExporter(reconcilermanager.HydrationController)
+	// Register the metrics exporter
+	podName, _ := os.Hostname()
+	oce, err := metrics.RegisterExporter(*metricsExporter, reconcilermanager.HydrationController, *prometheusMetricsAddr,
+		map[string]string{
+			metrics.ResourceKeyDeploymentName.Name(): *reconcilerName,
+			metrics.ResourceKeyPodName.Name():        podName,
+		})
 	if err != nil {
-		klog.Fatalf("Failed to register the OC Agent exporter: %v", err)
+		klog.Fatalf("Failed to register the %s metrics exporter: %v", *metricsExporter, err)
 	}
 
 	defer func() {
 		if err := oce.Stop(); err != nil {
-			klog.Fatalf("Unable to stop the OC Agent exporter: %v", err)
+			klog.Fatalf("Unable to stop the %s metrics exporter: %v", *metricsExporter, err)
 		}
 	}()
 
+	// Export traces with the metrics exporter, if it supports traces, or to
+	// the otel-agent sidecar otherwise
+	te, err := tracing.RegisterExporter(oce, *traceSamplingProbability)
+	if err != nil {
+		klog.Fatalf("Failed to register the trace exporter: %v", err)
+	}
+	if te != nil {
+		defer func() {
+			if err := te.Stop(); err != nil {
+				klog.Fatalf("Unable to stop the trace exporter: %v", err)
+			}
+		}()
+	}
+
 	absRepoRootDir, err := cmpath.AbsoluteOS(*repoRootDir)
 	if err != nil {
 		klog.Fatalf("--repo-root must be an absolute path: %v", err)
 	}
 	absSourceRootDir := absRepoRootDir.Join(cmpath.RelativeSlash(*sourceRootDir))
 	absHydratedRootDir := absRepoRootDir.Join(cmpath.RelativeSlash(*hydratedRootDir))
+	absRenderCacheDir := absRepoRootDir.Join(cmpath.RelativeSlash(*renderCacheDir))
 	absDonePath := absRepoRootDir.Join(cmpath.RelativeSlash(hydrate.DoneFile))
 
 	// Normalize syncDirRelative.
@@ -110,16 +163,23 @@ func main() {
 	relSyncDir := cmpath.RelativeOS(dir)
 
 	hydrator := &hydrate.Hydrator{
-		DonePath:        absDonePath,
-		SourceType:      v1beta1.SourceType(*sourceType),
-		SourceRoot:      absSourceRootDir,
-		HydratedRoot:    absHydratedRootDir,
-		SourceLink:      *sourceLinkDir,
-		HydratedLink:    *hydratedLinkDir,
-		SyncDir:         relSyncDir,
-		PollingPeriod:   *pollingPeriod,
-		RehydratePeriod: *rehydratePeriod,
-		ReconcilerName:  *reconcilerName,
+		DonePath:             absDonePath,
+		SourceType:           v1beta1.SourceType(*sourceType),
+		SourceRoot:           absSourceRootDir,
+		HydratedRoot:         absHydratedRootDir,
+		SourceLink:           *sourceLinkDir,
+		HydratedLink:         *hydratedLinkDir,
+		SyncDir:              relSyncDir,
+		SyncInclude:          util.SplitList(*syncInclude),
+		SyncExclude:          util.SplitList(*syncExclude),
+		PollingPeriod:        *pollingPeriod,
+		RehydratePeriod:      *rehydratePeriod,
+		ReconcilerName:       *reconcilerName,
+		AllowedExecFunctions: util.SplitList(*allowedExecFunctions),
+		RenderCacheRoot:      absRenderCacheDir,
+		RenderCacheSize:      *renderCacheSize,
+		RenderCacheRemoteTTL: *renderCacheRemoteTTL,
+		Refresh:              util.NotifyRefresh(),
 	}
 
 	hydrator.Run(context.Background())
@@ -0,0 +1,410 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package explain
+
+// entry documents an error code for users.
+type entry struct {
+	// title summarizes the error in a few words.
+	title string
+	// causes describes what triggers the error.
+	causes string
+	// fixes describes how to resolve the error.
+	fixes string
+}
+
+// deprecatedCodes are the error codes which are no longer reported.
+var deprecatedCodes = map[string]bool{
+	"1000": true, "1001": true, "1002": true, "1008": true, "1012": true,
+	"1015": true, "1016": true, "1018": true, "1022": true, "1023": true,
+	"1024": true, "1025": true, "1026": true, "1035": true, "1037": true,
+	"1040": true, "1049": true, "1051": true, "1059": true, "1062": true,
+	"1063": true, "2007": true,
+}
+
+// catalogue documents the error codes which may be reported by Config Sync.
+// KNV1XXX errors are mistakes in the source of truth, KNV2XXX errors are
+// problems in the cluster, and KNV9XXX errors are bugs.
+var catalogue = map[string]entry{
+	"1003": {
+		title:  "Namespace directory with subdirectories",
+		causes: "In a hierarchical repo, a directory containing a Namespace config also has subdirectories.",
+		fixes:  "Move the subdirectories out of the Namespace directory, or move the Namespace config into a leaf directory.",
+	},
+	"1004": {
+		title:  "Namespace selector on a cluster-scoped object",
+		causes: "A cluster-scoped object declares the configmanagement.gke.io/namespace-selector annotation.",
+		fixes:  "Remove the namespace-selector annotation from the cluster-scoped object.",
+	},
+	"1005": {
+		title:  "Invalid management annotation",
+		causes: "An object declares the configmanagement.gke.io/managed annotation with a value other than \"disabled\".",
+		fixes:  "Set the annotation to \"disabled\" to stop managing the object, or remove the annotation.",
+	},
+	"1006": {
+		title:  "Unparsable config",
+		causes: "A config in the source of truth could not be decoded into its declared kind, usually because of a field of the wrong type.",
+		fixes:  "Fix the config so that it matches the schema of its kind, e.g. with kubectl apply --dry-run=server.",
+	},
+	"1007": {
+		title:  "Object in an abstract namespace directory",
+		causes: "In a hierarchical repo, a kind which can't be inherited is declared in a directory which is not a Namespace directory.",
+		fixes:  "Move the config to a Namespace directory.",
+	},
+	"1009": {
+		title:  "Namespace does not match the directory",
+		causes: "In a hierarchical repo, a config declares a metadata.namespace other than the Namespace directory containing it.",
+		fixes:  "Remove metadata.namespace from the config, or set it to the name of the Namespace directory.",
+	},
+	"1010": {
+		title:  "Reserved annotation",
+		causes: "A config declares an unsupported annotation starting with configmanagement.gke.io/ or configsync.gke.io/.",
+		fixes:  "Remove the annotation from the config.",
+	},
+	"1011": {
+		title:  "Reserved label",
+		causes: "A config declares a label starting with configmanagement.gke.io/.",
+		fixes:  "Remove the label from the config.",
+	},
+	"1013": {
+		title:  "Unknown selector",
+		causes: "A config references a NamespaceSelector or ClusterSelector which is not declared, or not in scope of the config.",
+		fixes:  "Declare the selector in the config's directory or a parent directory, or remove the selector annotation.",
+	},
+	"1014": {
+		title:  "Invalid selector",
+		causes: "A NamespaceSelector or ClusterSelector has an invalid label selector.",
+		fixes:  "Fix the selector, so that it's a valid Kubernetes label selector.",
+	},
+	"1017": {
+		title:  "Missing Repo",
+		causes: "A hierarchical repo does not declare a Repo object in the system/ directory.",
+		fixes:  "Declare a Repo object in system/, or use sourceFormat: unstructured.",
+	},
+	"1019": {
+		title:  "Namespace outside of namespaces/",
+		causes: "In a hierarchical repo, a Namespace is declared at the top level of namespaces/ or outside of it.",
+		fixes:  "Move the Namespace config into its own subdirectory of namespaces/.",
+	},
+	"1020": {
+		title:  "Namespace name does not match the directory",
+		causes: "In a hierarchical repo, a Namespace's metadata.name differs from the name of its directory.",
+		fixes:  "Rename the Namespace or its directory, so that they match.",
+	},
+	"1021": {
+		title:  "Unknown kind",
+		causes: "A config has a kind which is neither built into the cluster, nor defined by a CustomResourceDefinition in the cluster or the source of truth.",
+		fixes:  "Declare the CustomResourceDefinition in the source of truth or install it in the cluster, or fix the apiVersion and kind of the config.",
+	},
+	"1027": {
+		title:  "Unsupported Repo version",
+		causes: "The Repo object in system/ declares an unsupported spec.version.",
+		fixes:  "Set spec.version of the Repo to \"1.0.0\".",
+	},
+	"1028": {
+		title:  "Reserved directory name",
+		causes: "A hierarchical repo has a directory with a reserved or invalid name, like config-management-system.",
+		fixes:  "Rename or remove the directory.",
+	},
+	"1029": {
+		title:  "Duplicate objects",
+		causes: "Several configs declare an object of the same group, kind, namespace and name.",
+		fixes:  "Remove or rename the duplicates.",
+	},
+	"1030": {
+		title:  "Several singletons in a directory",
+		causes: "A directory declares several objects of a kind which must be unique in a directory, like Namespace.",
+		fixes:  "Remove the extra objects, so that at most one remains.",
+	},
+	"1031": {
+		title:  "Missing name",
+		causes: "A config does not declare metadata.name.",
+		fixes:  "Add metadata.name to the config.",
+	},
+	"1032": {
+		title:  "Hierarchical kind in an unstructured repo",
+		causes: "A repo with sourceFormat: unstructured declares a kind which is only supported in hierarchical repos, like Repo or HierarchyConfig.",
+		fixes:  "Remove the config, or convert the repo to sourceFormat: hierarchy.",
+	},
+	"1033": {
+		title:  "System object outside of system/",
+		causes: "In a hierarchical repo, a kind which belongs in system/, like Repo or HierarchyConfig, is declared in another directory.",
+		fixes:  "Move the config to system/.",
+	},
+	"1034": {
+		title:  "Reserved Namespace",
+		causes: "The source of truth declares the config-management-system Namespace, or objects in it.",
+		fixes:  "Remove the Namespace and its objects from the source of truth.",
+	},
+	"1036": {
+		title:  "Invalid name",
+		causes: "A config declares a metadata.name which is not a valid Kubernetes name.",
+		fixes:  "Rename the config, using lowercase alphanumeric characters, '-' or '.', with fewer than 254 characters.",
+	},
+	"1038": {
+		title:  "Cluster-scoped object in namespaces/",
+		causes: "In a hierarchical repo, a kind which can't be declared in namespaces/, like a cluster-scoped kind, is declared there.",
+		fixes:  "Move the config to cluster/, or remove it.",
+	},
+	"1039": {
+		title:  "Object in the wrong top-level directory",
+		causes: "In a hierarchical repo, a config is declared in a top-level directory which doesn't allow its kind, e.g. a Cluster outside of clusterregistry/.",
+		fixes:  "Move the config to the top-level directory of its kind.",
+	},
+	"1041": {
+		title:  "Unsupported resource in HierarchyConfig",
+		causes: "A HierarchyConfig references a kind which can't be inherited, like Namespace.",
+		fixes:  "Remove the kind from the HierarchyConfig.",
+	},
+	"1042": {
+		title:  "Invalid hierarchy mode",
+		causes: "A HierarchyConfig declares an unsupported hierarchyMode.",
+		fixes:  "Set hierarchyMode to \"none\" or \"inherit\".",
+	},
+	"1043": {
+		title:  "Unsupported object",
+		causes: "The source of truth declares a kind which Config Sync doesn't support, like a CustomResourceDefinition in the configmanagement.gke.io group.",
+		fixes:  "Remove the config, or use a different group for the custom resource.",
+	},
+	"1044": {
+		title:  "Abstract namespace without Namespaces",
+		causes: "In a hierarchical repo, a directory has configs and subdirectories, but none of the subdirectories are Namespace directories, so the configs would never be synced.",
+		fixes:  "Add a Namespace directory below the directory, or remove its configs.",
+	},
+	"1045": {
+		title:  "Config with status",
+		causes: "A config declares fields which are reserved for the cluster, like status.",
+		fixes:  "Remove the status field from the config.",
+	},
+	"1046": {
+		title:  "Cluster-scoped resource in HierarchyConfig",
+		causes: "A HierarchyConfig references a cluster-scoped kind, which can't be inherited.",
+		fixes:  "Remove the kind from the HierarchyConfig.",
+	},
+	"1047": {
+		title:  "CRD removed with its custom resources",
+		causes: "A commit removes a CustomResourceDefinition but still declares custom resources of its kind.",
+		fixes:  "Remove the custom resources in the same commit as the CRD, or re-add the CRD.",
+	},
+	"1048": {
+		title:  "Invalid CRD name",
+		causes: "A CustomResourceDefinition's metadata.name is not <spec.names.plural>.<spec.group>.",
+		fixes:  "Rename the CRD, or fix spec.names.plural and spec.group.",
+	},
+	"1050": {
+		title:  "Deprecated group and kind",
+		causes: "A config uses a deprecated group for its kind, like extensions Deployments.",
+		fixes:  "Update the apiVersion of the config to the group named in the error.",
+	},
+	"1052": {
+		title:  "Namespace on a cluster-scoped object",
+		causes: "A cluster-scoped object declares metadata.namespace.",
+		fixes:  "Remove metadata.namespace from the config.",
+	},
+	"1053": {
+		title:  "Missing namespace",
+		causes: "A namespace-scoped object in an unstructured repo declares neither metadata.namespace nor a namespace-selector annotation.",
+		fixes:  "Set metadata.namespace, or add a namespace-selector annotation.",
+	},
+	"1054": {
+		title:  "Non-string annotation value",
+		causes: "A config declares an annotation whose value is not a string, like a number or a boolean.",
+		fixes:  "Quote the annotation value.",
+	},
+	"1055": {
+		title:  "Invalid namespace",
+		causes: "A config declares a metadata.namespace which is not a valid Namespace name.",
+		fixes:  "Set metadata.namespace to a valid Namespace name.",
+	},
+	"1056": {
+		title:  "Managed object in an unmanaged Namespace",
+		causes: "A Namespace is declared with configmanagement.gke.io/managed: disabled, but objects in it are managed.",
+		fixes:  "Remove the annotation from the Namespace, or add it to its objects.",
+	},
+	"1057": {
+		title:  "Reserved depth label",
+		causes: "A config declares a label ending with .tree.hnc.x-k8s.io/depth, which is reserved for the hierarchy controller.",
+		fixes:  "Remove the label from the config.",
+	},
+	"1058": {
+		title:  "Object outside the RepoSync namespace",
+		causes: "A RepoSync may only manage objects in its own namespace, but its source of truth declares another namespace or a cluster-scoped object.",
+		fixes:  "Omit metadata.namespace or set it to the RepoSync namespace, and move cluster-scoped objects to a RootSync.",
+	},
+	"1060": {
+		title:  "Management conflict",
+		causes: "An object is declared in the sources of truth of two RootSyncs or RepoSyncs, so both reconcilers try to manage it. The object is managed by the reconciler named in its configsync.gke.io/manager annotation.",
+		fixes:  "Remove the object from one of the two sources of truth. To move an object to another reconciler on purpose, use the configsync.gke.io/handoff-to annotation.",
+	},
+	"1061": {
+		title:  "Invalid RootSync or RepoSync",
+		causes: "The spec of the RootSync or RepoSync is invalid, e.g. a required field is missing for its source type or auth type.",
+		fixes:  "Fix the spec of the RootSync or RepoSync as described by the error.",
+	},
+	"1064": {
+		title:  "Invalid API resources",
+		causes: "The api-resources.txt file of the repo, used by nomos vet --no-api-server-check, could not be parsed.",
+		fixes:  "Regenerate the file with kubectl api-resources > api-resources.txt.",
+	},
+	"1065": {
+		title:  "Malformed CRD",
+		causes: "A CustomResourceDefinition in the source of truth could not be parsed.",
+		fixes:  "Fix the CRD so that it matches the CustomResourceDefinition schema.",
+	},
+	"1066": {
+		title:  "Conflicting cluster selectors",
+		causes: "A config declares both the configsync.gke.io/cluster-name-selector and the configmanagement.gke.io/cluster-selector annotations.",
+		fixes:  "Remove one of the two annotations.",
+	},
+	"1067": {
+		title:  "Unencodable declared fields",
+		causes: "The fields declared by a config could not be recorded for drift detection, usually because the config doesn't match its schema.",
+		fixes:  "Fix the config so that it matches the schema of its kind.",
+	},
+	"1068": {
+		title:  "Rendering error",
+		causes: "Rendering the source of truth with kustomize or helm failed because of a mistake in the source, like a missing resource or an invalid kustomization.yaml.",
+		fixes:  "Run kustomize build or nomos hydrate locally, and fix the reported error.",
+	},
+	"1069": {
+		title:  "Self-managing RootSync or RepoSync",
+		causes: "A RootSync or RepoSync declares itself in its own source of truth.",
+		fixes:  "Remove the RootSync or RepoSync from its own source of truth, and manage it from another one.",
+	},
+	"1070": {
+		title:  "Schema validation failure",
+		causes: "A config declares fields which don't exist in the schema of its kind, or fields of the wrong type.",
+		fixes:  "Fix the config, e.g. by checking the field names with kubectl explain.",
+	},
+	"1071": {
+		title:  "RepoSyncPolicy violation",
+		causes: "The objects declared for a RepoSync exceed the limits of the RepoSyncPolicy referenced by its Namespace, or the Namespace references a policy which does not exist or does not allow it.",
+		fixes:  "Remove the objects or fields denied by the policy, or ask a cluster admin to update the RepoSyncPolicy.",
+	},
+	"1072": {
+		title:  "Illegal handoff",
+		causes: "An object declares a configsync.gke.io/handoff-to annotation which is not a valid handoff, e.g. to a reconciler of another namespace, or to itself.",
+		fixes:  "Fix the annotation, so that it names a RootSync, or a RepoSync in the object's namespace.",
+	},
+	"1073": {
+		title:  "Illegal conflict policy",
+		causes: "An object declares a configsync.gke.io/conflict-policy annotation which is not a valid conflict policy.",
+		fixes:  "Set the annotation to \"force\", \"respect\", or \"fail\", or remove it to use the conflict policy of the RootSync or RepoSync.",
+	},
+	"1074": {
+		title:  "Illegal ignore-fields annotation",
+		causes: "An object declares a configsync.gke.io/ignore-fields annotation with an invalid field path, or with a path of a field which identifies the object, like .metadata.name.",
+		fixes:  "Fix the annotation, so that it lists the paths of the ignored fields separated by commas, like \".spec.replicas\".",
+	},
+	"1075": {
+		title:  "Field conflict",
+		causes: "An object with the \"fail\" conflict policy declares fields which are owned by another field manager, e.g. a controller or kubectl. The object is not applied, and it is not pruned.",
+		fixes:  "Remove the fields from the declaration of the object, stop the other manager from updating them, or set the configsync.gke.io/conflict-policy annotation to \"force\" or \"respect\".",
+	},
+	"1076": {
+		title:  "Admission policy violation",
+		causes: "A config violates a ValidatingAdmissionPolicy bound with the Deny action, or a Gatekeeper constraint with the deny enforcement action, of the cluster or declared in the source of truth, so the API server would deny it.",
+		fixes:  "Fix the config so that it satisfies the policy, or ask a cluster admin to update the policy, its binding or the constraint. The policyEnforcement override of the RootSync or RepoSync turns the violations into warnings.",
+	},
+	"1077": {
+		title:  "Admission policy warning",
+		causes: "A config violates a ValidatingAdmissionPolicy which is only bound with the Warn or Audit action, or a Gatekeeper constraint with the dryrun or warn enforcement action, or a policy could not be evaluated before apply, e.g. because its Rego template is invalid, or the policyEnforcement override of the RootSync or RepoSync is warn. The sync is not blocked.",
+		fixes:  "Fix the config so that it satisfies the policy. If the policy could not be evaluated, the API server still evaluates it when the config is applied.",
+	},
+	"2001": {
+		title:  "Path error",
+		causes: "A file or directory of the source of truth could not be read.",
+		fixes:  "Check that the sync directory exists in the source of truth, and that its files are readable.",
+	},
+	"2002": {
+		title:  "API server error",
+		causes: "A request to the Kubernetes API server failed.",
+		fixes:  "These errors are often transient and retried automatically. If it persists, check the health of the API server and the reconciler logs.",
+	},
+	"2003": {
+		title:  "Operating system error",
+		causes: "The reconciler failed to access the local file system.",
+		fixes:  "Check the reconciler logs. The error is usually fixed by restarting the reconciler.",
+	},
+	"2004": {
+		title:  "Source error",
+		causes: "The reconciler failed to fetch the source of truth, e.g. because of a wrong repo URL, branch, revision, or credentials.",
+		fixes:  "Check the spec of the RootSync or RepoSync and the Secret used for authentication, and the logs of the git-sync, oci-sync or helm-sync container.",
+	},
+	"2005": {
+		title:  "Fight with another controller",
+		causes: "Another controller keeps changing a managed object, and Config Sync keeps reverting it.",
+		fixes:  "Find the other controller modifying the object, e.g. with the object's managedFields, and stop it from modifying the fields declared in the source of truth.",
+	},
+	"2006": {
+		title:  "Deleting all Namespaces",
+		causes: "A commit would delete all the Namespaces managed by the reconciler, which usually is a mistake like a wrong sync directory.",
+		fixes:  "If this is intended, first delete all the Namespaces but one in a commit, and then the last one in another commit.",
+	},
+	"2008": {
+		title:  "Resource conflict",
+		causes: "A managed object was modified or created concurrently by another client.",
+		fixes:  "These errors are transient and retried automatically.",
+	},
+	"2009": {
+		title:  "Apply error",
+		causes: "The reconciler failed to apply, prune or delete managed objects.",
+		fixes:  "Check the error and the reconciler logs. Errors on specific objects are reported with KNV2010.",
+	},
+	"2010": {
+		title:  "Resource error",
+		causes: "The reconciler failed to apply, prune, delete or wait for a specific managed object.",
+		fixes:  "Check the error, and the status and events of the object.",
+	},
+	"2011": {
+		title:  "Missing resource",
+		causes: "An object which the reconciler expected to exist was not found in the cluster.",
+		fixes:  "These errors are usually transient and retried automatically.",
+	},
+	"2012": {
+		title:  "Several singletons in the cluster",
+		causes: "The cluster has more than one object of a kind which must be unique, like Repo.",
+		fixes:  "Delete the extra objects.",
+	},
+	"2013": {
+		title:  "Insufficient permission",
+		causes: "The reconciler's ServiceAccount is not allowed to manage an object.",
+		fixes:  "Grant the reconciler the permissions to manage the object, e.g. with a RoleBinding for a RepoSync reconciler.",
+	},
+	"2014": {
+		title:  "Invalid webhook configuration",
+		causes: "The Config Sync admission webhook configuration is invalid.",
+		fixes:  "Check the ValidatingWebhookConfiguration of Config Sync, or reinstall Config Sync.",
+	},
+	"2015": {
+		title:  "Internal rendering error",
+		causes: "The hydration controller failed to render the source of truth because of a problem in the cluster, not in the source.",
+		fixes:  "Check the logs of the hydration-controller container. The error is usually fixed by restarting the reconciler.",
+	},
+	"2016": {
+		title:  "Transient error",
+		causes: "A temporary error occurred, like a timeout.",
+		fixes:  "These errors are retried automatically. If one persists, check the reconciler logs.",
+	},
+	"9998": {
+		title:  "Internal error",
+		causes: "Config Sync encountered an unexpected state, which is a bug.",
+		fixes:  "File a bug with the error message and a nomos bugreport.",
+	},
+	"9999": {
+		title:  "Undocumented error",
+		causes: "An error occurred which has no specific code.",
+		fixes:  "Check the error message and the reconciler logs. If the cause is unclear, file a bug with the error message and a nomos bugreport.",
+	},
+}
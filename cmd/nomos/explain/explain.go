@@ -0,0 +1,359 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package explain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/cmd/nomos/status"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/client/restconfig"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/metadata"
+	pkgstatus "kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var namespace string
+
+// codePattern matches an error code, with or without the KNV prefix.
+var codePattern = regexp.MustCompile(`^(?i:KNV)?([0-9]{4})$`)
+
+// conflictingManagerPattern extracts the conflicting manager from the message
+// of a management conflict error.
+var conflictingManagerPattern = regexp.MustCompile(`repository managed by "([^"]*)"`)
+
+func init() {
+	flags.AddContexts(Cmd)
+	Cmd.Flags().DurationVar(&flags.ClientTimeout, "timeout", restconfig.DefaultTimeout, "Timeout for connecting to each cluster.")
+	Cmd.Flags().StringVar(&namespace, "namespace", configsync.ControllerNamespace,
+		fmt.Sprintf("Namespace of the RepoSync (leave unset for a RootSync in %s).", configsync.ControllerNamespace))
+}
+
+// Cmd explains error codes, or the errors of a RootSync or RepoSync.
+var Cmd = &cobra.Command{
+	Use:   "explain CODE|NAME",
+	Short: "Explains an error code, or the errors of a RootSync or RepoSync.",
+	Long: `Explains an error code, or the errors of a RootSync or RepoSync.
+
+If the argument is an error code, like KNV1060 or 1060, prints its causes and
+how to fix it.
+
+Otherwise, the argument is the name of a RootSync, or of a RepoSync in the
+namespace set by --namespace. Prints the errors reported in its status on all
+clusters, grouped by code and resource, with guidance on how to fix them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Don't show usage on error, as argument validation passed.
+		cmd.SilenceUsage = true
+
+		if m := codePattern.FindStringSubmatch(args[0]); m != nil {
+			return explainCode(os.Stdout, m[1])
+		}
+
+		clientMap, err := status.ClusterClients(cmd.Context(), flags.Contexts)
+		if err != nil {
+			return err
+		}
+		if len(clientMap) == 0 {
+			return errors.New("no clusters found")
+		}
+		// Use a sorted order of names to avoid shuffling in the output.
+		var names []string
+		for cluster := range clientMap {
+			names = append(names, cluster)
+		}
+		sort.Strings(names)
+
+		key := client.ObjectKey{Namespace: namespace, Name: args[0]}
+		failed := 0
+		for _, cluster := range names {
+			fmt.Printf("%s%s: %s %s\n", util.Bullet, cluster, syncKind(key), key)
+			c := clientMap[cluster]
+			if c == nil {
+				printError(errors.New("failed to connect to cluster"))
+				failed++
+				continue
+			}
+			if err := explainSync(cmd.Context(), os.Stdout, c.Client, key); err != nil {
+				printError(err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to explain the errors on %d of %d clusters", failed, len(names))
+		}
+		return nil
+	},
+}
+
+func printError(err error) {
+	fmt.Fprintf(os.Stderr, "%sError: %v%s\n", util.ColorRed, err, util.ColorDefault)
+}
+
+// explainCode prints the catalogue entry of the error code.
+func explainCode(w io.Writer, code string) error {
+	if deprecatedCodes[code] {
+		fmt.Fprintf(w, "KNV%s is no longer reported by Config Sync.\n", code)
+		return nil
+	}
+	e, found := catalogue[code]
+	if !found {
+		return fmt.Errorf("unknown error code KNV%s", code)
+	}
+	fmt.Fprintf(w, "KNV%s: %s\n\nCauses:\n  %s\n\nFixes:\n  %s\n", code, e.title, e.causes, e.fixes)
+	return nil
+}
+
+// explainSync prints the errors reported in the status of the RootSync or
+// RepoSync, grouped by code and resource.
+func explainSync(ctx context.Context, w io.Writer, c client.Client, key client.ObjectKey) error {
+	obj := newSyncObject(key)
+	if err := c.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	errs := syncErrors(obj)
+	if len(errs) == 0 {
+		fmt.Fprintf(w, "  No errors.\n")
+		return nil
+	}
+	manager := declared.ResourceManager(syncScope(key), key.Name)
+	for _, g := range groupErrors(errs) {
+		e, found := catalogue[g.code]
+		if !found {
+			e = entry{title: "Unknown error", fixes: "Check the error message and the reconciler logs."}
+		}
+		fmt.Fprintf(w, "  KNV%s: %s (%d %s)\n", g.code, e.title, g.count, plural(g.count, "error"))
+		if e.causes != "" {
+			fmt.Fprintf(w, "    Causes: %s\n", e.causes)
+		}
+		fmt.Fprintf(w, "    Fixes: %s\n", e.fixes)
+		for _, r := range g.resources {
+			if r.ref != nil {
+				fmt.Fprintf(w, "    - %s\n", describeRef(*r.ref))
+				if r.ref.SourcePath != "" {
+					fmt.Fprintf(w, "        Source: %s\n", r.ref.SourcePath)
+				}
+			} else {
+				fmt.Fprintf(w, "    - No specific resource\n")
+			}
+			for _, msg := range r.messages {
+				fmt.Fprintf(w, "        %s\n", msg)
+			}
+			if g.code == pkgstatus.ManagementConflictErrorCode && r.ref != nil {
+				explainConflict(ctx, w, c, *r.ref, r.messages, manager)
+			}
+		}
+	}
+	return nil
+}
+
+// explainConflict prints the conflicting manager of a management conflict,
+// and how to resolve it.
+func explainConflict(ctx context.Context, w io.Writer, c client.Client, ref v1beta1.ResourceRef, messages []string, manager string) {
+	conflicting, found := liveManager(ctx, c, ref)
+	if !found {
+		for _, msg := range messages {
+			if m := conflictingManagerPattern.FindStringSubmatch(msg); m != nil && m[1] != manager {
+				conflicting, found = m[1], true
+				break
+			}
+		}
+	}
+	if !found || conflicting == "" || conflicting == manager {
+		return
+	}
+	fmt.Fprintf(w, "        Conflicting manager: %s (%s)\n", conflicting, describeManager(conflicting))
+	fmt.Fprintf(w, "        Guidance: remove the object from the source of truth of either this %s or the %s. "+
+		"To move it to this one, annotate it with %s: %q in the source of truth of the %s, and keep it declared in both until the handoff completes.\n",
+		syncKindOfManager(manager), describeManager(conflicting),
+		metadata.ResourceHandoffKey, manager, describeManager(conflicting))
+}
+
+// liveManager returns the manager annotation of the object in the cluster.
+func liveManager(ctx context.Context, c client.Client, ref v1beta1.ResourceRef) (string, bool) {
+	if ref.GVK.Kind == "" || ref.Name == "" {
+		return "", false
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind(ref.GVK))
+	if err := c.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj); err != nil {
+		return "", false
+	}
+	return core.GetAnnotation(obj, metadata.ResourceManagerKey), true
+}
+
+// resourceErrors are the messages of the errors of a code for a resource.
+type resourceErrors struct {
+	// ref is nil for the errors which are not about a specific resource.
+	ref      *v1beta1.ResourceRef
+	messages []string
+}
+
+// errorGroup are the errors of a code.
+type errorGroup struct {
+	code      string
+	count     int
+	resources []*resourceErrors
+}
+
+// groupErrors groups the errors by code and resource, sorted by code and in
+// the order in which the resources are first reported.
+func groupErrors(errs []v1beta1.ConfigSyncError) []*errorGroup {
+	groups := make(map[string]*errorGroup)
+	var codes []string
+	for _, e := range errs {
+		g, found := groups[e.Code]
+		if !found {
+			g = &errorGroup{code: e.Code}
+			groups[e.Code] = g
+			codes = append(codes, e.Code)
+		}
+		g.count++
+		msg := errorMessage(e)
+		if len(e.Resources) == 0 {
+			g.add(nil, msg)
+		}
+		for i := range e.Resources {
+			g.add(&e.Resources[i], msg)
+		}
+	}
+	sort.Strings(codes)
+	result := make([]*errorGroup, len(codes))
+	for i, code := range codes {
+		result[i] = groups[code]
+	}
+	return result
+}
+
+func (g *errorGroup) add(ref *v1beta1.ResourceRef, msg string) {
+	var r *resourceErrors
+	for _, existing := range g.resources {
+		if (existing.ref == nil && ref == nil) ||
+			(existing.ref != nil && ref != nil && *existing.ref == *ref) {
+			r = existing
+			break
+		}
+	}
+	if r == nil {
+		r = &resourceErrors{ref: ref}
+		g.resources = append(g.resources, r)
+	}
+	for _, existing := range r.messages {
+		if existing == msg {
+			return
+		}
+	}
+	r.messages = append(r.messages, msg)
+}
+
+// errorMessage returns the first line of the error message, without the code.
+func errorMessage(e v1beta1.ConfigSyncError) string {
+	msg := strings.TrimSpace(e.ErrorMessage)
+	if i := strings.Index(msg, "\n"); i >= 0 {
+		msg = strings.TrimSpace(msg[:i])
+	}
+	return strings.TrimPrefix(msg, fmt.Sprintf("KNV%s: ", e.Code))
+}
+
+// syncErrors returns the errors reported in the status of the RootSync or
+// RepoSync.
+func syncErrors(obj client.Object) []v1beta1.ConfigSyncError {
+	var errs []v1beta1.ConfigSyncError
+	switch rs := obj.(type) {
+	case *v1beta1.RootSync:
+		for _, c := range rs.Status.Conditions {
+			errs = append(errs, c.Errors...)
+		}
+		errs = append(errs, rs.Status.Source.Errors...)
+		errs = append(errs, rs.Status.Rendering.Errors...)
+		errs = append(errs, rs.Status.Sync.Errors...)
+	case *v1beta1.RepoSync:
+		for _, c := range rs.Status.Conditions {
+			errs = append(errs, c.Errors...)
+		}
+		errs = append(errs, rs.Status.Source.Errors...)
+		errs = append(errs, rs.Status.Rendering.Errors...)
+		errs = append(errs, rs.Status.Sync.Errors...)
+	}
+	return errs
+}
+
+func describeRef(ref v1beta1.ResourceRef) string {
+	gk := schema.GroupKind{Group: ref.GVK.Group, Kind: ref.GVK.Kind}
+	if ref.Namespace == "" {
+		return fmt.Sprintf("%s %s", gk, ref.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", gk, ref.Namespace, ref.Name)
+}
+
+// describeManager returns the kind, namespace and name of the RootSync or
+// RepoSync of a manager annotation.
+func describeManager(manager string) string {
+	scope, name := declared.ManagerScopeAndName(manager)
+	if scope == declared.RootReconciler {
+		return fmt.Sprintf("%s %s/%s", configsync.RootSyncKind, configsync.ControllerNamespace, name)
+	}
+	return fmt.Sprintf("%s %s/%s", configsync.RepoSyncKind, scope, name)
+}
+
+func syncKindOfManager(manager string) string {
+	if declared.IsRootManager(manager) {
+		return configsync.RootSyncKind
+	}
+	return configsync.RepoSyncKind
+}
+
+func syncScope(key client.ObjectKey) declared.Scope {
+	if key.Namespace == configsync.ControllerNamespace {
+		return declared.RootReconciler
+	}
+	return declared.Scope(key.Namespace)
+}
+
+func newSyncObject(key client.ObjectKey) client.Object {
+	if key.Namespace == configsync.ControllerNamespace {
+		return &v1beta1.RootSync{}
+	}
+	return &v1beta1.RepoSync{}
+}
+
+func syncKind(key client.ObjectKey) string {
+	if key.Namespace == configsync.ControllerNamespace {
+		return configsync.RootSyncKind
+	}
+	return configsync.RepoSyncKind
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
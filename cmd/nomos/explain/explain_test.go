@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package explain
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kpt.dev/configsync/cmd/nomoserrors/examples"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCatalogueCoversAllCodes(t *testing.T) {
+	all := examples.Generate()
+	for _, code := range status.CodeRegistry() {
+		if all[code].Deprecated {
+			assert.True(t, deprecatedCodes[code], "KNV%s is deprecated, but not in deprecatedCodes", code)
+			continue
+		}
+		e, found := catalogue[code]
+		if assert.True(t, found, "KNV%s is missing from the catalogue", code) {
+			assert.NotEmpty(t, e.title, "KNV%s has no title", code)
+			assert.NotEmpty(t, e.causes, "KNV%s has no causes", code)
+			assert.NotEmpty(t, e.fixes, "KNV%s has no fixes", code)
+		}
+	}
+}
+
+func TestExplainCode(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, explainCode(&out, "1060"))
+	assert.True(t, strings.HasPrefix(out.String(), "KNV1060: Management conflict\n"), out.String())
+
+	out.Reset()
+	require.NoError(t, explainCode(&out, "1000"))
+	assert.Equal(t, "KNV1000 is no longer reported by Config Sync.\n", out.String())
+
+	assert.EqualError(t, explainCode(&out, "1234"), "unknown error code KNV1234")
+}
+
+func TestExplainSync(t *testing.T) {
+	deployment := fake.DeploymentObject(core.Name("web"), core.Namespace("bookstore"),
+		core.Annotation(metadata.ResourceManagerKey, "bookstore"))
+	deployment.SetGroupVersionKind(kinds.Deployment())
+	conflict := status.ManagementConflictErrorWrap(deployment, ":root").CurrentManagerError().ToCSE()
+	rs := fake.RootSyncObjectV1Beta1(configsync.RootSyncName)
+	rs.Status.Sync.Errors = []v1beta1.ConfigSyncError{
+		conflict,
+		// The same error reported twice is shown once.
+		conflict,
+		status.InternalError("oops").ToCSE(),
+	}
+	c := syncerFake.NewClient(t, core.Scheme, rs, deployment)
+
+	var out bytes.Buffer
+	key := client.ObjectKeyFromObject(rs)
+	require.NoError(t, explainSync(context.Background(), &out, c, key))
+	got := out.String()
+
+	assert.Contains(t, got, "KNV1060: Management conflict (2 errors)")
+	assert.Equal(t, 1, strings.Count(got, "- Deployment.apps bookstore/web"), got)
+	assert.Contains(t, got, "Conflicting manager: bookstore (RepoSync bookstore/repo-sync)")
+	assert.Contains(t, got, `annotate it with configsync.gke.io/handoff-to: ":root" in the source of truth of the RepoSync bookstore/repo-sync`)
+	assert.Contains(t, got, "KNV9998: Internal error (1 error)")
+	assert.Contains(t, got, "- No specific resource")
+	assert.Less(t, strings.Index(got, "KNV1060"), strings.Index(got, "KNV9998"))
+}
+
+func TestExplainSyncWithoutErrors(t *testing.T) {
+	rs := fake.RepoSyncObjectV1Beta1("bookstore", configsync.RepoSyncName)
+	c := syncerFake.NewClient(t, core.Scheme, rs)
+
+	var out bytes.Buffer
+	require.NoError(t, explainSync(context.Background(), &out, c, client.ObjectKeyFromObject(rs)))
+	assert.Equal(t, "  No errors.\n", out.String())
+}
@@ -98,7 +98,8 @@ func AddPath(cmd *cobra.Command) {
 // AddSkipAPIServerCheck adds the --no-api-server-check flag.
 func AddSkipAPIServerCheck(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&SkipAPIServer, SkipAPIServerFlag, false,
-		"If true, disables talking to the API Server for discovery.")
+		"If true, disables talking to the API Server for discovery. "+
+			"Objects are then validated against the schemas bundled into nomos instead of the schemas from the API Server.")
 }
 
 // AllClusters returns true if all clusters should be processed.
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -22,10 +23,13 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/cmd/nomos/bugreport"
+	"kpt.dev/configsync/cmd/nomos/explain"
 	"kpt.dev/configsync/cmd/nomos/hydrate"
 	"kpt.dev/configsync/cmd/nomos/initialize"
 	"kpt.dev/configsync/cmd/nomos/migrate"
 	"kpt.dev/configsync/cmd/nomos/status"
+	"kpt.dev/configsync/cmd/nomos/sync"
+	"kpt.dev/configsync/cmd/nomos/util"
 	"kpt.dev/configsync/cmd/nomos/version"
 	"kpt.dev/configsync/cmd/nomos/vet"
 	"kpt.dev/configsync/pkg/api/configmanagement"
@@ -48,6 +52,8 @@ func init() {
 	rootCmd.AddCommand(status.Cmd)
 	rootCmd.AddCommand(bugreport.Cmd)
 	rootCmd.AddCommand(migrate.Cmd)
+	rootCmd.AddCommand(sync.Cmd)
+	rootCmd.AddCommand(explain.Cmd)
 }
 
 func main() {
@@ -55,6 +61,10 @@ func main() {
 	flag.Parse()
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *util.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -19,6 +19,8 @@ import (
 	"time"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/admissionpolicy"
 	"kpt.dev/configsync/pkg/client/restconfig"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/status"
@@ -75,6 +77,44 @@ func GetSyncedCRDs(ctx context.Context, skipAPIServer bool, apiServerTimeout tim
 	return syncedCRDs, nil
 }
 
+// GetClusterAdmissionPolicies returns the ValidatingAdmissionPolicies, their
+// bindings, the Gatekeeper ConstraintTemplates and constraints, and the
+// Namespaces of the cluster in the current context, to evaluate the policies
+// against the declared objects.
+//
+// Times out after 15 seconds.
+func GetClusterAdmissionPolicies(ctx context.Context, skipAPIServer bool, apiServerTimeout time.Duration) ([]*unstructured.Unstructured, status.MultiError) {
+	if skipAPIServer {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	config, err := restconfig.NewRestConfig(apiServerTimeout)
+	if err != nil {
+		return nil, getSyncedCRDsError(err, "failed to create rest config")
+	}
+
+	mapper, err := apiutil.NewDynamicRESTMapper(config)
+	if err != nil {
+		return nil, getSyncedCRDsError(err, "failed to create mapper")
+	}
+
+	c, err := client.New(config, client.Options{
+		Scheme: core.Scheme,
+		Mapper: mapper,
+	})
+	if err != nil {
+		return nil, getSyncedCRDsError(err, "failed to create client")
+	}
+	objs, err := admissionpolicy.ClusterObjects(ctx, c)
+	if err != nil {
+		return nil, getSyncedCRDsError(err, "failed to list the admission policies")
+	}
+	return objs, nil
+}
+
 func getSyncedCRDsError(err error, message string) status.Error {
 	return status.APIServerError(err, message+". Did you mean to run with --no-api-server-check?")
 }
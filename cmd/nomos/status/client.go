@@ -46,6 +46,7 @@ import (
 	"kpt.dev/configsync/pkg/client/restconfig"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/resourcegroup"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
@@ -132,7 +133,7 @@ func (c *ClusterClient) resourceGroups(ctx context.Context, ns string, nsAndName
 		localRG := rg
 		resourceGroups = append(resourceGroups, &localRG)
 	}
-	return consistentOrder(nsAndNames, resourceGroups), nil
+	return mergeShards(consistentOrder(nsAndNames, resourceGroups), resourceGroups)
 }
 
 // clusterStatus returns the ClusterState for the cluster this client is connected to.
@@ -601,6 +602,33 @@ func consistentOrder(nsAndNames []types.NamespacedName, resourcegroups []*unstru
 	return rgs
 }
 
+// mergeShards merges the other shards of the sharded inventories into their
+// first ResourceGroup, so that the status of all the resources is reported.
+func mergeShards(rgs, resourcegroups []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	byName := map[types.NamespacedName]*unstructured.Unstructured{}
+	for _, r := range resourcegroups {
+		byName[types.NamespacedName{Namespace: r.GetNamespace(), Name: r.GetName()}] = r
+	}
+	for i, rg := range rgs {
+		if rg == nil || resourcegroup.ShardCount(rg) == 1 {
+			continue
+		}
+		var shards []*unstructured.Unstructured
+		for j := 1; j < resourcegroup.ShardCount(rg); j++ {
+			nn := types.NamespacedName{Namespace: rg.GetNamespace(), Name: resourcegroup.ShardName(rg.GetName(), j)}
+			if shard, found := byName[nn]; found {
+				shards = append(shards, shard)
+			}
+		}
+		merged, err := resourcegroup.MergeShards(rg, shards)
+		if err != nil {
+			return nil, err
+		}
+		rgs[i] = merged
+	}
+	return rgs, nil
+}
+
 func rgNotFoundErrMsg(name, ns string) string {
 	return fmt.Sprintf("resourcegroups.kpt.dev %q not found in namespace %q", name, ns)
 }
@@ -129,6 +129,9 @@ func (r *RepoState) printRows(writer io.Writer) {
 					fmt.Fprintf(writer, "%s%s%s%s%s\n", util.Indent, util.Indent, util.Indent, util.Indent, condition.Message)
 				}
 			}
+			for _, field := range r.ForeignFields {
+				fmt.Fprintf(writer, "%s%s%s%s%s\n", util.Indent, util.Indent, util.Indent, util.Indent, field)
+			}
 		}
 	}
 }
@@ -26,6 +26,7 @@ import (
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/conflictpolicy"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -90,6 +91,30 @@ func TestRepoState_PrintRows(t *testing.T) {
 			},
 			"  <root>:root-sync\thttps://github.com/tester/sample@master\t\n  SYNCED @ 0001-01-01 00:00:00 +0000 UTC\tabc123\t\n  Managed resources:\n  \tNAMESPACE\tNAME\tSTATUS\tSOURCEHASH\n  \tbookstore\tdeployment.apps/test\tCurrent\tabc123\n  \tbookstore\tservice/test\tFailed\tabc123\n        A detailed message explaining the current condition.\n  \tbookstore\tservice/test2\tConflict\tabc123\n        A detailed message explaining why it is in the status ownership overlap.\n",
 		},
+		{
+			"fields owned by other field managers",
+			&RepoState{
+				scope:    "<root>",
+				syncName: "root-sync",
+				git: &v1beta1.Git{
+					Repo: "https://github.com/tester/sample/",
+				},
+				status: "SYNCED",
+				commit: "abc123",
+				resources: []resourceState{{
+					Group:      "apps",
+					Kind:       "Deployment",
+					Namespace:  "bookstore",
+					Name:       "test",
+					Status:     "Current",
+					SourceHash: "abc123",
+					ForeignFields: []conflictpolicy.Conflict{
+						{Field: ".spec.replicas", Manager: "kube-controller-manager"},
+					},
+				}},
+			},
+			"  <root>:root-sync\thttps://github.com/tester/sample@master\t\n  SYNCED @ 0001-01-01 00:00:00 +0000 UTC\tabc123\t\n  Managed resources:\n  \tNAMESPACE\tNAME\tSTATUS\tSOURCEHASH\n  \tbookstore\tdeployment.apps/test\tCurrent\tabc123\n        .spec.replicas is owned by \"kube-controller-manager\"\n",
+		},
 		{
 			"optional git subdirectory specified",
 			&RepoState{
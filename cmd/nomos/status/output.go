@@ -0,0 +1,353 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// OutputSchemaVersion is the version of the structured `nomos status` output.
+// Fields may be added within a version, but never removed or renamed.
+const OutputSchemaVersion = "v1"
+
+// Exit codes of `nomos status` with a structured output format.
+const (
+	// ExitCodeSynced indicates all the clusters are reachable and all the
+	// RootSyncs and RepoSyncs are synced.
+	ExitCodeSynced = 0
+	// ExitCodeError indicates a cluster is unreachable or has an error, or a
+	// RootSync or RepoSync has errors or is stalled.
+	ExitCodeError = 2
+	// ExitCodePending indicates no errors, but a RootSync or RepoSync is still
+	// pending or reconciling.
+	ExitCodePending = 3
+)
+
+// Output is the structured output of `nomos status`.
+type Output struct {
+	// SchemaVersion is the version of the output schema.
+	SchemaVersion string `json:"schemaVersion"`
+	// Clusters is the status of each cluster, sorted by name.
+	Clusters []ClusterOutput `json:"clusters"`
+}
+
+// ClusterOutput is the sync status of all the repos on a cluster.
+type ClusterOutput struct {
+	// Name is the name of the cluster context.
+	Name string `json:"name"`
+	// Current is true for the current cluster context.
+	Current bool `json:"current,omitempty"`
+	// MultiRepo is false for clusters running in the legacy mono-repo mode.
+	MultiRepo *bool `json:"multiRepo,omitempty"`
+	// Status is set if Config Sync is not running properly on the cluster.
+	Status string `json:"status,omitempty"`
+	// Error is set if the status of the cluster could not be computed.
+	Error string `json:"error,omitempty"`
+	// Syncs is the status of each RootSync and RepoSync on the cluster.
+	Syncs []SyncOutput `json:"syncs,omitempty"`
+}
+
+// SyncOutput is the sync status of a single RootSync or RepoSync.
+type SyncOutput struct {
+	// Scope is "<root>" for RootSyncs, or the namespace of RepoSyncs.
+	Scope string `json:"scope"`
+	// Name is the name of the RootSync or RepoSync. It is empty for clusters
+	// running in the legacy mono-repo mode.
+	Name string `json:"name,omitempty"`
+	// SourceType is git, oci or helm.
+	SourceType string `json:"sourceType,omitempty"`
+	// Source is the source repository, with the directory and revision.
+	Source string `json:"source"`
+	// Status is SYNCED, PENDING, RECONCILING, STALLED or ERROR.
+	Status string `json:"status"`
+	// Commit is the source commit being synced.
+	Commit string `json:"commit"`
+	// LastSyncTimestamp is the time of the last successful sync.
+	LastSyncTimestamp *metav1.Time `json:"lastSyncTimestamp,omitempty"`
+	// ErrorSummary summarizes the errors.
+	ErrorSummary *v1beta1.ErrorSummary `json:"errorSummary,omitempty"`
+	// Errors are the error messages, possibly truncated.
+	Errors []string `json:"errors,omitempty"`
+	// Resources is the status of each managed resource, if available.
+	Resources []resourceState `json:"resources,omitempty"`
+}
+
+// WatchEventType is the type of change of a WatchEvent.
+type WatchEventType string
+
+const (
+	// WatchEventAdded indicates a cluster or sync was found.
+	WatchEventAdded WatchEventType = "ADDED"
+	// WatchEventModified indicates the status of a cluster or sync changed.
+	WatchEventModified WatchEventType = "MODIFIED"
+	// WatchEventDeleted indicates a cluster or sync is no longer found.
+	WatchEventDeleted WatchEventType = "DELETED"
+)
+
+// WatchEvent is a change of the status of a cluster or a sync, emitted as a
+// line of JSON by `nomos status --watch`.
+type WatchEvent struct {
+	// SchemaVersion is the version of the output schema.
+	SchemaVersion string `json:"schemaVersion"`
+	// Type is the type of change.
+	Type WatchEventType `json:"type"`
+	// Time is when the change was observed.
+	Time metav1.Time `json:"time"`
+	// Cluster is the name of the cluster context.
+	Cluster string `json:"cluster"`
+	// ClusterStatus is the status of the cluster, without the syncs. It is
+	// only set for cluster events.
+	ClusterStatus *ClusterOutput `json:"clusterStatus,omitempty"`
+	// Sync is the status of the sync. It is only set for sync events.
+	Sync *SyncOutput `json:"sync,omitempty"`
+}
+
+// validateOutputFlags validates the --format and --watch flags.
+func validateOutputFlags() error {
+	switch outputFormat {
+	case "", flags.OutputJSON, flags.OutputYAML:
+	default:
+		return fmt.Errorf("invalid --format %q: must be %s or %s", outputFormat, flags.OutputJSON, flags.OutputYAML)
+	}
+	if watch && outputFormat == flags.OutputYAML {
+		return fmt.Errorf("--watch emits newline-delimited JSON and does not support --format %s", flags.OutputYAML)
+	}
+	if !watch && outputFormat != "" && pollingInterval > 0 {
+		return fmt.Errorf("--poll is not supported with --format: use --watch to poll for changes")
+	}
+	return nil
+}
+
+// buildOutput converts the cluster states into the structured output.
+func buildOutput(stateMap map[string]*ClusterState, names []string, currentContext string) Output {
+	output := Output{
+		SchemaVersion: OutputSchemaVersion,
+		Clusters:      []ClusterOutput{},
+	}
+	for _, name := range names {
+		state := stateMap[name]
+		if state == nil {
+			continue
+		}
+		output.Clusters = append(output.Clusters, state.toOutput(name, name == currentContext))
+	}
+	return output
+}
+
+func (c *ClusterState) toOutput(clusterName string, current bool) ClusterOutput {
+	out := ClusterOutput{
+		Name:      clusterName,
+		Current:   current,
+		MultiRepo: c.isMulti,
+		Status:    c.status,
+		Error:     c.Error,
+	}
+	for _, repo := range c.repos {
+		if name == "" || name == repo.syncName {
+			out.Syncs = append(out.Syncs, repo.toOutput())
+		}
+	}
+	return out
+}
+
+func (r *RepoState) toOutput() SyncOutput {
+	out := SyncOutput{
+		Scope:        r.scope,
+		Name:         r.syncName,
+		SourceType:   string(r.sourceType),
+		Source:       sourceString(r.sourceType, r.git, r.oci, r.helm),
+		Status:       r.status,
+		Commit:       r.commit,
+		ErrorSummary: r.errorSummary,
+		Errors:       r.errors,
+	}
+	if !r.lastSyncTimestamp.IsZero() {
+		out.LastSyncTimestamp = r.lastSyncTimestamp.DeepCopy()
+	}
+	if resourceStatus && len(r.resources) > 0 {
+		out.Resources = append([]resourceState(nil), r.resources...)
+		sort.Sort(byNamespaceAndType(out.Resources))
+	}
+	return out
+}
+
+// exitCode returns the exit code reflecting the health of all the clusters
+// and syncs.
+func (o Output) exitCode() int {
+	code := ExitCodeSynced
+	for _, cluster := range o.Clusters {
+		if cluster.Status != "" || cluster.Error != "" {
+			return ExitCodeError
+		}
+		for _, sync := range cluster.Syncs {
+			switch sync.Status {
+			case syncedMsg:
+			case pendingMsg, reconcilingMsg:
+				code = ExitCodePending
+			default:
+				return ExitCodeError
+			}
+		}
+	}
+	return code
+}
+
+// exitError returns an error with the exit code of the output, or nil if all
+// the syncs are synced.
+func (o Output) exitError() error {
+	switch code := o.exitCode(); code {
+	case ExitCodeSynced:
+		return nil
+	case ExitCodePending:
+		return &util.ExitError{Code: code, Err: fmt.Errorf("not all RootSyncs and RepoSyncs are synced")}
+	default:
+		return &util.ExitError{Code: code, Err: fmt.Errorf("errors found on clusters, RootSyncs or RepoSyncs")}
+	}
+}
+
+// writeOutput writes the output in the specified format: json or yaml.
+func writeOutput(w io.Writer, format string, output Output) error {
+	if format == flags.OutputYAML {
+		data, err := yaml.Marshal(output)
+		if err != nil {
+			return fmt.Errorf("failed to encode the status: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode the status: %w", err)
+	}
+	return nil
+}
+
+// watchEvents returns the events for the changes between the previous and
+// the current output, in a stable order.
+func watchEvents(prev, cur Output, now metav1.Time) []WatchEvent {
+	var events []WatchEvent
+	newEvent := func(eventType WatchEventType, cluster string) WatchEvent {
+		return WatchEvent{
+			SchemaVersion: OutputSchemaVersion,
+			Type:          eventType,
+			Time:          now,
+			Cluster:       cluster,
+		}
+	}
+
+	prevClusters := make(map[string]ClusterOutput, len(prev.Clusters))
+	for _, c := range prev.Clusters {
+		prevClusters[c.Name] = c
+	}
+	for _, c := range cur.Clusters {
+		prevCluster, found := prevClusters[c.Name]
+		delete(prevClusters, c.Name)
+		clusterStatus := c.withoutSyncs()
+		switch {
+		case !found:
+			e := newEvent(WatchEventAdded, c.Name)
+			e.ClusterStatus = &clusterStatus
+			events = append(events, e)
+		case !reflect.DeepEqual(prevCluster.withoutSyncs(), clusterStatus):
+			e := newEvent(WatchEventModified, c.Name)
+			e.ClusterStatus = &clusterStatus
+			events = append(events, e)
+		}
+
+		prevSyncs := make(map[string]SyncOutput, len(prevCluster.Syncs))
+		for _, s := range prevCluster.Syncs {
+			prevSyncs[s.key()] = s
+		}
+		for i := range c.Syncs {
+			s := c.Syncs[i]
+			prevSync, found := prevSyncs[s.key()]
+			delete(prevSyncs, s.key())
+			switch {
+			case !found:
+				e := newEvent(WatchEventAdded, c.Name)
+				e.Sync = &s
+				events = append(events, e)
+			case !reflect.DeepEqual(prevSync, s):
+				e := newEvent(WatchEventModified, c.Name)
+				e.Sync = &s
+				events = append(events, e)
+			}
+		}
+		events = append(events, deletedSyncEvents(prevSyncs, newEvent(WatchEventDeleted, c.Name))...)
+	}
+
+	var deletedClusters []string
+	for clusterName := range prevClusters {
+		deletedClusters = append(deletedClusters, clusterName)
+	}
+	sort.Strings(deletedClusters)
+	for _, clusterName := range deletedClusters {
+		clusterStatus := prevClusters[clusterName].withoutSyncs()
+		e := newEvent(WatchEventDeleted, clusterName)
+		e.ClusterStatus = &clusterStatus
+		events = append(events, e)
+	}
+	return events
+}
+
+// deletedSyncEvents returns the DELETED events for the syncs, sorted by key.
+func deletedSyncEvents(syncs map[string]SyncOutput, template WatchEvent) []WatchEvent {
+	var keys []string
+	for key := range syncs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var events []WatchEvent
+	for _, key := range keys {
+		s := syncs[key]
+		e := template
+		e.Sync = &s
+		events = append(events, e)
+	}
+	return events
+}
+
+// writeWatchEvents writes the events as newline-delimited JSON.
+func writeWatchEvents(w io.Writer, events []WatchEvent) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			return fmt.Errorf("failed to encode the status event: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c ClusterOutput) withoutSyncs() ClusterOutput {
+	c.Syncs = nil
+	return c
+}
+
+func (s SyncOutput) key() string {
+	return s.Scope + "/" + s.Name
+}
@@ -0,0 +1,294 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+func TestBuildOutput(t *testing.T) {
+	name = ""
+	resourceStatus = true
+	isMulti := true
+	stateMap := map[string]*ClusterState{
+		"cluster-a": {
+			isMulti: &isMulti,
+			repos: []*RepoState{
+				{
+					scope:      "<root>",
+					syncName:   "root-sync",
+					sourceType: v1beta1.GitSource,
+					git:        git,
+					status:     syncedMsg,
+					commit:     "abc123",
+					resources: []resourceState{
+						{Namespace: "foo", Name: "b", Kind: "ConfigMap", Status: "Current"},
+						{Namespace: "bar", Name: "a", Kind: "ConfigMap", Status: "Current"},
+					},
+				},
+				{
+					scope:        "bookstore",
+					syncName:     "repo-sync",
+					sourceType:   v1beta1.OciSource,
+					oci:          oci,
+					status:       util.ErrorMsg,
+					commit:       "def456",
+					errors:       []string{"KNV1021: No CustomResourceDefinition is defined"},
+					errorSummary: errorSummayWithOneError,
+				},
+			},
+		},
+		"cluster-b": unavailableCluster("cluster-b"),
+	}
+
+	want := Output{
+		SchemaVersion: OutputSchemaVersion,
+		Clusters: []ClusterOutput{
+			{
+				Name:      "cluster-a",
+				Current:   true,
+				MultiRepo: &isMulti,
+				Syncs: []SyncOutput{
+					{
+						Scope:      "<root>",
+						Name:       "root-sync",
+						SourceType: "git",
+						Source:     "git@github.com:tester/sample/admin@v1",
+						Status:     syncedMsg,
+						Commit:     "abc123",
+						Resources: []resourceState{
+							{Namespace: "bar", Name: "a", Kind: "ConfigMap", Status: "Current"},
+							{Namespace: "foo", Name: "b", Kind: "ConfigMap", Status: "Current"},
+						},
+					},
+					{
+						Scope:        "bookstore",
+						Name:         "repo-sync",
+						SourceType:   "oci",
+						Source:       "us-docker.pkg.dev/test-project/test-ar-repo/sample/test",
+						Status:       util.ErrorMsg,
+						Commit:       "def456",
+						Errors:       []string{"KNV1021: No CustomResourceDefinition is defined"},
+						ErrorSummary: errorSummayWithOneError,
+					},
+				},
+			},
+			{
+				Name:   "cluster-b",
+				Status: "N/A",
+				Error:  "Failed to connect to cluster",
+			},
+		},
+	}
+
+	got := buildOutput(stateMap, []string{"cluster-a", "cluster-b"}, "cluster-a")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("buildOutput() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestOutputExitCode(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output Output
+		want   int
+	}{
+		{
+			name:   "no clusters",
+			output: Output{},
+			want:   ExitCodeSynced,
+		},
+		{
+			name: "all synced",
+			output: Output{Clusters: []ClusterOutput{
+				{Name: "a", Syncs: []SyncOutput{{Status: syncedMsg}, {Status: syncedMsg}}},
+			}},
+			want: ExitCodeSynced,
+		},
+		{
+			name: "pending",
+			output: Output{Clusters: []ClusterOutput{
+				{Name: "a", Syncs: []SyncOutput{{Status: syncedMsg}, {Status: pendingMsg}}},
+				{Name: "b", Syncs: []SyncOutput{{Status: reconcilingMsg}}},
+			}},
+			want: ExitCodePending,
+		},
+		{
+			name: "sync error wins over pending",
+			output: Output{Clusters: []ClusterOutput{
+				{Name: "a", Syncs: []SyncOutput{{Status: pendingMsg}}},
+				{Name: "b", Syncs: []SyncOutput{{Status: stalledMsg}}},
+			}},
+			want: ExitCodeError,
+		},
+		{
+			name: "unavailable cluster",
+			output: Output{Clusters: []ClusterOutput{
+				{Name: "a", Status: "N/A", Error: "Failed to connect to cluster"},
+			}},
+			want: ExitCodeError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.output.exitCode(); got != tc.want {
+				t.Errorf("exitCode() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	output := Output{
+		SchemaVersion: OutputSchemaVersion,
+		Clusters: []ClusterOutput{
+			{
+				Name: "cluster-a",
+				Syncs: []SyncOutput{
+					{Scope: "<root>", Name: "root-sync", Source: "git@github.com:tester/sample@main", Status: syncedMsg, Commit: "abc123"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: flags.OutputJSON,
+			want: `{
+  "schemaVersion": "v1",
+  "clusters": [
+    {
+      "name": "cluster-a",
+      "syncs": [
+        {
+          "scope": "<root>",
+          "name": "root-sync",
+          "source": "git@github.com:tester/sample@main",
+          "status": "SYNCED",
+          "commit": "abc123"
+        }
+      ]
+    }
+  ]
+}
+`,
+		},
+		{
+			format: flags.OutputYAML,
+			want: `clusters:
+- name: cluster-a
+  syncs:
+  - commit: abc123
+    name: root-sync
+    scope: <root>
+    source: git@github.com:tester/sample@main
+    status: SYNCED
+schemaVersion: v1
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeOutput(&buf, tc.format, output); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("writeOutput() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	now := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rootSync := SyncOutput{Scope: "<root>", Name: "root-sync", Status: pendingMsg, Commit: "abc123"}
+	rootSyncSynced := SyncOutput{Scope: "<root>", Name: "root-sync", Status: syncedMsg, Commit: "abc123"}
+	repoSync := SyncOutput{Scope: "bookstore", Name: "repo-sync", Status: syncedMsg, Commit: "def456"}
+
+	first := Output{Clusters: []ClusterOutput{
+		{Name: "cluster-a", Syncs: []SyncOutput{rootSync, repoSync}},
+		{Name: "cluster-b", Status: "N/A", Error: "Failed to connect to cluster"},
+	}}
+	second := Output{Clusters: []ClusterOutput{
+		{Name: "cluster-a", Syncs: []SyncOutput{rootSyncSynced}},
+	}}
+
+	event := func(eventType WatchEventType, cluster string, clusterStatus *ClusterOutput, sync *SyncOutput) WatchEvent {
+		return WatchEvent{
+			SchemaVersion: OutputSchemaVersion,
+			Type:          eventType,
+			Time:          now,
+			Cluster:       cluster,
+			ClusterStatus: clusterStatus,
+			Sync:          sync,
+		}
+	}
+
+	testCases := []struct {
+		name string
+		prev Output
+		cur  Output
+		want []WatchEvent
+	}{
+		{
+			name: "initial status",
+			cur:  first,
+			want: []WatchEvent{
+				event(WatchEventAdded, "cluster-a", &ClusterOutput{Name: "cluster-a"}, nil),
+				event(WatchEventAdded, "cluster-a", nil, &rootSync),
+				event(WatchEventAdded, "cluster-a", nil, &repoSync),
+				event(WatchEventAdded, "cluster-b", &ClusterOutput{Name: "cluster-b", Status: "N/A", Error: "Failed to connect to cluster"}, nil),
+			},
+		},
+		{
+			name: "no changes",
+			prev: first,
+			cur:  first,
+		},
+		{
+			name: "modified and deleted",
+			prev: first,
+			cur:  second,
+			want: []WatchEvent{
+				event(WatchEventModified, "cluster-a", nil, &rootSyncSynced),
+				event(WatchEventDeleted, "cluster-a", nil, &repoSync),
+				event(WatchEventDeleted, "cluster-b", &ClusterOutput{Name: "cluster-b", Status: "N/A", Error: "Failed to connect to cluster"}, nil),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := watchEvents(tc.prev, tc.cur, now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("watchEvents() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
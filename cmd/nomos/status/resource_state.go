@@ -20,6 +20,11 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/conflictpolicy"
+	"kpt.dev/configsync/pkg/core"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 )
 
@@ -31,6 +36,9 @@ type resourceState struct {
 	Status     string      `json:"status"`
 	SourceHash string      `json:"sourceHash,omitempty"`
 	Conditions []Condition `json:"conditions,omitempty"`
+	// ForeignFields are the declared fields left to other field managers by
+	// the conflict policy.
+	ForeignFields []conflictpolicy.Conflict `json:"foreignFields,omitempty"`
 }
 
 // Condition is the for the resource status condition
@@ -104,7 +112,30 @@ func resourceLevelStatus(rg *unstructured.Unstructured) ([]resourceState, error)
 	if err := yaml.Unmarshal(data, &states); err != nil {
 		return nil, err
 	}
-	return checkConflict(states), nil
+	return checkConflict(withForeignFields(states, rg)), nil
+}
+
+// withForeignFields adds the fields owned by other field managers, recorded in
+// the object sync status of the ResourceGroup, to the resource states.
+func withForeignFields(states []resourceState, rg *unstructured.Unstructured) []resourceState {
+	statuses, err := applier.ObjectSyncStatuses(rg)
+	if err != nil || len(statuses) == 0 {
+		return states
+	}
+	foreignFields := make(map[core.ID][]conflictpolicy.Conflict, len(statuses))
+	for _, s := range statuses {
+		if len(s.ForeignFields) > 0 {
+			foreignFields[s.ID()] = s.ForeignFields
+		}
+	}
+	for i, s := range states {
+		id := core.ID{
+			GroupKind: schema.GroupKind{Group: s.Group, Kind: s.Kind},
+			ObjectKey: client.ObjectKey{Namespace: s.Namespace, Name: s.Name},
+		}
+		states[i].ForeignFields = foreignFields[id]
+	}
+	return states
 }
 
 func checkConflict(states []resourceState) []resourceState {
@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/conflictpolicy"
 )
 
 func TestResourceState(t *testing.T) {
@@ -84,3 +86,67 @@ func TestResourceState(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestResourceLevelStatus_ForeignFields(t *testing.T) {
+	rg := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"resourceStatuses": []interface{}{
+				map[string]interface{}{
+					"group":     "apps",
+					"kind":      "Deployment",
+					"namespace": "bookstore",
+					"name":      "test",
+					"status":    "Current",
+				},
+				map[string]interface{}{
+					"kind":      "Service",
+					"namespace": "bookstore",
+					"name":      "test",
+					"status":    "Current",
+				},
+			},
+			"objectSyncStatuses": []interface{}{
+				map[string]interface{}{
+					"group":     "apps",
+					"kind":      "Deployment",
+					"namespace": "bookstore",
+					"name":      "test",
+					"foreignFields": []interface{}{
+						map[string]interface{}{"field": ".spec.replicas", "manager": "kube-controller-manager"},
+					},
+				},
+				map[string]interface{}{
+					"group":     "",
+					"kind":      "Service",
+					"namespace": "bookstore",
+					"name":      "test",
+				},
+			},
+		},
+	}}
+	got, err := resourceLevelStatus(rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []resourceState{
+		{
+			Group:     "apps",
+			Kind:      "Deployment",
+			Namespace: "bookstore",
+			Name:      "test",
+			Status:    "Current",
+			ForeignFields: []conflictpolicy.Conflict{
+				{Field: ".spec.replicas", Manager: "kube-controller-manager"},
+			},
+		},
+		{
+			Kind:      "Service",
+			Namespace: "bookstore",
+			Name:      "test",
+			Status:    "Current",
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/cmd/nomos/flags"
 	"kpt.dev/configsync/cmd/nomos/util"
@@ -39,6 +40,10 @@ const (
 	syncedMsg      = "SYNCED"
 	stalledMsg     = "STALLED"
 	reconcilingMsg = "RECONCILING"
+
+	// defaultWatchInterval is the polling interval of --watch, if --poll is
+	// unset.
+	defaultWatchInterval = 5 * time.Second
 )
 
 var (
@@ -46,6 +51,8 @@ var (
 	namespace       string
 	resourceStatus  bool
 	name            string
+	outputFormat    string
+	watch           bool
 )
 
 func init() {
@@ -55,6 +62,11 @@ func init() {
 	Cmd.Flags().StringVar(&namespace, "namespace", "", "Namespace repo to get status for (multi-repo only, leave unset to get all repos)")
 	Cmd.Flags().BoolVar(&resourceStatus, "resources", true, "show resource level status for Namespace repo (multi-repo only)")
 	Cmd.Flags().StringVar(&name, "name", "", "name to filter root and repo sync name)")
+	Cmd.Flags().StringVar(&outputFormat, "format", "",
+		fmt.Sprintf("Output format. Accepts '%s' and '%s' (leave unset for text). With a structured format, the exit code is %d if everything is synced, %d if anything is still pending, and %d on errors",
+			flags.OutputJSON, flags.OutputYAML, ExitCodeSynced, ExitCodePending, ExitCodeError))
+	Cmd.Flags().BoolVar(&watch, "watch", false,
+		fmt.Sprintf("Emit a newline-delimited JSON event for each change of a cluster, RootSync or RepoSync status, polling at the --poll interval (defaults to %s)", defaultWatchInterval))
 }
 
 // SaveToTempFile writes the `nomos status` output into a temporary file, and
@@ -96,10 +108,15 @@ var Cmd = &cobra.Command{
 	// TODO: make Configuration Management a constant (for product renaming)
 	Short: `Prints the status of all clusters with Configuration Management installed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFlags(); err != nil {
+			return err
+		}
 		// Don't show usage on error, as argument validation passed.
 		cmd.SilenceUsage = true
 
-		fmt.Println("Connecting to clusters...")
+		if outputFormat == "" && !watch {
+			fmt.Println("Connecting to clusters...")
+		}
 
 		clientMap, err := ClusterClients(cmd.Context(), flags.Contexts)
 		if err != nil {
@@ -117,6 +134,17 @@ var Cmd = &cobra.Command{
 		// Use a sorted order of names to avoid shuffling in the output.
 		names := clusterNames(clientMap)
 
+		if watch {
+			return watchStatus(cmd.Context(), os.Stdout, clientMap, names)
+		}
+		if outputFormat != "" {
+			output := structuredStatus(cmd.Context(), clientMap, names)
+			if err := writeOutput(os.Stdout, outputFormat, output); err != nil {
+				return err
+			}
+			return output.exitError()
+		}
+
 		writer := util.NewWriter(os.Stdout)
 		if pollingInterval > 0 {
 			for {
@@ -196,6 +224,39 @@ func printStatus(ctx context.Context, writer *tabwriter.Writer, clientMap map[st
 	writer.Flush()
 }
 
+// structuredStatus fetches the status from each cluster in the given map and
+// converts it into the structured output.
+func structuredStatus(ctx context.Context, clientMap map[string]*ClusterClient, names []string) Output {
+	stateMap, _ := clusterStates(ctx, clientMap)
+	currentContext, err := restconfig.CurrentContextName()
+	if err != nil {
+		klog.Warningf("Failed to get current context name with err: %v", errors.Cause(err))
+	}
+	return buildOutput(stateMap, names, currentContext)
+}
+
+// watchStatus polls the status from each cluster in the given map, and writes
+// an event for each change, until the context is cancelled.
+func watchStatus(ctx context.Context, out io.Writer, clientMap map[string]*ClusterClient, names []string) error {
+	interval := pollingInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	var prev Output
+	for {
+		cur := structuredStatus(ctx, clientMap, names)
+		if err := writeWatchEvents(out, watchEvents(prev, cur, metav1.Now())); err != nil {
+			return err
+		}
+		prev = cur
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
 // clearTerminal executes an OS-specific command to clear all output on the terminal.
 func clearTerminal(out io.Writer) {
 	var cmd *exec.Cmd
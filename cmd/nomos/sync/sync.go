@@ -0,0 +1,272 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/cmd/nomos/status"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/client/restconfig"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/reapply"
+	"kpt.dev/configsync/pkg/reposync"
+	"kpt.dev/configsync/pkg/rootsync"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	actionTrigger = "trigger"
+	actionPause   = "pause"
+	actionResume  = "resume"
+	actionReapply = "reapply"
+
+	defaultWaitTimeout = 5 * time.Minute
+	waitInterval       = 2 * time.Second
+)
+
+var (
+	name        string
+	namespace   string
+	waitTimeout time.Duration
+	// selector selects the managed objects to reapply.
+	selector reapply.Request
+)
+
+// Cmd groups the commands that control the sync of a RootSync or RepoSync.
+var Cmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Controls the sync of a RootSync or RepoSync on all clusters.",
+	Long: `Controls the sync of a RootSync or RepoSync on all clusters.
+
+The RootSync or RepoSync is selected with --name and --namespace. Each command
+waits until the reconciler acknowledges the request in the status, or until
+--wait-timeout (use 0 to skip waiting).`,
+}
+
+func init() {
+	reapplyCmd := newCommand(actionReapply, "Reapplies the selected managed resources, taking over their ownership and clearing their errors.")
+	reapplyCmd.Flags().StringVar(&selector.Group, "group", "", "Group of the managed resources to reapply (used with --kind)")
+	reapplyCmd.Flags().StringVar(&selector.Kind, "kind", "", "Kind of the managed resources to reapply")
+	reapplyCmd.Flags().StringVar(&selector.Namespace, "object-namespace", "", "Namespace of the managed resources to reapply")
+	reapplyCmd.Flags().StringVar(&selector.Name, "object-name", "", "Name of the managed resource to reapply (used with --kind)")
+
+	for _, cmd := range []*cobra.Command{
+		newCommand(actionTrigger, "Triggers an immediate resync of all the managed resources from the source of truth."),
+		newCommand(actionPause, "Pauses applying and remediating the managed resources."),
+		newCommand(actionResume, "Resumes applying and remediating the managed resources, and resyncs them."),
+		reapplyCmd,
+	} {
+		flags.AddContexts(cmd)
+		cmd.Flags().DurationVar(&flags.ClientTimeout, "timeout", restconfig.DefaultTimeout, "Timeout for connecting to each cluster")
+		cmd.Flags().StringVar(&name, "name", configsync.RootSyncName, "Name of the RootSync or RepoSync")
+		cmd.Flags().StringVar(&namespace, "namespace", configsync.ControllerNamespace,
+			fmt.Sprintf("Namespace of the RepoSync (leave unset for a RootSync in %s)", configsync.ControllerNamespace))
+		cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", defaultWaitTimeout, "Timeout for waiting for the reconciler to acknowledge the request")
+		Cmd.AddCommand(cmd)
+	}
+}
+
+func newCommand(action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action,
+		Short: short,
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Don't show usage on error, as argument validation passed.
+			cmd.SilenceUsage = true
+
+			clientMap, err := status.ClusterClients(cmd.Context(), flags.Contexts)
+			if err != nil {
+				return err
+			}
+			if len(clientMap) == 0 {
+				return errors.New("no clusters found")
+			}
+			// Use a sorted order of names to avoid shuffling in the output.
+			var names []string
+			for cluster := range clientMap {
+				names = append(names, cluster)
+			}
+			sort.Strings(names)
+
+			key := client.ObjectKey{Namespace: namespace, Name: name}
+			failed := 0
+			for _, cluster := range names {
+				c := clientMap[cluster]
+				if c == nil {
+					printError(cluster, key, errors.New("failed to connect to cluster"))
+					failed++
+					continue
+				}
+				if err := run(cmd.Context(), c.Client, key, action); err != nil {
+					printError(cluster, key, err)
+					failed++
+					continue
+				}
+				fmt.Printf("%s%s: %s %s\n", util.Bullet, cluster, syncKind(key), acknowledgedMessage(action, key))
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to %s the sync on %d of %d clusters", action, failed, len(names))
+			}
+			return nil
+		},
+	}
+}
+
+func printError(cluster string, key client.ObjectKey, err error) {
+	fmt.Fprintf(os.Stderr, "%s%s: %s %s: %sError: %v%s\n", util.Bullet, cluster, syncKind(key), key, util.ColorRed, err, util.ColorDefault)
+}
+
+// run requests the action on the RootSync or RepoSync, and waits for the
+// reconciler to acknowledge it.
+func run(ctx context.Context, c client.Client, key client.ObjectKey, action string) error {
+	token, err := request(ctx, c, key, action, time.Now())
+	if err != nil {
+		return err
+	}
+	if waitTimeout <= 0 {
+		return nil
+	}
+	obj := newSyncObject(key)
+	err = wait.PollImmediateWithContext(ctx, waitInterval, waitTimeout, func(ctx context.Context) (bool, error) {
+		if err := c.Get(ctx, key, obj); err != nil {
+			return false, err
+		}
+		return acknowledged(obj, action, token), nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("timed out after %s waiting for the reconciler to acknowledge the %s request", waitTimeout, action)
+	}
+	return err
+}
+
+// request sets the sync control annotations of the RootSync or RepoSync for
+// the action. Returns the token of the request, if the action is a trigger or
+// a reapply.
+func request(ctx context.Context, c client.Client, key client.ObjectKey, action string, now time.Time) (string, error) {
+	obj := newSyncObject(key)
+	if err := c.Get(ctx, key, obj); err != nil {
+		return "", err
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	var token string
+	switch action {
+	case actionTrigger:
+		if core.GetAnnotation(obj, metadata.SyncPausedAnnotationKey) == "true" {
+			return "", errors.New("the sync is paused, resume it to trigger a resync")
+		}
+		token = now.UTC().Format(time.RFC3339Nano)
+		core.SetAnnotation(obj, metadata.SyncTriggerAnnotationKey, token)
+	case actionPause:
+		core.SetAnnotation(obj, metadata.SyncPausedAnnotationKey, "true")
+	case actionResume:
+		core.RemoveAnnotations(obj, metadata.SyncPausedAnnotationKey)
+	case actionReapply:
+		if core.GetAnnotation(obj, metadata.SyncPausedAnnotationKey) == "true" {
+			return "", errors.New("the sync is paused, resume it to reapply resources")
+		}
+		req := selector
+		req.Token = now.UTC().Format(time.RFC3339Nano)
+		if err := req.Validate(); err != nil {
+			return "", err
+		}
+		value, err := req.Annotation()
+		if err != nil {
+			return "", err
+		}
+		token = req.Token
+		core.SetAnnotation(obj, metadata.ReapplyAnnotationKey, value)
+	default:
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+	if err := c.Patch(ctx, obj, client.MergeFrom(existing)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// acknowledged returns true if the reconciler reports the action in the
+// status of the RootSync or RepoSync.
+func acknowledged(obj client.Object, action, token string) bool {
+	switch rs := obj.(type) {
+	case *v1beta1.RootSync:
+		switch action {
+		case actionTrigger:
+			return rootsync.IsTriggered(rs, token)
+		case actionPause:
+			return rootsync.IsPaused(rs)
+		case actionResume:
+			return !rootsync.IsPaused(rs)
+		case actionReapply:
+			return rootsync.IsReapplied(rs, token)
+		}
+	case *v1beta1.RepoSync:
+		switch action {
+		case actionTrigger:
+			return reposync.IsTriggered(rs, token)
+		case actionPause:
+			return reposync.IsPaused(rs)
+		case actionResume:
+			return !reposync.IsPaused(rs)
+		case actionReapply:
+			return reposync.IsReapplied(rs, token)
+		}
+	}
+	return false
+}
+
+func acknowledgedMessage(action string, key client.ObjectKey) string {
+	var verb string
+	switch action {
+	case actionTrigger:
+		verb = "resynced"
+	case actionPause:
+		verb = "paused"
+	case actionResume:
+		verb = "resumed"
+	case actionReapply:
+		verb = fmt.Sprintf("reapplied (%s)", selector)
+	}
+	if waitTimeout <= 0 {
+		return fmt.Sprintf("%s requested to be %s", key, verb)
+	}
+	return fmt.Sprintf("%s %s", key, verb)
+}
+
+func newSyncObject(key client.ObjectKey) client.Object {
+	if key.Namespace == configsync.ControllerNamespace {
+		return &v1beta1.RootSync{}
+	}
+	return &v1beta1.RepoSync{}
+}
+
+func syncKind(key client.ObjectKey) string {
+	if key.Namespace == configsync.ControllerNamespace {
+		return configsync.RootSyncKind
+	}
+	return configsync.RepoSyncKind
+}
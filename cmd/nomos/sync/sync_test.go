@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/reapply"
+	"kpt.dev/configsync/pkg/reposync"
+	"kpt.dev/configsync/pkg/rootsync"
+	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRequest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rootKey := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: configsync.RootSyncName}
+	repoKey := client.ObjectKey{Namespace: "bookstore", Name: configsync.RepoSyncName}
+	c := syncerFake.NewClient(t, core.Scheme,
+		fake.RootSyncObjectV1Beta1(rootKey.Name),
+		fake.RepoSyncObjectV1Beta1(repoKey.Namespace, repoKey.Name))
+
+	token, err := request(ctx, c, rootKey, actionTrigger, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01T00:00:00Z", token)
+	rs := &v1beta1.RootSync{}
+	require.NoError(t, c.Get(ctx, rootKey, rs))
+	assert.Equal(t, token, core.GetAnnotation(rs, metadata.SyncTriggerAnnotationKey))
+	assert.False(t, acknowledged(rs, actionTrigger, token))
+	rootsync.SetTriggered(rs, token)
+	assert.True(t, acknowledged(rs, actionTrigger, token))
+	assert.False(t, acknowledged(rs, actionTrigger, "other-token"))
+
+	_, err = request(ctx, c, repoKey, actionPause, now)
+	require.NoError(t, err)
+	repoSync := &v1beta1.RepoSync{}
+	require.NoError(t, c.Get(ctx, repoKey, repoSync))
+	assert.Equal(t, "true", core.GetAnnotation(repoSync, metadata.SyncPausedAnnotationKey))
+	assert.False(t, acknowledged(repoSync, actionPause, ""))
+	reposync.SetPaused(repoSync, "SyncPaused", "paused")
+	assert.True(t, acknowledged(repoSync, actionPause, ""))
+	assert.False(t, acknowledged(repoSync, actionResume, ""))
+
+	_, err = request(ctx, c, repoKey, actionTrigger, now)
+	assert.EqualError(t, err, "the sync is paused, resume it to trigger a resync")
+
+	_, err = request(ctx, c, repoKey, actionResume, now)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, repoKey, repoSync))
+	assert.Empty(t, core.GetAnnotation(repoSync, metadata.SyncPausedAnnotationKey))
+	assert.True(t, acknowledged(repoSync, actionResume, ""))
+}
+
+func TestRequest_Reapply(t *testing.T) {
+	defer func(s reapply.Request) { selector = s }(selector)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rootKey := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: configsync.RootSyncName}
+	c := syncerFake.NewClient(t, core.Scheme, fake.RootSyncObjectV1Beta1(rootKey.Name))
+
+	selector = reapply.Request{}
+	_, err := request(ctx, c, rootKey, actionReapply, now)
+	assert.EqualError(t, err, "invalid reapply request: a kind or a namespace is required")
+
+	selector = reapply.Request{Group: "apps", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	token, err := request(ctx, c, rootKey, actionReapply, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01T00:00:00Z", token)
+	rs := &v1beta1.RootSync{}
+	require.NoError(t, c.Get(ctx, rootKey, rs))
+	req, err := reapply.Parse(core.GetAnnotation(rs, metadata.ReapplyAnnotationKey))
+	require.NoError(t, err)
+	assert.Equal(t, reapply.Request{Token: token, Group: "apps", Kind: "Deployment", Namespace: "shop", Name: "web"}, req)
+	assert.False(t, acknowledged(rs, actionReapply, token))
+	rootsync.SetReapplied(rs, token, 1)
+	assert.True(t, acknowledged(rs, actionReapply, token))
+	assert.False(t, acknowledged(rs, actionReapply, "other-token"))
+}
@@ -32,3 +32,21 @@ func PrintErrOrDie(err error) {
 		panic(printErr)
 	}
 }
+
+// ExitError is an error that sets the exit code of the nomos command.
+type ExitError struct {
+	// Code is the exit code.
+	Code int
+	// Err is the error to print.
+	Err error
+}
+
+// Error implements error.
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
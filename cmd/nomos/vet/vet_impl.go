@@ -132,6 +132,10 @@ func runVet(ctx context.Context, namespace string, sourceFormat filesystem.Sourc
 		}
 		numClusters++
 
+		warnings, err := splitWarnings(err)
+		for _, warning := range warnings {
+			_ = util.PrintErr(fmt.Errorf("warning: %w", warning))
+		}
 		if err != nil {
 			if clusterName == "" {
 				clusterName = nomosparse.UnregisteredCluster
@@ -161,6 +165,24 @@ func runVet(ctx context.Context, namespace string, sourceFormat filesystem.Sourc
 	return nil
 }
 
+// splitWarnings returns the warnings of the admission policies, which do not
+// fail nomos vet, and the other errors.
+func splitWarnings(errs status.MultiError) ([]status.Error, status.MultiError) {
+	if errs == nil {
+		return nil, nil
+	}
+	var warnings []status.Error
+	var others status.MultiError
+	for _, err := range errs.Errors() {
+		if err.Code() == status.AdmissionPolicyWarningErrorCode {
+			warnings = append(warnings, err)
+		} else {
+			others = status.Append(others, err)
+		}
+	}
+	return warnings, others
+}
+
 // clusterErrors is the set of vet errors for a specific Cluster.
 type clusterErrors struct {
 	name string
@@ -23,6 +23,7 @@ import (
 	"kpt.dev/configsync/pkg/api/configmanagement"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/conflictpolicy"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/importer/analyzer/hnc"
@@ -321,6 +322,40 @@ func Generate() AllExamples {
 	// 1069
 	result.add(validate.SelfReconcileError(fake.RootSyncV1Beta1(configsync.RootSyncName)))
 
+	// 1070
+	result.add(status.SchemaValidationError(fake.Deployment("namespaces/foo"),
+		fmt.Errorf(".spec.replica: field not declared in schema")))
+
+	// 1071
+	result.add(validate.RepoSyncPolicyKindError("tenants", fake.ClusterRole()))
+	result.add(validate.RepoSyncPolicyFieldError("tenants", fake.Deployment("namespaces/foo"), "spec.template.spec.hostNetwork"))
+	result.add(validate.RepoSyncPolicyObjectCountError("tenants", 120, 100))
+	result.add(validate.RepoSyncPolicyObjectBytesError("tenants", 2097152, 1048576))
+	result.add(validate.RepoSyncPolicyNamespaceError("tenants", "bookstore"))
+	result.add(validate.RepoSyncPolicyNotFoundError("tenants", "bookstore"))
+
+	// 1072
+	result.add(validate.IllegalHandoffError(fake.Role(core.Namespace("bookstore")), "videostore",
+		"a RepoSync may only manage objects in its namespace"))
+
+	// 1073
+	result.add(validate.IllegalConflictPolicyError(fake.Deployment("namespaces/bookstore"), "overwrite"))
+
+	// 1074
+	result.add(validate.IllegalIgnoreFieldsError(fake.Deployment("namespaces/bookstore"), errors.New(`invalid path ".spec..replicas": empty field name`)))
+
+	// 1075
+	result.add(conflictpolicy.ConflictError(fake.Deployment("namespaces/bookstore"),
+		[]conflictpolicy.Conflict{{Field: ".spec.replicas", Manager: "kubectl-edit"}}))
+
+	// 1076
+	result.add(status.AdmissionPolicyViolationError(fake.Deployment("namespaces/bookstore"),
+		`ValidatingAdmissionPolicy "no-host-network", bound by "no-host-network"`, "hostNetwork is not allowed"))
+
+	// 1077
+	result.add(status.AdmissionPolicyWarningError(fake.Deployment("namespaces/bookstore"),
+		`K8sRequiredLabels "must-have-owner"`, `you must provide labels: {"owner"}`))
+
 	// 2001
 	result.add(status.PathWrapError(errors.New("error creating directory"), "namespaces/foo"))
 
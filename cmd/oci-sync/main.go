@@ -96,6 +96,9 @@ func main() {
 		utillog.HandleError(log, true, "ERROR: unsupported authentication type %q", *flAuth)
 	}
 
+	// The reconciler requests a fetch with the RefreshSignal when a resync is
+	// requested.
+	refresh := util.NotifyRefresh()
 	initialSync := true
 	failCount := 0
 	for {
@@ -111,7 +114,7 @@ func main() {
 			log.Error(err, "unexpected error fetching package, will retry")
 			log.Info("waiting before retrying", "waitTime", util.WaitTime(*flWait))
 			cancel()
-			time.Sleep(util.WaitTime(*flWait))
+			util.WaitOrRefresh(util.WaitTime(*flWait), refresh)
 			continue
 		}
 
@@ -127,7 +130,7 @@ func main() {
 		log.DeleteErrorFile()
 		log.Info("next sync", "wait_time", util.WaitTime(*flWait))
 		cancel()
-		time.Sleep(util.WaitTime(*flWait))
+		util.WaitOrRefresh(util.WaitTime(*flWait), refresh)
 	}
 
 }
@@ -33,6 +33,7 @@ import (
 	"kpt.dev/configsync/pkg/profiler"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/reconcilermanager/controllers"
+	"kpt.dev/configsync/pkg/util"
 	"kpt.dev/configsync/pkg/util/log"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -51,6 +52,21 @@ var (
 		controllers.PollingPeriod(reconcilermanager.HydrationPollingPeriod, configsync.DefaultHydrationPollingPeriod),
 		"Period of time between checking the filesystem for source updates to render.")
 
+	hydrationAllowedExec = flag.String("hydration-allowed-exec-functions", os.Getenv(reconcilermanager.HydrationAllowedExecFunctions),
+		"Comma-separated list of the executables which the hydration controllers are allowed to run as exec functions of the Kptfile pipeline.")
+
+	metricsExporter = flag.String("metrics-exporter", util.EnvString(reconcilermanager.MetricsExporter, metrics.OtelAgentExporter),
+		fmt.Sprintf("The exporter used to export metrics from the reconciler-manager and the reconcilers. Must be %s or %s. "+
+			"When %s, the reconcilers are created without the otel-agent sidecar.",
+			metrics.OtelAgentExporter, metrics.PrometheusExporterType, metrics.PrometheusExporterType))
+
+	prometheusMetricsAddr = flag.String("prometheus-metrics-addr", fmt.Sprintf(":%d", metrics.PrometheusPort),
+		"The address the Prometheus metrics endpoint binds to, if the prometheus metrics exporter is used.")
+
+	traceSamplingProbability = flag.Float64("trace-sampling-probability", util.EnvFloat(reconcilermanager.TraceSamplingProbability, 0),
+		"The probability that the reconcilers record the trace of a parse-apply-watch loop, between 0 and 1. "+
+			"Traces are exported to the otel-agent sidecar, which is kept with the prometheus metrics exporter if traces are recorded.")
+
 	setupLog = ctrl.Log.WithName("setup")
 )
 
@@ -66,8 +82,8 @@ func main() {
 	profiler.Service()
 	ctrl.SetLogger(klogr.New())
 
-	setupLog.Info(fmt.Sprintf("running with flags --cluster-name=%s; --reconciler-polling-period=%s; --hydration-polling-period=%s",
-		*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod))
+	setupLog.Info(fmt.Sprintf("running with flags --cluster-name=%s; --reconciler-polling-period=%s; --hydration-polling-period=%s; --metrics-exporter=%s; --trace-sampling-probability=%v; --hydration-allowed-exec-functions=%s",
+		*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod, *metricsExporter, *traceSamplingProbability, *hydrationAllowedExec))
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: core.Scheme,
@@ -95,8 +111,8 @@ func main() {
 	}
 	watchFleetMembership := fleetMembershipCRDExists(dynamicClient, mgr.GetRESTMapper())
 
-	repoSync := controllers.NewRepoSyncReconciler(*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod,
-		mgr.GetClient(), watcher, dynamicClient,
+	repoSync := controllers.NewRepoSyncReconciler(*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod, *metricsExporter, *traceSamplingProbability,
+		util.SplitList(*hydrationAllowedExec), mgr.GetClient(), watcher, dynamicClient,
 		ctrl.Log.WithName("controllers").WithName(configsync.RepoSyncKind),
 		mgr.GetScheme())
 	if err := repoSync.SetupWithManager(mgr, watchFleetMembership); err != nil {
@@ -104,8 +120,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	rootSync := controllers.NewRootSyncReconciler(*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod,
-		mgr.GetClient(), watcher, dynamicClient,
+	rootSync := controllers.NewRootSyncReconciler(*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod, *metricsExporter, *traceSamplingProbability,
+		util.SplitList(*hydrationAllowedExec), mgr.GetClient(), watcher, dynamicClient,
 		ctrl.Log.WithName("controllers").WithName(configsync.RootSyncKind),
 		mgr.GetScheme())
 	if err := rootSync.SetupWithManager(mgr, watchFleetMembership); err != nil {
@@ -134,16 +150,21 @@ func main() {
 		setupLog.Error(err, "failed to register OpenCensus views")
 	}
 
-	// Register the OC Agent exporter
-	oce, err := metrics.RegisterOCAgentExporter(reconcilermanager.ManagerName)
+	// Register the metrics exporter
+	podName, _ := os.Hostname()
+	oce, err := metrics.RegisterExporter(*metricsExporter, reconcilermanager.ManagerName, *prometheusMetricsAddr,
+		map[string]string{
+			metrics.ResourceKeyDeploymentName.Name(): reconcilermanager.ManagerName,
+			metrics.ResourceKeyPodName.Name():        podName,
+		})
 	if err != nil {
-		setupLog.Error(err, "failed to register the OC Agent exporter")
+		setupLog.Error(err, "failed to register the metrics exporter", "exporter", *metricsExporter)
 		os.Exit(1)
 	}
 
 	defer func() {
 		if err := oce.Stop(); err != nil {
-			setupLog.Error(err, "unable to stop the OC Agent exporter")
+			setupLog.Error(err, "unable to stop the metrics exporter", "exporter", *metricsExporter)
 		}
 	}()
 
@@ -152,9 +173,9 @@ func main() {
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
-		// os.Exit(1) does not run deferred functions so explicitly stopping the OC Agent exporter.
+		// os.Exit(1) does not run deferred functions so explicitly stopping the metrics exporter.
 		if err := oce.Stop(); err != nil {
-			setupLog.Error(err, "unable to stop the OC Agent exporter")
+			setupLog.Error(err, "unable to stop the metrics exporter", "exporter", *metricsExporter)
 		}
 		os.Exit(1)
 	}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -22,9 +23,14 @@ import (
 
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
+	"kpt.dev/configsync/pkg/admissionpolicy"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/commitstatus"
+	"kpt.dev/configsync/pkg/conflictpolicy"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/git"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	ocmetrics "kpt.dev/configsync/pkg/metrics"
@@ -33,6 +39,7 @@ import (
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/reconcilermanager/controllers"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util"
 	"kpt.dev/configsync/pkg/util/log"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -60,6 +67,22 @@ var (
 		"The reference we're syncing to in the repo. Could be a specific commit or a chart version.")
 	syncDir = flag.String("sync-dir", os.Getenv(reconcilermanager.SyncDirKey),
 		"The relative path of the root configuration directory within the repo.")
+	syncInclude = flag.String("sync-include", os.Getenv(reconcilermanager.SyncIncludeKey),
+		"Comma-separated list of patterns of the files to sync from the sync directory. If empty, all the files are synced.")
+	syncExclude = flag.String("sync-exclude", os.Getenv(reconcilermanager.SyncExcludeKey),
+		"Comma-separated list of patterns of the files not to sync from the sync directory.")
+	gitVerificationPolicy = flag.String("git-verification-policy", os.Getenv(reconcilermanager.GitVerificationPolicyKey),
+		"The commits which must be signed by a trusted key, must be HeadSigned or AllCommitsSigned. If empty, the signatures are not verified.")
+	gitVerificationKeysDir = flag.String("git-verification-keys-dir", "/etc/git-verification",
+		"The absolute path in the container running the reconciler to the trusted keys for the Git commit signature verification.")
+	commitStatusProvider = flag.String("commit-status-provider", os.Getenv(reconcilermanager.CommitStatusProviderKey),
+		"The Git provider which the outcome of syncing each commit is reported to, must be github, gitlab or webhook. If empty, the outcome is not reported.")
+	commitStatusURL = flag.String("commit-status-url", os.Getenv(reconcilermanager.CommitStatusURLKey),
+		"The base URL of the Git provider API, or the URL of the webhook.")
+	commitStatusProject = flag.String("commit-status-project", os.Getenv(reconcilermanager.CommitStatusProjectKey),
+		"The GitHub repository or the GitLab project of the commit statuses. If empty, it is derived from the source repo URL.")
+	commitStatusMaxErrors = flag.Int("commit-status-max-errors", util.EnvInt(reconcilermanager.CommitStatusMaxErrorsKey, commitstatus.DefaultMaxErrors),
+		"The maximum number of errors in a commit status report.")
 
 	// Performance tuning flags.
 	sourceDir = flag.String(flags.sourceDir, "/repo/source/rev",
@@ -92,6 +115,17 @@ var (
 
 	apiServerTimeout = flag.String("api-server-timeout", os.Getenv(reconcilermanager.APIServerTimeout), "The client-side timeout for requests to the API server")
 
+	conflictPolicy = flag.String("conflict-policy", os.Getenv(reconcilermanager.ConflictPolicy),
+		fmt.Sprintf("The policy for the fields of the managed objects owned by other field managers. Must be %s, %s, or %s. Default: %s.",
+			configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect, configsync.ConflictPolicyFail, configsync.ConflictPolicyForce))
+
+	policyEnforcement = flag.String("policy-enforcement", os.Getenv(reconcilermanager.PolicyEnforcement),
+		fmt.Sprintf("How the declared objects violating the admission policies are reported. Must be %s, %s, or %s. Default: %s.",
+			configsync.PolicyEnforcementDeny, configsync.PolicyEnforcementWarn, configsync.PolicyEnforcementDisabled, configsync.PolicyEnforcementDeny))
+
+	ignoreDifferencesJSON = flag.String("ignore-differences", os.Getenv(reconcilermanager.IgnoreDifferences),
+		"The JSON-encoded fields of each kind which the reconciler neither applies nor remediates.")
+
 	debug = flag.Bool("debug", false,
 		"Enable debug mode, panicking in many scenarios where normally an InternalError would be logged. "+
 			"Do not use in production.")
@@ -100,6 +134,13 @@ var (
 	namespaceStrategy = flag.String(flags.namespaceStrategy, util.EnvString(reconcilermanager.NamespaceStrategy, ""),
 		fmt.Sprintf("Set the namespace strategy for the reconciler. Must be %s or %s. Default: %s.",
 			configsync.NamespaceStrategyImplicit, configsync.NamespaceStrategyExplicit, configsync.NamespaceStrategyImplicit))
+
+	metricsExporter = flag.String("metrics-exporter", util.EnvString(reconcilermanager.MetricsExporter, ocmetrics.OtelAgentExporter),
+		fmt.Sprintf("The exporter used to export metrics. Must be %s or %s.", ocmetrics.OtelAgentExporter, ocmetrics.PrometheusExporterType))
+	prometheusMetricsAddr = flag.String("prometheus-metrics-addr", fmt.Sprintf(":%d", ocmetrics.PrometheusPort),
+		"The address the Prometheus metrics endpoint binds to, if the prometheus metrics exporter is used.")
+	traceSamplingProbability = flag.Float64("trace-sampling-probability", util.EnvFloat(reconcilermanager.TraceSamplingProbability, 0),
+		"The probability of recording the trace of a parse-apply-watch loop, between 0 and 1.")
 )
 
 var flags = struct {
@@ -136,18 +177,33 @@ func main() {
 		klog.Fatalf("Failed to register OpenCensus views: %v", err)
 	}
 
-	// Register the OC Agent exporter
-	oce, err := ocmetrics.RegisterOCAgentExporter(reconcilermanager.Reconciler)
+	// Register the metrics exporter
+	oce, err := ocmetrics.RegisterExporter(*metricsExporter, reconcilermanager.Reconciler,
+		*prometheusMetricsAddr, syncResourceLabels())
 	if err != nil {
-		klog.Fatalf("Failed to register the OC Agent exporter: %v", err)
+		klog.Fatalf("Failed to register the %s metrics exporter: %v", *metricsExporter, err)
 	}
 
 	defer func() {
 		if err := oce.Stop(); err != nil {
-			klog.Fatalf("Unable to stop the OC Agent exporter: %v", err)
+			klog.Fatalf("Unable to stop the %s metrics exporter: %v", *metricsExporter, err)
 		}
 	}()
 
+	// Export traces with the metrics exporter, if it supports traces, or to
+	// the otel-agent sidecar otherwise
+	te, err := tracing.RegisterExporter(oce, *traceSamplingProbability)
+	if err != nil {
+		klog.Fatalf("Failed to register the trace exporter: %v", err)
+	}
+	if te != nil {
+		defer func() {
+			if err := te.Stop(); err != nil {
+				klog.Fatalf("Unable to stop the trace exporter: %v", err)
+			}
+		}()
+	}
+
 	absRepoRoot, err := cmpath.AbsoluteOS(*repoRootDir)
 	if err != nil {
 		klog.Fatalf("%s must be an absolute path: %v", flags.repoRootDir, err)
@@ -169,6 +225,14 @@ func main() {
 		klog.Fatal(err)
 	}
 
+	if err := conflictpolicy.Validate(configsync.ConflictPolicy(*conflictPolicy)); err != nil {
+		klog.Fatal(err)
+	}
+
+	if err := admissionpolicy.ValidateEnforcement(configsync.PolicyEnforcement(*policyEnforcement)); err != nil {
+		klog.Fatal(err)
+	}
+
 	opts := reconciler.Options{
 		ClusterName:             *clusterName,
 		FightDetectionThreshold: *fightDetectionThreshold,
@@ -187,11 +251,18 @@ func main() {
 		SourceType:              v1beta1.SourceType(*sourceType),
 		SourceRepo:              *sourceRepo,
 		SyncDir:                 relSyncDir,
+		SyncInclude:             splitPatterns(*syncInclude),
+		SyncExclude:             splitPatterns(*syncExclude),
+		GitVerification:         gitVerification(*gitVerificationPolicy, *gitVerificationKeysDir),
+		CommitStatus:            commitStatus(),
 		SyncName:                *syncName,
 		ReconcilerName:          *reconcilerName,
 		StatusMode:              *statusMode,
 		ReconcileTimeout:        *reconcileTimeout,
 		APIServerTimeout:        *apiServerTimeout,
+		ConflictPolicy:          configsync.ConflictPolicy(*conflictPolicy),
+		PolicyEnforcement:       configsync.PolicyEnforcement(*policyEnforcement),
+		IgnoreDifferences:       ignoreDifferences(*ignoreDifferencesJSON),
 		RenderingEnabled:        *renderingEnabled,
 	}
 
@@ -226,3 +297,86 @@ func main() {
 	}
 	reconciler.Run(opts)
 }
+
+// syncResourceLabels returns the resource attributes that identify the
+// RootSync or RepoSync being reconciled. These are added to every metric by the
+// Prometheus exporter, because there is no otel-agent to add them.
+func syncResourceLabels() map[string]string {
+	syncKind := configsync.RepoSyncKind
+	syncNamespace := *scope
+	if declared.Scope(*scope) == declared.RootReconciler {
+		syncKind = configsync.RootSyncKind
+		syncNamespace = configsync.ControllerNamespace
+	}
+	podName, _ := os.Hostname()
+	return map[string]string{
+		ocmetrics.ResourceKeySyncKind.Name():       syncKind,
+		ocmetrics.ResourceKeySyncName.Name():       *syncName,
+		ocmetrics.ResourceKeySyncNamespace.Name():  syncNamespace,
+		ocmetrics.ResourceKeySyncGeneration.Name(): os.Getenv(reconcilermanager.SyncGenerationKey),
+		ocmetrics.ResourceKeyDeploymentName.Name(): *reconcilerName,
+		ocmetrics.ResourceKeyPodName.Name():        podName,
+	}
+}
+
+// gitVerification returns the verifier of the Git commit signatures, or nil if
+// the policy is empty.
+func gitVerification(policy, keysDir string) *git.Verifier {
+	switch v1beta1.GitVerificationPolicy(policy) {
+	case "":
+		return nil
+	case v1beta1.HeadSigned, v1beta1.AllCommitsSigned:
+		return &git.Verifier{KeysDir: keysDir, Policy: v1beta1.GitVerificationPolicy(policy)}
+	default:
+		klog.Fatalf("Invalid Git verification policy %q: must be %s or %s", policy, v1beta1.HeadSigned, v1beta1.AllCommitsSigned)
+		return nil
+	}
+}
+
+// ignoreDifferences returns the ignored fields of each kind, from the
+// JSON-encoded ignoreDifferences override of the RootSync or RepoSync.
+func ignoreDifferences(value string) ignorefields.Rules {
+	if value == "" {
+		return nil
+	}
+	var diffs []v1beta1.IgnoreDifference
+	if err := json.Unmarshal([]byte(value), &diffs); err != nil {
+		klog.Fatalf("Invalid ignoreDifferences %q: %v", value, err)
+	}
+	rules, err := ignorefields.NewRules(diffs)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	return rules
+}
+
+// commitStatus returns the notifier of the outcome of syncing each commit, or
+// nil if the outcome is not reported.
+func commitStatus() *commitstatus.Notifier {
+	if *commitStatusProvider == "" {
+		return nil
+	}
+	n, err := commitstatus.NewNotifier(v1beta1.GitProvider(*commitStatusProvider), *commitStatusURL,
+		*commitStatusProject, *sourceRepo, os.Getenv(reconcilermanager.CommitStatusTokenKey))
+	if err != nil {
+		klog.Fatalf("Invalid commit status configuration: %v", err)
+	}
+	n.MaxErrors = *commitStatusMaxErrors
+	n.SyncKind = configsync.RepoSyncKind
+	n.SyncNamespace = *scope
+	if declared.Scope(*scope) == declared.RootReconciler {
+		n.SyncKind = configsync.RootSyncKind
+		n.SyncNamespace = configsync.ControllerNamespace
+	}
+	n.SyncName = *syncName
+	n.Context = fmt.Sprintf("config-sync/%s/%s", n.SyncNamespace, n.SyncName)
+	return n
+}
+
+// splitPatterns splits a comma-separated list of patterns.
+func splitPatterns(patterns string) []string {
+	if patterns == "" {
+		return nil
+	}
+	return strings.Split(patterns, ",")
+}
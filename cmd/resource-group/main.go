@@ -17,9 +17,9 @@ package main
 import (
 	"os"
 
-	"kpt.dev/resourcegroup/controllers/runner"
+	"kpt.dev/configsync/pkg/resourcegroup/controllers"
 )
 
 func main() {
-	os.Exit(runner.Run())
+	os.Exit(controllers.Run())
 }
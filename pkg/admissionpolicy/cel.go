@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"k8s.io/apiserver/pkg/cel/library"
+)
+
+// perCallLimit is the cost limit of the evaluation of an expression, like the
+// limit of the API server for the admission policies.
+const perCallLimit = 1000000
+
+var (
+	celEnvOnce sync.Once
+	celEnv     *cel.Env
+	celEnvErr  error
+)
+
+// newCELEnv returns the CEL environment of the admission policy expressions:
+// the variables of an admission request, and the Kubernetes CEL libraries.
+func newCELEnv() (*cel.Env, error) {
+	celEnvOnce.Do(func() {
+		opts := []cel.EnvOption{
+			cel.HomogeneousAggregateLiterals(),
+			cel.EagerlyValidateDeclarations(true),
+			cel.DefaultUTCTimeZone(true),
+			cel.Variable("object", cel.DynType),
+			cel.Variable("oldObject", cel.DynType),
+			cel.Variable("params", cel.DynType),
+			cel.Variable("namespaceObject", cel.DynType),
+			cel.Variable("request", cel.DynType),
+			cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+		}
+		opts = append(opts, library.ExtensionLibs...)
+		celEnv, celEnvErr = cel.NewEnv(opts...)
+	})
+	return celEnv, celEnvErr
+}
+
+// compiled is a compiled expression.
+type compiled struct {
+	source  string
+	program cel.Program
+}
+
+// compile compiles the expression.
+func compile(expr string) (compiled, error) {
+	env, err := newCELEnv()
+	if err != nil {
+		return compiled{source: expr}, err
+	}
+	checked, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return compiled{source: expr}, issues.Err()
+	}
+	program, err := env.Program(checked,
+		cel.EvalOptions(cel.OptOptimize),
+		cel.OptimizeRegex(library.ExtensionLibRegexOptimizations...),
+		cel.CostLimit(perCallLimit))
+	if err != nil {
+		return compiled{source: expr}, err
+	}
+	return compiled{source: expr, program: program}, nil
+}
+
+// eval evaluates the expression, and returns its value converted to a Go
+// value.
+func (c compiled) eval(vars map[string]interface{}) (interface{}, error) {
+	if c.program == nil {
+		return nil, fmt.Errorf("expression %q is not compiled", c.source)
+	}
+	out, _, err := c.program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return nativeValue(out)
+}
+
+// nativeValue converts the CEL values of the results, which are compared to
+// bool and string, to Go values.
+func nativeValue(v ref.Val) (interface{}, error) {
+	switch v.Type() {
+	case types.BoolType:
+		return v.Value().(bool), nil
+	case types.StringType:
+		return v.Value().(string), nil
+	case types.NullType:
+		return nil, nil
+	default:
+		return v, nil
+	}
+}
+
+// typeName returns the CEL type name of a result.
+func typeName(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null_type"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case ref.Val:
+		return t.Type().TypeName()
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// versions are the versions of the admission policy and Gatekeeper APIs, by
+// preference.
+var versions = []string{"v1", "v1beta1", "v1alpha1"}
+
+// ClusterObjects returns the ValidatingAdmissionPolicies, the
+// ValidatingAdmissionPolicyBindings, the Gatekeeper ConstraintTemplates and
+// constraints, and the Namespaces of the cluster, to be passed to NewSet. All
+// the Namespaces are listed if namespaces is empty.
+//
+// The policies are not evaluated, and no error is returned, if the cluster
+// does not serve the admission policy or Gatekeeper APIs, or if the client is
+// not allowed to read the policies.
+func ClusterObjects(ctx context.Context, c client.Reader, namespaces ...string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	for _, kind := range []string{PolicyKind, BindingKind} {
+		listed, err := listFirstServedVersion(ctx, c, Group, kind)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, listed...)
+	}
+	templates, err := listFirstServedVersion(ctx, c, TemplateGroup, TemplateKind)
+	if err != nil {
+		return nil, err
+	}
+	objs = append(objs, templates...)
+	for _, obj := range templates {
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "crd", "spec", "names", "kind")
+		if kind == "" {
+			continue
+		}
+		constraints, err := listFirstServedVersion(ctx, c, ConstraintGroup, kind)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, constraints...)
+	}
+	if len(objs) == 0 {
+		// The Namespaces are only used to match the namespace selectors of the
+		// policies.
+		return nil, nil
+	}
+
+	nsGVK := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	if len(namespaces) == 0 {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(nsGVK.GroupVersion().WithKind("NamespaceList"))
+		if err := c.List(ctx, list); err != nil {
+			if !apierrors.IsForbidden(err) {
+				return nil, err
+			}
+			klog.V(3).Infof("Not allowed to list the Namespaces, skipping the namespace selectors of the admission policies: %v", err)
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+		return objs, nil
+	}
+	for _, name := range namespaces {
+		ns := &unstructured.Unstructured{}
+		ns.SetGroupVersionKind(nsGVK)
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+			if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+				continue
+			}
+			return nil, err
+		}
+		objs = append(objs, ns)
+	}
+	return objs, nil
+}
+
+// listFirstServedVersion lists the objects of the group and kind with the
+// first version of the API served by the cluster.
+func listFirstServedVersion(ctx context.Context, c client.Reader, group, kind string) ([]*unstructured.Unstructured, error) {
+	for _, version := range versions {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: version, Kind: kind + "List"})
+		if err := c.List(ctx, list); err != nil {
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			if apierrors.IsForbidden(err) {
+				klog.Warningf("Not allowed to list the %ss, skipping them: %v", kind, err)
+				return nil, nil
+			}
+			return nil, err
+		}
+		var objs []*unstructured.Unstructured
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+		return objs, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,393 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// TemplateGroup is the API group of the Gatekeeper ConstraintTemplates.
+	TemplateGroup = "templates.gatekeeper.sh"
+	// TemplateKind is the kind of the Gatekeeper ConstraintTemplates.
+	TemplateKind = "ConstraintTemplate"
+	// ConstraintGroup is the API group of the Gatekeeper constraints, whose
+	// kinds are defined by the ConstraintTemplates.
+	ConstraintGroup = "constraints.gatekeeper.sh"
+)
+
+// admissionTarget is the target of the ConstraintTemplates which validate the
+// admission requests.
+const admissionTarget = "admission.k8s.gatekeeper.sh"
+
+// enforcementDeny is the enforcement action of the constraints whose
+// violations are denied. The other actions, dryrun and warn, do not.
+const enforcementDeny = "deny"
+
+// unsafeBuiltins are the Rego built-in functions which Gatekeeper does not
+// allow in the templates.
+var unsafeBuiltins = map[string]struct{}{
+	ast.HTTPSend.Name: {},
+}
+
+// templateSpec is the part of the spec of a ConstraintTemplate used to
+// evaluate its constraints. It is common to all the versions of the API.
+type templateSpec struct {
+	CRD struct {
+		Spec struct {
+			Names struct {
+				Kind string `json:"kind"`
+			} `json:"names"`
+		} `json:"spec"`
+	} `json:"crd"`
+	Targets []templateTarget `json:"targets,omitempty"`
+}
+
+type templateTarget struct {
+	Target string   `json:"target"`
+	Rego   string   `json:"rego,omitempty"`
+	Libs   []string `json:"libs,omitempty"`
+}
+
+// constraintSpec is the part of the spec of a Gatekeeper constraint used to
+// evaluate it. The parameters are read separately.
+type constraintSpec struct {
+	Match             *constraintMatch `json:"match,omitempty"`
+	EnforcementAction string           `json:"enforcementAction,omitempty"`
+}
+
+type constraintMatch struct {
+	Kinds              []kindSelector        `json:"kinds,omitempty"`
+	Scope              string                `json:"scope,omitempty"`
+	Namespaces         []string              `json:"namespaces,omitempty"`
+	ExcludedNamespaces []string              `json:"excludedNamespaces,omitempty"`
+	LabelSelector      *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	NamespaceSelector  *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Name               string                `json:"name,omitempty"`
+}
+
+type kindSelector struct {
+	APIGroups []string `json:"apiGroups,omitempty"`
+	Kinds     []string `json:"kinds,omitempty"`
+}
+
+type template struct {
+	name string
+	// kind is the kind of the constraints of the template.
+	kind string
+	obj  *unstructured.Unstructured
+	// modules are the Rego modules of the template, by file name.
+	modules map[string]string
+	// packagePath is the path of the package of the template, whose violation
+	// rule is evaluated.
+	packagePath string
+	query       rego.PreparedEvalQuery
+	// err is the error which prevents evaluating the template, if any.
+	err error
+}
+
+type constraint struct {
+	name       string
+	kind       string
+	obj        *unstructured.Unstructured
+	match      *constraintMatch
+	parameters interface{}
+	action     string
+}
+
+func newTemplate(obj *unstructured.Unstructured) (*template, error) {
+	spec := &templateSpec{}
+	if err := fromUnstructured(obj, spec); err != nil {
+		return nil, err
+	}
+	t := &template{
+		name: obj.GetName(),
+		kind: spec.CRD.Spec.Names.Kind,
+		obj:  obj,
+	}
+	if t.kind == "" {
+		return nil, fmt.Errorf("invalid %s %s: spec.crd.spec.names.kind is required", TemplateKind, t.name)
+	}
+	var target *templateTarget
+	for i := range spec.Targets {
+		if spec.Targets[i].Target == admissionTarget {
+			target = &spec.Targets[i]
+		}
+	}
+	switch {
+	case target == nil:
+		t.err = fmt.Errorf("the template has no %s target", admissionTarget)
+		return t, nil
+	case target.Rego == "":
+		t.err = fmt.Errorf("only the templates with Rego are supported")
+		return t, nil
+	}
+	module, err := ast.ParseModule(t.name+".rego", target.Rego)
+	if err != nil {
+		t.err = fmt.Errorf("invalid Rego: %w", err)
+		return t, nil
+	}
+	t.packagePath = module.Package.Path.String()
+	t.modules = map[string]string{t.name + ".rego": target.Rego}
+	for i, lib := range target.Libs {
+		t.modules[fmt.Sprintf("%s.lib%d.rego", t.name, i)] = lib
+	}
+	return t, nil
+}
+
+// prepareTemplates compiles the Rego of the templates. The inventory is the
+// data.inventory document of the templates, like the objects replicated by
+// Gatekeeper.
+func (s *Set) prepareTemplates(inv inventory) {
+	store := inmem.NewFromObject(map[string]interface{}{"inventory": inv.document()})
+	for _, t := range s.templates {
+		if t.err != nil {
+			continue
+		}
+		opts := []func(*rego.Rego){
+			rego.Query(t.packagePath + ".violation"),
+			rego.Store(store),
+			rego.UnsafeBuiltins(unsafeBuiltins),
+		}
+		for name, module := range t.modules {
+			opts = append(opts, rego.Module(name, module))
+		}
+		query, err := rego.New(opts...).PrepareForEval(context.Background())
+		if err != nil {
+			t.err = fmt.Errorf("invalid Rego: %w", err)
+			continue
+		}
+		t.query = query
+	}
+}
+
+func newConstraint(obj *unstructured.Unstructured) (*constraint, error) {
+	spec := &constraintSpec{}
+	if err := fromUnstructured(obj, spec); err != nil {
+		return nil, err
+	}
+	parameters, _, err := unstructured.NestedFieldCopy(obj.Object, "spec", "parameters")
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	if parameters == nil {
+		parameters = map[string]interface{}{}
+	}
+	action := strings.ToLower(spec.EnforcementAction)
+	if action == "" {
+		action = enforcementDeny
+	}
+	return &constraint{
+		name:       obj.GetName(),
+		kind:       obj.GetKind(),
+		obj:        obj,
+		match:      spec.Match,
+		parameters: parameters,
+		action:     action,
+	}, nil
+}
+
+// matches returns true if the match criteria of the constraint match the
+// object. Like in Gatekeeper, the namespace criteria do not apply to the
+// cluster-scoped objects, except the Namespaces.
+func (c *constraint) matches(obj *unstructured.Unstructured, nsLabels map[string]string, nsFound bool) bool {
+	m := c.match
+	if m == nil {
+		return true
+	}
+	gvk := obj.GroupVersionKind()
+	if len(m.Kinds) > 0 {
+		matched := false
+		for _, k := range m.Kinds {
+			if containsOrStar(k.APIGroups, gvk.Group) && containsOrStar(k.Kinds, gvk.Kind) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if m.Name != "" && !wildcardMatch(m.Name, obj.GetName()) {
+		return false
+	}
+	switch m.Scope {
+	case "Cluster":
+		if obj.GetNamespace() != "" {
+			return false
+		}
+	case "Namespaced":
+		if obj.GetNamespace() == "" {
+			return false
+		}
+	}
+	if !selectorMatches(m.LabelSelector, obj.GetLabels()) {
+		return false
+	}
+
+	ns := obj.GetNamespace()
+	if gvk.Group == "" && gvk.Kind == "Namespace" {
+		ns = obj.GetName()
+	}
+	if ns == "" {
+		return true
+	}
+	if len(m.Namespaces) > 0 && !wildcardMatchAny(m.Namespaces, ns) {
+		return false
+	}
+	if wildcardMatchAny(m.ExcludedNamespaces, ns) {
+		return false
+	}
+	if m.NamespaceSelector != nil && !isEmptySelector(m.NamespaceSelector) {
+		if !nsFound {
+			// The labels of the Namespace are unknown, so the constraint may
+			// or may not apply to the object.
+			return false
+		}
+		return selectorMatches(m.NamespaceSelector, nsLabels)
+	}
+	return true
+}
+
+// wildcardMatch matches the names with a prefix or suffix wildcard, like
+// "kube-*" or "*-system", like Gatekeeper.
+func wildcardMatch(pattern, name string) bool {
+	switch {
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))
+	case strings.HasPrefix(pattern, "*"):
+		return strings.HasSuffix(name, strings.TrimPrefix(pattern, "*"))
+	default:
+		return pattern == name
+	}
+}
+
+func wildcardMatchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if wildcardMatch(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate returns the violations of the constraint by the object.
+func (c *constraint) evaluate(obj *unstructured.Unstructured, t *template) []Violation {
+	warning := c.action != enforcementDeny
+	violation := func(message string, warning bool) Violation {
+		v := Violation{
+			Kind:    c.kind,
+			Policy:  c.name,
+			Message: message,
+			Warning: warning,
+			Sources: []*unstructured.Unstructured{c.obj},
+		}
+		if t != nil {
+			v.Sources = append(v.Sources, t.obj)
+		}
+		return v
+	}
+	if t == nil {
+		return []Violation{violation(fmt.Sprintf("the constraint could not be evaluated: no %s defines the kind %s", TemplateKind, c.kind), true)}
+	}
+	if t.err != nil {
+		return []Violation{violation(fmt.Sprintf("the constraint could not be evaluated: %s %s: %v", TemplateKind, t.name, t.err), true)}
+	}
+
+	gvk := obj.GroupVersionKind()
+	input := map[string]interface{}{
+		"review": map[string]interface{}{
+			"kind":      map[string]interface{}{"group": gvk.Group, "version": gvk.Version, "kind": gvk.Kind},
+			"name":      obj.GetName(),
+			"namespace": obj.GetNamespace(),
+			"operation": "CREATE",
+			"object":    obj.Object,
+		},
+		"parameters": c.parameters,
+	}
+	results, err := t.query.Eval(context.Background(), rego.EvalInput(input))
+	if err != nil {
+		return []Violation{violation(fmt.Sprintf("the constraint could not be evaluated: %v", err), true)}
+	}
+	var violations []Violation
+	for _, result := range results {
+		for _, expr := range result.Expressions {
+			set, ok := expr.Value.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, item := range set {
+				violations = append(violations, violation(violationMessage(item), warning))
+			}
+		}
+	}
+	return violations
+}
+
+// violationMessage returns the msg of a violation of a template.
+func violationMessage(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		if msg, ok := m["msg"].(string); ok && msg != "" {
+			return msg
+		}
+	}
+	return fmt.Sprintf("violation: %v", item)
+}
+
+// inventory is the data.inventory document of the templates: the cluster
+// objects by group version, kind and name, and the namespaced objects by
+// namespace, group version, kind and name.
+type inventory map[string]map[string]interface{}
+
+func newInventory() inventory {
+	return inventory{"cluster": {}, "namespace": {}}
+}
+
+func (inv inventory) add(obj *unstructured.Unstructured) {
+	gvk := obj.GroupVersionKind()
+	gv := schema.GroupVersion{Group: gvk.Group, Version: gvk.Version}.String()
+	var path []string
+	if obj.GetNamespace() == "" {
+		path = []string{"cluster", gv, gvk.Kind}
+	} else {
+		path = []string{"namespace", obj.GetNamespace(), gv, gvk.Kind}
+	}
+	m := inv[path[0]]
+	for _, key := range path[1:] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[obj.GetName()] = obj.Object
+}
+
+func (inv inventory) document() map[string]interface{} {
+	return map[string]interface{}{
+		"cluster":   map[string]interface{}(inv["cluster"]),
+		"namespace": map[string]interface{}(inv["namespace"]),
+	}
+}
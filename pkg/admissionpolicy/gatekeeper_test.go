@@ -0,0 +1,262 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const requiredLabelsRego = `package k8srequiredlabels
+
+import data.lib.labels.missing
+
+violation[{"msg": msg}] {
+	provided := {label | input.review.object.metadata.labels[label]}
+	required := {label | label := input.parameters.labels[_]}
+	count(missing(provided, required)) > 0
+	msg := sprintf("you must provide labels: %v", [missing(provided, required)])
+}
+`
+
+const labelsLib = `package lib.labels
+
+missing(provided, required) = required - provided
+`
+
+// uniqueNameRego denies the objects with the same name as another object of
+// the same kind in another namespace, using the inventory.
+const uniqueNameRego = `package k8suniquename
+
+violation[{"msg": msg}] {
+	obj := input.review.object
+	data.inventory.namespace[ns][_][obj.kind][obj.metadata.name]
+	ns != obj.metadata.namespace
+	msg := sprintf("%v %v is also declared in the namespace %v", [obj.kind, obj.metadata.name, ns])
+}
+`
+
+func constraintTemplate(kind string, targets ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": TemplateGroup + "/v1",
+		"kind":       TemplateKind,
+		"metadata":   map[string]interface{}{"name": strings.ToLower(kind)},
+		"spec": map[string]interface{}{
+			"crd": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"names": map[string]interface{}{"kind": kind},
+				},
+			},
+			"targets": targets,
+		},
+	}}
+}
+
+func regoTarget(rego string, libs ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"target": admissionTarget,
+		"rego":   rego,
+		"libs":   libs,
+	}
+}
+
+func gatekeeperConstraint(kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": ConstraintGroup + "/v1beta1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       spec,
+	}}
+}
+
+func requiredLabels(spec map[string]interface{}) *unstructured.Unstructured {
+	c := gatekeeperConstraint("K8sRequiredLabels", "must-have-owner", map[string]interface{}{
+		"match": map[string]interface{}{
+			"kinds": []interface{}{
+				map[string]interface{}{"apiGroups": []interface{}{"apps"}, "kinds": []interface{}{"Deployment"}},
+			},
+		},
+		"parameters": map[string]interface{}{"labels": []interface{}{"owner"}},
+	})
+	for k, v := range spec {
+		c.Object["spec"].(map[string]interface{})[k] = v
+	}
+	return c
+}
+
+func TestSet_EvaluateGatekeeper(t *testing.T) {
+	requiredLabelsTemplate := constraintTemplate("K8sRequiredLabels", regoTarget(requiredLabelsRego, labelsLib))
+	missingOwner := `you must provide labels: {"owner"}`
+	testCases := []struct {
+		name string
+		objs []*unstructured.Unstructured
+		obj  *unstructured.Unstructured
+		want []Violation
+	}{
+		{
+			name: "denied by a constraint",
+			objs: []*unstructured.Unstructured{requiredLabelsTemplate, requiredLabels(nil)},
+			obj:  deployment("shop", 1),
+			want: []Violation{
+				{Kind: "K8sRequiredLabels", Policy: "must-have-owner", Message: missingOwner},
+			},
+		},
+		{
+			name: "allowed by a constraint",
+			objs: []*unstructured.Unstructured{requiredLabelsTemplate, requiredLabels(nil)},
+			obj: func() *unstructured.Unstructured {
+				obj := deployment("shop", 1)
+				obj.SetLabels(map[string]string{"owner": "shop-team"})
+				return obj
+			}(),
+		},
+		{
+			name: "dryrun enforcement action",
+			objs: []*unstructured.Unstructured{
+				requiredLabelsTemplate,
+				requiredLabels(map[string]interface{}{"enforcementAction": "dryrun"}),
+			},
+			obj: deployment("shop", 1),
+			want: []Violation{
+				{Kind: "K8sRequiredLabels", Policy: "must-have-owner", Message: missingOwner, Warning: true},
+			},
+		},
+		{
+			name: "unmatched kind",
+			objs: []*unstructured.Unstructured{requiredLabelsTemplate, requiredLabels(nil)},
+			obj:  namespace("shop", nil),
+		},
+		{
+			name: "excluded namespace",
+			objs: []*unstructured.Unstructured{
+				requiredLabelsTemplate,
+				requiredLabels(map[string]interface{}{
+					"match": map[string]interface{}{"excludedNamespaces": []interface{}{"sh*"}},
+				}),
+			},
+			obj: deployment("shop", 1),
+		},
+		{
+			name: "matched namespace selector",
+			objs: []*unstructured.Unstructured{
+				namespace("shop", map[string]interface{}{"env": "prod"}),
+				requiredLabelsTemplate,
+				requiredLabels(map[string]interface{}{
+					"match": map[string]interface{}{
+						"namespaceSelector": map[string]interface{}{
+							"matchLabels": map[string]interface{}{"env": "prod"},
+						},
+					},
+				}),
+			},
+			obj: deployment("shop", 1),
+			want: []Violation{
+				{Kind: "K8sRequiredLabels", Policy: "must-have-owner", Message: missingOwner},
+			},
+		},
+		{
+			name: "unknown namespace",
+			objs: []*unstructured.Unstructured{
+				requiredLabelsTemplate,
+				requiredLabels(map[string]interface{}{
+					"match": map[string]interface{}{
+						"namespaceSelector": map[string]interface{}{
+							"matchLabels": map[string]interface{}{"env": "prod"},
+						},
+					},
+				}),
+			},
+			obj: deployment("shop", 1),
+		},
+		{
+			name: "inventory",
+			objs: []*unstructured.Unstructured{
+				constraintTemplate("K8sUniqueName", regoTarget(uniqueNameRego)),
+				gatekeeperConstraint("K8sUniqueName", "unique-name", map[string]interface{}{}),
+				deployment("shop", 1),
+				deployment("store", 1),
+			},
+			obj: deployment("shop", 1),
+			want: []Violation{
+				{Kind: "K8sUniqueName", Policy: "unique-name", Message: "Deployment frontend is also declared in the namespace store"},
+			},
+		},
+		{
+			name: "missing template warns",
+			objs: []*unstructured.Unstructured{requiredLabels(nil)},
+			obj:  deployment("shop", 1),
+			want: []Violation{
+				{Kind: "K8sRequiredLabels", Policy: "must-have-owner", Warning: true,
+					Message: "the constraint could not be evaluated: no ConstraintTemplate defines the kind K8sRequiredLabels"},
+			},
+		},
+		{
+			name: "template without Rego warns",
+			objs: []*unstructured.Unstructured{
+				constraintTemplate("K8sRequiredLabels", map[string]interface{}{"target": admissionTarget}),
+				requiredLabels(nil),
+			},
+			obj: deployment("shop", 1),
+			want: []Violation{
+				{Kind: "K8sRequiredLabels", Policy: "must-have-owner", Warning: true,
+					Message: "the constraint could not be evaluated: ConstraintTemplate k8srequiredlabels: only the templates with Rego are supported"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSet(tc.objs)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, withoutSources(s.Evaluate(tc.obj)))
+		})
+	}
+}
+
+func TestSet_EvaluateGatekeeperInvalidRego(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rego    string
+		wantErr string
+	}{
+		{
+			name:    "syntax error",
+			rego:    "package k8srequiredlabels\n\nviolation[{\"msg\": msg}] {",
+			wantErr: "invalid Rego",
+		},
+		{
+			name:    "unsafe built-in",
+			rego:    "package k8srequiredlabels\n\nviolation[{\"msg\": msg}] {\n\thttp.send({\"method\": \"get\", \"url\": \"https://example.com\"})\n\tmsg := \"denied\"\n}\n",
+			wantErr: "unsafe built-in function calls in expression: http.send",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			template := constraintTemplate("K8sRequiredLabels", regoTarget(tc.rego))
+			constraint := requiredLabels(nil)
+			s, err := NewSet([]*unstructured.Unstructured{template, constraint})
+			require.NoError(t, err)
+			violations := s.Evaluate(deployment("shop", 1))
+			require.Len(t, violations, 1)
+			assert.True(t, violations[0].Warning)
+			assert.Contains(t, violations[0].Message, tc.wantErr)
+			assert.Equal(t, `K8sRequiredLabels "must-have-owner"`, violations[0].PolicyName())
+			assert.Equal(t, []*unstructured.Unstructured{constraint, template}, violations[0].Sources)
+		})
+	}
+}
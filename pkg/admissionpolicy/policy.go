@@ -0,0 +1,619 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admissionpolicy evaluates the ValidatingAdmissionPolicies and the
+// Gatekeeper constraints declared in the source of truth or on the cluster
+// against the declared objects, so that the objects denied by a policy are
+// reported before they are applied, instead of being denied by the API server
+// halfway through an apply.
+//
+// The CEL expressions of the ValidatingAdmissionPolicies are evaluated with
+// cel-go and the Kubernetes CEL libraries, and the Rego of the Gatekeeper
+// ConstraintTemplates with OPA. Policies with parameters (paramKind) are not
+// evaluated, since their parameters are not known before apply.
+package admissionpolicy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+)
+
+const (
+	// Group is the API group of the admission policies.
+	Group = "admissionregistration.k8s.io"
+	// PolicyKind is the kind of the admission policies.
+	PolicyKind = "ValidatingAdmissionPolicy"
+	// BindingKind is the kind of the bindings of the admission policies.
+	BindingKind = "ValidatingAdmissionPolicyBinding"
+)
+
+// actionDeny is the validation action of the bindings which deny the objects
+// violating their policy. The other actions, Warn and Audit, do not.
+const actionDeny = "Deny"
+
+// policySpec is the part of the spec of a ValidatingAdmissionPolicy used to
+// evaluate it. It is common to all the versions of the API.
+type policySpec struct {
+	ParamKind        *struct{}        `json:"paramKind,omitempty"`
+	MatchConstraints *matchResources  `json:"matchConstraints,omitempty"`
+	Validations      []validation     `json:"validations,omitempty"`
+	FailurePolicy    string           `json:"failurePolicy,omitempty"`
+	MatchConditions  []namedCondition `json:"matchConditions,omitempty"`
+	Variables        []namedCondition `json:"variables,omitempty"`
+}
+
+type validation struct {
+	Expression        string `json:"expression"`
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+}
+
+// namedCondition is a match condition, or a variable, of a policy.
+type namedCondition struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+type matchResources struct {
+	NamespaceSelector    *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	ObjectSelector       *metav1.LabelSelector `json:"objectSelector,omitempty"`
+	ResourceRules        []resourceRule        `json:"resourceRules,omitempty"`
+	ExcludeResourceRules []resourceRule        `json:"excludeResourceRules,omitempty"`
+	MatchPolicy          string                `json:"matchPolicy,omitempty"`
+}
+
+type resourceRule struct {
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	Operations    []string `json:"operations,omitempty"`
+	APIGroups     []string `json:"apiGroups,omitempty"`
+	APIVersions   []string `json:"apiVersions,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+}
+
+// bindingSpec is the part of the spec of a ValidatingAdmissionPolicyBinding
+// used to evaluate its policy.
+type bindingSpec struct {
+	PolicyName        string          `json:"policyName"`
+	ParamRef          *struct{}       `json:"paramRef,omitempty"`
+	MatchResources    *matchResources `json:"matchResources,omitempty"`
+	ValidationActions []string        `json:"validationActions,omitempty"`
+}
+
+type policy struct {
+	name          string
+	obj           *unstructured.Unstructured
+	match         *matchResources
+	failurePolicy string
+	conditions    []compiled
+	variables     []namedVariable
+	validations   []compiledValidation
+	// err is the error which prevents evaluating the policy, if any.
+	err error
+}
+
+type namedVariable struct {
+	name string
+	expr compiled
+}
+
+type compiledValidation struct {
+	expr    compiled
+	message string
+	// messageExpr is nil if the validation has no message expression.
+	messageExpr *compiled
+}
+
+type binding struct {
+	name    string
+	obj     *unstructured.Unstructured
+	policy  string
+	match   *matchResources
+	actions []string
+}
+
+// Set is a set of admission policies and their bindings, and of Gatekeeper
+// constraints and their templates.
+type Set struct {
+	policies map[string]*policy
+	bindings []*binding
+	// templates are the Gatekeeper ConstraintTemplates, by constraint kind.
+	templates   map[string]*template
+	constraints []*constraint
+	// namespaces are the labels of the Namespaces, by name.
+	namespaces map[string]map[string]string
+}
+
+// NewSet returns the set of the ValidatingAdmissionPolicies, the
+// ValidatingAdmissionPolicyBindings, the Gatekeeper ConstraintTemplates and
+// the Gatekeeper constraints of the objects. The labels of the Namespaces of
+// the objects are used to match the namespace selectors of the policies. The
+// later objects replace the earlier ones with the same name, so the objects
+// declared in the source of truth are passed after the objects of the
+// cluster. The other objects are the inventory of the Gatekeeper constraints.
+func NewSet(objs []*unstructured.Unstructured) (*Set, error) {
+	s := &Set{
+		policies:   make(map[string]*policy),
+		templates:  make(map[string]*template),
+		namespaces: make(map[string]map[string]string),
+	}
+	bindings := make(map[string]*binding)
+	constraints := make(map[string]*unstructured.Unstructured)
+	var templates []*unstructured.Unstructured
+	inventory := newInventory()
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		switch {
+		case gvk.Group == TemplateGroup && gvk.Kind == TemplateKind:
+			templates = append(templates, obj)
+			continue
+		case gvk.Group == ConstraintGroup:
+			constraints[gvk.Kind+"/"+obj.GetName()] = obj
+			continue
+		case gvk.Group == "" && gvk.Kind == "Namespace":
+			s.namespaces[obj.GetName()] = obj.GetLabels()
+		case gvk.Group == Group && gvk.Kind == PolicyKind:
+			p, err := newPolicy(obj)
+			if err != nil {
+				return nil, err
+			}
+			s.policies[p.name] = p
+		case gvk.Group == Group && gvk.Kind == BindingKind:
+			b, err := newBinding(obj)
+			if err != nil {
+				return nil, err
+			}
+			if b != nil {
+				bindings[b.name] = b
+			}
+		}
+		inventory.add(obj)
+	}
+	for _, name := range sortedKeys(bindings) {
+		s.bindings = append(s.bindings, bindings[name])
+	}
+
+	for _, obj := range templates {
+		t, err := newTemplate(obj)
+		if err != nil {
+			return nil, err
+		}
+		s.templates[t.kind] = t
+	}
+	if len(s.templates) > 0 {
+		s.prepareTemplates(inventory)
+	}
+	for _, key := range sortedKeys(constraints) {
+		c, err := newConstraint(constraints[key])
+		if err != nil {
+			return nil, err
+		}
+		s.constraints = append(s.constraints, c)
+	}
+	return s, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateEnforcement returns an error if the policy enforcement of a RootSync
+// or RepoSync is invalid. An empty enforcement defaults to deny.
+func ValidateEnforcement(enforcement configsync.PolicyEnforcement) error {
+	switch enforcement {
+	case "", configsync.PolicyEnforcementDeny, configsync.PolicyEnforcementWarn, configsync.PolicyEnforcementDisabled:
+		return nil
+	default:
+		return fmt.Errorf("invalid policy enforcement %q: must be one of %q, %q, or %q", enforcement,
+			configsync.PolicyEnforcementDeny, configsync.PolicyEnforcementWarn, configsync.PolicyEnforcementDisabled)
+	}
+}
+
+// Empty returns true if the set has no bound policy and no constraint.
+func (s *Set) Empty() bool {
+	return s == nil || (len(s.bindings) == 0 && len(s.constraints) == 0)
+}
+
+func newPolicy(obj *unstructured.Unstructured) (*policy, error) {
+	spec := &policySpec{}
+	if err := fromUnstructured(obj, spec); err != nil {
+		return nil, err
+	}
+	p := &policy{
+		name:          obj.GetName(),
+		obj:           obj,
+		match:         spec.MatchConstraints,
+		failurePolicy: spec.FailurePolicy,
+	}
+	if spec.ParamKind != nil {
+		p.err = fmt.Errorf("policies with parameters are not supported")
+		return p, nil
+	}
+	var errs []string
+	compile := func(field, expr string) compiled {
+		c, err := compile(expr)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", field, err))
+		}
+		return c
+	}
+	for i, c := range spec.MatchConditions {
+		p.conditions = append(p.conditions, compile(fmt.Sprintf("matchConditions[%d]", i), c.Expression))
+	}
+	for i, v := range spec.Variables {
+		p.variables = append(p.variables, namedVariable{
+			name: v.Name,
+			expr: compile(fmt.Sprintf("variables[%d]", i), v.Expression),
+		})
+	}
+	for i, v := range spec.Validations {
+		cv := compiledValidation{
+			expr:    compile(fmt.Sprintf("validations[%d].expression", i), v.Expression),
+			message: v.Message,
+		}
+		if v.MessageExpression != "" {
+			me := compile(fmt.Sprintf("validations[%d].messageExpression", i), v.MessageExpression)
+			cv.messageExpr = &me
+		}
+		p.validations = append(p.validations, cv)
+	}
+	if len(errs) > 0 {
+		p.err = fmt.Errorf("invalid expressions: %s", strings.Join(errs, "; "))
+	}
+	return p, nil
+}
+
+// newBinding returns the binding, or nil if it binds a policy with parameters.
+func newBinding(obj *unstructured.Unstructured) (*binding, error) {
+	spec := &bindingSpec{}
+	if err := fromUnstructured(obj, spec); err != nil {
+		return nil, err
+	}
+	if spec.ParamRef != nil {
+		klog.V(3).Infof("Skipping %s %s: bindings with parameters are not supported", BindingKind, obj.GetName())
+		return nil, nil
+	}
+	actions := spec.ValidationActions
+	if len(actions) == 0 {
+		// The v1alpha1 API has no validation actions, and always denies.
+		actions = []string{actionDeny}
+	}
+	return &binding{
+		name:    obj.GetName(),
+		obj:     obj,
+		policy:  spec.PolicyName,
+		match:   spec.MatchResources,
+		actions: actions,
+	}, nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured, spec interface{}) error {
+	m, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return fmt.Errorf("invalid %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, spec); err != nil {
+		return fmt.Errorf("invalid %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// Violation is an object denied by a policy.
+type Violation struct {
+	// Kind is the kind of the policy: ValidatingAdmissionPolicy, or the kind
+	// of the Gatekeeper constraint.
+	Kind string
+	// Policy is the name of the policy, or of the Gatekeeper constraint.
+	Policy string
+	// Binding is the name of the binding of the policy. It is empty for the
+	// Gatekeeper constraints.
+	Binding string
+	// Message describes the violation.
+	Message string
+	// Warning is true if the binding only warns about, or audits, the
+	// violations of the policy, or if the policy could not be evaluated.
+	// Otherwise the API server denies the object.
+	Warning bool
+	// Sources are the objects of the policy and of its binding, or of the
+	// constraint and of its template.
+	Sources []*unstructured.Unstructured
+}
+
+// PolicyName returns the kind and the name of the violated policy, and of its
+// binding if any.
+func (v Violation) PolicyName() string {
+	if v.Binding == "" {
+		return fmt.Sprintf("%s %q", v.Kind, v.Policy)
+	}
+	return fmt.Sprintf("%s %q, bound by %q", v.Kind, v.Policy, v.Binding)
+}
+
+// Evaluate returns the violations of the policies by the object.
+func (s *Set) Evaluate(obj *unstructured.Unstructured) []Violation {
+	group := obj.GroupVersionKind().Group
+	if s.Empty() || group == Group || group == TemplateGroup || group == ConstraintGroup {
+		// The admission policies do not apply to the admission policies and
+		// bindings themselves, nor Gatekeeper to its constraints.
+		return nil
+	}
+	var namespace *unstructured.Unstructured
+	nsLabels, nsFound := s.namespaceLabels(obj)
+	if nsFound && obj.GetNamespace() != "" {
+		namespace = &unstructured.Unstructured{}
+		namespace.SetAPIVersion("v1")
+		namespace.SetKind("Namespace")
+		namespace.SetName(obj.GetNamespace())
+		namespace.SetLabels(nsLabels)
+	}
+
+	var violations []Violation
+	for _, b := range s.bindings {
+		p, found := s.policies[b.policy]
+		if !found {
+			continue
+		}
+		if !p.matches(obj, nsLabels, nsFound) || (b.match != nil && !matches(b.match, obj, nsLabels, nsFound)) {
+			continue
+		}
+		violations = append(violations, p.evaluate(obj, namespace, b)...)
+	}
+	for _, c := range s.constraints {
+		if !c.matches(obj, nsLabels, nsFound) {
+			continue
+		}
+		violations = append(violations, c.evaluate(obj, s.templates[c.kind])...)
+	}
+	return violations
+}
+
+// namespaceLabels returns the labels of the Namespace of the object, or of
+// the object itself if it is a Namespace.
+func (s *Set) namespaceLabels(obj *unstructured.Unstructured) (map[string]string, bool) {
+	if obj.GroupVersionKind().Group == "" && obj.GetKind() == "Namespace" {
+		return obj.GetLabels(), true
+	}
+	nsLabels, found := s.namespaces[obj.GetNamespace()]
+	return nsLabels, found
+}
+
+func (p *policy) matches(obj *unstructured.Unstructured, nsLabels map[string]string, nsFound bool) bool {
+	if p.match == nil {
+		// The API server requires the match constraints.
+		return false
+	}
+	return matches(p.match, obj, nsLabels, nsFound)
+}
+
+// matches returns true if the resource rules and the selectors match the
+// object. The object is matched as if it is created or updated.
+func matches(m *matchResources, obj *unstructured.Unstructured, nsLabels map[string]string, nsFound bool) bool {
+	if !selectorMatches(m.ObjectSelector, obj.GetLabels()) {
+		return false
+	}
+	namespaced := obj.GetNamespace() != ""
+	isNamespace := obj.GroupVersionKind().Group == "" && obj.GetKind() == "Namespace"
+	if (namespaced || isNamespace) && m.NamespaceSelector != nil && !isEmptySelector(m.NamespaceSelector) {
+		if !nsFound {
+			// The labels of the Namespace are unknown, so the policy may or
+			// may not apply to the object.
+			klog.V(3).Infof("Skipping the namespace selector of a policy for %s %s/%s: the labels of its Namespace are unknown",
+				obj.GetKind(), obj.GetNamespace(), obj.GetName())
+			return false
+		}
+		if !selectorMatches(m.NamespaceSelector, nsLabels) {
+			return false
+		}
+	}
+	// The resource rules of a binding are optional, and only narrow down the
+	// resources matched by its policy.
+	if len(m.ResourceRules) > 0 && !rulesMatch(m.ResourceRules, obj, m.MatchPolicy) {
+		return false
+	}
+	return len(m.ExcludeResourceRules) == 0 || !rulesMatch(m.ExcludeResourceRules, obj, m.MatchPolicy)
+}
+
+func isEmptySelector(s *metav1.LabelSelector) bool {
+	return len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0
+}
+
+func selectorMatches(s *metav1.LabelSelector, objLabels map[string]string) bool {
+	if s == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(s)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(objLabels))
+}
+
+// rulesMatch returns true if any rule matches the object. The resource of the
+// object is guessed from its kind, since the policies are evaluated without
+// the discovery of the cluster.
+func rulesMatch(rules []resourceRule, obj *unstructured.Unstructured, matchPolicy string) bool {
+	gvk := obj.GroupVersionKind()
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+	for _, r := range rules {
+		if !containsOrStar(r.Operations, "CREATE") && !containsOrStar(r.Operations, "UPDATE") {
+			continue
+		}
+		if !containsOrStar(r.APIGroups, gvk.Group) {
+			continue
+		}
+		// With the default Equivalent match policy, the API server matches
+		// the requests of all the versions of a resource.
+		if matchPolicy == "Exact" && !containsOrStar(r.APIVersions, gvk.Version) {
+			continue
+		}
+		if !containsOrStar(r.Resources, plural.Resource) && !contains(r.Resources, "*/*") {
+			continue
+		}
+		if len(r.ResourceNames) > 0 && !contains(r.ResourceNames, obj.GetName()) {
+			continue
+		}
+		switch r.Scope {
+		case "Cluster":
+			if obj.GetNamespace() != "" {
+				continue
+			}
+		case "Namespaced":
+			if obj.GetNamespace() == "" {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func containsOrStar(items []string, item string) bool {
+	return contains(items, "*") || contains(items, item)
+}
+
+// evaluate returns the violations of the policy by the object, for the
+// binding.
+func (p *policy) evaluate(obj, namespace *unstructured.Unstructured, b *binding) []Violation {
+	warning := !contains(b.actions, actionDeny)
+	violation := func(message string, warning bool) Violation {
+		return Violation{
+			Kind:    PolicyKind,
+			Policy:  p.name,
+			Binding: b.name,
+			Message: message,
+			Warning: warning,
+			Sources: []*unstructured.Unstructured{p.obj, b.obj},
+		}
+	}
+	// evalError returns the violation for an expression which could not be
+	// evaluated. The API server denies the object if the failure policy is
+	// Fail, but the expression may only fail because the object is evaluated
+	// before it is defaulted by the API server, so it is reported as a
+	// warning.
+	evalError := func(err error) []Violation {
+		if p.failurePolicy == "Ignore" {
+			return nil
+		}
+		return []Violation{violation(fmt.Sprintf("the policy could not be evaluated: %v", err), true)}
+	}
+	if p.err != nil {
+		return evalError(p.err)
+	}
+
+	vars := p.activation(obj, namespace)
+	for _, c := range p.conditions {
+		v, err := c.eval(vars)
+		if err != nil {
+			return evalError(fmt.Errorf("matchCondition %q: %w", c.source, err))
+		}
+		if b, ok := v.(bool); !ok {
+			return evalError(fmt.Errorf("matchCondition %q: the result must be a bool, not %s", c.source, typeName(v)))
+		} else if !b {
+			return nil
+		}
+	}
+
+	var violations []Violation
+	for _, v := range p.validations {
+		result, err := v.expr.eval(vars)
+		if err != nil {
+			violations = append(violations, evalError(fmt.Errorf("expression %q: %w", v.expr.source, err))...)
+			continue
+		}
+		valid, ok := result.(bool)
+		if !ok {
+			violations = append(violations, evalError(fmt.Errorf("expression %q: the result must be a bool, not %s", v.expr.source, typeName(result)))...)
+			continue
+		}
+		if !valid {
+			violations = append(violations, violation(v.messageFor(vars), warning))
+		}
+	}
+	return violations
+}
+
+// messageFor returns the message of a failed validation.
+func (v compiledValidation) messageFor(vars map[string]interface{}) string {
+	if v.messageExpr != nil {
+		if m, err := v.messageExpr.eval(vars); err == nil {
+			if s, ok := m.(string); ok && strings.TrimSpace(s) != "" {
+				return s
+			}
+		}
+	}
+	if v.message != "" {
+		return v.message
+	}
+	return fmt.Sprintf("failed expression: %s", v.expr.source)
+}
+
+// activation returns the variables of the expressions, for an object created
+// or updated by the reconciler. The variables of the policy are evaluated in
+// order, so that a variable may use the previous ones. A variable which fails
+// to evaluate is left unset, so the expressions using it fail.
+func (p *policy) activation(obj, namespace *unstructured.Unstructured) map[string]interface{} {
+	gvk := obj.GroupVersionKind()
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+	var namespaceObject interface{}
+	if namespace != nil {
+		namespaceObject = namespace.Object
+	}
+	variables := make(map[string]interface{}, len(p.variables))
+	vars := map[string]interface{}{
+		"object":          obj.Object,
+		"oldObject":       nil,
+		"params":          nil,
+		"namespaceObject": namespaceObject,
+		"request": map[string]interface{}{
+			"kind":      map[string]interface{}{"group": gvk.Group, "version": gvk.Version, "kind": gvk.Kind},
+			"resource":  map[string]interface{}{"group": plural.Group, "version": plural.Version, "resource": plural.Resource},
+			"name":      obj.GetName(),
+			"namespace": obj.GetNamespace(),
+			"operation": "CREATE",
+			"dryRun":    false,
+		},
+		"variables": variables,
+	}
+	for _, v := range p.variables {
+		value, err := v.expr.eval(vars)
+		if err != nil {
+			klog.V(3).Infof("Failed to evaluate the variable %q of the %s %s: %v", v.name, PolicyKind, p.name, err)
+			continue
+		}
+		variables[v.name] = value
+	}
+	return vars
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
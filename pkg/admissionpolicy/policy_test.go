@@ -0,0 +1,329 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func replicasPolicy(version string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Group + "/" + version,
+		"kind":       PolicyKind,
+		"metadata":   map[string]interface{}{"name": "max-replicas"},
+		"spec": map[string]interface{}{
+			"matchConstraints": map[string]interface{}{
+				"resourceRules": []interface{}{
+					map[string]interface{}{
+						"apiGroups":   []interface{}{"apps"},
+						"apiVersions": []interface{}{"v1"},
+						"operations":  []interface{}{"CREATE", "UPDATE"},
+						"resources":   []interface{}{"deployments"},
+					},
+				},
+			},
+			"validations": []interface{}{
+				map[string]interface{}{
+					"expression": "object.spec.replicas <= 5",
+					"message":    "too many replicas",
+				},
+			},
+		},
+	}}
+	for k, v := range spec {
+		obj.Object["spec"].(map[string]interface{})[k] = v
+	}
+	return obj
+}
+
+func policyBinding(version string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Group + "/" + version,
+		"kind":       BindingKind,
+		"metadata":   map[string]interface{}{"name": "max-replicas-binding"},
+		"spec":       map[string]interface{}{"policyName": "max-replicas"},
+	}}
+	for k, v := range spec {
+		obj.Object["spec"].(map[string]interface{})[k] = v
+	}
+	return obj
+}
+
+func namespace(name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": name, "labels": labels},
+	}}
+}
+
+func deployment(namespace string, replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "frontend", "namespace": namespace},
+		"spec":       map[string]interface{}{"replicas": replicas},
+	}}
+}
+
+func TestSet_Evaluate(t *testing.T) {
+	prodSelector := map[string]interface{}{
+		"namespaceSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"env": "prod"},
+		},
+	}
+	testCases := []struct {
+		name string
+		objs []*unstructured.Unstructured
+		obj  *unstructured.Unstructured
+		want []Violation
+	}{
+		{
+			name: "denied by a v1 policy",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: deployment("shop", 10),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Message: "too many replicas"},
+			},
+		},
+		{
+			name: "allowed by a v1 policy",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: deployment("shop", 2),
+		},
+		{
+			name: "v1alpha1 binding denies by default",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1alpha1", nil),
+				policyBinding("v1alpha1", nil),
+			},
+			obj: deployment("shop", 10),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Message: "too many replicas"},
+			},
+		},
+		{
+			name: "warn action",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Warn", "Audit"}}),
+			},
+			obj: deployment("shop", 10),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Message: "too many replicas", Warning: true},
+			},
+		},
+		{
+			name: "policy without binding",
+			objs: []*unstructured.Unstructured{replicasPolicy("v1", nil)},
+			obj:  deployment("shop", 10),
+		},
+		{
+			name: "binding with parameters",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{
+					"validationActions": []interface{}{"Deny"},
+					"paramRef":          map[string]interface{}{"name": "limits"},
+				}),
+			},
+			obj: deployment("shop", 10),
+		},
+		{
+			name: "unmatched resource",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"metadata":   map[string]interface{}{"name": "db", "namespace": "shop"},
+				"spec":       map[string]interface{}{"replicas": int64(10)},
+			}},
+		},
+		{
+			name: "matched namespace selector",
+			objs: []*unstructured.Unstructured{
+				namespace("shop", map[string]interface{}{"env": "prod"}),
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{
+					"validationActions": []interface{}{"Deny"},
+					"matchResources":    prodSelector,
+				}),
+			},
+			obj: deployment("shop", 10),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Message: "too many replicas"},
+			},
+		},
+		{
+			name: "unmatched namespace selector",
+			objs: []*unstructured.Unstructured{
+				namespace("shop", map[string]interface{}{"env": "dev"}),
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{
+					"validationActions": []interface{}{"Deny"},
+					"matchResources":    prodSelector,
+				}),
+			},
+			obj: deployment("shop", 10),
+		},
+		{
+			name: "unknown namespace",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", nil),
+				policyBinding("v1", map[string]interface{}{
+					"validationActions": []interface{}{"Deny"},
+					"matchResources":    prodSelector,
+				}),
+			},
+			obj: deployment("shop", 10),
+		},
+		{
+			name: "false match condition",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", map[string]interface{}{
+					"matchConditions": []interface{}{
+						map[string]interface{}{"name": "not-frontend", "expression": "object.metadata.name != 'frontend'"},
+					},
+				}),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: deployment("shop", 10),
+		},
+		{
+			name: "variables and message expression",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", map[string]interface{}{
+					"variables": []interface{}{
+						map[string]interface{}{"name": "limit", "expression": "namespaceObject.metadata.labels.env == 'prod' ? 5 : 2"},
+					},
+					"validations": []interface{}{
+						map[string]interface{}{
+							"expression":        "object.spec.replicas <= variables.limit",
+							"messageExpression": "'replicas must be at most ' + string(variables.limit)",
+						},
+					},
+				}),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+				namespace("shop", map[string]interface{}{"env": "dev"}),
+			},
+			obj: deployment("shop", 3),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Message: "replicas must be at most 2"},
+			},
+		},
+		{
+			name: "Kubernetes CEL libraries",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", map[string]interface{}{
+					"validations": []interface{}{
+						map[string]interface{}{
+							"expression": "object.metadata.name.matches('^[a-z]+$') && object.metadata.name.upperAscii() == 'FRONTEND' && [1, 2, 3].isSorted()",
+						},
+						map[string]interface{}{
+							"expression": "url('https://' + object.metadata.name + '.example.com').getScheme() == 'http'",
+							"message":    "the URL must be HTTP",
+						},
+					},
+				}),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: deployment("shop", 3),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Message: "the URL must be HTTP"},
+			},
+		},
+		{
+			name: "invalid expression warns",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", map[string]interface{}{
+					"validations": []interface{}{
+						map[string]interface{}{"expression": "object.spec.replicas"},
+						map[string]interface{}{"expression": "object.spec.memory == '1Gi'"},
+					},
+				}),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: deployment("shop", 3),
+			want: []Violation{
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Warning: true,
+					Message: `the policy could not be evaluated: expression "object.spec.replicas": the result must be a bool, not int`},
+				{Kind: PolicyKind, Policy: "max-replicas", Binding: "max-replicas-binding", Warning: true,
+					Message: `the policy could not be evaluated: expression "object.spec.memory == '1Gi'": no such key: memory`},
+			},
+		},
+		{
+			name: "invalid expression ignored",
+			objs: []*unstructured.Unstructured{
+				replicasPolicy("v1", map[string]interface{}{
+					"failurePolicy": "Ignore",
+					"validations": []interface{}{
+						map[string]interface{}{"expression": "object.spec.memory == '1Gi'"},
+					},
+				}),
+				policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+			},
+			obj: deployment("shop", 3),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSet(tc.objs)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, withoutSources(s.Evaluate(tc.obj)))
+		})
+	}
+}
+
+// withoutSources returns the violations without their source objects, which
+// are checked separately.
+func withoutSources(violations []Violation) []Violation {
+	for i := range violations {
+		violations[i].Sources = nil
+	}
+	return violations
+}
+
+func TestNewSet_InvalidExpression(t *testing.T) {
+	s, err := NewSet([]*unstructured.Unstructured{
+		replicasPolicy("v1", map[string]interface{}{
+			"validations": []interface{}{
+				map[string]interface{}{"expression": "object.spec.replicas <="},
+			},
+		}),
+		policyBinding("v1", map[string]interface{}{"validationActions": []interface{}{"Deny"}}),
+	})
+	require.NoError(t, err)
+	violations := s.Evaluate(deployment("shop", 3))
+	require.Len(t, violations, 1)
+	assert.True(t, violations[0].Warning)
+	assert.Contains(t, violations[0].Message, "the policy could not be evaluated: invalid expressions: validations[0].expression: ERROR")
+	assert.Equal(t, `ValidatingAdmissionPolicy "max-replicas", bound by "max-replicas-binding"`, violations[0].PolicyName())
+	require.Len(t, violations[0].Sources, 2)
+	assert.Equal(t, PolicyKind, violations[0].Sources[0].GetKind())
+	assert.Equal(t, BindingKind, violations[0].Sources[1].GetKind())
+}
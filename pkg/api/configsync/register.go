@@ -40,6 +40,8 @@ const (
 	RepoSyncKind = "RepoSync"
 	// RootSyncKind is the kind of the RepoSync resource.
 	RootSyncKind = "RootSync"
+	// RepoSyncPolicyKind is the kind of the RepoSyncPolicy resource.
+	RepoSyncPolicyKind = "RepoSyncPolicy"
 )
 
 const (
@@ -116,3 +118,37 @@ const (
 	// declared to be created by the reconciler.
 	NamespaceStrategyExplicit NamespaceStrategy = "explicit"
 )
+
+// ConflictPolicy specifies how the reconciler resolves the conflicts between
+// the declared fields of a managed object and the fields owned by other field
+// managers, like controllers and admission webhooks.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyForce indicates that the reconciler should take over the
+	// conflicting fields, except the fields of well-known controllers. Default
+	ConflictPolicyForce ConflictPolicy = "force"
+	// ConflictPolicyRespect indicates that the reconciler should leave the
+	// conflicting fields to the other field managers, and report them.
+	ConflictPolicyRespect ConflictPolicy = "respect"
+	// ConflictPolicyFail indicates that the reconciler should not apply objects
+	// with conflicting fields, and report a conflict error.
+	ConflictPolicyFail ConflictPolicy = "fail"
+)
+
+// PolicyEnforcement specifies how the reconciler reports the declared objects
+// which violate the ValidatingAdmissionPolicies or the Gatekeeper constraints.
+type PolicyEnforcement string
+
+const (
+	// PolicyEnforcementDeny indicates that the violations of the policies
+	// which deny the objects should block the sync, and the other violations
+	// should be reported as warnings. Default
+	PolicyEnforcementDeny PolicyEnforcement = "deny"
+	// PolicyEnforcementWarn indicates that all the violations should be
+	// reported as warnings, which do not block the sync.
+	PolicyEnforcementWarn PolicyEnforcement = "warn"
+	// PolicyEnforcementDisabled indicates that the policies should not be
+	// evaluated before apply.
+	PolicyEnforcementDisabled PolicyEnforcement = "disabled"
+)
@@ -40,6 +40,20 @@ type Git struct {
 	// +optional
 	Dir string `json:"dir,omitempty"`
 
+	// include is a list of patterns, in the gitignore format, of the files to
+	// sync from the directory. If set, only the matching files are synced.
+	// Patterns are relative to `dir`. Applied to the source files before
+	// rendering.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// exclude is a list of patterns, in the gitignore format, of the files not
+	// to sync from the directory, in addition to the files listed in the
+	// `.configsyncignore` file of the directory. Patterns are relative to
+	// `dir`. Applied to the source files before rendering.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
 	// period is the time duration between consecutive syncs. Default: 15s.
 	// Note to developers that customers specify this value using
 	// string (https://golang.org/pkg/time/#Duration.String) like "3s"
@@ -89,6 +103,50 @@ type Git struct {
 	// +nullable
 	// +optional
 	CACertSecretRef *SecretReference `json:"caCertSecretRef,omitempty"`
+
+	// verification specifies the signatures which the synced commits must
+	// have. If unset, the signatures of the commits are not verified.
+	// +nullable
+	// +optional
+	Verification *GitVerification `json:"verification,omitempty"`
+
+	// commitStatus specifies where the outcome of syncing each commit is
+	// reported. If unset, the outcome is not reported.
+	// +nullable
+	// +optional
+	CommitStatus *GitCommitStatus `json:"commitStatus,omitempty"`
+}
+
+// GitVerificationPolicy specifies which commits must be signed by a trusted
+// key.
+type GitVerificationPolicy string
+
+const (
+	// HeadSigned requires the synced commit to be signed by a trusted key.
+	HeadSigned GitVerificationPolicy = "HeadSigned"
+	// AllCommitsSigned requires all the commits since the last synced commit
+	// to be signed by a trusted key.
+	AllCommitsSigned GitVerificationPolicy = "AllCommitsSigned"
+)
+
+// GitVerification specifies how the signatures of the synced commits are
+// verified.
+type GitVerification struct {
+	// secretRef is the secret with the trusted public keys. Each value of the
+	// secret is either an ASCII-armored GPG public key block, or SSH public
+	// keys in the allowed_signers format. For RepoSync resources, the secret
+	// must be created in the same namespace as the RepoSync. For RootSync
+	// resource, the secret must be created in the config-management-system
+	// namespace.
+	SecretRef *SecretReference `json:"secretRef"`
+
+	// policy specifies which commits must be signed by a trusted key. Must be
+	// `HeadSigned` or `AllCommitsSigned`. With `AllCommitsSigned`, the
+	// commits since the last synced commit must be in the history fetched by
+	// git-sync. Default: `HeadSigned`.
+	// +kubebuilder:validation:Enum=HeadSigned;AllCommitsSigned
+	// +optional
+	Policy GitVerificationPolicy `json:"policy,omitempty"`
 }
 
 // SecretReference contains the reference to the secret used to connect to
@@ -98,3 +156,49 @@ type SecretReference struct {
 	// +optional
 	Name string `json:"name,omitempty"`
 }
+
+// GitProvider is the API which the outcome of syncing a commit is reported to.
+type GitProvider string
+
+const (
+	// GitHubProvider reports to the GitHub commit status API.
+	GitHubProvider GitProvider = "github"
+	// GitLabProvider reports to the GitLab commit status API.
+	GitLabProvider GitProvider = "gitlab"
+	// WebhookProvider posts the reports to a generic webhook.
+	WebhookProvider GitProvider = "webhook"
+)
+
+// GitCommitStatus specifies where the outcome of syncing each commit is
+// reported.
+type GitCommitStatus struct {
+	// provider is the API which the outcome is reported to. Must be `github`,
+	// `gitlab` or `webhook`. Required.
+	// +kubebuilder:validation:Enum=github;gitlab;webhook
+	Provider GitProvider `json:"provider"`
+
+	// url is the base URL of the GitHub or GitLab API, or the URL of the
+	// webhook. Default: `https://api.github.com` for `github`, and
+	// `https://gitlab.com` for `gitlab`. Required for `webhook`.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// project is the GitHub repository as `owner/repo`, or the GitLab project
+	// ID or path. Default: the path of the repo URL.
+	// +optional
+	Project string `json:"project,omitempty"`
+
+	// secretRef is the secret with the API token in a key named "token". It is
+	// optional for `webhook`. For RepoSync resources, the secret must be
+	// created in the same namespace as the RepoSync. For RootSync resource,
+	// the secret must be created in the config-management-system namespace.
+	// +nullable
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// maxErrors is the maximum number of errors included in a report.
+	// Default: 5.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxErrors int `json:"maxErrors,omitempty"`
+}
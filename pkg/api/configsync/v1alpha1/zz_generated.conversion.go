@@ -73,6 +73,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*GitCommitStatus)(nil), (*v1beta1.GitCommitStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_GitCommitStatus_To_v1beta1_GitCommitStatus(a.(*GitCommitStatus), b.(*v1beta1.GitCommitStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.GitCommitStatus)(nil), (*GitCommitStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_GitCommitStatus_To_v1alpha1_GitCommitStatus(a.(*v1beta1.GitCommitStatus), b.(*GitCommitStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*GitStatus)(nil), (*v1beta1.GitStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_GitStatus_To_v1beta1_GitStatus(a.(*GitStatus), b.(*v1beta1.GitStatus), scope)
 	}); err != nil {
@@ -83,6 +93,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*GitVerification)(nil), (*v1beta1.GitVerification)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_GitVerification_To_v1beta1_GitVerification(a.(*GitVerification), b.(*v1beta1.GitVerification), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.GitVerification)(nil), (*GitVerification)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_GitVerification_To_v1alpha1_GitVerification(a.(*v1beta1.GitVerification), b.(*GitVerification), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*HelmBase)(nil), (*v1beta1.HelmBase)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_HelmBase_To_v1beta1_HelmBase(a.(*HelmBase), b.(*v1beta1.HelmBase), scope)
 	}); err != nil {
@@ -113,6 +133,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*IgnoreDifference)(nil), (*v1beta1.IgnoreDifference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_IgnoreDifference_To_v1beta1_IgnoreDifference(a.(*IgnoreDifference), b.(*v1beta1.IgnoreDifference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.IgnoreDifference)(nil), (*IgnoreDifference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_IgnoreDifference_To_v1alpha1_IgnoreDifference(a.(*v1beta1.IgnoreDifference), b.(*IgnoreDifference), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Oci)(nil), (*v1beta1.Oci)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_Oci_To_v1beta1_Oci(a.(*Oci), b.(*v1beta1.Oci), scope)
 	}); err != nil {
@@ -449,6 +479,8 @@ func autoConvert_v1alpha1_Git_To_v1beta1_Git(in *Git, out *v1beta1.Git, s conver
 	out.Branch = in.Branch
 	out.Revision = in.Revision
 	out.Dir = in.Dir
+	out.Include = *(*[]string)(unsafe.Pointer(&in.Include))
+	out.Exclude = *(*[]string)(unsafe.Pointer(&in.Exclude))
 	out.Period = in.Period
 	out.Auth = configsync.AuthType(in.Auth)
 	out.GCPServiceAccountEmail = in.GCPServiceAccountEmail
@@ -456,6 +488,8 @@ func autoConvert_v1alpha1_Git_To_v1beta1_Git(in *Git, out *v1beta1.Git, s conver
 	out.SecretRef = (*v1beta1.SecretReference)(unsafe.Pointer(in.SecretRef))
 	out.NoSSLVerify = in.NoSSLVerify
 	out.CACertSecretRef = (*v1beta1.SecretReference)(unsafe.Pointer(in.CACertSecretRef))
+	out.Verification = (*v1beta1.GitVerification)(unsafe.Pointer(in.Verification))
+	out.CommitStatus = (*v1beta1.GitCommitStatus)(unsafe.Pointer(in.CommitStatus))
 	return nil
 }
 
@@ -469,6 +503,8 @@ func autoConvert_v1beta1_Git_To_v1alpha1_Git(in *v1beta1.Git, out *Git, s conver
 	out.Branch = in.Branch
 	out.Revision = in.Revision
 	out.Dir = in.Dir
+	out.Include = *(*[]string)(unsafe.Pointer(&in.Include))
+	out.Exclude = *(*[]string)(unsafe.Pointer(&in.Exclude))
 	out.Period = in.Period
 	out.Auth = configsync.AuthType(in.Auth)
 	out.GCPServiceAccountEmail = in.GCPServiceAccountEmail
@@ -476,6 +512,8 @@ func autoConvert_v1beta1_Git_To_v1alpha1_Git(in *v1beta1.Git, out *Git, s conver
 	out.SecretRef = (*SecretReference)(unsafe.Pointer(in.SecretRef))
 	out.NoSSLVerify = in.NoSSLVerify
 	out.CACertSecretRef = (*SecretReference)(unsafe.Pointer(in.CACertSecretRef))
+	out.Verification = (*GitVerification)(unsafe.Pointer(in.Verification))
+	out.CommitStatus = (*GitCommitStatus)(unsafe.Pointer(in.CommitStatus))
 	return nil
 }
 
@@ -484,6 +522,34 @@ func Convert_v1beta1_Git_To_v1alpha1_Git(in *v1beta1.Git, out *Git, s conversion
 	return autoConvert_v1beta1_Git_To_v1alpha1_Git(in, out, s)
 }
 
+func autoConvert_v1alpha1_GitCommitStatus_To_v1beta1_GitCommitStatus(in *GitCommitStatus, out *v1beta1.GitCommitStatus, s conversion.Scope) error {
+	out.Provider = v1beta1.GitProvider(in.Provider)
+	out.URL = in.URL
+	out.Project = in.Project
+	out.SecretRef = (*v1beta1.SecretReference)(unsafe.Pointer(in.SecretRef))
+	out.MaxErrors = in.MaxErrors
+	return nil
+}
+
+// Convert_v1alpha1_GitCommitStatus_To_v1beta1_GitCommitStatus is an autogenerated conversion function.
+func Convert_v1alpha1_GitCommitStatus_To_v1beta1_GitCommitStatus(in *GitCommitStatus, out *v1beta1.GitCommitStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_GitCommitStatus_To_v1beta1_GitCommitStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_GitCommitStatus_To_v1alpha1_GitCommitStatus(in *v1beta1.GitCommitStatus, out *GitCommitStatus, s conversion.Scope) error {
+	out.Provider = GitProvider(in.Provider)
+	out.URL = in.URL
+	out.Project = in.Project
+	out.SecretRef = (*SecretReference)(unsafe.Pointer(in.SecretRef))
+	out.MaxErrors = in.MaxErrors
+	return nil
+}
+
+// Convert_v1beta1_GitCommitStatus_To_v1alpha1_GitCommitStatus is an autogenerated conversion function.
+func Convert_v1beta1_GitCommitStatus_To_v1alpha1_GitCommitStatus(in *v1beta1.GitCommitStatus, out *GitCommitStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_GitCommitStatus_To_v1alpha1_GitCommitStatus(in, out, s)
+}
+
 func autoConvert_v1alpha1_GitStatus_To_v1beta1_GitStatus(in *GitStatus, out *v1beta1.GitStatus, s conversion.Scope) error {
 	out.Repo = in.Repo
 	out.Revision = in.Revision
@@ -510,6 +576,28 @@ func Convert_v1beta1_GitStatus_To_v1alpha1_GitStatus(in *v1beta1.GitStatus, out
 	return autoConvert_v1beta1_GitStatus_To_v1alpha1_GitStatus(in, out, s)
 }
 
+func autoConvert_v1alpha1_GitVerification_To_v1beta1_GitVerification(in *GitVerification, out *v1beta1.GitVerification, s conversion.Scope) error {
+	out.SecretRef = (*v1beta1.SecretReference)(unsafe.Pointer(in.SecretRef))
+	out.Policy = v1beta1.GitVerificationPolicy(in.Policy)
+	return nil
+}
+
+// Convert_v1alpha1_GitVerification_To_v1beta1_GitVerification is an autogenerated conversion function.
+func Convert_v1alpha1_GitVerification_To_v1beta1_GitVerification(in *GitVerification, out *v1beta1.GitVerification, s conversion.Scope) error {
+	return autoConvert_v1alpha1_GitVerification_To_v1beta1_GitVerification(in, out, s)
+}
+
+func autoConvert_v1beta1_GitVerification_To_v1alpha1_GitVerification(in *v1beta1.GitVerification, out *GitVerification, s conversion.Scope) error {
+	out.SecretRef = (*SecretReference)(unsafe.Pointer(in.SecretRef))
+	out.Policy = GitVerificationPolicy(in.Policy)
+	return nil
+}
+
+// Convert_v1beta1_GitVerification_To_v1alpha1_GitVerification is an autogenerated conversion function.
+func Convert_v1beta1_GitVerification_To_v1alpha1_GitVerification(in *v1beta1.GitVerification, out *GitVerification, s conversion.Scope) error {
+	return autoConvert_v1beta1_GitVerification_To_v1alpha1_GitVerification(in, out, s)
+}
+
 func autoConvert_v1alpha1_HelmBase_To_v1beta1_HelmBase(in *HelmBase, out *v1beta1.HelmBase, s conversion.Scope) error {
 	out.Repo = in.Repo
 	out.Chart = in.Chart
@@ -616,6 +704,30 @@ func Convert_v1beta1_HelmStatus_To_v1alpha1_HelmStatus(in *v1beta1.HelmStatus, o
 	return autoConvert_v1beta1_HelmStatus_To_v1alpha1_HelmStatus(in, out, s)
 }
 
+func autoConvert_v1alpha1_IgnoreDifference_To_v1beta1_IgnoreDifference(in *IgnoreDifference, out *v1beta1.IgnoreDifference, s conversion.Scope) error {
+	out.Group = in.Group
+	out.Kind = in.Kind
+	out.JSONPaths = *(*[]string)(unsafe.Pointer(&in.JSONPaths))
+	return nil
+}
+
+// Convert_v1alpha1_IgnoreDifference_To_v1beta1_IgnoreDifference is an autogenerated conversion function.
+func Convert_v1alpha1_IgnoreDifference_To_v1beta1_IgnoreDifference(in *IgnoreDifference, out *v1beta1.IgnoreDifference, s conversion.Scope) error {
+	return autoConvert_v1alpha1_IgnoreDifference_To_v1beta1_IgnoreDifference(in, out, s)
+}
+
+func autoConvert_v1beta1_IgnoreDifference_To_v1alpha1_IgnoreDifference(in *v1beta1.IgnoreDifference, out *IgnoreDifference, s conversion.Scope) error {
+	out.Group = in.Group
+	out.Kind = in.Kind
+	out.JSONPaths = *(*[]string)(unsafe.Pointer(&in.JSONPaths))
+	return nil
+}
+
+// Convert_v1beta1_IgnoreDifference_To_v1alpha1_IgnoreDifference is an autogenerated conversion function.
+func Convert_v1beta1_IgnoreDifference_To_v1alpha1_IgnoreDifference(in *v1beta1.IgnoreDifference, out *IgnoreDifference, s conversion.Scope) error {
+	return autoConvert_v1beta1_IgnoreDifference_To_v1alpha1_IgnoreDifference(in, out, s)
+}
+
 func autoConvert_v1alpha1_Oci_To_v1beta1_Oci(in *Oci, out *v1beta1.Oci, s conversion.Scope) error {
 	out.Image = in.Image
 	out.Dir = in.Dir
@@ -672,6 +784,9 @@ func autoConvert_v1alpha1_OverrideSpec_To_v1beta1_OverrideSpec(in *OverrideSpec,
 	out.StatusMode = in.StatusMode
 	out.ReconcileTimeout = (*metav1.Duration)(unsafe.Pointer(in.ReconcileTimeout))
 	out.APIServerTimeout = (*metav1.Duration)(unsafe.Pointer(in.APIServerTimeout))
+	out.ConflictPolicy = configsync.ConflictPolicy(in.ConflictPolicy)
+	out.PolicyEnforcement = configsync.PolicyEnforcement(in.PolicyEnforcement)
+	out.IgnoreDifferences = *(*[]v1beta1.IgnoreDifference)(unsafe.Pointer(&in.IgnoreDifferences))
 	out.EnableShellInRendering = (*bool)(unsafe.Pointer(in.EnableShellInRendering))
 	out.LogLevels = *(*[]v1beta1.ContainerLogLevelOverride)(unsafe.Pointer(&in.LogLevels))
 	return nil
@@ -688,6 +803,9 @@ func autoConvert_v1beta1_OverrideSpec_To_v1alpha1_OverrideSpec(in *v1beta1.Overr
 	out.StatusMode = in.StatusMode
 	out.ReconcileTimeout = (*metav1.Duration)(unsafe.Pointer(in.ReconcileTimeout))
 	out.APIServerTimeout = (*metav1.Duration)(unsafe.Pointer(in.APIServerTimeout))
+	out.ConflictPolicy = configsync.ConflictPolicy(in.ConflictPolicy)
+	out.PolicyEnforcement = configsync.PolicyEnforcement(in.PolicyEnforcement)
+	out.IgnoreDifferences = *(*[]IgnoreDifference)(unsafe.Pointer(&in.IgnoreDifferences))
 	out.EnableShellInRendering = (*bool)(unsafe.Pointer(in.EnableShellInRendering))
 	out.LogLevels = *(*[]ContainerLogLevelOverride)(unsafe.Pointer(&in.LogLevels))
 	return nil
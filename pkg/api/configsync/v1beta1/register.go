@@ -40,6 +40,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&RepoSync{},
 		&RepoSyncList{},
+		&RepoSyncPolicy{},
+		&RepoSyncPolicyList{},
 		&RootSync{},
 		&RootSyncList{},
 	)
@@ -110,6 +110,15 @@ const (
 	RepoSyncReconcilerFinalizing RepoSyncConditionType = "ReconcilerFinalizing"
 	// RepoSyncReconcilerFinalizerFailure means that the namespace reconciler finalizer has errored, blocking deletion.
 	RepoSyncReconcilerFinalizerFailure RepoSyncConditionType = "ReconcilerFinalizerFailure"
+	// RepoSyncPaused means that the namespace reconciler has paused applying and
+	// remediating managed resources, as requested by the sync-paused annotation.
+	RepoSyncPaused RepoSyncConditionType = "Paused"
+	// RepoSyncTriggered means that the namespace reconciler has completed a resync
+	// requested by the sync-trigger annotation.
+	RepoSyncTriggered RepoSyncConditionType = "Triggered"
+	// RepoSyncReapplied means that the namespace reconciler has reapplied the managed
+	// resources selected by the reapply annotation.
+	RepoSyncReapplied RepoSyncConditionType = "Reapplied"
 )
 
 // ErrorSource indicates the origination of errors.
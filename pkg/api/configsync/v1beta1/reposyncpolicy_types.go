@@ -0,0 +1,86 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
+
+// RepoSyncPolicy limits what the RepoSyncs in the namespaces which reference it
+// with the configsync.gke.io/repo-sync-policy label may declare.
+type RepoSyncPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec RepoSyncPolicySpec `json:"spec,omitempty"`
+}
+
+// RepoSyncPolicySpec defines the limits of a RepoSyncPolicy.
+type RepoSyncPolicySpec struct {
+	// namespaces is the list of the namespaces which may reference the policy.
+	// A RepoSync in a namespace which references the policy without being
+	// listed fails to sync. If empty, any namespace may reference the policy.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// allowedKinds is the list of the kinds which may be declared. A kind of
+	// `*` allows all the kinds of the group. If empty, all the kinds are
+	// allowed, except the denied kinds.
+	// +optional
+	AllowedKinds []metav1.GroupKind `json:"allowedKinds,omitempty"`
+
+	// deniedKinds is the list of the kinds which must not be declared. A kind
+	// of `*` denies all the kinds of the group. It takes precedence over
+	// allowedKinds.
+	// +optional
+	DeniedKinds []metav1.GroupKind `json:"deniedKinds,omitempty"`
+
+	// maxObjects is the maximum number of declared objects. If unset, the
+	// number of objects is not limited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxObjects *int64 `json:"maxObjects,omitempty"`
+
+	// maxTotalBytes is the maximum total size of the declared objects, in
+	// bytes of JSON. If unset, the size is not limited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxTotalBytes *int64 `json:"maxTotalBytes,omitempty"`
+
+	// forbiddenFields is the list of the fields which must not be set to a
+	// value other than null, false, 0 or empty. A field is a dot-separated
+	// path from the root of the object, e.g. `spec.template.spec.hostNetwork`.
+	// Lists are traversed implicitly, e.g. `spec.containers.securityContext.privileged`
+	// matches the field in every container. A path element of `*` matches any
+	// field, e.g. `spec.*.securityContext.privileged`.
+	// +optional
+	ForbiddenFields []string `json:"forbiddenFields,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RepoSyncPolicyList contains a list of RepoSyncPolicy
+type RepoSyncPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RepoSyncPolicy `json:"items"`
+}
@@ -62,6 +62,51 @@ type OverrideSpec struct {
 	// +optional
 	APIServerTimeout *metav1.Duration `json:"apiServerTimeout,omitempty"`
 
+	// conflictPolicy controls how the reconciler resolves the conflicts between
+	// the declared fields of the managed objects and the fields owned by other
+	// field managers, like controllers and admission webhooks.
+	// Must be "force", "respect" or "fail". Default: "force".
+	// "force" means that the reconciler takes over the conflicting fields,
+	// except the fields of well-known controllers, like the replicas set by a
+	// HorizontalPodAutoscaler.
+	// "respect" means that the reconciler leaves the conflicting fields to the
+	// other field managers, and reports them in the ResourceGroup.
+	// "fail" means that the reconciler does not apply objects with conflicting
+	// fields, and reports a conflict error.
+	// The configsync.gke.io/conflict-policy annotation overrides the policy
+	// of a single object.
+	//
+	// +kubebuilder:validation:Enum=force;respect;fail
+	// +optional
+	ConflictPolicy configsync.ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// policyEnforcement controls how the reconciler reports the declared
+	// objects which violate the ValidatingAdmissionPolicies or the Gatekeeper
+	// constraints of the cluster or of the source of truth, which are
+	// evaluated before apply.
+	// Must be "deny", "warn" or "disabled". Default: "deny".
+	// "deny" means that the violations of the policies which would deny the
+	// objects block the sync, and the other violations are reported as
+	// warnings.
+	// "warn" means that all the violations are reported as warnings, which do
+	// not block the sync.
+	// "disabled" means that the policies are not evaluated before apply.
+	//
+	// +kubebuilder:validation:Enum=deny;warn;disabled
+	// +optional
+	PolicyEnforcement configsync.PolicyEnforcement `json:"policyEnforcement,omitempty"`
+
+	// ignoreDifferences lists the fields of the managed objects of a kind which
+	// the reconciler neither applies nor remediates, like the replicas of a
+	// Deployment scaled by a HorizontalPodAutoscaler.
+	// The ignored fields are removed from the declared objects, so changes to
+	// them on the cluster are not reverted, and the admission webhook does not
+	// protect them.
+	// The configsync.gke.io/ignore-fields annotation ignores fields of a single
+	// object.
+	// +optional
+	IgnoreDifferences []IgnoreDifference `json:"ignoreDifferences,omitempty"`
+
 	// enableShellInRendering specifies whether to enable or disable the shell access in rendering process. Default: false.
 	// Kustomize remote bases requires shell access. Setting this field to true will enable shell in the rendering process and
 	// support pulling remote bases from public repositories.
@@ -145,6 +190,25 @@ type ContainerResourcesSpec struct {
 	MemoryLimit resource.Quantity `json:"memoryLimit,omitempty"`
 }
 
+// IgnoreDifference specifies the fields of the managed objects of a kind which
+// are ignored by the reconciler.
+type IgnoreDifference struct {
+	// group is the API group of the objects. Empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// kind is the kind of the objects.
+	// +kubebuilder:validation:Required
+	Kind string `json:"kind"`
+
+	// jsonPaths are the paths of the ignored fields, like ".spec.replicas".
+	// Field names with dots are quoted in brackets, like
+	// `.metadata.annotations["example.com/owner"]`. List items are selected
+	// with "[*]", or with a field value, like `.spec.containers[name="web"]`.
+	// +kubebuilder:validation:MinItems=1
+	JSONPaths []string `json:"jsonPaths"`
+}
+
 // ContainerLogLevelOverride specifies the container name and log level override value
 type ContainerLogLevelOverride struct {
 	// containerName specifies the name of the reconciler deployment container for which log level will be overridden.
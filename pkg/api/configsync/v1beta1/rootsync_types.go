@@ -110,6 +110,15 @@ const (
 	RootSyncReconcilerFinalizing RootSyncConditionType = "ReconcilerFinalizing"
 	// RootSyncReconcilerFinalizerFailure means that the root reconciler finalizer has errored, blocking deletion.
 	RootSyncReconcilerFinalizerFailure RootSyncConditionType = "ReconcilerFinalizerFailure"
+	// RootSyncPaused means that the root reconciler has paused applying and
+	// remediating managed resources, as requested by the sync-paused annotation.
+	RootSyncPaused RootSyncConditionType = "Paused"
+	// RootSyncTriggered means that the root reconciler has completed a resync
+	// requested by the sync-trigger annotation.
+	RootSyncTriggered RootSyncConditionType = "Triggered"
+	// RootSyncReapplied means that the root reconciler has reapplied the managed
+	// resources selected by the reapply annotation.
+	RootSyncReapplied RootSyncConditionType = "Reapplied"
 )
 
 // RootSyncCondition describes the state of a RootSync at a certain point.
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/GoogleContainerTools/kpt/pkg/live"
+	"go.opencensus.io/trace"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,6 +41,7 @@ import (
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/syncer/differ"
 	"kpt.dev/configsync/pkg/syncer/metrics"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util"
 	nomosutil "kpt.dev/configsync/pkg/util"
 	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
@@ -72,7 +74,22 @@ type Applier interface {
 	// Returns the set of GVKs which were successfully applied and any errors.
 	// This is called by the reconciler when changes are detected in the
 	// source of truth (git, OCI, helm) and periodically.
+	// Only the objects which changed since the last successful Apply are
+	// applied, along with the objects they depend on.
 	Apply(ctx context.Context, desiredResources []client.Object) (map[schema.GroupVersionKind]struct{}, status.MultiError)
+	// ResetApplied forgets the objects applied by the last successful Apply,
+	// so that the next Apply applies all the desired objects.
+	// This is called by the reconciler periodically, as a safety net.
+	ResetApplied()
+	// Reapply marks the objects with the specified IDs to be reapplied by the
+	// next Apply, even if they are unchanged, taking over their ownership if
+	// they are managed by another reconciler.
+	// This is called by the reconciler to handle the reapply annotation.
+	Reapply(ids []core.ID)
+	// Inventory returns the IDs of the objects tracked in the inventory.
+	// This is called by the reconciler to check the declared resources
+	// restored from a checkpoint.
+	Inventory(ctx context.Context) (map[core.ID]struct{}, error)
 	// Errors returns the errors encountered during apply.
 	// This method may be called while Destroy is running, to get the set of
 	// errors encounted so far.
@@ -82,11 +99,13 @@ type Applier interface {
 // Destroyer is a bulk client for deleting all the managed resource objects
 // tracked in a single ResourceGroup inventory.
 type Destroyer interface {
-	// Destroy deletes all managed resources.
+	// Destroy deletes all managed resources, in the teardown order.
+	// The progress func, if not nil, is called before each level of objects
+	// is deleted.
 	// Returns any errors encountered while destorying.
 	// This is called by the reconciler finalizer when deletion propagation is
 	// enabled.
-	Destroy(ctx context.Context) status.MultiError
+	Destroy(ctx context.Context, progress DestroyProgressFunc) status.MultiError
 	// Errors returns the errors encountered during destroy.
 	// This method may be called while Destroy is running, to get the set of
 	// errors encounted so far.
@@ -131,6 +150,19 @@ type supervisor struct {
 	// errs recieved from the current (if running) or previous Apply/Destroy.
 	// These errors is cleared at the start of the Apply/Destroy methods.
 	errs status.MultiError
+
+	// applied maps the ID of each object applied by the last successful Apply
+	// to the hash of its declared content. It is nil if the next Apply must
+	// apply all the objects.
+	applied map[core.ID]string
+	// appliedToken is the sync token of the objects applied by the last
+	// successful Apply. The unchanged objects are updated to the sync token of
+	// the next Apply, if it is different.
+	appliedToken string
+	// reapply is the set of objects to reapply with the next Apply, even if
+	// they are unchanged or managed by another reconciler. It is cleared
+	// when the Apply succeeds.
+	reapply map[core.ID]struct{}
 }
 
 var _ Applier = &supervisor{}
@@ -310,6 +342,12 @@ func (h *eventHandler) handleApplySkippedEvent(obj *unstructured.Unstructured, i
 
 	var policyErr *inventory.PolicyPreventedActuationError
 	if errors.As(err, &policyErr) {
+		if target := differ.HandoffTarget(obj); target != "" {
+			// The target reconciler claimed the object after the handoff was
+			// checked, so it is removed from the inventory on the next apply.
+			klog.Infof("Skipped applying object claimed by %q: %v", target, id)
+			return nil
+		}
 		// TODO: return ManagementConflictError with the conflicting manager if
 		// cli-utils supports reporting the conflicting manager in
 		// PolicyPreventedActuationError.
@@ -476,8 +514,8 @@ func handleMetrics(ctx context.Context, operation string, err error) {
 	m.RecordApplyOperation(ctx, m.ApplierController, operation, m.StatusTagKey(err))
 }
 
-// checkInventoryObjectSize checks the inventory object size limit.
-// If it is close to the size limit 1M, log a warning.
+// checkInventoryObjectSize checks the size of the first ResourceGroup of the
+// inventory. If it is close to the size limit 1M, log a warning.
 func (a *supervisor) checkInventoryObjectSize(ctx context.Context, c client.Client) {
 	u := newInventoryUnstructured(a.syncKind, a.syncName, a.syncNamespace, a.clientSet.StatusMode)
 	err := c.Get(ctx, client.ObjectKey{Namespace: a.syncNamespace, Name: a.syncName}, u)
@@ -488,8 +526,8 @@ func (a *supervisor) checkInventoryObjectSize(ctx context.Context, c client.Clie
 		}
 		if int64(size) > maxRequestBytes/2 {
 			klog.Warningf("ResourceGroup %s/%s is close to the maximum object size limit (size: %d, max: %s). "+
-				"There are too many resources being synced than Config Sync can handle! Please split your repo into smaller repos "+
-				"to avoid future failure.", a.syncNamespace, a.syncName, size, maxRequestBytesStr)
+				"The inventory is split across more ResourceGroups as it grows, but its resource statuses are larger than estimated. "+
+				"Please split your repo into smaller repos to avoid future failure.", a.syncNamespace, a.syncName, size, maxRequestBytesStr)
 		}
 	}
 }
@@ -506,7 +544,9 @@ func (a *supervisor) applyInner(ctx context.Context, objs []client.Object) (map[
 	objStatusMap := make(ObjectStatusMap)
 	// disabledObjs are objects for which the management are disabled
 	// through annotation.
-	enabledObjs, disabledObjs := partitionObjs(objs)
+	// handoffObjs are objects whose management is handed off to another
+	// reconciler through annotation.
+	enabledObjs, disabledObjs, handoffObjs := partitionObjs(objs)
 	if len(disabledObjs) > 0 {
 		klog.Infof("%v objects to be disabled: %v", len(disabledObjs), core.GKNNs(disabledObjs))
 		disabledCount, err := eh.handleDisabledObjects(ctx, a.inventory, disabledObjs)
@@ -519,12 +559,78 @@ func (a *supervisor) applyInner(ctx context.Context, objs []client.Object) (map[
 			Succeeded: disabledCount,
 		}
 	}
-	klog.Infof("%v objects to be applied: %v", len(enabledObjs), core.GKNNs(enabledObjs))
-	resources, err := toUnstructured(enabledObjs)
+	if len(handoffObjs) > 0 {
+		klog.Infof("%v objects to be handed off: %v", len(handoffObjs), core.GKNNs(handoffObjs))
+		pending, handoffCount, err := eh.handleHandoffObjects(ctx, a.inventory, handoffObjs)
+		if err != nil {
+			a.addError(err)
+			return nil, a.Errors()
+		}
+		s.HandoffObjs = &stats.HandoffObjStats{
+			Total:     uint64(len(handoffObjs)),
+			Succeeded: handoffCount,
+		}
+		// Keep managing the objects until the target reconciler claims them.
+		enabledObjs = append(enabledObjs, pending...)
+	}
+	// Claim the objects handed off to this reconciler before applying them,
+	// so that they are adopted and added to the inventory by this apply.
+	inventoryIDs, err := a.Inventory(ctx)
 	if err != nil {
+		a.addError(inventoryError(err, a.inventory))
+		return nil, a.Errors()
+	}
+	if _, err := eh.claimHandoffs(ctx, a.inventory.ID(), inventoryIDs, enabledObjs); err != nil {
 		a.addError(err)
 		return nil, a.Errors()
 	}
+	if reapplyObjs := a.reapplyObjects(enabledObjs); len(reapplyObjs) > 0 {
+		klog.Infof("%v objects to be reapplied: %v", len(reapplyObjs), core.GKNNs(reapplyObjs))
+		if _, err := eh.claimObjects(ctx, a.inventory.ID(), reapplyObjs); err != nil {
+			a.addError(err)
+			return nil, a.Errors()
+		}
+	}
+	allResources, err := toUnstructured(enabledObjs)
+	if err != nil {
+		a.addError(err)
+		return nil, a.Errors()
+	}
+	hashes := objectHashes(allResources)
+	resources, unchanged := allResources, []client.Object(nil)
+	if a.clientSet.incremental != nil {
+		resources, unchanged = a.incrementalSet(allResources, hashes)
+	}
+	// Forget the applied objects until this apply succeeds.
+	a.applied = nil
+	resources, skipped, foreignFields, conflictErrs := eh.resolveConflicts(ctx, resources, a.clientSet.ConflictPolicy, objStatusMap)
+	a.addError(conflictErrs)
+	if len(unchanged) > 0 {
+		klog.Infof("%v objects are unchanged since the last apply", len(unchanged))
+	}
+	if len(skipped) > 0 {
+		klog.Infof("%v objects are skipped because of conflicts or errors: %v", len(skipped), core.GKNNs(skipped))
+	}
+	if len(unchanged) > 0 || len(skipped) > 0 {
+		// Keep the unchanged objects, and the skipped objects which were
+		// applied before, in the inventory while applying, so that they are
+		// not pruned.
+		if a.clientSet.incremental == nil {
+			a.addError(Error(fmt.Errorf("unable to keep %d objects in the inventory without applying them", len(unchanged)+len(skipped))))
+			return nil, a.Errors()
+		}
+		if err := a.clientSet.incremental.retain(ctx, a.inventory,
+			object.ObjMetadataSet(objMetasFrom(unchanged)), object.ObjMetadataSet(objMetasFrom(skipped))); err != nil {
+			a.addError(inventoryError(err, a.inventory))
+			return nil, a.Errors()
+		}
+		defer a.clientSet.incremental.release()
+	}
+	var gknns []string
+	for _, obj := range resources {
+		gknns = append(gknns, core.GKNN(obj))
+	}
+	klog.Infof("%v objects to be applied: %v", len(resources), gknns)
 
 	unknownTypeResources := make(map[core.ID]struct{})
 	options := apply.ApplierOptions{
@@ -554,6 +660,14 @@ func (a *supervisor) applyInner(ctx context.Context, objs []client.Object) (map[
 	// This allows for picking up CRD changes.
 	meta.MaybeResetRESTMapper(a.clientSet.Mapper)
 
+	spans := newTaskSpans(ctx)
+	defer spans.endAll()
+	// The object sync statuses are recorded by the inventory writes of the
+	// apply.
+	a.clientSet.syncStatus.start(enabledObjs, objStatusMap, foreignFields)
+	defer a.clientSet.syncStatus.stop()
+	a.clientSet.shards.start(ctx)
+	defer a.clientSet.shards.stop()
 	events := a.clientSet.KptApplier.Run(ctx, a.inventory, object.UnstructuredSet(resources), options)
 	for e := range events {
 		switch e.Type {
@@ -563,6 +677,7 @@ func (a *supervisor) applyInner(ctx context.Context, objs []client.Object) (map[
 			}
 		case event.ActionGroupType:
 			klog.Info(e.ActionGroupEvent)
+			spans.handle(e.ActionGroupEvent)
 		case event.ErrorType:
 			klog.Info(e.ErrorEvent)
 			if util.IsRequestTooLargeError(e.ErrorEvent.Err) {
@@ -580,21 +695,27 @@ func (a *supervisor) applyInner(ctx context.Context, objs []client.Object) (map[
 			} else {
 				klog.V(1).Info(e.WaitEvent)
 			}
-			a.addError(eh.processWaitEvent(e.WaitEvent, s.WaitEvent, objStatusMap))
+			err := eh.processWaitEvent(e.WaitEvent, s.WaitEvent, objStatusMap)
+			objStatusMap.setError(idFrom(e.WaitEvent.Identifier), err)
+			a.addError(err)
 		case event.ApplyType:
 			if e.ApplyEvent.Error != nil {
 				klog.Info(e.ApplyEvent)
 			} else {
 				klog.V(1).Info(e.ApplyEvent)
 			}
-			a.addError(eh.processApplyEvent(ctx, e.ApplyEvent, s.ApplyEvent, objStatusMap, unknownTypeResources))
+			err := eh.processApplyEvent(ctx, e.ApplyEvent, s.ApplyEvent, objStatusMap, unknownTypeResources)
+			objStatusMap.setError(idFrom(e.ApplyEvent.Identifier), err)
+			a.addError(err)
 		case event.PruneType:
 			if e.PruneEvent.Error != nil {
 				klog.Info(e.PruneEvent)
 			} else {
 				klog.V(1).Info(e.PruneEvent)
 			}
-			a.addError(eh.processPruneEvent(ctx, e.PruneEvent, s.PruneEvent, objStatusMap))
+			err := eh.processPruneEvent(ctx, e.PruneEvent, s.PruneEvent, objStatusMap)
+			objStatusMap.setError(idFrom(e.PruneEvent.Identifier), err)
+			a.addError(err)
 		default:
 			klog.Infof("Unhandled event (%s): %v", e.Type, e)
 		}
@@ -609,9 +730,16 @@ func (a *supervisor) applyInner(ctx context.Context, objs []client.Object) (map[
 		gvks[resource.GetObjectKind().GroupVersionKind()] = struct{}{}
 	}
 
+	if a.Errors() == nil && len(unchanged) > 0 {
+		a.addError(a.updateSyncTokens(ctx, unchanged))
+	}
+
 	errs := a.Errors()
 	if errs == nil {
 		klog.V(4).Infof("Apply completed without error: all resources are up to date.")
+		a.applied = hashes
+		a.appliedToken = syncToken(enabledObjs)
+		a.reapply = nil
 	}
 	if s.Empty() {
 		klog.V(4).Infof("Applier made no new progress")
@@ -652,8 +780,16 @@ func (a *supervisor) invalidateErrors() {
 	a.errs = nil
 }
 
-// destroyInner triggers a kpt live destroy library call to destroy a set of resources.
-func (a *supervisor) destroyInner(ctx context.Context) status.MultiError {
+// destroyInner deletes the managed objects level by level, in the teardown
+// order, and then triggers a kpt live destroy library call to destroy the
+// remaining resources and the inventory.
+func (a *supervisor) destroyInner(ctx context.Context, progress DestroyProgressFunc) status.MultiError {
+	if err := a.teardownInner(ctx, progress); err != nil {
+		// Retry the teardown before deleting the objects of the next levels.
+		a.addError(err)
+		return a.Errors()
+	}
+
 	s := stats.NewSyncStats()
 	objStatusMap := make(ObjectStatusMap)
 	eh := eventHandler{
@@ -678,6 +814,10 @@ func (a *supervisor) destroyInner(ctx context.Context) status.MultiError {
 	// This allows for picking up CRD changes.
 	meta.MaybeResetRESTMapper(a.clientSet.Mapper)
 
+	spans := newTaskSpans(ctx)
+	defer spans.endAll()
+	a.clientSet.shards.start(ctx)
+	defer a.clientSet.shards.stop()
 	events := a.clientSet.KptDestroyer.Run(ctx, a.inventory, options)
 	for e := range events {
 		switch e.Type {
@@ -687,6 +827,7 @@ func (a *supervisor) destroyInner(ctx context.Context) status.MultiError {
 			}
 		case event.ActionGroupType:
 			klog.Info(e.ActionGroupEvent)
+			spans.handle(e.ActionGroupEvent)
 		case event.ErrorType:
 			klog.Info(e.ErrorEvent)
 			if util.IsRequestTooLargeError(e.ErrorEvent.Err) {
@@ -740,12 +881,42 @@ func (a *supervisor) Apply(ctx context.Context, desiredResource []client.Object)
 	// but for now, invalidate all errors until they recur.
 	// TODO: improve error cache invalidation to make rsync status more stable
 	a.invalidateErrors()
-	return a.applyInner(ctx, desiredResource)
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanApply,
+		trace.Int64Attribute(tracing.KeyObjectCount, int64(len(desiredResource))))
+	gvks, errs := a.applyInner(ctx, desiredResource)
+	tracing.EndSpan(span, errs)
+	return gvks, errs
+}
+
+// Inventory implements Applier.
+func (a *supervisor) Inventory(ctx context.Context) (map[core.ID]struct{}, error) {
+	shards, err := inventoryShards(ctx, a.clientSet.Client, a.inventory)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := loadShards(shards)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[core.ID]struct{}, len(objs))
+	for _, obj := range objs {
+		ids[idFrom(obj)] = struct{}{}
+	}
+	return ids, nil
+}
+
+// ResetApplied forgets the objects applied by the last successful Apply.
+// ResetApplied implements the Applier interface.
+func (a *supervisor) ResetApplied() {
+	a.execMux.Lock()
+	defer a.execMux.Unlock()
+
+	a.applied = nil
 }
 
 // Destroy all managed resource objects and return any errors.
 // Destroy implements the Destroyer interface.
-func (a *supervisor) Destroy(ctx context.Context) status.MultiError {
+func (a *supervisor) Destroy(ctx context.Context, progress DestroyProgressFunc) status.MultiError {
 	a.execMux.Lock()
 	defer a.execMux.Unlock()
 
@@ -753,7 +924,10 @@ func (a *supervisor) Destroy(ctx context.Context) status.MultiError {
 	// but for now, invalidate all errors until they recur.
 	// TODO: improve error cache invalidation to make rsync status more stable
 	a.invalidateErrors()
-	return a.destroyInner(ctx)
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanDestroy)
+	errs := a.destroyInner(ctx, progress)
+	tracing.EndSpan(span, errs)
+	return errs
 }
 
 // newInventoryUnstructured creates an inventory object as an unstructured.
@@ -783,7 +957,7 @@ func InventoryID(name, namespace string) string {
 func (h *eventHandler) handleDisabledObjects(ctx context.Context, rg *live.InventoryResourceGroup, objs []client.Object) (uint64, status.MultiError) {
 	// disabledCount tracks the number of objects which are disabled successfully
 	var disabledCount uint64
-	err := h.removeFromInventory(rg, objs)
+	err := h.removeFromInventory(ctx, rg, objs)
 	if err != nil {
 		if nomosutil.IsRequestTooLargeError(err) {
 			return disabledCount, largeResourceGroupError(err, idFromInventory(rg))
@@ -809,9 +983,135 @@ func (h *eventHandler) handleDisabledObjects(ctx context.Context, rg *live.Inven
 	return disabledCount, errs
 }
 
+// handleHandoffObjects checks, one by one, whether the specified objects have
+// been claimed by the manager named by their handoff annotation, and removes
+// the claimed objects from the inventory. The objects are not pruned. The
+// objects which are not claimed yet are still managed by this reconciler: they
+// are returned to be applied with their handoff annotation, which marks the
+// handoff as pending on the live objects, until the target reconciler declares
+// and claims them.
+// Returns the objects whose handoff is pending, the number of objects which
+// are handed off, and any errors encountered.
+func (h *eventHandler) handleHandoffObjects(ctx context.Context, rg *live.InventoryResourceGroup, objs []client.Object) ([]client.Object, uint64, status.MultiError) {
+	var errs status.MultiError
+	var pending, handedOff []client.Object
+	for _, obj := range objs {
+		id := core.IDOf(obj)
+		target := differ.HandoffTarget(obj)
+		claimed, err := h.handoffClaimed(ctx, obj, rg.ID(), target)
+		handleMetrics(ctx, "handoff", err)
+		switch {
+		case err != nil:
+			klog.Warningf("failed to hand off %v to %q: %v", id, target, err)
+			errs = status.Append(errs, err)
+		case claimed:
+			klog.V(4).Infof("handed off %v to %q", id, target)
+			handedOff = append(handedOff, obj)
+		default:
+			klog.V(4).Infof("handoff of %v to %q is pending", id, target)
+			pending = append(pending, obj)
+		}
+	}
+	if len(handedOff) == 0 {
+		return pending, 0, errs
+	}
+	if err := h.removeFromInventory(ctx, rg, handedOff); err != nil {
+		if nomosutil.IsRequestTooLargeError(err) {
+			return pending, 0, status.Append(errs, largeResourceGroupError(err, idFromInventory(rg)))
+		}
+		return pending, 0, status.Append(errs, Error(err))
+	}
+	return pending, uint64(len(handedOff)), errs
+}
+
+// handoffClaimed returns true if the target reconciler has claimed the object
+// handed off to it, i.e. the object is annotated with the inventory id of the
+// target. It returns false if the object is still owned by this reconciler,
+// is not owned by any reconciler, or does not exist.
+func (h *eventHandler) handoffClaimed(ctx context.Context, obj client.Object, inventoryID, target string) (bool, status.Error) {
+	gvk, err := kinds.Lookup(obj, h.clientSet.Client.Scheme())
+	if err != nil {
+		return false, Error(err)
+	}
+	uObj := &unstructured.Unstructured{}
+	uObj.SetGroupVersionKind(gvk)
+	err = h.clientSet.Client.Get(ctx, client.ObjectKeyFromObject(obj), uObj)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// The applier creates the object, marked for the target.
+			return false, nil
+		}
+		return false, Error(err)
+	}
+	switch core.GetAnnotation(uObj, metadata.OwningInventoryKey) {
+	case handoffInventoryID(target):
+		return true, nil
+	case inventoryID, "":
+		return false, nil
+	default:
+		// The object is owned by another reconciler, so it's not ours to hand off.
+		return false, KptManagementConflictError(obj)
+	}
+}
+
+// claimHandoffs claims the declared objects which are not in the inventory yet,
+// and which are handed off to this reconciler by the reconciler managing them,
+// as marked by the handoff annotation of the live objects. The apply which
+// follows adopts the claimed objects, and adds them to the inventory, so no
+// management conflict is reported for them.
+// Returns the number of objects which are claimed, and any errors encountered.
+func (h *eventHandler) claimHandoffs(ctx context.Context, inventoryID string, inventory map[core.ID]struct{}, objs []client.Object) (uint64, status.MultiError) {
+	var claimCount uint64
+	var errs status.MultiError
+	for _, obj := range objs {
+		if _, found := inventory[core.IDOf(obj)]; found {
+			// Objects in the inventory are already owned by this reconciler.
+			continue
+		}
+		claimed, err := h.claimHandoff(ctx, obj, inventoryID)
+		if err != nil {
+			handleMetrics(ctx, "claim", err)
+			klog.Warningf("failed to claim %v: %v", core.IDOf(obj), err)
+			errs = status.Append(errs, err)
+		} else if claimed {
+			handleMetrics(ctx, "claim", nil)
+			claimCount++
+		}
+	}
+	return claimCount, errs
+}
+
+// claimHandoff claims the object if its live state is handed off to the
+// manager of the declared object, and returns true if it was claimed.
+func (h *eventHandler) claimHandoff(ctx context.Context, obj client.Object, inventoryID string) (bool, status.Error) {
+	gvk, err := kinds.Lookup(obj, h.clientSet.Client.Scheme())
+	if err != nil {
+		return false, Error(err)
+	}
+	uObj := &unstructured.Unstructured{}
+	uObj.SetGroupVersionKind(gvk)
+	err = h.clientSet.Client.Get(ctx, client.ObjectKeyFromObject(obj), uObj)
+	if err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, Error(err)
+	}
+	manager := core.GetAnnotation(obj, metadata.ResourceManagerKey)
+	if manager == "" || differ.HandoffTarget(uObj) != manager ||
+		core.GetAnnotation(uObj, metadata.OwningInventoryKey) == inventoryID {
+		return false, nil
+	}
+	klog.Infof("Claiming object %s handed off by %q", core.IDOf(obj), core.GetAnnotation(uObj, metadata.ResourceManagerKey))
+	if err := h.patchOwner(ctx, uObj, inventoryID, manager); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // removeFromInventory removes the specified objects from the inventory, if it
 // exists.
-func (h *eventHandler) removeFromInventory(rg *live.InventoryResourceGroup, objs []client.Object) error {
+func (h *eventHandler) removeFromInventory(ctx context.Context, rg *live.InventoryResourceGroup, objs []client.Object) error {
 	clusterInv, err := h.clientSet.InvClient.GetClusterInventoryInfo(rg)
 	if err != nil {
 		return err
@@ -820,20 +1120,31 @@ func (h *eventHandler) removeFromInventory(rg *live.InventoryResourceGroup, objs
 		// If inventory does not exist, there is nothing to remove
 		return nil
 	}
-	wrappedInv, err := wrapInventoryObj(clusterInv)
+	oldObjs, err := h.clientSet.InvClient.GetClusterObjs(rg)
 	if err != nil {
 		return err
 	}
-	oldObjs, err := wrappedInv.Load()
+	newObjs := removeFrom(oldObjs, objs)
+	if len(newObjs) == len(oldObjs) {
+		// The objects were removed by a previous apply.
+		return nil
+	}
+	// Keep the status of the other objects.
+	shards, err := inventoryShards(ctx, h.clientSet.Client, rg)
 	if err != nil {
 		return err
 	}
-	newObjs := removeFrom(oldObjs, objs)
-	err = rg.Store(newObjs, nil)
+	statuses, err := loadShardStatuses(shards)
 	if err != nil {
 		return err
 	}
-	return h.clientSet.InvClient.Replace(rg, newObjs, nil, common.DryRunNone)
+	var newStatus []actuation.ObjectStatus
+	for _, id := range newObjs {
+		if s, found := statuses[id]; found {
+			newStatus = append(newStatus, s)
+		}
+	}
+	return h.clientSet.InvClient.Replace(rg, newObjs, newStatus, common.DryRunNone)
 }
 
 // abandonObject removes ConfigSync labels and annotations from an object,
@@ -693,3 +693,204 @@ func newTestObj(name string) *unstructured.Unstructured {
 		Kind:    "Test",
 	}, core.Namespace("test-namespace"), core.Name(name))
 }
+
+func TestHandoffClaimed(t *testing.T) {
+	inventoryID := InventoryID("rs", "test-namespace")
+	sourceManager := declared.ResourceManager("test-namespace", "rs")
+	targetManager := declared.ResourceManager(declared.RootReconciler, "root-sync")
+	targetInventoryID := InventoryID("root-sync", "config-management-system")
+
+	newDeployment := func(manager, owner string) *unstructured.Unstructured {
+		obj := newDeploymentObj()
+		obj.SetAnnotations(map[string]string{
+			metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+			metadata.ResourceIDKey:         core.GKNN(obj),
+			metadata.ResourceManagerKey:    manager,
+			metadata.OwningInventoryKey:    owner,
+		})
+		return obj
+	}
+
+	testcases := []struct {
+		name        string
+		serverObjs  []client.Object
+		wantClaimed bool
+		wantErr     bool
+	}{
+		{
+			name: "object not found",
+		},
+		{
+			name:       "handoff pending",
+			serverObjs: []client.Object{newDeployment(sourceManager, inventoryID)},
+		},
+		{
+			name:        "object claimed by the target",
+			serverObjs:  []client.Object{newDeployment(targetManager, targetInventoryID)},
+			wantClaimed: true,
+		},
+		{
+			name:       "object owned by another inventory",
+			serverObjs: []client.Object{newDeployment("other", "other_rs")},
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := testingfake.NewClient(t, core.Scheme, tc.serverObjs...)
+			eh := eventHandler{clientSet: &ClientSet{Client: fakeClient}}
+
+			obj := newDeploymentObj()
+			core.SetAnnotation(obj, metadata.ResourceHandoffKey, targetManager)
+			claimed, err := eh.handoffClaimed(context.Background(), obj, inventoryID, targetManager)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantClaimed, claimed)
+		})
+	}
+}
+
+func TestClaimHandoff(t *testing.T) {
+	inventoryID := InventoryID("rs", "test-namespace")
+	manager := declared.ResourceManager("test-namespace", "rs")
+	sourceManager := declared.ResourceManager(declared.RootReconciler, "root-sync")
+	sourceInventoryID := InventoryID("root-sync", "config-management-system")
+
+	newDeployment := func(handoff string) *unstructured.Unstructured {
+		obj := newDeploymentObj()
+		obj.SetAnnotations(map[string]string{
+			metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+			metadata.ResourceManagerKey:    sourceManager,
+			metadata.OwningInventoryKey:    sourceInventoryID,
+		})
+		if handoff != "" {
+			core.SetAnnotation(obj, metadata.ResourceHandoffKey, handoff)
+		}
+		return obj
+	}
+
+	testcases := []struct {
+		name            string
+		serverObjs      []client.Object
+		wantClaimed     bool
+		wantAnnotations map[string]string
+	}{
+		{
+			name: "object not found",
+		},
+		{
+			name:       "object not handed off",
+			serverObjs: []client.Object{newDeployment("")},
+			wantAnnotations: map[string]string{
+				metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+				metadata.ResourceManagerKey:    sourceManager,
+				metadata.OwningInventoryKey:    sourceInventoryID,
+			},
+		},
+		{
+			name:       "object handed off to another reconciler",
+			serverObjs: []client.Object{newDeployment("other")},
+			wantAnnotations: map[string]string{
+				metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+				metadata.ResourceManagerKey:    sourceManager,
+				metadata.OwningInventoryKey:    sourceInventoryID,
+				metadata.ResourceHandoffKey:    "other",
+			},
+		},
+		{
+			name:        "object handed off to this reconciler",
+			serverObjs:  []client.Object{newDeployment(manager)},
+			wantClaimed: true,
+			wantAnnotations: map[string]string{
+				metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+				metadata.ResourceManagerKey:    manager,
+				metadata.OwningInventoryKey:    inventoryID,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := testingfake.NewClient(t, core.Scheme, tc.serverObjs...)
+			eh := eventHandler{clientSet: &ClientSet{Client: fakeClient}}
+
+			obj := newDeploymentObj()
+			core.SetAnnotation(obj, metadata.ResourceManagerKey, manager)
+			claimed, err := eh.claimHandoff(context.Background(), obj, inventoryID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantClaimed, claimed)
+			if tc.wantAnnotations == nil {
+				return
+			}
+			got := &unstructured.Unstructured{}
+			got.SetGroupVersionKind(obj.GroupVersionKind())
+			require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(obj), got))
+			testutil.AssertEqual(t, tc.wantAnnotations, got.GetAnnotations())
+		})
+	}
+}
+
+func TestClaimObject(t *testing.T) {
+	inventoryID := InventoryID("rs", "test-namespace")
+	manager := declared.ResourceManager("test-namespace", "rs")
+
+	newDeployment := func(manager, owner string) *unstructured.Unstructured {
+		obj := newDeploymentObj()
+		obj.SetAnnotations(map[string]string{
+			metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+			metadata.ResourceManagerKey:    manager,
+			metadata.OwningInventoryKey:    owner,
+		})
+		return obj
+	}
+
+	testcases := []struct {
+		name            string
+		serverObjs      []client.Object
+		wantAnnotations map[string]string
+	}{
+		{
+			name: "object not found",
+		},
+		{
+			name:       "object already owned",
+			serverObjs: []client.Object{newDeployment(manager, inventoryID)},
+			wantAnnotations: map[string]string{
+				metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+				metadata.ResourceManagerKey:    manager,
+				metadata.OwningInventoryKey:    inventoryID,
+			},
+		},
+		{
+			name:       "object owned by another inventory",
+			serverObjs: []client.Object{newDeployment("other", "other_rs")},
+			wantAnnotations: map[string]string{
+				metadata.ResourceManagementKey: metadata.ResourceManagementEnabled,
+				metadata.ResourceManagerKey:    manager,
+				metadata.OwningInventoryKey:    inventoryID,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := testingfake.NewClient(t, core.Scheme, tc.serverObjs...)
+			eh := eventHandler{clientSet: &ClientSet{Client: fakeClient}}
+
+			obj := newDeploymentObj()
+			core.SetAnnotation(obj, metadata.ResourceManagerKey, manager)
+			require.NoError(t, eh.claimObject(context.Background(), obj, inventoryID))
+			if tc.wantAnnotations == nil {
+				return
+			}
+			got := &unstructured.Unstructured{}
+			got.SetGroupVersionKind(obj.GroupVersionKind())
+			require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(obj), got))
+			testutil.AssertEqual(t, tc.wantAnnotations, got.GetAnnotations())
+		})
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/cmd/util"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"sigs.k8s.io/cli-utils/pkg/apply"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/inventory"
@@ -50,13 +52,37 @@ type ClientSet struct {
 	Client       client.Client
 	Mapper       meta.RESTMapper
 	StatusMode   string
+	// ConflictPolicy is the default policy for the fields of the managed
+	// objects owned by other field managers.
+	ConflictPolicy configsync.ConflictPolicy
+	// IgnoreDifferences are the fields of each kind which are neither applied
+	// nor remediated.
+	IgnoreDifferences ignorefields.Rules
+	// incremental is the InvClient, if it can keep the objects skipped by an
+	// incremental apply in the inventory. All the objects are applied if it
+	// is nil.
+	incremental *incrementalInventoryClient
+	// syncStatus records the object sync statuses in the inventory, if the
+	// status mode is enabled.
+	syncStatus *syncStatusRecorder
+	// shards is the sharded inventory client wrapped by the InvClient. It
+	// uses the context of the running apply or destroy.
+	shards *shardedInventoryClient
 }
 
 // NewClientSet constructs a new ClientSet.
-func NewClientSet(c client.Client, configFlags *genericclioptions.ConfigFlags, statusMode string) (*ClientSet, error) {
+func NewClientSet(c client.Client, configFlags *genericclioptions.ConfigFlags, statusMode string, conflictPolicy configsync.ConflictPolicy, ignoreDifferences ignorefields.Rules) (*ClientSet, error) {
 	matchVersionKubeConfigFlags := util.NewMatchVersionFlags(configFlags)
 	f := util.NewFactory(matchVersionKubeConfigFlags)
+	return newClientSet(c, f, statusMode, conflictPolicy, ignoreDifferences)
+}
 
+// newClientSet constructs a new ClientSet with the clients of the factory.
+// The applier and destroyer share the sharded inventory client, so that they
+// read and write the same ResourceGroups as the reconciler. It is wrapped by
+// the incremental inventory client, so that the objects skipped by an
+// incremental apply stay in the inventory.
+func newClientSet(c client.Client, f util.Factory, statusMode string, conflictPolicy configsync.ConflictPolicy, ignoreDifferences ignorefields.Rules) (*ClientSet, error) {
 	var statusPolicy inventory.StatusPolicy
 	if statusMode == StatusEnabled {
 		klog.Infof("Enabled status reporting")
@@ -65,11 +91,28 @@ func NewClientSet(c client.Client, configFlags *genericclioptions.ConfigFlags, s
 		klog.Infof("Disabled status reporting")
 		statusPolicy = inventory.StatusPolicyNone
 	}
-	invClient, err := inventory.NewClient(f, live.WrapInventoryObj,
+	// The object sync statuses are only recorded with the resource statuses.
+	var syncStatus *syncStatusRecorder
+	wrapInventoryObj := live.WrapInventoryObj
+	if statusPolicy == inventory.StatusPolicyAll {
+		syncStatus = &syncStatusRecorder{}
+		wrapInventoryObj = syncStatus.wrapInventoryObj
+	}
+	clusterClient, err := inventory.NewClient(f, wrapInventoryObj,
 		live.InvToUnstructuredFunc, statusPolicy, live.ResourceGroupGVK)
 	if err != nil {
 		return nil, err
 	}
+	shards := &shardedInventoryClient{
+		Client:       clusterClient,
+		client:       c,
+		statusPolicy: statusPolicy,
+		syncStatus:   syncStatus,
+	}
+	invClient := &incrementalInventoryClient{
+		Client: shards,
+		client: c,
+	}
 
 	applier, err := apply.NewApplierBuilder().
 		WithInventoryClient(invClient).
@@ -93,11 +136,16 @@ func NewClientSet(c client.Client, configFlags *genericclioptions.ConfigFlags, s
 	}
 
 	return &ClientSet{
-		KptApplier:   applier,
-		KptDestroyer: destroyer,
-		InvClient:    invClient,
-		Client:       c,
-		Mapper:       mapper,
-		StatusMode:   statusMode,
+		KptApplier:        applier,
+		KptDestroyer:      destroyer,
+		InvClient:         invClient,
+		Client:            c,
+		Mapper:            mapper,
+		StatusMode:        statusMode,
+		ConflictPolicy:    conflictPolicy,
+		IgnoreDifferences: ignoreDifferences,
+		incremental:       invClient,
+		syncStatus:        syncStatus,
+		shards:            shards,
 	}, nil
 }
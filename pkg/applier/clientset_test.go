@@ -0,0 +1,348 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	restfake "k8s.io/client-go/rest/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	resourcegroupv1alpha1 "kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// testFactory is a util.Factory whose clients read and write the storage of
+// a fake ClientSet, so that the cli-utils applier and destroyer can run
+// without a cluster. Objects are applied through a fake REST client, which
+// forwards the requests to the fake dynamic client.
+type testFactory struct {
+	util.Factory
+	clientSet *testingfake.ClientSet
+	mapper    meta.RESTMapper
+}
+
+func (f *testFactory) DynamicClient() (dynamic.Interface, error) {
+	return f.clientSet.DynamicClient, nil
+}
+
+func (f *testFactory) ToRESTMapper() (meta.RESTMapper, error) {
+	return f.mapper, nil
+}
+
+func (f *testFactory) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return memory.NewMemCacheClient(&fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}), nil
+}
+
+func (f *testFactory) ToRESTConfig() (*rest.Config, error) {
+	return &rest.Config{}, nil
+}
+
+func (f *testFactory) UnstructuredClientForMapping(mapping *meta.RESTMapping) (resource.RESTClient, error) {
+	return &restfake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		GroupVersion:         mapping.GroupVersionKind.GroupVersion(),
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			return f.serve(req, mapping)
+		}),
+	}, nil
+}
+
+// serve handles the GET and PATCH requests of an object.
+func (f *testFactory) serve(req *http.Request, mapping *meta.RESTMapping) (*http.Response, error) {
+	ctx := req.Context()
+	var namespace string
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(segments) > 2 && segments[0] == "namespaces" {
+		namespace = segments[1]
+	}
+	name := segments[len(segments)-1]
+	resourceClient := f.clientSet.DynamicClient.Resource(mapping.Resource).Namespace(namespace)
+
+	var obj *unstructured.Unstructured
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		obj, err = resourceClient.Get(ctx, name, metav1.GetOptions{})
+	case http.MethodPatch:
+		var body []byte
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		obj, err = resourceClient.Patch(ctx, name, types.PatchType(req.Header.Get("Content-Type")), body, metav1.PatchOptions{
+			FieldManager: req.URL.Query().Get("fieldManager"),
+		})
+	default:
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+
+	var result runtime.Object = obj
+	code := http.StatusOK
+	if err != nil {
+		status := apierrors.APIStatus(apierrors.NewInternalError(err))
+		if apiStatus, ok := err.(apierrors.APIStatus); ok {
+			status = apiStatus
+		}
+		statusObj := status.Status()
+		statusObj.APIVersion, statusObj.Kind = "v1", "Status"
+		result, code = &statusObj, int(statusObj.Code)
+	}
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, result)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}, nil
+}
+
+func configMaps(n int) []client.Object {
+	var objs []client.Object
+	for i := 0; i < n; i++ {
+		objs = append(objs, fake.ConfigMapObject(core.Namespace("shop"), core.Name(fmt.Sprintf("cm-%d", i))))
+	}
+	return objs
+}
+
+func TestNewClientSet_ShardedInventory(t *testing.T) {
+	defer func(size int64) { inventoryShardBytes = size }(inventoryShardBytes)
+	// About 3 ConfigMaps per shard, with their object sync statuses.
+	inventoryShardBytes = 4000
+
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClientSet := testingfake.NewClientSet(t, scheme)
+	f := &testFactory{
+		clientSet: fakeClientSet,
+		mapper:    fakeClientSet.Client.RESTMapper(),
+	}
+	cs, err := newClientSet(fakeClientSet.Client, f, StatusEnabled, configsync.ConflictPolicyForce, nil)
+	require.NoError(t, err)
+	sup, err := NewRootSupervisor(cs, "root-sync", 10*time.Second)
+	require.NoError(t, err)
+
+	shardCount := func() int {
+		rg := &unstructured.Unstructured{}
+		rg.SetGroupVersionKind(live.ResourceGroupGVK)
+		err := fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: "root-sync"}, rg)
+		require.NoError(t, err)
+		return resourcegroup.ShardCount(rg)
+	}
+	assertConfigMaps := func(n int) {
+		t.Helper()
+		for i := 0; i < 10; i++ {
+			cm := fake.ConfigMapObject()
+			err := fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: fmt.Sprintf("cm-%d", i)}, cm)
+			if i < n {
+				assert.NoError(t, err, "cm-%d", i)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err), "cm-%d: got %v", i, err)
+			}
+		}
+	}
+
+	// The applier stores a large inventory across several ResourceGroups.
+	_, errs := sup.Apply(ctx, configMaps(10))
+	require.NoError(t, errs)
+	assertConfigMaps(10)
+	assert.Equal(t, 4, shardCount())
+	objs, err := cs.InvClient.GetClusterObjs(sup.(*supervisor).inventory)
+	require.NoError(t, err)
+	assert.Len(t, objs, 10)
+
+	// The applier prunes the objects stored in every shard.
+	_, errs = sup.Apply(ctx, configMaps(2))
+	require.NoError(t, errs)
+	assertConfigMaps(2)
+	assert.Equal(t, 1, shardCount())
+	shard := &unstructured.Unstructured{}
+	shard.SetGroupVersionKind(kinds.ResourceGroup())
+	err = fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: resourcegroup.ShardName("root-sync", 1)}, shard)
+	assert.True(t, apierrors.IsNotFound(err), "got %v", err)
+
+	// The destroyer deletes the objects and the ResourceGroups of every shard.
+	_, errs = sup.Apply(ctx, configMaps(10))
+	require.NoError(t, errs)
+	assert.Equal(t, 4, shardCount())
+	errs = sup.Destroy(ctx, nil)
+	require.NoError(t, errs)
+	assertConfigMaps(0)
+	rgs := &unstructured.UnstructuredList{}
+	rgs.SetGroupVersionKind(kinds.ResourceGroup().GroupVersion().WithKind("ResourceGroupList"))
+	require.NoError(t, fakeClientSet.Client.List(ctx, rgs, client.InNamespace("config-management-system")))
+	assert.Empty(t, rgs.Items)
+}
+
+func TestNewClientSet_ObjectSyncStatus(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	// The ResourceGroups are stored as Unstructured, so that the fake client
+	// keeps the status fields unknown to the typed ResourceGroup.
+	scheme.AddKnownTypeWithName(live.ResourceGroupGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(live.ResourceGroupGVK.GroupVersion().WithKind("ResourceGroupList"), &unstructured.UnstructuredList{})
+	fakeClientSet := testingfake.NewClientSet(t, scheme)
+	f := &testFactory{
+		clientSet: fakeClientSet,
+		mapper:    fakeClientSet.Client.RESTMapper(),
+	}
+	cs, err := newClientSet(fakeClientSet.Client, f, StatusEnabled, configsync.ConflictPolicyForce, nil)
+	require.NoError(t, err)
+	sup, err := NewRootSupervisor(cs, "root-sync", 10*time.Second)
+	require.NoError(t, err)
+
+	// The object sync statuses are written with the inventory.
+	_, errs := sup.Apply(ctx, configMaps(2))
+	require.NoError(t, errs)
+	rg := &unstructured.Unstructured{}
+	rg.SetGroupVersionKind(live.ResourceGroupGVK)
+	require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: "root-sync"}, rg))
+	statuses, err := ObjectSyncStatuses(rg)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	for i, status := range statuses {
+		assert.Equal(t, fmt.Sprintf("cm-%d", i), status.Name)
+		assert.NotNil(t, status.LastAppliedTime, status.Name)
+	}
+
+	// Each shard records the statuses of the objects it stores.
+	defer func(size int64) { inventoryShardBytes = size }(inventoryShardBytes)
+	inventoryShardBytes = 4000
+	_, errs = sup.Apply(ctx, configMaps(10))
+	require.NoError(t, errs)
+	require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: "root-sync"}, rg))
+	require.Equal(t, 4, resourcegroup.ShardCount(rg))
+	count := 0
+	for i := 0; i < 4; i++ {
+		shard := &unstructured.Unstructured{}
+		shard.SetGroupVersionKind(live.ResourceGroupGVK)
+		require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: resourcegroup.ShardName("root-sync", i)}, shard))
+		objs, err := live.WrapInventoryObj(shard).Load()
+		require.NoError(t, err)
+		statuses, err := ObjectSyncStatuses(shard)
+		require.NoError(t, err)
+		require.Len(t, statuses, len(objs), shard.GetName())
+		for _, status := range statuses {
+			assert.True(t, objs.Contains(objMetaFromID(status.ID())), status.Name)
+		}
+		count += len(statuses)
+	}
+	assert.Equal(t, 10, count)
+}
+
+func TestNewClientSet_Handoff(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClientSet := testingfake.NewClientSet(t, scheme)
+	f := &testFactory{
+		clientSet: fakeClientSet,
+		mapper:    fakeClientSet.Client.RESTMapper(),
+	}
+	cs, err := newClientSet(fakeClientSet.Client, f, StatusEnabled, configsync.ConflictPolicyForce, nil)
+	require.NoError(t, err)
+	sup, err := NewRootSupervisor(cs, "root-sync", 10*time.Second)
+	require.NoError(t, err)
+
+	_, errs := sup.Apply(ctx, configMaps(2))
+	require.NoError(t, errs)
+
+	target := declared.ResourceManager("shop", "repo-sync")
+	objs := configMaps(2)
+	for _, obj := range objs {
+		core.SetAnnotation(obj, metadata.ResourceHandoffKey, target)
+	}
+	_, errs = sup.Apply(ctx, objs)
+	require.NoError(t, errs)
+
+	// The handoff of the objects is pending, so they are still managed by the
+	// RootSync, and marked for the RepoSync.
+	cm := fake.ConfigMapObject()
+	require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "cm-0"}, cm))
+	assert.Equal(t, InventoryID("root-sync", configsync.ControllerNamespace), core.GetAnnotation(cm, metadata.OwningInventoryKey))
+	assert.Equal(t, target, core.GetAnnotation(cm, metadata.ResourceHandoffKey))
+	invObjs, err := cs.InvClient.GetClusterObjs(sup.(*supervisor).inventory)
+	require.NoError(t, err)
+	require.Len(t, invObjs, 2)
+
+	// The RepoSync claims cm-0 and adds it to its inventory in the same apply,
+	// without a management conflict.
+	repoSup, err := NewNamespaceSupervisor(cs, "shop", "repo-sync", 10*time.Second)
+	require.NoError(t, err)
+	repoObjs := configMaps(1)
+	core.SetAnnotation(repoObjs[0], metadata.ResourceManagerKey, target)
+	_, errs = repoSup.Apply(ctx, repoObjs)
+	require.NoError(t, errs)
+	require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "cm-0"}, cm))
+	assert.Equal(t, InventoryID("repo-sync", "shop"), core.GetAnnotation(cm, metadata.OwningInventoryKey))
+	assert.Equal(t, target, core.GetAnnotation(cm, metadata.ResourceManagerKey))
+	assert.Empty(t, core.GetAnnotation(cm, metadata.ResourceHandoffKey))
+	invObjs, err = cs.InvClient.GetClusterObjs(repoSup.(*supervisor).inventory)
+	require.NoError(t, err)
+	require.Len(t, invObjs, 1)
+	assert.Equal(t, "cm-0", invObjs[0].Name)
+
+	// cm-1 is taken over by another reconciler, so it fails to be handed off.
+	require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "cm-1"}, cm))
+	core.SetAnnotation(cm, metadata.OwningInventoryKey, "other_rs")
+	require.NoError(t, fakeClientSet.Client.Update(ctx, cm))
+
+	// Only the object handed off successfully is removed from the inventory
+	// of the RootSync, and it is not pruned.
+	_, errs = sup.Apply(ctx, objs)
+	require.Error(t, errs)
+	require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "cm-0"}, cm))
+	invObjs, err = cs.InvClient.GetClusterObjs(sup.(*supervisor).inventory)
+	require.NoError(t, err)
+	require.Len(t, invObjs, 1)
+	assert.Equal(t, "cm-1", invObjs[0].Name)
+}
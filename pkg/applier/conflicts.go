@@ -0,0 +1,108 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/conflictpolicy"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/ignorefields"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resolveConflicts resolves the conflicts of the objects to apply with the
+// fields owned by other field managers, according to the conflict policy.
+// The ignored fields of the objects are set to their live values, so that they
+// are left unchanged by the apply.
+//
+// Returns the objects to apply, without the respected fields, the objects
+// skipped because of unresolved conflicts or errors, the respected fields of
+// each object, and any errors encountered. The skipped objects are recorded as
+// skipped in the objStatusMap.
+func (h *eventHandler) resolveConflicts(ctx context.Context, objs []*unstructured.Unstructured, defaultPolicy configsync.ConflictPolicy, objStatusMap ObjectStatusMap) ([]*unstructured.Unstructured, []client.Object, map[core.ID][]conflictpolicy.Conflict, status.MultiError) {
+	var toApply []*unstructured.Unstructured
+	var skipped []client.Object
+	respected := make(map[core.ID][]conflictpolicy.Conflict)
+	var errs status.MultiError
+	skip := func(obj *unstructured.Unstructured, err status.Error) {
+		skipped = append(skipped, obj)
+		objStatusMap[core.IDOf(obj)] = &ObjectStatus{
+			Strategy:  actuation.ActuationStrategyApply,
+			Actuation: actuation.ActuationSkipped,
+			Error:     err,
+		}
+		errs = status.Append(errs, err)
+	}
+	for _, obj := range objs {
+		ignored, err := ignorefields.Paths(obj, h.clientSet.IgnoreDifferences)
+		if err != nil {
+			skip(obj, status.InternalErrorBuilder.Wrap(err).BuildWithResources(obj))
+			continue
+		}
+		if len(ignored) == 0 && !conflictpolicy.NeedsLive(obj, defaultPolicy) {
+			toApply = append(toApply, obj)
+			continue
+		}
+		live, err := h.getLive(ctx, obj)
+		if err != nil {
+			klog.Warningf("failed to get %v to resolve its conflicts: %v", core.IDOf(obj), err)
+			skip(obj, status.APIServerErrorWrap(err, obj))
+			continue
+		}
+		if err := ignorefields.Preserve(obj, live, h.clientSet.IgnoreDifferences); err != nil {
+			skip(obj, status.InternalErrorBuilder.Wrap(err).BuildWithResources(obj))
+			continue
+		}
+		res, err := conflictpolicy.Resolve(obj, live, defaultPolicy)
+		if err != nil {
+			skip(obj, status.InternalErrorBuilder.Wrap(err).BuildWithResources(obj))
+			continue
+		}
+		if len(res.Respected) > 0 {
+			klog.Infof("Respecting the fields of %v owned by other field managers: %v", core.IDOf(obj), res.Respected)
+			respected[core.IDOf(obj)] = res.Respected
+		}
+		if res.Object == nil {
+			skip(obj, conflictpolicy.ConflictError(obj, res.Unresolved))
+			continue
+		}
+		toApply = append(toApply, res.Object)
+	}
+	return toApply, skipped, respected, errs
+}
+
+// getLive returns the live state of the object, or nil if it does not exist.
+func (h *eventHandler) getLive(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	err := h.clientSet.Client.Get(ctx, client.ObjectKeyFromObject(obj), live)
+	switch {
+	case err == nil:
+		return live, nil
+	case apierrors.IsNotFound(err), meta.IsNoMatchError(err):
+		// The object, or its type, does not exist yet.
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/conflictpolicy"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// liveClient is a client which only gets the live object. The fake client
+// drops the managed fields.
+type liveClient struct {
+	client.Client
+	live *unstructured.Unstructured
+}
+
+func (c liveClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	if c.live == nil || client.ObjectKeyFromObject(c.live) != key {
+		return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	c.live.DeepCopyInto(obj.(*unstructured.Unstructured))
+	return nil
+}
+
+func TestResolveConflicts(t *testing.T) {
+	newDeployment := func(paused bool, policy string) *unstructured.Unstructured {
+		obj := newDeploymentObj()
+		require.NoError(t, unstructured.SetNestedField(obj.Object, paused, "spec", "paused"))
+		if policy != "" {
+			core.SetAnnotation(obj, metadata.ConflictPolicyAnnotationKey, policy)
+		}
+		return obj
+	}
+	live := newDeployment(true, "")
+	live.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:    "kubectl-edit",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:paused":{}}}`)},
+	}})
+	pausedConflict := conflictpolicy.Conflict{Field: ".spec.paused", Manager: "kubectl-edit"}
+
+	testcases := []struct {
+		name          string
+		live          *unstructured.Unstructured
+		obj           *unstructured.Unstructured
+		defaultPolicy configsync.ConflictPolicy
+		wantApply     []*unstructured.Unstructured
+		wantSkipped   bool
+		wantRespected map[core.ID][]conflictpolicy.Conflict
+	}{
+		{
+			name:          "object not found",
+			obj:           newDeployment(false, ""),
+			defaultPolicy: configsync.ConflictPolicyFail,
+			wantApply:     []*unstructured.Unstructured{newDeployment(false, "")},
+			wantRespected: map[core.ID][]conflictpolicy.Conflict{},
+		},
+		{
+			name:          "force",
+			live:          live,
+			obj:           newDeployment(false, ""),
+			wantApply:     []*unstructured.Unstructured{newDeployment(false, "")},
+			wantRespected: map[core.ID][]conflictpolicy.Conflict{},
+		},
+		{
+			name: "respect",
+			live: live,
+			obj:  newDeployment(false, "respect"),
+			wantApply: []*unstructured.Unstructured{func() *unstructured.Unstructured {
+				obj := newDeployment(false, "respect")
+				unstructured.RemoveNestedField(obj.Object, "spec", "paused")
+				return obj
+			}()},
+			wantRespected: map[core.ID][]conflictpolicy.Conflict{
+				core.IDOf(live): {pausedConflict},
+			},
+		},
+		{
+			name:          "fail",
+			live:          live,
+			obj:           newDeployment(false, ""),
+			defaultPolicy: configsync.ConflictPolicyFail,
+			wantSkipped:   true,
+			wantRespected: map[core.ID][]conflictpolicy.Conflict{},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			eh := eventHandler{clientSet: &ClientSet{Client: liveClient{live: tc.live}}}
+			objStatusMap := make(ObjectStatusMap)
+
+			toApply, skipped, respected, errs := eh.resolveConflicts(context.Background(),
+				[]*unstructured.Unstructured{tc.obj}, tc.defaultPolicy, objStatusMap)
+			assert.Equal(t, tc.wantApply, toApply)
+			assert.Equal(t, tc.wantRespected, respected)
+			if !tc.wantSkipped {
+				assert.Empty(t, skipped)
+				assert.NoError(t, errs)
+				return
+			}
+			assert.Equal(t, []client.Object{tc.obj}, skipped)
+			assert.Contains(t, errs.Error(), "KNV"+conflictpolicy.FieldConflictErrorCode)
+			objStatus := objStatusMap[core.IDOf(tc.obj)]
+			require.NotNil(t, objStatus)
+			assert.Equal(t, actuation.ActuationSkipped, objStatus.Actuation)
+		})
+	}
+}
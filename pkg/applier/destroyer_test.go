@@ -199,7 +199,7 @@ func TestDestroy(t *testing.T) {
 			destroyer, err := NewNamespaceSupervisor(cs, "test-namespace", "rs", 5*time.Minute)
 			require.NoError(t, err)
 
-			errs := destroyer.Destroy(context.Background())
+			errs := destroyer.Destroy(context.Background(), nil)
 			testutil.AssertEqual(t, tc.multiErr, errs)
 		})
 	}
@@ -0,0 +1,320 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	nomosutil "kpt.dev/configsync/pkg/util"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/object/dependson"
+	"sigs.k8s.io/cli-utils/pkg/object/mutation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// objectHashes returns the hash of the declared content of each object.
+// The sync token is excluded, since it changes with every commit.
+func objectHashes(objs []*unstructured.Unstructured) map[core.ID]string {
+	hashes := make(map[core.ID]string, len(objs))
+	for _, obj := range objs {
+		u := obj.DeepCopy()
+		core.RemoveAnnotations(u, metadata.SyncTokenAnnotationKey)
+		data, err := json.Marshal(u.Object)
+		if err != nil {
+			// This should never happen. The object is always applied.
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[core.IDOf(obj)] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// incrementalSet splits the objects into the objects to apply and the objects
+// which are unchanged since the last successful apply, according to their
+// hashes. The objects to apply include the changed objects and the objects
+// they depend on, since the applier requires dependencies to be in the apply
+// set.
+//
+// All the objects are applied if there is no successful apply to compare to,
+// or if a Namespace or a CustomResourceDefinition was removed, since pruning
+// them also deletes the objects in them, which must then be checked by the
+// applier.
+func (a *supervisor) incrementalSet(objs []*unstructured.Unstructured, hashes map[core.ID]string) ([]*unstructured.Unstructured, []client.Object) {
+	if a.applied == nil {
+		return objs, nil
+	}
+	for id := range a.applied {
+		if _, found := hashes[id]; found {
+			continue
+		}
+		if id.GroupKind == kinds.Namespace().GroupKind() || id.GroupKind == kinds.CustomResourceDefinition() {
+			klog.Infof("Applying all objects, since %v was removed", id)
+			return objs, nil
+		}
+	}
+
+	byID := make(map[object.ObjMetadata]*unstructured.Unstructured, len(objs))
+	for _, obj := range objs {
+		byID[object.UnstructuredToObjMetadata(obj)] = obj
+	}
+	toApply := make(map[object.ObjMetadata]bool)
+	var queue []object.ObjMetadata
+	for _, obj := range objs {
+		id := core.IDOf(obj)
+		if prev, found := a.applied[id]; found && prev == hashes[id] {
+			continue
+		}
+		objMeta := object.UnstructuredToObjMetadata(obj)
+		toApply[objMeta] = true
+		queue = append(queue, objMeta)
+	}
+	for len(queue) > 0 {
+		obj := byID[queue[0]]
+		queue = queue[1:]
+		for _, dep := range dependencies(obj) {
+			if _, declared := byID[dep]; declared && !toApply[dep] {
+				toApply[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	var changed []*unstructured.Unstructured
+	var unchanged []client.Object
+	for _, obj := range objs {
+		if toApply[object.UnstructuredToObjMetadata(obj)] {
+			changed = append(changed, obj)
+		} else {
+			unchanged = append(unchanged, obj)
+		}
+	}
+	return changed, unchanged
+}
+
+// updateSyncTokens sets the sync token annotation of the unchanged objects on
+// the cluster to the token of the declared objects, since the applier did not
+// apply them. The hashes of the objects exclude the sync token, so that a new
+// commit does not change all of them. Objects which no longer exist are
+// skipped, since the remediator recreates them.
+func (a *supervisor) updateSyncTokens(ctx context.Context, unchanged []client.Object) status.MultiError {
+	token := syncToken(unchanged)
+	if token == "" || token == a.appliedToken {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{metadata.SyncTokenAnnotationKey: token},
+		},
+	})
+	if err != nil {
+		return Error(err)
+	}
+	klog.Infof("Updating the sync token of %d unchanged objects to %s", len(unchanged), token)
+	var errs status.MultiError
+	for _, obj := range unchanged {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+		u.SetNamespace(obj.GetNamespace())
+		u.SetName(obj.GetName())
+		err := a.clientSet.Client.Patch(ctx, u, client.RawPatch(types.MergePatchType, patch),
+			client.FieldOwner(configsync.FieldManager))
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = status.Append(errs, Error(fmt.Errorf("failed to update the sync token of %s: %w", core.GKNN(obj), err)))
+		}
+	}
+	return errs
+}
+
+// syncToken returns the sync token of the objects, which is the same for all
+// the objects of a commit.
+func syncToken(objs []client.Object) string {
+	for _, obj := range objs {
+		if token := core.GetAnnotation(obj, metadata.SyncTokenAnnotationKey); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// dependencies returns the objects the object depends on explicitly, through
+// the depends-on or the apply-time-mutation annotations. Invalid annotations
+// are ignored here and reported by the applier.
+func dependencies(obj *unstructured.Unstructured) []object.ObjMetadata {
+	var deps []object.ObjMetadata
+	if depSet, err := dependson.ReadAnnotation(obj); err == nil {
+		deps = append(deps, depSet...)
+	}
+	if mutation.HasAnnotation(obj) {
+		if atm, err := mutation.ReadAnnotation(obj); err == nil {
+			for _, sub := range atm {
+				deps = append(deps, sub.SourceRef.ToObjMetadata())
+			}
+		}
+	}
+	return deps
+}
+
+// incrementalInventoryClient is an inventory.Client which keeps the objects
+// skipped by an incremental apply in the inventory. The applier and the pruner
+// of cli-utils only see the objects passed to them, so the skipped objects are
+// hidden from the pruner, and added to every inventory write along with the
+// status they had before the apply. The inventory on the cluster always
+// includes them, so they are neither pruned nor forgotten if the reconciler
+// stops during the apply.
+type incrementalInventoryClient struct {
+	inventory.Client
+	client client.Client
+
+	mux sync.Mutex
+	// retained are the objects skipped by the running apply.
+	retained object.ObjMetadataSet
+	// retainedStatus is the status of the retained objects before the apply.
+	retainedStatus []actuation.ObjectStatus
+}
+
+var _ inventory.Client = &incrementalInventoryClient{}
+
+// retain keeps the objects in the inventory until release is called. It
+// records their current status, since cli-utils resets the status of the
+// objects in the inventory when the apply starts.
+//
+// The unchanged objects keep their previous status. The skipped objects are
+// only kept if they are already in the inventory, with the skipped status,
+// since Config Sync has not applied the others and must not prune them.
+func (c *incrementalInventoryClient) retain(ctx context.Context, inv inventory.Info, unchanged, skipped object.ObjMetadataSet) error {
+	shards, err := inventoryShards(ctx, c.client, inv)
+	if err != nil {
+		return err
+	}
+	objs, err := loadShards(shards)
+	if err != nil {
+		return err
+	}
+	statuses, err := loadShardStatuses(shards)
+	if err != nil {
+		return err
+	}
+	retained := append(object.ObjMetadataSet{}, unchanged...)
+	var retainedStatus []actuation.ObjectStatus
+	for _, id := range unchanged {
+		if status, found := statuses[id]; found {
+			retainedStatus = append(retainedStatus, status)
+		}
+	}
+	for _, id := range skipped {
+		if !objs.Contains(id) {
+			continue
+		}
+		retained = append(retained, id)
+		status, found := statuses[id]
+		if !found {
+			status = actuation.ObjectStatus{ObjectReference: inventory.ObjectReferenceFromObjMetadata(id)}
+		}
+		status.Strategy = actuation.ActuationStrategyApply
+		status.Actuation = actuation.ActuationSkipped
+		status.Reconcile = actuation.ReconcileSkipped
+		retainedStatus = append(retainedStatus, status)
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.retained = retained
+	c.retainedStatus = retainedStatus
+	return nil
+}
+
+// release stops keeping the retained objects in the inventory.
+func (c *incrementalInventoryClient) release() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.retained = nil
+	c.retainedStatus = nil
+}
+
+func (c *incrementalInventoryClient) retainedObjs() (object.ObjMetadataSet, []actuation.ObjectStatus) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.retained, c.retainedStatus
+}
+
+// GetClusterObjs implements inventory.Client. It excludes the retained
+// objects, so that the pruner does not delete them.
+func (c *incrementalInventoryClient) GetClusterObjs(inv inventory.Info) (object.ObjMetadataSet, error) {
+	objs, err := c.Client.GetClusterObjs(inv)
+	if err != nil {
+		return nil, err
+	}
+	retained, _ := c.retainedObjs()
+	return objs.Diff(retained), nil
+}
+
+// Merge implements inventory.Client. It keeps the retained objects in the
+// inventory.
+func (c *incrementalInventoryClient) Merge(inv inventory.Info, objs object.ObjMetadataSet, dryRun common.DryRunStrategy) (object.ObjMetadataSet, error) {
+	retained, _ := c.retainedObjs()
+	return c.Client.Merge(inv, objs.Union(retained), dryRun)
+}
+
+// Replace implements inventory.Client. It keeps the retained objects, and
+// their previous status, in the inventory.
+func (c *incrementalInventoryClient) Replace(inv inventory.Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus, dryRun common.DryRunStrategy) error {
+	retained, retainedStatus := c.retainedObjs()
+	if len(retained) == 0 {
+		return c.Client.Replace(inv, objs, status, dryRun)
+	}
+	var newStatus []actuation.ObjectStatus
+	for _, s := range status {
+		if !retained.Contains(inventory.ObjMetadataFromObjectReference(s.ObjectReference)) {
+			newStatus = append(newStatus, s)
+		}
+	}
+	newStatus = append(newStatus, retainedStatus...)
+	return c.Client.Replace(inv, objs.Union(retained), newStatus, dryRun)
+}
+
+// inventoryError wraps an error updating the inventory.
+func inventoryError(err error, rg *live.InventoryResourceGroup) status.Error {
+	if nomosutil.IsRequestTooLargeError(err) {
+		return largeResourceGroupError(err, idFromInventory(rg))
+	}
+	return Error(err)
+}
+
+// objMetasFrom returns the ObjMetadata of each object.
+func objMetasFrom(objs []client.Object) []object.ObjMetadata {
+	result := make([]object.ObjMetadata, len(objs))
+	for i, obj := range objs {
+		result[i] = ObjMetaFromObject(obj)
+	}
+	return result
+}
@@ -0,0 +1,343 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	resourcegroupv1alpha1 "kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/object/dependson"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestObjectHashes(t *testing.T) {
+	objs, errs := toUnstructured([]client.Object{
+		fake.ConfigMapObject(core.Name("cm"), core.Namespace("shop"), core.Annotation(metadata.SyncTokenAnnotationKey, "abc123")),
+	})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	before := objectHashes(objs)
+
+	core.SetAnnotation(objs[0], metadata.SyncTokenAnnotationKey, "def456")
+	if diff := cmp.Diff(before, objectHashes(objs)); diff != "" {
+		t.Errorf("the sync token changed the hash: %s", diff)
+	}
+
+	core.SetLabel(objs[0], "team", "shop")
+	if cmp.Equal(before, objectHashes(objs)) {
+		t.Error("a new label did not change the hash")
+	}
+}
+
+func TestIncrementalSet(t *testing.T) {
+	namespace := func() client.Object {
+		return fake.NamespaceObject("shop")
+	}
+	sa := func() client.Object {
+		return fake.ServiceAccountObject("sa", core.Namespace("shop"))
+	}
+	cm := func(opts ...core.MetaMutator) client.Object {
+		return fake.ConfigMapObject(append(opts, core.Name("cm"), core.Namespace("shop"))...)
+	}
+	deployment := func(opts ...core.MetaMutator) client.Object {
+		opts = append(opts, core.Name("deployment"), core.Namespace("shop"),
+			core.Annotation(dependson.Annotation, "/namespaces/shop/ServiceAccount/sa"))
+		return fake.DeploymentObject(opts...)
+	}
+	changed := core.Label("team", "shop")
+
+	testCases := []struct {
+		name          string
+		applied       []client.Object
+		declared      []client.Object
+		reapply       []client.Object
+		wantApply     []string
+		wantUnchanged []string
+	}{
+		{
+			name:      "first apply",
+			declared:  []client.Object{namespace(), sa(), cm()},
+			wantApply: []string{"ConfigMap", "Namespace", "ServiceAccount"},
+		},
+		{
+			name:          "nothing changed",
+			applied:       []client.Object{namespace(), sa(), cm()},
+			declared:      []client.Object{namespace(), sa(), cm()},
+			wantUnchanged: []string{"ConfigMap", "Namespace", "ServiceAccount"},
+		},
+		{
+			name:          "changed object",
+			applied:       []client.Object{namespace(), sa(), cm()},
+			declared:      []client.Object{namespace(), sa(), cm(changed)},
+			wantApply:     []string{"ConfigMap"},
+			wantUnchanged: []string{"Namespace", "ServiceAccount"},
+		},
+		{
+			name:          "changed object with dependencies",
+			applied:       []client.Object{namespace(), sa(), cm(), deployment()},
+			declared:      []client.Object{namespace(), sa(), cm(), deployment(changed)},
+			wantApply:     []string{"Deployment", "ServiceAccount"},
+			wantUnchanged: []string{"ConfigMap", "Namespace"},
+		},
+		{
+			name:          "reapplied object",
+			applied:       []client.Object{namespace(), sa(), cm()},
+			declared:      []client.Object{namespace(), sa(), cm()},
+			reapply:       []client.Object{cm()},
+			wantApply:     []string{"ConfigMap"},
+			wantUnchanged: []string{"Namespace", "ServiceAccount"},
+		},
+		{
+			name:          "removed object",
+			applied:       []client.Object{namespace(), sa(), cm()},
+			declared:      []client.Object{namespace(), sa()},
+			wantUnchanged: []string{"Namespace", "ServiceAccount"},
+		},
+		{
+			name:      "removed Namespace",
+			applied:   []client.Object{namespace(), fake.NamespaceObject("other"), cm()},
+			declared:  []client.Object{namespace(), cm()},
+			wantApply: []string{"ConfigMap", "Namespace"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &supervisor{}
+			if tc.applied != nil {
+				applied, errs := toUnstructured(tc.applied)
+				if errs != nil {
+					t.Fatal(errs)
+				}
+				a.applied = objectHashes(applied)
+			}
+			var reapplyIDs []core.ID
+			for _, obj := range tc.reapply {
+				reapplyIDs = append(reapplyIDs, core.IDOf(obj))
+			}
+			a.Reapply(reapplyIDs)
+			declared, errs := toUnstructured(tc.declared)
+			if errs != nil {
+				t.Fatal(errs)
+			}
+
+			toApply, unchanged := a.incrementalSet(declared, objectHashes(declared))
+			var gotApply, gotUnchanged []string
+			for _, obj := range toApply {
+				gotApply = append(gotApply, obj.GetKind())
+			}
+			for _, obj := range unchanged {
+				gotUnchanged = append(gotUnchanged, obj.(*unstructured.Unstructured).GetKind())
+			}
+			sort.Strings(gotApply)
+			sort.Strings(gotUnchanged)
+			if diff := cmp.Diff(tc.wantApply, gotApply); diff != "" {
+				t.Errorf("objects to apply: %s", diff)
+			}
+			if diff := cmp.Diff(tc.wantUnchanged, gotUnchanged); diff != "" {
+				t.Errorf("unchanged objects: %s", diff)
+			}
+		})
+	}
+}
+
+func TestIncrementalApply(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClientSet := testingfake.NewClientSet(t, scheme)
+	f := &testFactory{
+		clientSet: fakeClientSet,
+		mapper:    fakeClientSet.Client.RESTMapper(),
+	}
+	cs, err := newClientSet(fakeClientSet.Client, f, StatusEnabled, configsync.ConflictPolicyForce, nil)
+	require.NoError(t, err)
+	sup, err := NewRootSupervisor(cs, "root-sync", 10*time.Second)
+	require.NoError(t, err)
+
+	token := "commit-1"
+	configMap := func(i int, data string) client.Object {
+		cm := fake.ConfigMapObject(core.Namespace("shop"), core.Name(fmt.Sprintf("cm-%d", i)),
+			core.Annotation(metadata.SyncTokenAnnotationKey, token))
+		cm.Data = map[string]string{"key": data}
+		return cm
+	}
+	getConfigMap := func(i int) client.Object {
+		cm := fake.ConfigMapObject()
+		err := fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: fmt.Sprintf("cm-%d", i)}, cm)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		return cm
+	}
+	resourceVersion := func(i int) string {
+		if cm := getConfigMap(i); cm != nil {
+			return cm.GetResourceVersion()
+		}
+		return ""
+	}
+	inventoryStatus := func() map[object.ObjMetadata]actuation.ObjectStatus {
+		rg := &unstructured.Unstructured{}
+		rg.SetGroupVersionKind(kinds.ResourceGroup())
+		err := fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: "root-sync"}, rg)
+		require.NoError(t, err)
+		statuses, err := loadShardStatuses([]*unstructured.Unstructured{rg})
+		require.NoError(t, err)
+		return statuses
+	}
+	assertInventory := func(n int) {
+		t.Helper()
+		objs, err := cs.InvClient.GetClusterObjs(sup.(*supervisor).inventory)
+		require.NoError(t, err)
+		assert.Len(t, objs, n)
+		statuses := inventoryStatus()
+		assert.Len(t, statuses, n)
+		for id, status := range statuses {
+			assert.Equal(t, actuation.ActuationSucceeded, status.Actuation, "%v", id)
+			assert.Equal(t, actuation.ReconcileSucceeded, status.Reconcile, "%v", id)
+		}
+	}
+
+	_, errs := sup.Apply(ctx, []client.Object{configMap(0, "a"), configMap(1, "a"), configMap(2, "a")})
+	require.NoError(t, errs)
+	assertInventory(3)
+	rv0, rv1 := resourceVersion(0), resourceVersion(1)
+
+	// Only the changed object is applied. The unchanged objects stay in the
+	// inventory, with their status.
+	_, errs = sup.Apply(ctx, []client.Object{configMap(0, "a"), configMap(1, "b"), configMap(2, "a")})
+	require.NoError(t, errs)
+	assert.Equal(t, rv0, resourceVersion(0))
+	assert.NotEqual(t, rv1, resourceVersion(1))
+	assertInventory(3)
+
+	// The removed object is pruned, and the unchanged objects are not.
+	_, errs = sup.Apply(ctx, []client.Object{configMap(0, "a"), configMap(1, "b")})
+	require.NoError(t, errs)
+	assert.Equal(t, rv0, resourceVersion(0))
+	assert.Equal(t, "", resourceVersion(2))
+	assertInventory(2)
+
+	// The sync token of the unchanged objects is updated for a new commit.
+	token = "commit-2"
+	_, errs = sup.Apply(ctx, []client.Object{configMap(0, "a"), configMap(1, "c")})
+	require.NoError(t, errs)
+	assert.Equal(t, "commit-2", core.GetAnnotation(getConfigMap(0), metadata.SyncTokenAnnotationKey))
+	assert.Equal(t, "commit-2", core.GetAnnotation(getConfigMap(1), metadata.SyncTokenAnnotationKey))
+	assertInventory(2)
+	rv0 = resourceVersion(0)
+
+	// The unchanged objects are not updated again for the same commit.
+	_, errs = sup.Apply(ctx, []client.Object{configMap(0, "a"), configMap(1, "d")})
+	require.NoError(t, errs)
+	assert.Equal(t, rv0, resourceVersion(0))
+}
+
+func TestApply_IgnoredFieldTransition(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClientSet := testingfake.NewClientSet(t, scheme)
+	f := &testFactory{
+		clientSet: fakeClientSet,
+		mapper:    fakeClientSet.Client.RESTMapper(),
+	}
+	cs, err := newClientSet(fakeClientSet.Client, f, StatusEnabled, configsync.ConflictPolicyForce, nil)
+	require.NoError(t, err)
+	sup, err := NewRootSupervisor(cs, "root-sync", 10*time.Second)
+	require.NoError(t, err)
+
+	liveData := func() map[string]string {
+		cm := fake.ConfigMapObject()
+		require.NoError(t, fakeClientSet.Client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "cm"}, cm))
+		return cm.Data
+	}
+
+	// Config Sync applies the replicas key.
+	cm := fake.ConfigMapObject(core.Namespace("shop"), core.Name("cm"))
+	cm.Data = map[string]string{"key": "a", "replicas": "3"}
+	_, errs := sup.Apply(ctx, []client.Object{cm})
+	require.NoError(t, errs)
+	assert.Equal(t, map[string]string{"key": "a", "replicas": "3"}, liveData())
+
+	// The field is ignored, so the parser removes it from the declared object.
+	// The apply keeps its live value, instead of deleting it.
+	cm = fake.ConfigMapObject(core.Namespace("shop"), core.Name("cm"),
+		core.Annotation(metadata.IgnoreFieldsAnnotationKey, ".data.replicas"))
+	cm.Data = map[string]string{"key": "b"}
+	_, errs = sup.Apply(ctx, []client.Object{cm})
+	require.NoError(t, errs)
+	assert.Equal(t, map[string]string{"key": "b", "replicas": "3"}, liveData())
+}
+
+func TestIncrementalInventoryClient_Retain(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClientSet := testingfake.NewClientSet(t, scheme)
+	f := &testFactory{
+		clientSet: fakeClientSet,
+		mapper:    fakeClientSet.Client.RESTMapper(),
+	}
+	cs, err := newClientSet(fakeClientSet.Client, f, StatusEnabled, configsync.ConflictPolicyForce, nil)
+	require.NoError(t, err)
+	sup, err := NewRootSupervisor(cs, "root-sync", 10*time.Second)
+	require.NoError(t, err)
+
+	objs := configMaps(3)
+	_, errs := sup.Apply(ctx, objs[:2])
+	require.NoError(t, errs)
+	ids := objMetasFrom(objs)
+
+	// The unchanged object keeps its status. The skipped object which was
+	// applied before is kept with the skipped status, and the skipped object
+	// which was never applied is not added to the inventory.
+	inv := sup.(*supervisor).inventory
+	require.NoError(t, cs.incremental.retain(ctx, inv, object.ObjMetadataSet{ids[0]}, object.ObjMetadataSet{ids[1], ids[2]}))
+	defer cs.incremental.release()
+	retained, retainedStatus := cs.incremental.retainedObjs()
+	assert.Equal(t, object.ObjMetadataSet{ids[0], ids[1]}, retained)
+	require.Len(t, retainedStatus, 2)
+	assert.Equal(t, actuation.ActuationSucceeded, retainedStatus[0].Actuation)
+	assert.Equal(t, actuation.ReconcileSucceeded, retainedStatus[0].Reconcile)
+	assert.Equal(t, ids[1], inventory.ObjMetadataFromObjectReference(retainedStatus[1].ObjectReference))
+	assert.Equal(t, actuation.ActuationSkipped, retainedStatus[1].Actuation)
+	assert.Equal(t, actuation.ReconcileSkipped, retainedStatus[1].Reconcile)
+}
@@ -0,0 +1,460 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// inventoryShardBytes is the estimated size of the objects stored in each
+// ResourceGroup of a sharded inventory, with their object sync statuses. It
+// leaves room for the resource statuses written by the ResourceGroup
+// controller.
+var inventoryShardBytes = maxRequestBytes / 2
+
+// inventoryEntryBytes is the estimated size of the spec and status entries of
+// an object in a ResourceGroup, excluding its group, kind, namespace and name.
+const inventoryEntryBytes = 300
+
+// shardedInventoryClient is an inventory.Client which splits the inventory of
+// a RootSync or RepoSync across several ResourceGroups once it is too large to
+// be stored in one, since the size of a ResourceGroup is limited by the etcd
+// maxRequestBytes.
+//
+// The first shard is the inventory ResourceGroup. It records the number of
+// shards in the ResourceGroupShardsKey annotation. The other shards are named
+// by resourcegroup.ShardName and share the inventory ID of the first one, so
+// the objects keep the same owning inventory. Each object is stored in the
+// shard picked by the hash of its ID.
+//
+// Inventories which fit in a single ResourceGroup are handled by the wrapped
+// inventory.Client, so unsharded inventories are read and written as before.
+//
+// The methods of inventory.Client take no context, so the context of the
+// apply or destroy calling them is set by start.
+type shardedInventoryClient struct {
+	inventory.Client
+	client       client.Client
+	statusPolicy inventory.StatusPolicy
+	// syncStatus records the object sync statuses in each ResourceGroup of
+	// the inventory.
+	syncStatus *syncStatusRecorder
+
+	mux sync.Mutex
+	// ctx is the context of the running apply or destroy, if any.
+	ctx context.Context
+}
+
+var _ inventory.Client = &shardedInventoryClient{}
+
+// start uses the context of an apply or destroy for the requests of the
+// client, until stop is called.
+func (s *shardedInventoryClient) start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.ctx = ctx
+}
+
+// stop stops using the context passed to start.
+func (s *shardedInventoryClient) stop() {
+	if s == nil {
+		return
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.ctx = nil
+}
+
+// context returns the context of the running apply or destroy, or the
+// background context if there is none.
+func (s *shardedInventoryClient) context() context.Context {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// GetClusterObjs implements inventory.Client. It returns the objects of all
+// the shards.
+func (s *shardedInventoryClient) GetClusterObjs(inv inventory.Info) (object.ObjMetadataSet, error) {
+	shards, err := inventoryShards(s.context(), s.client, inv)
+	if err != nil {
+		return nil, err
+	}
+	return loadShards(shards)
+}
+
+// Merge implements inventory.Client.
+func (s *shardedInventoryClient) Merge(inv inventory.Info, objs object.ObjMetadataSet, dryRun common.DryRunStrategy) (object.ObjMetadataSet, error) {
+	ctx := s.context()
+	shards, err := inventoryShards(ctx, s.client, inv)
+	if err != nil {
+		return nil, err
+	}
+	clusterObjs, err := loadShards(shards)
+	if err != nil {
+		return nil, err
+	}
+	unionObjs := clusterObjs.Union(objs)
+	if len(shards) <= 1 && s.shardCount(unionObjs) == 1 {
+		return s.Client.Merge(inv, objs, dryRun)
+	}
+
+	pruneIds := clusterObjs.Diff(objs)
+	if dryRun.ClientOrServerDryRun() {
+		return pruneIds, nil
+	}
+	var status []actuation.ObjectStatus
+	if s.statusPolicy == inventory.StatusPolicyAll {
+		status = pendingStatus(pruneIds, unionObjs)
+	}
+	return pruneIds, s.store(ctx, inv, shards, unionObjs, status)
+}
+
+// Replace implements inventory.Client.
+func (s *shardedInventoryClient) Replace(inv inventory.Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus, dryRun common.DryRunStrategy) error {
+	if dryRun.ClientOrServerDryRun() {
+		return nil
+	}
+	ctx := s.context()
+	shards, err := inventoryShards(ctx, s.client, inv)
+	if err != nil {
+		return err
+	}
+	if len(shards) <= 1 && s.shardCount(objs) == 1 {
+		return s.Client.Replace(inv, objs, status, dryRun)
+	}
+	return s.store(ctx, inv, shards, objs, status)
+}
+
+// DeleteInventoryObj implements inventory.Client. It deletes the other shards
+// before the inventory ResourceGroup.
+func (s *shardedInventoryClient) DeleteInventoryObj(inv inventory.Info, dryRun common.DryRunStrategy) error {
+	if !dryRun.ClientOrServerDryRun() {
+		if err := s.deleteShards(s.context(), inv, 1); err != nil {
+			return err
+		}
+	}
+	return s.Client.DeleteInventoryObj(inv, dryRun)
+}
+
+// store splits the objects across the shards and writes them, from the last
+// shard to the first one.
+//
+// When the number of shards changes, objects move between shards. They are
+// first added to their new shard before being removed from their old one, so
+// that no object is missing from the inventory if the reconciler stops
+// midway. Shards which are no longer needed are deleted once the first shard
+// records the new number of shards.
+func (s *shardedInventoryClient) store(ctx context.Context, inv inventory.Info, shards []*unstructured.Unstructured, objs object.ObjMetadataSet, status []actuation.ObjectStatus) error {
+	count := s.shardCount(objs)
+	current := len(shards)
+	newObjs := splitObjects(objs, count)
+
+	if current > 0 && current != count {
+		total := count
+		if current > total {
+			total = current
+		}
+		for i := total - 1; i >= 0; i-- {
+			var union object.ObjMetadataSet
+			if i < current {
+				oldObjs, err := live.WrapInventoryObj(shards[i]).Load()
+				if err != nil {
+					return err
+				}
+				union = oldObjs
+			}
+			if i < count {
+				union = union.Union(newObjs[i])
+			}
+			if err := s.writeShard(ctx, inv, i, total, union, status); err != nil {
+				return err
+			}
+		}
+	}
+	for i := count - 1; i >= 0; i-- {
+		if err := s.writeShard(ctx, inv, i, count, newObjs[i], status); err != nil {
+			return err
+		}
+	}
+	if err := s.deleteShards(ctx, inv, count); err != nil {
+		return err
+	}
+	klog.V(3).Infof("Stored %d objects in %d ResourceGroups of inventory %s/%s", len(objs), count, inv.Namespace(), inv.Name())
+	return nil
+}
+
+// writeShard creates or updates the i-th shard with the specified objects and
+// their status. The first shard also records the number of shards.
+func (s *shardedInventoryClient) writeShard(ctx context.Context, inv inventory.Info, i, count int, objs object.ObjMetadataSet, status []actuation.ObjectStatus) error {
+	rg, err := getShard(ctx, s.client, inv, i)
+	if err != nil {
+		return err
+	}
+	found := rg != nil
+	if !found {
+		rg = live.InvToUnstructuredFunc(inv).DeepCopy()
+		rg.SetName(resourcegroup.ShardName(inv.Name(), i))
+		rg.SetResourceVersion("")
+		core.RemoveAnnotations(rg, metadata.ResourceGroupShardsKey)
+	}
+	if i == 0 {
+		if count > 1 {
+			core.SetAnnotation(rg, metadata.ResourceGroupShardsKey, strconv.Itoa(count))
+		} else {
+			core.RemoveAnnotations(rg, metadata.ResourceGroupShardsKey)
+		}
+	}
+	if s.statusPolicy == inventory.StatusPolicyNone {
+		status = nil
+	}
+	wrapped := live.WrapInventoryObj(rg)
+	if s.syncStatus != nil {
+		wrapped = s.syncStatus.wrapInventoryObj(rg)
+	}
+	if err := wrapped.Store(objs, status); err != nil {
+		return err
+	}
+	rg, err = wrapped.GetObject()
+	if err != nil {
+		return err
+	}
+	rgStatus, hasStatus, err := unstructured.NestedMap(rg.Object, "status")
+	if err != nil {
+		return err
+	}
+	if found {
+		err = s.client.Update(ctx, rg)
+	} else {
+		err = s.client.Create(ctx, rg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write ResourceGroup %s/%s: %w", rg.GetNamespace(), rg.GetName(), err)
+	}
+	if s.statusPolicy == inventory.StatusPolicyAll && hasStatus {
+		if err := unstructured.SetNestedMap(rg.Object, rgStatus, "status"); err != nil {
+			return err
+		}
+		if err := s.client.Status().Update(ctx, rg); err != nil {
+			return fmt.Errorf("failed to write the status of ResourceGroup %s/%s: %w", rg.GetNamespace(), rg.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// deleteShards deletes the shards from the specified index, from the last one
+// to the first one, so that the remaining shards are always contiguous.
+func (s *shardedInventoryClient) deleteShards(ctx context.Context, inv inventory.Info, from int) error {
+	last := from
+	for {
+		rg, err := getShard(ctx, s.client, inv, last)
+		if err != nil {
+			return err
+		}
+		if rg == nil {
+			break
+		}
+		last++
+	}
+	for i := last - 1; i >= from; i-- {
+		rg := resourcegroup.Unstructured(resourcegroup.ShardName(inv.Name(), i), inv.Namespace(), inv.ID())
+		if err := s.client.Delete(ctx, rg); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ResourceGroup %s/%s: %w", rg.GetNamespace(), rg.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// inventoryShards returns the ResourceGroups of the inventory, starting with
+// the inventory ResourceGroup. It returns no ResourceGroup if the inventory
+// does not exist.
+func inventoryShards(ctx context.Context, c client.Client, inv inventory.Info) ([]*unstructured.Unstructured, error) {
+	rg, err := getShard(ctx, c, inv, 0)
+	if err != nil || rg == nil {
+		return nil, err
+	}
+	shards := []*unstructured.Unstructured{rg}
+	for i := 1; i < resourcegroup.ShardCount(rg); i++ {
+		shard, err := getShard(ctx, c, inv, i)
+		if err != nil {
+			return nil, err
+		}
+		if shard == nil {
+			// The shard was deleted while the inventory was being torn down.
+			klog.Warningf("ResourceGroup %s/%s of inventory %s is missing", inv.Namespace(), resourcegroup.ShardName(inv.Name(), i), inv.ID())
+			shard = resourcegroup.Unstructured(resourcegroup.ShardName(inv.Name(), i), inv.Namespace(), inv.ID())
+		}
+		shards = append(shards, shard)
+	}
+	return shards, nil
+}
+
+// getShard returns the i-th shard of the inventory, or nil if it does not
+// exist. It returns an error if a ResourceGroup with the name of the shard
+// belongs to another inventory.
+func getShard(ctx context.Context, c client.Client, inv inventory.Info, i int) (*unstructured.Unstructured, error) {
+	rg := &unstructured.Unstructured{}
+	rg.SetGroupVersionKind(live.ResourceGroupGVK)
+	key := client.ObjectKey{Namespace: inv.Namespace(), Name: resourcegroup.ShardName(inv.Name(), i)}
+	if err := c.Get(ctx, key, rg); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ResourceGroup %s: %w", key, err)
+	}
+	if id := rg.GetLabels()[common.InventoryLabel]; i > 0 && id != inv.ID() {
+		return nil, fmt.Errorf("ResourceGroup %s belongs to inventory %q, not %q", key, id, inv.ID())
+	}
+	return rg, nil
+}
+
+// loadShards returns the objects stored in the shards.
+func loadShards(shards []*unstructured.Unstructured) (object.ObjMetadataSet, error) {
+	var objs object.ObjMetadataSet
+	for _, shard := range shards {
+		shardObjs, err := live.WrapInventoryObj(shard).Load()
+		if err != nil {
+			return nil, err
+		}
+		objs = objs.Union(shardObjs)
+	}
+	return objs, nil
+}
+
+// loadShardStatuses returns the status of the objects stored in the shards,
+// by object. The wrapped inventory.Client does not read them back.
+func loadShardStatuses(shards []*unstructured.Unstructured) (map[object.ObjMetadata]actuation.ObjectStatus, error) {
+	statuses := make(map[object.ObjMetadata]actuation.ObjectStatus)
+	for _, shard := range shards {
+		entries, _, err := unstructured.NestedSlice(shard.Object, "status", "resourceStatuses")
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			m, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field := func(name string) string {
+				value, _, _ := unstructured.NestedString(m, name)
+				return value
+			}
+			status := actuation.ObjectStatus{
+				ObjectReference: actuation.ObjectReference{
+					Group:     field("group"),
+					Kind:      field("kind"),
+					Namespace: field("namespace"),
+					Name:      field("name"),
+				},
+			}
+			for s := actuation.ActuationStrategyApply; s <= actuation.ActuationStrategyDelete; s++ {
+				if s.String() == field("strategy") {
+					status.Strategy = s
+				}
+			}
+			for a := actuation.ActuationPending; a <= actuation.ActuationFailed; a++ {
+				if a.String() == field("actuation") {
+					status.Actuation = a
+				}
+			}
+			for r := actuation.ReconcilePending; r <= actuation.ReconcileTimeout; r++ {
+				if r.String() == field("reconcile") {
+					status.Reconcile = r
+				}
+			}
+			statuses[inventory.ObjMetadataFromObjectReference(status.ObjectReference)] = status
+		}
+	}
+	return statuses, nil
+}
+
+// shardCount returns the number of ResourceGroups needed to store the objects,
+// and their object sync statuses if they are recorded.
+func (s *shardedInventoryClient) shardCount(objs object.ObjMetadataSet) int {
+	var extraBytes int64
+	if s.syncStatus != nil {
+		extraBytes = objectSyncStatusEntryBytes
+	}
+	return inventoryShardCount(objs, extraBytes)
+}
+
+// inventoryShardCount returns the number of ResourceGroups needed to store the
+// objects, with extraBytes more for each object.
+func inventoryShardCount(objs object.ObjMetadataSet, extraBytes int64) int {
+	var size int64
+	for _, id := range objs {
+		size += int64(len(id.GroupKind.Group)+len(id.GroupKind.Kind)+len(id.Namespace)+len(id.Name)+inventoryEntryBytes) + extraBytes
+	}
+	return int(size/inventoryShardBytes) + 1
+}
+
+// splitObjects splits the objects across the specified number of shards, by
+// the hash of their ID.
+func splitObjects(objs object.ObjMetadataSet, count int) []object.ObjMetadataSet {
+	result := make([]object.ObjMetadataSet, count)
+	for _, id := range objs {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(id.String()))
+		i := int(h.Sum32() % uint32(count))
+		result[i] = append(result[i], id)
+	}
+	return result
+}
+
+// pendingStatus returns the status stored for the objects of the inventory
+// before they are actuated, like the wrapped inventory.Client does.
+func pendingStatus(pruneIds, unionIds object.ObjMetadataSet) []actuation.ObjectStatus {
+	var status []actuation.ObjectStatus
+	for _, id := range unionIds {
+		status = append(status, actuation.ObjectStatus{
+			ObjectReference: inventory.ObjectReferenceFromObjMetadata(id),
+			Strategy:        actuation.ActuationStrategyApply,
+			Actuation:       actuation.ActuationPending,
+			Reconcile:       actuation.ReconcilePending,
+		})
+	}
+	for _, id := range pruneIds {
+		status = append(status, actuation.ObjectStatus{
+			ObjectReference: inventory.ObjectReferenceFromObjMetadata(id),
+			Strategy:        actuation.ActuationStrategyDelete,
+			Actuation:       actuation.ActuationPending,
+			Reconcile:       actuation.ReconcilePending,
+		})
+	}
+	return status
+}
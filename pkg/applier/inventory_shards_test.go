@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	resourcegroupv1alpha1 "kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// configMapIDs returns the IDs of n ConfigMaps.
+func configMapIDs(n int) object.ObjMetadataSet {
+	var ids object.ObjMetadataSet
+	for i := 0; i < n; i++ {
+		ids = append(ids, object.ObjMetadata{
+			GroupKind: kinds.ConfigMap().GroupKind(),
+			Namespace: "shop",
+			Name:      fmt.Sprintf("cm-%d", i),
+		})
+	}
+	return ids
+}
+
+func TestShardedInventoryClient(t *testing.T) {
+	defer func(size int64) { inventoryShardBytes = size }(inventoryShardBytes)
+	// About 3 ConfigMaps per shard.
+	inventoryShardBytes = 1000
+
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClient := testingfake.NewClient(t, scheme)
+	inner := inventory.NewFakeClient(nil)
+	s := &shardedInventoryClient{
+		Client:       inner,
+		client:       fakeClient,
+		statusPolicy: inventory.StatusPolicyAll,
+	}
+
+	invObj := newInventoryUnstructured(kinds.RootSyncV1Beta1().Kind, "root-sync", "config-management-system", StatusEnabled)
+	inv, err := wrapInventoryObj(invObj)
+	require.NoError(t, err)
+	shardKey := func(i int) client.ObjectKey {
+		return client.ObjectKey{Namespace: "config-management-system", Name: resourcegroup.ShardName("root-sync", i)}
+	}
+	getShard := func(i int) (*unstructured.Unstructured, error) {
+		rg := &unstructured.Unstructured{}
+		rg.SetGroupVersionKind(live.ResourceGroupGVK)
+		return rg, fakeClient.Get(ctx, shardKey(i), rg)
+	}
+
+	// Small inventories are stored by the wrapped client.
+	require.NoError(t, s.Replace(inv, configMapIDs(1), nil, common.DryRunNone))
+	innerObjs, err := inner.GetClusterObjs(inv)
+	require.NoError(t, err)
+	assert.Len(t, innerObjs, 1)
+
+	// Large inventories are split across several ResourceGroups.
+	require.NoError(t, fakeClient.Create(ctx, invObj.DeepCopy()))
+	pruneIds, err := s.Merge(inv, configMapIDs(10), common.DryRunNone)
+	require.NoError(t, err)
+	assert.Empty(t, pruneIds)
+	head, err := getShard(0)
+	require.NoError(t, err)
+	assert.Equal(t, "4", core.GetAnnotation(head, metadata.ResourceGroupShardsKey))
+	total := 0
+	for i := 0; i < 4; i++ {
+		shard, err := getShard(i)
+		require.NoError(t, err, "shard %d", i)
+		assert.Equal(t, inv.ID(), shard.GetLabels()[common.InventoryLabel])
+		objs, err := live.WrapInventoryObj(shard).Load()
+		require.NoError(t, err)
+		total += len(objs)
+		statuses, _, err := unstructured.NestedSlice(shard.Object, "status", "resourceStatuses")
+		require.NoError(t, err)
+		assert.Len(t, statuses, len(objs))
+	}
+	assert.Equal(t, 10, total, "each object is stored in a single shard")
+	objs, err := s.GetClusterObjs(inv)
+	require.NoError(t, err)
+	assert.True(t, objs.Equal(configMapIDs(10)), "got %v", objs)
+
+	// Merging fewer objects returns the objects to prune.
+	pruneIds, err = s.Merge(inv, configMapIDs(8), common.DryRunNone)
+	require.NoError(t, err)
+	assert.True(t, pruneIds.Equal(configMapIDs(10)[8:]), "got %v", pruneIds)
+
+	// The shards which are no longer needed are deleted.
+	require.NoError(t, s.Replace(inv, configMapIDs(2), nil, common.DryRunNone))
+	head, err = getShard(0)
+	require.NoError(t, err)
+	assert.Empty(t, core.GetAnnotation(head, metadata.ResourceGroupShardsKey))
+	_, err = getShard(1)
+	assert.True(t, apierrors.IsNotFound(err), "got %v", err)
+	objs, err = s.GetClusterObjs(inv)
+	require.NoError(t, err)
+	assert.True(t, objs.Equal(configMapIDs(2)), "got %v", objs)
+
+	// Deleting the inventory deletes all the shards.
+	require.NoError(t, s.Replace(inv, configMapIDs(10), nil, common.DryRunNone))
+	require.NoError(t, s.DeleteInventoryObj(inv, common.DryRunNone))
+	for i := 1; i < 4; i++ {
+		_, err = getShard(i)
+		assert.True(t, apierrors.IsNotFound(err), "shard %d: got %v", i, err)
+	}
+}
+
+func TestShardedInventoryClient_NameConflict(t *testing.T) {
+	defer func(size int64) { inventoryShardBytes = size }(inventoryShardBytes)
+	inventoryShardBytes = 1000
+
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClient := testingfake.NewClient(t, scheme)
+	s := &shardedInventoryClient{
+		Client:       inventory.NewFakeClient(nil),
+		client:       fakeClient,
+		statusPolicy: inventory.StatusPolicyNone,
+	}
+
+	invObj := newInventoryUnstructured(kinds.RootSyncV1Beta1().Kind, "root-sync", "config-management-system", StatusDisabled)
+	inv, err := wrapInventoryObj(invObj)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Create(ctx, invObj.DeepCopy()))
+	// The inventory of a RootSync named like a shard.
+	other := newInventoryUnstructured(kinds.RootSyncV1Beta1().Kind, "root-sync-shard-1", "config-management-system", StatusDisabled)
+	require.NoError(t, fakeClient.Create(ctx, other))
+
+	err = s.Replace(inv, configMapIDs(10), nil, common.DryRunNone)
+	assert.ErrorContains(t, err, `belongs to inventory "config-management-system_root-sync-shard-1"`)
+}
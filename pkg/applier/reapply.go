@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reapply marks the objects with the specified IDs to be reapplied by the next
+// Apply, even if they are unchanged since the last successful Apply.
+// Reapply implements the Applier interface.
+func (a *supervisor) Reapply(ids []core.ID) {
+	a.execMux.Lock()
+	defer a.execMux.Unlock()
+
+	if a.reapply == nil {
+		a.reapply = make(map[core.ID]struct{}, len(ids))
+	}
+	for _, id := range ids {
+		delete(a.applied, id)
+		a.reapply[id] = struct{}{}
+	}
+}
+
+// reapplyObjects returns the objects marked to be reapplied.
+func (a *supervisor) reapplyObjects(objs []client.Object) []client.Object {
+	var reapplyObjs []client.Object
+	for _, obj := range objs {
+		if _, found := a.reapply[core.IDOf(obj)]; found {
+			reapplyObjs = append(reapplyObjs, obj)
+		}
+	}
+	return reapplyObjs
+}
+
+// claimObjects sets the manager and the owning inventory of the specified
+// objects to this reconciler, so that the applier reapplies them even if they
+// are managed by another reconciler.
+// Returns the number of objects which are claimed successfully, and any errors
+// encountered.
+func (h *eventHandler) claimObjects(ctx context.Context, inventoryID string, objs []client.Object) (uint64, status.MultiError) {
+	var claimCount uint64
+	var errs status.MultiError
+	for _, obj := range objs {
+		err := h.claimObject(ctx, obj, inventoryID)
+		handleMetrics(ctx, "claim", err)
+		if err != nil {
+			klog.Warningf("failed to claim %v: %v", core.IDOf(obj), err)
+			errs = status.Append(errs, err)
+		} else {
+			claimCount++
+		}
+	}
+	return claimCount, errs
+}
+
+// claimObject sets the manager and the owning inventory of an object to the
+// ones of the declared object.
+func (h *eventHandler) claimObject(ctx context.Context, obj client.Object, inventoryID string) status.Error {
+	gvk, err := kinds.Lookup(obj, h.clientSet.Client.Scheme())
+	if err != nil {
+		return Error(err)
+	}
+	uObj := &unstructured.Unstructured{}
+	uObj.SetGroupVersionKind(gvk)
+	err = h.clientSet.Client.Get(ctx, client.ObjectKeyFromObject(obj), uObj)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// The applier creates the object.
+			return nil
+		}
+		return Error(err)
+	}
+	manager := core.GetAnnotation(obj, metadata.ResourceManagerKey)
+	if core.GetAnnotation(uObj, metadata.OwningInventoryKey) == inventoryID &&
+		core.GetAnnotation(uObj, metadata.ResourceManagerKey) == manager {
+		// Already ours.
+		return nil
+	}
+	klog.Infof("Claiming object %s for reapply", core.IDOf(obj))
+	return h.patchOwner(ctx, uObj, inventoryID, manager)
+}
+
+// patchOwner sets the owning inventory and the manager, if not empty, of the
+// live object, and removes its handoff annotation.
+func (h *eventHandler) patchOwner(ctx context.Context, uObj *unstructured.Unstructured, inventoryID, manager string) status.Error {
+	// Use minimal before & after objects to simplify DeepCopy and building
+	// the merge patch.
+	fromObj := &unstructured.Unstructured{}
+	fromObj.SetGroupVersionKind(uObj.GroupVersionKind())
+	fromObj.SetNamespace(uObj.GetNamespace())
+	fromObj.SetName(uObj.GetName())
+	fromObj.SetAnnotations(uObj.GetAnnotations())
+
+	toObj := fromObj.DeepCopy()
+	core.SetAnnotation(toObj, metadata.OwningInventoryKey, inventoryID)
+	if manager != "" {
+		core.SetAnnotation(toObj, metadata.ResourceManagerKey, manager)
+	}
+	core.RemoveAnnotations(toObj, metadata.ResourceHandoffKey)
+	err := h.clientSet.Client.Patch(ctx, toObj, client.MergeFrom(fromObj),
+		client.FieldOwner(configsync.FieldManager))
+	if err != nil {
+		return Error(err)
+	}
+	return nil
+}
@@ -209,6 +209,27 @@ func (s *DisabledObjStats) Empty() bool {
 	return s == nil || s.Total == 0
 }
 
+// HandoffObjStats tracks the stats for objects handed off to another manager
+type HandoffObjStats struct {
+	// Total tracks the number of objects to be handed off
+	Total uint64
+	// Succeeded tracks how many objects were handed off successfully
+	Succeeded uint64
+}
+
+// String returns the stats as a human readable String.
+func (s HandoffObjStats) String() string {
+	if s.Empty() {
+		return ""
+	}
+	return fmt.Sprintf("handed off %d out of %d objects", s.Succeeded, s.Total)
+}
+
+// Empty returns true if no events were recorded.
+func (s *HandoffObjStats) Empty() bool {
+	return s == nil || s.Total == 0
+}
+
 // SyncStats tracks the stats for all the events
 type SyncStats struct {
 	ApplyEvent  *ApplyEventStats
@@ -216,6 +237,7 @@ type SyncStats struct {
 	DeleteEvent *DeleteEventStats
 	WaitEvent   *WaitEventStats
 	DisableObjs *DisabledObjStats
+	HandoffObjs *HandoffObjStats
 	// ErrorTypeEvents tracks the number of ErrorType events
 	ErrorTypeEvents uint64
 }
@@ -238,6 +260,9 @@ func (s SyncStats) String() string {
 	if !s.DisableObjs.Empty() {
 		strs = append(strs, s.DisableObjs.String())
 	}
+	if !s.HandoffObjs.Empty() {
+		strs = append(strs, s.HandoffObjs.String())
+	}
 	if s.ErrorTypeEvents > 0 {
 		strs = append(strs, fmt.Sprintf("ErrorEvents: %d", s.ErrorTypeEvents))
 	}
@@ -246,7 +271,7 @@ func (s SyncStats) String() string {
 
 // Empty returns true if no events were recorded.
 func (s *SyncStats) Empty() bool {
-	return s == nil || s.ErrorTypeEvents == 0 && s.PruneEvent.Empty() && s.DeleteEvent.Empty() && s.ApplyEvent.Empty() && s.WaitEvent.Empty() && s.DisableObjs.Empty()
+	return s == nil || s.ErrorTypeEvents == 0 && s.PruneEvent.Empty() && s.DeleteEvent.Empty() && s.ApplyEvent.Empty() && s.WaitEvent.Empty() && s.DisableObjs.Empty() && s.HandoffObjs.Empty()
 }
 
 // NewSyncStats constructs a SyncStats with empty event maps.
@@ -257,5 +282,6 @@ func NewSyncStats() *SyncStats {
 		DeleteEvent: &DeleteEventStats{},
 		WaitEvent:   &WaitEventStats{},
 		DisableObjs: &DisabledObjStats{},
+		HandoffObjs: &HandoffObjStats{},
 	}
 }
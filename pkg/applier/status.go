@@ -32,6 +32,8 @@ type ObjectStatus struct {
 	Actuation actuation.ActuationStatus
 	// Reconcile indicates whether reconciliation has been performed yet and how it went.
 	Reconcile actuation.ReconcileStatus
+	// Error is the actuation or reconcile error, if any.
+	Error error
 }
 
 // ObjectStatusMap is a map of object IDs to ObjectStatus.
@@ -152,6 +154,16 @@ func (m ObjectStatusMap) Filter(
 	return ids
 }
 
+// setError records the actuation or reconcile error of the object, if any.
+func (m ObjectStatusMap) setError(id core.ID, err error) {
+	if err == nil {
+		return
+	}
+	if status, found := m[id]; found && status != nil {
+		status.Error = err
+	}
+}
+
 // actuationStatuses is the list of ActuationStatus enums in order for logging.
 var actuationStatuses = []actuation.ActuationStatus{
 	// actuation.ActuationPending, // Don't log pending actuation. It doesn't emit for all objects.
@@ -0,0 +1,400 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/conflictpolicy"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxObjectHistory is the number of recent transitions kept for each
+	// object.
+	maxObjectHistory = 5
+
+	// maxObjectErrorLength is the maximum length of a recorded error message.
+	maxObjectErrorLength = 512
+
+	// objectSyncStatusEntryBytes is the estimated size of the object sync
+	// status of an object, with its history. It is counted in the size of
+	// each shard of the inventory, so that the statuses usually fit.
+	objectSyncStatusEntryBytes = 1024
+
+	// maxObjectSyncStatusBytes is the maximum size of the object sync statuses
+	// of a ResourceGroup. Long errors can make the statuses larger than
+	// estimated, so they are truncated beyond this size, a quarter of
+	// maxRequestBytes, to keep the ResourceGroup under the etcd request size
+	// limit.
+	maxObjectSyncStatusBytes = 384 * 1024
+)
+
+const (
+	// historyDroppedReason is the reason of the
+	// ObjectSyncStatusTruncatedCondition when the history of the objects was
+	// dropped.
+	historyDroppedReason = "HistoryDropped"
+
+	// statusDroppedReason is the reason of the
+	// ObjectSyncStatusTruncatedCondition when the statuses of the objects were
+	// dropped.
+	statusDroppedReason = "StatusDropped"
+)
+
+// ObjectSyncStatus is the sync status of a managed object, recorded in the
+// `.status.objectSyncStatuses` of the ResourceGroup of the inventory which
+// stores the object. It is written with the inventory by the
+// syncStatusRecorder, and kept by the ResourceGroup controller when it updates
+// the resource statuses.
+type ObjectSyncStatus struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// LastAppliedCommit is the source commit of the last successful apply.
+	LastAppliedCommit string `json:"lastAppliedCommit,omitempty"`
+	// LastAppliedTime is the time of the last successful apply.
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+	// LastError is the last actuation or reconcile error.
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime is the time of the last actuation or reconcile error.
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+	// ForeignFields are the declared fields respected by the last apply,
+	// because they are owned by other field managers.
+	ForeignFields []conflictpolicy.Conflict `json:"foreignFields,omitempty"`
+	// History is the list of recent transitions, oldest first.
+	// The History is dropped if the statuses would be too large.
+	History []ObjectTransition `json:"history,omitempty"`
+}
+
+// ObjectTransition is a change in the sync status of a managed object.
+type ObjectTransition struct {
+	// Time is when the transition was observed.
+	Time metav1.Time `json:"time"`
+	// Commit is the source commit being synced.
+	Commit string `json:"commit,omitempty"`
+	// Strategy is the method of actuation: Apply or Delete.
+	Strategy string `json:"strategy"`
+	// Actuation indicates how the actuation went.
+	Actuation string `json:"actuation"`
+	// Reconcile indicates how the reconciliation went.
+	Reconcile string `json:"reconcile"`
+	// Error is the actuation or reconcile error, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// ID returns the ID of the object.
+func (s ObjectSyncStatus) ID() core.ID {
+	return core.ID{
+		GroupKind: schema.GroupKind{Group: s.Group, Kind: s.Kind},
+		ObjectKey: client.ObjectKey{Namespace: s.Namespace, Name: s.Name},
+	}
+}
+
+// sameState returns true if the transitions have the same commit and status,
+// regardless of when they were observed.
+func (t ObjectTransition) sameState(other ObjectTransition) bool {
+	t.Time = other.Time
+	return t == other
+}
+
+// ObjectSyncStatuses returns the object sync statuses recorded in the status
+// of the ResourceGroup, if any.
+func ObjectSyncStatuses(rg *unstructured.Unstructured) ([]ObjectSyncStatus, error) {
+	items, _, err := unstructured.NestedSlice(rg.Object, "status", resourcegroup.ObjectSyncStatusesField)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]ObjectSyncStatus, 0, len(items))
+	for _, item := range items {
+		content, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid status.%s: %T is not an object", resourcegroup.ObjectSyncStatusesField, item)
+		}
+		var s ObjectSyncStatus
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &s); err != nil {
+			return nil, fmt.Errorf("invalid status.%s: %w", resourcegroup.ObjectSyncStatusesField, err)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// updateObjectSyncStatuses merges the result of an apply into the previous
+// object sync statuses, and returns the new statuses sorted by object ID.
+// Only the desired objects are kept, so pruned objects are dropped.
+// The foreignFields are the fields respected by the apply, by object ID.
+func updateObjectSyncStatuses(prev []ObjectSyncStatus, objs []client.Object, objStatusMap ObjectStatusMap, foreignFields map[core.ID][]conflictpolicy.Conflict, now metav1.Time) []ObjectSyncStatus {
+	prevMap := make(map[core.ID]ObjectSyncStatus, len(prev))
+	for _, s := range prev {
+		prevMap[s.ID()] = s
+	}
+
+	statuses := make([]ObjectSyncStatus, 0, len(objs))
+	for _, obj := range objs {
+		id := core.IDOf(obj)
+		s, found := prevMap[id]
+		if !found {
+			s = ObjectSyncStatus{
+				Group:     id.Group,
+				Kind:      id.Kind,
+				Namespace: id.Namespace,
+				Name:      id.Name,
+			}
+		}
+		// Objects without a status were not actuated, for example because the
+		// apply was interrupted. Keep their previous status.
+		if objStatus, found := objStatusMap[id]; found && objStatus != nil {
+			commit := core.GetAnnotation(obj, metadata.SyncTokenAnnotationKey)
+			s = updateObjectSyncStatus(s, *objStatus, commit, now)
+			s.ForeignFields = foreignFields[id]
+		}
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID().String() < statuses[j].ID().String()
+	})
+	return statuses
+}
+
+func updateObjectSyncStatus(s ObjectSyncStatus, objStatus ObjectStatus, commit string, now metav1.Time) ObjectSyncStatus {
+	t := ObjectTransition{
+		Time:      now,
+		Commit:    commit,
+		Strategy:  objStatus.Strategy.String(),
+		Actuation: objStatus.Actuation.String(),
+		Reconcile: objStatus.Reconcile.String(),
+		Error:     objectErrorMessage(objStatus),
+	}
+	if objStatus.Strategy == actuation.ActuationStrategyApply && objStatus.Actuation == actuation.ActuationSucceeded {
+		s.LastAppliedCommit = commit
+		s.LastAppliedTime = now.DeepCopy()
+	}
+	if t.Error != "" {
+		s.LastError = t.Error
+		s.LastErrorTime = now.DeepCopy()
+	}
+	if len(s.History) == 0 || !s.History[len(s.History)-1].sameState(t) {
+		// Copy the history, to avoid modifying the previous statuses.
+		history := append([]ObjectTransition(nil), s.History...)
+		history = append(history, t)
+		if len(history) > maxObjectHistory {
+			history = history[len(history)-maxObjectHistory:]
+		}
+		s.History = history
+	}
+	return s
+}
+
+// objectErrorMessage returns the truncated error message of the object, or a
+// generic message if the reconciliation failed or timed out without error.
+func objectErrorMessage(objStatus ObjectStatus) string {
+	var msg string
+	switch {
+	case objStatus.Error != nil:
+		msg = objStatus.Error.Error()
+	case objStatus.Reconcile == actuation.ReconcileFailed || objStatus.Reconcile == actuation.ReconcileTimeout:
+		msg = fmt.Sprintf("reconcile %s", strings.ToLower(objStatus.Reconcile.String()))
+	}
+	if len(msg) > maxObjectErrorLength {
+		msg = msg[:maxObjectErrorLength] + "..."
+	}
+	return msg
+}
+
+// truncateObjectSyncStatuses returns the object sync statuses to record, and
+// the ObjectSyncStatusTruncatedCondition of the ResourceGroup. The history is
+// dropped if the statuses are larger than maxObjectSyncStatusBytes, and all the
+// statuses are dropped if they are still too large. The condition is empty if
+// the statuses are not truncated.
+func truncateObjectSyncStatuses(statuses []ObjectSyncStatus) ([]ObjectSyncStatus, v1alpha1.Condition, error) {
+	size, err := objectSyncStatusesSize(statuses)
+	if err != nil || size <= maxObjectSyncStatusBytes {
+		return statuses, v1alpha1.Condition{}, err
+	}
+	trimmed := make([]ObjectSyncStatus, len(statuses))
+	for i, s := range statuses {
+		s.History = nil
+		trimmed[i] = s
+	}
+	trimmedSize, err := objectSyncStatusesSize(trimmed)
+	if err != nil {
+		return nil, v1alpha1.Condition{}, err
+	}
+	condition := v1alpha1.Condition{
+		Type:   resourcegroup.ObjectSyncStatusTruncatedCondition,
+		Status: v1alpha1.TrueConditionStatus,
+	}
+	if trimmedSize <= maxObjectSyncStatusBytes {
+		condition.Reason = historyDroppedReason
+		condition.Message = fmt.Sprintf("The history of the objects was dropped: the object sync statuses are too large (size: %d, max: %d)", size, maxObjectSyncStatusBytes)
+		return trimmed, condition, nil
+	}
+	condition.Reason = statusDroppedReason
+	condition.Message = fmt.Sprintf("The object sync statuses were dropped: they are too large, even without history (size: %d, max: %d)", trimmedSize, maxObjectSyncStatusBytes)
+	return nil, condition, nil
+}
+
+// objectSyncStatusesSize returns the size of the object sync statuses, encoded
+// as JSON.
+func objectSyncStatusesSize(statuses []ObjectSyncStatus) (int64, error) {
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// setObjectSyncStatuses sets the object sync statuses and the
+// ObjectSyncStatusTruncatedCondition of the ResourceGroup.
+func setObjectSyncStatuses(rg *unstructured.Unstructured, statuses []ObjectSyncStatus, condition v1alpha1.Condition) error {
+	if len(statuses) == 0 {
+		unstructured.RemoveNestedField(rg.Object, "status", resourcegroup.ObjectSyncStatusesField)
+	} else {
+		items := make([]interface{}, len(statuses))
+		for i := range statuses {
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&statuses[i])
+			if err != nil {
+				return err
+			}
+			items[i] = content
+		}
+		if err := unstructured.SetNestedSlice(rg.Object, items, "status", resourcegroup.ObjectSyncStatusesField); err != nil {
+			return err
+		}
+	}
+	if condition.Status == "" {
+		condition.Type = resourcegroup.ObjectSyncStatusTruncatedCondition
+	}
+	return resourcegroup.SetCondition(rg, condition)
+}
+
+// syncStatusRecorder records the object sync statuses of the running apply in
+// the status of the ResourceGroups of the inventory, as part of the inventory
+// writes of the apply, so that they need no write of their own. Each
+// ResourceGroup records the statuses of the objects it stores, so the
+// statuses are sharded with the inventory.
+//
+// The inventory is written by the task runner of cli-utils, while the events
+// of the apply update the objStatusMap. The runner sends an event, on an
+// unbuffered channel, before starting each inventory task, so the statuses of
+// the previous events are recorded by the time the inventory is written.
+type syncStatusRecorder struct {
+	mux sync.Mutex
+	// active is true while an apply is running.
+	active        bool
+	objs          []client.Object
+	objStatusMap  ObjectStatusMap
+	foreignFields map[core.ID][]conflictpolicy.Conflict
+}
+
+// start records the statuses of the apply of objs in the next inventory
+// writes, until stop is called.
+func (r *syncStatusRecorder) start(objs []client.Object, objStatusMap ObjectStatusMap, foreignFields map[core.ID][]conflictpolicy.Conflict) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.active = true
+	r.objs = objs
+	r.objStatusMap = objStatusMap
+	r.foreignFields = foreignFields
+}
+
+// stop stops recording the statuses of the apply.
+func (r *syncStatusRecorder) stop() {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.active = false
+	r.objs = nil
+	r.objStatusMap = nil
+	r.foreignFields = nil
+}
+
+// record updates the object sync statuses of the inventory ResourceGroup rg,
+// which stores the objects ids, with the statuses of the running apply, if
+// any. Failures are logged, but do not block the inventory write.
+func (r *syncStatusRecorder) record(rg *unstructured.Unstructured, ids object.ObjMetadataSet) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if !r.active {
+		return
+	}
+	prev, err := ObjectSyncStatuses(rg)
+	if err != nil {
+		klog.Warningf("Resetting the object sync status of ResourceGroup %s/%s: %v", rg.GetNamespace(), rg.GetName(), err)
+	}
+	var objs []client.Object
+	for _, obj := range r.objs {
+		if ids.Contains(ObjMetaFromObject(obj)) {
+			objs = append(objs, obj)
+		}
+	}
+	statuses := updateObjectSyncStatuses(prev, objs, r.objStatusMap, r.foreignFields, metav1.Now())
+	statuses, condition, err := truncateObjectSyncStatuses(statuses)
+	if err == nil {
+		err = setObjectSyncStatuses(rg, statuses, condition)
+	}
+	if err != nil {
+		klog.Warningf("Failed to record the object sync status of ResourceGroup %s/%s: %v", rg.GetNamespace(), rg.GetName(), err)
+	}
+}
+
+// wrapInventoryObj wraps the inventory ResourceGroup rg in an inventory
+// Storage which records the object sync statuses in it when it is stored.
+func (r *syncStatusRecorder) wrapInventoryObj(rg *unstructured.Unstructured) inventory.Storage {
+	return &syncStatusStorage{Storage: live.WrapInventoryObj(rg), rg: rg, recorder: r}
+}
+
+// syncStatusStorage is the inventory Storage of the inventory ResourceGroup.
+// The wrapped Storage writes a copy of rg, so the object sync statuses are set
+// on rg before the objects are stored.
+type syncStatusStorage struct {
+	inventory.Storage
+	rg       *unstructured.Unstructured
+	recorder *syncStatusRecorder
+}
+
+// Store implements inventory.Storage.
+func (s *syncStatusStorage) Store(objs object.ObjMetadataSet, status []actuation.ObjectStatus) error {
+	s.recorder.record(s.rg, objs)
+	return s.Storage.Store(objs, status)
+}
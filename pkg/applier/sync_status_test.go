@@ -0,0 +1,320 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/conflictpolicy"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func withCommit(u *unstructured.Unstructured, commit string) *unstructured.Unstructured {
+	core.SetAnnotation(u, metadata.SyncTokenAnnotationKey, commit)
+	return u
+}
+
+func syncStatusFor(u *unstructured.Unstructured) ObjectSyncStatus {
+	id := core.IDOf(u)
+	return ObjectSyncStatus{
+		Group:     id.Group,
+		Kind:      id.Kind,
+		Namespace: id.Namespace,
+		Name:      id.Name,
+	}
+}
+
+func TestUpdateObjectSyncStatuses(t *testing.T) {
+	t1 := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	t2 := metav1.NewTime(t1.Add(time.Minute))
+
+	deployment := withCommit(newDeploymentObj(), "def456")
+	test1 := withCommit(newTestObj("test-1"), "def456")
+	test2 := withCommit(newTestObj("test-2"), "def456")
+
+	appliedDeployment := syncStatusFor(deployment)
+	appliedDeployment.LastAppliedCommit = "abc123"
+	appliedDeployment.LastAppliedTime = &t1
+	appliedDeployment.History = []ObjectTransition{
+		{Time: t1, Commit: "abc123", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+	}
+
+	testcases := []struct {
+		name          string
+		prev          []ObjectSyncStatus
+		objs          []client.Object
+		objStatusMap  ObjectStatusMap
+		foreignFields map[core.ID][]conflictpolicy.Conflict
+		expected      []ObjectSyncStatus
+	}{
+		{
+			name: "new object applied",
+			objs: []client.Object{deployment},
+			objStatusMap: ObjectStatusMap{
+				core.IDOf(deployment): {Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSucceeded, Reconcile: actuation.ReconcileSucceeded},
+			},
+			expected: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := syncStatusFor(deployment)
+					s.LastAppliedCommit = "def456"
+					s.LastAppliedTime = &t2
+					s.History = []ObjectTransition{
+						{Time: t2, Commit: "def456", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+					}
+					return s
+				}(),
+			},
+		},
+		{
+			name: "unchanged state does not add a transition",
+			prev: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := appliedDeployment
+					s.History = []ObjectTransition{
+						{Time: t1, Commit: "def456", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+					}
+					return s
+				}(),
+			},
+			objs: []client.Object{deployment},
+			objStatusMap: ObjectStatusMap{
+				core.IDOf(deployment): {Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSucceeded, Reconcile: actuation.ReconcileSucceeded},
+			},
+			expected: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := appliedDeployment
+					s.LastAppliedCommit = "def456"
+					s.LastAppliedTime = &t2
+					s.History = []ObjectTransition{
+						{Time: t1, Commit: "def456", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+					}
+					return s
+				}(),
+			},
+		},
+		{
+			name: "failed apply keeps the last applied commit",
+			prev: []ObjectSyncStatus{appliedDeployment},
+			objs: []client.Object{deployment},
+			objStatusMap: ObjectStatusMap{
+				core.IDOf(deployment): {Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationFailed, Error: errors.New("failed to apply")},
+			},
+			expected: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := appliedDeployment
+					s.LastError = "failed to apply"
+					s.LastErrorTime = &t2
+					s.History = []ObjectTransition{
+						{Time: t1, Commit: "abc123", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+						{Time: t2, Commit: "def456", Strategy: "Apply", Actuation: "Failed", Reconcile: "Pending", Error: "failed to apply"},
+					}
+					return s
+				}(),
+			},
+		},
+		{
+			name: "reconcile timeout is recorded as an error",
+			objs: []client.Object{test1},
+			objStatusMap: ObjectStatusMap{
+				core.IDOf(test1): {Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSucceeded, Reconcile: actuation.ReconcileTimeout},
+			},
+			expected: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := syncStatusFor(test1)
+					s.LastAppliedCommit = "def456"
+					s.LastAppliedTime = &t2
+					s.LastError = "reconcile timeout"
+					s.LastErrorTime = &t2
+					s.History = []ObjectTransition{
+						{Time: t2, Commit: "def456", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Timeout", Error: "reconcile timeout"},
+					}
+					return s
+				}(),
+			},
+		},
+		{
+			name: "respected fields are recorded",
+			prev: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := appliedDeployment
+					s.ForeignFields = []conflictpolicy.Conflict{{Field: ".spec.paused", Manager: "kubectl-edit"}}
+					return s
+				}(),
+			},
+			objs: []client.Object{deployment},
+			objStatusMap: ObjectStatusMap{
+				core.IDOf(deployment): {Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSucceeded, Reconcile: actuation.ReconcileSucceeded},
+			},
+			foreignFields: map[core.ID][]conflictpolicy.Conflict{
+				core.IDOf(deployment): {{Field: ".spec.replicas", Manager: "kube-controller-manager"}},
+			},
+			expected: []ObjectSyncStatus{
+				func() ObjectSyncStatus {
+					s := appliedDeployment
+					s.LastAppliedCommit = "def456"
+					s.LastAppliedTime = &t2
+					s.ForeignFields = []conflictpolicy.Conflict{{Field: ".spec.replicas", Manager: "kube-controller-manager"}}
+					s.History = []ObjectTransition{
+						{Time: t1, Commit: "abc123", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+						{Time: t2, Commit: "def456", Strategy: "Apply", Actuation: "Succeeded", Reconcile: "Succeeded"},
+					}
+					return s
+				}(),
+			},
+		},
+		{
+			name: "objects not actuated keep their status and pruned objects are dropped",
+			prev: []ObjectSyncStatus{appliedDeployment, syncStatusFor(test2)},
+			objs: []client.Object{test1, deployment},
+			objStatusMap: ObjectStatusMap{
+				core.IDOf(test1): {Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSkipped, Error: errors.New("skipped")},
+				core.IDOf(test2): {Strategy: actuation.ActuationStrategyDelete, Actuation: actuation.ActuationSucceeded},
+			},
+			expected: []ObjectSyncStatus{
+				appliedDeployment,
+				func() ObjectSyncStatus {
+					s := syncStatusFor(test1)
+					s.LastError = "skipped"
+					s.LastErrorTime = &t2
+					s.History = []ObjectTransition{
+						{Time: t2, Commit: "def456", Strategy: "Apply", Actuation: "Skipped", Reconcile: "Pending", Error: "skipped"},
+					}
+					return s
+				}(),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := updateObjectSyncStatuses(tc.prev, tc.objs, tc.objStatusMap, tc.foreignFields, t2)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestUpdateObjectSyncStatusHistoryLimit(t *testing.T) {
+	obj := newDeploymentObj()
+	s := syncStatusFor(obj)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxObjectHistory+2; i++ {
+		now := metav1.NewTime(start.Add(time.Duration(i) * time.Minute))
+		s = updateObjectSyncStatus(s, ObjectStatus{
+			Strategy:  actuation.ActuationStrategyApply,
+			Actuation: actuation.ActuationSucceeded,
+			Reconcile: actuation.ReconcileSucceeded,
+		}, fmt.Sprintf("commit-%d", i), now)
+	}
+	require.Len(t, s.History, maxObjectHistory)
+	assert.Equal(t, "commit-2", s.History[0].Commit)
+	assert.Equal(t, fmt.Sprintf("commit-%d", maxObjectHistory+1), s.History[maxObjectHistory-1].Commit)
+	assert.Equal(t, fmt.Sprintf("commit-%d", maxObjectHistory+1), s.LastAppliedCommit)
+}
+
+func TestTruncateObjectSyncStatuses(t *testing.T) {
+	longError := strings.Repeat("x", maxObjectErrorLength)
+	newStatuses := func(count int) []ObjectSyncStatus {
+		var statuses []ObjectSyncStatus
+		for i := 0; i < count; i++ {
+			s := syncStatusFor(newTestObj(fmt.Sprintf("test-%d", i)))
+			s.History = []ObjectTransition{
+				{Commit: "abc123", Strategy: "Apply", Actuation: "Failed", Reconcile: "Pending", Error: longError},
+			}
+			statuses = append(statuses, s)
+		}
+		return statuses
+	}
+
+	t.Run("small statuses are recorded with history", func(t *testing.T) {
+		statuses := newStatuses(2)
+		truncated, condition, err := truncateObjectSyncStatuses(statuses)
+		require.NoError(t, err)
+		assert.Empty(t, condition.Status)
+		rg := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		require.NoError(t, setObjectSyncStatuses(rg, truncated, condition))
+		decoded, err := ObjectSyncStatuses(rg)
+		require.NoError(t, err)
+		assert.Equal(t, statuses, decoded)
+		_, found, err := unstructured.NestedSlice(rg.Object, "status", "conditions")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("history is dropped when too large", func(t *testing.T) {
+		truncated, condition, err := truncateObjectSyncStatuses(newStatuses(1000))
+		require.NoError(t, err)
+		require.Len(t, truncated, 1000)
+		for _, s := range truncated {
+			assert.Empty(t, s.History)
+		}
+		assert.Equal(t, v1alpha1.TrueConditionStatus, condition.Status)
+		assert.Equal(t, historyDroppedReason, condition.Reason)
+	})
+
+	t.Run("statuses are dropped when too large without history", func(t *testing.T) {
+		statuses := newStatuses(1000)
+		for i := range statuses {
+			statuses[i].LastError = longError
+		}
+		truncated, condition, err := truncateObjectSyncStatuses(statuses)
+		require.NoError(t, err)
+		assert.Empty(t, truncated)
+		assert.Equal(t, statusDroppedReason, condition.Reason)
+
+		// The condition is removed once the statuses fit again.
+		rg := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		require.NoError(t, setObjectSyncStatuses(rg, truncated, condition))
+		conditions, _, err := unstructured.NestedSlice(rg.Object, "status", "conditions")
+		require.NoError(t, err)
+		require.Len(t, conditions, 1)
+		require.NoError(t, setObjectSyncStatuses(rg, newStatuses(1), v1alpha1.Condition{}))
+		_, found, err := unstructured.NestedSlice(rg.Object, "status", "conditions")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+}
+
+func TestSyncStatusRecorder(t *testing.T) {
+	test1 := newTestObj("test-1")
+	test2 := newTestObj("test-2")
+	objStatusMap := ObjectStatusMap{
+		core.IDOf(test1): &ObjectStatus{Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSucceeded, Reconcile: actuation.ReconcileSucceeded},
+		core.IDOf(test2): &ObjectStatus{Strategy: actuation.ActuationStrategyApply, Actuation: actuation.ActuationSucceeded, Reconcile: actuation.ReconcileSucceeded},
+	}
+	r := &syncStatusRecorder{}
+	r.start([]client.Object{test1, test2}, objStatusMap, nil)
+	defer r.stop()
+
+	// Each ResourceGroup records the statuses of the objects it stores.
+	rg := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	r.record(rg, object.ObjMetadataSet{ObjMetaFromObject(test2)})
+	statuses, err := ObjectSyncStatuses(rg)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, core.IDOf(test2), statuses[0].ID())
+}
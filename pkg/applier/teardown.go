@@ -0,0 +1,317 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/lifecycle"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/differ"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/object/graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The built-in ranks of the teardown order. Objects of a lower rank are
+// deleted first.
+const (
+	// webhookRank is for admission webhooks and aggregated APIs, which are
+	// usually served by managed workloads and would otherwise fail the
+	// deletion of the other objects once their backends are gone.
+	webhookRank = iota
+	// customResourceRank is for custom resources, which are deleted while
+	// their controllers and definitions still exist.
+	customResourceRank
+	// workloadRank is for workloads and any other built-in objects.
+	workloadRank
+	// rbacRank is for RBAC objects and ServiceAccounts, which the workloads
+	// may need until they are gone.
+	rbacRank
+	// crdRank is for CustomResourceDefinitions.
+	crdRank
+	// namespaceRank is for Namespaces, which are deleted last.
+	namespaceRank
+)
+
+// teardownPollInterval is how often the objects of a level are checked while
+// waiting for their deletion.
+var teardownPollInterval = time.Second
+
+// DestroyProgress describes the level being deleted by an ordered teardown.
+type DestroyProgress struct {
+	// Level is the 1-based index of the level being deleted.
+	Level int
+	// Levels is the number of levels of the teardown.
+	Levels int
+	// Kinds are the sorted kinds of the objects of the level.
+	Kinds []string
+}
+
+// String returns a message suitable for the ReconcilerFinalizing condition.
+func (p DestroyProgress) String() string {
+	return fmt.Sprintf("Deleting level %d of %d: %s", p.Level, p.Levels, strings.Join(p.Kinds, ", "))
+}
+
+// DestroyProgressFunc is called by the Destroyer before deleting each level of
+// managed objects.
+type DestroyProgressFunc func(DestroyProgress)
+
+// teardownRank returns the built-in rank of an object in the teardown order.
+func teardownRank(obj *unstructured.Unstructured) int {
+	gk := obj.GroupVersionKind().GroupKind()
+	switch gk {
+	case kinds.APIService().GroupKind():
+		return webhookRank
+	case kinds.CustomResourceDefinition():
+		return crdRank
+	case kinds.Namespace().GroupKind():
+		return namespaceRank
+	case kinds.ServiceAccount().GroupKind():
+		return rbacRank
+	}
+	switch gk.Group {
+	case admissionv1.GroupName:
+		return webhookRank
+	case rbacv1.GroupName:
+		return rbacRank
+	}
+	if isCustomResource(gk) {
+		return customResourceRank
+	}
+	return workloadRank
+}
+
+// isCustomResource returns true if the group is not one of the built-in
+// Kubernetes API groups. The built-in groups either have no dot in their name,
+// like "apps", or end with ".k8s.io".
+func isCustomResource(gk schema.GroupKind) bool {
+	return strings.Contains(gk.Group, ".") && !strings.HasSuffix(gk.Group, ".k8s.io")
+}
+
+// teardownLevels groups the objects into the levels of the teardown order.
+// The objects of a level may be deleted together, once the objects of the
+// previous levels are gone. The levels follow the built-in ranks, unless the
+// depends-on annotations, namespaces or CRDs require an object to be deleted
+// after one of a higher rank.
+func teardownLevels(objs object.UnstructuredSet) ([]object.UnstructuredSet, *graph.Graph, error) {
+	g, err := graph.DependencyGraph(objs)
+	if err != nil {
+		// Invalid depends-on annotations, like external dependencies, don't
+		// block the teardown. Their edges are not part of the graph.
+		klog.Warningf("Ignoring invalid dependencies during teardown: %v", err)
+	}
+	// Detect cycles, which would keep the levels from converging.
+	if _, err := g.Sort(); err != nil {
+		return nil, nil, err
+	}
+	ids := object.UnstructuredSetToObjMetadataSet(objs)
+	levels := make(map[object.ObjMetadata]int, len(objs))
+	for i, obj := range objs {
+		levels[ids[i]] = teardownRank(obj)
+	}
+	// An object is deleted after the objects which depend on it. Raise the
+	// levels of the dependencies until none is lower than its dependents.
+	for changed := true; changed; {
+		changed = false
+		for _, id := range ids {
+			for _, dep := range g.Dependencies(id) {
+				if depLevel, found := levels[dep]; found && depLevel <= levels[id] {
+					levels[dep] = levels[id] + 1
+					changed = true
+				}
+			}
+		}
+	}
+	byLevel := make(map[int]object.UnstructuredSet)
+	var keys []int
+	for i, obj := range objs {
+		level := levels[ids[i]]
+		if _, found := byLevel[level]; !found {
+			keys = append(keys, level)
+		}
+		byLevel[level] = append(byLevel[level], obj)
+	}
+	sort.Ints(keys)
+	result := make([]object.UnstructuredSet, len(keys))
+	for i, key := range keys {
+		result[i] = byLevel[key]
+	}
+	return result, g, nil
+}
+
+// levelKinds returns the sorted kinds of the objects of a level.
+func levelKinds(objs object.UnstructuredSet) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, obj := range objs {
+		kind := obj.GetKind()
+		if !seen[kind] {
+			seen[kind] = true
+			result = append(result, kind)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// teardownInner deletes the live objects of the inventory level by level, in
+// the teardown order, and waits up to the reconcile timeout for each level to
+// be gone before deleting the next one.
+//
+// Objects which are not owned by the inventory, or which have the
+// prevent-deletion annotation, are retained, along with the objects they
+// depend on. They are left to the cli-utils destroyer, which reports why they
+// can't be deleted.
+func (a *supervisor) teardownInner(ctx context.Context, progress DestroyProgressFunc) status.Error {
+	objs, err := a.liveInventoryObjects(ctx)
+	if err != nil {
+		return Error(err)
+	}
+	levels, g, err := teardownLevels(objs)
+	if err != nil {
+		return Error(err)
+	}
+	retained := make(map[object.ObjMetadata]bool)
+	for i, level := range levels {
+		var deleted object.UnstructuredSet
+		for _, obj := range level {
+			id := object.UnstructuredToObjMetadata(obj)
+			if !a.deletable(obj) || dependentRetained(g, id, retained) {
+				retained[id] = true
+				continue
+			}
+			deleted = append(deleted, obj)
+		}
+		if len(deleted) == 0 {
+			continue
+		}
+		p := DestroyProgress{Level: i + 1, Levels: len(levels), Kinds: levelKinds(deleted)}
+		klog.Info(p.String())
+		if progress != nil {
+			progress(p)
+		}
+		if err := a.deleteLevel(ctx, deleted); err != nil {
+			return Error(fmt.Errorf("failed to delete level %d of %d: %w", p.Level, p.Levels, err))
+		}
+	}
+	return nil
+}
+
+// liveInventoryObjects returns the live objects tracked by all the shards of
+// the inventory.
+func (a *supervisor) liveInventoryObjects(ctx context.Context) (object.UnstructuredSet, error) {
+	c := a.clientSet.Client
+	shards, err := inventoryShards(ctx, c, a.inventory)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := loadShards(shards)
+	if err != nil {
+		return nil, err
+	}
+	var objs object.UnstructuredSet
+	for _, id := range ids {
+		mapping, err := c.RESTMapper().RESTMapping(id.GroupKind)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				// The resource type is gone, and so are its objects.
+				continue
+			}
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(mapping.GroupVersionKind)
+		err = c.Get(ctx, client.ObjectKey{Name: id.Name, Namespace: id.Namespace}, obj)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// deletable returns true if the ordered teardown may delete the object.
+func (a *supervisor) deletable(obj *unstructured.Unstructured) bool {
+	if owner := obj.GetAnnotations()[metadata.OwningInventoryKey]; owner != a.inventory.ID() {
+		return false
+	}
+	if lifecycle.HasPreventDeletion(obj) {
+		return false
+	}
+	return !(isNamespace(obj) && differ.SpecialNamespaces[obj.GetName()])
+}
+
+// dependentRetained returns true if an object which depends on the specified
+// object is retained.
+func dependentRetained(g *graph.Graph, id object.ObjMetadata, retained map[object.ObjMetadata]bool) bool {
+	for _, dependent := range g.Dependents(id) {
+		if retained[dependent] {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteLevel deletes the objects of a level and waits for them to be gone.
+func (a *supervisor) deleteLevel(ctx context.Context, objs object.UnstructuredSet) error {
+	c := a.clientSet.Client
+	for _, obj := range objs {
+		err := c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationForeground))
+		handleMetrics(ctx, "delete", err)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("deleting %s: %w", object.UnstructuredToObjMetadata(obj), err)
+		}
+	}
+	var remaining object.ObjMetadataSet
+	err := wait.PollImmediateWithContext(ctx, teardownPollInterval, a.reconcileTimeout, func(ctx context.Context) (bool, error) {
+		remaining = nil
+		for _, obj := range objs {
+			current := &unstructured.Unstructured{}
+			current.SetGroupVersionKind(obj.GroupVersionKind())
+			err := c.Get(ctx, client.ObjectKeyFromObject(obj), current)
+			switch {
+			case apierrors.IsNotFound(err):
+			case err != nil:
+				return false, err
+			case current.GetUID() == obj.GetUID():
+				remaining = append(remaining, object.UnstructuredToObjMetadata(obj))
+			}
+		}
+		return len(remaining) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out after %v waiting for the deletion of %v", a.reconcileTimeout, remaining)
+	}
+	return err
+}
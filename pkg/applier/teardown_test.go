@@ -0,0 +1,196 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	resourcegroupv1alpha1 "kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/object/dependson"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// levelNames returns the names of the objects of each level.
+func levelNames(levels []object.UnstructuredSet) [][]string {
+	var result [][]string
+	for _, level := range levels {
+		var names []string
+		for _, obj := range level {
+			names = append(names, obj.GetName())
+		}
+		result = append(result, names)
+	}
+	return result
+}
+
+func TestTeardownLevels(t *testing.T) {
+	crd := fake.UnstructuredObject(kinds.CustomResourceDefinitionV1(), core.Name("anvils.acme.com"))
+	crdObj := func(opts ...core.MetaMutator) *unstructured.Unstructured {
+		return fake.UnstructuredObject(kinds.Anvil(), append([]core.MetaMutator{core.Name("anvil"), core.Namespace("shop")}, opts...)...)
+	}
+	namespace := fake.UnstructuredObject(kinds.Namespace(), core.Name("shop"))
+	deployment := func(opts ...core.MetaMutator) *unstructured.Unstructured {
+		return fake.UnstructuredObject(kinds.Deployment(), append([]core.MetaMutator{core.Name("deployment"), core.Namespace("shop")}, opts...)...)
+	}
+	sa := fake.UnstructuredObject(kinds.ServiceAccount(), core.Name("sa"), core.Namespace("shop"))
+	clusterRole := fake.UnstructuredObject(kinds.ClusterRole(), core.Name("cluster-role"))
+	webhook := fake.UnstructuredObject(kinds.ValidatingWebhookConfiguration(), core.Name("webhook"))
+
+	testCases := []struct {
+		name       string
+		objs       object.UnstructuredSet
+		wantLevels [][]string
+		wantErr    bool
+	}{
+		{
+			name: "built-in order",
+			objs: object.UnstructuredSet{namespace, crd, clusterRole, sa, deployment(), crdObj(), webhook},
+			wantLevels: [][]string{
+				{"webhook"},
+				{"anvil"},
+				{"deployment"},
+				{"cluster-role", "sa"},
+				{"anvils.acme.com"},
+				{"shop"},
+			},
+		},
+		{
+			name: "depends-on raises the level of a dependency",
+			objs: object.UnstructuredSet{
+				namespace,
+				crdObj(),
+				deployment(core.Annotation(dependson.Annotation, "acme.com/namespaces/shop/Anvil/anvil")),
+				sa,
+			},
+			wantLevels: [][]string{
+				{"deployment"},
+				{"anvil", "sa"},
+				{"shop"},
+			},
+		},
+		{
+			name: "external dependencies are ignored",
+			objs: object.UnstructuredSet{
+				deployment(core.Annotation(dependson.Annotation, "/namespaces/other/ServiceAccount/sa")),
+				sa,
+			},
+			wantLevels: [][]string{
+				{"deployment"},
+				{"sa"},
+			},
+		},
+		{
+			name: "cyclic dependencies",
+			objs: object.UnstructuredSet{
+				crdObj(core.Annotation(dependson.Annotation, "apps/namespaces/shop/Deployment/deployment")),
+				deployment(core.Annotation(dependson.Annotation, "acme.com/namespaces/shop/Anvil/anvil")),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels, _, err := teardownLevels(tc.objs)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantLevels, levelNames(levels))
+		})
+	}
+}
+
+func TestTeardown(t *testing.T) {
+	inventoryID := InventoryID("root-sync", "config-management-system")
+	owned := core.Annotation(metadata.OwningInventoryKey, inventoryID)
+	objs := []*unstructured.Unstructured{
+		fake.UnstructuredObject(kinds.Namespace(), core.Name("shop"), owned),
+		fake.UnstructuredObject(kinds.Deployment(), core.Name("deployment"), core.Namespace("shop"), owned),
+		fake.UnstructuredObject(kinds.ServiceAccount(), core.Name("sa"), core.Namespace("shop"), owned),
+		fake.UnstructuredObject(kinds.RepoSyncV1Beta1(), core.Name("repo-sync"), core.Namespace("shop"), owned),
+		fake.UnstructuredObject(kinds.ClusterRole(), core.Name("cluster-role"), owned),
+		// The ConfigMap and the Role can't be deleted, so neither can their
+		// Namespace.
+		fake.UnstructuredObject(kinds.Namespace(), core.Name("kept"), owned),
+		fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("kept"), owned,
+			core.Annotation(common.LifecycleDeleteAnnotation, common.PreventDeletion)),
+		fake.UnstructuredObject(kinds.Role(), core.Name("role"), core.Namespace("kept"),
+			core.Annotation(metadata.OwningInventoryKey, "other")),
+	}
+	// The inventory is stored as a typed ResourceGroup by the fake client.
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1beta1.AddToScheme(scheme))
+	require.NoError(t, resourcegroupv1alpha1.AddToScheme(scheme))
+	fakeClient := testingfake.NewClient(t, scheme)
+	for _, obj := range objs {
+		require.NoError(t, fakeClient.Create(context.Background(), obj.DeepCopy()))
+	}
+
+	cs := &ClientSet{
+		KptDestroyer: newFakeKptDestroyer(nil),
+		Client:       fakeClient,
+	}
+	destroyer, err := NewRootSupervisor(cs, "root-sync", 5*time.Second)
+	require.NoError(t, err)
+	inv := destroyer.(*supervisor).inventory
+	require.NoError(t, inv.Store(object.UnstructuredSetToObjMetadataSet(objs), nil))
+	invObj, err := inv.GetObject()
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Create(context.Background(), invObj))
+
+	var progress []DestroyProgress
+	errs := destroyer.Destroy(context.Background(), func(p DestroyProgress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, errs)
+
+	assert.Equal(t, []DestroyProgress{
+		{Level: 1, Levels: 4, Kinds: []string{"RepoSync"}},
+		{Level: 2, Levels: 4, Kinds: []string{"Deployment"}},
+		{Level: 3, Levels: 4, Kinds: []string{"ClusterRole", "ServiceAccount"}},
+		{Level: 4, Levels: 4, Kinds: []string{"Namespace"}},
+	}, progress)
+
+	for _, obj := range objs {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(obj), current)
+		switch obj.GetName() {
+		case "kept", "cm", "role":
+			assert.NoError(t, err, "%s should be retained", obj.GetName())
+		default:
+			assert.True(t, apierrors.IsNotFound(err), "%s should be deleted, got %v", obj.GetName(), err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+	"kpt.dev/configsync/pkg/tracing"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+// taskSpans records a span for each cli-utils action group (apply, prune,
+// delete and wait tasks), from the Started event to the Finished event.
+type taskSpans struct {
+	ctx   context.Context
+	spans map[string]*trace.Span
+}
+
+func newTaskSpans(ctx context.Context) *taskSpans {
+	return &taskSpans{
+		ctx:   ctx,
+		spans: make(map[string]*trace.Span),
+	}
+}
+
+// handle starts or ends the span for the action group.
+func (ts *taskSpans) handle(e event.ActionGroupEvent) {
+	switch e.Status {
+	case event.Started:
+		_, span := tracing.StartSpan(ts.ctx, tracing.TaskSpanName(e.GroupName),
+			trace.StringAttribute(tracing.KeyActionGroup, e.GroupName),
+			trace.StringAttribute(tracing.KeyAction, e.Action.String()))
+		ts.spans[e.GroupName] = span
+	case event.Finished:
+		if span, found := ts.spans[e.GroupName]; found {
+			span.End()
+			delete(ts.spans, e.GroupName)
+		}
+	}
+}
+
+// endAll ends the spans of the action groups which never finished, for
+// example because the context was cancelled.
+func (ts *taskSpans) endAll() {
+	for groupName, span := range ts.spans {
+		span.End()
+		delete(ts.spans, groupName)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kpt.dev/configsync/pkg/testing/testtracing"
+	"kpt.dev/configsync/pkg/tracing"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+func TestTaskSpans(t *testing.T) {
+	exporter := testtracing.RegisterExporter(t)
+
+	ctx, applySpan := tracing.StartSpan(context.Background(), tracing.SpanApply)
+	spans := newTaskSpans(ctx)
+	for _, e := range []event.ActionGroupEvent{
+		{GroupName: "apply-0", Action: event.ApplyAction, Status: event.Started},
+		{GroupName: "apply-0", Action: event.ApplyAction, Status: event.Finished},
+		{GroupName: "wait-0", Action: event.WaitAction, Status: event.Started},
+		{GroupName: "wait-0", Action: event.WaitAction, Status: event.Finished},
+		{GroupName: "prune-0", Action: event.PruneAction, Status: event.Started},
+	} {
+		spans.handle(e)
+	}
+	// prune-0 never finished
+	spans.endAll()
+	applySpan.End()
+
+	assert.Equal(t, []string{"applier.apply-0", "applier.wait-0", "applier.prune-0", tracing.SpanApply},
+		exporter.SpanNames())
+	applySpanData := exporter.Span(tracing.SpanApply)
+	waitSpanData := exporter.Span("applier.wait-0")
+	assert.Equal(t, applySpanData.SpanID, waitSpanData.ParentSpanID)
+	assert.Equal(t, map[string]interface{}{
+		tracing.KeyActionGroup: "wait-0",
+		tracing.KeyAction:      "Wait",
+	}, waitSpanData.Attributes)
+}
@@ -23,26 +23,43 @@ import (
 	"golang.org/x/net/context"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/differ"
 	syncerreconcile "kpt.dev/configsync/pkg/syncer/reconcile"
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/cli-utils/pkg/object/mutation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func partitionObjs(objs []client.Object) ([]client.Object, []client.Object) {
+func partitionObjs(objs []client.Object) ([]client.Object, []client.Object, []client.Object) {
 	var enabled []client.Object
 	var disabled []client.Object
+	var handoff []client.Object
 	for _, obj := range objs {
-		if obj.GetAnnotations()[metadata.ResourceManagementKey] == metadata.ResourceManagementDisabled {
+		switch {
+		case obj.GetAnnotations()[metadata.ResourceManagementKey] == metadata.ResourceManagementDisabled:
 			disabled = append(disabled, obj)
-		} else {
+		case differ.HandoffTarget(obj) != "":
+			handoff = append(handoff, obj)
+		default:
 			enabled = append(enabled, obj)
 		}
 	}
-	return enabled, disabled
+	return enabled, disabled, handoff
+}
+
+// handoffInventoryID returns the inventory id of the R*Sync with the specified
+// manager.
+func handoffInventoryID(manager string) string {
+	scope, name := declared.ManagerScopeAndName(manager)
+	if scope == declared.RootReconciler {
+		return InventoryID(name, configsync.ControllerNamespace)
+	}
+	return InventoryID(name, string(scope))
 }
 
 func toUnstructured(objs []client.Object) ([]*unstructured.Unstructured, status.MultiError) {
@@ -22,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/syncer/syncertest"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"sigs.k8s.io/cli-utils/pkg/object"
@@ -34,6 +35,7 @@ func TestPartitionObjs(t *testing.T) {
 		objs          []client.Object
 		enabledCount  int
 		disabledCount int
+		handoffCount  int
 	}{
 		{
 			name: "all managed objs",
@@ -62,16 +64,29 @@ func TestPartitionObjs(t *testing.T) {
 			enabledCount:  1,
 			disabledCount: 1,
 		},
+		{
+			name: "handoff objs",
+			objs: []client.Object{
+				fake.DeploymentObject(core.Name("deploy"), core.Namespace("default"), syncertest.ManagementEnabled),
+				fake.ServiceObject(core.Name("service"), core.Namespace("default"), syncertest.ManagementEnabled,
+					core.Annotation(metadata.ResourceHandoffKey, "default")),
+			},
+			enabledCount: 1,
+			handoffCount: 1,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			enabled, disabled := partitionObjs(tc.objs)
+			enabled, disabled, handoff := partitionObjs(tc.objs)
 			if len(enabled) != tc.enabledCount {
 				t.Errorf("expected %d enabled objects, but got %d", tc.enabledCount, enabled)
 			}
 			if len(disabled) != tc.disabledCount {
 				t.Errorf("expected %d disabled objects, but got %d", tc.disabledCount, enabled)
 			}
+			if len(handoff) != tc.handoffCount {
+				t.Errorf("expected %d handoff objects, but got %d", tc.handoffCount, len(handoff))
+			}
 		})
 	}
 }
@@ -0,0 +1,256 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commitstatus reports the outcome of syncing each commit back to the
+// Git provider.
+package commitstatus
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/util"
+)
+
+// Phase is the step of the sync which a report is about.
+type Phase string
+
+const (
+	// SourcePhase is fetching and parsing the source.
+	SourcePhase Phase = "source"
+	// RenderingPhase is rendering the source.
+	RenderingPhase Phase = "rendering"
+	// SyncPhase is applying the configs to the cluster.
+	SyncPhase Phase = "sync"
+)
+
+// State is the outcome of a phase.
+type State string
+
+const (
+	// Pending means the phase is in progress.
+	Pending State = "pending"
+	// Success means the commit is synced.
+	Success State = "success"
+	// Failure means the phase failed.
+	Failure State = "failure"
+)
+
+const (
+	// DefaultMaxErrors is the default maximum number of errors in a report.
+	DefaultMaxErrors = 5
+	// queueSize is the number of reports waiting to be delivered. Reports
+	// are dropped when the queue is full.
+	queueSize = 16
+	// maxDelivered is the number of commits whose last delivered report is
+	// remembered for deduplication.
+	maxDelivered = 64
+)
+
+// Report is the outcome of syncing a commit.
+type Report struct {
+	// Commit is the synced commit.
+	Commit string `json:"commit"`
+	// Phase is the last phase reached by the sync.
+	Phase Phase `json:"phase"`
+	// State is the outcome of the phase.
+	State State `json:"state"`
+	// ErrorCount is the total number of errors.
+	ErrorCount int `json:"errorCount"`
+	// Errors are the first errors, up to the maximum number of errors.
+	Errors []v1beta1.ConfigSyncError `json:"errors,omitempty"`
+}
+
+// key identifies the content of the report for deduplication.
+func (r Report) key() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Notifier delivers the reports to the Git provider in the background, with
+// retries, and at most once per commit and outcome.
+type Notifier struct {
+	// Provider is the API the reports are delivered to.
+	Provider v1beta1.GitProvider
+	// URL is the base URL of the API, or the URL of the webhook.
+	URL string
+	// Project is the GitHub repository or the GitLab project.
+	Project string
+	// Token authenticates the requests. It is optional for webhooks.
+	Token string
+	// Context distinguishes the reports of this RootSync or RepoSync from
+	// other commit statuses.
+	Context string
+	// SyncKind, SyncNamespace and SyncName identify the RootSync or RepoSync
+	// in webhook payloads.
+	SyncKind      string
+	SyncNamespace string
+	SyncName      string
+	// MaxErrors is the maximum number of errors in a report.
+	MaxErrors int
+	// Client sends the requests.
+	Client *http.Client
+	// Backoff is the backoff of the delivery retries.
+	Backoff wait.Backoff
+
+	queue chan Report
+
+	mux sync.Mutex
+	// delivered is the key of the last report queued for each commit.
+	delivered map[string]string
+}
+
+// NewNotifier returns a Notifier delivering the reports to the provider.
+func NewNotifier(provider v1beta1.GitProvider, url, project, repo, token string) (*Notifier, error) {
+	n := &Notifier{
+		Provider:  provider,
+		URL:       url,
+		Project:   project,
+		Token:     token,
+		MaxErrors: DefaultMaxErrors,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		Backoff: wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Steps:    5,
+			Jitter:   0.1,
+		},
+	}
+	switch provider {
+	case v1beta1.GitHubProvider:
+		if n.URL == "" {
+			n.URL = "https://api.github.com"
+		}
+	case v1beta1.GitLabProvider:
+		if n.URL == "" {
+			n.URL = "https://gitlab.com"
+		}
+	case v1beta1.WebhookProvider:
+		if n.URL == "" {
+			return nil, fmt.Errorf("the URL is required for the %s provider", provider)
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown commit status provider %q", provider)
+	}
+	if n.Project == "" {
+		n.Project = projectFromRepo(repo)
+		if n.Project == "" {
+			return nil, fmt.Errorf("failed to get the %s project from the repo URL %q", provider, repo)
+		}
+	}
+	return n, nil
+}
+
+// Notify queues the report for delivery, unless the same report was already
+// queued for the commit. It does not block.
+func (n *Notifier) Notify(r Report) {
+	if r.Commit == "" {
+		return
+	}
+	if n.MaxErrors >= 0 && len(r.Errors) > n.MaxErrors {
+		r.Errors = r.Errors[:n.MaxErrors]
+	}
+	key := r.key()
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if n.queue == nil {
+		n.queue = make(chan Report, queueSize)
+	}
+	if n.delivered == nil || len(n.delivered) >= maxDelivered {
+		n.delivered = map[string]string{}
+	}
+	if n.delivered[r.Commit] == key {
+		return
+	}
+	select {
+	case n.queue <- r:
+		n.delivered[r.Commit] = key
+	default:
+		klog.Warningf("Dropped the %s commit status of commit %s: too many pending reports", r.State, r.Commit)
+	}
+}
+
+// Run delivers the queued reports until the context is done.
+func (n *Notifier) Run(ctx context.Context) {
+	n.mux.Lock()
+	if n.queue == nil {
+		n.queue = make(chan Report, queueSize)
+	}
+	queue := n.queue
+	n.mux.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-queue:
+			if err := n.deliver(ctx, r); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				klog.Errorf("Failed to report the %s commit status of commit %s: %v", r.State, r.Commit, err)
+				// Forget the report, so the next identical report is retried.
+				n.mux.Lock()
+				if n.delivered[r.Commit] == r.key() {
+					delete(n.delivered, r.Commit)
+				}
+				n.mux.Unlock()
+				continue
+			}
+			klog.V(3).Infof("Reported the %s commit status of commit %s", r.State, r.Commit)
+		}
+	}
+}
+
+// deliver sends the report, retrying on network errors, rate limiting and
+// server errors.
+func (n *Notifier) deliver(ctx context.Context, r Report) error {
+	return util.RetryWithBackoff(n.Backoff, func() error {
+		req, err := n.request(ctx, r)
+		if err != nil {
+			return err
+		}
+		resp, err := n.Client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return util.NewRetriableError(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err = fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, bytes.TrimSpace(body))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return util.NewRetriableError(err)
+		}
+		return err
+	})
+}
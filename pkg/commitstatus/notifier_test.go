@@ -0,0 +1,343 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commitstatus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+const commit = "1234567890abcdef"
+
+// request is a request received by the test server.
+type request struct {
+	path   string
+	header http.Header
+	body   map[string]interface{}
+}
+
+// testServer is a stand-in for the Git provider API, answering with the
+// queued status codes, then with 201.
+type testServer struct {
+	*httptest.Server
+	t        *testing.T
+	mux      sync.Mutex
+	codes    []int
+	requests []request
+	received chan struct{}
+}
+
+func newTestServer(t *testing.T, codes ...int) *testServer {
+	s := &testServer{t: t, codes: codes, received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read the request body: %v", err)
+		}
+		body := map[string]interface{}{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("failed to decode the request body %q: %v", data, err)
+		}
+		s.mux.Lock()
+		s.requests = append(s.requests, request{path: r.URL.EscapedPath(), header: r.Header, body: body})
+		code := http.StatusCreated
+		if len(s.codes) > 0 {
+			code, s.codes = s.codes[0], s.codes[1:]
+		}
+		s.mux.Unlock()
+		w.WriteHeader(code)
+		s.received <- struct{}{}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// wait waits for n requests and returns all the received requests.
+func (s *testServer) wait(n int) []request {
+	s.t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.received:
+		case <-time.After(10 * time.Second):
+			s.t.Fatalf("timed out waiting for request %d", i+1)
+		}
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]request(nil), s.requests...)
+}
+
+func newTestNotifier(t *testing.T, provider v1beta1.GitProvider, url string) *Notifier {
+	n, err := NewNotifier(provider, url, "", "git@example.com:team/configs.git", "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Context = "config-sync/config-management-system/root-sync"
+	n.SyncKind = "RootSync"
+	n.SyncNamespace = "config-management-system"
+	n.SyncName = "root-sync"
+	n.Backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go n.Run(ctx)
+	return n
+}
+
+func failure() Report {
+	return Report{
+		Commit:     commit,
+		Phase:      SyncPhase,
+		State:      Failure,
+		ErrorCount: 2,
+		Errors: []v1beta1.ConfigSyncError{
+			{
+				Code:         "1021",
+				ErrorMessage: "KNV1021: No CustomResourceDefinition is defined for the type \"Anvil.acme.com\" in the cluster.",
+				Resources:    []v1beta1.ResourceRef{{SourcePath: "namespaces/foo/anvil.yaml"}},
+			},
+			{
+				Code:         "2009",
+				ErrorMessage: "KNV2009: failed to apply Role.rbac.authorization.k8s.io, foo/admin",
+			},
+		},
+	}
+}
+
+func TestNotifyGitHub(t *testing.T) {
+	s := newTestServer(t)
+	n := newTestNotifier(t, v1beta1.GitHubProvider, s.URL)
+
+	n.Notify(failure())
+	got := s.wait(1)
+
+	want := request{
+		path: "/repos/team/configs/statuses/" + commit,
+		body: map[string]interface{}{
+			"state":       "failure",
+			"context":     "config-sync/config-management-system/root-sync",
+			"description": "Config Sync sync failed with 2 error(s): namespaces/foo/anvil.yaml: KNV1021: No CustomResourceDefinition is defined for the type \"Anvil.a...",
+		},
+	}
+	if diff := cmp.Diff(want.path, got[0].path); diff != "" {
+		t.Errorf("path diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want.body, got[0].body); diff != "" {
+		t.Errorf("body diff (-want +got):\n%s", diff)
+	}
+	if auth := got[0].header.Get("Authorization"); auth != "Bearer secret-token" {
+		t.Errorf("got Authorization header %q, want %q", auth, "Bearer secret-token")
+	}
+}
+
+func TestNotifyGitLab(t *testing.T) {
+	s := newTestServer(t)
+	n := newTestNotifier(t, v1beta1.GitLabProvider, s.URL)
+	n.Project = "team/sub/configs"
+
+	n.Notify(failure())
+	got := s.wait(1)
+
+	if want := "/api/v4/projects/team%2Fsub%2Fconfigs/statuses/" + commit; got[0].path != want {
+		t.Errorf("got path %q, want %q", got[0].path, want)
+	}
+	if state := got[0].body["state"]; state != "failed" {
+		t.Errorf("got state %v, want failed", state)
+	}
+	if name := got[0].body["name"]; name != n.Context {
+		t.Errorf("got name %v, want %q", name, n.Context)
+	}
+	if token := got[0].header.Get("PRIVATE-TOKEN"); token != "secret-token" {
+		t.Errorf("got PRIVATE-TOKEN header %q, want %q", token, "secret-token")
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	s := newTestServer(t)
+	n := newTestNotifier(t, v1beta1.WebhookProvider, s.URL+"/hook")
+	n.MaxErrors = 1
+
+	n.Notify(failure())
+	got := s.wait(1)
+
+	if got[0].path != "/hook" {
+		t.Errorf("got path %q, want /hook", got[0].path)
+	}
+	body := got[0].body
+	for key, want := range map[string]interface{}{
+		"kind":       "RootSync",
+		"namespace":  "config-management-system",
+		"name":       "root-sync",
+		"commit":     commit,
+		"phase":      "sync",
+		"state":      "failure",
+		"errorCount": float64(2),
+	} {
+		if body[key] != want {
+			t.Errorf("got %s %v, want %v", key, body[key], want)
+		}
+	}
+	errs, _ := body["errors"].([]interface{})
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want the errors truncated to 1", len(errs))
+	}
+	if !strings.Contains(string(mustMarshal(t, errs[0])), "namespaces/foo/anvil.yaml") {
+		t.Errorf("got error %v, want the source path of the error", errs[0])
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	testCases := []struct {
+		name         string
+		codes        []int
+		wantRequests int
+	}{
+		{
+			name:         "retry server errors",
+			codes:        []int{http.StatusInternalServerError, http.StatusTooManyRequests},
+			wantRequests: 3,
+		},
+		{
+			name:         "no retry on client errors",
+			codes:        []int{http.StatusUnprocessableEntity},
+			wantRequests: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, tc.codes...)
+			n := newTestNotifier(t, v1beta1.GitHubProvider, s.URL)
+
+			n.Notify(failure())
+			s.wait(tc.wantRequests)
+			// Give the notifier a chance to send unexpected requests.
+			time.Sleep(50 * time.Millisecond)
+			if got := len(s.wait(0)); got != tc.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tc.wantRequests)
+			}
+		})
+	}
+}
+
+func TestNotifyDeduplicates(t *testing.T) {
+	s := newTestServer(t)
+	n := newTestNotifier(t, v1beta1.GitHubProvider, s.URL)
+
+	pending := Report{Commit: commit, Phase: SyncPhase, State: Pending}
+	n.Notify(pending)
+	n.Notify(pending)
+	n.Notify(failure())
+	n.Notify(failure())
+	n.Notify(Report{Commit: "", Phase: SourcePhase, State: Failure})
+	got := s.wait(2)
+	time.Sleep(50 * time.Millisecond)
+	if len(s.wait(0)) != 2 {
+		t.Fatalf("got %d requests, want 2", len(s.wait(0)))
+	}
+	if got[0].body["state"] != "pending" || got[1].body["state"] != "failure" {
+		t.Errorf("got states %v and %v, want pending and failure", got[0].body["state"], got[1].body["state"])
+	}
+
+	// A new outcome of the commit is reported again.
+	n.Notify(Report{Commit: commit, Phase: SyncPhase, State: Success})
+	got = s.wait(1)
+	if got[2].body["state"] != "success" {
+		t.Errorf("got state %v, want success", got[2].body["state"])
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	testCases := []struct {
+		name        string
+		provider    v1beta1.GitProvider
+		url         string
+		project     string
+		repo        string
+		wantURL     string
+		wantProject string
+		wantErr     bool
+	}{
+		{
+			name:        "github with https repo",
+			provider:    v1beta1.GitHubProvider,
+			repo:        "https://github.com/owner/repo.git",
+			wantURL:     "https://api.github.com",
+			wantProject: "owner/repo",
+		},
+		{
+			name:        "gitlab with scp-like repo",
+			provider:    v1beta1.GitLabProvider,
+			repo:        "git@gitlab.com:group/sub/repo.git",
+			wantURL:     "https://gitlab.com",
+			wantProject: "group/sub/repo",
+		},
+		{
+			name:        "github enterprise with project",
+			provider:    v1beta1.GitHubProvider,
+			url:         "https://github.example.com/api/v3",
+			project:     "owner/other",
+			repo:        "https://github.example.com/owner/repo",
+			wantURL:     "https://github.example.com/api/v3",
+			wantProject: "owner/other",
+		},
+		{
+			name:     "webhook without url",
+			provider: v1beta1.WebhookProvider,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown provider",
+			provider: "bitbucket",
+			repo:     "https://bitbucket.org/owner/repo",
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := NewNotifier(tc.provider, tc.url, tc.project, tc.repo, "")
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("got NewNotifier() error nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.URL != tc.wantURL || n.Project != tc.wantProject {
+				t.Errorf("got URL %q and project %q, want %q and %q", n.URL, n.Project, tc.wantURL, tc.wantProject)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commitstatus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+// maxDescription is the maximum length of a GitHub commit status description.
+const maxDescription = 140
+
+// githubStatus is the body of the GitHub create commit status API.
+type githubStatus struct {
+	State       string `json:"state"`
+	Context     string `json:"context"`
+	Description string `json:"description"`
+}
+
+// gitlabStatus is the body of the GitLab set commit pipeline status API.
+type gitlabStatus struct {
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// webhookPayload is the body posted to a generic webhook.
+type webhookPayload struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Context   string `json:"context"`
+	Report
+}
+
+// request returns the HTTP request delivering the report to the provider.
+func (n *Notifier) request(ctx context.Context, r Report) (*http.Request, error) {
+	var endpoint string
+	var body interface{}
+	header := http.Header{}
+	switch n.Provider {
+	case v1beta1.GitHubProvider:
+		endpoint = fmt.Sprintf("%s/repos/%s/statuses/%s", strings.TrimSuffix(n.URL, "/"), n.Project, r.Commit)
+		body = githubStatus{State: string(r.State), Context: n.Context, Description: description(r)}
+		header.Set("Accept", "application/vnd.github+json")
+		if n.Token != "" {
+			header.Set("Authorization", "Bearer "+n.Token)
+		}
+	case v1beta1.GitLabProvider:
+		endpoint = fmt.Sprintf("%s/api/v4/projects/%s/statuses/%s", strings.TrimSuffix(n.URL, "/"), url.PathEscape(n.Project), r.Commit)
+		state := string(r.State)
+		if r.State == Failure {
+			state = "failed"
+		}
+		body = gitlabStatus{State: state, Name: n.Context, Description: description(r)}
+		if n.Token != "" {
+			header.Set("PRIVATE-TOKEN", n.Token)
+		}
+	case v1beta1.WebhookProvider:
+		endpoint = n.URL
+		body = webhookPayload{Kind: n.SyncKind, Namespace: n.SyncNamespace, Name: n.SyncName, Context: n.Context, Report: r}
+		if n.Token != "" {
+			header.Set("Authorization", "Bearer "+n.Token)
+		}
+	default:
+		return nil, fmt.Errorf("unknown commit status provider %q", n.Provider)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// description summarizes the report in a single line short enough for the
+// GitHub commit status API.
+func description(r Report) string {
+	var desc string
+	switch r.State {
+	case Pending:
+		desc = fmt.Sprintf("Config Sync %s in progress", r.Phase)
+	case Success:
+		desc = "Config Sync synced the commit"
+	default:
+		desc = fmt.Sprintf("Config Sync %s failed with %d error(s)", r.Phase, r.ErrorCount)
+		if len(r.Errors) > 0 {
+			desc += ": " + errorSummary(r.Errors[0])
+		}
+	}
+	if len(desc) > maxDescription {
+		desc = desc[:maxDescription-3] + "..."
+	}
+	return desc
+}
+
+// errorSummary formats the file paths and the message of an error in a single
+// line.
+func errorSummary(e v1beta1.ConfigSyncError) string {
+	var paths []string
+	for _, res := range e.Resources {
+		if res.SourcePath != "" {
+			paths = append(paths, res.SourcePath)
+		}
+	}
+	msg := strings.Join(strings.Fields(e.ErrorMessage), " ")
+	if len(paths) == 0 {
+		return msg
+	}
+	return strings.Join(paths, ", ") + ": " + msg
+}
+
+// projectFromRepo returns the path of the repo URL without the .git suffix,
+// e.g. owner/repo for https://github.com/owner/repo.git or
+// git@github.com:owner/repo.git.
+func projectFromRepo(repo string) string {
+	var path string
+	if u, err := url.Parse(repo); err == nil && u.Host != "" {
+		path = u.Path
+	} else if i := strings.Index(repo, ":"); i >= 0 {
+		// scp-like syntax: [user@]host:path
+		path = repo[i+1:]
+	}
+	path = strings.Trim(path, "/")
+	return strings.TrimSuffix(path, ".git")
+}
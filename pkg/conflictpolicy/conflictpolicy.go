@@ -0,0 +1,281 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conflictpolicy resolves the conflicts between the declared fields of
+// the managed objects and the fields owned by other field managers, according
+// to the conflict policy of the RootSync or RepoSync and of each object.
+package conflictpolicy
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// configSyncManagers are the field managers of Config Sync. The fields they own
+// never conflict. "reconciler" is the default field manager of the older
+// remediator, which used client-side patches and updates.
+var configSyncManagers = map[string]bool{
+	configsync.FieldManager: true,
+	"reconciler":            true,
+}
+
+// Conflict is a declared field of an object which is owned by another field
+// manager, with a different value.
+type Conflict struct {
+	// Field is the path of the field, e.g. `.spec.replicas`.
+	Field string `json:"field"`
+	// Manager is the field manager which owns the field.
+	Manager string `json:"manager"`
+}
+
+// String returns a description of the conflict.
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s is owned by %q", c.Field, c.Manager)
+}
+
+// Validate returns an error if the conflict policy is not valid. The empty
+// policy is valid, and defaults to force.
+func Validate(policy configsync.ConflictPolicy) error {
+	switch policy {
+	case "", configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect, configsync.ConflictPolicyFail:
+		return nil
+	default:
+		return fmt.Errorf("invalid conflict policy %q: must be one of %q, %q, or %q", policy,
+			configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect, configsync.ConflictPolicyFail)
+	}
+}
+
+// Policy returns the conflict policy of the object: the value of its
+// conflict-policy annotation, if any, or the default policy of the RootSync or
+// RepoSync. The returned bool is true if the policy is set by the annotation.
+func Policy(obj client.Object, defaultPolicy configsync.ConflictPolicy) (configsync.ConflictPolicy, bool) {
+	if value, found := obj.GetAnnotations()[metadata.ConflictPolicyAnnotationKey]; found {
+		return configsync.ConflictPolicy(value), true
+	}
+	if defaultPolicy == "" {
+		return configsync.ConflictPolicyForce, false
+	}
+	return defaultPolicy, false
+}
+
+// NeedsLive returns true if the conflicts of the object must be resolved
+// against its live state before it is applied. The live state is not needed
+// to force the ownership of all the fields, unless some of them may be owned
+// by a well-known controller.
+func NeedsLive(obj client.Object, defaultPolicy configsync.ConflictPolicy) bool {
+	policy, explicit := Policy(obj, defaultPolicy)
+	if policy != configsync.ConflictPolicyForce {
+		return true
+	}
+	return !explicit && hasWellKnownOwners(obj.GetObjectKind().GroupVersionKind().GroupKind())
+}
+
+// conflict is a Conflict with the parsed path of the field.
+type conflict struct {
+	Conflict
+	path fieldpath.Path
+}
+
+// find returns the declared fields of the object which are owned by other
+// field managers in the live object, with a different value. The fields of the
+// status subresource are ignored, since they are never applied.
+func find(declared, live *unstructured.Unstructured) ([]conflict, error) {
+	var conflicts []conflict
+	for _, entry := range live.GetManagedFields() {
+		if configSyncManagers[entry.Manager] || entry.Subresource == "status" || entry.FieldsV1 == nil {
+			continue
+		}
+		set := &fieldpath.Set{}
+		if err := set.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+			return nil, fmt.Errorf("invalid managed fields of %q: %w", entry.Manager, err)
+		}
+		set.Leaves().Iterate(func(path fieldpath.Path) {
+			// Keys and values of associative lists identify the list items.
+			// The items themselves are not applied fields.
+			if len(path) == 0 || path[len(path)-1].Key != nil || path[len(path)-1].Value != nil {
+				return
+			}
+			declaredValue, found := valueAt(declared.Object, path)
+			if !found {
+				return
+			}
+			liveValue, found := valueAt(live.Object, path)
+			if found && value.Equals(value.NewValueInterface(declaredValue), value.NewValueInterface(liveValue)) {
+				return
+			}
+			conflicts = append(conflicts, conflict{
+				Conflict: Conflict{Field: path.String(), Manager: entry.Manager},
+				path:     path.Copy(),
+			})
+		})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Field != conflicts[j].Field {
+			return conflicts[i].Field < conflicts[j].Field
+		}
+		return conflicts[i].Manager < conflicts[j].Manager
+	})
+	return conflicts, nil
+}
+
+// Resolution is the result of resolving the conflicts of a declared object.
+type Resolution struct {
+	// Policy is the conflict policy of the object.
+	Policy configsync.ConflictPolicy
+	// Object is the object to apply, without the respected fields. It is nil
+	// if the object must not be applied, because of unresolved conflicts.
+	Object *unstructured.Unstructured
+	// Respected are the conflicting fields left to the other field managers.
+	Respected []Conflict
+	// Unresolved are the conflicting fields which fail the object, with the
+	// fail policy.
+	Unresolved []Conflict
+}
+
+// Resolve resolves the conflicts between the declared object and the live
+// object, according to the conflict policy of the declared object.
+//
+// The fields owned by well-known controllers are respected, unless the object
+// explicitly sets the force policy. The other conflicts are forced, respected,
+// or unresolved, depending on the policy. The live object may be nil, if it
+// does not exist.
+func Resolve(declared, live *unstructured.Unstructured, defaultPolicy configsync.ConflictPolicy) (Resolution, error) {
+	policy, explicit := Policy(declared, defaultPolicy)
+	res := Resolution{Policy: policy, Object: declared}
+	if live == nil {
+		return res, nil
+	}
+	conflicts, err := find(declared, live)
+	if err != nil {
+		return Resolution{}, err
+	}
+	gk := declared.GroupVersionKind().GroupKind()
+	var respected []fieldpath.Path
+	for _, c := range conflicts {
+		switch {
+		case policy == configsync.ConflictPolicyRespect,
+			isWellKnownOwner(gk, c.Conflict) && !(explicit && policy == configsync.ConflictPolicyForce):
+			res.Respected = append(res.Respected, c.Conflict)
+			respected = append(respected, c.path)
+		case policy == configsync.ConflictPolicyFail:
+			res.Unresolved = append(res.Unresolved, c.Conflict)
+		}
+	}
+	if len(res.Unresolved) > 0 {
+		res.Object = nil
+		return res, nil
+	}
+	if len(respected) > 0 {
+		res.Object = declared.DeepCopy()
+		for _, path := range respected {
+			removeField(res.Object.Object, path)
+		}
+	}
+	return res, nil
+}
+
+// FieldConflictErrorCode is the error code for objects with the fail conflict
+// policy which declare fields owned by other field managers.
+const FieldConflictErrorCode = "1075"
+
+var fieldConflictErrorBuilder = status.NewErrorBuilder(FieldConflictErrorCode)
+
+// ConflictError reports that an object with the fail conflict policy declares
+// fields owned by other field managers.
+func ConflictError(resource client.Object, conflicts []Conflict) status.Error {
+	descs := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		descs[i] = c.String()
+	}
+	return fieldConflictErrorBuilder.
+		Sprintf("The %q reconciler cannot apply fields owned by another field manager with the %q conflict policy: %s. "+
+			"Remove the fields from the declaration of this resource, stop the other manager from updating them, "+
+			"or set the %s annotation to %q or %q.",
+			core.GetAnnotation(resource, metadata.ResourceManagerKey), configsync.ConflictPolicyFail,
+			strings.Join(descs, ", "), metadata.ConflictPolicyAnnotationKey,
+			configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect).
+		BuildWithResources(resource)
+}
+
+// wellKnownOwner is a field owned by a well-known controller, which updates the
+// field of objects usually declared by users.
+type wellKnownOwner struct {
+	// manager is the field manager of the controller.
+	manager string
+	// kinds are the kinds of the objects whose field is owned.
+	kinds []schema.GroupKind
+	// fieldSuffix is the suffix of the path of the owned field.
+	fieldSuffix string
+}
+
+var (
+	workloadKinds = []schema.GroupKind{
+		{Group: "apps", Kind: "Deployment"},
+		{Group: "apps", Kind: "StatefulSet"},
+		{Group: "apps", Kind: "ReplicaSet"},
+	}
+	caBundleKinds = []schema.GroupKind{
+		{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"},
+		{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"},
+		{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"},
+		{Group: "apiregistration.k8s.io", Kind: "APIService"},
+	}
+)
+
+// wellKnownOwners are the fields of the well-known controllers which are
+// respected by default.
+var wellKnownOwners = []wellKnownOwner{
+	// The HorizontalPodAutoscaler controller scales the workloads.
+	{manager: "kube-controller-manager", kinds: workloadKinds, fieldSuffix: ".spec.replicas"},
+	// The cert-manager CA injector and Istio inject CA bundles.
+	{manager: "cert-manager-cainjector", kinds: caBundleKinds, fieldSuffix: ".caBundle"},
+	{manager: "pilot-discovery", kinds: caBundleKinds, fieldSuffix: ".caBundle"},
+}
+
+func hasWellKnownOwners(gk schema.GroupKind) bool {
+	for _, owner := range wellKnownOwners {
+		for _, kind := range owner.kinds {
+			if kind == gk {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isWellKnownOwner(gk schema.GroupKind, c Conflict) bool {
+	for _, owner := range wellKnownOwners {
+		if owner.manager != c.Manager || !strings.HasSuffix(c.Field, owner.fieldSuffix) {
+			continue
+		}
+		for _, kind := range owner.kinds {
+			if kind == gk {
+				return true
+			}
+		}
+	}
+	return false
+}
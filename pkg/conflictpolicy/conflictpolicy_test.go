@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conflictpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/metadata"
+)
+
+func deployment(replicas int64, image string, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "shop",
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": image},
+					},
+				},
+			},
+		},
+	}}
+	if annotations != nil {
+		u.SetAnnotations(annotations)
+	}
+	return u
+}
+
+func withManagedFields(u *unstructured.Unstructured, entries ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+	u.SetManagedFields(entries)
+	return u
+}
+
+func managedFields(manager, subresource, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:     manager,
+		Operation:   metav1.ManagedFieldsOperationUpdate,
+		Subresource: subresource,
+		FieldsType:  "FieldsV1",
+		FieldsV1:    &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+const (
+	replicasFields = `{"f:spec":{"f:replicas":{}}}`
+	imageFields    = `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"web\"}":{".":{},"f:image":{}}}}}}}`
+)
+
+func TestResolve(t *testing.T) {
+	hpa := managedFields("kube-controller-manager", "scale", replicasFields)
+	kubectl := managedFields("kubectl-set", "", imageFields)
+
+	testCases := []struct {
+		name           string
+		declared       *unstructured.Unstructured
+		live           *unstructured.Unstructured
+		defaultPolicy  configsync.ConflictPolicy
+		wantPolicy     configsync.ConflictPolicy
+		wantObject     *unstructured.Unstructured
+		wantRespected  []Conflict
+		wantUnresolved []Conflict
+	}{
+		{
+			name:       "object does not exist",
+			declared:   deployment(1, "web:v2", nil),
+			wantPolicy: configsync.ConflictPolicyForce,
+			wantObject: deployment(1, "web:v2", nil),
+		},
+		{
+			name:       "no conflicts",
+			declared:   deployment(1, "web:v2", nil),
+			live:       withManagedFields(deployment(1, "web:v1", nil), managedFields(configsync.FieldManager, "", imageFields)),
+			wantPolicy: configsync.ConflictPolicyForce,
+			wantObject: deployment(1, "web:v2", nil),
+		},
+		{
+			name:       "field owned by another manager with the same value",
+			declared:   deployment(1, "web:v1", nil),
+			live:       withManagedFields(deployment(1, "web:v1", nil), kubectl),
+			wantPolicy: configsync.ConflictPolicyForce,
+			wantObject: deployment(1, "web:v1", nil),
+		},
+		{
+			name:       "force conflicting fields by default",
+			declared:   deployment(1, "web:v2", nil),
+			live:       withManagedFields(deployment(1, "web:v1", nil), kubectl),
+			wantPolicy: configsync.ConflictPolicyForce,
+			wantObject: deployment(1, "web:v2", nil),
+		},
+		{
+			name:          "respect fields owned by well-known controllers by default",
+			declared:      deployment(1, "web:v2", nil),
+			live:          withManagedFields(deployment(3, "web:v1", nil), hpa, kubectl),
+			wantPolicy:    configsync.ConflictPolicyForce,
+			wantObject:    withoutReplicas(deployment(1, "web:v2", nil)),
+			wantRespected: []Conflict{{Field: ".spec.replicas", Manager: "kube-controller-manager"}},
+		},
+		{
+			name:       "force fields owned by well-known controllers with the annotation",
+			declared:   deployment(1, "web:v2", map[string]string{metadata.ConflictPolicyAnnotationKey: "force"}),
+			live:       withManagedFields(deployment(3, "web:v1", nil), hpa),
+			wantPolicy: configsync.ConflictPolicyForce,
+			wantObject: deployment(1, "web:v2", map[string]string{metadata.ConflictPolicyAnnotationKey: "force"}),
+		},
+		{
+			name:          "respect conflicting fields",
+			declared:      deployment(1, "web:v2", nil),
+			live:          withManagedFields(deployment(1, "web:v1", nil), kubectl),
+			defaultPolicy: configsync.ConflictPolicyRespect,
+			wantPolicy:    configsync.ConflictPolicyRespect,
+			wantObject:    withoutImage(deployment(1, "web:v2", nil)),
+			wantRespected: []Conflict{{Field: `.spec.template.spec.containers[name="web"].image`, Manager: "kubectl-set"}},
+		},
+		{
+			name:           "fail on conflicting fields",
+			declared:       deployment(1, "web:v2", map[string]string{metadata.ConflictPolicyAnnotationKey: "fail"}),
+			live:           withManagedFields(deployment(3, "web:v1", nil), hpa, kubectl),
+			defaultPolicy:  configsync.ConflictPolicyRespect,
+			wantPolicy:     configsync.ConflictPolicyFail,
+			wantRespected:  []Conflict{{Field: ".spec.replicas", Manager: "kube-controller-manager"}},
+			wantUnresolved: []Conflict{{Field: `.spec.template.spec.containers[name="web"].image`, Manager: "kubectl-set"}},
+		},
+		{
+			name:          "fail respects fields owned by well-known controllers",
+			declared:      deployment(1, "web:v1", nil),
+			live:          withManagedFields(deployment(3, "web:v1", nil), hpa),
+			defaultPolicy: configsync.ConflictPolicyFail,
+			wantPolicy:    configsync.ConflictPolicyFail,
+			wantObject:    withoutReplicas(deployment(1, "web:v1", nil)),
+			wantRespected: []Conflict{{Field: ".spec.replicas", Manager: "kube-controller-manager"}},
+		},
+		{
+			name:          "ignore fields of the status subresource",
+			declared:      deployment(1, "web:v2", nil),
+			live:          withManagedFields(deployment(3, "web:v1", nil), managedFields("kubectl-set", "status", replicasFields)),
+			defaultPolicy: configsync.ConflictPolicyFail,
+			wantPolicy:    configsync.ConflictPolicyFail,
+			wantObject:    deployment(1, "web:v2", nil),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Resolve(tc.declared, tc.live, tc.defaultPolicy)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPolicy, res.Policy)
+			assert.Equal(t, tc.wantObject, res.Object)
+			assert.Equal(t, tc.wantRespected, res.Respected)
+			assert.Equal(t, tc.wantUnresolved, res.Unresolved)
+		})
+	}
+}
+
+func withoutReplicas(u *unstructured.Unstructured) *unstructured.Unstructured {
+	unstructured.RemoveNestedField(u.Object, "spec", "replicas")
+	return u
+}
+
+func withoutImage(u *unstructured.Unstructured) *unstructured.Unstructured {
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	delete(containers[0].(map[string]interface{}), "image")
+	_ = unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers")
+	return u
+}
+
+func TestNeedsLive(t *testing.T) {
+	testCases := []struct {
+		name          string
+		obj           *unstructured.Unstructured
+		defaultPolicy configsync.ConflictPolicy
+		want          bool
+	}{
+		{
+			name: "force by default without well-known owners",
+			obj:  &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}},
+			want: false,
+		},
+		{
+			name: "force by default with well-known owners",
+			obj:  deployment(1, "web:v1", nil),
+			want: true,
+		},
+		{
+			name: "force with the annotation",
+			obj:  deployment(1, "web:v1", map[string]string{metadata.ConflictPolicyAnnotationKey: "force"}),
+			want: false,
+		},
+		{
+			name:          "respect",
+			obj:           &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}},
+			defaultPolicy: configsync.ConflictPolicyRespect,
+			want:          true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, NeedsLive(tc.obj, tc.defaultPolicy))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, policy := range []configsync.ConflictPolicy{"", configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect, configsync.ConflictPolicyFail} {
+		assert.NoError(t, Validate(policy))
+	}
+	assert.Error(t, Validate("overwrite"))
+}
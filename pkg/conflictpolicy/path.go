@@ -0,0 +1,104 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conflictpolicy
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// valueAt returns the value of the unstructured content at the path, if any.
+func valueAt(content interface{}, path fieldpath.Path) (interface{}, bool) {
+	current := content
+	for _, pe := range path {
+		next, found := child(current, pe)
+		if !found {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// child returns the child of the unstructured content selected by the path
+// element, if any.
+func child(content interface{}, pe fieldpath.PathElement) (interface{}, bool) {
+	switch {
+	case pe.FieldName != nil:
+		m, ok := content.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, found := m[*pe.FieldName]
+		return v, found
+	case pe.Key != nil:
+		list, ok := content.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok && matchesKey(m, *pe.Key) {
+				return item, true
+			}
+		}
+		return nil, false
+	case pe.Value != nil:
+		list, ok := content.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		for _, item := range list {
+			if value.Equals(value.NewValueInterface(item), *pe.Value) {
+				return item, true
+			}
+		}
+		return nil, false
+	case pe.Index != nil:
+		list, ok := content.([]interface{})
+		if !ok || *pe.Index < 0 || *pe.Index >= len(list) {
+			return nil, false
+		}
+		return list[*pe.Index], true
+	default:
+		return nil, false
+	}
+}
+
+// matchesKey returns true if the list item has all the fields of the key.
+func matchesKey(item map[string]interface{}, key value.FieldList) bool {
+	for _, field := range key {
+		v, found := item[field.Name]
+		if !found || !value.Equals(value.NewValueInterface(v), field.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeField removes the field at the path from the unstructured content, if
+// any. Only map fields can be removed: list items identify the fields of other
+// managers, and are kept.
+func removeField(content map[string]interface{}, path fieldpath.Path) {
+	if len(path) == 0 || path[len(path)-1].FieldName == nil {
+		return
+	}
+	parent, found := valueAt(content, path[:len(path)-1])
+	if !found {
+		return
+	}
+	if m, ok := parent.(map[string]interface{}); ok {
+		delete(m, *path[len(path)-1].FieldName)
+	}
+}
@@ -0,0 +1,356 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package declared
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// checkpointDataKey is the BinaryData key of a checkpoint chunk.
+	checkpointDataKey = "declared.json.gz"
+	// checkpointCommitKey is the annotation recording the commit of the
+	// declared resources stored in a checkpoint chunk.
+	checkpointCommitKey = configsync.ConfigSyncPrefix + "declared-commit"
+	// checkpointHashKey is the annotation recording the hash of the encoded
+	// declared resources of a checkpoint. It is only set on the first chunk.
+	checkpointHashKey = configsync.ConfigSyncPrefix + "declared-hash"
+	// checkpointChunksKey is the annotation recording the number of chunks of
+	// a checkpoint. It is only set on the first chunk.
+	checkpointChunksKey = configsync.ConfigSyncPrefix + "declared-chunks"
+)
+
+// MaxCheckpointChunks is the number of ConfigMaps which may store the
+// checkpoint of a reconciler. The reconciler-manager creates them, since the
+// reconcilers are only allowed to read and update them.
+const MaxCheckpointChunks = 16
+
+// checkpointChunkSize is the maximum number of bytes stored in each ConfigMap,
+// well below the 1MiB limit of an object.
+var checkpointChunkSize = 512 * 1024
+
+// Checkpoint stores the declared resources of a reconciler in ConfigMaps, so
+// that they can be restored when the reconciler restarts.
+//
+// The declared resources are encoded as gzipped JSON and split into chunks.
+// The first chunk records the commit, the hash of the data and the number of
+// chunks, and is written last, so an interrupted Save is detected by Restore.
+//
+// The ConfigMaps are stored in the config-management-system namespace, next to
+// the reconciler Deployment, so that the users of a namespace cannot edit the
+// declared resources of its RepoSyncs. They are created and deleted by the
+// reconciler-manager with the other objects of the reconciler, which is only
+// allowed to read and update its own ConfigMaps.
+type Checkpoint struct {
+	client client.Client
+	scope  Scope
+	name   string
+	labels map[string]string
+
+	// commit and hash identify the checkpoint last saved or restored, so that
+	// Save can skip writing the same declared resources again.
+	commit string
+	hash   string
+}
+
+// NewCheckpoint returns a Checkpoint for the RootSync or RepoSync reconciler
+// with the given scope and sync name.
+func NewCheckpoint(c client.Client, scope Scope, syncName string) *Checkpoint {
+	cp := &Checkpoint{
+		client: c,
+		scope:  scope,
+		labels: map[string]string{
+			metadata.SyncNameLabel: syncName,
+		},
+	}
+	if scope == RootReconciler {
+		cp.name = core.RootReconcilerName(syncName)
+		cp.labels[metadata.SyncKindLabel] = configsync.RootSyncKind
+		cp.labels[metadata.SyncNamespaceLabel] = configsync.ControllerNamespace
+	} else {
+		cp.name = core.NsReconcilerName(string(scope), syncName)
+		cp.labels[metadata.SyncKindLabel] = configsync.RepoSyncKind
+		cp.labels[metadata.SyncNamespaceLabel] = string(scope)
+	}
+	return cp
+}
+
+// CheckpointName returns the name of the ConfigMap storing the i-th chunk of
+// the checkpoint of the reconciler with the given name.
+func CheckpointName(reconcilerName string, i int) string {
+	return fmt.Sprintf("%s-declared-%d", reconcilerName, i)
+}
+
+// CheckpointNames returns the names of the ConfigMaps which may store the
+// checkpoint of the reconciler with the given name.
+func CheckpointNames(reconcilerName string) []string {
+	names := make([]string, MaxCheckpointChunks)
+	for i := range names {
+		names[i] = CheckpointName(reconcilerName, i)
+	}
+	return names
+}
+
+// chunkKey returns the key of the ConfigMap storing the i-th chunk.
+func (cp *Checkpoint) chunkKey(i int) client.ObjectKey {
+	return client.ObjectKey{
+		Namespace: configsync.ControllerNamespace,
+		Name:      CheckpointName(cp.name, i),
+	}
+}
+
+// Save stores the current declared resources and their commit.
+// Chunks left over from a larger previous checkpoint are cleared.
+// Save does nothing if the commit and the declared resources are the same as
+// the last checkpoint.
+func (cp *Checkpoint) Save(ctx context.Context, resources *Resources) error {
+	objs, commit := resources.DeclaredUnstructureds()
+	if commit == "" {
+		// Nothing has been declared yet.
+		return nil
+	}
+	data, err := encodeCheckpoint(objs)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if cp.commit == "" {
+		// Compare with the checkpoint saved before the reconciler restarted.
+		head := &corev1.ConfigMap{}
+		if err := cp.client.Get(ctx, cp.chunkKey(0), head); err == nil {
+			cp.commit = head.Annotations[checkpointCommitKey]
+			cp.hash = head.Annotations[checkpointHashKey]
+		} else if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "getting checkpoint ConfigMap %s", cp.chunkKey(0))
+		}
+	}
+	if cp.commit == commit && cp.hash == hash {
+		klog.V(3).Infof("Skipping the checkpoint of commit %q: the declared resources are unchanged", commit)
+		return nil
+	}
+	var chunks [][]byte
+	for len(data) > checkpointChunkSize {
+		chunks = append(chunks, data[:checkpointChunkSize])
+		data = data[checkpointChunkSize:]
+	}
+	chunks = append(chunks, data)
+	if len(chunks) > MaxCheckpointChunks {
+		return errors.Errorf("the declared resources of commit %q need %d checkpoint ConfigMaps, more than the maximum of %d", commit, len(chunks), MaxCheckpointChunks)
+	}
+
+	// Write the first chunk last, so it only references complete chunks.
+	for i := len(chunks) - 1; i >= 0; i-- {
+		annotations := map[string]string{checkpointCommitKey: commit}
+		if i == 0 {
+			annotations[checkpointChunksKey] = strconv.Itoa(len(chunks))
+			annotations[checkpointHashKey] = hash
+		}
+		if err := cp.writeChunk(ctx, i, chunks[i], annotations); err != nil {
+			return err
+		}
+	}
+
+	for i := len(chunks); i < MaxCheckpointChunks; i++ {
+		if err := cp.clearChunk(ctx, i); err != nil {
+			return err
+		}
+	}
+	cp.commit = commit
+	cp.hash = hash
+	klog.V(3).Infof("Checkpointed %d declared resources of commit %q in %d ConfigMaps", len(objs), commit, len(chunks))
+	return nil
+}
+
+// writeChunk creates or replaces the ConfigMap storing the i-th chunk.
+func (cp *Checkpoint) writeChunk(ctx context.Context, i int, data []byte, annotations map[string]string) error {
+	key := cp.chunkKey(i)
+	cm := &corev1.ConfigMap{}
+	err := cp.client.Get(ctx, key, cm)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "getting checkpoint ConfigMap %s", key)
+	}
+	found := err == nil
+	cm.Name = key.Name
+	cm.Namespace = key.Namespace
+	cm.Labels = cp.labels
+	cm.Annotations = annotations
+	cm.Data = nil
+	cm.BinaryData = map[string][]byte{checkpointDataKey: data}
+	if found {
+		err = cp.client.Update(ctx, cm)
+	} else {
+		err = cp.client.Create(ctx, cm)
+	}
+	return errors.Wrapf(err, "writing checkpoint ConfigMap %s", key)
+}
+
+// clearChunk removes the data of the ConfigMap storing the i-th chunk, if it
+// exists.
+func (cp *Checkpoint) clearChunk(ctx context.Context, i int) error {
+	key := cp.chunkKey(i)
+	cm := &corev1.ConfigMap{}
+	if err := cp.client.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "getting checkpoint ConfigMap %s", key)
+	}
+	if len(cm.BinaryData) == 0 && len(cm.Annotations) == 0 {
+		return nil
+	}
+	cm.Annotations = nil
+	cm.BinaryData = nil
+	return errors.Wrapf(cp.client.Update(ctx, cm), "clearing checkpoint ConfigMap %s", key)
+}
+
+// Restore loads the last saved declared resources into resources, unless
+// resources have already been declared. It returns false if there is no
+// checkpoint to restore.
+//
+// inventory is the set of objects in the inventory of the reconciler. The
+// checkpoint is not restored if it declares an object outside the scope of
+// the reconciler or outside the inventory, since it is then either stale or
+// not written by the reconciler.
+func (cp *Checkpoint) Restore(ctx context.Context, resources *Resources, inventory map[core.ID]struct{}) (bool, error) {
+	head := &corev1.ConfigMap{}
+	if err := cp.client.Get(ctx, cp.chunkKey(0), head); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "getting checkpoint ConfigMap %s", cp.chunkKey(0))
+	}
+	commit := head.Annotations[checkpointCommitKey]
+	if len(head.Annotations) == 0 {
+		// The ConfigMap was created by the reconciler-manager, but no
+		// checkpoint was saved yet.
+		return false, nil
+	}
+	count, err := strconv.Atoi(head.Annotations[checkpointChunksKey])
+	if err != nil || count < 1 || count > MaxCheckpointChunks {
+		return false, errors.Errorf("checkpoint ConfigMap %s has an invalid %s annotation", cp.chunkKey(0), checkpointChunksKey)
+	}
+
+	var data []byte
+	data = append(data, head.BinaryData[checkpointDataKey]...)
+	for i := 1; i < count; i++ {
+		cm := &corev1.ConfigMap{}
+		if err := cp.client.Get(ctx, cp.chunkKey(i), cm); err != nil {
+			return false, errors.Wrapf(err, "getting checkpoint ConfigMap %s", cp.chunkKey(i))
+		}
+		if cm.Annotations[checkpointCommitKey] != commit {
+			return false, errors.Errorf("checkpoint ConfigMap %s is from commit %q, not %q", cp.chunkKey(i), cm.Annotations[checkpointCommitKey], commit)
+		}
+		data = append(data, cm.BinaryData[checkpointDataKey]...)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if hash != head.Annotations[checkpointHashKey] {
+		return false, errors.Errorf("checkpoint of commit %q does not match its hash", commit)
+	}
+	objs, err := decodeCheckpoint(data)
+	if err != nil {
+		return false, errors.Wrapf(err, "decoding checkpoint of commit %q", commit)
+	}
+	if err := cp.validate(objs, inventory); err != nil {
+		return false, errors.Wrapf(err, "checkpoint of commit %q", commit)
+	}
+	cp.commit = commit
+	cp.hash = hash
+	if !resources.restore(objs, commit) {
+		return false, nil
+	}
+	klog.Infof("Restored %d declared resources of commit %q from the checkpoint", len(objs), commit)
+	return true, nil
+}
+
+// validate returns an error if one of the restored objects is invalid, is
+// outside the scope of the reconciler, or is not in the inventory.
+func (cp *Checkpoint) validate(objs []*unstructured.Unstructured, inventory map[core.ID]struct{}) error {
+	for _, obj := range objs {
+		id := core.IDOf(obj)
+		if id.Kind == "" || id.Name == "" {
+			return errors.Errorf("object %s has no kind or name", id)
+		}
+		if cp.scope != RootReconciler && id.Namespace != string(cp.scope) {
+			return errors.Errorf("object %s is not in namespace %q", id, cp.scope)
+		}
+		if _, found := inventory[id]; !found {
+			return errors.Errorf("object %s is not in the inventory", id)
+		}
+	}
+	return nil
+}
+
+// encodeCheckpoint returns the gzipped JSON list of the objects, sorted by ID.
+func encodeCheckpoint(objs []*unstructured.Unstructured) ([]byte, error) {
+	sort.Slice(objs, func(i, j int) bool {
+		return core.IDOf(objs[i]).String() < core.IDOf(objs[j]).String()
+	})
+	var list []map[string]interface{}
+	for _, obj := range objs {
+		list = append(list, obj.Object)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		return nil, errors.Wrap(err, "encoding checkpoint")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "compressing checkpoint")
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCheckpoint is the inverse of encodeCheckpoint.
+func decodeCheckpoint(data []byte) ([]*unstructured.Unstructured, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var list []map[string]interface{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	var objs []*unstructured.Unstructured
+	for _, obj := range list {
+		objs = append(objs, &unstructured.Unstructured{Object: obj})
+	}
+	return objs, nil
+}
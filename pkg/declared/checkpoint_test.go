@@ -0,0 +1,243 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package declared
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"kpt.dev/configsync/pkg/core"
+	syncerfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaces returns n Namespaces, which are large enough together to need
+// several checkpoint chunks when checkpointChunkSize is small.
+func namespaces(n int) []client.Object {
+	var objs []client.Object
+	for i := 0; i < n; i++ {
+		objs = append(objs, fake.NamespaceObject(fmt.Sprintf("namespace-%d", i)))
+	}
+	return objs
+}
+
+// inventoryOf returns the IDs of the objects.
+func inventoryOf(objs []client.Object) map[core.ID]struct{} {
+	ids := make(map[core.ID]struct{}, len(objs))
+	for _, obj := range objs {
+		ids[core.IDOf(obj)] = struct{}{}
+	}
+	return ids
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	defer func(size int) { checkpointChunkSize = size }(checkpointChunkSize)
+	checkpointChunkSize = 64
+
+	ctx := context.Background()
+	c := syncerfake.NewClient(t, core.Scheme)
+	cp := NewCheckpoint(c, RootReconciler, "root-sync")
+
+	var err error
+	saved := &Resources{}
+	_, err = saved.Update(ctx, namespaces(20), "abc123")
+	require.NoError(t, err)
+	require.NoError(t, cp.Save(ctx, saved))
+
+	head := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: "root-reconciler-declared-0"}, head))
+	assert.Equal(t, "abc123", head.Annotations[checkpointCommitKey])
+	assert.Equal(t, "RootSync", head.Labels["configsync.gke.io/sync-kind"])
+	chunks, err := strconv.Atoi(head.Annotations[checkpointChunksKey])
+	require.NoError(t, err)
+	require.Greater(t, chunks, 2, "expected several chunks")
+
+	restored := &Resources{}
+	ok, err := cp.Restore(ctx, restored, inventoryOf(namespaces(20)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, saved.objectSet, restored.objectSet)
+	assert.Equal(t, "abc123", restored.commit)
+
+	// The restored set is the previous set of the safeguard.
+	_, err = restored.Update(ctx, nil, "def456")
+	assert.Error(t, err)
+
+	// A smaller checkpoint clears the chunks left over.
+	_, err = saved.Update(ctx, namespaces(2), "def456")
+	require.NoError(t, err)
+	require.NoError(t, cp.Save(ctx, saved))
+	last := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: CheckpointName("root-reconciler", chunks-1)}, last))
+	assert.Empty(t, last.BinaryData)
+	assert.Empty(t, last.Annotations)
+
+	restored = &Resources{}
+	ok, err = cp.Restore(ctx, restored, inventoryOf(namespaces(20)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, restored.objectSet, 2)
+	assert.Equal(t, "def456", restored.commit)
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	defer func(size int) { checkpointChunkSize = size }(checkpointChunkSize)
+	checkpointChunkSize = 64
+
+	ctx := context.Background()
+	c := syncerfake.NewClient(t, core.Scheme)
+	cp := NewCheckpoint(c, RootReconciler, "root-sync")
+
+	// No checkpoint.
+	var err error
+	ok, err := cp.Restore(ctx, &Resources{}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	saved := &Resources{}
+	_, err = saved.Update(ctx, namespaces(20), "abc123")
+	require.NoError(t, err)
+	require.NoError(t, cp.Save(ctx, saved))
+
+	// Resources which have already been declared are not overwritten.
+	declared := &Resources{}
+	_, err = declared.Update(ctx, namespaces(1), "def456")
+	require.NoError(t, err)
+	ok, err = cp.Restore(ctx, declared, inventoryOf(namespaces(20)))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "def456", declared.commit)
+
+	// Objects which are not in the inventory mean the checkpoint is stale.
+	restored := &Resources{}
+	_, err = cp.Restore(ctx, restored, inventoryOf(namespaces(19)))
+	assert.Error(t, err)
+	assert.Nil(t, restored.objectSet)
+
+	// A chunk from another commit means the last Save was interrupted.
+	chunk := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: "config-management-system", Name: "root-reconciler-declared-1"}
+	require.NoError(t, c.Get(ctx, key, chunk))
+	chunk.Annotations[checkpointCommitKey] = "def456"
+	require.NoError(t, c.Update(ctx, chunk))
+	restored = &Resources{}
+	_, err = cp.Restore(ctx, restored, inventoryOf(namespaces(20)))
+	assert.Error(t, err)
+	assert.Nil(t, restored.objectSet)
+}
+
+func TestCheckpointRestore_Namespace(t *testing.T) {
+	ctx := context.Background()
+	c := syncerfake.NewClient(t, core.Scheme)
+	cp := NewCheckpoint(c, Scope("bookstore"), "repo-sync")
+
+	objs := []client.Object{
+		fake.ConfigMapObject(core.Namespace("bookstore"), core.Name("inventory")),
+		fake.ConfigMapObject(core.Namespace("shipping"), core.Name("inventory")),
+	}
+	saved := &Resources{}
+	_, err := saved.Update(ctx, objs, "abc123")
+	require.NoError(t, err)
+	require.NoError(t, cp.Save(ctx, saved))
+
+	head := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "config-management-system", Name: "ns-reconciler-bookstore-declared-0"}, head))
+	assert.Equal(t, "RepoSync", head.Labels["configsync.gke.io/sync-kind"])
+	assert.Equal(t, "bookstore", head.Labels["configsync.gke.io/sync-namespace"])
+
+	// Objects outside the namespace of the RepoSync are never restored.
+	restored := &Resources{}
+	_, restoreErr := cp.Restore(ctx, restored, inventoryOf(objs))
+	assert.Error(t, restoreErr)
+	assert.Nil(t, restored.objectSet)
+}
+
+func TestCheckpointSave_Unchanged(t *testing.T) {
+	ctx := context.Background()
+	c := syncerfake.NewClient(t, core.Scheme)
+	key := client.ObjectKey{Namespace: "config-management-system", Name: "root-reconciler-declared-0"}
+
+	saved := &Resources{}
+	_, err := saved.Update(ctx, namespaces(2), "abc123")
+	require.NoError(t, err)
+	require.NoError(t, NewCheckpoint(c, RootReconciler, "root-sync").Save(ctx, saved))
+	head := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, key, head))
+	version := head.ResourceVersion
+
+	// The same commit and resources are not written again, even after a
+	// restart.
+	cp := NewCheckpoint(c, RootReconciler, "root-sync")
+	require.NoError(t, cp.Save(ctx, saved))
+	require.NoError(t, c.Get(ctx, key, head))
+	assert.Equal(t, version, head.ResourceVersion)
+
+	// A new commit is written.
+	_, err = saved.Update(ctx, namespaces(2), "def456")
+	require.NoError(t, err)
+	require.NoError(t, cp.Save(ctx, saved))
+	require.NoError(t, c.Get(ctx, key, head))
+	assert.NotEqual(t, version, head.ResourceVersion)
+	assert.Equal(t, "def456", head.Annotations[checkpointCommitKey])
+}
+
+func TestCheckpoint_PreCreated(t *testing.T) {
+	defer func(size int) { checkpointChunkSize = size }(checkpointChunkSize)
+	checkpointChunkSize = 64
+
+	ctx := context.Background()
+	c := syncerfake.NewClient(t, core.Scheme)
+	// The reconciler-manager creates the empty ConfigMaps.
+	for _, name := range CheckpointNames("ns-reconciler-shop") {
+		cm := &corev1.ConfigMap{}
+		cm.Name = name
+		cm.Namespace = "config-management-system"
+		require.NoError(t, c.Create(ctx, cm))
+	}
+	cp := NewCheckpoint(c, Scope("shop"), "repo-sync")
+
+	ok, err := cp.Restore(ctx, &Resources{}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	saved := &Resources{}
+	_, err = saved.Update(ctx, []client.Object{fake.RoleObject(core.Namespace("shop"))}, "abc123")
+	require.NoError(t, err)
+	require.NoError(t, cp.Save(ctx, saved))
+	restored := &Resources{}
+	ok, err = cp.Restore(ctx, restored, inventoryOf([]client.Object{fake.RoleObject(core.Namespace("shop"))}))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc123", restored.commit)
+}
+
+func TestCheckpointSave_TooLarge(t *testing.T) {
+	defer func(size int) { checkpointChunkSize = size }(checkpointChunkSize)
+	checkpointChunkSize = 1
+
+	ctx := context.Background()
+	c := syncerfake.NewClient(t, core.Scheme)
+	cp := NewCheckpoint(c, RootReconciler, "root-sync")
+	saved := &Resources{}
+	_, err := saved.Update(ctx, namespaces(20), "abc123")
+	require.NoError(t, err)
+	assert.Error(t, cp.Save(ctx, saved))
+}
@@ -79,6 +79,27 @@ func (r *Resources) Update(ctx context.Context, objects []client.Object, commit
 	return newObjects, nil
 }
 
+// restore sets the resource declaration set to the objects restored from a
+// Checkpoint, unless the set has already been declared. Unlike Update, it
+// skips the deletion safeguard, since the objects were validated before they
+// were saved and Checkpoint.Restore checks them against the inventory.
+// Returns true if the objects were restored.
+func (r *Resources) restore(objects []*unstructured.Unstructured, commit string) bool {
+	newSet := make(map[core.ID]*unstructured.Unstructured, len(objects))
+	for _, obj := range objects {
+		newSet[core.IDOf(obj)] = obj
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.objectSet != nil {
+		return false
+	}
+	r.objectSet = newSet
+	r.commit = commit
+	return true
+}
+
 // Get returns a copy of the resource declaration as read from Git
 func (r *Resources) Get(id core.ID) (*unstructured.Unstructured, string, bool) {
 	objSet, commit := r.getObjectSet()
@@ -122,6 +122,10 @@ func (d Diff) updateType(scope declared.Scope, syncName string) Operation {
 	// another object, possible causing (and being surfaced as) a resource fight.
 	canManage := CanManage(scope, syncName, d.Actual, admissionv1.Update)
 	switch {
+	case differ.HandedOff(d.Declared, d.Actual):
+		// The management is handed off to the current manager, which claimed
+		// the object, so the remediator must not revert it.
+		return NoOp
 	case differ.ManagementEnabled(d.Declared) && canManage:
 		if d.Actual.GetAnnotations()[metadata.LifecycleMutationAnnotation] == metadata.IgnoreMutation &&
 			d.Declared.GetAnnotations()[metadata.LifecycleMutationAnnotation] == metadata.IgnoreMutation {
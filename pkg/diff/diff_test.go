@@ -64,7 +64,28 @@ func TestDiffType(t *testing.T) {
 			declared: fake.RoleObject(),
 			want:     Error,
 		},
+		{
+			name:     "declared + no actual, management handoff pending: create",
+			declared: fake.RoleObject(syncertest.ManagementEnabled, core.Annotation(metadata.ResourceHandoffKey, "shipping")),
+			want:     Create,
+		},
 		// Declared + actual paths.
+		{
+			name:     "declared + actual, management handoff pending, self-owned object: update",
+			scope:    declared.RootReconciler,
+			declared: fake.RoleObject(syncertest.ManagementEnabled, core.Annotation(metadata.ResourceHandoffKey, "shipping")),
+			actual: fake.RoleObject(syncertest.ManagementEnabled,
+				difftest.ManagedBy(declared.RootReconciler, syncName)),
+			want: Update,
+		},
+		{
+			name:     "declared + actual, management handed off, target-owned object: no op",
+			scope:    declared.RootReconciler,
+			declared: fake.RoleObject(syncertest.ManagementEnabled, core.Annotation(metadata.ResourceHandoffKey, "shipping")),
+			actual: fake.RoleObject(syncertest.ManagementEnabled,
+				difftest.ManagedBy("shipping", configsync.RepoSyncName)),
+			want: NoOp,
+		},
 		{
 			name:     "declared + actual, management enabled, no manager annotation, root scope, can manage: update",
 			scope:    declared.RootReconciler,
@@ -60,7 +60,7 @@ func CanManage(scope declared.Scope, syncName string, obj client.Object, op admi
 	}
 	oldManager := core.GetAnnotation(obj, metadata.ResourceManagerKey)
 	newManager := declared.ResourceManager(scope, syncName)
-	reconciler, _ := reconcilerName(newManager)
+	reconciler, _ := ReconcilerName(newManager)
 	err := ValidateManager(reconciler, oldManager, core.IDOf(obj), op)
 	if err != nil {
 		klog.V(3).Infof("diff.CanManage? %v", err)
@@ -89,7 +89,7 @@ func ValidateManager(reconciler, manager string, id core.ID, op admissionv1.Oper
 		return nil
 	}
 
-	oldReconciler, syncScope := reconcilerName(manager)
+	oldReconciler, syncScope := ReconcilerName(manager)
 
 	if err := declared.ValidateScope(string(syncScope)); err != nil {
 		// All managers are allowed to manage an object with an invalid manager.
@@ -145,7 +145,9 @@ func ValidateManager(reconciler, manager string, id core.ID, op admissionv1.Oper
 	return nil
 }
 
-func reconcilerName(manager string) (string, declared.Scope) {
+// ReconcilerName returns the name of the reconciler and the scope of the
+// R*Sync specified by the manager annotation value.
+func ReconcilerName(manager string) (string, declared.Scope) {
 	syncScope, syncName := declared.ManagerScopeAndName(manager)
 	var reconciler string
 	if syncScope == declared.RootReconciler {
@@ -0,0 +1,458 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// errObjectNotFound is returned when an object is not in the repository, e.g.
+// the parent of a commit at the boundary of a shallow clone.
+var errObjectNotFound = errors.New("object not found")
+
+// Pack object types, see gitformat-pack(5).
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+// maxDeltaChain bounds the length of the delta chains resolved in the pack
+// files, so a corrupt pack cannot recurse indefinitely.
+const maxDeltaChain = 1000
+
+// repository reads the objects of a Git repository checked out by git-sync,
+// so the reconciler does not need the git binary.
+type repository struct {
+	objectsDir string
+	packs      []*packIndex
+}
+
+// openRepository opens the repository of the worktree at dir. The .git entry
+// of a worktree created by git-sync is a file pointing to the Git directory
+// of the worktree, whose commondir holds the objects.
+func openRepository(dir string) (*repository, error) {
+	gitDir := filepath.Join(dir, ".git")
+	info, err := os.Stat(gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the Git repository %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		content, err := os.ReadFile(gitDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the Git repository %s: %w", dir, err)
+		}
+		line := strings.TrimSpace(string(content))
+		if !strings.HasPrefix(line, "gitdir:") {
+			return nil, fmt.Errorf("failed to open the Git repository %s: invalid .git file %q", dir, line)
+		}
+		gitDir = resolvePath(dir, strings.TrimSpace(strings.TrimPrefix(line, "gitdir:")))
+	}
+	if content, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		gitDir = resolvePath(gitDir, strings.TrimSpace(string(content)))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open the Git repository %s: %w", dir, err)
+	}
+
+	r := &repository{objectsDir: filepath.Join(gitDir, "objects")}
+	idxFiles, err := filepath.Glob(filepath.Join(r.objectsDir, "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	for _, idxFile := range idxFiles {
+		p, err := readPackIndex(idxFile)
+		if err != nil {
+			return nil, err
+		}
+		r.packs = append(r.packs, p)
+	}
+	return r, nil
+}
+
+// resolvePath returns path, relative to dir unless it is absolute.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// readObject returns the type and content of the object with the hash.
+func (r *repository) readObject(hash string) (int, []byte, error) {
+	if len(hash) != 2*sha1Size {
+		return 0, nil, fmt.Errorf("invalid object name %q", hash)
+	}
+	id, err := hex.DecodeString(hash)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid object name %q", hash)
+	}
+	f, err := os.Open(filepath.Join(r.objectsDir, hash[:2], hash[2:]))
+	switch {
+	case err == nil:
+		defer func() { _ = f.Close() }()
+		return readLooseObject(f)
+	case !os.IsNotExist(err):
+		return 0, nil, err
+	}
+	for _, p := range r.packs {
+		if offset, ok := p.find(id); ok {
+			return r.readPackObject(p, offset)
+		}
+	}
+	return 0, nil, errObjectNotFound
+}
+
+// readLooseObject reads a zlib compressed "<type> <size>\x00<content>" object.
+func readLooseObject(f io.Reader) (int, []byte, error) {
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read the loose object: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read the loose object: %w", err)
+	}
+	header, data, ok := bytes.Cut(content, []byte{0})
+	if !ok {
+		return 0, nil, fmt.Errorf("invalid loose object header")
+	}
+	name, _, _ := strings.Cut(string(header), " ")
+	switch name {
+	case "commit":
+		return packCommit, data, nil
+	case "tree":
+		return packTree, data, nil
+	case "blob":
+		return packBlob, data, nil
+	case "tag":
+		return packTag, data, nil
+	default:
+		return 0, nil, fmt.Errorf("invalid loose object type %q", name)
+	}
+}
+
+const sha1Size = 20
+
+// packIndex is a version 2 pack index, see gitformat-pack(5).
+type packIndex struct {
+	packFile  string
+	fanout    [256]uint32
+	hashes    []byte
+	offsets   []byte
+	offsets64 []byte
+}
+
+func readPackIndex(idxFile string) (*packIndex, error) {
+	content, err := os.ReadFile(idxFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the pack index: %w", err)
+	}
+	invalid := fmt.Errorf("invalid pack index %s", idxFile)
+	if len(content) < 8+256*4 || !bytes.Equal(content[:4], []byte("\377tOc")) || binary.BigEndian.Uint32(content[4:8]) != 2 {
+		return nil, invalid
+	}
+	p := &packIndex{packFile: strings.TrimSuffix(idxFile, ".idx") + ".pack"}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(content[8+4*i:])
+	}
+	n := int(p.fanout[255])
+	rest := content[8+256*4:]
+	if len(rest) < n*(sha1Size+4+4) {
+		return nil, invalid
+	}
+	p.hashes = rest[:n*sha1Size]
+	// Skip the CRC32 checksums.
+	rest = rest[n*(sha1Size+4):]
+	p.offsets = rest[:n*4]
+	p.offsets64 = rest[n*4:]
+	return p, nil
+}
+
+// find returns the offset of the object with the id in the pack file.
+func (p *packIndex) find(id []byte) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashes[(lo+i)*sha1Size:(lo+i+1)*sha1Size], id) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.hashes[i*sha1Size:(i+1)*sha1Size], id) {
+		return 0, false
+	}
+	offset := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+	j := int(offset & 0x7fffffff)
+	if len(p.offsets64) < (j+1)*8 {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.offsets64[j*8:])), true
+}
+
+// readPackObject reads the object at the offset of the pack file, resolving
+// its delta chain.
+func (r *repository) readPackObject(p *packIndex, offset int64) (int, []byte, error) {
+	f, err := os.Open(p.packFile)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read the pack file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var deltas [][]byte
+	for len(deltas) <= maxDeltaChain {
+		br := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+		typ, err := readPackEntryType(br)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid pack file %s: %w", p.packFile, err)
+		}
+		var baseType int
+		var base []byte
+		switch typ {
+		case packCommit, packTree, packBlob, packTag:
+			data, err := inflate(br)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid pack file %s: %w", p.packFile, err)
+			}
+			baseType, base = typ, data
+		case packOfsDelta:
+			distance, err := readOfsDeltaDistance(br)
+			if err != nil || distance <= 0 || distance > offset {
+				return 0, nil, fmt.Errorf("invalid pack file %s: invalid delta offset", p.packFile)
+			}
+			delta, err := inflate(br)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid pack file %s: %w", p.packFile, err)
+			}
+			deltas = append(deltas, delta)
+			offset -= distance
+			continue
+		case packRefDelta:
+			id := make([]byte, sha1Size)
+			if _, err := io.ReadFull(br, id); err != nil {
+				return 0, nil, fmt.Errorf("invalid pack file %s: %w", p.packFile, err)
+			}
+			delta, err := inflate(br)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid pack file %s: %w", p.packFile, err)
+			}
+			deltas = append(deltas, delta)
+			baseType, base, err = r.readObject(hex.EncodeToString(id))
+			if err != nil {
+				return 0, nil, err
+			}
+		default:
+			return 0, nil, fmt.Errorf("invalid pack file %s: invalid object type %d", p.packFile, typ)
+		}
+		for i := len(deltas) - 1; i >= 0; i-- {
+			base, err = applyDelta(base, deltas[i])
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid pack file %s: %w", p.packFile, err)
+			}
+		}
+		return baseType, base, nil
+	}
+	return 0, nil, fmt.Errorf("invalid pack file %s: delta chain too long", p.packFile)
+}
+
+// readPackEntryType reads the type and size header of a pack entry and
+// returns the type. The size is implied by the compressed data.
+func readPackEntryType(br io.ByteReader) (int, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	typ := int(b>>4) & 7
+	for b&0x80 != 0 {
+		if b, err = br.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return typ, nil
+}
+
+// readOfsDeltaDistance reads the distance to the base of an OFS_DELTA entry.
+func readOfsDeltaDistance(br io.ByteReader) (int64, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	distance := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = br.ReadByte(); err != nil {
+			return 0, err
+		}
+		distance = ((distance + 1) << 7) | int64(b&0x7f)
+	}
+	return distance, nil
+}
+
+func inflate(r io.Reader) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+	return io.ReadAll(zr)
+}
+
+// applyDelta applies a delta of a pack file to the base object.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	invalid := errors.New("invalid delta")
+	br := bytes.NewReader(delta)
+	srcSize, err := binary.ReadUvarint(br)
+	if err != nil || srcSize != uint64(len(base)) {
+		return nil, invalid
+	}
+	dstSize, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, invalid
+	}
+	result := make([]byte, 0, dstSize)
+	for br.Len() > 0 {
+		op, _ := br.ReadByte()
+		switch {
+		case op&0x80 != 0:
+			var offset, size uint64
+			for i := 0; i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				b, err := br.ReadByte()
+				if err != nil {
+					return nil, invalid
+				}
+				if i < 4 {
+					offset |= uint64(b) << (8 * i)
+				} else {
+					size |= uint64(b) << (8 * (i - 4))
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, invalid
+			}
+			result = append(result, base[offset:offset+size]...)
+		case op != 0:
+			data := make([]byte, op)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, invalid
+			}
+			result = append(result, data...)
+		default:
+			return nil, invalid
+		}
+	}
+	if uint64(len(result)) != dstSize {
+		return nil, invalid
+	}
+	return result, nil
+}
+
+// commit is a parsed Git commit object.
+type commit struct {
+	parents []string
+	// committerEmail is the email of the committer.
+	committerEmail string
+	// signature is the ASCII-armored signature in the gpgsig header.
+	signature []byte
+	// payload is the commit object without the signature, which is the
+	// signed content.
+	payload []byte
+}
+
+// readCommit reads and parses the commit with the hash.
+func (r *repository) readCommit(hash string) (*commit, error) {
+	typ, data, err := r.readObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if typ != packCommit {
+		return nil, fmt.Errorf("object %s is not a commit", hash)
+	}
+	return parseCommit(data)
+}
+
+// parseCommit parses the headers of a commit object, see git-commit-tree(1).
+// Header values continue on the following lines starting with a space.
+func parseCommit(data []byte) (*commit, error) {
+	c := &commit{}
+	var payload, signature bytes.Buffer
+	inSignature := false
+	rest := data
+	for len(rest) > 0 {
+		line, next, found := bytes.Cut(rest, []byte("\n"))
+		if !found {
+			return nil, fmt.Errorf("invalid commit: truncated headers")
+		}
+		rest = next
+		if len(line) == 0 {
+			// The end of the headers.
+			payload.WriteString("\n")
+			payload.Write(rest)
+			break
+		}
+		if line[0] == ' ' {
+			if inSignature {
+				signature.WriteString("\n")
+				signature.Write(line[1:])
+			} else {
+				payload.Write(line)
+				payload.WriteString("\n")
+			}
+			continue
+		}
+		inSignature = false
+		key, value, _ := bytes.Cut(line, []byte(" "))
+		switch string(key) {
+		case "gpgsig":
+			inSignature = true
+			signature.Write(value)
+			continue
+		case "parent":
+			c.parents = append(c.parents, string(value))
+		case "committer":
+			if start, end := bytes.IndexByte(value, '<'), bytes.IndexByte(value, '>'); start >= 0 && end > start {
+				c.committerEmail = string(value[start+1 : end])
+			}
+		}
+		payload.Write(line)
+		payload.WriteString("\n")
+	}
+	c.payload = payload.Bytes()
+	if signature.Len() > 0 {
+		c.signature = append(signature.Bytes(), '\n')
+	}
+	return c, nil
+}
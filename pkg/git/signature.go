@@ -0,0 +1,304 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	// openpgp is frozen, but it is the only OpenPGP implementation vendored.
+	"golang.org/x/crypto/openpgp" //nolint:staticcheck
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// gpgKeyBlock is the header of an ASCII-armored GPG public key.
+	gpgKeyBlock = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	// gpgSignatureBlock is the header of an ASCII-armored GPG signature.
+	gpgSignatureBlock = "-----BEGIN PGP SIGNATURE-----"
+	// sshSignatureBlock is the header of an ASCII-armored SSH signature.
+	sshSignatureBlock = "-----BEGIN SSH SIGNATURE-----"
+	// sshSignatureNamespace is the namespace of the SSH signatures of Git
+	// commits.
+	sshSignatureNamespace = "git"
+)
+
+// trustedKeys are the keys trusted to sign the commits.
+type trustedKeys struct {
+	gpg openpgp.EntityList
+	ssh []allowedSigner
+}
+
+// allowedSigner is an SSH key trusted to sign the commits of the committers
+// matching its principals, see ssh-keygen(1) ALLOWED SIGNERS.
+type allowedSigner struct {
+	principals string
+	key        ssh.PublicKey
+}
+
+// readTrustedKeys reads the trusted keys from the files of the secret volume
+// at dir. A file either holds an ASCII-armored GPG public key block, or SSH
+// keys in the allowed signers format.
+func readTrustedKeys(dir string) (*trustedKeys, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the trusted keys: %w", err)
+	}
+	keys := &trustedKeys{}
+	for _, entry := range entries {
+		// Skip the ..data links and timestamped directories of the secret
+		// volume.
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the trusted keys: %w", err)
+		}
+		if bytes.Contains(content, []byte(gpgKeyBlock)) {
+			entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted key %q: %w", entry.Name(), err)
+			}
+			keys.gpg = append(keys.gpg, entities...)
+			continue
+		}
+		signers, err := parseAllowedSigners(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted key %q: %w", entry.Name(), err)
+		}
+		keys.ssh = append(keys.ssh, signers...)
+	}
+	if len(keys.gpg) == 0 && len(keys.ssh) == 0 {
+		return nil, fmt.Errorf("no trusted keys found in the verification secret")
+	}
+	return keys, nil
+}
+
+// parseAllowedSigners parses SSH keys in the allowed signers format:
+// "principals [namespaces="git"] keytype base64-key [comment]". The
+// principals are required, so a key is only trusted for the committers it
+// is issued to.
+func parseAllowedSigners(content []byte) ([]allowedSigner, error) {
+	var signers []allowedSigner
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || isSSHKeyType(fields[0]) {
+			return nil, fmt.Errorf("want a GPG public key block or SSH allowed signers "+
+				"with principals, options and keys, got %q", line)
+		}
+		principals, keyFields := fields[0], fields[1:]
+		if !isSSHKeyType(keyFields[0]) {
+			if err := checkSignerOptions(keyFields[0]); err != nil {
+				return nil, fmt.Errorf("%w in %q", err, line)
+			}
+			keyFields = keyFields[1:]
+		}
+		if len(keyFields) < 2 {
+			return nil, fmt.Errorf("missing key in %q", line)
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(keyFields[0] + " " + keyFields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in %q: %w", line, err)
+		}
+		signers = append(signers, allowedSigner{principals: principals, key: key})
+	}
+	return signers, scanner.Err()
+}
+
+// isSSHKeyType returns true if the field is the type of an SSH public key,
+// e.g. ssh-ed25519 or ecdsa-sha2-nistp256.
+func isSSHKeyType(field string) bool {
+	return strings.HasPrefix(field, "ssh-") || strings.HasPrefix(field, "ecdsa-") ||
+		strings.HasPrefix(field, "sk-")
+}
+
+// checkSignerOptions returns an error unless the options of an allowed
+// signer only restrict the key to the namespaces including git.
+func checkSignerOptions(options string) error {
+	for _, option := range splitOptions(options) {
+		name, value, _ := strings.Cut(option, "=")
+		if !strings.EqualFold(name, "namespaces") {
+			return fmt.Errorf("unsupported option %q", name)
+		}
+		if !matchPatternList(sshSignatureNamespace, strings.Trim(value, `"`)) {
+			return fmt.Errorf("the namespaces %s do not include %q", value, sshSignatureNamespace)
+		}
+	}
+	return nil
+}
+
+// splitOptions splits the comma-separated options, which may quote values
+// with commas.
+func splitOptions(options string) []string {
+	var result []string
+	quoted := false
+	start := 0
+	for i, r := range options {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			result = append(result, options[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, options[start:])
+}
+
+// matchPatternList returns true if the value matches the comma-separated
+// patterns and none of the patterns negated with "!", like the principals of
+// ssh-keygen(1) ALLOWED SIGNERS. The patterns support the "*" and "?"
+// wildcards.
+func matchPatternList(value, patterns string) bool {
+	matched := false
+	for _, pattern := range strings.Split(patterns, ",") {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		// Escape the characters which are not wildcards in the SSH patterns.
+		pattern = strings.NewReplacer(`\`, `\\`, `[`, `\[`).Replace(pattern)
+		if ok, err := path.Match(pattern, value); err != nil || !ok {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// verifySignature returns an error unless the commit is signed by a trusted
+// key issued to its committer.
+func (k *trustedKeys) verifySignature(c *commit) error {
+	switch {
+	case len(c.signature) == 0:
+		return fmt.Errorf("the commit is not signed")
+	case bytes.HasPrefix(c.signature, []byte(gpgSignatureBlock)):
+		return k.verifyGPGSignature(c)
+	case bytes.HasPrefix(c.signature, []byte(sshSignatureBlock)):
+		return k.verifySSHSignature(c)
+	default:
+		return fmt.Errorf("unsupported signature format")
+	}
+}
+
+func (k *trustedKeys) verifyGPGSignature(c *commit) error {
+	signer, err := openpgp.CheckArmoredDetachedSignature(k.gpg, bytes.NewReader(c.payload), bytes.NewReader(c.signature))
+	if err != nil {
+		return fmt.Errorf("invalid GPG signature: %w", err)
+	}
+	for _, identity := range signer.Identities {
+		if identity.UserId != nil && strings.EqualFold(identity.UserId.Email, c.committerEmail) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the GPG key %s is not issued to the committer %q", signer.PrimaryKey.KeyIdString(), c.committerEmail)
+}
+
+// sshSignature is the SSHSIG signature format of OpenSSH, see
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig.
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data signed by an SSHSIG signature, after the
+// "SSHSIG" magic preamble.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func (k *trustedKeys) verifySSHSignature(c *commit) error {
+	armored := strings.TrimSpace(string(c.signature))
+	armored = strings.TrimPrefix(armored, sshSignatureBlock)
+	armored = strings.TrimSuffix(armored, "-----END SSH SIGNATURE-----")
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(armored), ""))
+	if err != nil || !bytes.HasPrefix(blob, []byte("SSHSIG")) {
+		return fmt.Errorf("invalid SSH signature")
+	}
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob[len("SSHSIG"):], &sig); err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Version != 1 || sig.Namespace != sshSignatureNamespace {
+		return fmt.Errorf("invalid SSH signature: want version 1 and namespace %q, got version %d and namespace %q",
+			sshSignatureNamespace, sig.Version, sig.Namespace)
+	}
+	key, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	var trusted bool
+	for _, signer := range k.ssh {
+		if bytes.Equal(signer.key.Marshal(), key.Marshal()) && matchPatternList(c.committerEmail, signer.principals) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return fmt.Errorf("the SSH key %s is not trusted for the committer %q", ssh.FingerprintSHA256(key), c.committerEmail)
+	}
+
+	var hash []byte
+	switch sig.HashAlgorithm {
+	case "sha256":
+		sum := sha256.Sum256(c.payload)
+		hash = sum[:]
+	case "sha512":
+		sum := sha512.Sum512(c.payload)
+		hash = sum[:]
+	default:
+		return fmt.Errorf("invalid SSH signature: unsupported hash algorithm %q", sig.HashAlgorithm)
+	}
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, signature); err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	signed := append([]byte("SSHSIG"), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          hash,
+	})...)
+	if err := key.Verify(signed, signature); err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	return nil
+}
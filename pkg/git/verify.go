@@ -0,0 +1,163 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package git verifies the signatures of the commits fetched by git-sync.
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+// Verifier verifies that the synced commits are signed by a trusted GPG or
+// SSH key. The signatures are verified in-process, reading the objects of the
+// repository fetched by git-sync, so the reconciler image needs neither git
+// nor gpg.
+type Verifier struct {
+	// KeysDir is the directory of the mounted secret with the trusted keys.
+	KeysDir string
+	// Policy specifies which commits must be signed.
+	Policy v1beta1.GitVerificationPolicy
+
+	// lastVerified is the last commit verified successfully.
+	lastVerified string
+}
+
+// LastVerified returns the last commit verified successfully, or an empty
+// string if no commit has been verified yet.
+func (v *Verifier) LastVerified() string {
+	return v.lastVerified
+}
+
+// Verify returns an error if the commit in the repository at dir is not
+// signed by a trusted key issued to its committer. With the AllCommitsSigned
+// policy, the commits between the last verified commit, or lastSynced if no
+// commit has been verified yet, and the commit are verified as well.
+func (v *Verifier) Verify(dir, commit, lastSynced string) error {
+	if commit == v.lastVerified {
+		return nil
+	}
+	base := v.lastVerified
+	if base == "" {
+		base = lastSynced
+	}
+
+	keys, err := readTrustedKeys(v.KeysDir)
+	if err != nil {
+		return err
+	}
+	repo, err := openRepository(dir)
+	if err != nil {
+		return err
+	}
+
+	commits := []string{commit}
+	if v.Policy == v1beta1.AllCommitsSigned && base != "" && base != commit {
+		commits, err = repo.commitsSince(base, commit)
+		if err != nil {
+			return err
+		}
+	}
+	for _, hash := range commits {
+		c, err := repo.readCommit(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		if err := keys.verifySignature(c); err != nil {
+			return fmt.Errorf("commit %s is not signed by a trusted key: %w", hash, err)
+		}
+	}
+	klog.Infof("Verified the signatures of %d commit(s) up to %s", len(commits), commit)
+	v.lastVerified = commit
+	return nil
+}
+
+// commitsSince returns the commits reachable from commit but not from base,
+// like git rev-list base..commit.
+func (r *repository) commitsSince(base, commit string) ([]string, error) {
+	notAncestor := fmt.Errorf("failed to verify the commits since the last synced commit %s: "+
+		"it is not an ancestor of commit %s, or it is not in the history fetched by git-sync "+
+		"(consider increasing spec.override.gitSyncDepth)", base, commit)
+	excluded, err := r.history(base)
+	if err != nil {
+		if errors.Is(err, errObjectNotFound) {
+			return nil, notAncestor
+		}
+		return nil, err
+	}
+	if excluded[commit] {
+		return nil, notAncestor
+	}
+
+	var commits []string
+	reachesBase := false
+	queue := []string{commit}
+	seen := map[string]bool{commit: true}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		c, err := r.readCommit(hash)
+		if err != nil {
+			if errors.Is(err, errObjectNotFound) {
+				// The commit is beyond the shallow clone, so it cannot be
+				// verified.
+				return nil, notAncestor
+			}
+			return nil, err
+		}
+		commits = append(commits, hash)
+		for _, parent := range c.parents {
+			if parent == base {
+				reachesBase = true
+			}
+			if excluded[parent] || seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			queue = append(queue, parent)
+		}
+	}
+	if !reachesBase {
+		return nil, notAncestor
+	}
+	return commits, nil
+}
+
+// history returns the commit and its ancestors, up to the boundary of the
+// shallow clone.
+func (r *repository) history(commit string) (map[string]bool, error) {
+	history := map[string]bool{commit: true}
+	queue := []string{commit}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		c, err := r.readCommit(hash)
+		if errors.Is(err, errObjectNotFound) && hash != commit {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range c.parents {
+			if !history[parent] {
+				history[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,316 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+// testRepo is a Git repository with commits signed by SSH and GPG keys.
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	env  []string
+	keys map[string]string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	for _, bin := range []string{"git", "gpg", "ssh-keygen"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	home := t.TempDir()
+	r := &testRepo{
+		t:   t,
+		dir: filepath.Join(home, "repo"),
+		env: append(os.Environ(), "HOME="+home, "GNUPGHOME="+filepath.Join(home, "gnupg"),
+			"GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull,
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com"),
+		keys: map[string]string{},
+	}
+	if err := os.Mkdir(filepath.Join(home, "gnupg"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"trusted", "untrusted"} {
+		key := filepath.Join(home, name)
+		r.cmd(home, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", key)
+		r.keys[name] = key
+	}
+	r.cmd(home, "gpg", "--batch", "--passphrase", "", "--quick-gen-key", "Test <test@example.com>", "rsa2048", "sign", "never")
+	r.keys["gpg"] = r.cmd(home, "gpg", "--batch", "--armor", "--export", "test@example.com")
+	r.cmd(home, "git", "init", "-q", "-b", "main", r.dir)
+	return r
+}
+
+func (r *testRepo) cmd(dir string, name string, args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = r.env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("%s %s: %v: %s", name, strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// commit creates a commit signed with the key, or an unsigned commit if key
+// is empty, and returns its hash.
+func (r *testRepo) commit(key string) string {
+	r.t.Helper()
+	args := []string{"commit", "-q", "--allow-empty", "-m", "commit"}
+	switch key {
+	case "":
+	case "gpg":
+		args = append([]string{"-c", "user.signingkey=test@example.com"}, append(args, "-S")...)
+	default:
+		args = append([]string{"-c", "gpg.format=ssh", "-c", "user.signingkey=" + r.keys[key]}, append(args, "-S")...)
+	}
+	r.cmd(r.dir, "git", args...)
+	return strings.TrimSpace(r.cmd(r.dir, "git", "rev-parse", "HEAD"))
+}
+
+// keysDir returns a directory with the trusted keys, laid out like a secret
+// volume.
+func (r *testRepo) keysDir(files map[string]string) string {
+	r.t.Helper()
+	dir := r.t.TempDir()
+	data := filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+	if err := os.Mkdir(data, 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")); err != nil {
+		r.t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(data, name), []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// allowedSigner returns the SSH public key with the name in the allowed
+// signers format, trusted for the principals.
+func (r *testRepo) allowedSigner(principals, name string) string {
+	r.t.Helper()
+	content, err := os.ReadFile(r.keys[name] + ".pub")
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return principals + " " + string(content)
+}
+
+func TestVerify(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("trusted")
+	unsigned := r.commit("")
+	untrusted := r.commit("untrusted")
+	gpgSigned := r.commit("gpg")
+	head := r.commit("trusted")
+	keys := r.keysDir(map[string]string{
+		"allowed_signers": "# trusted keys\n" + r.allowedSigner("test@example.com", "trusted") +
+			r.allowedSigner("other@example.com", "untrusted"),
+		"gpg.asc": r.keys["gpg"],
+	})
+
+	testCases := []struct {
+		name       string
+		keys       string
+		policy     v1beta1.GitVerificationPolicy
+		commit     string
+		lastSynced string
+		wantErr    string
+	}{
+		{
+			name:   "HEAD signed by a trusted SSH key",
+			keys:   keys,
+			policy: v1beta1.HeadSigned,
+			commit: head,
+		},
+		{
+			name:   "HEAD signed by a trusted GPG key",
+			keys:   keys,
+			policy: v1beta1.HeadSigned,
+			commit: gpgSigned,
+		},
+		{
+			name:    "HEAD signed by an untrusted key",
+			keys:    keys,
+			policy:  v1beta1.HeadSigned,
+			commit:  untrusted,
+			wantErr: "commit " + untrusted + " is not signed by a trusted key: the SSH key",
+		},
+		{
+			name:    "HEAD not signed",
+			keys:    keys,
+			policy:  v1beta1.HeadSigned,
+			commit:  unsigned,
+			wantErr: "commit " + unsigned + " is not signed by a trusted key",
+		},
+		{
+			name:       "HEAD signed with unsigned commits since the last synced commit",
+			keys:       keys,
+			policy:     v1beta1.HeadSigned,
+			commit:     head,
+			lastSynced: base,
+		},
+		{
+			name:       "all commits signed with unsigned commits since the last synced commit",
+			keys:       keys,
+			policy:     v1beta1.AllCommitsSigned,
+			commit:     head,
+			lastSynced: base,
+			wantErr:    "is not signed by a trusted key",
+		},
+		{
+			name:       "all commits signed since the last synced commit",
+			keys:       keys,
+			policy:     v1beta1.AllCommitsSigned,
+			commit:     head,
+			lastSynced: untrusted,
+		},
+		{
+			name:       "all commits signed without a last synced commit",
+			keys:       keys,
+			policy:     v1beta1.AllCommitsSigned,
+			commit:     head,
+			lastSynced: "",
+		},
+		{
+			name:       "last synced commit not an ancestor",
+			keys:       keys,
+			policy:     v1beta1.AllCommitsSigned,
+			commit:     base,
+			lastSynced: head,
+			wantErr:    "is not an ancestor of commit " + base,
+		},
+		{
+			name:   "HEAD signed by a key trusted for the committer with namespaces",
+			keys:   r.keysDir(map[string]string{"ssh": r.allowedSigner(`test@example.com namespaces="file,git"`, "untrusted")}),
+			policy: v1beta1.HeadSigned,
+			commit: untrusted,
+		},
+		{
+			name:    "HEAD signed by a key trusted for other committers",
+			keys:    r.keysDir(map[string]string{"ssh": r.allowedSigner("*,!test@example.com", "trusted")}),
+			policy:  v1beta1.HeadSigned,
+			commit:  head,
+			wantErr: `is not trusted for the committer "test@example.com"`,
+		},
+		{
+			name:    "no trusted keys",
+			keys:    r.keysDir(nil),
+			policy:  v1beta1.HeadSigned,
+			commit:  head,
+			wantErr: "no trusted keys found",
+		},
+		{
+			name:    "invalid trusted key",
+			keys:    r.keysDir(map[string]string{"key": "not a key"}),
+			policy:  v1beta1.HeadSigned,
+			commit:  head,
+			wantErr: `invalid trusted key "key"`,
+		},
+		{
+			name:    "SSH key without principals",
+			keys:    r.keysDir(map[string]string{"key": strings.TrimPrefix(r.allowedSigner("", "trusted"), " ")}),
+			policy:  v1beta1.HeadSigned,
+			commit:  head,
+			wantErr: `invalid trusted key "key": want a GPG public key block or SSH allowed signers with principals`,
+		},
+		{
+			name:    "SSH key with unsupported options",
+			keys:    r.keysDir(map[string]string{"key": r.allowedSigner("test@example.com cert-authority", "trusted")}),
+			policy:  v1beta1.HeadSigned,
+			commit:  head,
+			wantErr: `unsupported option "cert-authority"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &Verifier{KeysDir: tc.keys, Policy: tc.policy}
+			err := v.Verify(r.dir, tc.commit, tc.lastSynced)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got Verify() error %v, want nil", err)
+				}
+				if v.lastVerified != tc.commit {
+					t.Errorf("got lastVerified %q, want %q", v.lastVerified, tc.commit)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("got Verify() error %v, want an error containing %q", err, tc.wantErr)
+			}
+			if v.lastVerified != "" {
+				t.Errorf("got lastVerified %q, want the failed commit not to be recorded", v.lastVerified)
+			}
+		})
+	}
+}
+
+func TestVerifyFromLastVerified(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("trusted")
+	r.commit("")
+	second := r.commit("trusted")
+	v := &Verifier{KeysDir: r.keysDir(map[string]string{"ssh": r.allowedSigner("*@example.com", "trusted")}), Policy: v1beta1.AllCommitsSigned}
+
+	if err := v.Verify(r.dir, first, ""); err != nil {
+		t.Fatal(err)
+	}
+	// The unsigned commit since the last verified commit fails the
+	// verification, even if the last synced commit is more recent.
+	if err := v.Verify(r.dir, second, second); err == nil {
+		t.Errorf("got Verify() error nil, want the unsigned commit since the last verified commit to fail")
+	}
+	if got := v.LastVerified(); got != first {
+		t.Errorf("got LastVerified() %q, want %q", got, first)
+	}
+}
+
+func TestVerifyPackedWorktree(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("trusted")
+	r.commit("gpg")
+	head := r.commit("trusted")
+	// Pack the objects with deltas, and check out the commit in a linked
+	// worktree like git-sync does.
+	r.cmd(r.dir, "git", "gc", "-q", "--aggressive")
+	worktree := filepath.Join(r.t.TempDir(), "worktree")
+	r.cmd(r.dir, "git", "worktree", "add", "-q", "--detach", worktree, head)
+	keys := r.keysDir(map[string]string{
+		"allowed_signers": r.allowedSigner("test@example.com", "trusted"),
+		"gpg.asc":         r.keys["gpg"],
+	})
+
+	v := &Verifier{KeysDir: keys, Policy: v1beta1.AllCommitsSigned}
+	if err := v.Verify(worktree, head, base); err != nil {
+		t.Fatalf("got Verify() error %v, want nil", err)
+	}
+}
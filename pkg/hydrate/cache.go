@@ -0,0 +1,380 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// renderCacheVersion is the version of the render cache keys. It must be
+	// bumped when the rendering changes for the same inputs.
+	renderCacheVersion = "v1"
+	// renderedDir is the directory of the rendered configs in a cache entry.
+	renderedDir = "rendered"
+	// cacheMetadataFile is the file of the metadata of a cache entry.
+	cacheMetadataFile = "metadata.json"
+	// tmpCachePrefix is the prefix of the cache entries being added or
+	// removed.
+	tmpCachePrefix = "tmp-"
+)
+
+// renderCache is a content-addressed cache of the rendered configs. Each entry
+// is a directory under root named after the key of the rendering inputs. The
+// rendered files are hard links to the files of the hydrated directories, so
+// entries are cheap to add and to reuse.
+type renderCache struct {
+	// root is the directory of the cache entries. It must be on the same
+	// filesystem as the hydrated directories.
+	root string
+	// size is the maximum number of entries. The least recently used entries
+	// are evicted beyond it.
+	size int
+	// remoteTTL is the period of time after which the configs rendered from
+	// remote bases or Helm charts expire.
+	remoteTTL time.Duration
+}
+
+// cacheMetadata is the metadata of a cache entry.
+type cacheMetadata struct {
+	// Created is the time the configs were rendered.
+	Created time.Time `json:"created"`
+	// Remote is true if the configs were rendered from remote bases or Helm
+	// charts, which may change without changing the key.
+	Remote bool `json:"remote,omitempty"`
+}
+
+// lookup returns the directory of the configs rendered with the key, if they
+// are in the cache and not expired.
+func (c *renderCache) lookup(key string) (string, bool) {
+	dir := filepath.Join(c.root, key)
+	content, err := os.ReadFile(filepath.Join(dir, cacheMetadataFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			klog.Warningf("Unable to read the render cache entry %s: %v", dir, err)
+		}
+		return "", false
+	}
+	meta := cacheMetadata{}
+	if err := json.Unmarshal(content, &meta); err != nil {
+		klog.Warningf("Unable to parse the render cache entry %s: %v", dir, err)
+		return "", false
+	}
+	if meta.Remote && time.Since(meta.Created) > c.remoteTTL {
+		klog.Infof("The render cache entry %s is expired because it has remote bases or Helm charts", dir)
+		return "", false
+	}
+	// The modification time of the entry is its last use.
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		klog.Warningf("Unable to update the last use of the render cache entry %s: %v", dir, err)
+	}
+	return filepath.Join(dir, renderedDir), true
+}
+
+// add adds the configs rendered in dir to the cache with the key, and evicts
+// the least recently used entries beyond the size of the cache.
+func (c *renderCache) add(key, dir string, remote bool) error {
+	if err := os.MkdirAll(c.root, os.FileMode(0755)); err != nil {
+		return errors.Wrapf(err, "unable to make directory: %s", c.root)
+	}
+	tmpDir, err := os.MkdirTemp(c.root, tmpCachePrefix)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create a temporary directory under %s", c.root)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			klog.Warningf("Unable to remove the temporary directory %s: %v", tmpDir, err)
+		}
+	}()
+	if err := linkTree(dir, filepath.Join(tmpDir, renderedDir)); err != nil {
+		return err
+	}
+	content, err := json.Marshal(cacheMetadata{Created: time.Now(), Remote: remote})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, cacheMetadataFile), content, os.FileMode(0644)); err != nil {
+		return errors.Wrapf(err, "unable to write the metadata of the render cache entry %s", key)
+	}
+
+	entry := filepath.Join(c.root, key)
+	if _, err := os.Stat(entry); err == nil {
+		// An expired entry is replaced. It is moved out of the way first, so
+		// the entry is never partially removed.
+		oldDir, err := os.MkdirTemp(c.root, tmpCachePrefix)
+		if err != nil {
+			return errors.Wrapf(err, "unable to create a temporary directory under %s", c.root)
+		}
+		defer func() {
+			if err := os.RemoveAll(oldDir); err != nil {
+				klog.Warningf("Unable to remove the expired render cache entry %s: %v", oldDir, err)
+			}
+		}()
+		if err := os.Rename(entry, filepath.Join(oldDir, key)); err != nil {
+			return errors.Wrapf(err, "unable to remove the expired render cache entry %s", entry)
+		}
+	}
+	if err := os.Rename(tmpDir, entry); err != nil {
+		return errors.Wrapf(err, "unable to rename %s to %s", tmpDir, entry)
+	}
+	c.evict()
+	return nil
+}
+
+// evict removes the least recently used entries beyond the size of the cache.
+func (c *renderCache) evict() {
+	entries, err := os.ReadDir(c.root)
+	if err != nil {
+		klog.Warningf("Unable to list the render cache entries in %s: %v", c.root, err)
+		return
+	}
+	var keys []os.FileInfo
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), tmpCachePrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, info)
+	}
+	if len(keys) <= c.size {
+		return
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ModTime().After(keys[j].ModTime())
+	})
+	for _, info := range keys[c.size:] {
+		dir := filepath.Join(c.root, info.Name())
+		klog.V(3).Infof("Evicting the render cache entry %s", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			klog.Warningf("Unable to evict the render cache entry %s: %v", dir, err)
+		}
+	}
+}
+
+// linkTree replaces dst with a copy of the src directory, whose files are hard
+// links to the files of src. Files are copied if they can't be linked.
+func linkTree(src, dst string) error {
+	if err := os.RemoveAll(dst); err != nil {
+		return errors.Wrapf(err, "unable to remove %s", dst)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			if err := os.Link(path, target); err == nil {
+				return nil
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFile copies the file src to dst.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// renderKey returns the key of the configs rendered from syncDir, and whether
+// they are rendered from remote bases or Helm charts. The key is a hash of the
+// files in syncDir and in the local Kustomize bases outside of syncDir, of the
+// version and flags of Kustomize, and of the allowed exec functions.
+// kustomizeVersion is empty if syncDir is not rendered by Kustomize.
+func renderKey(syncDir, kustomizeVersion string, allowedExec []string) (string, bool, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "cache %s\n", renderCacheVersion)
+	fmt.Fprintf(h, "exec %q\n", allowedExec)
+	dirs := []string{syncDir}
+	remote := false
+	if kustomizeVersion != "" {
+		fmt.Fprintf(h, "kustomize %s %q\n", kustomizeVersion, kustomizeBuildFlags)
+		bases, hasRemote, err := externalBases(syncDir)
+		if err != nil {
+			return "", false, err
+		}
+		dirs = append(dirs, bases...)
+		remote = hasRemote
+	}
+	for _, dir := range dirs {
+		if err := hashTree(h, syncDir, dir); err != nil {
+			return "", false, errors.Wrapf(err, "unable to hash the files in %s", dir)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), remote, nil
+}
+
+// hashTree writes the paths relative to root, the modes, and the contents of
+// the files in dir to w. The `.git` files are skipped, because they differ
+// between commits of the same repository.
+func hashTree(w io.Writer, root, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Name() == ".git" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case info.IsDir():
+			fmt.Fprintf(w, "dir %q\n", rel)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "symlink %q %q\n", rel, link)
+		default:
+			fmt.Fprintf(w, "file %q %o %d\n", rel, info.Mode().Perm(), info.Size())
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// externalBases returns the local Kustomize bases and components of the
+// kustomization in syncDir which are outside of syncDir, and whether the
+// kustomization has remote bases or Helm charts.
+func externalBases(syncDir string) ([]string, bool, error) {
+	visited := make(map[string]bool)
+	var bases []string
+	remote := false
+	var visit func(dir string) error
+	visit = func(dir string) error {
+		if visited[dir] {
+			return nil
+		}
+		visited[dir] = true
+		if rel, err := filepath.Rel(syncDir, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			bases = append(bases, dir)
+		}
+		k, err := readKustomization(dir)
+		if err != nil || k == nil {
+			return err
+		}
+		for _, chart := range k.HelmCharts {
+			if chart.Repo != "" {
+				remote = true
+			}
+		}
+		for _, chart := range k.HelmChartInflationGenerator {
+			if chart.ChartRepoURL != "" {
+				remote = true
+			}
+		}
+		for _, r := range append(append(append([]string{}, k.Resources...), k.Bases...), k.Components...) {
+			if strings.Contains(r, "://") || (&resource.Origin{}).Append(r).Repo != "" {
+				remote = true
+				continue
+			}
+			base := filepath.Join(dir, r)
+			if info, err := os.Stat(base); err == nil && info.IsDir() {
+				if err := visit(base); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(filepath.Clean(syncDir)); err != nil {
+		return nil, false, err
+	}
+	sort.Strings(bases)
+	return bases, remote, nil
+}
+
+// readKustomization returns the kustomization in dir, or nil if dir has no
+// kustomization file.
+func readKustomization(dir string) (*types.Kustomization, error) {
+	for _, name := range validKustomizationFiles {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "unable to read %s", path)
+		}
+		k := &types.Kustomization{}
+		if err := yaml.Unmarshal(content, k); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %s", path)
+		}
+		return k, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+)
+
+const overlayKustomization = `resources:
+- ../../base
+- service.yaml
+`
+
+func TestRenderKey(t *testing.T) {
+	newRepo := func(t *testing.T) string {
+		repo := t.TempDir()
+		writeFiles(t, repo, map[string]string{
+			"overlays/prod/kustomization.yaml": overlayKustomization,
+			"overlays/prod/service.yaml":       serviceYAML,
+			"base/kustomization.yaml":          "resources:\n- deployment.yaml\n",
+			"base/deployment.yaml":             deploymentYAML,
+			"README.md":                        "readme",
+			".git":                             "gitdir: ../.git/worktrees/1234567890abcdef",
+		})
+		return repo
+	}
+	baseKey := func(t *testing.T) string {
+		repo := newRepo(t)
+		key, remote, err := renderKey(filepath.Join(repo, "overlays/prod"), "v5.1.1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if remote {
+			t.Errorf("got renderKey() remote = true, want false")
+		}
+		return key
+	}
+	want := baseKey(t)
+
+	testCases := []struct {
+		name       string
+		files      map[string]string
+		version    string
+		exec       []string
+		wantSame   bool
+		wantRemote bool
+	}{
+		{
+			name:     "same inputs",
+			version:  "v5.1.1",
+			wantSame: true,
+		},
+		{
+			name:     "file changed outside of the inputs",
+			files:    map[string]string{"README.md": "new readme", ".git": "gitdir: ../.git/worktrees/abcdef1234567890"},
+			version:  "v5.1.1",
+			wantSame: true,
+		},
+		{
+			name:    "file changed in the sync directory",
+			files:   map[string]string{"overlays/prod/service.yaml": serviceYAML + "  labels: {}\n"},
+			version: "v5.1.1",
+		},
+		{
+			name:    "file added in the sync directory",
+			files:   map[string]string{"overlays/prod/other.yaml": serviceYAML},
+			version: "v5.1.1",
+		},
+		{
+			name:    "file changed in a base outside of the sync directory",
+			files:   map[string]string{"base/deployment.yaml": serviceYAML},
+			version: "v5.1.1",
+		},
+		{
+			name:    "different Kustomize version",
+			version: "v5.2.0",
+		},
+		{
+			name:    "different allowed exec functions",
+			version: "v5.1.1",
+			exec:    []string{"/usr/local/bin/my-fn"},
+		},
+		{
+			name: "remote base",
+			files: map[string]string{
+				"overlays/prod/kustomization.yaml": overlayKustomization + "- https://github.com/kubernetes-sigs/kustomize//examples/multibases?ref=v3.3.1\n",
+			},
+			version:    "v5.1.1",
+			wantRemote: true,
+		},
+		{
+			name: "remote Helm chart",
+			files: map[string]string{
+				"base/kustomization.yaml": "resources:\n- deployment.yaml\nhelmCharts:\n- name: nginx\n  repo: https://charts.bitnami.com/bitnami\n",
+			},
+			version:    "v5.1.1",
+			wantRemote: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newRepo(t)
+			writeFiles(t, repo, tc.files)
+			got, remote, err := renderKey(filepath.Join(repo, "overlays/prod"), tc.version, tc.exec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantSame && got != want {
+				t.Errorf("got renderKey() = %s, want %s", got, want)
+			}
+			if !tc.wantSame && got == want {
+				t.Errorf("got renderKey() = %s, want a different key", got)
+			}
+			if remote != tc.wantRemote {
+				t.Errorf("got renderKey() remote = %t, want %t", remote, tc.wantRemote)
+			}
+		})
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	rendered := t.TempDir()
+	writeFiles(t, rendered, map[string]string{"service.yaml": serviceYAML})
+	cache := &renderCache{root: filepath.Join(t.TempDir(), "cache"), size: 2, remoteTTL: time.Hour}
+
+	if _, found := cache.lookup("a"); found {
+		t.Fatalf("got lookup(a) found in empty cache")
+	}
+	for _, key := range []string{"a", "b"} {
+		if err := cache.add(key, rendered, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Make "a" the most recently used entry.
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(filepath.Join(cache.root, "b"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	dir, found := cache.lookup("a")
+	if !found {
+		t.Fatalf("got lookup(a) not found, want found")
+	}
+	if got := readOutput(t, dir); got != "# service.yaml\n"+serviceYAML {
+		t.Errorf("got cached configs %q, want service.yaml", got)
+	}
+	info, err := os.Stat(filepath.Join(dir, "service.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcInfo, err := os.Stat(filepath.Join(rendered, "service.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(info, srcInfo) {
+		t.Errorf("got cached file copied, want a hard link to the rendered file")
+	}
+
+	// Adding "c" evicts the least recently used entry "b".
+	if err := cache.add("c", rendered, false); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, found := cache.lookup(key); found != want {
+			t.Errorf("got lookup(%s) found = %t, want %t", key, found, want)
+		}
+	}
+
+	// Entries rendered from remote bases expire after the TTL. The cache is
+	// large enough not to evict the local entries.
+	cache.size = 3
+	cache.remoteTTL = 0
+	if err := cache.add("remote", rendered, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := cache.lookup("remote"); found {
+		t.Errorf("got lookup(remote) found after the TTL, want not found")
+	}
+	if _, found := cache.lookup("c"); !found {
+		t.Errorf("got lookup(c) not found, want local entries not to expire")
+	}
+	// Expired entries are replaced.
+	cache.remoteTTL = time.Hour
+	if err := cache.add("remote", rendered, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := cache.lookup("remote"); !found {
+		t.Errorf("got lookup(remote) not found, want found")
+	}
+}
+
+func TestRenderWithCache(t *testing.T) {
+	syncDir := t.TempDir()
+	writeFiles(t, syncDir, map[string]string{
+		Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - image: set-namespace
+    configMap:
+      namespace: bookstore
+`,
+		"service.yaml": serviceYAML,
+	})
+	cacheRoot, err := cmpath.AbsoluteOS(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Hydrator{
+		RenderCacheRoot:      cacheRoot,
+		RenderCacheSize:      1,
+		RenderCacheRemoteTTL: time.Hour,
+	}
+
+	hydrated := t.TempDir()
+	first := filepath.Join(hydrated, "commit1")
+	if err := h.renderWithCache(syncDir, first); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(hydrated, "commit2")
+	if err := h.renderWithCache(syncDir, second); err != nil {
+		t.Fatal(err)
+	}
+	want := readOutput(t, first)
+	if got := readOutput(t, second); got != want {
+		t.Errorf("got rendered configs:\n%s\nwant:\n%s", got, want)
+	}
+	firstInfo, err := os.Stat(filepath.Join(first, "service.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondInfo, err := os.Stat(filepath.Join(second, "service.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(firstInfo, secondInfo) {
+		t.Errorf("got configs rendered again, want the configs in the render cache to be reused")
+	}
+}
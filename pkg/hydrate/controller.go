@@ -22,23 +22,31 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/importer/filesystem/ignore"
+	"kpt.dev/configsync/pkg/kmetrics"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util"
 )
 
 const (
 	// tmpLink is the temporary soft link name.
 	tmpLink = "tmp-link"
+	// tmpSourcePrefix is the prefix of the staging directories of the source
+	// files to render.
+	tmpSourcePrefix = "tmp-source-"
 	// DoneFile is the file name that indicates the hydration is done.
 	DoneFile = "done"
 	// ErrorFile is the file name of the hydration errors.
@@ -61,12 +69,40 @@ type Hydrator struct {
 	HydratedLink string
 	// SyncDir is the relative path to the configs within the Git repository.
 	SyncDir cmpath.Relative
+	// SyncInclude is the list of patterns of the files to render from
+	// SyncDir. If empty, all the files are rendered.
+	SyncInclude []string
+	// SyncExclude is the list of patterns of the files not to render from
+	// SyncDir.
+	SyncExclude []string
 	// PollingPeriod is the period of time between checking the filesystem for source updates to render.
 	PollingPeriod time.Duration
 	// RehydratePeriod is the period of time between rehydrating on errors.
 	RehydratePeriod time.Duration
 	// ReconcilerName is the name of the reconciler.
 	ReconcilerName string
+	// AllowedExecFunctions are the executables allowed to run as exec
+	// functions of the Kptfile pipeline.
+	AllowedExecFunctions []string
+	// RenderCacheRoot is the absolute path to the render cache directory. It
+	// must be on the same volume as HydratedRoot.
+	RenderCacheRoot cmpath.Absolute
+	// RenderCacheSize is the maximum number of rendered directories kept in
+	// the render cache. The render cache is disabled if it is 0.
+	RenderCacheSize int
+	// RenderCacheRemoteTTL is the period of time after which the configs
+	// rendered from remote bases or Helm charts are rendered again.
+	RenderCacheRemoteTTL time.Duration
+	// Refresh receives the requests of the reconciler to render the source
+	// configs again, bypassing the render cache, when a resync is requested.
+	Refresh <-chan os.Signal
+
+	// refreshing is true while the source configs are rendered again for a
+	// request received on Refresh.
+	refreshing bool
+	// kustomizeVersion is the version of the installed Kustomize, for the
+	// render cache keys.
+	kustomizeVersion string
 }
 
 // Run runs the hydration process periodically.
@@ -90,6 +126,15 @@ func (h *Hydrator) Run(ctx context.Context) {
 		case <-rehydrateTimer.C:
 			hydrateErr = h.rehydrateOnError(hydrateErr, srcCommit, syncDir)
 			rehydrateTimer.Reset(h.RehydratePeriod) // Schedule rehydrate attempt
+		case <-h.Refresh:
+			// Removing the done file renders the current commit again.
+			klog.Info("Rendering the source configs again, as requested by the reconciler")
+			if err := os.RemoveAll(h.DonePath.OSPath()); err != nil {
+				klog.Errorf("unable to remove the done file %s: %v", h.DonePath.OSPath(), err)
+				continue
+			}
+			h.refreshing = true
+			runTimer.Reset(0) // Schedule re-run attempt
 		case <-runTimer.C:
 			// pull the source commit and directory with retries within 5 minutes.
 			srcCommit, syncDir, err = SourceCommitAndDirWithRetry(util.SourceRetryBackoff, h.SourceType, absSourceDir, h.SyncDir, h.ReconcilerName)
@@ -105,7 +150,12 @@ func (h *Hydrator) Run(ctx context.Context) {
 				// If the commit has been processed before, regardless of success or failure,
 				// skip the hydration to avoid repeated execution.
 				// The rehydrate ticker will retry on the failed commit.
+				_, span := tracing.StartSpan(ctx, tracing.SpanRender,
+					trace.StringAttribute(tracing.KeyReconciler, h.ReconcilerName),
+					trace.StringAttribute(tracing.KeyCommit, srcCommit))
 				hydrateErr = h.hydrate(srcCommit, syncDir)
+				h.refreshing = false
+				tracing.EndSpan(span, hydrateErr)
 				if err := h.complete(srcCommit, hydrateErr); err != nil {
 					klog.Errorf("failed to complete the rendering execution for commit %q: %v", srcCommit, err)
 				}
@@ -115,12 +165,24 @@ func (h *Hydrator) Run(ctx context.Context) {
 	}
 }
 
-// runHydrate runs `kustomize build` on the source configs.
+// runHydrate renders the source configs with `kustomize build` and the
+// function pipeline of the Kptfile.
 func (h *Hydrator) runHydrate(sourceCommit string, syncDir cmpath.Absolute) HydrationError {
 	newHydratedDir := h.HydratedRoot.Join(cmpath.RelativeOS(sourceCommit))
 	dest := newHydratedDir.Join(h.SyncDir).OSPath()
 
-	if err := kustomizeBuild(syncDir.OSPath(), dest, true); err != nil {
+	renderDir, stagingDir, err := h.stageSources(syncDir.OSPath())
+	if err != nil {
+		return NewInternalError(errors.Wrapf(err, "unable to stage the source files of %s", syncDir.OSPath()))
+	}
+	if stagingDir != "" {
+		defer func() {
+			if err := os.RemoveAll(stagingDir); err != nil {
+				klog.Warningf("Unable to remove the staging directory %s: %v", stagingDir, err)
+			}
+		}()
+	}
+	if err := h.renderWithCache(renderDir, dest); err != nil {
 		return err
 	}
 
@@ -128,7 +190,7 @@ func (h *Hydrator) runHydrate(sourceCommit string, syncDir cmpath.Absolute) Hydr
 	if err != nil {
 		return NewTransientError(err)
 	} else if sourceCommit != newCommit {
-		return NewTransientError(fmt.Errorf("source commit changed while running Kustomize build, was %s, now %s. It will be retried in the next sync", sourceCommit, newCommit))
+		return NewTransientError(fmt.Errorf("source commit changed while rendering, was %s, now %s. It will be retried in the next sync", sourceCommit, newCommit))
 	}
 
 	if err := updateSymlink(h.HydratedRoot.OSPath(), h.HydratedLink, newHydratedDir.OSPath()); err != nil {
@@ -138,6 +200,152 @@ func (h *Hydrator) runHydrate(sourceCommit string, syncDir cmpath.Absolute) Hydr
 	return nil
 }
 
+// stageSources returns the directory to render the configs of syncDir from.
+// If files of syncDir may be ignored, by the ignore files or by the include
+// and exclude patterns, the source repository is linked to a staging directory
+// without them, and the sync directory in it is returned with the staging
+// directory, which the caller must remove.
+func (h *Hydrator) stageSources(syncDir string) (string, string, error) {
+	absSyncDir, err := cmpath.AbsoluteOS(syncDir)
+	if err != nil {
+		return "", "", err
+	}
+	if len(h.SyncInclude) == 0 && len(h.SyncExclude) == 0 {
+		found, err := ignore.HasFiles(absSyncDir)
+		if err != nil || !found {
+			return syncDir, "", err
+		}
+	}
+
+	// The configs may refer to files outside of the sync directory, such as
+	// the bases of a Kustomization, so the whole repository is staged.
+	sourceDir := syncDir
+	if rel := filepath.Clean(h.SyncDir.OSPath()); rel != "." {
+		for range strings.Split(rel, string(filepath.Separator)) {
+			sourceDir = filepath.Dir(sourceDir)
+		}
+	}
+	if err := os.MkdirAll(h.HydratedRoot.OSPath(), os.FileMode(0755)); err != nil {
+		return "", "", errors.Wrapf(err, "unable to make directory: %s", h.HydratedRoot.OSPath())
+	}
+	stagingDir, err := os.MkdirTemp(h.HydratedRoot.OSPath(), tmpSourcePrefix)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "unable to create a temporary directory under %s", h.HydratedRoot.OSPath())
+	}
+	stagedSource := filepath.Join(stagingDir, filepath.Base(sourceDir))
+	stagedSyncDir := filepath.Join(stagedSource, h.SyncDir.OSPath())
+	if err := h.stage(sourceDir, stagedSource, stagedSyncDir); err != nil {
+		if rmErr := os.RemoveAll(stagingDir); rmErr != nil {
+			klog.Warningf("Unable to remove the staging directory %s: %v", stagingDir, rmErr)
+		}
+		return "", "", err
+	}
+	return stagedSyncDir, stagingDir, nil
+}
+
+// stage links the source repository sourceDir to stagedSource, and removes
+// the files ignored from its sync directory stagedSyncDir.
+func (h *Hydrator) stage(sourceDir, stagedSource, stagedSyncDir string) error {
+	if err := linkTree(sourceDir, stagedSource); err != nil {
+		return err
+	}
+	dir, err := cmpath.AbsoluteOS(stagedSyncDir)
+	if err != nil {
+		return err
+	}
+	matcher, err := ignore.NewMatcher(dir, h.SyncInclude, h.SyncExclude)
+	if err != nil {
+		return err
+	}
+	return matcher.RemoveIgnored()
+}
+
+// render renders the configs in syncDir to dest. If syncDir has both a
+// Kustomization and a Kptfile pipeline, the pipeline runs on the output of
+// `kustomize build`.
+func (h *Hydrator) render(syncDir, dest string) HydrationError {
+	kustomize, err := needsKustomize(syncDir)
+	if err != nil {
+		return NewInternalError(errors.Wrapf(err, "unable to check if Kustomize is needed for the source directory: %s", syncDir))
+	}
+	pipeline, err := readPipeline(syncDir)
+	if err != nil {
+		return NewActionableError(err)
+	}
+	input := syncDir
+	if kustomize {
+		if err := kustomizeBuild(syncDir, dest, true); err != nil {
+			return err
+		}
+		input = dest
+	}
+	if pipeline == nil {
+		return nil
+	}
+	return runPipeline(pipeline, syncDir, input, dest, h.AllowedExecFunctions)
+}
+
+// renderWithCache renders the configs in syncDir to dest, unless the configs
+// rendered from the same inputs are in the render cache and no refresh was
+// requested.
+func (h *Hydrator) renderWithCache(syncDir, dest string) HydrationError {
+	if h.RenderCacheSize <= 0 {
+		return h.render(syncDir, dest)
+	}
+	key, remote, err := h.renderKey(syncDir)
+	if err != nil {
+		klog.Warningf("Rendering %s without the render cache: %v", syncDir, err)
+		return h.render(syncDir, dest)
+	}
+	cache := &renderCache{
+		root:      h.RenderCacheRoot.OSPath(),
+		size:      h.RenderCacheSize,
+		remoteTTL: h.RenderCacheRemoteTTL,
+	}
+	cached, found := cache.lookup(key)
+	if found && h.refreshing {
+		klog.Infof("Rendering %s again instead of reusing %s, as requested by the reconciler", syncDir, cached)
+		found = false
+	}
+	if found {
+		kmetrics.RecordRenderCacheResult(context.Background(), kmetrics.CacheHit)
+		if err := linkTree(cached, dest); err == nil {
+			klog.Infof("Reused the configs rendered from the same inputs as %s in %s", syncDir, cached)
+			return nil
+		}
+		klog.Warningf("Unable to reuse the configs rendered in %s: %v", cached, err)
+	} else {
+		kmetrics.RecordRenderCacheResult(context.Background(), kmetrics.CacheMiss)
+	}
+	if err := h.render(syncDir, dest); err != nil {
+		return err
+	}
+	if err := cache.add(key, dest, remote); err != nil {
+		klog.Warningf("Unable to add the configs rendered from %s to the render cache: %v", syncDir, err)
+	}
+	return nil
+}
+
+// renderKey returns the render cache key of the configs in syncDir, and
+// whether they are rendered from remote bases or Helm charts.
+func (h *Hydrator) renderKey(syncDir string) (string, bool, error) {
+	kustomize, err := needsKustomize(syncDir)
+	if err != nil {
+		return "", false, err
+	}
+	if kustomize && h.kustomizeVersion == "" {
+		h.kustomizeVersion, err = getVersion(Kustomize)
+		if err != nil {
+			return "", false, errors.Wrap(err, "unable to get the Kustomize version")
+		}
+	}
+	version := ""
+	if kustomize {
+		version = h.kustomizeVersion
+	}
+	return renderKey(syncDir, version, h.AllowedExecFunctions)
+}
+
 // ComputeCommit returns the computed commit from given sourceDir, or error
 // if the sourceDir fails symbolic link evaluation
 func ComputeCommit(sourceDir cmpath.Absolute) (string, error) {
@@ -158,7 +366,7 @@ func (h *Hydrator) absSourceDir() cmpath.Absolute {
 // hydrate renders the source git repo to hydrated configs.
 func (h *Hydrator) hydrate(sourceCommit string, syncDirPath cmpath.Absolute) HydrationError {
 	syncDir := syncDirPath.OSPath()
-	hydrate, err := needsKustomize(syncDir)
+	hydrate, err := needsRendering(syncDir)
 	if err != nil {
 		return NewInternalError(errors.Wrapf(err, "unable to check if rendering is needed for the source directory: %s", syncDir))
 	}
@@ -172,7 +380,7 @@ func (h *Hydrator) hydrate(sourceCommit string, syncDirPath cmpath.Absolute) Hyd
 				"To fix, either add kustomization.yaml in the sync directory to trigger the rendering process, "+
 				"or remove kustomizaiton.yaml from all sub directories to skip rendering.", syncDir))
 		}
-		klog.V(5).Infof("no rendering is needed because of no Kustomization config file in the source configs with commit %s", sourceCommit)
+		klog.V(5).Infof("no rendering is needed because of no Kustomization config file or Kptfile pipeline in the source configs with commit %s", sourceCommit)
 		if err := os.RemoveAll(h.HydratedRoot.OSPath()); err != nil {
 			return NewInternalError(err)
 		}
@@ -307,6 +515,10 @@ func exportError(commit, root, errorFile string, hydrationError HydrationError)
 		Code:  hydrationError.Code(),
 		Error: hydrationError.Error(),
 	}
+	var fnErr FunctionError
+	if errors.As(hydrationError, &fnErr) {
+		payload.Results = fnErr.Results
+	}
 
 	jb, err := json.Marshal(payload)
 	if err != nil {
@@ -27,6 +27,7 @@ import (
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	ft "kpt.dev/configsync/pkg/importer/filesystem/filesystemtest"
+	"kpt.dev/configsync/pkg/importer/filesystem/ignore"
 	"sigs.k8s.io/cli-utils/pkg/testutil"
 )
 
@@ -206,7 +207,7 @@ func TestRunHydrate(t *testing.T) {
 		{
 			name:      "Run hydrate when source commit is changed",
 			commit:    differentCommit,
-			wantedErr: testutil.EqualError(NewTransientError(fmt.Errorf("source commit changed while running Kustomize build, was %s, now %s. It will be retried in the next sync", originCommit, differentCommit))),
+			wantedErr: testutil.EqualError(NewTransientError(fmt.Errorf("source commit changed while rendering, was %s, now %s. It will be retried in the next sync", originCommit, differentCommit))),
 		},
 	}
 
@@ -329,3 +330,111 @@ func TestComputeCommit(t *testing.T) {
 		})
 	}
 }
+
+func TestStageSources(t *testing.T) {
+	files := map[string]string{
+		"base/kustomization.yaml":             "resources: [cm.yaml]",
+		"base/cm.yaml":                        "kind: ConfigMap",
+		"configs/kustomization.yaml":          "resources: [../base, ns.yaml, tmpl.yaml]",
+		"configs/ns.yaml":                     "kind: Namespace",
+		"configs/tmpl.yaml":                   "kind: Template",
+		"configs/docs/README.md":              "docs",
+		"configs/overlays/" + ignore.File:     "/*.md\n",
+		"configs/overlays/kustomization.yaml": "resources: [..]",
+		"configs/overlays/NOTES.md":           "notes",
+	}
+
+	testCases := []struct {
+		name        string
+		ignoreFile  string
+		include     []string
+		exclude     []string
+		wantRemoved []string
+	}{
+		{
+			name:        "nested ignore file",
+			wantRemoved: []string{"configs/overlays/NOTES.md"},
+		},
+		{
+			name:        "ignore file and exclude patterns",
+			ignoreFile:  "docs/\n",
+			exclude:     []string{"tmpl.yaml"},
+			wantRemoved: []string{"configs/docs/README.md", "configs/overlays/NOTES.md", "configs/tmpl.yaml"},
+		},
+		{
+			name:        "include patterns",
+			include:     []string{"*.yaml"},
+			wantRemoved: []string{"configs/docs/README.md", "configs/overlays/NOTES.md"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := cmpath.AbsoluteOS(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sourceDir := root.Join(cmpath.RelativeSlash("source/" + originCommit))
+			all := map[string]string{}
+			for f, content := range files {
+				all[f] = content
+			}
+			if tc.ignoreFile != "" {
+				all["configs/"+ignore.File] = tc.ignoreFile
+			}
+			for f, content := range all {
+				p := sourceDir.Join(cmpath.RelativeSlash(f)).OSPath()
+				if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			hydrator := &Hydrator{
+				HydratedRoot: root.Join(cmpath.RelativeSlash("hydrated")),
+				SyncDir:      cmpath.RelativeSlash("configs"),
+				SyncInclude:  tc.include,
+				SyncExclude:  tc.exclude,
+			}
+			syncDir := sourceDir.Join(hydrator.SyncDir).OSPath()
+			renderDir, stagingDir, err := hydrator.stageSources(syncDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.NotEqual(t, syncDir, renderDir)
+			assert.Equal(t, filepath.Join(stagingDir, originCommit, "configs"), renderDir)
+
+			removed := map[string]bool{}
+			for _, f := range tc.wantRemoved {
+				removed[f] = true
+			}
+			stagedSource := filepath.Join(stagingDir, originCommit)
+			for f := range all {
+				_, err := os.Stat(filepath.Join(stagedSource, filepath.FromSlash(f)))
+				if removed[f] {
+					assert.True(t, os.IsNotExist(err), "%s: got %v", f, err)
+				} else {
+					assert.NoError(t, err, f)
+				}
+				// The source files are never removed.
+				_, err = os.Stat(sourceDir.Join(cmpath.RelativeSlash(f)).OSPath())
+				assert.NoError(t, err, f)
+			}
+		})
+	}
+
+	// The source files are rendered in place if nothing is ignored.
+	root, err := cmpath.AbsoluteOS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hydrator := &Hydrator{HydratedRoot: root.Join(cmpath.RelativeSlash("hydrated")), SyncDir: cmpath.RelativeSlash(".")}
+	renderDir, stagingDir, err := hydrator.stageSources(root.OSPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, root.OSPath(), renderDir)
+	assert.Empty(t, stagingDir)
+}
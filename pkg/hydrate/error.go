@@ -14,7 +14,16 @@
 
 package hydrate
 
-import "kpt.dev/configsync/pkg/status"
+import (
+	"fmt"
+
+	"github.com/GoogleContainerTools/kpt-functions-sdk/go/fn"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+)
 
 // HydrationError is a wrapper of the error in the hydration process with the error code.
 type HydrationError interface {
@@ -68,10 +77,71 @@ func (e TransientError) Code() string {
 	return status.TransientErrorCode
 }
 
+// FunctionError represents the user actionable error of a failed function in
+// the Kptfile pipeline, with the error results of the function.
+type FunctionError struct {
+	error
+	// Results are the error results of the function.
+	Results []FunctionResult
+}
+
+// NewFunctionError returns the wrapper of the function error.
+func NewFunctionError(e error, results []FunctionResult) FunctionError {
+	return FunctionError{error: e, Results: results}
+}
+
+// Code returns the user actionable error code.
+func (e FunctionError) Code() string {
+	return status.ActionableHydrationErrorCode
+}
+
+// FunctionResult is a result of a function in the Kptfile pipeline.
+type FunctionResult struct {
+	// Function is the name, image or exec of the function.
+	Function string
+	fn.Result
+}
+
+// statusError returns the rendering error of the result, with the resource
+// the result refers to, if any.
+func (r FunctionResult) statusError() status.Error {
+	err := fmt.Errorf("function %q: %s", r.Function, r.Result.String())
+	if r.ResourceRef == nil {
+		return status.HydrationError(status.ActionableHydrationErrorCode, err)
+	}
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(r.ResourceRef.APIVersion)
+	u.SetKind(r.ResourceRef.Kind)
+	u.SetName(r.ResourceRef.Name)
+	u.SetNamespace(r.ResourceRef.Namespace)
+	if r.File != nil && r.File.Path != "" {
+		core.SetAnnotation(u, metadata.SourcePathAnnotationKey, r.File.Path)
+	}
+	return status.HydrationResourceError(err, u)
+}
+
+// StatusErrors returns the rendering errors of the HydrationError. Each error
+// result of a FunctionError is a separate error, with the resource it refers
+// to.
+func StatusErrors(err HydrationError) status.MultiError {
+	var fnErr FunctionError
+	if !errors.As(err, &fnErr) || len(fnErr.Results) == 0 {
+		return status.HydrationError(err.Code(), err)
+	}
+	var errs status.MultiError
+	for _, r := range fnErr.Results {
+		errs = status.Append(errs, r.statusError())
+	}
+	return errs
+}
+
 // HydrationErrorPayload is the payload of the hydration error in the error file.
 type HydrationErrorPayload struct {
 	// Code is the error code to indicate if it is a user error or an internal error.
 	Code string
 	// Error is the message of the hydration error.
 	Error string
+	// Results are the error results of the failed function in the Kptfile
+	// pipeline, if any.
+	Results []FunctionResult `json:",omitempty"`
 }
@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	setnamespace "github.com/GoogleContainerTools/kpt-functions-catalog/functions/go/set-namespace/transformer"
+	"github.com/GoogleContainerTools/kpt-functions-sdk/go/fn"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/exec"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/runtimeutil"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/starlark"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// defaultFunctionRegistry is the registry of the function images which
+	// are referenced by their short name, e.g. `set-namespace:v0.4.1`.
+	defaultFunctionRegistry = "gcr.io/kpt-fn/"
+
+	// The images of the built-in functions, without registry and tag.
+	setNamespaceImage = "set-namespace"
+	applySettersImage = "apply-setters"
+	starlarkImage     = "starlark"
+
+	// setterCommentPrefix is the prefix of the comments which mark the fields
+	// set by apply-setters, e.g. `# kpt-set: ${image}:${tag}`.
+	setterCommentPrefix = "kpt-set:"
+)
+
+// setterPattern matches the references to setters in the setter comments.
+var setterPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// newFunctionFilter returns the filter which runs the function, and the
+// FunctionFilter which holds its results once it runs.
+//
+// Image functions run in-process, and only the built-in images are supported:
+// set-namespace, apply-setters and starlark. Exec functions run as a
+// subprocess, and must be in the allowedExec list.
+func newFunctionFilter(f kptfilev1.Function, pkgDir string, config *yaml.RNode, allowedExec []string) (kio.Filter, *runtimeutil.FunctionFilter, error) {
+	functionFilter := runtimeutil.FunctionFilter{
+		FunctionConfig: config,
+		// Functions of the Kptfile pipeline apply to all the resources, not
+		// only to the resources in the directory of their config.
+		GlobalScope: true,
+	}
+	switch {
+	case f.Image != "" && f.Exec != "":
+		return nil, nil, fmt.Errorf("function %q must not set both image and exec", functionName(f))
+	case f.Exec != "":
+		if !contains(allowedExec, f.Exec) {
+			return nil, nil, fmt.Errorf("exec function %q is not allowed. The allowed exec functions are %v", f.Exec, allowedExec)
+		}
+		filter := &exec.Filter{
+			Path:           f.Exec,
+			WorkingDir:     pkgDir,
+			FunctionFilter: functionFilter,
+		}
+		return filter, &filter.FunctionFilter, nil
+	case f.Image != "":
+		switch imageName(f.Image) {
+		case setNamespaceImage:
+			functionFilter.Run = runSetNamespace
+			return &functionFilter, &functionFilter, nil
+		case applySettersImage:
+			functionFilter.Run = runApplySetters
+			return &functionFilter, &functionFilter, nil
+		case starlarkImage:
+			filter := &starlark.Filter{
+				Name:           functionName(f),
+				Program:        starlarkProgram(config),
+				FunctionFilter: functionFilter,
+			}
+			return filter, &filter.FunctionFilter, nil
+		default:
+			return nil, nil, fmt.Errorf("function image %q is not supported. The supported images are %s%s, %s%s and %s%s",
+				f.Image, defaultFunctionRegistry, setNamespaceImage, defaultFunctionRegistry, applySettersImage, defaultFunctionRegistry, starlarkImage)
+		}
+	default:
+		return nil, nil, fmt.Errorf("function %q must set either image or exec", functionName(f))
+	}
+}
+
+// functionName returns the name of the function for logs and errors.
+func functionName(f kptfilev1.Function) string {
+	switch {
+	case f.Name != "":
+		return f.Name
+	case f.Image != "":
+		return f.Image
+	default:
+		return f.Exec
+	}
+}
+
+// imageName returns the name of the function image without the default
+// registry, the tag and the digest. Images from other registries keep their
+// registry, so they don't match the built-in functions.
+func imageName(image string) string {
+	name := strings.SplitN(image, "@", 2)[0]
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return strings.TrimPrefix(name, defaultFunctionRegistry)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// runSetNamespace runs the set-namespace function in-process.
+func runSetNamespace(reader io.Reader, writer io.Writer) error {
+	return fn.Execute(fn.ResourceListProcessorFunc(setnamespace.Run), reader, writer)
+}
+
+// starlarkProgram returns the starlark program of the function config, which
+// is either a StarlarkRun with a `source` field, or a ConfigMap with a
+// `source` key.
+func starlarkProgram(config *yaml.RNode) string {
+	source, err := config.Pipe(yaml.Lookup("source"))
+	if err == nil && source != nil {
+		return yaml.GetValue(source)
+	}
+	return config.GetDataMap()["source"]
+}
+
+// runApplySetters runs the apply-setters function in-process. It sets the
+// fields with a `kpt-set` comment to the values of the setters in the data
+// of the function config.
+func runApplySetters(reader io.Reader, writer io.Writer) error {
+	rw := &kio.ByteReadWriter{
+		Reader:                reader,
+		Writer:                writer,
+		OmitReaderAnnotations: true,
+		KeepReaderAnnotations: true,
+	}
+	nodes, err := rw.Read()
+	if err != nil {
+		return err
+	}
+	setters := rw.FunctionConfig.GetDataMap()
+	for _, node := range nodes {
+		applySetters(node.YNode(), setters)
+	}
+	return rw.Write(nodes)
+}
+
+// applySetters sets the fields of the node marked with a `kpt-set` comment.
+// The comment of a scalar field or of a sequence item is on its value; the
+// comment of a sequence field is on its key.
+func applySetters(node *yaml.Node, setters map[string]string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			applySetters(child, setters)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			comment := value.LineComment
+			if comment == "" {
+				comment = key.LineComment
+			}
+			if !setField(value, comment, setters) {
+				applySetters(value, setters)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if !setField(item, item.LineComment, setters) {
+				applySetters(item, setters)
+			}
+		}
+	}
+}
+
+// setField sets the value of the field to the setter expression of the
+// comment. It returns false if the comment is not a setter comment. The field
+// is left unchanged if any of the setters it references is not set.
+func setField(value *yaml.Node, comment string, setters map[string]string) bool {
+	comment = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "#"))
+	if !strings.HasPrefix(comment, setterCommentPrefix) {
+		return false
+	}
+	expression := strings.TrimSpace(strings.TrimPrefix(comment, setterCommentPrefix))
+	missing := false
+	result := setterPattern.ReplaceAllStringFunc(expression, func(ref string) string {
+		v, found := setters[setterPattern.FindStringSubmatch(ref)[1]]
+		if !found {
+			missing = true
+		}
+		return v
+	})
+	if missing {
+		return true
+	}
+	switch value.Kind {
+	case yaml.ScalarNode:
+		value.Value = result
+	case yaml.SequenceNode:
+		// A sequence is set by a single setter whose value is a YAML sequence,
+		// e.g. `[a, b]`.
+		seq, err := yaml.Parse(result)
+		if err != nil || seq.YNode().Kind != yaml.SequenceNode {
+			return true
+		}
+		value.Content = seq.YNode().Content
+	}
+	return true
+}
@@ -0,0 +1,335 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt-functions-sdk/go/fn"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Kptfile is the file name of the kpt package configuration, which declares
+// the pipeline of KRM functions to render the package.
+const Kptfile = "Kptfile"
+
+// readPipeline returns the function pipeline of the Kptfile in dir. It returns
+// nil if the Kptfile doesn't exist, is ignored, or has no function.
+func readPipeline(dir string) (*kptfilev1.Pipeline, error) {
+	kptfilePath := filepath.Join(dir, Kptfile)
+	matcher, err := newIgnoreMatcher(dir)
+	if err != nil {
+		return nil, err
+	}
+	if matcher.Ignored(kptfilePath, false) {
+		return nil, nil
+	}
+	content, err := os.ReadFile(kptfilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "unable to read %s", kptfilePath)
+	}
+	kptfile := &kptfilev1.KptFile{}
+	if err := yaml.Unmarshal(content, kptfile); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", kptfilePath)
+	}
+	if kptfile.Pipeline.IsEmpty() {
+		return nil, nil
+	}
+	return kptfile.Pipeline, nil
+}
+
+// HasPipeline checks if the Kptfile in dir declares a function pipeline.
+func HasPipeline(dir string) (bool, error) {
+	pipeline, err := readPipeline(dir)
+	return pipeline != nil, err
+}
+
+// needsRendering checks if the configs in dir need to be rendered, with
+// `kustomize build` or the function pipeline of the Kptfile.
+func needsRendering(dir string) (bool, error) {
+	kustomize, err := needsKustomize(dir)
+	if err != nil || kustomize {
+		return kustomize, err
+	}
+	return HasPipeline(dir)
+}
+
+// runPipeline runs the functions of the pipeline on the configs in input, and
+// writes the rendered configs to output. The Kptfile of the pipeline and the
+// function configs are in pkgDir. input and output may be the same directory,
+// when the pipeline renders the output of `kustomize build`.
+func runPipeline(pipeline *kptfilev1.Pipeline, pkgDir, input, output string, allowedExec []string) HydrationError {
+	fileSystem := filesys.FileSystemOrOnDisk{FileSystem: filesys.MakeFsOnDisk()}
+	configPaths, err := functionConfigPaths(pkgDir, pipeline)
+	if err != nil {
+		return NewActionableError(err)
+	}
+	matcher, err := newIgnoreMatcher(input)
+	if err != nil {
+		return NewInternalError(err)
+	}
+	reader := kio.LocalPackageReader{
+		PackagePath:        input,
+		MatchFilesGlob:     kio.MatchAll,
+		IncludeSubpackages: true,
+		FileSystem:         fileSystem,
+		FileSkipFunc: func(relPath string) bool {
+			absPath := filepath.Join(input, relPath)
+			return configPaths[absPath] || matcher.Ignored(absPath, false)
+		},
+	}
+	nodes, err := reader.Read()
+	if err != nil {
+		return NewActionableError(errors.Wrapf(err, "unable to read the configs in %s", input))
+	}
+
+	for _, f := range pipeline.Mutators {
+		nodes, err = runFunction(f, pkgDir, nodes, allowedExec)
+		if err != nil {
+			return functionError(err)
+		}
+	}
+	for _, f := range pipeline.Validators {
+		// Validators are not permitted to mutate resources, so their output
+		// is discarded.
+		if _, err := runFunction(f, pkgDir, copyNodes(nodes), allowedExec); err != nil {
+			return functionError(err)
+		}
+	}
+
+	if _, err := os.Stat(output); err == nil {
+		mustDeleteOutput(err, output)
+	}
+	if err := os.MkdirAll(output, os.FileMode(0755)); err != nil {
+		return NewInternalError(errors.Wrapf(err, "unable to make directory: %s", output))
+	}
+	writer := kio.LocalPackageWriter{
+		PackagePath: output,
+		FileSystem:  fileSystem,
+	}
+	if err := writer.Write(nodes); err != nil {
+		writeErr := errors.Wrapf(err, "unable to write the rendered configs to %s", output)
+		mustDeleteOutput(writeErr, output)
+		return NewInternalError(writeErr)
+	}
+	return nil
+}
+
+// functionError converts the error of a function into a HydrationError.
+func functionError(err error) HydrationError {
+	var fnErr FunctionError
+	if errors.As(err, &fnErr) {
+		return fnErr
+	}
+	return NewActionableError(err)
+}
+
+// functionConfigPaths returns the absolute paths of the function configs of
+// the pipeline, which are not rendered.
+func functionConfigPaths(pkgDir string, pipeline *kptfilev1.Pipeline) (map[string]bool, error) {
+	result := make(map[string]bool)
+	for _, f := range append(append([]kptfilev1.Function{}, pipeline.Mutators...), pipeline.Validators...) {
+		if f.ConfigPath == "" {
+			continue
+		}
+		absPath, err := configPath(pkgDir, f.ConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		result[absPath] = true
+	}
+	return result, nil
+}
+
+// configPath returns the absolute path of the function config, which must be
+// in pkgDir.
+func configPath(pkgDir, slashPath string) (string, error) {
+	absPath := filepath.Join(pkgDir, filepath.FromSlash(slashPath))
+	rel, err := filepath.Rel(pkgDir, absPath)
+	if err != nil || path.IsAbs(slashPath) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("configPath %q must be a relative path in the directory of the %s", slashPath, Kptfile)
+	}
+	return absPath, nil
+}
+
+// functionConfig returns the function config of the function, which is
+// either read from its configPath, or built from its configMap.
+func functionConfig(f kptfilev1.Function, pkgDir string) (*yaml.RNode, error) {
+	if f.ConfigPath != "" && len(f.ConfigMap) != 0 {
+		return nil, fmt.Errorf("function %q must not set both configPath and configMap", functionName(f))
+	}
+	if f.ConfigPath != "" {
+		absPath, err := configPath(pkgDir, f.ConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		node, err := yaml.ReadFile(absPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the config of function %q", functionName(f))
+		}
+		return node, nil
+	}
+	node, err := yaml.Parse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: function-input
+`)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.ConfigMap) != 0 {
+		node.SetDataMap(f.ConfigMap)
+	}
+	return node, nil
+}
+
+// runFunction runs the function on the nodes selected by its selectors, and
+// returns the function output with the nodes which are not selected.
+func runFunction(f kptfilev1.Function, pkgDir string, nodes []*yaml.RNode, allowedExec []string) ([]*yaml.RNode, error) {
+	config, err := functionConfig(f, pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	filter, functionFilter, err := newFunctionFilter(f, pkgDir, config, allowedExec)
+	if err != nil {
+		return nil, err
+	}
+	var input, saved []*yaml.RNode
+	for _, node := range nodes {
+		if isSelected(node, f.Selectors, f.Exclusions) {
+			input = append(input, node)
+		} else {
+			saved = append(saved, node)
+		}
+	}
+	klog.V(3).Infof("Running function %q on %d resources", functionName(f), len(input))
+	output, err := filter.Filter(input)
+	results, resultsErr := functionResults(functionName(f), functionFilter.Results)
+	if resultsErr != nil {
+		return nil, resultsErr
+	}
+	var failed []FunctionResult
+	for _, r := range results {
+		if r.Severity == fn.Error {
+			failed = append(failed, r)
+		} else {
+			klog.Infof("Function %q: %s", functionName(f), r.Result)
+		}
+	}
+	if err != nil || len(failed) > 0 {
+		if err == nil {
+			err = fmt.Errorf("function %q failed with %d errors", functionName(f), len(failed))
+		} else {
+			err = errors.Wrapf(err, "function %q failed", functionName(f))
+		}
+		return nil, NewFunctionError(err, failed)
+	}
+	return append(output, saved...), nil
+}
+
+// functionResults parses the results of a function.
+func functionResults(function string, node *yaml.RNode) ([]FunctionResult, error) {
+	if node.IsNilOrEmpty() {
+		return nil, nil
+	}
+	s, err := node.String()
+	if err != nil {
+		return nil, err
+	}
+	var results fn.Results
+	if err := yaml.Unmarshal([]byte(s), &results); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the results of function %q", function)
+	}
+	var result []FunctionResult
+	for _, r := range results {
+		if r != nil {
+			result = append(result, FunctionResult{Function: function, Result: *r})
+		}
+	}
+	return result, nil
+}
+
+// isSelected returns true if the node matches any of the selectors, or if
+// there is no selector, and doesn't match any of the exclusions.
+func isSelected(node *yaml.RNode, selectors, exclusions []kptfilev1.Selector) bool {
+	for _, s := range exclusions {
+		if matchSelector(node, s) {
+			return false
+		}
+	}
+	if len(selectors) == 0 {
+		return true
+	}
+	for _, s := range selectors {
+		if matchSelector(node, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSelector returns true if the node matches all the criteria of the
+// selector.
+func matchSelector(node *yaml.RNode, s kptfilev1.Selector) bool {
+	if s.IsEmpty() {
+		return false
+	}
+	if s.APIVersion != "" && s.APIVersion != node.GetApiVersion() {
+		return false
+	}
+	if s.Kind != "" && s.Kind != node.GetKind() {
+		return false
+	}
+	if s.Name != "" && s.Name != node.GetName() {
+		return false
+	}
+	if s.Namespace != "" && s.Namespace != node.GetNamespace() {
+		return false
+	}
+	labels := node.GetLabels()
+	for k, v := range s.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	annotations := node.GetAnnotations()
+	for k, v := range s.Annotations {
+		if annotations[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// copyNodes returns a deep copy of the nodes.
+func copyNodes(nodes []*yaml.RNode) []*yaml.RNode {
+	result := make([]*yaml.RNode, len(nodes))
+	for i, node := range nodes {
+		result[i] = node.Copy()
+	}
+	return result
+}
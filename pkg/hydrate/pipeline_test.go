@@ -0,0 +1,357 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt-functions-sdk/go/fn"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const deploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.0 # kpt-set: nginx:${tag}
+        args: # kpt-set: ${args}
+        - --foo
+`
+
+const serviceYAML = `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readOutput returns the content of all the files rendered in dir.
+func readOutput(t *testing.T, dir string) string {
+	t.Helper()
+	var result []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		result = append(result, "# "+rel+"\n"+string(content))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(result, "")
+}
+
+func TestRunPipeline(t *testing.T) {
+	testCases := []struct {
+		name        string
+		files       map[string]string
+		allowedExec []string
+		// wantOutput are substrings of the rendered output.
+		wantOutput []string
+		// wantNotOutput are substrings which must not be in the rendered output.
+		wantNotOutput []string
+		wantErr       string
+		wantResults   []FunctionResult
+	}{
+		{
+			name: "set-namespace with configMap",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - image: gcr.io/kpt-fn/set-namespace:v0.4.1
+    configMap:
+      namespace: bookstore
+`,
+				"deployment.yaml": deploymentYAML,
+			},
+			wantOutput: []string{"namespace: bookstore"},
+		},
+		{
+			name: "set-namespace with selectors",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - image: set-namespace
+    configMap:
+      namespace: bookstore
+    selectors:
+    - kind: Service
+`,
+				"deployment.yaml": deploymentYAML,
+				"service.yaml":    serviceYAML,
+			},
+			wantOutput:    []string{"kind: Service\nmetadata:\n  name: nginx\n  namespace: bookstore"},
+			wantNotOutput: []string{"kind: Deployment\nmetadata:\n  name: nginx\n  namespace: bookstore"},
+		},
+		{
+			name: "apply-setters with configPath",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - image: gcr.io/kpt-fn/apply-setters:v0.2
+    configPath: setters.yaml
+`,
+				"setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  replicas: "3"
+  tag: "1.21"
+  args: "[--bar, --baz]"
+`,
+				"deployment.yaml": deploymentYAML,
+			},
+			wantOutput:    []string{"replicas: 3", "image: nginx:1.21", "- --bar", "- --baz"},
+			wantNotOutput: []string{"name: setters", "--foo"},
+		},
+		{
+			name: "starlark mutator",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - image: gcr.io/kpt-fn/starlark:v0.4
+    configPath: label.yaml
+`,
+				"label.yaml": `apiVersion: fn.kpt.dev/v1alpha1
+kind: StarlarkRun
+metadata:
+  name: label
+source: |
+  def set_labels(resources):
+    for r in resources:
+      r["metadata"]["labels"] = {"team": "books"}
+  set_labels(ctx.resource_list["items"])
+`,
+				"service.yaml": serviceYAML,
+			},
+			wantOutput: []string{"team: books"},
+		},
+		{
+			name: "starlark validator failure",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  validators:
+  - name: require-namespace
+    image: gcr.io/kpt-fn/starlark:v0.4
+    configMap:
+      source: |
+        def validate(resources):
+          results = []
+          for r in resources:
+            if "namespace" not in r["metadata"]:
+              results.append({
+                "message": "namespace is required",
+                "severity": "error",
+                "resourceRef": {"apiVersion": r["apiVersion"], "kind": r["kind"], "name": r["metadata"]["name"]},
+                "file": {"path": "service.yaml"},
+              })
+          return results
+        ctx.resource_list["results"] = validate(ctx.resource_list["items"])
+`,
+				"service.yaml": serviceYAML,
+			},
+			wantErr: `function "require-namespace" failed with 1 errors`,
+			wantResults: []FunctionResult{
+				{
+					Function: "require-namespace",
+					Result: fn.Result{
+						Message:  "namespace is required",
+						Severity: fn.Error,
+					},
+				},
+			},
+		},
+		{
+			name: "exec function not allowed",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - exec: /usr/local/bin/my-fn
+`,
+				"service.yaml": serviceYAML,
+			},
+			allowedExec: []string{"/usr/local/bin/other-fn"},
+			wantErr:     `exec function "/usr/local/bin/my-fn" is not allowed`,
+		},
+		{
+			name: "unsupported image",
+			files: map[string]string{
+				Kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+pipeline:
+  mutators:
+  - image: gcr.io/kpt-fn/set-labels:v0.1
+`,
+				"service.yaml": serviceYAML,
+			},
+			wantErr: `function image "gcr.io/kpt-fn/set-labels:v0.1" is not supported`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := t.TempDir()
+			output := filepath.Join(t.TempDir(), "output")
+			writeFiles(t, input, tc.files)
+
+			pipeline, err := readPipeline(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pipeline == nil {
+				t.Fatalf("got readPipeline() = nil, want pipeline")
+			}
+			hydrationErr := runPipeline(pipeline, input, input, output, tc.allowedExec)
+			if tc.wantErr != "" {
+				if hydrationErr == nil {
+					t.Fatalf("got runPipeline() error = nil, want %q", tc.wantErr)
+				}
+				if !strings.Contains(hydrationErr.Error(), tc.wantErr) {
+					t.Errorf("got runPipeline() error = %q, want %q", hydrationErr, tc.wantErr)
+				}
+				if hydrationErr.Code() != status.ActionableHydrationErrorCode {
+					t.Errorf("got runPipeline() error code = %s, want %s", hydrationErr.Code(), status.ActionableHydrationErrorCode)
+				}
+				var results []FunctionResult
+				if fnErr, ok := hydrationErr.(FunctionError); ok {
+					results = fnErr.Results
+				}
+				if len(results) != len(tc.wantResults) {
+					t.Fatalf("got results %v, want %v", results, tc.wantResults)
+				}
+				for i, want := range tc.wantResults {
+					got := results[i]
+					if got.Function != want.Function || got.Message != want.Message || got.Severity != want.Severity {
+						t.Errorf("got result %v, want %v", got, want)
+					}
+				}
+				return
+			}
+			if hydrationErr != nil {
+				t.Fatalf("got runPipeline() error = %v, want nil", hydrationErr)
+			}
+			got := readOutput(t, output)
+			for _, want := range tc.wantOutput {
+				if !strings.Contains(got, want) {
+					t.Errorf("got rendered output:\n%s\nwant it to contain:\n%s", got, want)
+				}
+			}
+			for _, notWant := range tc.wantNotOutput {
+				if strings.Contains(got, notWant) {
+					t.Errorf("got rendered output:\n%s\nwant it not to contain:\n%s", got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	err := NewFunctionError(os.ErrInvalid, []FunctionResult{
+		{
+			Function: "require-namespace",
+			Result: fn.Result{
+				Message:  "namespace is required",
+				Severity: fn.Error,
+				File:     &fn.File{Path: "service.yaml"},
+				ResourceRef: &yaml.ResourceIdentifier{
+					TypeMeta: yaml.TypeMeta{APIVersion: "v1", Kind: "Service"},
+					NameMeta: yaml.NameMeta{Name: "nginx"},
+				},
+			},
+		},
+		{
+			Function: "require-namespace",
+			Result: fn.Result{
+				Message:  "no resources",
+				Severity: fn.Error,
+			},
+		},
+	})
+
+	cses := status.ToCSE(StatusErrors(err))
+	if len(cses) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(cses), cses)
+	}
+	if len(cses[0].Resources) != 1 {
+		t.Fatalf("got error resources %v, want 1", cses[0].Resources)
+	}
+	ref := cses[0].Resources[0]
+	if ref.Name != "nginx" || ref.GVK.Kind != "Service" || ref.SourcePath != "service.yaml" {
+		t.Errorf("got error resource %+v, want Service nginx in service.yaml", ref)
+	}
+	if len(cses[1].Resources) != 0 {
+		t.Errorf("got error resources %v, want none", cses[1].Resources)
+	}
+	for _, cse := range cses {
+		if cse.Code != status.ActionableHydrationErrorCode {
+			t.Errorf("got error code %s, want %s", cse.Code, status.ActionableHydrationErrorCode)
+		}
+	}
+}
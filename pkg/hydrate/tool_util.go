@@ -34,6 +34,7 @@ import (
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/importer/filesystem/ignore"
 	"kpt.dev/configsync/pkg/kmetrics"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/util/discovery"
@@ -59,14 +60,19 @@ var (
 	validKustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
 )
 
-// needsKustomize checks if there is a Kustomization config file under the directory.
+// needsKustomize checks if there is a Kustomization config file under the
+// directory, which is not ignored by the ignore file of the directory.
 func needsKustomize(dir string) (bool, error) {
+	matcher, err := newIgnoreMatcher(dir)
+	if err != nil {
+		return false, err
+	}
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to traverse the directory: %s", dir)
 	}
 	for _, f := range files {
-		if HasKustomization(filepath.Base(f.Name())) {
+		if HasKustomization(filepath.Base(f.Name())) && !matcher.Ignored(filepath.Join(dir, f.Name()), f.IsDir()) {
 			return true, nil
 		}
 	}
@@ -84,26 +90,35 @@ func HasKustomization(filename string) bool {
 }
 
 // hasKustomizeSubdir checks if there exists a kustomization config file in any
-// of the subdirectory under dir.
+// of the subdirectory under dir, which is not ignored by the ignore file of dir.
 func hasKustomizeSubdir(dir string) (bool, error) {
-	found := false
-	err := filepath.Walk(dir,
-		func(path string, fi os.FileInfo, err error) error {
-			if found {
-				return nil
-			}
-			if err != nil {
-				return err
-			}
-			if fi.IsDir() {
-				return nil
-			}
-			if HasKustomization(fi.Name()) {
-				found = true
-			}
-			return nil
-		})
-	return found, err
+	matcher, err := newIgnoreMatcher(dir)
+	if err != nil {
+		return false, err
+	}
+	files, _, err := matcher.ListFiles()
+	if err != nil {
+		return false, err
+	}
+	for _, f := range files {
+		if HasKustomization(filepath.Base(f.OSPath())) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newIgnoreMatcher returns the matcher of the files ignored in dir.
+func newIgnoreMatcher(dir string) (*ignore.Matcher, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := cmpath.AbsoluteOS(abs)
+	if err != nil {
+		return nil, err
+	}
+	return ignore.NewMatcher(absDir, nil, nil)
 }
 
 // mustDeleteOutput deletes the hydrated output directory with retries.
@@ -124,16 +139,21 @@ func mustDeleteOutput(err error, output string) {
 	klog.Fatalf("Attempted to delete the output directory %s for %d times, but all failed. Exiting now...", output, retries)
 }
 
+// kustomizeBuildFlags are the flags of the 'kustomize build' command, apart
+// from the output directory.
+//
+// The `--enable-alpha-plugins` and `--enable-exec` flags are to support rendering
+// Helm charts using the Helm inflation function.
+// The `--enable-helm` flag is to enable use of the Helm chart inflator generator.
+// We decided to enable all the flags so that both the Helm plugin and Helm
+// inflation function are supported. This provides us with a fallback plan
+// if the new Helm inflation function is having issues.
+// It has no side-effect if no Helm chart in the DRY configs.
+var kustomizeBuildFlags = []string{"--enable-alpha-plugins", "--enable-exec", "--enable-helm"}
+
 // kustomizeBuild runs the 'kustomize build' command to render the configs.
 func kustomizeBuild(input, output string, sendMetrics bool) HydrationError {
-	// The `--enable-alpha-plugins` and `--enable-exec` flags are to support rendering
-	// Helm charts using the Helm inflation function.
-	// The `--enable-helm` flag is to enable use of the Helm chart inflator generator.
-	// We decided to enable all the flags so that both the Helm plugin and Helm
-	// inflation function are supported. This provides us with a fallback plan
-	// if the new Helm inflation function is having issues.
-	// It has no side-effect if no Helm chart in the DRY configs.
-	args := []string{"--enable-alpha-plugins", "--enable-exec", "--enable-helm", "--output", output}
+	args := append(append([]string{}, kustomizeBuildFlags...), "--output", output)
 
 	if _, err := os.Stat(output); err == nil {
 		mustDeleteOutput(err, output)
@@ -288,6 +308,10 @@ func ValidateOptions(ctx context.Context, rootDir cmpath.Absolute, apiServerTime
 	if err != nil {
 		return options, err
 	}
+	admissionPolicies, err := nomosparse.GetClusterAdmissionPolicies(ctx, flags.SkipAPIServer, apiServerTimeout)
+	if err != nil {
+		return options, err
+	}
 
 	var serverResourcer discovery.ServerResourcer = discovery.NoOpServerResourcer{}
 	var converter *declared.ValueConverter
@@ -322,5 +346,6 @@ func ValidateOptions(ctx context.Context, rootDir cmpath.Absolute, apiServerTime
 	options.BuildScoper = discovery.ScoperBuilder(serverResourcer, addFunc)
 	options.Converter = converter
 	options.AllowUnknownKinds = flags.SkipAPIServer
+	options.AdmissionPolicies = admissionPolicies
 	return options, nil
 }
@@ -0,0 +1,137 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ignorefields removes the ignored fields from the declared objects, so
+// that the reconciler neither applies nor remediates them. The fields are
+// ignored with the ignore-fields annotation of an object, or for every object
+// of a kind with the ignoreDifferences override of the RootSync or RepoSync.
+package ignorefields
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/util"
+)
+
+// Validate returns an error if the path does not select a field of the object,
+// or selects a field which identifies the object, and so cannot be ignored.
+func Validate(p Path) error {
+	switch {
+	case p[0].isList():
+		return fmt.Errorf("the path must start with a field name")
+	case len(p) == 1 && (p[0].field == "apiVersion" || p[0].field == "kind" || p[0].field == "metadata"):
+		return fmt.Errorf("the .%s field cannot be ignored", p[0].field)
+	case len(p) == 2 && p[0].field == "metadata" && (p[1].field == "name" || p[1].field == "namespace"):
+		return fmt.Errorf("the .metadata.%s field cannot be ignored", p[1].field)
+	}
+	return nil
+}
+
+// ParseAnnotation parses and validates the paths of the ignore-fields
+// annotation of the object, if any.
+func ParseAnnotation(obj *unstructured.Unstructured) ([]Path, error) {
+	value, found := obj.GetAnnotations()[metadata.IgnoreFieldsAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+	var paths []Path
+	for _, item := range util.SplitList(value) {
+		p, err := Parse(item)
+		if err != nil {
+			return nil, err
+		}
+		if err := Validate(p); err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", item, err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// Rules are the paths of the ignored fields of each kind.
+type Rules map[schema.GroupKind][]Path
+
+// NewRules parses and validates the ignoreDifferences override of a RootSync
+// or RepoSync.
+func NewRules(diffs []v1beta1.IgnoreDifference) (Rules, error) {
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+	rules := make(Rules)
+	for _, diff := range diffs {
+		if diff.Kind == "" {
+			return nil, fmt.Errorf("ignoreDifferences must specify the kind")
+		}
+		gk := schema.GroupKind{Group: diff.Group, Kind: diff.Kind}
+		for _, path := range diff.JSONPaths {
+			p, err := Parse(path)
+			if err != nil {
+				return nil, fmt.Errorf("ignoreDifferences of %s: %w", gk, err)
+			}
+			if err := Validate(p); err != nil {
+				return nil, fmt.Errorf("ignoreDifferences of %s: invalid path %q: %w", gk, path, err)
+			}
+			rules[gk] = append(rules[gk], p)
+		}
+	}
+	return rules, nil
+}
+
+// Paths returns the paths of the ignored fields of the object: the fields of
+// its ignore-fields annotation, and the fields of its kind in the rules.
+func Paths(obj *unstructured.Unstructured, rules Rules) ([]Path, error) {
+	paths, err := ParseAnnotation(obj)
+	if err != nil {
+		return nil, err
+	}
+	return append(paths, rules[obj.GroupVersionKind().GroupKind()]...), nil
+}
+
+// Remove removes the ignored fields from the object. Removed fields are
+// neither applied, nor declared in the declared-fields annotation, nor
+// remediated.
+func Remove(obj *unstructured.Unstructured, rules Rules) error {
+	paths, err := Paths(obj, rules)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		obj.Object = remove(obj.Object, p).(map[string]interface{})
+	}
+	return nil
+}
+
+// Preserve copies the ignored fields of the live object into the object to
+// apply, which had them removed by Remove. Server-side apply removes the
+// fields which Config Sync applied before but no longer applies, so the fields
+// which Config Sync applied before they were ignored are applied with their
+// live values, to leave them unchanged. live is nil if the object does not
+// exist yet.
+func Preserve(obj, live *unstructured.Unstructured, rules Rules) error {
+	if live == nil {
+		return nil
+	}
+	paths, err := Paths(obj, rules)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		obj.Object = preserve(obj.Object, live.Object, p).(map[string]interface{})
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ignorefields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/metadata"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		want    Path
+		wantErr bool
+	}{
+		{
+			name: "field names",
+			path: ".spec.replicas",
+			want: Path{{field: "spec"}, {field: "replicas"}},
+		},
+		{
+			name: "without leading dot",
+			path: "spec.replicas",
+			want: Path{{field: "spec"}, {field: "replicas"}},
+		},
+		{
+			name: "quoted field name",
+			path: `.metadata.annotations["example.com/owner"]`,
+			want: Path{{field: "metadata"}, {field: "annotations"}, {field: "example.com/owner"}},
+		},
+		{
+			name: "every list item",
+			path: ".webhooks[*].clientConfig.caBundle",
+			want: Path{{field: "webhooks"}, {all: true}, {field: "clientConfig"}, {field: "caBundle"}},
+		},
+		{
+			name: "list items with a quoted value",
+			path: `.spec.containers[name="web"].image`,
+			want: Path{{field: "spec"}, {field: "containers"}, {key: "name", value: "web"}, {field: "image"}},
+		},
+		{
+			name: "list items with an unquoted value",
+			path: ".spec.ports[port=80]",
+			want: Path{{field: "spec"}, {field: "ports"}, {key: "port", value: "80"}},
+		},
+		{
+			name:    "empty",
+			path:    " ",
+			wantErr: true,
+		},
+		{
+			name:    "empty field name",
+			path:    ".spec..replicas",
+			wantErr: true,
+		},
+		{
+			name:    "trailing dot",
+			path:    ".spec.",
+			wantErr: true,
+		},
+		{
+			name:    "missing closing bracket",
+			path:    ".spec.containers[*",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			path:    `.metadata.annotations["owner]`,
+			wantErr: true,
+		},
+		{
+			name:    "index",
+			path:    ".spec.containers[0].image",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, path := range []string{".spec.replicas", ".metadata.labels", ".metadata.annotations[\"owner\"]"} {
+		p, err := Parse(path)
+		require.NoError(t, err)
+		assert.NoError(t, Validate(p), path)
+	}
+	for _, path := range []string{".apiVersion", ".kind", ".metadata", ".metadata.name", ".metadata.namespace", "[*].spec"} {
+		p, err := Parse(path)
+		require.NoError(t, err)
+		assert.Error(t, Validate(p), path)
+	}
+}
+
+func webhookConfiguration(annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "MutatingWebhookConfiguration",
+		"metadata": map[string]interface{}{
+			"name": "injector",
+		},
+		"webhooks": []interface{}{
+			map[string]interface{}{
+				"name":         "a.example.com",
+				"clientConfig": map[string]interface{}{"caBundle": "Y2E=", "url": "https://a.example.com"},
+			},
+			map[string]interface{}{
+				"name":         "b.example.com",
+				"clientConfig": map[string]interface{}{"caBundle": "Y2E=", "url": "https://b.example.com"},
+			},
+		},
+	}}
+	if annotations != nil {
+		u.SetAnnotations(annotations)
+	}
+	return u
+}
+
+func TestRemove(t *testing.T) {
+	rules, err := NewRules([]v1beta1.IgnoreDifference{
+		{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration", JSONPaths: []string{".webhooks[*].clientConfig.caBundle"}},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		obj   *unstructured.Unstructured
+		rules Rules
+		want  func(*unstructured.Unstructured)
+	}{
+		{
+			name: "no ignored fields",
+			obj:  webhookConfiguration(nil),
+			want: func(*unstructured.Unstructured) {},
+		},
+		{
+			name:  "fields of the kind",
+			obj:   webhookConfiguration(nil),
+			rules: rules,
+			want: func(u *unstructured.Unstructured) {
+				for _, w := range u.Object["webhooks"].([]interface{}) {
+					delete(w.(map[string]interface{})["clientConfig"].(map[string]interface{}), "caBundle")
+				}
+			},
+		},
+		{
+			name: "fields of the annotation",
+			obj: webhookConfiguration(map[string]string{
+				metadata.IgnoreFieldsAnnotationKey: `.webhooks[name="b.example.com"].clientConfig.url, .metadata.labels`,
+			}),
+			want: func(u *unstructured.Unstructured) {
+				w := u.Object["webhooks"].([]interface{})[1].(map[string]interface{})
+				delete(w["clientConfig"].(map[string]interface{}), "url")
+			},
+		},
+		{
+			name: "list items",
+			obj: webhookConfiguration(map[string]string{
+				metadata.IgnoreFieldsAnnotationKey: `.webhooks[name="a.example.com"]`,
+			}),
+			want: func(u *unstructured.Unstructured) {
+				u.Object["webhooks"] = u.Object["webhooks"].([]interface{})[1:]
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			want := tc.obj.DeepCopy()
+			tc.want(want)
+			require.NoError(t, Remove(tc.obj, tc.rules))
+			assert.Equal(t, want, tc.obj)
+		})
+	}
+}
+
+func TestPreserve(t *testing.T) {
+	rules, err := NewRules([]v1beta1.IgnoreDifference{
+		{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration", JSONPaths: []string{".webhooks[*].clientConfig.caBundle"}},
+	})
+	require.NoError(t, err)
+	injectCABundle := func(u *unstructured.Unstructured) {
+		for _, w := range u.Object["webhooks"].([]interface{}) {
+			w.(map[string]interface{})["clientConfig"].(map[string]interface{})["caBundle"] = "aW5qZWN0ZWQ="
+		}
+	}
+
+	testCases := []struct {
+		name  string
+		obj   *unstructured.Unstructured
+		rules Rules
+		live  func(*unstructured.Unstructured)
+		want  func(*unstructured.Unstructured)
+	}{
+		{
+			name: "no ignored fields",
+			obj:  webhookConfiguration(nil),
+			live: injectCABundle,
+			want: func(*unstructured.Unstructured) {},
+		},
+		{
+			name:  "fields of the kind",
+			obj:   webhookConfiguration(nil),
+			rules: rules,
+			live:  injectCABundle,
+			want:  injectCABundle,
+		},
+		{
+			name: "fields of the annotation",
+			obj: webhookConfiguration(map[string]string{
+				metadata.IgnoreFieldsAnnotationKey: `.webhooks[name="b.example.com"].clientConfig.url, .metadata.labels`,
+			}),
+			live: func(u *unstructured.Unstructured) {
+				u.SetLabels(map[string]string{"team": "platform"})
+				w := u.Object["webhooks"].([]interface{})[1].(map[string]interface{})
+				w["clientConfig"].(map[string]interface{})["url"] = "https://b.example.org"
+			},
+			want: func(u *unstructured.Unstructured) {
+				u.SetLabels(map[string]string{"team": "platform"})
+				w := u.Object["webhooks"].([]interface{})[1].(map[string]interface{})
+				w["clientConfig"].(map[string]interface{})["url"] = "https://b.example.org"
+			},
+		},
+		{
+			name: "fields missing from the live object",
+			obj: webhookConfiguration(map[string]string{
+				metadata.IgnoreFieldsAnnotationKey: `.metadata.labels`,
+			}),
+			live: func(*unstructured.Unstructured) {},
+			want: func(*unstructured.Unstructured) {},
+		},
+		{
+			name: "list items",
+			obj: webhookConfiguration(map[string]string{
+				metadata.IgnoreFieldsAnnotationKey: `.webhooks[name="a.example.com"]`,
+			}),
+			live: injectCABundle,
+			want: func(u *unstructured.Unstructured) {
+				webhooks := u.Object["webhooks"].([]interface{})
+				a := webhooks[0].(map[string]interface{})
+				a["clientConfig"].(map[string]interface{})["caBundle"] = "aW5qZWN0ZWQ="
+				u.Object["webhooks"] = []interface{}{webhooks[1], a}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			live := tc.obj.DeepCopy()
+			tc.live(live)
+			want := tc.obj.DeepCopy()
+			tc.want(want)
+			require.NoError(t, Remove(tc.obj, tc.rules))
+			require.NoError(t, Preserve(tc.obj, live, tc.rules))
+			assert.Equal(t, want, tc.obj)
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	_, err := NewRules([]v1beta1.IgnoreDifference{{Kind: "Deployment", JSONPaths: []string{".metadata.name"}}})
+	assert.Error(t, err)
+	_, err = NewRules([]v1beta1.IgnoreDifference{{JSONPaths: []string{".spec.replicas"}}})
+	assert.Error(t, err)
+	rules, err := NewRules(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ignorefields
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// segment is a step of a Path: a field of a map, every item of a list, or the
+// items of a list whose field has the given value.
+type segment struct {
+	// field is the name of the field, for a map step.
+	field string
+	// all is true for the `[*]` step, which selects every list item.
+	all bool
+	// key and value select the list items whose field key has the value,
+	// for the `[key=value]` step.
+	key   string
+	value string
+}
+
+func (s segment) isList() bool {
+	return s.all || s.key != ""
+}
+
+// matches returns true if the list item is selected by the list step.
+func (s segment) matches(item interface{}) bool {
+	if s.all {
+		return true
+	}
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	v, found := m[s.key]
+	return found && fmt.Sprint(v) == s.value
+}
+
+// Path is the parsed path of a field.
+type Path []segment
+
+// Parse parses the path of a field. The path is made of field names separated
+// by dots, with an optional leading dot, like `.spec.replicas`. Field names
+// with dots or brackets are quoted in brackets, like
+// `.metadata.annotations["example.com/owner"]`. The items of a list are
+// selected with `[*]`, for every item, or with `[key="value"]`, for the items
+// whose field has the value, like `.spec.containers[name="web"].image`.
+func Parse(path string) (Path, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), ".")
+	if rest == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	var p Path
+	for rest != "" {
+		var s segment
+		var err error
+		if rest[0] == '[' {
+			s, rest, err = parseBrackets(rest)
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			s, rest = segment{field: rest[:end]}, rest[end:]
+			if s.field == "" {
+				err = fmt.Errorf("empty field name")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", path, err)
+		}
+		p = append(p, s)
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" || rest[0] == '[' {
+				return nil, fmt.Errorf("invalid path %q: empty field name", path)
+			}
+		}
+	}
+	return p, nil
+}
+
+// parseBrackets parses the bracketed step at the start of the path, and
+// returns the rest of the path.
+func parseBrackets(path string) (segment, string, error) {
+	inner := path[1:]
+	var s segment
+	switch {
+	case strings.HasPrefix(inner, "*"):
+		s.all = true
+		inner = inner[1:]
+	case strings.HasPrefix(inner, `"`):
+		quoted, err := strconv.QuotedPrefix(inner)
+		if err != nil {
+			return s, "", fmt.Errorf("unterminated quoted field name")
+		}
+		s.field, _ = strconv.Unquote(quoted)
+		inner = inner[len(quoted):]
+	default:
+		eq := strings.Index(inner, "=")
+		if eq <= 0 {
+			return s, "", fmt.Errorf("list items must be selected with [*] or [key=value]")
+		}
+		s.key, inner = inner[:eq], inner[eq+1:]
+		if strings.HasPrefix(inner, `"`) {
+			quoted, err := strconv.QuotedPrefix(inner)
+			if err != nil {
+				return s, "", fmt.Errorf("unterminated quoted value")
+			}
+			s.value, _ = strconv.Unquote(quoted)
+			inner = inner[len(quoted):]
+		} else {
+			end := strings.Index(inner, "]")
+			if end < 0 {
+				end = len(inner)
+			}
+			s.value, inner = inner[:end], inner[end:]
+		}
+	}
+	if !strings.HasPrefix(inner, "]") {
+		return s, "", fmt.Errorf("missing closing bracket")
+	}
+	rest := inner[1:]
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		return s, "", fmt.Errorf("unexpected %q after closing bracket", rest[0])
+	}
+	return s, rest, nil
+}
+
+// remove removes the fields at the path from the node, and returns the node.
+// List items selected by the last step of the path are removed from the list.
+func remove(node interface{}, p Path) interface{} {
+	s := p[0]
+	if !s.isList() {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		if len(p) == 1 {
+			delete(m, s.field)
+		} else if child, found := m[s.field]; found {
+			m[s.field] = remove(child, p[1:])
+		}
+		return m
+	}
+	items, ok := node.([]interface{})
+	if !ok {
+		return node
+	}
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		switch {
+		case !s.matches(item):
+			kept = append(kept, item)
+		case len(p) > 1:
+			kept = append(kept, remove(item, p[1:]))
+		}
+	}
+	return kept
+}
+
+// preserve copies the fields at the path from the live node into the node, and
+// returns the node. The list items removed by the last step of the path are
+// added back from the live list. The list items selected by another step are
+// paired in order with the live list items selected by the same step.
+func preserve(node, live interface{}, p Path) interface{} {
+	s := p[0]
+	if !s.isList() {
+		liveMap, ok := live.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		liveChild, found := liveMap[s.field]
+		if !found {
+			return node
+		}
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			if node != nil {
+				// The declared field has another type, which is applied.
+				return node
+			}
+			m = make(map[string]interface{})
+		}
+		if len(p) == 1 {
+			m[s.field] = runtime.DeepCopyJSONValue(liveChild)
+		} else {
+			m[s.field] = preserve(m[s.field], liveChild, p[1:])
+		}
+		return m
+	}
+	liveItems, ok := live.([]interface{})
+	if !ok {
+		return node
+	}
+	items, ok := node.([]interface{})
+	if !ok && node != nil {
+		return node
+	}
+	var selected []interface{}
+	for _, item := range liveItems {
+		if s.matches(item) {
+			selected = append(selected, item)
+		}
+	}
+	if len(p) == 1 {
+		for _, item := range selected {
+			items = append(items, runtime.DeepCopyJSONValue(item))
+		}
+		return items
+	}
+	i := 0
+	for j, item := range items {
+		if i == len(selected) {
+			break
+		}
+		if s.matches(item) {
+			items[j] = preserve(item, selected[i], p[1:])
+			i++
+		}
+	}
+	return items
+}
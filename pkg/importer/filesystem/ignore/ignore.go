@@ -0,0 +1,238 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ignore selects the files Config Sync reads from a sync directory.
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitignore "github.com/monochromegane/go-gitignore"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+)
+
+// File is the name of the file which lists the files to ignore in a directory
+// of a sync directory, using the gitignore pattern format.
+const File = ".configsyncignore"
+
+// gitDir is the directory of a git repository, which is never read.
+const gitDir = ".git"
+
+// Matcher matches the files in a sync directory which Config Sync ignores.
+type Matcher struct {
+	dir string
+	// ignore matches the patterns in the ignore File and the exclude globs.
+	ignore gitignore.IgnoreMatcher
+	// include matches the include globs, or is nil if all the files are
+	// included.
+	include gitignore.IgnoreMatcher
+	// nested are the matchers of the ignore Files in the subdirectories, by
+	// directory. A directory without an ignore File has a nil matcher.
+	nested map[string]gitignore.IgnoreMatcher
+}
+
+// NewMatcher returns a Matcher for the sync directory dir.
+//
+// The patterns are read from the ignore File in dir, if it exists. The
+// patterns of the ignore Files in the subdirectories of dir are relative to
+// their directory, and add to the patterns of the parent directories. include
+// and exclude are lists of gitignore patterns relative to dir. If include is
+// not empty, only the files matching one of its patterns are read. Files
+// matching one of the exclude patterns are ignored.
+func NewMatcher(dir cmpath.Absolute, include, exclude []string) (*Matcher, error) {
+	patterns := strings.Join(exclude, "\n")
+	content, err := os.ReadFile(dir.Join(cmpath.RelativeSlash(File)).OSPath())
+	switch {
+	case err == nil:
+		patterns = string(content) + "\n" + patterns
+	case !os.IsNotExist(err):
+		return nil, errors.Wrapf(err, "reading %s", File)
+	}
+
+	m := &Matcher{
+		dir:    dir.OSPath(),
+		ignore: gitignore.NewGitIgnoreFromReader(dir.OSPath(), strings.NewReader(patterns)),
+		nested: map[string]gitignore.IgnoreMatcher{},
+	}
+	if len(include) > 0 {
+		m.include = gitignore.NewGitIgnoreFromReader(dir.OSPath(), strings.NewReader(strings.Join(include, "\n")))
+	}
+	return m, nil
+}
+
+// Ignored returns true if the file or directory at the OS-specific path is
+// ignored. It does not check whether a parent directory is ignored.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	if m.ignore.Match(path, isDir) {
+		return true
+	}
+	for dir := filepath.Dir(path); strings.HasPrefix(dir, m.dir) && dir != m.dir; dir = filepath.Dir(dir) {
+		nested, err := m.nestedMatcher(dir)
+		if err != nil {
+			klog.Warningf("Unable to read the %s file in %s: %v", File, dir, err)
+			continue
+		}
+		if nested != nil && nested.Match(path, isDir) {
+			return true
+		}
+	}
+	if isDir || m.include == nil {
+		return false
+	}
+	// The file is included if it, or one of its parent directories, matches an
+	// include pattern.
+	if m.include.Match(path, false) {
+		return false
+	}
+	for dir := filepath.Dir(path); strings.HasPrefix(dir, m.dir) && dir != m.dir; dir = filepath.Dir(dir) {
+		if m.include.Match(dir, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// nestedMatcher returns the matcher of the ignore File in the subdirectory
+// dir, or nil if dir has no ignore File.
+func (m *Matcher) nestedMatcher(dir string) (gitignore.IgnoreMatcher, error) {
+	if nested, found := m.nested[dir]; found {
+		return nested, nil
+	}
+	content, err := os.ReadFile(filepath.Join(dir, File))
+	switch {
+	case err == nil:
+		m.nested[dir] = gitignore.NewGitIgnoreFromReader(dir, strings.NewReader(string(content)))
+	case os.IsNotExist(err):
+		m.nested[dir] = nil
+	default:
+		return nil, errors.Wrapf(err, "reading %s", File)
+	}
+	return m.nested[dir], nil
+}
+
+// ListFiles returns the files in the sync directory of the Matcher which are
+// not ignored, with symbolic links evaluated, and the number of ignored files.
+// Git directories are always skipped.
+func (m *Matcher) ListFiles() ([]cmpath.Absolute, int, error) {
+	var result []cmpath.Absolute
+	ignored := 0
+	ignoredDirs := map[string]bool{}
+	err := filepath.Walk(m.dir,
+		func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				if fi.Name() == gitDir {
+					return filepath.SkipDir
+				}
+				if path != m.dir {
+					// Read the ignore File of the directory before its files,
+					// so that reading errors are not skipped.
+					if _, err := m.nestedMatcher(path); err != nil {
+						return err
+					}
+				}
+				if path != m.dir && (ignoredDirs[filepath.Dir(path)] || m.Ignored(path, true)) {
+					ignoredDirs[path] = true
+				}
+				return nil
+			}
+			if fi.Name() == File {
+				return nil
+			}
+			if ignoredDirs[filepath.Dir(path)] || m.Ignored(path, false) {
+				klog.V(4).Infof("Ignoring file %s", path)
+				ignored++
+				return nil
+			}
+			abs, err := cmpath.AbsoluteOS(path)
+			if err != nil {
+				return err
+			}
+			abs, err = abs.EvalSymlinks()
+			if err != nil {
+				return err
+			}
+			result = append(result, abs)
+			return nil
+		})
+	return result, ignored, err
+}
+
+// errFound stops walking a directory once an ignore File is found.
+var errFound = errors.New("found")
+
+// HasFiles returns true if there is an ignore File in dir or in one of its
+// subdirectories. Git directories are always skipped.
+func HasFiles(dir cmpath.Absolute) (bool, error) {
+	err := filepath.Walk(dir.OSPath(),
+		func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() && fi.Name() == gitDir {
+				return filepath.SkipDir
+			}
+			if !fi.IsDir() && fi.Name() == File {
+				return errFound
+			}
+			return nil
+		})
+	if err == errFound {
+		return true, nil
+	}
+	return false, err
+}
+
+// RemoveIgnored removes the files and directories of the sync directory of the
+// Matcher which are ignored, so that tools reading the directory, such as
+// Kustomize, only read the files which are not ignored. The ignore Files are
+// kept. Git directories are never removed.
+func (m *Matcher) RemoveIgnored() error {
+	return filepath.Walk(m.dir,
+		func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == m.dir {
+				return nil
+			}
+			if fi.IsDir() {
+				if fi.Name() == gitDir {
+					return filepath.SkipDir
+				}
+				if _, err := m.nestedMatcher(path); err != nil {
+					return err
+				}
+				if !m.Ignored(path, true) {
+					return nil
+				}
+				klog.V(4).Infof("Removing ignored directory %s", path)
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			if fi.Name() == File || !m.Ignored(path, false) {
+				return nil
+			}
+			klog.V(4).Infof("Removing ignored file %s", path)
+			return os.Remove(path)
+		})
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+)
+
+func TestListFiles(t *testing.T) {
+	files := []string{
+		"namespaces/foo/ns.yaml",
+		"namespaces/foo/deployment.tmpl.yaml",
+		"docs/README.md",
+		"docs/example.yaml",
+		"test/fixtures/role.yaml",
+		"cluster/crd.yaml",
+		".git/config",
+	}
+
+	testCases := []struct {
+		name        string
+		ignoreFiles map[string]string
+		include     []string
+		exclude     []string
+		want        []string
+		wantIgnored int
+	}{
+		{
+			name: "no ignore file reads all files",
+			want: []string{
+				"cluster/crd.yaml",
+				"docs/README.md",
+				"docs/example.yaml",
+				"namespaces/foo/deployment.tmpl.yaml",
+				"namespaces/foo/ns.yaml",
+				"test/fixtures/role.yaml",
+			},
+		},
+		{
+			name:        "ignore file",
+			ignoreFiles: map[string]string{File: "# Not configs\ndocs/\ntest\n*.tmpl.yaml\n"},
+			want: []string{
+				"cluster/crd.yaml",
+				"namespaces/foo/ns.yaml",
+			},
+			wantIgnored: 4,
+		},
+		{
+			name:        "ignore file with negation",
+			ignoreFiles: map[string]string{File: "docs/*\n!docs/example.yaml\n"},
+			want: []string{
+				"cluster/crd.yaml",
+				"docs/example.yaml",
+				"namespaces/foo/deployment.tmpl.yaml",
+				"namespaces/foo/ns.yaml",
+				"test/fixtures/role.yaml",
+			},
+			wantIgnored: 1,
+		},
+		{
+			name:    "include and exclude",
+			include: []string{"namespaces/", "cluster/*.yaml"},
+			exclude: []string{"*.tmpl.yaml"},
+			want: []string{
+				"cluster/crd.yaml",
+				"namespaces/foo/ns.yaml",
+			},
+			wantIgnored: 4,
+		},
+		{
+			name: "nested ignore files",
+			ignoreFiles: map[string]string{
+				File:                    "*.md\n",
+				"namespaces/" + File:    "*.tmpl.yaml\n",
+				"test/fixtures/" + File: "/role.yaml\n",
+			},
+			want: []string{
+				"cluster/crd.yaml",
+				"docs/example.yaml",
+				"namespaces/foo/ns.yaml",
+			},
+			wantIgnored: 3,
+		},
+		{
+			name:        "nested ignore file patterns are relative to their directory",
+			ignoreFiles: map[string]string{"namespaces/" + File: "/ns.yaml\n/cluster\n"},
+			want: []string{
+				"cluster/crd.yaml",
+				"docs/README.md",
+				"docs/example.yaml",
+				"namespaces/foo/deployment.tmpl.yaml",
+				"namespaces/foo/ns.yaml",
+				"test/fixtures/role.yaml",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := cmpath.AbsoluteOS(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir, err = dir.EvalSymlinks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range files {
+				p := dir.Join(cmpath.RelativeSlash(f)).OSPath()
+				if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for f, content := range tc.ignoreFiles {
+				if err := os.WriteFile(dir.Join(cmpath.RelativeSlash(f)).OSPath(), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			m, err := NewMatcher(dir, tc.include, tc.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ignored, err := m.ListFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotRelative []string
+			for _, f := range got {
+				rel, err := filepath.Rel(dir.OSPath(), f.OSPath())
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotRelative = append(gotRelative, filepath.ToSlash(rel))
+			}
+			sort.Strings(gotRelative)
+			if diff := cmp.Diff(tc.want, gotRelative); diff != "" {
+				t.Error(diff)
+			}
+			if ignored != tc.wantIgnored {
+				t.Errorf("got %d ignored files, want %d", ignored, tc.wantIgnored)
+			}
+		})
+	}
+}
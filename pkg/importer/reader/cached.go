@@ -0,0 +1,193 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/status"
+)
+
+// racyWindow is the time before a Read during which changes to a file may not
+// change its modification time, because of the coarse clock of filesystems.
+// The files modified during that window are read again by the next Read, even
+// if their size and modification time are unchanged.
+const racyWindow = time.Second
+
+// Cached reads FileObjects from a filesystem, like File, but only parses the
+// files which changed since the previous Read, which makes reading a new
+// commit of a large repository much cheaper when only a few files changed.
+//
+// The files whose path, size and modification time are unchanged are not read
+// again. The other files are read and hashed, and only decoded if their
+// contents changed, since each commit is usually checked out into a new
+// directory.
+//
+// Files are identified by their path relative to the root directory, so the
+// cache is reused across the directories of different commits.
+type Cached struct {
+	mux sync.Mutex
+	// entries maps the relative path of each file read by the previous Read to
+	// the objects parsed from it.
+	entries map[string]cachedFile
+}
+
+// cachedFile is the result of parsing a file without errors.
+type cachedFile struct {
+	policyDir cmpath.Relative
+	osPath    string
+	size      int64
+	modTime   time.Time
+	readTime  time.Time
+	sum       [sha256.Size]byte
+	objs      []ast.FileObject
+	// validated is true if the objects passed validation since they were
+	// parsed.
+	validated bool
+}
+
+var _ Reader = &Cached{}
+
+// Read implements Reader.
+func (r *Cached) Read(filePaths FilePaths) ([]ast.FileObject, status.MultiError) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	now := time.Now()
+	entries := make(map[string]cachedFile, len(filePaths.Files))
+	var objs []ast.FileObject
+	var errs status.MultiError
+	read, parsed := 0, 0
+	for _, f := range filePaths.Files {
+		if isIgnoredPath(f) || !isConfigFile(f.OSPath()) {
+			continue
+		}
+		key, err := filepath.Rel(filePaths.RootDir.OSPath(), f.OSPath())
+		if err != nil {
+			key = f.OSPath()
+		}
+		entry, found := r.entries[key]
+		found = found && entry.policyDir == filePaths.PolicyDir
+		// Errors are reported when the file is read.
+		info, statErr := os.Stat(f.OSPath())
+		if statErr == nil && found && entry.unmodified(f.OSPath(), info) {
+			entries[key] = entry
+			objs = append(objs, deepCopy(entry.objs)...)
+			continue
+		}
+
+		read++
+		contents, _, err := readFile(f.OSPath())
+		if err != nil {
+			errs = status.Append(errs, status.PathWrapError(err, f.OSPath()))
+			continue
+		}
+		sum := sha256.Sum256(contents)
+		if found && entry.sum == sum {
+			entry.setStat(f.OSPath(), info, now)
+			entries[key] = entry
+			objs = append(objs, deepCopy(entry.objs)...)
+			continue
+		}
+
+		parsed++
+		unstructureds, err := parseContents(f.OSPath(), contents)
+		if err != nil {
+			errs = status.Append(errs, status.PathWrapError(err, f.OSPath()))
+			continue
+		}
+		newObjs, newErrs := fileObjectsFrom(unstructureds, filePaths.RootDir, filePaths.PolicyDir, f)
+		if newErrs != nil {
+			// Errors include the absolute path of the file, so they are not
+			// cached.
+			errs = status.Append(errs, newErrs)
+			continue
+		}
+		entry = cachedFile{
+			policyDir: filePaths.PolicyDir,
+			sum:       sum,
+			objs:      deepCopy(newObjs),
+		}
+		entry.setStat(f.OSPath(), info, now)
+		entries[key] = entry
+		objs = append(objs, newObjs...)
+	}
+	// Forget the files which were removed.
+	r.entries = entries
+	klog.V(1).Infof("Read %d and parsed %d changed files out of %d files", read, parsed, len(filePaths.Files))
+
+	if errs != nil {
+		return nil, errs
+	}
+	return objs, nil
+}
+
+// setStat records the size and the modification time of the file read at the
+// path. They are left unset if the file could not be stat'ed, so that it is
+// read again by the next Read.
+func (f *cachedFile) setStat(osPath string, info os.FileInfo, readTime time.Time) {
+	f.osPath, f.readTime = osPath, readTime
+	if info != nil {
+		f.size, f.modTime = info.Size(), info.ModTime()
+	} else {
+		f.size, f.modTime = 0, time.Time{}
+	}
+}
+
+// unmodified returns true if the file at the path has the size and the
+// modification time it had when it was read, and was not modified during the
+// racyWindow before it was read.
+func (f cachedFile) unmodified(osPath string, info os.FileInfo) bool {
+	return f.osPath == osPath && !f.modTime.IsZero() && f.size == info.Size() && f.modTime.Equal(info.ModTime()) &&
+		f.modTime.Before(f.readTime.Add(-racyWindow))
+}
+
+// Validated returns true if the file of the object passed validation and is
+// unchanged since. The validators of a single object skip such objects.
+func (r *Cached) Validated(obj ast.FileObject) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	entry, found := r.entries[obj.OSPath()]
+	return found && entry.validated
+}
+
+// SetValidated records that the objects returned by the last Read passed
+// validation.
+func (r *Cached) SetValidated() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for key, entry := range r.entries {
+		entry.validated = true
+		r.entries[key] = entry
+	}
+}
+
+// deepCopy returns a deep copy of the objects, since they are mutated by the
+// validation and hydration which follow. Copying is much cheaper than decoding
+// the files again.
+func deepCopy(objs []ast.FileObject) []ast.FileObject {
+	result := make([]ast.FileObject, len(objs))
+	for i := range objs {
+		result[i] = objs[i].DeepCopy()
+	}
+	return result
+}
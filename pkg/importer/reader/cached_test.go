@@ -0,0 +1,172 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	ft "kpt.dev/configsync/pkg/importer/filesystem/filesystemtest"
+	"kpt.dev/configsync/pkg/importer/reader"
+)
+
+const (
+	fooFile = "foo.yaml"
+	barFile = "bar.yaml"
+)
+
+func namespaceYAML(name, label string) string {
+	return `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ` + name + `
+  labels:
+    team: ` + label + `
+`
+}
+
+// labels returns the team label of each object, sorted by object name.
+func labels(objs []ast.FileObject) []string {
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].GetName() < objs[j].GetName()
+	})
+	var result []string
+	for _, obj := range objs {
+		result = append(result, obj.GetName()+"="+obj.GetLabels()["team"])
+	}
+	return result
+}
+
+func TestCachedReader_Read(t *testing.T) {
+	dir := ft.NewTestDir(t,
+		ft.FileContents(fooFile, namespaceYAML("foo", "a")),
+		ft.FileContents(barFile, namespaceYAML("bar", "a")),
+	)
+	r := &reader.Cached{}
+
+	objs, err := r.Read(dir.FilePaths(fooFile, barFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bar=a", "foo=a"}, labels(objs))
+
+	// Mutating the objects read does not change the cached objects.
+	objs[0].SetLabels(map[string]string{"team": "mutated"})
+	objs, err = r.Read(dir.FilePaths(fooFile, barFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bar=a", "foo=a"}, labels(objs))
+
+	// Changed files are parsed again.
+	require.NoError(t, os.WriteFile(filepath.Join(dir.Root().OSPath(), fooFile), []byte(namespaceYAML("foo", "b")), 0644))
+	objs, err = r.Read(dir.FilePaths(fooFile, barFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bar=a", "foo=b"}, labels(objs))
+
+	// Removed files are forgotten.
+	objs, err = r.Read(dir.FilePaths(fooFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"foo=b"}, labels(objs))
+}
+
+func TestCachedReader_Read_Errors(t *testing.T) {
+	dir := ft.NewTestDir(t,
+		ft.FileContents(fooFile, namespaceYAML("foo", "a")),
+		ft.FileContents(barFile, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: bar
+  annotations: a
+`),
+	)
+	r := &reader.Cached{}
+
+	// Errors are returned again, since files with errors are not cached.
+	for i := 0; i < 2; i++ {
+		objs, err := r.Read(dir.FilePaths(fooFile, barFile))
+		require.NotNil(t, err)
+		assert.Len(t, err.Errors(), 1)
+		assert.Empty(t, objs)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir.Root().OSPath(), barFile), []byte(namespaceYAML("bar", "a")), 0644))
+	objs, err := r.Read(dir.FilePaths(fooFile, barFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bar=a", "foo=a"}, labels(objs))
+}
+
+func TestCachedReader_Read_Unmodified(t *testing.T) {
+	dir := ft.NewTestDir(t,
+		ft.FileContents(fooFile, namespaceYAML("foo", "a")),
+	)
+	path := filepath.Join(dir.Root().OSPath(), fooFile)
+	modTime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	r := &reader.Cached{}
+
+	objs, err := r.Read(dir.FilePaths(fooFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"foo=a"}, labels(objs))
+
+	// Files with the same size and modification time are not read again.
+	require.NoError(t, os.WriteFile(path, []byte(namespaceYAML("foo", "b")), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	objs, err = r.Read(dir.FilePaths(fooFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"foo=a"}, labels(objs))
+
+	// Files with a new modification time are read again.
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	objs, err = r.Read(dir.FilePaths(fooFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"foo=b"}, labels(objs))
+}
+
+func TestCachedReader_Validated(t *testing.T) {
+	dir := ft.NewTestDir(t,
+		ft.FileContents(fooFile, namespaceYAML("foo", "a")),
+		ft.FileContents(barFile, namespaceYAML("bar", "a")),
+	)
+	r := &reader.Cached{}
+	validated := func(objs []ast.FileObject) []string {
+		var result []string
+		for _, obj := range objs {
+			if r.Validated(obj) {
+				result = append(result, obj.GetName())
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	objs, err := r.Read(dir.FilePaths(fooFile, barFile))
+	require.Nil(t, err)
+	assert.Empty(t, validated(objs))
+
+	r.SetValidated()
+	assert.Equal(t, []string{"bar", "foo"}, validated(objs))
+
+	// The changed files must be validated again.
+	require.NoError(t, os.WriteFile(filepath.Join(dir.Root().OSPath(), fooFile), []byte(namespaceYAML("foo", "b")), 0644))
+	objs, err = r.Read(dir.FilePaths(fooFile, barFile))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bar"}, validated(objs))
+}
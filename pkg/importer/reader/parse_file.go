@@ -32,30 +32,48 @@ import (
 )
 
 func parseFile(path string) ([]*unstructured.Unstructured, error) {
+	contents, ok, err := readFile(path)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return parseContents(path, contents)
+}
+
+// readFile returns the contents of the YAML or JSON file at path. It returns
+// false for the other files, which are not parsed.
+func readFile(path string) ([]byte, bool, error) {
 	if !filepath.IsAbs(path) {
-		return nil, errors.New("attempted to read relative path")
+		return nil, false, errors.New("attempted to read relative path")
 	}
 
+	if !isConfigFile(path) {
+		return nil, false, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		klog.Errorf("Failed to read file declared in git from mounted filesystem: %s", path)
+		importer.Metrics.Violations.Inc()
+		return nil, false, err
+	}
+	return contents, true, nil
+}
+
+// isConfigFile returns true if the file at the path may declare objects.
+func isConfigFile(path string) bool {
 	switch filepath.Ext(path) {
-	case ".yml", ".yaml":
-		contents, err := ioutil.ReadFile(path)
-		if err != nil {
-			klog.Errorf("Failed to read file declared in git from mounted filesystem: %s", path)
-			importer.Metrics.Violations.Inc()
-			return nil, err
-		}
-		return parseYAMLFile(contents)
-	case ".json":
-		contents, err := ioutil.ReadFile(path)
-		if err != nil {
-			klog.Errorf("Failed to read file declared in git from mounted filesystem: %s", path)
-			importer.Metrics.Violations.Inc()
-			return nil, err
-		}
-		return parseJSONFile(contents)
+	case ".yml", ".yaml", ".json":
+		return true
 	default:
-		return nil, nil
+		return false
+	}
+}
+
+// parseContents parses the contents of the YAML or JSON file at path.
+func parseContents(path string, contents []byte) ([]*unstructured.Unstructured, error) {
+	if filepath.Ext(path) == ".json" {
+		return parseJSONFile(contents)
 	}
+	return parseYAMLFile(contents)
 }
 
 func isEmptyYAMLDocument(document string) bool {
@@ -77,19 +77,32 @@ func (r *File) Read(filePaths FilePaths) ([]ast.FileObject, status.MultiError) {
 
 // Read implements Reader.
 func (r *File) read(rootDir cmpath.Absolute, policyDir cmpath.Relative, file cmpath.Absolute) ([]ast.FileObject, status.MultiError) {
-	splitPath := strings.Split(file.OSPath(), "/")
-	for _, pathPiece := range splitPath {
-		if pathPiece == ".github" || pathPiece == ".gitlab" || pathPiece == ".gitlab-ci.yml" {
-			klog.Infof("Ignoring file path: %v", file.OSPath())
-			return nil, nil
-		}
+	if isIgnoredPath(file) {
+		return nil, nil
 	}
 
 	unstructureds, err := parseFile(file.OSPath())
 	if err != nil {
 		return nil, status.PathWrapError(err, file.OSPath())
 	}
+	return fileObjectsFrom(unstructureds, rootDir, policyDir, file)
+}
+
+// isIgnoredPath returns true if the file is in a CI configuration directory,
+// which is never synced.
+func isIgnoredPath(file cmpath.Absolute) bool {
+	splitPath := strings.Split(file.OSPath(), "/")
+	for _, pathPiece := range splitPath {
+		if pathPiece == ".github" || pathPiece == ".gitlab" || pathPiece == ".gitlab-ci.yml" {
+			klog.Infof("Ignoring file path: %v", file.OSPath())
+			return true
+		}
+	}
+	return false
+}
 
+// fileObjectsFrom converts the objects parsed from the file to FileObjects.
+func fileObjectsFrom(unstructureds []*unstructured.Unstructured, rootDir cmpath.Absolute, policyDir cmpath.Relative, file cmpath.Absolute) ([]ast.FileObject, status.MultiError) {
 	var fileObjects []ast.FileObject
 	var errs status.MultiError
 	for _, u := range unstructureds {
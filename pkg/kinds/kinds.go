@@ -224,6 +224,11 @@ func RepoSyncV1Beta1() schema.GroupVersionKind {
 	return configsyncv1beta1.SchemeGroupVersion.WithKind(configsync.RepoSyncKind)
 }
 
+// RepoSyncPolicyV1Beta1 returns the v1beta1 RepoSyncPolicy GroupVersionKind.
+func RepoSyncPolicyV1Beta1() schema.GroupVersionKind {
+	return configsyncv1beta1.SchemeGroupVersion.WithKind(configsync.RepoSyncPolicyKind)
+}
+
 // RootSyncV1Alpha1 returns the canonical RootSync GroupVersionKind.
 func RootSyncV1Alpha1() schema.GroupVersionKind {
 	return v1alpha1.SchemeGroupVersion.WithKind(configsync.RootSyncKind)
@@ -80,4 +80,10 @@ var (
 		"kustomize_build_latency",
 		"Kustomize build latency",
 		stats.UnitMilliseconds)
+
+	// RenderCacheCount is the number of hits and misses of the render cache
+	RenderCacheCount = stats.Int64(
+		"rendering_cache_count",
+		"The number of hits and misses of the render cache of the hydration-controller",
+		stats.UnitDimensionless)
 )
@@ -33,6 +33,14 @@ var (
 	keyBaseCount, _              = tag.NewKey("base_source")
 	keyPatchCount, _             = tag.NewKey("patch_field")
 	keyTopTierCount, _           = tag.NewKey("top_tier_field")
+	keyCacheResult, _            = tag.NewKey("result")
+)
+
+const (
+	// CacheHit is the result of a lookup which found the rendered configs in the render cache.
+	CacheHit = "hit"
+	// CacheMiss is the result of a lookup which didn't find the rendered configs in the render cache.
+	CacheMiss = "miss"
 )
 
 // RecordKustomizeFieldCountData records all data relevant to the kustomization's field counts
@@ -66,6 +74,12 @@ func RecordKustomizeExecutionTime(ctx context.Context, executionTime float64) {
 	record(ctx, KustomizeExecutionTime.M(executionTime))
 }
 
+// RecordRenderCacheResult produces measurement for RenderCacheCount view
+func RecordRenderCacheResult(ctx context.Context, result string) {
+	tagCtx, _ := tag.New(ctx, tag.Upsert(keyCacheResult, result))
+	record(tagCtx, RenderCacheCount.M(1))
+}
+
 // recordKustomizeFieldCount produces measurement for KustomizeFieldCount view
 func recordKustomizeFieldCount(ctx context.Context, fieldCount map[string]int) {
 	for field, count := range fieldCount {
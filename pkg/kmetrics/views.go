@@ -109,6 +109,15 @@ var (
 		Description: "Execution time of `kustomize build`",
 		Aggregation: view.Distribution(0, 10, 20, 40, 80, 160, 320, 640, 1280, 2560, 5120, 10240),
 	}
+
+	// RenderCacheCountView is the number of hits and misses of the render cache
+	RenderCacheCountView = &view.View{
+		Name:        RenderCacheCount.Name(),
+		Measure:     RenderCacheCount,
+		Description: "The number of hits and misses of the render cache of the hydration-controller",
+		TagKeys:     []tag.Key{keyCacheResult},
+		Aggregation: view.Count(),
+	}
 )
 
 // RegisterKustomizeMetricsViews registers the views so that recorded metrics can be exported. .
@@ -124,5 +133,6 @@ func RegisterKustomizeMetricsViews() error {
 		KustomizeTopTierMetricsView,
 		KustomizeResourceCountView,
 		KustomizeExecutionTimeView,
+		RenderCacheCountView,
 	)
 }
@@ -40,11 +40,6 @@ const (
 	// This annotation is set by Config Sync users on a managed resource.
 	NamespaceSelectorAnnotationKey = ConfigManagementPrefix + "namespace-selector"
 
-	// DeclaredConfigAnnotationKey is the annotation key that stores the declared configuration of
-	// a resource in Git.
-	// This annotation is set by Config Sync on a managed resource.
-	DeclaredConfigAnnotationKey = ConfigManagementPrefix + "declared-config"
-
 	// SourcePathAnnotationKey is the annotation key representing the relative path from POLICY_DIR
 	// where the object was originally declared. Paths are slash-separated and OS-agnostic.
 	// This annotation is set by Config Sync on a managed resource.
@@ -145,6 +140,48 @@ const (
 	// reconciler-manager will create the reconciler with the hydration-controller
 	// sidecar container.
 	RequiresRenderingAnnotationKey = configsync.ConfigSyncPrefix + "requires-rendering"
+
+	// SyncTriggerAnnotationKey is the annotation key set on RootSync/RepoSync
+	// objects to request an immediate resync. The value is a unique token,
+	// which the reconciler reports in the Triggered condition when the
+	// resync is done.
+	SyncTriggerAnnotationKey = configsync.ConfigSyncPrefix + "sync-trigger"
+
+	// SyncPausedAnnotationKey is the annotation key set on RootSync/RepoSync
+	// objects to pause applying and remediating managed resources. The
+	// reconciler sets the Paused condition while the value is "true".
+	SyncPausedAnnotationKey = configsync.ConfigSyncPrefix + "sync-paused"
+
+	// ReapplyAnnotationKey is the annotation key set on RootSync/RepoSync
+	// objects to request the reapply of some managed objects. The value is a
+	// JSON encoded reapply.Request, with a unique token, which the reconciler
+	// reports in the Reapplied condition when the objects are reapplied.
+	ReapplyAnnotationKey = configsync.ConfigSyncPrefix + "reapply"
+
+	// ResourceHandoffKey is the annotation key set on objects in the source
+	// repository to hand off their management to another RootSync or RepoSync.
+	// The value is the manager of the target R*Sync, in the format of the
+	// ResourceManagerKey annotation. The current manager stops applying the
+	// object and hands its inventory entry over without pruning it.
+	ResourceHandoffKey = configsync.ConfigSyncPrefix + "handoff-to"
+
+	// ConflictPolicyAnnotationKey is the annotation key set on objects in the
+	// source repository to override the conflict policy of the RootSync or
+	// RepoSync for the object. The value must be "force", "respect", or
+	// "fail".
+	ConflictPolicyAnnotationKey = configsync.ConfigSyncPrefix + "conflict-policy"
+
+	// IgnoreFieldsAnnotationKey is the annotation key set on objects in the
+	// source repository to list the paths of the fields which Config Sync
+	// neither applies nor remediates, separated by commas, like
+	// ".spec.replicas".
+	IgnoreFieldsAnnotationKey = configsync.ConfigSyncPrefix + "ignore-fields"
+
+	// ResourceGroupShardsKey is the annotation key set on the ResourceGroup
+	// inventory of a RootSync or RepoSync to record the number of
+	// ResourceGroups the inventory is split across. It is not set while the
+	// inventory fits in a single ResourceGroup.
+	ResourceGroupShardsKey = configsync.ConfigSyncPrefix + "resourcegroup-shards"
 )
 
 // Lifecycle annotations
@@ -56,6 +56,11 @@ const (
 	// This is used to enable selecting pods by label, primarily for printing logs.
 	// Example: kubectl logs deployment/<deploy-name> <container-name> -n config-management-system
 	DeploymentNameLabel = configsync.ConfigSyncPrefix + "deployment-name"
+
+	// RepoSyncPolicyLabel is the label of a Namespace which references the
+	// RepoSyncPolicy limiting what the RepoSyncs in the Namespace may declare.
+	// It is the only Config Sync label which users may declare.
+	RepoSyncPolicyLabel = configsync.ConfigSyncPrefix + "repo-sync-policy"
 )
 
 // DepthSuffix is a label suffix for hierarchical namespace depth.
@@ -54,6 +54,9 @@ var sourceAnnotations = map[string]bool{
 	ResourceManagementKey:                  true,
 	LifecycleMutationAnnotation:            true,
 	DeletionPropagationPolicyAnnotationKey: true,
+	ResourceHandoffKey:                     true,
+	ConflictPolicyAnnotationKey:            true,
+	IgnoreFieldsAnnotationKey:              true,
 }
 
 // IsSourceAnnotation returns true if the annotation is a ConfigSync source
@@ -62,6 +62,12 @@ var (
 		"The number of declared resources parsed from Git",
 		stats.UnitDimensionless)
 
+	// IgnoredFiles metric measures the number of files ignored in the sync directory.
+	IgnoredFiles = stats.Int64(
+		"ignored_files",
+		"The number of files in the sync directory ignored by the ignore file or the include and exclude patterns",
+		stats.UnitDimensionless)
+
 	// ApplyOperations metric measures the number of applier apply events.
 	ApplyOperations = stats.Int64(
 		"apply_operations",
@@ -163,6 +163,7 @@ processors:
           - rg_reconcile_duration_seconds
           - parser_duration_seconds
           - declared_resources
+          - ignored_files
           - apply_operations_total
           - apply_duration_seconds
           - resource_fights_total
@@ -188,6 +189,12 @@ processors:
           - action: aggregate_labels
             label_set: []
             aggregation_type: max
+      - include: ignored_files
+        action: update
+        operations:
+          - action: aggregate_labels
+            label_set: []
+            aggregation_type: max
       - include: kcc_resource_count
         action: update
         operations:
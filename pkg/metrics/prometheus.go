@@ -0,0 +1,258 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/metric/metricproducer"
+	"k8s.io/klog/v2"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// PrometheusNamespace is the prefix added to the name of every metric
+	// served by the PrometheusExporter. It matches the namespace used by the
+	// Prometheus exporter of the otel-collector, so that dashboards and alerts
+	// work the same way with either pipeline.
+	PrometheusNamespace = "config_sync"
+
+	// PrometheusPath is the HTTP path on which the PrometheusExporter serves
+	// metrics.
+	PrometheusPath = "/metrics"
+
+	// PrometheusPort is the default port on which the reconciler,
+	// reconciler-manager and admission-webhook serve metrics when the
+	// Prometheus exporter is enabled. It matches the port of the
+	// otel-collector Prometheus exporter.
+	PrometheusPort = 8675
+
+	// HydrationControllerPrometheusPort is the default port on which the
+	// hydration-controller serves metrics when the Prometheus exporter is
+	// enabled. It differs from PrometheusPort because the hydration-controller
+	// shares a Pod with the reconciler.
+	HydrationControllerPrometheusPort = 8676
+
+	// ResourceKeyContainerName is the Prometheus label for the
+	// k8s.container.name resource attribute.
+	ResourceKeyContainerName = "k8s_container_name"
+
+	// prometheusShutdownTimeout is how long Stop waits for in-flight scrapes.
+	prometheusShutdownTimeout = 5 * time.Second
+)
+
+// PrometheusExporter is a Prometheus collector which serves all the registered
+// OpenCensus views, without forwarding them to the otel-agent sidecar.
+//
+// Metric names and labels are converted the same way as the otel-collector
+// Prometheus exporter converts them:
+// - metric names are prefixed with PrometheusNamespace
+// - invalid characters in metric names and label keys are replaced with "_"
+// - resource attributes are added to every metric as labels
+type PrometheusExporter struct {
+	resourceLabels prometheus.Labels
+	server         *http.Server
+}
+
+var _ prometheus.Collector = &PrometheusExporter{}
+
+// NewPrometheusExporter returns a new PrometheusExporter which adds the
+// specified resource attributes to every metric.
+func NewPrometheusExporter(resourceLabels map[string]string) *PrometheusExporter {
+	labels := make(prometheus.Labels, len(resourceLabels))
+	for k, v := range resourceLabels {
+		if v == "" {
+			continue
+		}
+		labels[sanitizePrometheusName(k)] = v
+	}
+	return &PrometheusExporter{resourceLabels: labels}
+}
+
+// RegisterPrometheusExporter creates the Prometheus metrics exporter and starts
+// serving metrics on the specified address.
+func RegisterPrometheusExporter(addr, containerName string, resourceLabels map[string]string) (*PrometheusExporter, error) {
+	labels := map[string]string{ResourceKeyContainerName: containerName}
+	for k, v := range resourceLabels {
+		labels[k] = v
+	}
+	pe := NewPrometheusExporter(labels)
+	if err := pe.Start(addr); err != nil {
+		return nil, err
+	}
+	return pe, nil
+}
+
+// Handler returns an http.Handler that serves the registered views, along
+// with the controller-runtime metrics, in the Prometheus text format.
+func (pe *PrometheusExporter) Handler() (http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(pe); err != nil {
+		return nil, fmt.Errorf("registering Prometheus collector: %w", err)
+	}
+	gatherers := prometheus.Gatherers{registry, ctrlmetrics.Registry}
+	return promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{
+		ErrorLog:      klogErrorLogger{},
+		ErrorHandling: promhttp.ContinueOnError,
+	}), nil
+}
+
+// Start listens on the specified address and serves metrics in the
+// background until Stop is called.
+func (pe *PrometheusExporter) Start(addr string) error {
+	handler, err := pe.Handler()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %q: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(PrometheusPath, handler)
+	pe.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		klog.Infof("Serving Prometheus metrics on %s%s", listener.Addr(), PrometheusPath)
+		if err := pe.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Prometheus metrics server exited: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop shuts down the metrics server, if started.
+func (pe *PrometheusExporter) Stop() error {
+	if pe.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), prometheusShutdownTimeout)
+	defer cancel()
+	return pe.server.Shutdown(ctx)
+}
+
+// Describe implements prometheus.Collector.
+//
+// No descriptors are sent, which makes this an unchecked collector. The set of
+// registered views is not known ahead of time.
+func (pe *PrometheusExporter) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+//
+// Collect reads the current value of every registered view, so metrics are
+// always up to date when scraped, regardless of the view reporting period.
+func (pe *PrometheusExporter) Collect(ch chan<- prometheus.Metric) {
+	for _, producer := range metricproducer.GlobalManager().GetAll() {
+		for _, m := range producer.Read() {
+			pe.collectMetric(ch, m)
+		}
+	}
+}
+
+func (pe *PrometheusExporter) collectMetric(ch chan<- prometheus.Metric, m *metricdata.Metric) {
+	labelKeys := make([]string, len(m.Descriptor.LabelKeys))
+	for i, key := range m.Descriptor.LabelKeys {
+		labelKeys[i] = sanitizePrometheusName(key.Key)
+	}
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(PrometheusNamespace, "", sanitizePrometheusName(m.Descriptor.Name)),
+		m.Descriptor.Description,
+		labelKeys,
+		pe.resourceLabels)
+	for _, ts := range m.TimeSeries {
+		labelValues := make([]string, len(ts.LabelValues))
+		for i, value := range ts.LabelValues {
+			if value.Present {
+				labelValues[i] = value.Value
+			}
+		}
+		for _, point := range ts.Points {
+			pm, err := toPrometheusMetric(desc, m.Descriptor.Type, point, labelValues)
+			if err != nil {
+				klog.Warningf("Failed to convert metric %q to Prometheus: %v", m.Descriptor.Name, err)
+				continue
+			}
+			ch <- pm
+		}
+	}
+}
+
+// toPrometheusMetric converts an OpenCensus point to a Prometheus metric.
+func toPrometheusMetric(desc *prometheus.Desc, metricType metricdata.Type, point metricdata.Point, labelValues []string) (prometheus.Metric, error) {
+	switch metricType {
+	case metricdata.TypeCumulativeInt64:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, float64(point.Value.(int64)), labelValues...)
+	case metricdata.TypeCumulativeFloat64:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, point.Value.(float64), labelValues...)
+	case metricdata.TypeGaugeInt64:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(point.Value.(int64)), labelValues...)
+	case metricdata.TypeGaugeFloat64:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, point.Value.(float64), labelValues...)
+	case metricdata.TypeCumulativeDistribution, metricdata.TypeGaugeDistribution:
+		dist := point.Value.(*metricdata.Distribution)
+		buckets := make(map[float64]uint64)
+		if dist.BucketOptions != nil {
+			// Prometheus buckets are cumulative, OpenCensus buckets are not.
+			var count uint64
+			for i, bound := range dist.BucketOptions.Bounds {
+				if i < len(dist.Buckets) {
+					count += uint64(dist.Buckets[i].Count)
+				}
+				buckets[bound] = count
+			}
+		}
+		return prometheus.NewConstHistogram(desc, uint64(dist.Count), dist.Sum, buckets, labelValues...)
+	default:
+		return nil, fmt.Errorf("unsupported metric type: %v", metricType)
+	}
+}
+
+// sanitizePrometheusName replaces the characters which are not allowed in
+// Prometheus metric names and label keys with underscores.
+// For example, "configsync.sync.kind" becomes "configsync_sync_kind".
+func sanitizePrometheusName(name string) string {
+	if name == "" {
+		return name
+	}
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if sanitized[0] >= '0' && sanitized[0] <= '9' {
+		sanitized = "key_" + sanitized
+	}
+	return sanitized
+}
+
+// klogErrorLogger adapts klog to the promhttp.Logger interface.
+type klogErrorLogger struct{}
+
+func (klogErrorLogger) Println(v ...interface{}) {
+	klog.Error(v...)
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+func TestPrometheusExporter(t *testing.T) {
+	counter := stats.Int64("test_prometheus_operations", "test counter", stats.UnitDimensionless)
+	gauge := stats.Int64("test_prometheus_last_value", "test gauge", stats.UnitDimensionless)
+	duration := stats.Float64("test_prometheus_duration_seconds", "test histogram", stats.UnitSeconds)
+	views := []*view.View{
+		{
+			Name:        counter.Name() + "_total",
+			Measure:     counter,
+			Description: "The total number of test operations",
+			TagKeys:     []tag.Key{KeyOperation, KeyStatus},
+			Aggregation: view.Count(),
+		},
+		{
+			Name:        gauge.Name(),
+			Measure:     gauge,
+			Description: "The last test value",
+			TagKeys:     []tag.Key{KeyCommit},
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        duration.Name(),
+			Measure:     duration,
+			Description: "The test latency distribution",
+			Aggregation: view.Distribution(1, 5),
+		},
+	}
+	require.NoError(t, view.Register(views...))
+	t.Cleanup(func() { view.Unregister(views...) })
+
+	ctx, err := tag.New(context.Background(),
+		tag.Upsert(KeyOperation, "update"),
+		tag.Upsert(KeyStatus, StatusSuccess),
+		tag.Upsert(KeyCommit, "abc123"))
+	require.NoError(t, err)
+	stats.Record(ctx, counter.M(1))
+	stats.Record(ctx, counter.M(1))
+	stats.Record(ctx, gauge.M(42))
+	stats.Record(ctx, duration.M(0.5), duration.M(2), duration.M(10))
+
+	pe := NewPrometheusExporter(map[string]string{
+		"configsync.sync.kind":     "RootSync",
+		ResourceKeyContainerName:   "reconciler",
+		ResourceKeySyncName.Name(): "",
+	})
+	handler, err := pe.Handler()
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	got := string(body)
+
+	for _, want := range []string{
+		`# TYPE config_sync_test_prometheus_operations_total counter`,
+		`config_sync_test_prometheus_operations_total{configsync_sync_kind="RootSync",k8s_container_name="reconciler",operation="update",status="success"} 2`,
+		`# TYPE config_sync_test_prometheus_last_value gauge`,
+		`config_sync_test_prometheus_last_value{commit="abc123",configsync_sync_kind="RootSync",k8s_container_name="reconciler"} 42`,
+		`# TYPE config_sync_test_prometheus_duration_seconds histogram`,
+		`config_sync_test_prometheus_duration_seconds_bucket{configsync_sync_kind="RootSync",k8s_container_name="reconciler",le="1"} 1`,
+		`config_sync_test_prometheus_duration_seconds_bucket{configsync_sync_kind="RootSync",k8s_container_name="reconciler",le="5"} 2`,
+		`config_sync_test_prometheus_duration_seconds_bucket{configsync_sync_kind="RootSync",k8s_container_name="reconciler",le="+Inf"} 3`,
+		`config_sync_test_prometheus_duration_seconds_count{configsync_sync_kind="RootSync",k8s_container_name="reconciler"} 3`,
+	} {
+		require.Contains(t, got, want)
+	}
+	// Empty resource attributes are omitted.
+	require.NotContains(t, got, ResourceKeySyncName.Name())
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	testCases := map[string]string{
+		"reconciler_errors":        "reconciler_errors",
+		"configsync.sync.kind":     "configsync_sync_kind",
+		"kustomize/resource-count": "kustomize_resource_count",
+		"3rd_party":                "key_3rd_party",
+		"":                         "",
+	}
+	for in, want := range testCases {
+		require.Equal(t, want, sanitizePrometheusName(in), "sanitizePrometheusName(%q)", in)
+	}
+}
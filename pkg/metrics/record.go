@@ -108,6 +108,14 @@ func RecordDeclaredResources(ctx context.Context, commit string, numResources in
 	record(tagCtx, measurement)
 }
 
+// RecordIgnoredFiles produces a measurement for the IgnoredFiles view.
+func RecordIgnoredFiles(ctx context.Context, commit string, numFiles int) {
+	tagCtx, _ := tag.New(ctx,
+		tag.Upsert(KeyCommit, commit))
+	measurement := IgnoredFiles.M(int64(numFiles))
+	record(tagCtx, measurement)
+}
+
 // RecordApplyOperation produces a measurement for the ApplyOperations view.
 func RecordApplyOperation(ctx context.Context, controller, operation, status string) {
 	tagCtx, _ := tag.New(ctx,
@@ -15,12 +15,52 @@
 package metrics
 
 import (
+	"fmt"
 	"os"
 
 	"contrib.go.opencensus.io/exporter/ocagent"
 	"go.opencensus.io/stats/view"
 )
 
+const (
+	// OtelAgentExporter is the metrics exporter which forwards metrics to the
+	// otel-agent sidecar, which forwards them to the otel-collector.
+	OtelAgentExporter = "otel-agent"
+
+	// PrometheusExporterType is the metrics exporter which serves metrics
+	// directly on a Prometheus scrape endpoint, without the otel-agent sidecar.
+	PrometheusExporterType = "prometheus"
+)
+
+// Exporter is a registered metrics exporter.
+type Exporter interface {
+	// Stop flushes or stops serving metrics.
+	Stop() error
+}
+
+// RegisterExporter creates the metrics exporter of the specified type.
+// The prometheusAddr and resourceLabels are only used by the Prometheus
+// exporter, because the otel-agent adds the resource attributes itself.
+func RegisterExporter(exporterType, containerName, prometheusAddr string, resourceLabels map[string]string) (Exporter, error) {
+	switch exporterType {
+	case "", OtelAgentExporter:
+		oce, err := RegisterOCAgentExporter(containerName)
+		if err != nil {
+			return nil, err
+		}
+		return oce, nil
+	case PrometheusExporterType:
+		pe, err := RegisterPrometheusExporter(prometheusAddr, containerName, resourceLabels)
+		if err != nil {
+			return nil, err
+		}
+		return pe, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q: must be %s or %s",
+			exporterType, OtelAgentExporter, PrometheusExporterType)
+	}
+}
+
 // RegisterOCAgentExporter creates the OC Agent metrics exporter.
 func RegisterOCAgentExporter(containerName string) (*ocagent.Exporter, error) {
 	// Add the k8s.container.name resource label so that the google cloud monitoring
@@ -56,6 +96,7 @@ func RegisterReconcilerMetricsViews() error {
 		LastApplyTimestampView,
 		LastSyncTimestampView,
 		DeclaredResourcesView,
+		IgnoredFilesView,
 		ApplyOperationsView,
 		ApplyDurationView,
 		ResourceFightsView,
@@ -92,6 +92,15 @@ var (
 		Aggregation: view.LastValue(),
 	}
 
+	// IgnoredFilesView aggregates the IgnoredFiles metric measurements.
+	IgnoredFilesView = &view.View{
+		Name:        IgnoredFiles.Name(),
+		Measure:     IgnoredFiles,
+		Description: "The current number of files ignored in the sync directory",
+		TagKeys:     []tag.Key{KeyCommit},
+		Aggregation: view.LastValue(),
+	}
+
 	// ApplyOperationsView aggregates the ApplyOps metric measurements.
 	ApplyOperationsView = &view.View{
 		Name:        ApplyOperations.Name() + "_total",
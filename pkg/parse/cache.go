@@ -58,6 +58,11 @@ type cacheForCommit struct {
 	// declared resources.
 	applied bool
 
+	// resync indicates whether the applier must apply all the declared
+	// resources, rather than only the ones which changed since the last
+	// successful apply. It is set by a force-resync, as a safety net.
+	resync bool
+
 	// watchesUpdated indicates whether the remediator watches have been updated
 	// for the latest declared resources.
 	watchesUpdated bool
@@ -22,13 +22,18 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/discovery"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/admissionpolicy"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/reader"
@@ -37,14 +42,16 @@ import (
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/reposync"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util/compare"
 	utildiscovery "kpt.dev/configsync/pkg/util/discovery"
 	"kpt.dev/configsync/pkg/validate"
+	rawvalidate "kpt.dev/configsync/pkg/validate/raw/validate"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // NewNamespaceRunner creates a new runnable parser for parsing a Namespace repo.
-func NewNamespaceRunner(clusterName, syncName, reconcilerName string, scope declared.Scope, fileReader reader.Reader, c client.Client, pollingPeriod, resyncPeriod, retryPeriod, statusUpdatePeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Applier, rem remediator.Interface, renderingEnabled bool) (Parser, error) {
+func NewNamespaceRunner(clusterName, syncName, reconcilerName string, scope declared.Scope, fileReader reader.Reader, c client.Client, syncReader client.Reader, pollingPeriod, resyncPeriod, retryPeriod, statusUpdatePeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Applier, rem remediator.Interface, renderingEnabled bool, ignoreDifferences ignorefields.Rules, policyEnforcement configsync.PolicyEnforcement) (Parser, error) {
 	converter, err := declared.NewValueConverter(dc)
 	if err != nil {
 		return nil, err
@@ -67,11 +74,17 @@ func NewNamespaceRunner(clusterName, syncName, reconcilerName string, scope decl
 				resources:  resources,
 				applier:    app,
 				remediator: rem,
+				checkpoint: declared.NewCheckpoint(c, scope, syncName),
 			},
 			discoveryInterface: dc,
 			converter:          converter,
 			mux:                &sync.Mutex{},
 			renderingEnabled:   renderingEnabled,
+			ignoreDifferences:  ignoreDifferences,
+			policyEnforcement:  policyEnforcement,
+			syncReader:         syncReader,
+			refreshSource:      refreshSource,
+			validatedFiles:     validatedFilesOf(fileReader),
 		},
 		scope: scope,
 	}, nil
@@ -93,7 +106,7 @@ func (p *namespace) options() *opts {
 }
 
 // parseSource implements the Parser interface
-func (p *namespace) parseSource(_ context.Context, state sourceState) ([]ast.FileObject, status.MultiError) {
+func (p *namespace) parseSource(ctx context.Context, state sourceState) ([]ast.FileObject, status.MultiError) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
@@ -115,20 +128,42 @@ func (p *namespace) parseSource(_ context.Context, state sourceState) ([]ast.Fil
 	}
 
 	options := validate.Options{
-		ClusterName:    p.clusterName,
-		ReconcilerName: p.reconcilerName,
-		PolicyDir:      p.SyncDir,
-		PreviousCRDs:   crds,
-		BuildScoper:    builder,
-		Converter:      p.converter,
+		ClusterName:       p.clusterName,
+		ReconcilerName:    p.reconcilerName,
+		PolicyDir:         p.SyncDir,
+		PreviousCRDs:      crds,
+		BuildScoper:       builder,
+		Converter:         p.converter,
+		IgnoreDifferences: p.ignoreDifferences,
+	}
+	if p.validatedFiles != nil {
+		options.Validated = p.validatedFiles.Validated
 	}
 	options = OptionsForScope(options, p.scope)
+	policy, err := p.repoSyncPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	options.RepoSyncPolicy = policy
+	options.PolicyEnforcement = p.policyEnforcement
+	if p.policyEnforcement != configsync.PolicyEnforcementDisabled {
+		// The namespace reconciler may only read its own Namespace.
+		policyObjs, policyErr := admissionpolicy.ClusterObjects(ctx, p.client, string(p.scope))
+		if policyErr != nil {
+			return nil, status.APIServerError(policyErr, "failed to list the admission policies")
+		}
+		options.AdmissionPolicies = policyObjs
+	}
 
+	_, span := tracing.StartSpan(ctx, tracing.SpanValidateUnstructured,
+		trace.Int64Attribute(tracing.KeyObjectCount, int64(len(objs))))
 	objs, err = validate.Unstructured(objs, options)
+	tracing.EndSpan(span, err)
 
 	if status.HasBlockingErrors(err) {
 		return nil, err
 	}
+	p.setValidated(err)
 
 	// Duplicated with root.go.
 	e := addAnnotationsAndLabels(objs, p.scope, p.syncName, p.sourceContext(), state.commit)
@@ -139,6 +174,30 @@ func (p *namespace) parseSource(_ context.Context, state sourceState) ([]ast.Fil
 	return objs, err
 }
 
+// repoSyncPolicy returns the RepoSyncPolicy referenced by the Namespace of the
+// RepoSync, or nil if the Namespace does not reference one.
+func (p *namespace) repoSyncPolicy(ctx context.Context) (*v1beta1.RepoSyncPolicy, status.MultiError) {
+	ns := &corev1.Namespace{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: string(p.scope)}, ns); err != nil {
+		return nil, status.APIServerError(err, "failed to get the Namespace of the RepoSync")
+	}
+	name := ns.GetLabels()[metadata.RepoSyncPolicyLabel]
+	if name == "" {
+		return nil, nil
+	}
+	policy := &v1beta1.RepoSyncPolicy{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: name}, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, rawvalidate.RepoSyncPolicyNotFoundError(name, ns.Name)
+		}
+		return nil, status.APIServerError(err, "failed to get the RepoSyncPolicy of the Namespace")
+	}
+	if !rawvalidate.IsRepoSyncPolicyAllowed(policy, ns.Name) {
+		return nil, rawvalidate.RepoSyncPolicyNamespaceError(name, ns.Name)
+	}
+	return policy, nil
+}
+
 // setSourceStatus implements the Parser interface
 //
 // setSourceStatus sets the source status with a given source state and set of errors.  If errs is empty, all errors
@@ -219,6 +278,58 @@ func (p *namespace) setRequiresRendering(ctx context.Context, renderingRequired
 	return p.client.Patch(ctx, rs, client.MergeFrom(existing))
 }
 
+// getLastSyncedCommit implements the Parser interface
+func (p *namespace) getLastSyncedCommit(ctx context.Context) (string, error) {
+	rs := &v1beta1.RepoSync{}
+	if err := p.client.Get(ctx, reposync.ObjectKey(p.scope, p.syncName), rs); err != nil {
+		return "", status.APIServerError(err, "failed to get RepoSync for parser")
+	}
+	return rs.Status.LastSyncedCommit, nil
+}
+
+// getSyncControl implements the Parser interface
+func (p *namespace) getSyncControl(ctx context.Context) (syncControl, error) {
+	rs := &v1beta1.RepoSync{}
+	if err := p.getRSync(ctx, reposync.ObjectKey(p.scope, p.syncName), rs); err != nil {
+		return syncControl{}, status.APIServerError(err, "failed to get RepoSync for parser")
+	}
+	return syncControlFromObject(rs), nil
+}
+
+// setSyncControlStatus implements the Parser interface
+func (p *namespace) setSyncControlStatus(ctx context.Context, rsync client.Object, paused bool, trigger string, reapplied *reapplyResult) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	rs, ok := rsync.(*v1beta1.RepoSync)
+	if !ok {
+		rs = &v1beta1.RepoSync{}
+		if err := p.client.Get(ctx, reposync.ObjectKey(p.scope, p.syncName), rs); err != nil {
+			return status.APIServerError(err, "failed to get RepoSync")
+		}
+	}
+	var updated bool
+	if paused {
+		updated = reposync.SetPaused(rs, "SyncPaused", "Applying and remediating managed resources is paused")
+	} else {
+		updated = reposync.RemoveCondition(rs, v1beta1.RepoSyncPaused)
+	}
+	if trigger != "" && reposync.SetTriggered(rs, trigger) {
+		updated = true
+	}
+	if reapplied != nil && reposync.SetReapplied(rs, reapplied.token, reapplied.count) {
+		updated = true
+	}
+	if !updated {
+		// avoid unnecessary updates
+		return nil
+	}
+	if err := p.client.Status().Update(ctx, rs); err != nil {
+		return status.APIServerError(err, "failed to update RepoSync sync control status")
+	}
+	return nil
+}
+
 // setRenderingStatus implements the Parser interface
 func (p *namespace) setRenderingStatus(ctx context.Context, oldStatus, newStatus renderingStatus) error {
 	if oldStatus.equal(newStatus) {
@@ -16,14 +16,19 @@ package parse
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
+	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/filesystem"
+	"kpt.dev/configsync/pkg/importer/reader"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/util/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -38,6 +43,21 @@ type opts struct {
 	// status.
 	client client.Client
 
+	// syncReader reads the RSync from the cache of the controller-manager,
+	// which watches it for the finalizer, so that the sync control
+	// annotations are checked without a request to the API server. The
+	// client is used if it is nil, or until its cache is started.
+	syncReader client.Reader
+
+	// validatedFiles tracks the files which passed validation, if the reader
+	// of the parser caches the files. It is nil otherwise.
+	validatedFiles validatedFiles
+
+	// refreshSource requests the other containers of the reconciler Pod to
+	// fetch and render the source again, when a resync is requested. It is
+	// skipped if it is nil.
+	refreshSource func() error
+
 	// reconcilerName is the name of the reconciler resources, such as service
 	// account, service, deployment and etc.
 	reconcilerName string
@@ -75,6 +95,14 @@ type opts struct {
 	// running for this reconciler.
 	renderingEnabled bool
 
+	// ignoreDifferences are the fields of each kind which are removed from the
+	// declared objects, so that they are neither applied nor remediated.
+	ignoreDifferences ignorefields.Rules
+
+	// policyEnforcement specifies how the violations of the admission policies
+	// are reported. The policies are not evaluated if it is disabled.
+	policyEnforcement configsync.PolicyEnforcement
+
 	files
 	updater
 }
@@ -95,12 +123,60 @@ type Parser interface {
 	K8sClient() client.Client
 	// setRequiresRendering sets the requires-rendering annotation on the RSync
 	setRequiresRendering(ctx context.Context, renderingRequired bool) error
+	// getLastSyncedCommit returns the last synced commit in the RSync status
+	getLastSyncedCommit(ctx context.Context) (string, error)
+	// getSyncControl returns the sync control annotations of the RSync, read
+	// from the syncReader
+	getSyncControl(ctx context.Context) (syncControl, error)
+	// setSyncControlStatus sets the Paused, Triggered and Reapplied conditions
+	// on the RSync read by getSyncControl, or on the latest RSync if rsync is nil
+	setSyncControlStatus(ctx context.Context, rsync client.Object, paused bool, trigger string, reapplied *reapplyResult) error
+}
+
+// validatedFiles tracks the files which passed validation, so that the
+// validation of their objects can be skipped while they are unchanged.
+type validatedFiles interface {
+	// Validated returns true if the file of the object passed validation and
+	// is unchanged since.
+	Validated(obj ast.FileObject) bool
+	// SetValidated records that the files of the last parse passed validation.
+	SetValidated()
+}
+
+// validatedFilesOf returns the reader if it tracks the files which passed
+// validation, or nil.
+func validatedFilesOf(r reader.Reader) validatedFiles {
+	if v, ok := r.(validatedFiles); ok {
+		return v
+	}
+	return nil
+}
+
+// setValidated records that the files of the last parse passed validation, if
+// there are no errors.
+func (o *opts) setValidated(errs status.MultiError) {
+	if o.validatedFiles != nil && errs == nil {
+		o.validatedFiles.SetValidated()
+	}
 }
 
 func (o *opts) k8sClient() client.Client {
 	return o.client
 }
 
+// getRSync reads the RSync with the syncReader. The client is used until the
+// cache of the syncReader is started.
+func (o *opts) getRSync(ctx context.Context, key client.ObjectKey, rsync client.Object) error {
+	if o.syncReader != nil {
+		err := o.syncReader.Get(ctx, key, rsync)
+		var notStarted *cache.ErrCacheNotStarted
+		if !errors.As(err, &notStarted) {
+			return err
+		}
+	}
+	return o.client.Get(ctx, key, rsync)
+}
+
 func (o *opts) discoveryClient() discovery.ServerResourcer {
 	return o.discoveryInterface
 }
@@ -24,6 +24,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,12 +32,14 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/admissionpolicy"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
@@ -47,6 +50,7 @@ import (
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/rootsync"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util/compare"
 	utildiscovery "kpt.dev/configsync/pkg/util/discovery"
 	"kpt.dev/configsync/pkg/validate"
@@ -55,7 +59,7 @@ import (
 )
 
 // NewRootRunner creates a new runnable parser for parsing a Root repository.
-func NewRootRunner(clusterName, syncName, reconcilerName string, format filesystem.SourceFormat, fileReader reader.Reader, c client.Client, pollingPeriod, resyncPeriod, retryPeriod, statusUpdatePeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Applier, rem remediator.Interface, renderingEnabled bool, namespaceStrategy configsync.NamespaceStrategy) (Parser, error) {
+func NewRootRunner(clusterName, syncName, reconcilerName string, format filesystem.SourceFormat, fileReader reader.Reader, c client.Client, syncReader client.Reader, pollingPeriod, resyncPeriod, retryPeriod, statusUpdatePeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Applier, rem remediator.Interface, renderingEnabled bool, namespaceStrategy configsync.NamespaceStrategy, ignoreDifferences ignorefields.Rules, policyEnforcement configsync.PolicyEnforcement) (Parser, error) {
 	converter, err := declared.NewValueConverter(dc)
 	if err != nil {
 		return nil, err
@@ -78,11 +82,17 @@ func NewRootRunner(clusterName, syncName, reconcilerName string, format filesyst
 				resources:  resources,
 				applier:    app,
 				remediator: rem,
+				checkpoint: declared.NewCheckpoint(c, declared.RootReconciler, syncName),
 			},
 			discoveryInterface: dc,
 			converter:          converter,
 			mux:                &sync.Mutex{},
 			renderingEnabled:   renderingEnabled,
+			ignoreDifferences:  ignoreDifferences,
+			policyEnforcement:  policyEnforcement,
+			syncReader:         syncReader,
+			refreshSource:      refreshSource,
+			validatedFiles:     validatedFilesOf(fileReader),
 		},
 		sourceFormat:      format,
 		namespaceStrategy: namespaceStrategy,
@@ -109,7 +119,7 @@ func (p *root) options() *opts {
 }
 
 // parseSource implements the Parser interface
-func (p *root) parseSource(_ context.Context, state sourceState) ([]ast.FileObject, status.MultiError) {
+func (p *root) parseSource(ctx context.Context, state sourceState) ([]ast.FileObject, status.MultiError) {
 	wantFiles := state.files
 	if p.sourceFormat == filesystem.SourceFormatHierarchy {
 		// We're using hierarchical mode for the root repository, so ignore files
@@ -136,27 +146,46 @@ func (p *root) parseSource(_ context.Context, state sourceState) ([]ast.FileObje
 	}
 
 	options := validate.Options{
-		ClusterName:    p.clusterName,
-		ReconcilerName: p.reconcilerName,
-		PolicyDir:      p.SyncDir,
-		PreviousCRDs:   crds,
-		BuildScoper:    builder,
-		Converter:      p.converter,
+		ClusterName:       p.clusterName,
+		ReconcilerName:    p.reconcilerName,
+		PolicyDir:         p.SyncDir,
+		PreviousCRDs:      crds,
+		BuildScoper:       builder,
+		Converter:         p.converter,
+		IgnoreDifferences: p.ignoreDifferences,
+	}
+	if p.validatedFiles != nil {
+		options.Validated = p.validatedFiles.Validated
 	}
 	options = OptionsForScope(options, p.scope)
+	options.PolicyEnforcement = p.policyEnforcement
+	if p.policyEnforcement != configsync.PolicyEnforcementDisabled {
+		policyObjs, policyErr := admissionpolicy.ClusterObjects(ctx, p.client)
+		if policyErr != nil {
+			return nil, status.APIServerError(policyErr, "failed to list the admission policies")
+		}
+		options.AdmissionPolicies = policyObjs
+	}
 
 	if p.sourceFormat == filesystem.SourceFormatUnstructured {
 		if p.namespaceStrategy == configsync.NamespaceStrategyImplicit {
 			options.Visitors = append(options.Visitors, p.addImplicitNamespaces)
 		}
+		_, span := tracing.StartSpan(ctx, tracing.SpanValidateUnstructured,
+			trace.Int64Attribute(tracing.KeyObjectCount, int64(len(objs))))
 		objs, err = validate.Unstructured(objs, options)
+		tracing.EndSpan(span, err)
 	} else {
+		_, span := tracing.StartSpan(ctx, tracing.SpanValidateHierarchical,
+			trace.Int64Attribute(tracing.KeyObjectCount, int64(len(objs))))
 		objs, err = validate.Hierarchical(objs, options)
+		tracing.EndSpan(span, err)
 	}
 
 	if status.HasBlockingErrors(err) {
 		return nil, err
 	}
+	p.setValidated(err)
 
 	// Duplicated with namespace.go.
 	e := addAnnotationsAndLabels(objs, declared.RootReconciler, p.syncName, p.sourceContext(), state.commit)
@@ -279,6 +308,58 @@ func (p *root) setRequiresRendering(ctx context.Context, renderingRequired bool)
 	return p.client.Patch(ctx, rs, client.MergeFrom(existing))
 }
 
+// getLastSyncedCommit implements the Parser interface
+func (p *root) getLastSyncedCommit(ctx context.Context) (string, error) {
+	rs := &v1beta1.RootSync{}
+	if err := p.client.Get(ctx, rootsync.ObjectKey(p.syncName), rs); err != nil {
+		return "", status.APIServerError(err, "failed to get RootSync for parser")
+	}
+	return rs.Status.LastSyncedCommit, nil
+}
+
+// getSyncControl implements the Parser interface
+func (p *root) getSyncControl(ctx context.Context) (syncControl, error) {
+	rs := &v1beta1.RootSync{}
+	if err := p.getRSync(ctx, rootsync.ObjectKey(p.syncName), rs); err != nil {
+		return syncControl{}, status.APIServerError(err, "failed to get RootSync for parser")
+	}
+	return syncControlFromObject(rs), nil
+}
+
+// setSyncControlStatus implements the Parser interface
+func (p *root) setSyncControlStatus(ctx context.Context, rsync client.Object, paused bool, trigger string, reapplied *reapplyResult) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	rs, ok := rsync.(*v1beta1.RootSync)
+	if !ok {
+		rs = &v1beta1.RootSync{}
+		if err := p.client.Get(ctx, rootsync.ObjectKey(p.syncName), rs); err != nil {
+			return status.APIServerError(err, "failed to get RootSync")
+		}
+	}
+	var updated bool
+	if paused {
+		updated = rootsync.SetPaused(rs, "SyncPaused", "Applying and remediating managed resources is paused")
+	} else {
+		updated = rootsync.RemoveCondition(rs, v1beta1.RootSyncPaused)
+	}
+	if trigger != "" && rootsync.SetTriggered(rs, trigger) {
+		updated = true
+	}
+	if reapplied != nil && rootsync.SetReapplied(rs, reapplied.token, reapplied.count) {
+		updated = true
+	}
+	if !updated {
+		// avoid unnecessary updates
+		return nil
+	}
+	if err := p.client.Status().Update(ctx, rs); err != nil {
+		return status.APIServerError(err, "failed to update RootSync sync control status")
+	}
+	return nil
+}
+
 // setRenderingStatus implements the Parser interface
 func (p *root) setRenderingStatus(ctx context.Context, oldStatus, newStatus renderingStatus) error {
 	if oldStatus.equal(newStatus) {
@@ -25,7 +25,9 @@ import (
 	"github.com/pkg/errors"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/utils/pointer"
@@ -89,6 +91,10 @@ func (r *noOpRemediator) UpdateWatches(_ context.Context, _ map[schema.GroupVers
 	return nil
 }
 
+func (r *noOpRemediator) Refresh(_ context.Context, _ []client.Object) status.MultiError {
+	return nil
+}
+
 func (r *noOpRemediator) Errors() status.MultiError {
 	return nil
 }
@@ -920,6 +926,56 @@ func TestRoot_SourceAndSyncReconcilerErrorsMetricValidation(t *testing.T) {
 	}
 }
 
+func TestUpdater_CheckpointAfterApply(t *testing.T) {
+	testCases := []struct {
+		name           string
+		applyErrors    []status.Error
+		wantCheckpoint bool
+	}{
+		{
+			name:           "checkpoint saved after a successful apply",
+			wantCheckpoint: true,
+		},
+		{
+			name: "checkpoint not saved after a failed apply",
+			applyErrors: []status.Error{
+				applier.Error(errors.New("sync error")),
+			},
+			wantCheckpoint: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			fakeClient := syncertest.NewClient(t, core.Scheme)
+			u := &updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{},
+				applier:    &fakeApplier{errors: tc.applyErrors},
+				checkpoint: declared.NewCheckpoint(fakeClient, declared.RootReconciler, rootSyncName),
+			}
+			cache := &cacheForCommit{
+				source:      sourceState{commit: "abc123"},
+				objsToApply: []ast.FileObject{fake.Namespace("namespaces/foo")},
+			}
+			_ = u.Update(ctx, cache)
+
+			cm := &corev1.ConfigMap{}
+			key := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: declared.CheckpointName(rootReconcilerName, 0)}
+			err := fakeClient.Get(ctx, key, cm)
+			if tc.wantCheckpoint {
+				if err != nil {
+					t.Fatalf("failed to get the checkpoint ConfigMap: %v", err)
+				}
+			} else if !apierrors.IsNotFound(err) {
+				t.Errorf("want the checkpoint ConfigMap not to be saved, got error: %v", err)
+			}
+		})
+	}
+}
+
 func sortObjects(left, right client.Object) bool {
 	leftID := core.IDOf(left)
 	rightID := core.IDOf(right)
@@ -971,6 +1027,14 @@ func (a *fakeApplier) Apply(_ context.Context, objs []client.Object) (map[schema
 	return nil, errs
 }
 
+func (a *fakeApplier) ResetApplied() {}
+
+func (a *fakeApplier) Reapply(_ []core.ID) {}
+
+func (a *fakeApplier) Inventory(_ context.Context) (map[core.ID]struct{}, error) {
+	return nil, nil
+}
+
 func (a *fakeApplier) Errors() status.MultiError {
 	var errs status.MultiError
 	for _, e := range a.errors {
@@ -22,14 +22,17 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/commitstatus"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/hydrate"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util"
 	webhookconfiguration "kpt.dev/configsync/pkg/webhook/configuration"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,6 +44,9 @@ const (
 	triggerRetry              = "retry"
 	triggerManagementConflict = "managementConflict"
 	triggerWatchUpdate        = "watchUpdate"
+	triggerManual             = "manual"
+	triggerResume             = "resume"
+	triggerReapply            = "reapply"
 )
 
 const (
@@ -65,7 +71,9 @@ const (
 )
 
 // Run keeps checking whether a parse-apply-watch loop is necessary and starts a loop if needed.
-func Run(ctx context.Context, p Parser) {
+// If paused is true, the reconciler starts paused, as the remediator was
+// started paused, until the sync-paused annotation is removed.
+func Run(ctx context.Context, p Parser, paused bool) {
 	opts := p.options()
 	// Use timers, not tickers.
 	// Tickers can cause memory leaks and continuous execution, when execution
@@ -86,6 +94,10 @@ func Run(ctx context.Context, p Parser) {
 		backoff:     defaultBackoff(),
 		retryTimer:  retryTimer,
 		retryPeriod: opts.retryPeriod,
+		paused:      paused,
+	}
+	if opts.CommitStatus != nil {
+		go opts.CommitStatus.Run(ctx)
 	}
 	for {
 		select {
@@ -97,11 +109,17 @@ func Run(ctx context.Context, p Parser) {
 		// If the reconciler is in the process of reconciling a given commit, the resync won't
 		// happen until the ongoing reconciliation is done.
 		case <-resyncTimer.C:
+			if state.paused {
+				klog.V(3).Info("Skipping force-resync while paused")
+				resyncTimer.Reset(opts.resyncPeriod) // Schedule resync attempt
+				continue
+			}
 			klog.Infof("It is time for a force-resync")
 			// Reset the cache partially to make sure all the steps of a parse-apply-watch loop will run.
 			// The cached sourceState will not be reset to avoid reading all the source files unnecessarily.
 			// The cached needToRetry will not be reset to avoid resetting the backoff retries.
 			state.resetPartialCache()
+			state.cache.resync = true
 			run(ctx, p, triggerResync, state)
 
 			resyncTimer.Reset(opts.resyncPeriod) // Schedule resync attempt
@@ -112,8 +130,19 @@ func Run(ctx context.Context, p Parser) {
 		// Re-import declared resources from the filesystem (from git-sync).
 		// If the reconciler is in the process of reconciling a given commit, the re-import won't
 		// happen until the ongoing reconciliation is done.
+		// The sync control annotations are checked before each re-import.
 		case <-runTimer.C:
-			run(ctx, p, triggerReimport, state)
+			trigger := updateSyncControl(ctx, p, state)
+			if !state.paused {
+				if trigger == "" {
+					trigger = triggerReimport
+				}
+				run(ctx, p, trigger, state)
+				if trigger == triggerManual {
+					acknowledgeSyncTrigger(ctx, p, state)
+				}
+				acknowledgeReapply(ctx, p, state)
+			}
 
 			runTimer.Reset(opts.pollingPeriod) // Schedule re-import attempt
 			// we should not reset retryTimer under this `case` since it is not aware of the
@@ -123,7 +152,11 @@ func Run(ctx context.Context, p Parser) {
 		// Retry if there was an error, conflict, or any watches need to be updated.
 		case <-retryTimer.C:
 			var trigger string
-			if opts.managementConflict() {
+			if state.paused {
+				// Retries are skipped while paused, and resumed with a resync.
+				retryTimer.Reset(opts.retryPeriod)
+				continue
+			} else if opts.managementConflict() {
 				// Reset the cache partially to make sure all the steps of a parse-apply-watch loop will run.
 				// The cached sourceState will not be reset to avoid reading all the source files unnecessarily.
 				// The cached needToRetry will not be reset to avoid resetting the backoff retries.
@@ -183,11 +216,78 @@ func Run(ctx context.Context, p Parser) {
 	}
 }
 
+// verifyCommit returns a source error if the commit fails the signature
+// verification, so the reconciler keeps the last verified commit synced.
+// The last synced commit is only read from the RSync status until a commit
+// has been verified, so a verified commit needs no API call.
+func verifyCommit(ctx context.Context, p Parser, commit string, syncDir cmpath.Absolute) status.MultiError {
+	verifier := p.options().CommitVerifier
+	var lastSynced string
+	if verifier.LastVerified() == "" {
+		var err error
+		lastSynced, err = p.getLastSyncedCommit(ctx)
+		if err != nil {
+			return status.Append(nil, err)
+		}
+	}
+	if err := verifier.Verify(syncDir.OSPath(), commit, lastSynced); err != nil {
+		return status.SourceError.Wrap(err).Build()
+	}
+	return nil
+}
+
+// reportCommitStatus reports the outcome of syncing the commit, as recorded in
+// the status of the RootSync or RepoSync, to the Git provider.
+func reportCommitStatus(n *commitstatus.Notifier, state *reconcilerState, commit string) {
+	r := commitstatus.Report{Commit: commit}
+	var errs status.MultiError
+	switch {
+	case state.sourceStatus.commit == commit && state.sourceStatus.errs != nil:
+		r.Phase, r.State, errs = commitstatus.SourcePhase, commitstatus.Failure, state.sourceStatus.errs
+	case state.renderingStatus.commit == commit && state.renderingStatus.errs != nil:
+		r.Phase, r.State, errs = commitstatus.RenderingPhase, commitstatus.Failure, state.renderingStatus.errs
+	case state.renderingStatus.commit == commit && state.renderingStatus.message == RenderingInProgress:
+		r.Phase, r.State = commitstatus.RenderingPhase, commitstatus.Pending
+	case state.syncStatus.commit != commit || state.syncStatus.syncing:
+		r.Phase, r.State = commitstatus.SyncPhase, commitstatus.Pending
+	case state.syncStatus.errs != nil:
+		r.Phase, r.State, errs = commitstatus.SyncPhase, commitstatus.Failure, state.syncStatus.errs
+	default:
+		r.Phase, r.State = commitstatus.SyncPhase, commitstatus.Success
+	}
+	if errs != nil {
+		r.ErrorCount = len(errs.Errors())
+		r.Errors = status.ToCSE(errs)
+	}
+	n.Notify(r)
+}
+
 func run(ctx context.Context, p Parser, trigger string, state *reconcilerState) {
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanParseRun,
+		trace.StringAttribute(tracing.KeyTrigger, trigger),
+		trace.StringAttribute(tracing.KeySyncName, p.options().syncName),
+		trace.StringAttribute(tracing.KeyReconciler, p.options().reconcilerName))
+	// The cached errors are set when the checkpoint is invalidated and cleared
+	// when the checkpoint is updated.
+	defer func() { tracing.EndSpan(span, state.cache.errs) }()
+
 	var syncDir cmpath.Absolute
 	gs := sourceStatus{}
 	// pull the source commit and directory with retries within 5 minutes.
+	_, commitSpan := tracing.StartSpan(ctx, tracing.SpanReadSourceCommit)
 	gs.commit, syncDir, gs.errs = hydrate.SourceCommitAndDirWithRetry(util.SourceRetryBackoff, p.options().SourceType, p.options().SourceDir, p.options().SyncDir, p.options().reconcilerName)
+	commitSpan.AddAttributes(trace.StringAttribute(tracing.KeyCommit, gs.commit))
+	tracing.EndSpan(commitSpan, gs.errs)
+	span.AddAttributes(trace.StringAttribute(tracing.KeyCommit, gs.commit))
+	if p.options().CommitStatus != nil {
+		defer reportCommitStatus(p.options().CommitStatus, state, gs.commit)
+	}
+
+	// Reject the commit if its signature, or the signatures of the commits
+	// since the last synced commit, can't be verified.
+	if gs.errs == nil && p.options().CommitVerifier != nil {
+		gs.errs = verifyCommit(ctx, p, gs.commit, syncDir)
+	}
 
 	// If failed to fetch the source commit and directory, set `.status.source` to fail early.
 	// Otherwise, set `.status.rendering` before `.status.source` because the parser needs to
@@ -333,8 +433,14 @@ func read(ctx context.Context, p Parser, trigger string, state *reconcilerState,
 // parseHydrationState reads from the file path which the hydration-controller
 // container writes to. It checks if the hydrated files are ready and returns
 // a renderingStatus.
-func parseHydrationState(p Parser, srcState sourceState, hydrationStatus renderingStatus) (sourceState, renderingStatus) {
+func parseHydrationState(ctx context.Context, p Parser, srcState sourceState, hydrationStatus renderingStatus) (sourceState, renderingStatus) {
 	options := p.options()
+	_, span := tracing.StartSpan(ctx, tracing.SpanParseHydrationState,
+		trace.StringAttribute(tracing.KeyCommit, srcState.commit))
+	defer func() {
+		span.AddAttributes(trace.StringAttribute(tracing.KeyRenderingMessage, hydrationStatus.message))
+		tracing.EndSpan(span, hydrationStatus.errs)
+	}()
 	if !options.renderingEnabled {
 		hydrationStatus.message = RenderingSkipped
 		return srcState, hydrationStatus
@@ -354,7 +460,7 @@ func parseHydrationState(p Parser, srcState sourceState, hydrationStatus renderi
 		srcState, hydrationErr = options.readHydratedDirWithRetry(util.HydratedRetryBackoff, absHydratedRoot, options.reconcilerName, srcState)
 		if hydrationErr != nil {
 			hydrationStatus.message = RenderingFailed
-			hydrationStatus.errs = status.HydrationError(hydrationErr.Code(), hydrationErr)
+			hydrationStatus.errs = hydrate.StatusErrors(hydrationErr)
 			return srcState, hydrationStatus
 		}
 		hydrationStatus.message = RenderingSucceeded
@@ -379,6 +485,8 @@ func parseHydrationState(p Parser, srcState sourceState, hydrationStatus renderi
 func readFromSource(ctx context.Context, p Parser, trigger string, recState *reconcilerState, srcState sourceState) (renderingStatus, sourceStatus) {
 	options := p.options()
 	start := time.Now()
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanReadFromSource,
+		trace.StringAttribute(tracing.KeyCommit, srcState.commit))
 
 	hydrationStatus := renderingStatus{
 		commit:            srcState.commit,
@@ -388,7 +496,11 @@ func readFromSource(ctx context.Context, p Parser, trigger string, recState *rec
 		commit: srcState.commit,
 	}
 
-	srcState, hydrationStatus = parseHydrationState(p, srcState, hydrationStatus)
+	defer func() {
+		tracing.EndSpan(span, status.Append(hydrationStatus.errs, srcStatus.errs))
+	}()
+
+	srcState, hydrationStatus = parseHydrationState(ctx, p, srcState, hydrationStatus)
 	if hydrationStatus.errs != nil {
 		return hydrationStatus, srcStatus
 	}
@@ -399,19 +511,32 @@ func readFromSource(ctx context.Context, p Parser, trigger string, recState *rec
 
 	// Read all the files under srcState.syncDir
 	srcStatus.errs = options.readConfigFiles(&srcState)
+	span.AddAttributes(trace.Int64Attribute(tracing.KeyFileCount, int64(len(srcState.files))))
 
 	if !options.renderingEnabled {
-		// Check if any kustomization files exist
+		// Check if any kustomization files or a Kptfile pipeline exist
+		requiresRendering := false
 		for _, fi := range srcState.files {
 			if hydrate.HasKustomization(path.Base(fi.OSPath())) {
-				// Source of truth requires hydration, but the hydration-controller is not running
-				hydrationStatus.message = RenderingRequired
-				hydrationStatus.requiresRendering = true
-				err := hydrate.NewTransientError(fmt.Errorf("sync source contains dry configs and hydration-controller is not running"))
-				hydrationStatus.errs = status.HydrationError(err.Code(), err)
-				return hydrationStatus, srcStatus
+				requiresRendering = true
+				break
 			}
 		}
+		if !requiresRendering && srcStatus.errs == nil {
+			if hasPipeline, err := hydrate.HasPipeline(srcState.syncDir.OSPath()); err != nil {
+				klog.Warningf("Failed to check the %s pipeline in %s: %v", hydrate.Kptfile, srcState.syncDir.OSPath(), err)
+			} else {
+				requiresRendering = hasPipeline
+			}
+		}
+		if requiresRendering {
+			// Source of truth requires hydration, but the hydration-controller is not running
+			hydrationStatus.message = RenderingRequired
+			hydrationStatus.requiresRendering = true
+			err := hydrate.NewTransientError(fmt.Errorf("sync source contains dry configs and hydration-controller is not running"))
+			hydrationStatus.errs = status.HydrationError(err.Code(), err)
+			return hydrationStatus, srcStatus
+		}
 	}
 
 	klog.Infof("New source changes (%s) detected, reset the cache", srcState.syncDir.OSPath())
@@ -420,6 +545,7 @@ func readFromSource(ctx context.Context, p Parser, trigger string, recState *rec
 	if srcStatus.errs == nil {
 		// Set `state.cache.source` after `readConfigFiles` succeeded
 		recState.cache.source = srcState
+		metrics.RecordIgnoredFiles(ctx, srcState.commit, srcState.ignoredFiles)
 	}
 	metrics.RecordParserDuration(ctx, trigger, "read", metrics.StatusTagKey(srcStatus.errs), start)
 	return hydrationStatus, srcStatus
@@ -431,12 +557,20 @@ func parseSource(ctx context.Context, p Parser, trigger string, state *reconcile
 	}
 
 	start := time.Now()
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanParseSource,
+		trace.StringAttribute(tracing.KeyCommit, state.cache.source.commit),
+		trace.Int64Attribute(tracing.KeyFileCount, int64(len(state.cache.source.files))))
 	objs, sourceErrs := p.parseSource(ctx, state.cache.source)
+	span.AddAttributes(trace.Int64Attribute(tracing.KeyObjectCount, int64(len(objs))))
+	tracing.EndSpan(span, sourceErrs)
 	metrics.RecordParserDuration(ctx, trigger, "parse", metrics.StatusTagKey(sourceErrs), start)
 	state.cache.setParserResult(objs, sourceErrs)
 
 	if !status.HasBlockingErrors(sourceErrs) {
+		_, webhookSpan := tracing.StartSpan(ctx, tracing.SpanUpdateWebhookConfiguration,
+			trace.Int64Attribute(tracing.KeyObjectCount, int64(len(objs))))
 		err := webhookconfiguration.Update(ctx, p.options().k8sClient(), p.options().discoveryClient(), objs)
+		tracing.EndSpan(webhookSpan, err)
 		if err != nil {
 			// Don't block if updating the admission webhook fails.
 			// Return an error instead if we remove the remediator as otherwise we
@@ -484,7 +618,11 @@ func parseAndUpdate(ctx context.Context, p Parser, trigger string, state *reconc
 
 	klog.V(3).Info("Updater starting...")
 	start := time.Now()
-	syncErrs := p.options().Update(ctx, &state.cache)
+	updateCtx, span := tracing.StartSpan(ctx, tracing.SpanUpdate,
+		trace.StringAttribute(tracing.KeyCommit, state.cache.source.commit),
+		trace.Int64Attribute(tracing.KeyObjectCount, int64(len(state.cache.objsToApply))))
+	syncErrs := p.options().Update(updateCtx, &state.cache)
+	tracing.EndSpan(span, syncErrs)
 	metrics.RecordParserDuration(ctx, trigger, "update", metrics.StatusTagKey(syncErrs), start)
 	klog.V(3).Info("Updater stopped")
 
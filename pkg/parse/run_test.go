@@ -16,7 +16,10 @@ package parse
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"sync"
@@ -25,9 +28,11 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/trace"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/commitstatus"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/hydrate"
@@ -42,6 +47,8 @@ import (
 	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"kpt.dev/configsync/pkg/testing/openapitest"
+	"kpt.dev/configsync/pkg/testing/testtracing"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util"
 	"sigs.k8s.io/cli-utils/pkg/testutil"
 )
@@ -400,3 +407,151 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunTracing(t *testing.T) {
+	exporter := testtracing.RegisterExporter(t)
+
+	rootDir := t.TempDir()
+	sourceRoot := filepath.Join(rootDir, "source")
+	sourceCommit := "abcd123"
+	if err := createRootDir(sourceRoot, sourceCommit); err != nil {
+		t.Fatal(err)
+	}
+	fs := FileSource{
+		SourceDir:    cmpath.Absolute(filepath.Join(sourceRoot, symLink)),
+		RepoRoot:     cmpath.Absolute(rootDir),
+		HydratedRoot: filepath.Join(rootDir, "hydrated"),
+		HydratedLink: symLink,
+		SourceType:   v1beta1.GitSource,
+		SourceRepo:   "https://github.com/test/test.git",
+		SourceBranch: "main",
+	}
+	parser := newParser(t, fs, false)
+	state := &reconcilerState{
+		backoff:     defaultBackoff(),
+		retryTimer:  time.NewTimer(configsync.DefaultReconcilerRetryPeriod),
+		retryPeriod: configsync.DefaultReconcilerRetryPeriod,
+	}
+	run(context.Background(), parser, triggerReimport, state)
+	assert.Nil(t, state.cache.errs)
+
+	// Spans are exported when they end, so children come before parents.
+	assert.Equal(t, []string{
+		tracing.SpanReadSourceCommit,
+		tracing.SpanParseHydrationState,
+		tracing.SpanReadFromSource,
+		tracing.SpanValidateUnstructured,
+		tracing.SpanParseSource,
+		tracing.SpanUpdateWebhookConfiguration,
+		tracing.SpanUpdateDeclared,
+		tracing.SpanUpdateWatches,
+		tracing.SpanUpdate,
+		tracing.SpanParseRun,
+	}, exporter.SpanNames())
+
+	runSpan := exporter.Span(tracing.SpanParseRun)
+	assert.Equal(t, map[string]interface{}{
+		tracing.KeyTrigger:    triggerReimport,
+		tracing.KeySyncName:   rootSyncName,
+		tracing.KeyReconciler: rootReconcilerName,
+		tracing.KeyCommit:     sourceCommit,
+	}, runSpan.Attributes)
+	assert.Equal(t, int32(trace.StatusCodeOK), runSpan.Status.Code)
+	for _, sd := range exporter.Spans() {
+		assert.Equal(t, runSpan.TraceID, sd.TraceID, "span %q should be in the same trace", sd.Name)
+	}
+	assert.Equal(t, runSpan.SpanID, exporter.Span(tracing.SpanParseSource).ParentSpanID)
+	assert.Equal(t, exporter.Span(tracing.SpanParseSource).SpanID, exporter.Span(tracing.SpanValidateUnstructured).ParentSpanID)
+	assert.Equal(t, exporter.Span(tracing.SpanUpdate).SpanID, exporter.Span(tracing.SpanUpdateDeclared).ParentSpanID)
+}
+
+func TestReportCommitStatus(t *testing.T) {
+	const commit = "abc123"
+	syncErr := status.APIServerError(fmt.Errorf("apply failed"), "failed to apply")
+	testCases := []struct {
+		name           string
+		state          *reconcilerState
+		wantPhase      commitstatus.Phase
+		wantState      commitstatus.State
+		wantErrorCount int
+	}{
+		{
+			name: "source error",
+			state: &reconcilerState{
+				sourceStatus: sourceStatus{commit: commit, errs: status.SourceError.Sprint("bad commit").Build()},
+			},
+			wantPhase:      commitstatus.SourcePhase,
+			wantState:      commitstatus.Failure,
+			wantErrorCount: 1,
+		},
+		{
+			name: "rendering in progress",
+			state: &reconcilerState{
+				renderingStatus: renderingStatus{commit: commit, message: RenderingInProgress},
+			},
+			wantPhase: commitstatus.RenderingPhase,
+			wantState: commitstatus.Pending,
+		},
+		{
+			name: "syncing",
+			state: &reconcilerState{
+				sourceStatus: sourceStatus{commit: commit},
+				syncStatus:   syncStatus{commit: commit, syncing: true},
+			},
+			wantPhase: commitstatus.SyncPhase,
+			wantState: commitstatus.Pending,
+		},
+		{
+			name: "sync errors",
+			state: &reconcilerState{
+				sourceStatus: sourceStatus{commit: commit},
+				syncStatus:   syncStatus{commit: commit, errs: status.Append(syncErr, syncErr)},
+			},
+			wantPhase:      commitstatus.SyncPhase,
+			wantState:      commitstatus.Failure,
+			wantErrorCount: 2,
+		},
+		{
+			name: "synced",
+			state: &reconcilerState{
+				sourceStatus: sourceStatus{commit: commit},
+				syncStatus:   syncStatus{commit: commit},
+			},
+			wantPhase: commitstatus.SyncPhase,
+			wantState: commitstatus.Success,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reports := make(chan commitstatus.Report, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var report commitstatus.Report
+				if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+					t.Errorf("failed to decode the report: %v", err)
+				}
+				reports <- report
+			}))
+			defer server.Close()
+			n, err := commitstatus.NewNotifier(v1beta1.WebhookProvider, server.URL, "", "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go n.Run(ctx)
+
+			reportCommitStatus(n, tc.state, commit)
+			select {
+			case got := <-reports:
+				if got.Commit != commit || got.Phase != tc.wantPhase || got.State != tc.wantState || got.ErrorCount != tc.wantErrorCount {
+					t.Errorf("got report %+v, want phase %s, state %s and %d error(s)", got, tc.wantPhase, tc.wantState, tc.wantErrorCount)
+				}
+				if len(got.Errors) != tc.wantErrorCount {
+					t.Errorf("got %d errors in the report, want %d", len(got.Errors), tc.wantErrorCount)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("timed out waiting for the report")
+			}
+		})
+	}
+}
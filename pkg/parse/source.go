@@ -25,8 +25,11 @@ import (
 	"k8s.io/klog/v2"
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/commitstatus"
+	"kpt.dev/configsync/pkg/git"
 	"kpt.dev/configsync/pkg/hydrate"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/importer/filesystem/ignore"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/status"
@@ -53,6 +56,17 @@ type FileSource struct {
 	SourceBranch string
 	// SourceRev is the revision of the source repo to sync.
 	SourceRev string
+	// SyncInclude is the list of patterns of the source files to sync. If
+	// empty, all the source files are synced.
+	SyncInclude []string
+	// SyncExclude is the list of patterns of the source files not to sync.
+	SyncExclude []string
+	// CommitVerifier verifies the signatures of the synced Git commits. If
+	// nil, the signatures are not verified.
+	CommitVerifier *git.Verifier
+	// CommitStatus reports the outcome of syncing each commit to the Git
+	// provider. If nil, the outcome is not reported.
+	CommitStatus *commitstatus.Notifier
 }
 
 // files lists files in a repository and ensures the source repository hasn't been
@@ -73,6 +87,11 @@ type sourceState struct {
 	syncDir cmpath.Absolute
 	// files is the list of all observed files in the sync directory (recursively).
 	files []cmpath.Absolute
+	// rendered is true if syncDir contains the hydrated files.
+	rendered bool
+	// ignoredFiles is the number of files in the sync directory which are
+	// ignored, because of the ignore file or the include and exclude patterns.
+	ignoredFiles int
 }
 
 // readConfigFiles reads all the files under state.syncDir and sets state.files.
@@ -91,9 +110,17 @@ func (o *files) readConfigFiles(state *sourceState) status.Error {
 		o.currentSyncDir = syncDir.OSPath()
 	}
 
-	var fileList []cmpath.Absolute
-	var err error
-	fileList, err = listFiles(syncDir, map[string]bool{".git": true})
+	// The include and exclude patterns only apply to the source files. The
+	// hydration-controller renders the configs without the excluded files.
+	var include, exclude []string
+	if !state.rendered {
+		include, exclude = o.SyncInclude, o.SyncExclude
+	}
+	matcher, err := ignore.NewMatcher(syncDir, include, exclude)
+	if err != nil {
+		return status.PathWrapError(errors.Wrap(err, "reading the ignore file in the configs directory"), syncDir.OSPath())
+	}
+	fileList, ignoredFiles, err := matcher.ListFiles()
 	if err != nil {
 		return status.PathWrapError(errors.Wrap(err, "listing files in the configs directory"), syncDir.OSPath())
 	}
@@ -106,6 +133,7 @@ func (o *files) readConfigFiles(state *sourceState) status.Error {
 	}
 
 	state.files = fileList
+	state.ignoredFiles = ignoredFiles
 	return nil
 }
 
@@ -172,38 +200,11 @@ func (o *files) readHydratedDir(hydratedRoot cmpath.Absolute, reconciler string,
 			return result, util.NewRetriableError(fmt.Errorf("failed to evaluate symbolic link to the hydrated sync directory %s: %v", relSyncDir.OSPath(), err))
 		}
 		result.syncDir = syncDir
+		result.rendered = true
 	}
 	return result, nil
 }
 
-// listFiles returns a list of all files in the specified directory.
-func listFiles(dir cmpath.Absolute, ignore map[string]bool) ([]cmpath.Absolute, error) {
-	var result []cmpath.Absolute
-	err := filepath.Walk(dir.OSPath(),
-		func(path string, fi os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if fi.IsDir() {
-				if _, contains := ignore[fi.Name()]; contains {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-			abs, err := cmpath.AbsoluteOS(path)
-			if err != nil {
-				return err
-			}
-			abs, err = abs.EvalSymlinks()
-			if err != nil {
-				return err
-			}
-			result = append(result, abs)
-			return nil
-		})
-	return result, err
-}
-
 // hydratedError returns the error details from the error file generated by the hydration controller.
 func hydratedError(errorFile, label string) hydrate.HydrationError {
 	content, err := os.ReadFile(errorFile)
@@ -220,6 +221,9 @@ func hydratedError(errorFile, label string) hydrate.HydrationError {
 	if err := json.Unmarshal(content, payload); err != nil {
 		return hydrate.NewInternalError(err)
 	}
+	if len(payload.Results) > 0 {
+		return hydrate.NewFunctionError(errors.New(payload.Error), payload.Results)
+	}
 	if payload.Code == status.ActionableHydrationErrorCode {
 		return hydrate.NewActionableError(errors.New(payload.Error))
 	}
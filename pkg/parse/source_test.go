@@ -277,8 +277,9 @@ func TestReadHydratedDirWithRetry(t *testing.T) {
 			}
 
 			wantState := sourceState{
-				commit:  tc.commit,
-				syncDir: cmpath.Absolute(filepath.Join(parserCommitDir, syncDir)),
+				commit:   tc.commit,
+				syncDir:  cmpath.Absolute(filepath.Join(parserCommitDir, syncDir)),
+				rendered: true,
 			}
 
 			t.Logf("start calling readHydratedDirWithRetry at %v", time.Now())
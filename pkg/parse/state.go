@@ -85,6 +85,19 @@ type reconcilerState struct {
 
 	retryTimer *time.Timer
 
+	// paused is true if applying and remediating managed resources is paused
+	// by the sync-paused annotation.
+	paused bool
+
+	// syncTrigger is the token of the last handled sync-trigger annotation.
+	syncTrigger string
+
+	// reapplyRequest is the last handled reapply annotation.
+	reapplyRequest string
+	// reapplied is the result of the reapply request handled by the current
+	// run, until it is reported in the Reapplied condition.
+	reapplied *reapplyResult
+
 	retryPeriod time.Duration
 }
 
@@ -0,0 +1,206 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/reapply"
+	"kpt.dev/configsync/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// procRoot is the directory listing the processes of the reconciler Pod, which
+// share their process namespace.
+const procRoot = "/proc"
+
+// syncControl is the sync control requested with annotations on the RSync,
+// usually set by `nomos sync`.
+type syncControl struct {
+	// paused is true if applying and remediating managed resources should be
+	// paused.
+	paused bool
+	// trigger is the token of the latest requested resync, if any.
+	trigger string
+	// reapply is the latest reapply request, if any.
+	reapply string
+	// rsync is the RSync the annotations were read from. Its status is
+	// updated with the sync control status, so that no other read is needed.
+	rsync client.Object
+}
+
+// reapplyResult is the result of a reapply request, reported in the
+// Reapplied condition.
+type reapplyResult struct {
+	// token is the token of the reapply request.
+	token string
+	// count is the number of declared objects selected by the request.
+	count int
+}
+
+func syncControlFromObject(obj client.Object) syncControl {
+	return syncControl{
+		paused:  core.GetAnnotation(obj, metadata.SyncPausedAnnotationKey) == "true",
+		trigger: core.GetAnnotation(obj, metadata.SyncTriggerAnnotationKey),
+		reapply: core.GetAnnotation(obj, metadata.ReapplyAnnotationKey),
+		rsync:   obj,
+	}
+}
+
+// Paused returns true if applying and remediating managed resources is paused
+// by the sync-paused annotation on the RSync. The reconciler checks it on
+// startup, before restoring the declared resources checkpoint and starting the
+// remediator.
+func Paused(ctx context.Context, p Parser) (bool, error) {
+	ctrl, err := p.getSyncControl(ctx)
+	if err != nil {
+		return false, err
+	}
+	return ctrl.paused, nil
+}
+
+// refreshSource sends the util.RefreshSignal to the processes of the other
+// containers of the reconciler Pod which fetch and render the source, so that
+// a requested resync syncs the latest commit of the source.
+func refreshSource() error {
+	count, err := util.SignalRefresh(procRoot)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Requested %d processes to fetch and render the source again", count)
+	return nil
+}
+
+// updateSyncControl reads the sync control annotations and updates the
+// reconciler state to match. It pauses or resumes the remediator, and resets
+// the cache if a resync is required. A requested resync also makes the source
+// be fetched and rendered again, and the new commit, if any, is synced once
+// it is ready.
+//
+// Returns the trigger of the resync to run, or an empty string if no resync is
+// required.
+func updateSyncControl(ctx context.Context, p Parser, state *reconcilerState) string {
+	ctrl, err := p.getSyncControl(ctx)
+	if err != nil {
+		klog.Warningf("failed to read sync control annotations: %v", err)
+		return ""
+	}
+	opts := p.options()
+
+	var trigger string
+	switch {
+	case ctrl.paused && !state.paused:
+		klog.Info("Pausing apply and remediation, as requested by the sync-paused annotation")
+		opts.remediator.Pause()
+		state.paused = true
+	case !ctrl.paused && state.paused:
+		klog.Info("Resuming apply and remediation, as requested by the sync-paused annotation")
+		opts.remediator.Resume()
+		state.paused = false
+		// Reset the cache partially to make sure the changes made while
+		// paused are reverted.
+		state.resetPartialCache()
+		trigger = triggerResume
+	}
+
+	// Resyncs requested while paused are deferred until the sync is resumed.
+	if !state.paused && ctrl.trigger != "" && ctrl.trigger != state.syncTrigger {
+		klog.Infof("Resync requested by the sync-trigger annotation (token: %s)", ctrl.trigger)
+		// Reset the cache partially to make sure all the steps of a parse-apply-watch loop will run.
+		// The cached sourceState will not be reset to avoid reading all the source files unnecessarily.
+		// The cached needToRetry will not be reset to avoid resetting the backoff retries.
+		state.resetPartialCache()
+		state.syncTrigger = ctrl.trigger
+		trigger = triggerManual
+		if opts.refreshSource != nil {
+			if err := opts.refreshSource(); err != nil {
+				klog.Warningf("failed to request a fetch of the source: %v", err)
+			}
+		}
+	}
+
+	// Reapply requests made while paused are also deferred.
+	if !state.paused && ctrl.reapply != "" && ctrl.reapply != state.reapplyRequest {
+		state.reapplyRequest = ctrl.reapply
+		if reapplyTrigger := handleReapply(ctx, p, state, ctrl.reapply); trigger == "" {
+			trigger = reapplyTrigger
+		}
+	}
+
+	if err := p.setSyncControlStatus(ctx, ctrl.rsync, state.paused, "", nil); err != nil {
+		klog.Warningf("failed to update sync control status: %v", err)
+	}
+	return trigger
+}
+
+// handleReapply marks the declared objects selected by the reapply request to
+// be reapplied, and refreshes their watches, so that the next
+// parse-apply-watch loop reapplies them and the remediator checks them again.
+//
+// Returns the trigger of the resync to run, or an empty string if the request
+// is invalid.
+func handleReapply(ctx context.Context, p Parser, state *reconcilerState, value string) string {
+	req, err := reapply.Parse(value)
+	if err != nil {
+		klog.Warningf("Ignoring the reapply annotation: %v", err)
+		return ""
+	}
+	opts := p.options()
+	declaredObjs, _ := opts.resources.DeclaredObjects()
+	var objs []client.Object
+	var ids []core.ID
+	for _, obj := range declaredObjs {
+		if id := core.IDOf(obj); req.Matches(id) {
+			objs = append(objs, obj)
+			ids = append(ids, id)
+		}
+	}
+	klog.Infof("Reapply requested by the reapply annotation (token: %s) for %s: %d objects selected", req.Token, req, len(objs))
+	opts.applier.Reapply(ids)
+	if errs := opts.remediator.Refresh(ctx, objs); errs != nil {
+		klog.Warningf("failed to refresh the watches of the objects to reapply: %v", errs)
+	}
+	// Reset the cache partially to make sure the apply step runs.
+	state.resetPartialCache()
+	state.reapplied = &reapplyResult{token: req.Token, count: len(objs)}
+	return triggerReapply
+}
+
+// acknowledgeSyncTrigger reports the handled sync-trigger token in the
+// Triggered condition, after the requested resync has run.
+func acknowledgeSyncTrigger(ctx context.Context, p Parser, state *reconcilerState) {
+	if err := p.setSyncControlStatus(ctx, nil, state.paused, state.syncTrigger, nil); err != nil {
+		klog.Warningf("failed to update sync control status: %v", err)
+		// Handle the trigger again next time, to retry the acknowledgement.
+		state.syncTrigger = ""
+	}
+}
+
+// acknowledgeReapply reports the handled reapply request in the Reapplied
+// condition, after the objects have been reapplied.
+func acknowledgeReapply(ctx context.Context, p Parser, state *reconcilerState) {
+	if state.reapplied == nil {
+		return
+	}
+	if err := p.setSyncControlStatus(ctx, nil, state.paused, "", state.reapplied); err != nil {
+		klog.Warningf("failed to update sync control status: %v", err)
+		// Handle the request again next time, to retry the acknowledgement.
+		state.reapplyRequest = ""
+	}
+	state.reapplied = nil
+}
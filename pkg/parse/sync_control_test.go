@@ -0,0 +1,205 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/reapply"
+	"kpt.dev/configsync/pkg/rootsync"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type pauseRecordingRemediator struct {
+	noOpRemediator
+	calls []string
+}
+
+func (r *pauseRecordingRemediator) Pause() {
+	r.calls = append(r.calls, "Pause")
+}
+
+func (r *pauseRecordingRemediator) Resume() {
+	r.calls = append(r.calls, "Resume")
+}
+
+type reapplyRecordingApplier struct {
+	fakeApplier
+	reapplied []core.ID
+}
+
+func (a *reapplyRecordingApplier) Reapply(ids []core.ID) {
+	a.reapplied = append(a.reapplied, ids...)
+}
+
+type refreshRecordingRemediator struct {
+	noOpRemediator
+	refreshed []core.ID
+}
+
+func (r *refreshRecordingRemediator) Refresh(_ context.Context, objs []client.Object) status.MultiError {
+	for _, obj := range objs {
+		r.refreshed = append(r.refreshed, core.IDOf(obj))
+	}
+	return nil
+}
+
+func TestUpdateSyncControl(t *testing.T) {
+	ctx := context.Background()
+	parser := newParser(t, FileSource{}, false)
+	rem := &pauseRecordingRemediator{}
+	parser.options().remediator = rem
+	refreshes := 0
+	parser.options().refreshSource = func() error {
+		refreshes++
+		return nil
+	}
+	c := parser.options().client
+	state := &reconcilerState{}
+
+	getRootSync := func() *v1beta1.RootSync {
+		rs := &v1beta1.RootSync{}
+		require.NoError(t, c.Get(ctx, rootsync.ObjectKey(rootSyncName), rs))
+		return rs
+	}
+	setAnnotation := func(key, value string) {
+		rs := getRootSync()
+		existing := rs.DeepCopy()
+		if value == "" {
+			core.RemoveAnnotations(rs, key)
+		} else {
+			core.SetAnnotation(rs, key, value)
+		}
+		require.NoError(t, c.Patch(ctx, rs, client.MergeFrom(existing)))
+	}
+
+	// No annotations
+	assert.Equal(t, "", updateSyncControl(ctx, parser, state))
+	assert.False(t, state.paused)
+	assert.Empty(t, rem.calls)
+
+	// Trigger a resync
+	setAnnotation(metadata.SyncTriggerAnnotationKey, "token-1")
+	state.cache.applied = true
+	assert.Equal(t, triggerManual, updateSyncControl(ctx, parser, state))
+	assert.False(t, state.cache.applied, "cache should be reset")
+	assert.Equal(t, 1, refreshes, "source should be fetched again")
+	assert.False(t, rootsync.IsTriggered(getRootSync(), "token-1"))
+	acknowledgeSyncTrigger(ctx, parser, state)
+	assert.True(t, rootsync.IsTriggered(getRootSync(), "token-1"))
+
+	// The same trigger is only handled once
+	state.cache.applied = true
+	assert.Equal(t, "", updateSyncControl(ctx, parser, state))
+	assert.True(t, state.cache.applied)
+	assert.Equal(t, 1, refreshes)
+
+	// Pause
+	setAnnotation(metadata.SyncPausedAnnotationKey, "true")
+	assert.Equal(t, "", updateSyncControl(ctx, parser, state))
+	assert.True(t, state.paused)
+	assert.Equal(t, []string{"Pause"}, rem.calls)
+	assert.True(t, rootsync.IsPaused(getRootSync()))
+
+	// Triggers are deferred while paused
+	setAnnotation(metadata.SyncTriggerAnnotationKey, "token-2")
+	assert.Equal(t, "", updateSyncControl(ctx, parser, state))
+	assert.Equal(t, []string{"Pause"}, rem.calls)
+	assert.Equal(t, 1, refreshes)
+
+	// Resume runs the deferred trigger
+	setAnnotation(metadata.SyncPausedAnnotationKey, "")
+	assert.Equal(t, triggerManual, updateSyncControl(ctx, parser, state))
+	assert.False(t, state.paused)
+	assert.Equal(t, []string{"Pause", "Resume"}, rem.calls)
+	assert.False(t, rootsync.IsPaused(getRootSync()))
+	assert.Equal(t, "token-2", state.syncTrigger)
+	assert.Equal(t, 2, refreshes)
+}
+
+func TestUpdateSyncControl_Reapply(t *testing.T) {
+	ctx := context.Background()
+	parser := newParser(t, FileSource{}, false)
+	app := &reapplyRecordingApplier{}
+	rem := &refreshRecordingRemediator{}
+	parser.options().applier = app
+	parser.options().remediator = rem
+	c := parser.options().client
+	state := &reconcilerState{}
+
+	cm := fake.ConfigMapObject(core.Name("cm"), core.Namespace("shop"))
+	role := fake.RoleObject(core.Name("role"), core.Namespace("shop"))
+	otherCM := fake.ConfigMapObject(core.Name("cm"), core.Namespace("billing"))
+	_, err := parser.options().resources.Update(ctx, []client.Object{cm, role, otherCM}, "abc123")
+	require.NoError(t, err)
+
+	getRootSync := func() *v1beta1.RootSync {
+		rs := &v1beta1.RootSync{}
+		require.NoError(t, c.Get(ctx, rootsync.ObjectKey(rootSyncName), rs))
+		return rs
+	}
+	request := func(req reapply.Request) {
+		value, err := req.Annotation()
+		require.NoError(t, err)
+		rs := getRootSync()
+		existing := rs.DeepCopy()
+		core.SetAnnotation(rs, metadata.ReapplyAnnotationKey, value)
+		require.NoError(t, c.Patch(ctx, rs, client.MergeFrom(existing)))
+	}
+
+	// Reapply a namespace
+	request(reapply.Request{Token: "token-1", Namespace: "shop"})
+	state.cache.applied = true
+	assert.Equal(t, triggerReapply, updateSyncControl(ctx, parser, state))
+	assert.False(t, state.cache.applied, "cache should be reset")
+	want := []core.ID{core.IDOf(cm), core.IDOf(role)}
+	assert.ElementsMatch(t, want, app.reapplied)
+	assert.ElementsMatch(t, want, rem.refreshed)
+	assert.False(t, rootsync.IsReapplied(getRootSync(), "token-1"))
+	acknowledgeReapply(ctx, parser, state)
+	assert.True(t, rootsync.IsReapplied(getRootSync(), "token-1"))
+
+	// The same request is only handled once
+	state.cache.applied = true
+	assert.Equal(t, "", updateSyncControl(ctx, parser, state))
+	assert.True(t, state.cache.applied)
+
+	// Reapply an object
+	app.reapplied, rem.refreshed = nil, nil
+	request(reapply.Request{Token: "token-2", Kind: "ConfigMap", Namespace: "billing", Name: "cm"})
+	assert.Equal(t, triggerReapply, updateSyncControl(ctx, parser, state))
+	assert.Equal(t, []core.ID{core.IDOf(otherCM)}, app.reapplied)
+	acknowledgeReapply(ctx, parser, state)
+	assert.True(t, rootsync.IsReapplied(getRootSync(), "token-2"))
+
+	// Invalid requests are ignored
+	app.reapplied = nil
+	rs := getRootSync()
+	existing := rs.DeepCopy()
+	core.SetAnnotation(rs, metadata.ReapplyAnnotationKey, `{"token":"token-3"}`)
+	require.NoError(t, c.Patch(ctx, rs, client.MergeFrom(existing)))
+	assert.Equal(t, "", updateSyncControl(ctx, parser, state))
+	assert.Empty(t, app.reapplied)
+	acknowledgeReapply(ctx, parser, state)
+	assert.False(t, rootsync.IsReapplied(getRootSync(), "token-3"))
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"go.opencensus.io/trace"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
@@ -29,6 +30,7 @@ import (
 	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/tracing"
 	"kpt.dev/configsync/pkg/util/clusterconfig"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -39,6 +41,9 @@ type updater struct {
 	resources  *declared.Resources
 	remediator remediator.Interface
 	applier    applier.Applier
+	// checkpoint stores the declared resources, so they can be restored after
+	// the reconciler restarts. Optional.
+	checkpoint *declared.Checkpoint
 
 	errorMux       sync.RWMutex
 	validationErrs status.MultiError
@@ -130,7 +135,7 @@ func (u *updater) declaredCRDs() ([]*v1beta1.CustomResourceDefinition, status.Mu
 // 1. Pauses the remediator
 // 2. Validates and sterilizes the objects
 // 3. Updates the declared resource objects in memory
-// 4. Applies the objects
+// 4. Applies the objects and checkpoints the declared resources
 // 5. Updates the remediator watches
 // 6. Restarts the remediator
 //
@@ -182,11 +187,17 @@ func (u *updater) update(ctx context.Context, cache *cacheForCommit) status.Mult
 
 	// Apply the declared resources
 	if !cache.applied {
+		if cache.resync {
+			u.applier.ResetApplied()
+		}
 		declaredObjs, _ := u.resources.DeclaredObjects()
 		_, err := u.apply(ctx, declaredObjs, cache.source.commit)
 		if err != nil {
 			return err
 		}
+		// Only checkpoint the declared resources once they have been applied,
+		// so that the remediator never restores objects which were not.
+		u.saveCheckpoint(ctx)
 		// Only mark the commit as applied if there were no (non-blocking) parse errors.
 		// This ensures the apply will be retried until parsing fully succeeds.
 		if cache.parserErrs == nil {
@@ -220,7 +231,11 @@ func (u *updater) update(ctx context.Context, cache *cacheForCommit) status.Mult
 
 func (u *updater) declare(ctx context.Context, objs []client.Object, commit string) ([]client.Object, status.MultiError) {
 	klog.V(1).Info("Declared resources updating...")
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanUpdateDeclared,
+		trace.StringAttribute(tracing.KeyCommit, commit),
+		trace.Int64Attribute(tracing.KeyObjectCount, int64(len(objs))))
 	objs, err := u.resources.Update(ctx, objs, commit)
+	tracing.EndSpan(span, err)
 	u.setValidationErrs(err)
 	if err != nil {
 		klog.Warningf("Failed to validate declared resources: %v", err)
@@ -230,6 +245,19 @@ func (u *updater) declare(ctx context.Context, objs []client.Object, commit stri
 	return objs, nil
 }
 
+// saveCheckpoint stores the declared resources, so they can be restored after
+// the reconciler restarts.
+func (u *updater) saveCheckpoint(ctx context.Context) {
+	if u.checkpoint == nil {
+		return
+	}
+	// The checkpoint is only an optimization for restarts, so failing to
+	// save it doesn't block the sync.
+	if err := u.checkpoint.Save(ctx, u.resources); err != nil {
+		klog.Warningf("Failed to checkpoint declared resources: %v", err)
+	}
+}
+
 func (u *updater) apply(ctx context.Context, objs []client.Object, commit string) (map[schema.GroupVersionKind]struct{}, status.MultiError) {
 	klog.V(1).Info("Applier starting...")
 	start := time.Now()
@@ -247,7 +275,10 @@ func (u *updater) apply(ctx context.Context, objs []client.Object, commit string
 // ones.
 func (u *updater) watch(ctx context.Context, gvks map[schema.GroupVersionKind]struct{}) status.MultiError {
 	klog.V(1).Info("Remediator watches updating...")
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanUpdateWatches,
+		trace.Int64Attribute(tracing.KeyGVKCount, int64(len(gvks))))
 	watchErrs := u.remediator.UpdateWatches(ctx, gvks)
+	tracing.EndSpan(span, watchErrs)
 	u.setWatchErrs(watchErrs)
 	if watchErrs != nil {
 		klog.Warningf("Failed to update resource watches: %v", watchErrs)
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reapply handles the requests to reapply some of the managed objects
+// of a RootSync or RepoSync, set with the reapply annotation, usually by
+// `nomos sync reapply`.
+package reapply
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/core"
+)
+
+// Request is a request to reapply the managed objects matching a selector.
+// The reconciler reapplies the declared objects which match all the non-empty
+// fields of the selector.
+type Request struct {
+	// Token identifies the request. The reconciler reports the token of the
+	// last handled request in the Reapplied condition of the RootSync or
+	// RepoSync.
+	Token string `json:"token"`
+	// Group is the group of the objects to reapply. It is only used along with
+	// Kind.
+	Group string `json:"group,omitempty"`
+	// Kind is the kind of the objects to reapply.
+	Kind string `json:"kind,omitempty"`
+	// Namespace is the namespace of the objects to reapply.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object to reapply. It requires Kind.
+	Name string `json:"name,omitempty"`
+}
+
+// Parse parses the value of the reapply annotation.
+func Parse(value string) (Request, error) {
+	var r Request
+	if err := json.Unmarshal([]byte(value), &r); err != nil {
+		return Request{}, errors.Wrap(err, "invalid reapply request")
+	}
+	if err := r.Validate(); err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// Validate returns an error if the request has no token or no selector.
+// Requests must select some objects, since reapplying all the objects is a
+// resync, which is requested with the sync-trigger annotation.
+func (r Request) Validate() error {
+	switch {
+	case r.Token == "":
+		return errors.New("invalid reapply request: missing token")
+	case r.Kind == "" && r.Namespace == "":
+		return errors.New("invalid reapply request: a kind or a namespace is required")
+	case r.Kind == "" && (r.Group != "" || r.Name != ""):
+		return errors.New("invalid reapply request: a group or a name requires a kind")
+	}
+	return nil
+}
+
+// Annotation returns the value of the reapply annotation for the request.
+func (r Request) Annotation() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Matches returns true if the object with the specified ID is selected by the
+// request.
+func (r Request) Matches(id core.ID) bool {
+	if r.Kind != "" && id.GroupKind != (schema.GroupKind{Group: r.Group, Kind: r.Kind}) {
+		return false
+	}
+	if r.Namespace != "" && id.Namespace != r.Namespace {
+		return false
+	}
+	if r.Name != "" && id.Name != r.Name {
+		return false
+	}
+	return true
+}
+
+// String returns a description of the selector of the request.
+func (r Request) String() string {
+	var parts []string
+	if r.Kind != "" {
+		parts = append(parts, "kind "+schema.GroupKind{Group: r.Group, Kind: r.Kind}.String())
+	}
+	if r.Namespace != "" {
+		parts = append(parts, fmt.Sprintf("namespace %q", r.Namespace))
+	}
+	if r.Name != "" {
+		parts = append(parts, fmt.Sprintf("name %q", r.Name))
+	}
+	return strings.Join(parts, ", ")
+}
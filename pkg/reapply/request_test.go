@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reapply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/core"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		want    Request
+		wantErr string
+	}{
+		{
+			name:  "object",
+			value: `{"token":"t1","group":"apps","kind":"Deployment","namespace":"shop","name":"web"}`,
+			want:  Request{Token: "t1", Group: "apps", Kind: "Deployment", Namespace: "shop", Name: "web"},
+		},
+		{
+			name:  "namespace",
+			value: `{"token":"t1","namespace":"shop"}`,
+			want:  Request{Token: "t1", Namespace: "shop"},
+		},
+		{
+			name:    "invalid JSON",
+			value:   `shop`,
+			wantErr: "invalid reapply request",
+		},
+		{
+			name:    "missing token",
+			value:   `{"kind":"ConfigMap"}`,
+			wantErr: "missing token",
+		},
+		{
+			name:    "missing selector",
+			value:   `{"token":"t1"}`,
+			wantErr: "a kind or a namespace is required",
+		},
+		{
+			name:    "name without kind",
+			value:   `{"token":"t1","namespace":"shop","name":"web"}`,
+			wantErr: "a group or a name requires a kind",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.value)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			value, err := got.Annotation()
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestRequest_Matches(t *testing.T) {
+	deployment := core.ID{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		ObjectKey: client.ObjectKey{Namespace: "shop", Name: "web"},
+	}
+	testCases := []struct {
+		name    string
+		request Request
+		want    bool
+	}{
+		{name: "object", request: Request{Group: "apps", Kind: "Deployment", Namespace: "shop", Name: "web"}, want: true},
+		{name: "kind", request: Request{Group: "apps", Kind: "Deployment"}, want: true},
+		{name: "namespace", request: Request{Namespace: "shop"}, want: true},
+		{name: "other group", request: Request{Kind: "Deployment"}, want: false},
+		{name: "other namespace", request: Request{Kind: "Deployment", Group: "apps", Namespace: "billing"}, want: false},
+		{name: "other name", request: Request{Kind: "Deployment", Group: "apps", Name: "api"}, want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.request.Matches(deployment))
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/applier"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/status"
@@ -278,7 +279,7 @@ func TestRepoSyncFinalize(t *testing.T) {
 				defer close(continueCh)
 				stopped = true
 			}
-			destroyFunc := func(context.Context) status.MultiError {
+			destroyFunc := func(context.Context, applier.DestroyProgressFunc) status.MultiError {
 				// Lookup the current RepoSync
 				key := client.ObjectKeyFromObject(repoSync1)
 				rsync := &v1beta1.RepoSync{}
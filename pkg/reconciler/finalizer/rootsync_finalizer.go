@@ -66,7 +66,7 @@ func (f *RootSyncFinalizer) Finalize(ctx context.Context, syncObj client.Object)
 	<-f.ControllersStopped
 	klog.Info("Finalizer executing: Parser & Remediator stopped")
 
-	if _, err := f.setFinalizingCondition(ctx, rs); err != nil {
+	if _, err := f.setFinalizingCondition(ctx, rs, "Deleting managed resource objects"); err != nil {
 		return errors.Wrap(err, "setting Finalizing condition")
 	}
 
@@ -132,10 +132,10 @@ func (f *RootSyncFinalizer) RemoveFinalizer(ctx context.Context, syncObj client.
 }
 
 // setFinalizingCondition sets the ReconcilerFinalizing condition on the
-// specified object.
-func (f *RootSyncFinalizer) setFinalizingCondition(ctx context.Context, syncObj *v1beta1.RootSync) (bool, error) {
+// specified object, with the specified message.
+func (f *RootSyncFinalizer) setFinalizingCondition(ctx context.Context, syncObj *v1beta1.RootSync, message string) (bool, error) {
 	updated, err := mutate.Status(ctx, f.Client, syncObj, func() error {
-		if !rootsync.SetReconcilerFinalizing(syncObj, "ResourcesDeleting", "Deleting managed resource objects") {
+		if !rootsync.SetReconcilerFinalizing(syncObj, "ResourcesDeleting", message) {
 			// Already removed. No change necessary.
 			return &mutate.NoUpdateError{}
 		}
@@ -175,8 +175,14 @@ func (f *RootSyncFinalizer) removeFinalizingCondition(ctx context.Context, syncO
 
 // deleteManagedObjects uses the destroyer to delete managed objects and then
 // updates the ReconcilerFinalizerFailure condition on the specified object.
+// The ReconcilerFinalizing condition reports the level of objects being
+// deleted.
 func (f *RootSyncFinalizer) deleteManagedObjects(ctx context.Context, syncObj *v1beta1.RootSync) error {
-	destroyErrs := f.Destroyer.Destroy(ctx)
+	destroyErrs := f.Destroyer.Destroy(ctx, func(p applier.DestroyProgress) {
+		if _, err := f.setFinalizingCondition(ctx, syncObj, p.String()); err != nil {
+			klog.Warningf("Failed to report the deletion progress: %v", err)
+		}
+	})
 	// Update the FinalizerFailure condition whether the destroy succeeded or failed
 	if _, updateErr := f.updateFailureCondition(ctx, syncObj, destroyErrs); updateErr != nil {
 		updateErr = errors.Wrap(updateErr, "updating FinalizerFailure condition")
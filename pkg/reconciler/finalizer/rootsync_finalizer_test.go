@@ -286,7 +286,7 @@ func TestRootSyncFinalize(t *testing.T) {
 				defer close(continueCh)
 				stopped = true
 			}
-			destroyFunc := func(context.Context) status.MultiError {
+			destroyFunc := func(context.Context, applier.DestroyProgressFunc) status.MultiError {
 				// Lookup the current RootSync
 				key := client.ObjectKeyFromObject(rootSync1)
 				rsync := &v1beta1.RootSync{}
@@ -330,6 +330,47 @@ func TestRootSyncFinalize(t *testing.T) {
 	}
 }
 
+func TestRootSyncFinalizeProgress(t *testing.T) {
+	rootSync1 := yamlToTypedObject(t, rootSync1Yaml).(*v1beta1.RootSync)
+	rootSync1.SetFinalizers([]string{
+		metadata.ReconcilerFinalizer,
+	})
+	fakeClient := fake.NewClient(t, scheme, rootSync1)
+
+	continueCh := make(chan struct{})
+	var messages []string
+	destroyFunc := func(_ context.Context, progress applier.DestroyProgressFunc) status.MultiError {
+		for _, p := range []applier.DestroyProgress{
+			{Level: 1, Levels: 2, Kinds: []string{"Deployment", "Service"}},
+			{Level: 2, Levels: 2, Kinds: []string{"Namespace"}},
+		} {
+			progress(p)
+			rsync := &v1beta1.RootSync{}
+			err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(rootSync1), rsync)
+			require.NoError(t, err)
+			for _, c := range rsync.Status.Conditions {
+				if c.Type == v1beta1.RootSyncReconcilerFinalizing {
+					messages = append(messages, c.Message)
+				}
+			}
+		}
+		return nil
+	}
+	finalizer := &RootSyncFinalizer{
+		Destroyer:          newFakeDestroyer(nil, destroyFunc),
+		Client:             fakeClient,
+		StopControllers:    func() { close(continueCh) },
+		ControllersStopped: continueCh,
+	}
+
+	err := finalizer.Finalize(context.Background(), rootSync1.DeepCopy())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Deleting level 1 of 2: Deployment, Service",
+		"Deleting level 2 of 2: Namespace",
+	}, messages)
+}
+
 func TestRootSyncAddFinalizer(t *testing.T) {
 	rootSync1 := yamlToTypedObject(t, rootSync1Yaml).(*v1beta1.RootSync)
 
@@ -638,21 +679,21 @@ func updateToRemoveFinalizers(ctx context.Context, fakeClient *fake.Client, obj
 
 type fakeDestroyer struct {
 	errs        status.MultiError
-	destroyFunc func(context.Context) status.MultiError
+	destroyFunc func(context.Context, applier.DestroyProgressFunc) status.MultiError
 }
 
 var _ applier.Destroyer = &fakeDestroyer{}
 
-func newFakeDestroyer(errs status.MultiError, destroyFunc func(context.Context) status.MultiError) *fakeDestroyer {
+func newFakeDestroyer(errs status.MultiError, destroyFunc func(context.Context, applier.DestroyProgressFunc) status.MultiError) *fakeDestroyer {
 	return &fakeDestroyer{
 		errs:        errs,
 		destroyFunc: destroyFunc,
 	}
 }
 
-func (d *fakeDestroyer) Destroy(ctx context.Context) status.MultiError {
+func (d *fakeDestroyer) Destroy(ctx context.Context, progress applier.DestroyProgressFunc) status.MultiError {
 	if d.destroyFunc != nil {
-		return d.destroyFunc(ctx)
+		return d.destroyFunc(ctx, progress)
 	}
 	return d.errs
 }
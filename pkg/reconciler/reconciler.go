@@ -26,8 +26,11 @@ import (
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
 	"kpt.dev/configsync/pkg/client/restconfig"
+	"kpt.dev/configsync/pkg/commitstatus"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/git"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/importer/reader"
@@ -101,12 +104,31 @@ type Options struct {
 	SourceType v1beta1.SourceType
 	// SyncDir is the relative path to the configurations in the source.
 	SyncDir cmpath.Relative
+	// SyncInclude is the list of patterns of the files to sync from SyncDir.
+	SyncInclude []string
+	// SyncExclude is the list of patterns of the files not to sync from SyncDir.
+	SyncExclude []string
+	// GitVerification verifies the signatures of the synced Git commits. If
+	// nil, the signatures are not verified.
+	GitVerification *git.Verifier
+	// CommitStatus reports the outcome of syncing each commit to the Git
+	// provider. If nil, the outcome is not reported.
+	CommitStatus *commitstatus.Notifier
 	// StatusMode controls the kpt applier to inject the actuation status data or not
 	StatusMode string
 	// ReconcileTimeout controls the reconcile/prune Timeout in kpt applier
 	ReconcileTimeout string
 	// APIServerTimeout is the client-side timeout used for talking to the API server
 	APIServerTimeout string
+	// ConflictPolicy is the default policy for the fields of the managed
+	// objects owned by other field managers.
+	ConflictPolicy configsync.ConflictPolicy
+	// PolicyEnforcement specifies how the violations of the admission policies
+	// are reported.
+	PolicyEnforcement configsync.PolicyEnforcement
+	// IgnoreDifferences are the fields of each kind which the reconciler
+	// neither applies nor remediates.
+	IgnoreDifferences ignorefields.Rules
 	// RenderingEnabled indicates whether the reconciler Pod is currently running
 	// with the hydration-controller.
 	RenderingEnabled bool
@@ -168,10 +190,7 @@ func Run(opts Options) {
 
 	// Configure the Applier.
 	genericClient := syncerclient.New(cl, metrics.APICallDuration)
-	baseApplier, err := reconcile.NewApplierForMultiRepo(cfg, genericClient)
-	if err != nil {
-		klog.Fatalf("Instantiating Applier: %v", err)
-	}
+	baseApplier := reconcile.NewApplierForMultiRepo(genericClient, opts.ConflictPolicy, opts.IgnoreDifferences)
 
 	reconcileTimeout, err := time.ParseDuration(opts.ReconcileTimeout)
 	if err != nil {
@@ -180,7 +199,7 @@ func Run(opts Options) {
 	if reconcileTimeout < 0 {
 		klog.Fatalf("Invalid reconcileTimeout: %v, timeout should not be negative", reconcileTimeout)
 	}
-	clientSet, err := applier.NewClientSet(cl, configFlags, opts.StatusMode)
+	clientSet, err := applier.NewClientSet(cl, configFlags, opts.StatusMode, opts.ConflictPolicy, opts.IgnoreDifferences)
 	if err != nil {
 		klog.Fatalf("Error creating clients: %v", err)
 	}
@@ -205,34 +224,6 @@ func Run(opts Options) {
 		klog.Fatalf("Instantiating Remediator: %v", err)
 	}
 
-	// Configure the Parser.
-	var parser parse.Parser
-	fs := parse.FileSource{
-		SourceDir:    opts.SourceRoot,
-		RepoRoot:     opts.RepoRoot,
-		HydratedRoot: opts.HydratedRoot,
-		HydratedLink: opts.HydratedLink,
-		SyncDir:      opts.SyncDir,
-		SourceType:   opts.SourceType,
-		SourceRepo:   opts.SourceRepo,
-		SourceBranch: opts.SourceBranch,
-		SourceRev:    opts.SourceRev,
-	}
-	if opts.ReconcilerScope == declared.RootReconciler {
-		parser, err = parse.NewRootRunner(opts.ClusterName, opts.SyncName, opts.ReconcilerName, opts.SourceFormat, &reader.File{}, cl,
-			opts.PollingPeriod, opts.ResyncPeriod, opts.RetryPeriod, opts.StatusUpdatePeriod, fs, discoveryClient, decls, supervisor, rem, opts.RenderingEnabled,
-			opts.NamespaceStrategy)
-		if err != nil {
-			klog.Fatalf("Instantiating Root Repository Parser: %v", err)
-		}
-	} else {
-		parser, err = parse.NewNamespaceRunner(opts.ClusterName, opts.SyncName, opts.ReconcilerName, opts.ReconcilerScope, &reader.File{}, cl,
-			opts.PollingPeriod, opts.ResyncPeriod, opts.RetryPeriod, opts.StatusUpdatePeriod, fs, discoveryClient, decls, supervisor, rem, opts.RenderingEnabled)
-		if err != nil {
-			klog.Fatalf("Instantiating Namespace Repository Parser: %v", err)
-		}
-	}
-
 	// Start listening to signals
 	signalCtx := signals.SetupSignalHandler()
 
@@ -259,6 +250,42 @@ func Run(opts Options) {
 		klog.Fatalf("Instantiating Controller Manager: %v", err)
 	}
 
+	// Configure the Parser.
+	// The Parser reads the sync control annotations of the RSync from the
+	// cache of the ControllerManager, which watches it for the Finalizer.
+	var parser parse.Parser
+	fs := parse.FileSource{
+		SourceDir:      opts.SourceRoot,
+		RepoRoot:       opts.RepoRoot,
+		HydratedRoot:   opts.HydratedRoot,
+		HydratedLink:   opts.HydratedLink,
+		SyncDir:        opts.SyncDir,
+		SourceType:     opts.SourceType,
+		SourceRepo:     opts.SourceRepo,
+		SourceBranch:   opts.SourceBranch,
+		SourceRev:      opts.SourceRev,
+		SyncInclude:    opts.SyncInclude,
+		SyncExclude:    opts.SyncExclude,
+		CommitVerifier: opts.GitVerification,
+		CommitStatus:   opts.CommitStatus,
+	}
+	// Only the files which changed are parsed again for each new commit.
+	fileReader := &reader.Cached{}
+	if opts.ReconcilerScope == declared.RootReconciler {
+		parser, err = parse.NewRootRunner(opts.ClusterName, opts.SyncName, opts.ReconcilerName, opts.SourceFormat, fileReader, cl, mgr.GetClient(),
+			opts.PollingPeriod, opts.ResyncPeriod, opts.RetryPeriod, opts.StatusUpdatePeriod, fs, discoveryClient, decls, supervisor, rem, opts.RenderingEnabled,
+			opts.NamespaceStrategy, opts.IgnoreDifferences, opts.PolicyEnforcement)
+		if err != nil {
+			klog.Fatalf("Instantiating Root Repository Parser: %v", err)
+		}
+	} else {
+		parser, err = parse.NewNamespaceRunner(opts.ClusterName, opts.SyncName, opts.ReconcilerName, opts.ReconcilerScope, fileReader, cl, mgr.GetClient(),
+			opts.PollingPeriod, opts.ResyncPeriod, opts.RetryPeriod, opts.StatusUpdatePeriod, fs, discoveryClient, decls, supervisor, rem, opts.RenderingEnabled, opts.IgnoreDifferences, opts.PolicyEnforcement)
+		if err != nil {
+			klog.Fatalf("Instantiating Namespace Repository Parser: %v", err)
+		}
+	}
+
 	// This cancelFunc will be used by the Finalizer to stop all the other
 	// controllers (Parser & Remediator).
 	ctx, stopControllers := context.WithCancel(signalCtx)
@@ -307,13 +334,37 @@ func Run(opts Options) {
 		}
 	}()
 
+	// Nothing is applied or remediated while the sync is paused, so neither the
+	// declared resources checkpoint is restored nor the Remediator started
+	// until the sync-paused annotation is removed.
+	paused, err := parse.Paused(ctx, parser)
+	if err != nil {
+		klog.Warningf("Failed to read the sync-paused annotation: %v", err)
+	}
+
+	// Restore the declared resources saved before the last restart, so the
+	// Remediator can resume before the Parser has finished the first sync.
+	if !paused {
+		checkpoint := declared.NewCheckpoint(cl, opts.ReconcilerScope, opts.SyncName)
+		if inventory, err := supervisor.Inventory(ctx); err != nil {
+			klog.Warningf("Failed to read the inventory to restore the declared resources checkpoint: %v", err)
+		} else if restored, err := checkpoint.Restore(ctx, decls, inventory); err != nil {
+			klog.Warningf("Failed to restore the declared resources checkpoint: %v", err)
+		} else if restored {
+			gvks, _ := decls.DeclaredGVKs()
+			if errs := rem.UpdateWatches(ctx, gvks); errs != nil {
+				klog.Warningf("Failed to start watches for the restored declared resources: %v", errs)
+			}
+		}
+	}
+
 	klog.Info("Starting Remediator")
 	// TODO: Convert the Remediator to use the controller-manager framework.
-	doneChanForRemediator := rem.Start(ctx) // non-blocking
+	doneChanForRemediator := rem.Start(ctx, paused) // non-blocking
 
 	klog.Info("Starting Parser")
 	// TODO: Convert the Parser to use the controller-manager framework.
-	parse.Run(ctx, parser) // blocks until ctx.Done()
+	parse.Run(ctx, parser, paused) // blocks until ctx.Done()
 	klog.Info("Parser exited")
 
 	// Wait for Remediator to exit
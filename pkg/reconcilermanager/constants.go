@@ -53,6 +53,40 @@ const (
 	// read by the hydration controller and the reconciler.
 	SyncDirKey = "SYNC_DIR"
 
+	// SyncIncludeKey is the OS env variable key for the comma-separated list
+	// of patterns of the files to sync from the sync directory.
+	SyncIncludeKey = "SYNC_INCLUDE"
+
+	// SyncExcludeKey is the OS env variable key for the comma-separated list
+	// of patterns of the files not to sync from the sync directory.
+	SyncExcludeKey = "SYNC_EXCLUDE"
+
+	// GitVerificationPolicyKey is the OS env variable key for the policy of
+	// the Git commit signature verification. If unset, the signatures are not
+	// verified.
+	GitVerificationPolicyKey = "GIT_VERIFICATION_POLICY"
+
+	// CommitStatusProviderKey is the OS env variable key for the Git provider
+	// which the outcome of syncing each commit is reported to. If unset, the
+	// outcome is not reported.
+	CommitStatusProviderKey = "COMMIT_STATUS_PROVIDER"
+
+	// CommitStatusURLKey is the OS env variable key for the base URL of the
+	// Git provider API, or the URL of the webhook.
+	CommitStatusURLKey = "COMMIT_STATUS_URL"
+
+	// CommitStatusProjectKey is the OS env variable key for the GitHub
+	// repository or the GitLab project of the commit statuses.
+	CommitStatusProjectKey = "COMMIT_STATUS_PROJECT"
+
+	// CommitStatusMaxErrorsKey is the OS env variable key for the maximum
+	// number of errors in a commit status report.
+	CommitStatusMaxErrorsKey = "COMMIT_STATUS_MAX_ERRORS"
+
+	// CommitStatusTokenKey is the OS env variable key for the token
+	// authenticating the commit status requests.
+	CommitStatusTokenKey = "COMMIT_STATUS_TOKEN"
+
 	// GitSync is the name of the git-sync container in reconciler pods.
 	GitSync = "git-sync"
 
@@ -92,6 +126,18 @@ const (
 	// NamespaceStrategy tells the reconciler container which NamespaceStrategy to
 	// use
 	NamespaceStrategy = "NAMESPACE_STRATEGY"
+
+	// ConflictPolicy tells the reconciler container the default policy for the
+	// fields of the managed objects owned by other field managers.
+	ConflictPolicy = "CONFLICT_POLICY"
+
+	// PolicyEnforcement tells the reconciler container how to report the
+	// declared objects which violate the admission policies.
+	PolicyEnforcement = "POLICY_ENFORCEMENT"
+
+	// IgnoreDifferences tells the reconciler container the JSON-encoded fields
+	// of each kind which it neither applies nor remediates.
+	IgnoreDifferences = "IGNORE_DIFFERENCES"
 )
 
 const (
@@ -116,6 +162,11 @@ const (
 	// HydrationPollingPeriod defines how often the hydration controller should
 	// poll the filesystem for rendering the DRY configs.
 	HydrationPollingPeriod = "HYDRATION_POLLING_PERIOD"
+
+	// HydrationAllowedExecFunctions is the comma-separated list of the
+	// executables which the hydration controller is allowed to run as exec
+	// functions of the Kptfile pipeline.
+	HydrationAllowedExecFunctions = "HYDRATION_ALLOWED_EXEC_FUNCTIONS"
 )
 
 const (
@@ -165,3 +216,15 @@ const (
 	// HelmSyncWait is the OS env variable key for the Helm sync wait period in seconds.
 	HelmSyncWait = "HELM_SYNC_WAIT"
 )
+
+const (
+	// MetricsExporter is the OS env variable key for the metrics exporter used
+	// by the Config Sync components. Must be "otel-agent" (default) or
+	// "prometheus".
+	MetricsExporter = "METRICS_EXPORTER"
+
+	// TraceSamplingProbability is the OS env variable key for the probability
+	// of recording the trace of a parse-apply-watch loop, between 0 (default)
+	// and 1.
+	TraceSamplingProbability = "TRACE_SAMPLING_PROBABILITY"
+)
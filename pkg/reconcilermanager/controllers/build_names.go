@@ -32,6 +32,13 @@ func RepoSyncPermissionsName() string {
 	return fmt.Sprintf("%s:%s", configsync.GroupName, core.NsReconcilerPrefix)
 }
 
+// CheckpointPermissionsName returns the name of the Role and RoleBinding which
+// allow a reconciler to write its declared resources checkpoint.
+// e.g. configsync.gke.io:ns-reconciler-shop:declared-checkpoint
+func CheckpointPermissionsName(reconcilerName string) string {
+	return fmt.Sprintf("%s:%s:declared-checkpoint", configsync.GroupName, reconcilerName)
+}
+
 // RootSyncPermissionsName returns root reconciler ClusterRoleBinding name.
 // e.g. configsync.gke.io:root-reconciler:my-root-sync
 func RootSyncPermissionsName(reconcilerName string) string {
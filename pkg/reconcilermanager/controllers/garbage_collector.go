@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/reconcilermanager"
@@ -135,6 +136,37 @@ func (r *reconcilerBase) deleteConfigMaps(ctx context.Context, reconcilerRef typ
 	return nil
 }
 
+// deleteCheckpoints deletes the ConfigMaps storing the declared resources
+// checkpointed by the reconciler, and the Role and RoleBinding which allow the
+// reconciler to write them.
+func (r *reconcilerBase) deleteCheckpoints(ctx context.Context, reconcilerRef types.NamespacedName) error {
+	for _, name := range declared.CheckpointNames(reconcilerRef.Name) {
+		cm := &corev1.ConfigMap{}
+		cm.Name = name
+		cm.Namespace = reconcilerRef.Namespace
+		key := client.ObjectKeyFromObject(cm)
+		if err := r.client.Get(ctx, key, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return NewObjectOperationErrorWithKey(err, cm, OperationDelete, key)
+		}
+		if err := r.cleanup(ctx, cm); err != nil {
+			return err
+		}
+	}
+	rb := &rbacv1.RoleBinding{}
+	rb.Name = CheckpointPermissionsName(reconcilerRef.Name)
+	rb.Namespace = reconcilerRef.Namespace
+	if err := r.cleanup(ctx, rb); err != nil {
+		return err
+	}
+	role := &rbacv1.Role{}
+	role.Name = CheckpointPermissionsName(reconcilerRef.Name)
+	role.Namespace = reconcilerRef.Namespace
+	return r.cleanup(ctx, role)
+}
+
 func (r *reconcilerBase) deleteServiceAccount(ctx context.Context, reconcilerRef types.NamespacedName) error {
 	sa := &corev1.ServiceAccount{}
 	sa.Name = reconcilerRef.Name
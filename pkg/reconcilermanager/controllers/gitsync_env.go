@@ -94,6 +94,10 @@ type options struct {
 	noSSLVerify bool
 	// caCertSecretRef specifies the name of a secret containing a CA certificate
 	caCertSecretRef string
+	// fetchHistory specifies whether to fetch the history of the synced
+	// commit when depth is unset, to verify the signatures of the commits
+	// since the last synced commit.
+	fetchHistory bool
 }
 
 // gitSyncTokenAuthEnv returns environment variables for git-sync container for 'token' Auth.
@@ -196,8 +200,10 @@ func gitSyncEnvs(_ context.Context, opts options) []corev1.EnvVar {
 		// However, if history is too large git-sync will OOM when it tries to pull all of it.
 		// Try to set a happy medium here -- if syncRev is set, pull 500 commits from master;
 		// if it isn't, just the latest commit will do and will save memory.
+		// The same 500 commits are pulled when the signatures of all the commits
+		// since the last synced commit must be verified.
 		// See b/175088702 and b/158988143
-		if opts.ref == "" || opts.ref == DefaultSyncRev {
+		if (opts.ref == "" || opts.ref == DefaultSyncRev) && !opts.fetchHistory {
 			result = append(result, corev1.EnvVar{
 				Name:  GitSyncDepth,
 				Value: SyncDepthNoRev,
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,8 +39,10 @@ import (
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	hubv1 "kpt.dev/configsync/pkg/api/hub/v1"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/util"
@@ -59,6 +62,16 @@ const (
 	// It will be used in both the indexing and watching.
 	caCertSecretRefField = ".spec.git.caCertSecretRef.name"
 
+	// gitVerificationSecretRefField is the path of the field in the
+	// RootSync|RepoSync CRDs that we wish to use as the "object reference".
+	// It will be used in both the indexing and watching.
+	gitVerificationSecretRefField = ".spec.git.verification.secretRef.name"
+
+	// commitStatusSecretRefField is the path of the field in the
+	// RootSync|RepoSync CRDs that we wish to use as the "object reference".
+	// It will be used in both the indexing and watching.
+	commitStatusSecretRefField = ".spec.git.commitStatus.secretRef.name"
+
 	// helmSecretRefField is the path of the field in the RootSync|RepoSync CRDs
 	// that we wish to use as the "object reference".
 	// It will be used in both the indexing and watching.
@@ -115,6 +128,18 @@ type reconcilerBase struct {
 
 	// syncKind is the kind of the sync object: RootSync or RepoSync.
 	syncKind string
+
+	// metricsExporter is the exporter the reconcilers use to export metrics:
+	// otel-agent or prometheus.
+	metricsExporter string
+
+	// traceSamplingProbability is the probability that the reconcilers record
+	// the trace of a parse-apply-watch loop. Traces are not recorded if 0.
+	traceSamplingProbability float64
+
+	// hydrationAllowedExec are the executables which the hydration controller
+	// is allowed to run as exec functions of the Kptfile pipeline.
+	hydrationAllowedExec []string
 }
 
 func (r *reconcilerBase) serviceAccountSubject(reconcilerRef types.NamespacedName) rbacv1.Subject {
@@ -162,6 +187,77 @@ func (r *reconcilerBase) upsertServiceAccount(
 	return childSARef, nil
 }
 
+// upsertCheckpoint creates the ConfigMaps which store the declared resources
+// checkpoint of the reconciler, and the Role and RoleBinding which only allow
+// the reconciler to read and update them. RBAC cannot limit create requests
+// to resource names, so the ConfigMaps are created here, empty. Existing
+// ConfigMaps are left unchanged, since they may store a checkpoint.
+func (r *reconcilerBase) upsertCheckpoint(ctx context.Context, reconcilerRef types.NamespacedName, labelMap map[string]string) error {
+	names := declared.CheckpointNames(reconcilerRef.Name)
+	for _, name := range names {
+		cm := &corev1.ConfigMap{}
+		cm.Name = name
+		cm.Namespace = reconcilerRef.Namespace
+		key := client.ObjectKeyFromObject(cm)
+		if err := r.client.Get(ctx, key, cm); err == nil {
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			return NewObjectOperationErrorWithKey(err, cm, OperationGet, key)
+		}
+		core.AddLabels(cm, labelMap)
+		if err := r.client.Create(ctx, cm); err != nil && !apierrors.IsAlreadyExists(err) {
+			return NewObjectOperationErrorWithKey(err, cm, OperationCreate, key)
+		}
+	}
+
+	permissionsRef := client.ObjectKey{
+		Namespace: reconcilerRef.Namespace,
+		Name:      CheckpointPermissionsName(reconcilerRef.Name),
+	}
+	role := &rbacv1.Role{}
+	role.Name = permissionsRef.Name
+	role.Namespace = permissionsRef.Namespace
+	op, err := CreateOrUpdate(ctx, r.client, role, func() error {
+		core.AddLabels(role, labelMap)
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: names,
+			Verbs:         []string{"get", "update"},
+		}}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.logger(ctx).Info("Managed object upsert successful",
+			logFieldObjectRef, permissionsRef.String(),
+			logFieldObjectKind, "Role",
+			logFieldOperation, op)
+	}
+
+	rb := &rbacv1.RoleBinding{}
+	rb.Name = permissionsRef.Name
+	rb.Namespace = permissionsRef.Namespace
+	op, err = CreateOrUpdate(ctx, r.client, rb, func() error {
+		core.AddLabels(rb, labelMap)
+		rb.RoleRef = rolereference(permissionsRef.Name, "Role")
+		rb.Subjects = []rbacv1.Subject{r.serviceAccountSubject(reconcilerRef)}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.logger(ctx).Info("Managed object upsert successful",
+			logFieldObjectRef, permissionsRef.String(),
+			logFieldObjectKind, "RoleBinding",
+			logFieldOperation, op)
+	}
+	return nil
+}
+
 type mutateFn func(client.Object) error
 
 func (r *reconcilerBase) upsertDeployment(ctx context.Context, reconcilerRef types.NamespacedName, labelMap map[string]string, mutateObject mutateFn) (*unstructured.Unstructured, controllerutil.OperationResult, error) {
@@ -484,6 +580,58 @@ func mountConfigMapValuesFiles(templateSpec *corev1.PodSpec, c *corev1.Container
 	}
 }
 
+// usePrometheusExporter returns true if the reconciler containers should serve
+// metrics on a Prometheus endpoint, instead of exporting them to the otel-agent
+// sidecar.
+func (r *reconcilerBase) usePrometheusExporter() bool {
+	return r.metricsExporter == metrics.PrometheusExporterType
+}
+
+// mutateContainerPrometheusExporter configures the container to serve metrics
+// on a Prometheus endpoint. The port must match the default port of the
+// container binary.
+func mutateContainerPrometheusExporter(c *corev1.Container, port int32) {
+	c.Env = append(c.Env, corev1.EnvVar{
+		Name:  reconcilermanager.MetricsExporter,
+		Value: metrics.PrometheusExporterType,
+	})
+	c.Ports = append(c.Ports, corev1.ContainerPort{
+		ContainerPort: port,
+		Protocol:      corev1.ProtocolTCP,
+	})
+}
+
+// useOtelAgent returns true if the reconciler Deployments need the otel-agent
+// sidecar: to export the metrics, unless they are served on a Prometheus
+// endpoint, or to export the traces, if they are recorded.
+func (r *reconcilerBase) useOtelAgent() bool {
+	return !r.usePrometheusExporter() || r.traceSamplingProbability > 0
+}
+
+// mutateContainerTracing configures the sampling probability of the traces
+// exported by the container to the otel-agent sidecar.
+func (r *reconcilerBase) mutateContainerTracing(c *corev1.Container) {
+	if r.traceSamplingProbability <= 0 {
+		return
+	}
+	c.Env = append(c.Env, corev1.EnvVar{
+		Name:  reconcilermanager.TraceSamplingProbability,
+		Value: strconv.FormatFloat(r.traceSamplingProbability, 'f', -1, 64),
+	})
+}
+
+// mutateContainerAllowedExec configures the executables which the hydration
+// controller is allowed to run as exec functions of the Kptfile pipeline.
+func (r *reconcilerBase) mutateContainerAllowedExec(c *corev1.Container) {
+	if len(r.hydrationAllowedExec) == 0 {
+		return
+	}
+	c.Env = append(c.Env, corev1.EnvVar{
+		Name:  reconcilermanager.HydrationAllowedExecFunctions,
+		Value: strings.Join(r.hydrationAllowedExec, ","),
+	})
+}
+
 func mutateContainerLogLevel(c *corev1.Container, override []v1beta1.ContainerLogLevelOverride) {
 	if len(override) == 0 {
 		return
@@ -601,6 +749,40 @@ func (r *reconcilerBase) validateCACertSecret(ctx context.Context, namespace, ca
 	return nil
 }
 
+// validateGitVerificationSecret verifies that the secret with the trusted keys
+// of the Git commit signature verification exists.
+func (r *reconcilerBase) validateGitVerificationSecret(ctx context.Context, namespace, secretName string) error {
+	if secretName == "" {
+		return nil
+	}
+	if _, err := validateSecretExist(ctx, secretName, namespace, r.client); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf("Secret %s not found, create one with the trusted keys to verify the Git commit signatures", secretName)
+		}
+		return errors.Wrapf(err, "Secret %s get failed", secretName)
+	}
+	return nil
+}
+
+// validateCommitStatusSecret verifies that the secret with the token of the
+// commit status reports exists and has the token key.
+func (r *reconcilerBase) validateCommitStatusSecret(ctx context.Context, namespace, secretName string) error {
+	if secretName == "" {
+		return nil
+	}
+	secret, err := validateSecretExist(ctx, secretName, namespace, r.client)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf("Secret %s not found, create one with the token to report the commit statuses", secretName)
+		}
+		return errors.Wrapf(err, "Secret %s get failed", secretName)
+	}
+	if _, ok := secret.Data[CommitStatusTokenKey]; !ok {
+		return errors.Errorf("commitStatus.secretRef was set, but %s key is not present in %s Secret", CommitStatusTokenKey, secretName)
+	}
+	return nil
+}
+
 // addTypeInformationToObject looks up and adds GVK to a runtime.Object based upon the loaded Scheme
 func (r *reconcilerBase) addTypeInformationToObject(obj runtime.Object) error {
 	gvk, err := kinds.Lookup(obj, r.scheme)
@@ -89,20 +89,23 @@ const (
 )
 
 // NewRepoSyncReconciler returns a new RepoSyncReconciler.
-func NewRepoSyncReconciler(clusterName string, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, client client.Client, watcher client.WithWatch, dynamicClient dynamic.Interface, log logr.Logger, scheme *runtime.Scheme) *RepoSyncReconciler {
+func NewRepoSyncReconciler(clusterName string, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, metricsExporter string, traceSamplingProbability float64, hydrationAllowedExec []string, client client.Client, watcher client.WithWatch, dynamicClient dynamic.Interface, log logr.Logger, scheme *runtime.Scheme) *RepoSyncReconciler {
 	return &RepoSyncReconciler{
 		reconcilerBase: reconcilerBase{
 			loggingController: loggingController{
 				log: log,
 			},
-			clusterName:             clusterName,
-			client:                  client,
-			dynamicClient:           dynamicClient,
-			watcher:                 watcher,
-			scheme:                  scheme,
-			reconcilerPollingPeriod: reconcilerPollingPeriod,
-			hydrationPollingPeriod:  hydrationPollingPeriod,
-			syncKind:                configsync.RepoSyncKind,
+			clusterName:              clusterName,
+			client:                   client,
+			dynamicClient:            dynamicClient,
+			watcher:                  watcher,
+			scheme:                   scheme,
+			reconcilerPollingPeriod:  reconcilerPollingPeriod,
+			hydrationPollingPeriod:   hydrationPollingPeriod,
+			syncKind:                 configsync.RepoSyncKind,
+			metricsExporter:          metricsExporter,
+			traceSamplingProbability: traceSamplingProbability,
+			hydrationAllowedExec:     hydrationAllowedExec,
 		},
 		configMapWatches: make(map[string]bool),
 	}
@@ -219,6 +222,18 @@ func (r *RepoSyncReconciler) upsertManagedObjects(ctx context.Context, reconcile
 		return errors.Wrap(err, "upserting CA cert secret")
 	}
 
+	// Create secret in config-management-system namespace using the
+	// existing secret in the reposync.namespace.
+	if _, err := r.upsertGitVerificationSecret(ctx, rs, reconcilerRef); err != nil {
+		return errors.Wrap(err, "upserting Git verification secret")
+	}
+
+	// Create secret in config-management-system namespace using the
+	// existing secret in the reposync.namespace.
+	if _, err := r.upsertCommitStatusSecret(ctx, rs, reconcilerRef); err != nil {
+		return errors.Wrap(err, "upserting commit status secret")
+	}
+
 	labelMap := map[string]string{
 		metadata.SyncNamespaceLabel: rs.Namespace,
 		metadata.SyncNameLabel:      rs.Name,
@@ -246,6 +261,10 @@ func (r *RepoSyncReconciler) upsertManagedObjects(ctx context.Context, reconcile
 		return errors.Wrap(err, "upserting service account")
 	}
 
+	if err := r.upsertCheckpoint(ctx, reconcilerRef, labelMap); err != nil {
+		return errors.Wrap(err, "upserting declared resources checkpoint")
+	}
+
 	// Overwrite reconciler rolebinding.
 	if _, err := r.upsertRoleBinding(ctx, reconcilerRef, rsRef); err != nil {
 		return errors.Wrap(err, "upserting role binding")
@@ -433,6 +452,10 @@ func (r *RepoSyncReconciler) deleteManagedObjects(ctx context.Context, reconcile
 		return errors.Wrap(err, "deleting config maps")
 	}
 
+	if err := r.deleteCheckpoints(ctx, reconcilerRef); err != nil {
+		return errors.Wrap(err, "deleting declared resources checkpoints")
+	}
+
 	if err := r.deleteSecrets(ctx, reconcilerRef); err != nil {
 		return errors.Wrap(err, "deleting secrets")
 	}
@@ -482,6 +505,36 @@ func (r *RepoSyncReconciler) SetupWithManager(mgr controllerruntime.Manager, wat
 	}); err != nil {
 		return err
 	}
+	// Index the `gitVerificationSecretRefField` field, so that we will be able to lookup RepoSync be a referenced `gitVerificationSecretRefField` name.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, gitVerificationSecretRefField, func(rawObj client.Object) []string {
+		rs, ok := rawObj.(*v1beta1.RepoSync)
+		if !ok {
+			// Only add index for RepoSync
+			return nil
+		}
+		secretName := gitVerificationSecretName(rs.Spec.SourceType, rs.Spec.Git)
+		if secretName == "" {
+			return nil
+		}
+		return []string{secretName}
+	}); err != nil {
+		return err
+	}
+	// Index the `commitStatusSecretRefField` field, so that we will be able to lookup RepoSync be a referenced `commitStatusSecretRefField` name.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, commitStatusSecretRefField, func(rawObj client.Object) []string {
+		rs, ok := rawObj.(*v1beta1.RepoSync)
+		if !ok {
+			// Only add index for RepoSync
+			return nil
+		}
+		secretName := commitStatusSecretName(rs.Spec.SourceType, rs.Spec.Git)
+		if secretName == "" {
+			return nil
+		}
+		return []string{secretName}
+	}); err != nil {
+		return err
+	}
 	// Index the `helmSecretRefName` field, so that we will be able to lookup RepoSync be a referenced `SecretRef` name.
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, helmSecretRefField, func(rawObj client.Object) []string {
 		rs, ok := rawObj.(*v1beta1.RepoSync)
@@ -655,7 +708,7 @@ func (r *RepoSyncReconciler) mapSecretToRepoSyncs(secret client.Object) []reconc
 	// The user-managed ns-reconciler Secret might be shared among multiple RepoSync objects in the same namespace,
 	// so requeue all the attached RepoSync objects.
 	attachedRepoSyncs := &v1beta1.RepoSyncList{}
-	secretFields := []string{gitSecretRefField, caCertSecretRefField, helmSecretRefField}
+	secretFields := []string{gitSecretRefField, caCertSecretRefField, gitVerificationSecretRefField, commitStatusSecretRefField, helmSecretRefField}
 	for _, secretField := range secretFields {
 		listOps := &client.ListOptions{
 			FieldSelector: fields.OneTermEqualSelector(secretField, sRef.Name),
@@ -828,6 +881,12 @@ func (r *RepoSyncReconciler) populateContainerEnvs(ctx context.Context, rs *v1be
 		reconcilermanager.HydrationController: hydrationEnvs(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, declared.Scope(rs.Namespace), reconcilerName, r.hydrationPollingPeriod.String()),
 		reconcilermanager.Reconciler:          reconcilerEnvs(r.clusterName, rs.Name, rs.Generation, reconcilerName, declared.Scope(rs.Namespace), rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, reposync.GetHelmBase(rs.Spec.Helm), r.reconcilerPollingPeriod.String(), rs.Spec.SafeOverride().StatusMode, v1beta1.GetReconcileTimeout(rs.Spec.SafeOverride().ReconcileTimeout), v1beta1.GetAPIServerTimeout(rs.Spec.SafeOverride().APIServerTimeout), enableRendering(rs.GetAnnotations())),
 	}
+	result[reconcilermanager.Reconciler] = append(result[reconcilermanager.Reconciler],
+		conflictPolicyEnvs(rs.Spec.SafeOverride().ConflictPolicy)...)
+	result[reconcilermanager.Reconciler] = append(result[reconcilermanager.Reconciler],
+		policyEnforcementEnvs(rs.Spec.SafeOverride().PolicyEnforcement)...)
+	result[reconcilermanager.Reconciler] = append(result[reconcilermanager.Reconciler],
+		ignoreDifferencesEnvs(rs.Spec.SafeOverride().IgnoreDifferences)...)
 	switch v1beta1.SourceType(rs.Spec.SourceType) {
 	case v1beta1.GitSource:
 		result[reconcilermanager.GitSync] = gitSyncEnvs(ctx, options{
@@ -840,6 +899,7 @@ func (r *RepoSyncReconciler) populateContainerEnvs(ctx context.Context, rs *v1be
 			depth:           rs.Spec.SafeOverride().GitSyncDepth,
 			noSSLVerify:     rs.Spec.Git.NoSSLVerify,
 			caCertSecretRef: v1beta1.GetSecretName(rs.Spec.Git.CACertSecretRef),
+			fetchHistory:    verifyAllCommits(rs.Spec.Git),
 		})
 		if enableAskpassSidecar(rs.Spec.SourceType, rs.Spec.Git.Auth) {
 			result[reconcilermanager.GCENodeAskpassSidecar] = gceNodeAskPassSidecarEnvs(rs.Spec.GCPServiceAccountEmail)
@@ -865,6 +925,12 @@ func (r *RepoSyncReconciler) validateRepoSync(ctx context.Context, rs *v1beta1.R
 		return err
 	}
 
+	if rs.Spec.Override != nil {
+		if err := validate.IgnoreDifferences(rs.Spec.Override.IgnoreDifferences, rs); err != nil {
+			return err
+		}
+	}
+
 	return r.validateValuesFileSourcesRefs(ctx, rs)
 }
 
@@ -897,6 +963,12 @@ func (r *RepoSyncReconciler) validateGitSpec(ctx context.Context, rs *v1beta1.Re
 	if err := r.validateCACertSecret(ctx, rs.Namespace, v1beta1.GetSecretName(rs.Spec.Git.CACertSecretRef)); err != nil {
 		return err
 	}
+	if err := r.validateGitVerificationSecret(ctx, rs.Namespace, gitVerificationSecretName(rs.Spec.SourceType, rs.Spec.Git)); err != nil {
+		return err
+	}
+	if err := r.validateCommitStatusSecret(ctx, rs.Namespace, commitStatusSecretName(rs.Spec.SourceType, rs.Spec.Git)); err != nil {
+		return err
+	}
 	return r.validateNamespaceSecret(ctx, rs, reconcilerName)
 }
 
@@ -1060,6 +1132,15 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 			caCertSecretRefName = ReconcilerResourceName(reconcilerName, caCertSecretRefName)
 		}
 		templateSpec.Volumes = filterVolumes(templateSpec.Volumes, auth, secretName, caCertSecretRefName, rs.Spec.SourceType, r.membership)
+		verificationSecretName := gitVerificationSecretName(rs.Spec.SourceType, rs.Spec.Git)
+		if verificationSecretName != "" {
+			verificationSecretName = ReconcilerResourceName(reconcilerName, verificationSecretName)
+			templateSpec.Volumes = append(templateSpec.Volumes, gitVerificationVolumes(verificationSecretName)...)
+		}
+		commitStatusSecret := commitStatusSecretName(rs.Spec.SourceType, rs.Spec.Git)
+		if commitStatusSecret != "" {
+			commitStatusSecret = ReconcilerResourceName(reconcilerName, commitStatusSecret)
+		}
 
 		autopilot, err := r.isAutopilot()
 		if err != nil {
@@ -1082,6 +1163,16 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 			switch container.Name {
 			case reconcilermanager.Reconciler:
 				container.Env = append(container.Env, containerEnvs[container.Name]...)
+				if verificationSecretName != "" {
+					container.VolumeMounts = append(container.VolumeMounts, gitVerificationVolumeMounts()...)
+				}
+				if commitStatusSecret != "" {
+					container.Env = append(container.Env, commitStatusTokenEnv(commitStatusSecret))
+				}
+				if r.usePrometheusExporter() {
+					mutateContainerPrometheusExporter(&container, metrics.PrometheusPort)
+				}
+				r.mutateContainerTracing(&container)
 			case reconcilermanager.HydrationController:
 				if !enableRendering(rs.GetAnnotations()) {
 					// if the sync source does not require rendering, omit the hydration controller
@@ -1090,6 +1181,11 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 				} else {
 					container.Env = append(container.Env, containerEnvs[container.Name]...)
 					container.Image = updateHydrationControllerImage(container.Image, rs.Spec.SafeOverride().OverrideSpec)
+					if r.usePrometheusExporter() {
+						mutateContainerPrometheusExporter(&container, metrics.HydrationControllerPrometheusPort)
+					}
+					r.mutateContainerTracing(&container)
+					r.mutateContainerAllowedExec(&container)
 				}
 			case reconcilermanager.OciSync:
 				// Don't add the oci-sync container when sourceType is NOT oci.
@@ -1138,7 +1234,14 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 					// TODO: enable resource/logLevel overrides for gcenode-askpass-sidecar
 				}
 			case metrics.OtelAgentName:
-				container.Env = append(container.Env, containerEnvs[container.Name]...)
+				if !r.useOtelAgent() {
+					// The otel-agent isn't needed when metrics are scraped
+					// directly from the reconciler and hydration-controller,
+					// and no traces are recorded.
+					addContainer = false
+				} else {
+					container.Env = append(container.Env, containerEnvs[container.Name]...)
+				}
 			default:
 				return errors.Errorf("unknown container in reconciler deployment template: %q", container.Name)
 			}
@@ -47,6 +47,7 @@ import (
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/reposync"
 	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
@@ -215,6 +216,24 @@ func reposyncOverrideAPIServerTimeout(apiServerTimout metav1.Duration) func(*v1b
 	}
 }
 
+func reposyncOverrideConflictPolicy(policy configsync.ConflictPolicy) func(*v1beta1.RepoSync) {
+	return func(rs *v1beta1.RepoSync) {
+		rs.Spec.SafeOverride().ConflictPolicy = policy
+	}
+}
+
+func reposyncOverridePolicyEnforcement(enforcement configsync.PolicyEnforcement) func(*v1beta1.RepoSync) {
+	return func(rs *v1beta1.RepoSync) {
+		rs.Spec.SafeOverride().PolicyEnforcement = enforcement
+	}
+}
+
+func reposyncOverrideIgnoreDifferences(diffs ...v1beta1.IgnoreDifference) func(*v1beta1.RepoSync) {
+	return func(rs *v1beta1.RepoSync) {
+		rs.Spec.SafeOverride().IgnoreDifferences = diffs
+	}
+}
+
 func reposyncNoSSLVerify() func(*v1beta1.RepoSync) {
 	return func(rs *v1beta1.RepoSync) {
 		rs.Spec.NoSSLVerify = true
@@ -227,6 +246,24 @@ func reposyncCACert(caCertSecretRef string) func(sync *v1beta1.RepoSync) {
 	}
 }
 
+func reposyncGitVerification(secretRef string, policy v1beta1.GitVerificationPolicy) func(sync *v1beta1.RepoSync) {
+	return func(rs *v1beta1.RepoSync) {
+		rs.Spec.Git.Verification = &v1beta1.GitVerification{
+			SecretRef: &v1beta1.SecretReference{Name: secretRef},
+			Policy:    policy,
+		}
+	}
+}
+
+func reposyncCommitStatus(provider v1beta1.GitProvider, secretRef string) func(sync *v1beta1.RepoSync) {
+	return func(rs *v1beta1.RepoSync) {
+		rs.Spec.Git.CommitStatus = &v1beta1.GitCommitStatus{
+			Provider:  provider,
+			SecretRef: &v1beta1.SecretReference{Name: secretRef},
+		}
+	}
+}
+
 func reposyncRenderingRequired(renderingRequired bool) func(sync *v1beta1.RepoSync) {
 	return func(rs *v1beta1.RepoSync) {
 		val := strconv.FormatBool(renderingRequired)
@@ -312,6 +349,9 @@ func setupNSReconciler(t *testing.T, objs ...client.Object) (*syncerFake.Client,
 		testCluster,
 		filesystemPollingPeriod,
 		hydrationPollingPeriod,
+		metrics.OtelAgentExporter,
+		0,
+		nil,
 		cs.Client,
 		cs.Client,
 		cs.DynamicClient,
@@ -1134,6 +1174,100 @@ func TestRepoSyncCreateWithCACert(t *testing.T) {
 	t.Log("Deployment successfully created")
 }
 
+func TestRepoSyncCreateWithGitVerification(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+	keysSecret := "trusted-keys"
+	rs := repoSyncWithGit(reposyncNs, reposyncName, reposyncRef(gitRevision), reposyncBranch(branch),
+		reposyncSecretType(configsync.AuthSSH), reposyncSecretRef(reposyncSSHKey),
+		reposyncGitVerification(keysSecret, ""))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	keys := fake.SecretObject(keysSecret, core.Namespace(rs.Namespace))
+	keys.Data = map[string][]byte{"ssh": []byte("ssh-ed25519 AAAA")}
+	fakeClient, fakeDynamicClient, testReconciler := setupNSReconciler(t, rs,
+		secretObj(t, reposyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)), keys)
+
+	// Test creating Deployment resources.
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	repoContainerEnvs := testReconciler.populateContainerEnvs(ctx, rs, nsReconcilerName)
+	require.Contains(t, repoContainerEnvs[reconcilermanager.Reconciler],
+		corev1.EnvVar{Name: reconcilermanager.GitVerificationPolicyKey, Value: string(v1beta1.HeadSigned)})
+	resourceOverrides := setContainerResourceDefaults(nil, ReconcilerContainerResourceDefaults())
+	nsKeysSecret := nsReconcilerName + "-" + keysSecret
+	repoDeployment := repoSyncDeployment(nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		secretMutator(nsReconcilerName+"-"+reposyncSSHKey),
+		gitVerificationMutator(nsKeysSecret),
+		containerResourcesMutator(resourceOverrides),
+		containerEnvMutator(repoContainerEnvs),
+		setUID("1"), setResourceVersion("1"), setGeneration(1),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(repoDeployment): repoDeployment}
+
+	if err := validateDeployments(wantDeployments, fakeDynamicClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+
+	// The trusted keys are copied to the config-management-system namespace.
+	upserted := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: v1.NSConfigManagementSystem, Name: nsKeysSecret}, upserted); err != nil {
+		t.Fatalf("failed to get the upserted Git verification secret: %v", err)
+	}
+	require.Equal(t, keys.Data, upserted.Data)
+	require.True(t, isUpsertedSecret(rs, nsKeysSecret))
+}
+
+func TestRepoSyncCreateWithCommitStatus(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+	tokenSecret := "gitlab-token"
+	rs := repoSyncWithGit(reposyncNs, reposyncName, reposyncRef(gitRevision), reposyncBranch(branch),
+		reposyncSecretType(configsync.AuthSSH), reposyncSecretRef(reposyncSSHKey),
+		reposyncCommitStatus(v1beta1.GitLabProvider, tokenSecret))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	token := fake.SecretObject(tokenSecret, core.Namespace(rs.Namespace))
+	token.Data = map[string][]byte{CommitStatusTokenKey: []byte("test-token")}
+	fakeClient, fakeDynamicClient, testReconciler := setupNSReconciler(t, rs,
+		secretObj(t, reposyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)), token)
+
+	// Test creating Deployment resources.
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	repoContainerEnvs := testReconciler.populateContainerEnvs(ctx, rs, nsReconcilerName)
+	require.Contains(t, repoContainerEnvs[reconcilermanager.Reconciler],
+		corev1.EnvVar{Name: reconcilermanager.CommitStatusProviderKey, Value: string(v1beta1.GitLabProvider)})
+	resourceOverrides := setContainerResourceDefaults(nil, ReconcilerContainerResourceDefaults())
+	nsTokenSecret := nsReconcilerName + "-" + tokenSecret
+	repoDeployment := repoSyncDeployment(nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		secretMutator(nsReconcilerName+"-"+reposyncSSHKey),
+		containerResourcesMutator(resourceOverrides),
+		containerEnvMutator(repoContainerEnvs),
+		commitStatusMutator(nsTokenSecret),
+		setUID("1"), setResourceVersion("1"), setGeneration(1),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(repoDeployment): repoDeployment}
+
+	if err := validateDeployments(wantDeployments, fakeDynamicClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+
+	// The token is copied to the config-management-system namespace.
+	upserted := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: v1.NSConfigManagementSystem, Name: nsTokenSecret}, upserted); err != nil {
+		t.Fatalf("failed to get the upserted commit status secret: %v", err)
+	}
+	require.Equal(t, token.Data, upserted.Data)
+	require.True(t, isUpsertedSecret(rs, nsTokenSecret))
+}
+
 func TestRepoSyncUpdateCACert(t *testing.T) {
 	// Mock out parseDeployment for testing.
 	parseDeployment = parsedDeployment
@@ -3731,6 +3865,36 @@ func TestPopulateRepoContainerEnvs(t *testing.T) {
 				reconcilermanager.Reconciler: {reconcilermanager.RenderingEnabled: "true"},
 			}),
 		},
+		{
+			name: "conflict policy override sets env var",
+			repoSync: repoSyncWithGit(reposyncNs, reposyncName,
+				reposyncOverrideConflictPolicy(configsync.ConflictPolicyRespect),
+				reposyncRenderingRequired(false),
+			),
+			expected: createEnv(map[string]map[string]string{
+				reconcilermanager.Reconciler: {reconcilermanager.ConflictPolicy: "respect"},
+			}),
+		},
+		{
+			name: "policy enforcement override sets env var",
+			repoSync: repoSyncWithGit(reposyncNs, reposyncName,
+				reposyncOverridePolicyEnforcement(configsync.PolicyEnforcementWarn),
+				reposyncRenderingRequired(false),
+			),
+			expected: createEnv(map[string]map[string]string{
+				reconcilermanager.Reconciler: {reconcilermanager.PolicyEnforcement: "warn"},
+			}),
+		},
+		{
+			name: "ignore differences override sets env var",
+			repoSync: repoSyncWithGit(reposyncNs, reposyncName,
+				reposyncOverrideIgnoreDifferences(v1beta1.IgnoreDifference{Group: "apps", Kind: "Deployment", JSONPaths: []string{".spec.replicas"}}),
+				reposyncRenderingRequired(false),
+			),
+			expected: createEnv(map[string]map[string]string{
+				reconcilermanager.Reconciler: {reconcilermanager.IgnoreDifferences: `[{"group":"apps","kind":"Deployment","jsonPaths":[".spec.replicas"]}]`},
+			}),
+		},
 	}
 
 	ctx := context.Background()
@@ -80,20 +80,23 @@ type RootSyncReconciler struct {
 }
 
 // NewRootSyncReconciler returns a new RootSyncReconciler.
-func NewRootSyncReconciler(clusterName string, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, client client.Client, watcher client.WithWatch, dynamicClient dynamic.Interface, log logr.Logger, scheme *runtime.Scheme) *RootSyncReconciler {
+func NewRootSyncReconciler(clusterName string, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, metricsExporter string, traceSamplingProbability float64, hydrationAllowedExec []string, client client.Client, watcher client.WithWatch, dynamicClient dynamic.Interface, log logr.Logger, scheme *runtime.Scheme) *RootSyncReconciler {
 	return &RootSyncReconciler{
 		reconcilerBase: reconcilerBase{
 			loggingController: loggingController{
 				log: log,
 			},
-			clusterName:             clusterName,
-			client:                  client,
-			watcher:                 watcher,
-			dynamicClient:           dynamicClient,
-			scheme:                  scheme,
-			reconcilerPollingPeriod: reconcilerPollingPeriod,
-			hydrationPollingPeriod:  hydrationPollingPeriod,
-			syncKind:                configsync.RootSyncKind,
+			clusterName:              clusterName,
+			client:                   client,
+			watcher:                  watcher,
+			dynamicClient:            dynamicClient,
+			scheme:                   scheme,
+			reconcilerPollingPeriod:  reconcilerPollingPeriod,
+			hydrationPollingPeriod:   hydrationPollingPeriod,
+			syncKind:                 configsync.RootSyncKind,
+			metricsExporter:          metricsExporter,
+			traceSamplingProbability: traceSamplingProbability,
+			hydrationAllowedExec:     hydrationAllowedExec,
 		},
 	}
 }
@@ -206,6 +209,10 @@ func (r *RootSyncReconciler) upsertManagedObjects(ctx context.Context, reconcile
 		return errors.Wrap(err, "upserting service account")
 	}
 
+	if err := r.upsertCheckpoint(ctx, reconcilerRef, labelMap); err != nil {
+		return errors.Wrap(err, "upserting declared resources checkpoint")
+	}
+
 	// Overwrite reconciler clusterrolebinding.
 	if err := r.configureClusterRoleBinding(ctx, reconcilerRef, rs.Spec.SafeOverride().ClusterRole); err != nil {
 		return errors.Wrap(err, "configuring cluster role binding")
@@ -387,6 +394,10 @@ func (r *RootSyncReconciler) deleteManagedObjects(ctx context.Context, reconcile
 		return errors.Wrap(err, "deleting config maps")
 	}
 
+	if err := r.deleteCheckpoints(ctx, reconcilerRef); err != nil {
+		return errors.Wrap(err, "deleting declared resources checkpoints")
+	}
+
 	// Note: ReconcilerManager doesn't manage the RootSync Secret.
 	// So we don't need to delete it here.
 
@@ -676,6 +687,12 @@ func (r *RootSyncReconciler) populateContainerEnvs(ctx context.Context, rs *v1be
 			namespaceStrategyEnv(rs.Spec.SafeOverride().NamespaceStrategy),
 		),
 	}
+	result[reconcilermanager.Reconciler] = append(result[reconcilermanager.Reconciler],
+		conflictPolicyEnvs(rs.Spec.SafeOverride().ConflictPolicy)...)
+	result[reconcilermanager.Reconciler] = append(result[reconcilermanager.Reconciler],
+		policyEnforcementEnvs(rs.Spec.SafeOverride().PolicyEnforcement)...)
+	result[reconcilermanager.Reconciler] = append(result[reconcilermanager.Reconciler],
+		ignoreDifferencesEnvs(rs.Spec.SafeOverride().IgnoreDifferences)...)
 	switch v1beta1.SourceType(rs.Spec.SourceType) {
 	case v1beta1.GitSource:
 		result[reconcilermanager.GitSync] = gitSyncEnvs(ctx, options{
@@ -688,6 +705,7 @@ func (r *RootSyncReconciler) populateContainerEnvs(ctx context.Context, rs *v1be
 			depth:           rs.Spec.SafeOverride().GitSyncDepth,
 			noSSLVerify:     rs.Spec.Git.NoSSLVerify,
 			caCertSecretRef: v1beta1.GetSecretName(rs.Spec.Git.CACertSecretRef),
+			fetchHistory:    verifyAllCommits(rs.Spec.Git),
 		})
 		if enableAskpassSidecar(rs.Spec.SourceType, rs.Spec.Git.Auth) {
 			result[reconcilermanager.GCENodeAskpassSidecar] = gceNodeAskPassSidecarEnvs(rs.Spec.GCPServiceAccountEmail)
@@ -713,6 +731,12 @@ func (r *RootSyncReconciler) validateRootSync(ctx context.Context, rs *v1beta1.R
 		return err
 	}
 
+	if rs.Spec.Override != nil {
+		if err := validate.IgnoreDifferences(rs.Spec.Override.IgnoreDifferences, rs); err != nil {
+			return err
+		}
+	}
+
 	return r.validateValuesFileSourcesRefs(ctx, rs)
 }
 
@@ -751,6 +775,12 @@ func (r *RootSyncReconciler) validateGitSpec(ctx context.Context, rs *v1beta1.Ro
 	if err := r.validateCACertSecret(ctx, rs.Namespace, v1beta1.GetSecretName(rs.Spec.Git.CACertSecretRef)); err != nil {
 		return err
 	}
+	if err := r.validateGitVerificationSecret(ctx, rs.Namespace, gitVerificationSecretName(rs.Spec.SourceType, rs.Spec.Git)); err != nil {
+		return err
+	}
+	if err := r.validateCommitStatusSecret(ctx, rs.Namespace, commitStatusSecretName(rs.Spec.SourceType, rs.Spec.Git)); err != nil {
+		return err
+	}
 	return r.validateRootSecret(ctx, rs, reconcilerName)
 }
 
@@ -945,6 +975,11 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 		// authenticate with the git or helm repository using the authorization method specified
 		// in the RootSync CR.
 		templateSpec.Volumes = filterVolumes(templateSpec.Volumes, auth, secretRefName, caCertSecretRefName, rs.Spec.SourceType, r.membership)
+		verificationSecretName := gitVerificationSecretName(rs.Spec.SourceType, rs.Spec.Git)
+		if verificationSecretName != "" {
+			templateSpec.Volumes = append(templateSpec.Volumes, gitVerificationVolumes(verificationSecretName)...)
+		}
+		commitStatusSecret := commitStatusSecretName(rs.Spec.SourceType, rs.Spec.Git)
 
 		autopilot, err := r.isAutopilot()
 		if err != nil {
@@ -966,6 +1001,16 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 			switch container.Name {
 			case reconcilermanager.Reconciler:
 				container.Env = append(container.Env, containerEnvs[container.Name]...)
+				if verificationSecretName != "" {
+					container.VolumeMounts = append(container.VolumeMounts, gitVerificationVolumeMounts()...)
+				}
+				if commitStatusSecret != "" {
+					container.Env = append(container.Env, commitStatusTokenEnv(commitStatusSecret))
+				}
+				if r.usePrometheusExporter() {
+					mutateContainerPrometheusExporter(&container, metrics.PrometheusPort)
+				}
+				r.mutateContainerTracing(&container)
 			case reconcilermanager.HydrationController:
 				if !enableRendering(rs.GetAnnotations()) {
 					// if the sync source does not require rendering, omit the hydration controller
@@ -974,6 +1019,11 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 				} else {
 					container.Env = append(container.Env, containerEnvs[container.Name]...)
 					container.Image = updateHydrationControllerImage(container.Image, rs.Spec.SafeOverride().OverrideSpec)
+					if r.usePrometheusExporter() {
+						mutateContainerPrometheusExporter(&container, metrics.HydrationControllerPrometheusPort)
+					}
+					r.mutateContainerTracing(&container)
+					r.mutateContainerAllowedExec(&container)
 				}
 			case reconcilermanager.OciSync:
 				// Don't add the oci-sync container when sourceType is NOT oci.
@@ -1023,7 +1073,14 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 					// TODO: enable resource/logLevel overrides for gcenode-askpass-sidecar
 				}
 			case metrics.OtelAgentName:
-				container.Env = append(container.Env, containerEnvs[container.Name]...)
+				if !r.useOtelAgent() {
+					// The otel-agent isn't needed when metrics are scraped
+					// directly from the reconciler and hydration-controller,
+					// and no traces are recorded.
+					addContainer = false
+				} else {
+					container.Env = append(container.Env, containerEnvs[container.Name]...)
+				}
 			default:
 				return errors.Errorf("unknown container in reconciler deployment template: %q", container.Name)
 			}
@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/utils/pointer"
@@ -41,9 +42,11 @@ import (
 	hubv1 "kpt.dev/configsync/pkg/api/hub/v1"
 	"kpt.dev/configsync/pkg/client/restconfig"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/rootsync"
 	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
@@ -143,6 +146,9 @@ func setupRootReconciler(t *testing.T, objs ...client.Object) (*syncerFake.Clien
 		testCluster,
 		filesystemPollingPeriod,
 		hydrationPollingPeriod,
+		metrics.OtelAgentExporter,
+		0,
+		nil,
 		cs.Client,
 		cs.Client,
 		cs.DynamicClient,
@@ -227,6 +233,24 @@ func rootsyncOverrideAPIServerTimeout(apiServerTimout metav1.Duration) func(*v1b
 	}
 }
 
+func rootsyncOverrideConflictPolicy(policy configsync.ConflictPolicy) func(*v1beta1.RootSync) {
+	return func(rs *v1beta1.RootSync) {
+		rs.Spec.SafeOverride().ConflictPolicy = policy
+	}
+}
+
+func rootsyncOverridePolicyEnforcement(enforcement configsync.PolicyEnforcement) func(*v1beta1.RootSync) {
+	return func(rs *v1beta1.RootSync) {
+		rs.Spec.SafeOverride().PolicyEnforcement = enforcement
+	}
+}
+
+func rootsyncOverrideIgnoreDifferences(diffs ...v1beta1.IgnoreDifference) func(*v1beta1.RootSync) {
+	return func(rs *v1beta1.RootSync) {
+		rs.Spec.SafeOverride().IgnoreDifferences = diffs
+	}
+}
+
 func rootsyncOverrideClusterRole(clusterRole string) func(*v1beta1.RootSync) {
 	return func(rs *v1beta1.RootSync) {
 		rs.Spec.SafeOverride().ClusterRole.Name = clusterRole
@@ -245,6 +269,25 @@ func rootsyncCACert(caCertSecretRef string) func(*v1beta1.RootSync) {
 	}
 }
 
+func rootsyncGitVerification(secretRef string, policy v1beta1.GitVerificationPolicy) func(*v1beta1.RootSync) {
+	return func(rs *v1beta1.RootSync) {
+		rs.Spec.Git.Verification = &v1beta1.GitVerification{
+			SecretRef: &v1beta1.SecretReference{Name: secretRef},
+			Policy:    policy,
+		}
+	}
+}
+
+func rootsyncCommitStatus(provider v1beta1.GitProvider, secretRef string) func(*v1beta1.RootSync) {
+	return func(rs *v1beta1.RootSync) {
+		rs.Spec.Git.CommitStatus = &v1beta1.GitCommitStatus{
+			Provider:  provider,
+			SecretRef: &v1beta1.SecretReference{Name: secretRef},
+			MaxErrors: 3,
+		}
+	}
+}
+
 func rootsyncRenderingRequired(renderingRequired bool) func(*v1beta1.RootSync) {
 	return func(rs *v1beta1.RootSync) {
 		val := strconv.FormatBool(renderingRequired)
@@ -695,6 +738,98 @@ func TestRootSyncCreateWithNoSSLVerify(t *testing.T) {
 	t.Log("Deployment successfully created")
 }
 
+func TestRootSyncWithPrometheusExporter(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = func(de *appsv1.Deployment) error {
+		if err := parsedDeployment(de); err != nil {
+			return err
+		}
+		de.Spec.Template.Spec.Containers = append(de.Spec.Template.Spec.Containers,
+			corev1.Container{Name: metrics.OtelAgentName})
+		return nil
+	}
+
+	rs := rootSyncWithGit(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	_, fakeDynamicClient, testReconciler := setupRootReconciler(t, rs, secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)))
+	testReconciler.metricsExporter = metrics.PrometheusExporterType
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	uObj, err := fakeDynamicClient.Resource(kinds.DeploymentResource()).
+		Namespace(configsync.ControllerNamespace).
+		Get(ctx, rootReconcilerName, metav1.GetOptions{})
+	require.NoError(t, err)
+	obj, err := kinds.ToTypedObject(uObj, core.Scheme)
+	require.NoError(t, err)
+	dep := obj.(*appsv1.Deployment)
+
+	wantEnv := corev1.EnvVar{Name: reconcilermanager.MetricsExporter, Value: metrics.PrometheusExporterType}
+	wantPorts := map[string]int32{
+		reconcilermanager.Reconciler:          metrics.PrometheusPort,
+		reconcilermanager.HydrationController: metrics.HydrationControllerPrometheusPort,
+	}
+	var gotContainers []string
+	for _, container := range dep.Spec.Template.Spec.Containers {
+		gotContainers = append(gotContainers, container.Name)
+		port, found := wantPorts[container.Name]
+		if !found {
+			continue
+		}
+		require.Contains(t, container.Env, wantEnv, "container %s", container.Name)
+		require.Equal(t, []corev1.ContainerPort{{ContainerPort: port, Protocol: corev1.ProtocolTCP}},
+			container.Ports, "container %s", container.Name)
+	}
+	require.NotContains(t, gotContainers, metrics.OtelAgentName)
+	require.Contains(t, gotContainers, reconcilermanager.Reconciler)
+	require.Contains(t, gotContainers, reconcilermanager.HydrationController)
+}
+
+func TestRootSyncWithPrometheusExporterAndTracing(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = func(de *appsv1.Deployment) error {
+		if err := parsedDeployment(de); err != nil {
+			return err
+		}
+		de.Spec.Template.Spec.Containers = append(de.Spec.Template.Spec.Containers,
+			corev1.Container{Name: metrics.OtelAgentName})
+		return nil
+	}
+
+	rs := rootSyncWithGit(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	_, fakeDynamicClient, testReconciler := setupRootReconciler(t, rs, secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)))
+	testReconciler.metricsExporter = metrics.PrometheusExporterType
+	testReconciler.traceSamplingProbability = 0.5
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	uObj, err := fakeDynamicClient.Resource(kinds.DeploymentResource()).
+		Namespace(configsync.ControllerNamespace).
+		Get(ctx, rootReconcilerName, metav1.GetOptions{})
+	require.NoError(t, err)
+	obj, err := kinds.ToTypedObject(uObj, core.Scheme)
+	require.NoError(t, err)
+	dep := obj.(*appsv1.Deployment)
+
+	// The otel-agent is kept to export the traces.
+	wantEnv := corev1.EnvVar{Name: reconcilermanager.TraceSamplingProbability, Value: "0.5"}
+	var gotContainers []string
+	for _, container := range dep.Spec.Template.Spec.Containers {
+		gotContainers = append(gotContainers, container.Name)
+		if container.Name == reconcilermanager.Reconciler || container.Name == reconcilermanager.HydrationController {
+			require.Contains(t, container.Env, wantEnv, "container %s", container.Name)
+		}
+	}
+	require.Contains(t, gotContainers, metrics.OtelAgentName)
+}
+
 func TestRootSyncUpdateNoSSLVerify(t *testing.T) {
 	// Mock out parseDeployment for testing.
 	parseDeployment = parsedDeployment
@@ -1028,6 +1163,121 @@ func TestRootSyncWithoutCACertSecret(t *testing.T) {
 	require.Equal(t, fmt.Sprintf("Secret %s not found, create one to allow client connections with CA certificate", caCertSecret), err.Error(), "unexpected function error")
 }
 
+func TestRootSyncCreateWithGitVerification(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+	keysSecret := "trusted-keys"
+	rs := rootSyncWithGit(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch),
+		rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey),
+		rootsyncGitVerification(keysSecret, v1beta1.AllCommitsSigned))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	_, fakeDynamicClient, testReconciler := setupRootReconciler(t, rs,
+		secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)),
+		fake.SecretObject(keysSecret, core.Namespace(rs.Namespace)))
+
+	// Test creating Deployment resources.
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	rootContainerEnvs := testReconciler.populateContainerEnvs(ctx, rs, rootReconcilerName)
+	require.Contains(t, rootContainerEnvs[reconcilermanager.Reconciler],
+		corev1.EnvVar{Name: reconcilermanager.GitVerificationPolicyKey, Value: string(v1beta1.AllCommitsSigned)})
+	// The history since the last synced commit is fetched to verify it.
+	require.Contains(t, rootContainerEnvs[reconcilermanager.GitSync],
+		corev1.EnvVar{Name: GitSyncDepth, Value: SyncDepthRev})
+	resourceOverrides := setContainerResourceDefaults(nil, ReconcilerContainerResourceDefaults())
+	rootDeployment := rootSyncDeployment(rootReconcilerName,
+		setServiceAccountName(rootReconcilerName),
+		secretMutator(rootsyncSSHKey),
+		gitVerificationMutator(keysSecret),
+		containerResourcesMutator(resourceOverrides),
+		containerEnvMutator(rootContainerEnvs),
+		setUID("1"), setResourceVersion("1"), setGeneration(1),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(rootDeployment): rootDeployment}
+
+	if err := validateDeployments(wantDeployments, fakeDynamicClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+	t.Log("Deployment successfully created")
+}
+
+func TestRootSyncWithoutGitVerificationSecret(t *testing.T) {
+	keysSecret := "trusted-keys"
+	rs := rootSyncWithGit(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch),
+		rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey),
+		rootsyncGitVerification(keysSecret, v1beta1.HeadSigned))
+
+	// no trusted keys secret is setup to trigger not found error
+	_, _, testReconciler := setupRootReconciler(t, rs, secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)))
+	ctx := context.Background()
+
+	err := testReconciler.validateGitSpec(ctx, rs, rootReconcilerName)
+	require.Error(t, err, "Function call should return an error")
+	require.Equal(t, fmt.Sprintf("Secret %s not found, create one with the trusted keys to verify the Git commit signatures", keysSecret), err.Error(), "unexpected function error")
+}
+
+func TestRootSyncCreateWithCommitStatus(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+	tokenSecret := "github-token"
+	rs := rootSyncWithGit(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch),
+		rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey),
+		rootsyncCommitStatus(v1beta1.GitHubProvider, tokenSecret))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	commitStatusSecret := fake.SecretObject(tokenSecret, core.Namespace(rs.Namespace))
+	commitStatusSecret.Data = map[string][]byte{CommitStatusTokenKey: []byte("test-token")}
+	_, fakeDynamicClient, testReconciler := setupRootReconciler(t, rs,
+		secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)),
+		commitStatusSecret)
+
+	// Test creating Deployment resources.
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	rootContainerEnvs := testReconciler.populateContainerEnvs(ctx, rs, rootReconcilerName)
+	require.Contains(t, rootContainerEnvs[reconcilermanager.Reconciler],
+		corev1.EnvVar{Name: reconcilermanager.CommitStatusProviderKey, Value: string(v1beta1.GitHubProvider)})
+	require.Contains(t, rootContainerEnvs[reconcilermanager.Reconciler],
+		corev1.EnvVar{Name: reconcilermanager.CommitStatusMaxErrorsKey, Value: "3"})
+	resourceOverrides := setContainerResourceDefaults(nil, ReconcilerContainerResourceDefaults())
+	rootDeployment := rootSyncDeployment(rootReconcilerName,
+		setServiceAccountName(rootReconcilerName),
+		secretMutator(rootsyncSSHKey),
+		containerResourcesMutator(resourceOverrides),
+		containerEnvMutator(rootContainerEnvs),
+		commitStatusMutator(tokenSecret),
+		setUID("1"), setResourceVersion("1"), setGeneration(1),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(rootDeployment): rootDeployment}
+
+	if err := validateDeployments(wantDeployments, fakeDynamicClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+	t.Log("Deployment successfully created")
+}
+
+func TestRootSyncWithInvalidCommitStatusSecret(t *testing.T) {
+	tokenSecret := "github-token"
+	rs := rootSyncWithGit(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch),
+		rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey),
+		rootsyncCommitStatus(v1beta1.GitHubProvider, tokenSecret))
+
+	// the token secret has no token key to trigger a validation error
+	_, _, testReconciler := setupRootReconciler(t, rs,
+		secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)),
+		fake.SecretObject(tokenSecret, core.Namespace(rs.Namespace)))
+	ctx := context.Background()
+
+	err := testReconciler.validateGitSpec(ctx, rs, rootReconcilerName)
+	require.Error(t, err, "Function call should return an error")
+	require.Equal(t, fmt.Sprintf("commitStatus.secretRef was set, but %s key is not present in %s Secret", CommitStatusTokenKey, tokenSecret), err.Error(), "unexpected function error")
+}
+
 func TestRootSyncCreateWithOverrideGitSyncDepth(t *testing.T) {
 	// Mock out parseDeployment for testing.
 	parseDeployment = parsedDeployment
@@ -2284,6 +2534,26 @@ func TestMultipleRootSyncs(t *testing.T) {
 	}
 	t.Log("Deployment successfully updated")
 
+	// The ConfigMaps storing the declared resources checkpoint, and the
+	// permissions to write them, are created for the reconciler.
+	for _, name := range declared.CheckpointNames(rootReconcilerName) {
+		cm := &corev1.ConfigMap{}
+		if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: name}, cm); err != nil {
+			t.Fatalf("failed to get the checkpoint ConfigMap, got error: %v, want error: nil", err)
+		}
+	}
+	checkpointRole := &rbacv1.Role{}
+	checkpointRoleKey := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: CheckpointPermissionsName(rootReconcilerName)}
+	if err := fakeClient.Get(ctx, checkpointRoleKey, checkpointRole); err != nil {
+		t.Fatalf("failed to get the checkpoint Role, got error: %v, want error: nil", err)
+	}
+	if diff := cmp.Diff(declared.CheckpointNames(rootReconcilerName), checkpointRole.Rules[0].ResourceNames); diff != "" {
+		t.Errorf("unexpected checkpoint Role resource names (-want +got):\n%s", diff)
+	}
+	if err := fakeClient.Get(ctx, checkpointRoleKey, &rbacv1.RoleBinding{}); err != nil {
+		t.Fatalf("failed to get the checkpoint RoleBinding, got error: %v, want error: nil", err)
+	}
+
 	// Test garbage collecting ClusterRoleBinding after all RootSyncs are deleted
 	rs1.ResourceVersion = "" // Skip ResourceVersion validation
 	if err := fakeClient.Delete(ctx, rs1); err != nil {
@@ -2406,6 +2676,20 @@ func validateRootGeneratedResourcesDeleted(t *testing.T, fakeClient *syncerFake.
 		t.Error(err)
 	}
 
+	// Verify the declared resources checkpoint and its permissions are deleted.
+	for _, name := range declared.CheckpointNames(reconcilerName) {
+		cm := fake.ConfigMapObject(core.Namespace(configsync.ControllerNamespace), core.Name(name))
+		if err := validateResourceDeleted(core.IDOf(cm), fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+	checkpointPermissions := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: CheckpointPermissionsName(reconcilerName)}
+	for _, gk := range []schema.GroupKind{kinds.Role().GroupKind(), kinds.RoleBinding().GroupKind()} {
+		if err := validateResourceDeleted(core.ID{GroupKind: gk, ObjectKey: checkpointPermissions}, fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+
 	// ReconcilerManager doesn't manage the RootSync Secret
 }
 
@@ -3324,6 +3608,36 @@ func TestPopulateRootContainerEnvs(t *testing.T) {
 				reconcilermanager.Reconciler: {reconcilermanager.RenderingEnabled: "true"},
 			}),
 		},
+		{
+			name: "conflict policy override sets env var",
+			rootSync: rootSyncWithGit(rootsyncName,
+				rootsyncOverrideConflictPolicy(configsync.ConflictPolicyRespect),
+				rootsyncRenderingRequired(false),
+			),
+			expected: createEnv(map[string]map[string]string{
+				reconcilermanager.Reconciler: {reconcilermanager.ConflictPolicy: "respect"},
+			}),
+		},
+		{
+			name: "policy enforcement override sets env var",
+			rootSync: rootSyncWithGit(rootsyncName,
+				rootsyncOverridePolicyEnforcement(configsync.PolicyEnforcementWarn),
+				rootsyncRenderingRequired(false),
+			),
+			expected: createEnv(map[string]map[string]string{
+				reconcilermanager.Reconciler: {reconcilermanager.PolicyEnforcement: "warn"},
+			}),
+		},
+		{
+			name: "ignore differences override sets env var",
+			rootSync: rootSyncWithGit(rootsyncName,
+				rootsyncOverrideIgnoreDifferences(v1beta1.IgnoreDifference{Group: "apps", Kind: "Deployment", JSONPaths: []string{".spec.replicas"}}),
+				rootsyncRenderingRequired(false),
+			),
+			expected: createEnv(map[string]map[string]string{
+				reconcilermanager.Reconciler: {reconcilermanager.IgnoreDifferences: `[{"group":"apps","kind":"Deployment","jsonPaths":[".spec.replicas"]}]`},
+			}),
+		},
 	}
 
 	ctx := context.Background()
@@ -3635,6 +3949,28 @@ func caCertSecretMutator(secretName, caCertSecretName string) depMutator {
 	}
 }
 
+func gitVerificationMutator(secretName string) depMutator {
+	return func(dep *appsv1.Deployment) {
+		dep.Spec.Template.Spec.Volumes = append(dep.Spec.Template.Spec.Volumes, gitVerificationVolumes(secretName)...)
+		for i, con := range dep.Spec.Template.Spec.Containers {
+			if con.Name == reconcilermanager.Reconciler {
+				dep.Spec.Template.Spec.Containers[i].VolumeMounts = append(con.VolumeMounts,
+					corev1.VolumeMount{Name: GitVerificationVolume, MountPath: GitVerificationPath, ReadOnly: true})
+			}
+		}
+	}
+}
+
+func commitStatusMutator(secretName string) depMutator {
+	return func(dep *appsv1.Deployment) {
+		for i, con := range dep.Spec.Template.Spec.Containers {
+			if con.Name == reconcilermanager.Reconciler {
+				dep.Spec.Template.Spec.Containers[i].Env = append(con.Env, commitStatusTokenEnv(secretName))
+			}
+		}
+	}
+}
+
 func envVarMutator(envName, secretName, key string) depMutator {
 	return func(dep *appsv1.Deployment) {
 		for i, con := range dep.Spec.Template.Spec.Containers {
@@ -36,6 +36,12 @@ func isUpsertedSecret(rs *v1beta1.RepoSync, secretName string) bool {
 	if shouldUpsertCACertSecret(rs) && secretName == ReconcilerResourceName(reconcilerName, v1beta1.GetSecretName(rs.Spec.Git.CACertSecretRef)) {
 		return true
 	}
+	if shouldUpsertGitVerificationSecret(rs) && secretName == ReconcilerResourceName(reconcilerName, v1beta1.GetSecretName(rs.Spec.Git.Verification.SecretRef)) {
+		return true
+	}
+	if shouldUpsertCommitStatusSecret(rs) && secretName == ReconcilerResourceName(reconcilerName, v1beta1.GetSecretName(rs.Spec.Git.CommitStatus.SecretRef)) {
+		return true
+	}
 	if shouldUpsertGitSecret(rs) && secretName == ReconcilerResourceName(reconcilerName, v1beta1.GetSecretName(rs.Spec.Git.SecretRef)) {
 		return true
 	}
@@ -49,6 +55,14 @@ func shouldUpsertCACertSecret(rs *v1beta1.RepoSync) bool {
 	return v1beta1.SourceType(rs.Spec.SourceType) == v1beta1.GitSource && rs.Spec.Git != nil && rs.Spec.CACertSecretRef != nil && useCACert(rs.Spec.CACertSecretRef.Name)
 }
 
+func shouldUpsertGitVerificationSecret(rs *v1beta1.RepoSync) bool {
+	return gitVerificationSecretName(rs.Spec.SourceType, rs.Spec.Git) != ""
+}
+
+func shouldUpsertCommitStatusSecret(rs *v1beta1.RepoSync) bool {
+	return commitStatusSecretName(rs.Spec.SourceType, rs.Spec.Git) != ""
+}
+
 func shouldUpsertGitSecret(rs *v1beta1.RepoSync) bool {
 	return v1beta1.SourceType(rs.Spec.SourceType) == v1beta1.GitSource && rs.Spec.Git != nil && rs.Spec.Git.SecretRef != nil && !SkipForAuth(rs.Spec.Auth)
 }
@@ -103,6 +117,43 @@ func (r *reconcilerBase) upsertCACertSecret(ctx context.Context, rs *v1beta1.Rep
 	return client.ObjectKey{}, nil
 }
 
+// upsertGitVerificationSecret creates or updates the secret with the trusted
+// keys of the Git commit signature verification in the
+// config-management-system namespace using an existing secret in the RepoSync
+// namespace.
+func (r *reconcilerBase) upsertGitVerificationSecret(ctx context.Context, rs *v1beta1.RepoSync, reconcilerRef types.NamespacedName) (client.ObjectKey, error) {
+	rsRef := client.ObjectKeyFromObject(rs)
+	if shouldUpsertGitVerificationSecret(rs) {
+		nsSecretRef, cmsSecretRef := getSecretRefs(rsRef, reconcilerRef, v1beta1.GetSecretName(rs.Spec.Git.Verification.SecretRef))
+		userSecret, err := getUserSecret(ctx, r.client, nsSecretRef)
+		if err != nil {
+			return cmsSecretRef, errors.Wrap(err, "user secret required for git commit signature verification")
+		}
+		_, err = r.upsertSecret(ctx, cmsSecretRef, rsRef, userSecret)
+		return cmsSecretRef, err
+	}
+	// No secret required
+	return client.ObjectKey{}, nil
+}
+
+// upsertCommitStatusSecret creates or updates the secret with the token of the
+// commit status reports in the config-management-system namespace using an
+// existing secret in the RepoSync namespace.
+func (r *reconcilerBase) upsertCommitStatusSecret(ctx context.Context, rs *v1beta1.RepoSync, reconcilerRef types.NamespacedName) (client.ObjectKey, error) {
+	rsRef := client.ObjectKeyFromObject(rs)
+	if shouldUpsertCommitStatusSecret(rs) {
+		nsSecretRef, cmsSecretRef := getSecretRefs(rsRef, reconcilerRef, v1beta1.GetSecretName(rs.Spec.Git.CommitStatus.SecretRef))
+		userSecret, err := getUserSecret(ctx, r.client, nsSecretRef)
+		if err != nil {
+			return cmsSecretRef, errors.Wrap(err, "user secret required for git commit status reports")
+		}
+		_, err = r.upsertSecret(ctx, cmsSecretRef, rsRef, userSecret)
+		return cmsSecretRef, err
+	}
+	// No secret required
+	return client.ObjectKey{}, nil
+}
+
 func getSecretRefs(rsRef, reconcilerRef client.ObjectKey, secretName string) (nsSecretRef, cmsSecretRef client.ObjectKey) {
 	// User managed secret
 	nsSecretRef = client.ObjectKey{
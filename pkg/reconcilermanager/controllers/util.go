@@ -15,6 +15,7 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -46,11 +47,14 @@ func updateHydrationControllerImage(image string, overrides v1beta1.OverrideSpec
 func hydrationEnvs(sourceType string, gitConfig *v1beta1.Git, ociConfig *v1beta1.Oci, scope declared.Scope, reconcilerName, pollPeriod string) []corev1.EnvVar {
 	var result []corev1.EnvVar
 	var syncDir string
+	var syncInclude, syncExclude []string
 	switch v1beta1.SourceType(sourceType) {
 	case v1beta1.OciSource:
 		syncDir = ociConfig.Dir
 	case v1beta1.GitSource:
 		syncDir = gitConfig.Dir
+		syncInclude = gitConfig.Include
+		syncExclude = gitConfig.Exclude
 	case v1beta1.HelmSource:
 		syncDir = "."
 	}
@@ -81,6 +85,19 @@ func hydrationEnvs(sourceType string, gitConfig *v1beta1.Git, ociConfig *v1beta1
 			Name:  reconcilermanager.HydrationPollingPeriod,
 			Value: pollPeriod,
 		})
+	// The include and exclude patterns select the source files to render.
+	if len(syncInclude) > 0 {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.SyncIncludeKey,
+			Value: strings.Join(syncInclude, ","),
+		})
+	}
+	if len(syncExclude) > 0 {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.SyncExcludeKey,
+			Value: strings.Join(syncExclude, ","),
+		})
+	}
 	return result
 }
 
@@ -94,6 +111,9 @@ func reconcilerEnvs(clusterName, syncName string, syncGeneration int64, reconcil
 	var syncBranch string
 	var syncRevision string
 	var syncDir string
+	var syncInclude, syncExclude []string
+	var verificationPolicy v1beta1.GitVerificationPolicy
+	var commitStatus *v1beta1.GitCommitStatus
 	switch v1beta1.SourceType(sourceType) {
 	case v1beta1.OciSource:
 		syncRepo = ociConfig.Image
@@ -109,6 +129,15 @@ func reconcilerEnvs(clusterName, syncName string, syncGeneration int64, reconcil
 	case v1beta1.GitSource:
 		syncRepo = gitConfig.Repo
 		syncDir = gitConfig.Dir
+		syncInclude = gitConfig.Include
+		syncExclude = gitConfig.Exclude
+		if gitConfig.Verification != nil {
+			verificationPolicy = gitConfig.Verification.Policy
+			if verificationPolicy == "" {
+				verificationPolicy = v1beta1.HeadSigned
+			}
+		}
+		commitStatus = gitConfig.CommitStatus
 		if gitConfig.Branch != "" {
 			syncBranch = gitConfig.Branch
 		} else {
@@ -193,9 +222,65 @@ func reconcilerEnvs(clusterName, syncName string, syncGeneration int64, reconcil
 			Value: syncRevision,
 		})
 	}
+	if len(syncInclude) > 0 {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.SyncIncludeKey,
+			Value: strings.Join(syncInclude, ","),
+		})
+	}
+	if len(syncExclude) > 0 {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.SyncExcludeKey,
+			Value: strings.Join(syncExclude, ","),
+		})
+	}
+	if verificationPolicy != "" {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.GitVerificationPolicyKey,
+			Value: string(verificationPolicy),
+		})
+	}
+	if commitStatus != nil {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.CommitStatusProviderKey,
+			Value: string(commitStatus.Provider),
+		})
+		if commitStatus.URL != "" {
+			result = append(result, corev1.EnvVar{
+				Name:  reconcilermanager.CommitStatusURLKey,
+				Value: commitStatus.URL,
+			})
+		}
+		if commitStatus.Project != "" {
+			result = append(result, corev1.EnvVar{
+				Name:  reconcilermanager.CommitStatusProjectKey,
+				Value: commitStatus.Project,
+			})
+		}
+		if commitStatus.MaxErrors > 0 {
+			result = append(result, corev1.EnvVar{
+				Name:  reconcilermanager.CommitStatusMaxErrorsKey,
+				Value: strconv.Itoa(commitStatus.MaxErrors),
+			})
+		}
+	}
 	return result
 }
 
+// commitStatusTokenEnv returns the environment variable of the reconciler
+// container with the token of the commit status reports.
+func commitStatusTokenEnv(secretName string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: reconcilermanager.CommitStatusTokenKey,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  CommitStatusTokenKey,
+			},
+		},
+	}
+}
+
 // sourceFormatEnv returns the environment variable for SOURCE_FORMAT in the reconciler container.
 func sourceFormatEnv(format string) corev1.EnvVar {
 	return corev1.EnvVar{
@@ -215,6 +300,44 @@ func namespaceStrategyEnv(strategy configsync.NamespaceStrategy) corev1.EnvVar {
 	}
 }
 
+// conflictPolicyEnvs returns the environment variables for CONFLICT_POLICY in
+// the reconciler container, if the policy is set.
+func conflictPolicyEnvs(policy configsync.ConflictPolicy) []corev1.EnvVar {
+	if policy == "" {
+		return nil
+	}
+	return []corev1.EnvVar{{
+		Name:  reconcilermanager.ConflictPolicy,
+		Value: string(policy),
+	}}
+}
+
+// policyEnforcementEnvs returns the environment variables for
+// POLICY_ENFORCEMENT in the reconciler container, if the enforcement is set.
+func policyEnforcementEnvs(enforcement configsync.PolicyEnforcement) []corev1.EnvVar {
+	if enforcement == "" {
+		return nil
+	}
+	return []corev1.EnvVar{{
+		Name:  reconcilermanager.PolicyEnforcement,
+		Value: string(enforcement),
+	}}
+}
+
+// ignoreDifferencesEnvs returns the environment variables for
+// IGNORE_DIFFERENCES in the reconciler container, if any fields are ignored.
+func ignoreDifferencesEnvs(diffs []v1beta1.IgnoreDifference) []corev1.EnvVar {
+	if len(diffs) == 0 {
+		return nil
+	}
+	// Marshaling strings never fails.
+	value, _ := json.Marshal(diffs)
+	return []corev1.EnvVar{{
+		Name:  reconcilermanager.IgnoreDifferences,
+		Value: string(value),
+	}}
+}
+
 // ociSyncEnvs returns the environment variables for the oci-sync container.
 func ociSyncEnvs(image string, auth configsync.AuthType, period float64) []corev1.EnvVar {
 	var result []corev1.EnvVar
@@ -38,9 +38,20 @@ const CACertVolume = "ca-cert"
 // CACertSecretKey is the name of the key in the Secret's data map whose value holds the CA cert
 const CACertSecretKey = "cert"
 
+// CommitStatusTokenKey is the key of the token in the secret of the commit
+// status reports.
+const CommitStatusTokenKey = "token"
+
 // CACertPath is the path where the certificate is mounted.
 const CACertPath = "/etc/ca-cert"
 
+// GitVerificationVolume is the volume name of the trusted keys used to verify
+// the signatures of the Git commits.
+const GitVerificationVolume = "git-verification"
+
+// GitVerificationPath is the path where the trusted keys are mounted.
+const GitVerificationPath = "/etc/git-verification"
+
 // defaultMode is the default permission of the `gcp-ksa` volume.
 var defaultMode int32 = 0644
 
@@ -148,3 +159,56 @@ func volumeMounts(auth configsync.AuthType, caCertSecretRef, sourceType string,
 	})
 	return volumeMount
 }
+
+// gitVerificationSecretName returns the name of the secret with the trusted
+// keys of the Git commit signature verification, or an empty string if the
+// signatures are not verified.
+func gitVerificationSecretName(sourceType string, git *v1beta1.Git) string {
+	if v1beta1.SourceType(sourceType) != v1beta1.GitSource || git == nil || git.Verification == nil {
+		return ""
+	}
+	return v1beta1.GetSecretName(git.Verification.SecretRef)
+}
+
+// commitStatusSecretName returns the name of the secret with the token of the
+// commit status reports, or an empty string if there is none.
+func commitStatusSecretName(sourceType string, git *v1beta1.Git) string {
+	if v1beta1.SourceType(sourceType) != v1beta1.GitSource || git == nil || git.CommitStatus == nil {
+		return ""
+	}
+	return v1beta1.GetSecretName(git.CommitStatus.SecretRef)
+}
+
+// verifyAllCommits returns true if the signatures of all the commits since the
+// last synced commit must be verified.
+func verifyAllCommits(git *v1beta1.Git) bool {
+	return git != nil && git.Verification != nil && git.Verification.Policy == v1beta1.AllCommitsSigned
+}
+
+// gitVerificationVolumes returns the volumes of the trusted keys of the Git
+// commit signature verification.
+func gitVerificationVolumes(secretName string) []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: GitVerificationVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  secretName,
+					DefaultMode: &defaultMode,
+				},
+			},
+		},
+	}
+}
+
+// gitVerificationVolumeMounts returns the VolumeMounts of the Git commit
+// signature verification in the reconciler container.
+func gitVerificationVolumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			MountPath: GitVerificationPath,
+			Name:      GitVerificationVolume,
+			ReadOnly:  true,
+		},
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"time"
 
+	"go.opencensus.io/trace"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
@@ -29,6 +30,7 @@ import (
 	syncerclient "kpt.dev/configsync/pkg/syncer/client"
 	syncerreconcile "kpt.dev/configsync/pkg/syncer/reconcile"
 	"kpt.dev/configsync/pkg/syncer/reconcile/fight"
+	"kpt.dev/configsync/pkg/tracing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -71,6 +73,9 @@ func newReconciler(
 // ensures that the version on the server matches it.
 func (r *reconciler) Remediate(ctx context.Context, id core.ID, obj client.Object) status.Error {
 	start := time.Now()
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanRemediate,
+		trace.StringAttribute(tracing.KeySyncName, r.syncName),
+		trace.StringAttribute(tracing.KeyObjectID, id.String()))
 
 	declU, commit, found := r.declared.Get(id)
 	// Yes, this if block is necessary because Go is pedantic about nil interfaces.
@@ -84,8 +89,15 @@ func (r *reconciler) Remediate(ctx context.Context, id core.ID, obj client.Objec
 		Declared: decl,
 		Actual:   obj,
 	}
+	if span.IsRecordingEvents() {
+		// Only compute the operation when the span is sampled.
+		span.AddAttributes(
+			trace.StringAttribute(tracing.KeyCommit, commit),
+			trace.StringAttribute(tracing.KeyOperation, string(objDiff.Operation(r.scope, r.syncName))))
+	}
 
 	err := r.remediate(ctx, id, objDiff)
+	tracing.EndSpan(span, err)
 
 	// Record duration, even if there's an error
 	metrics.RecordRemediateDuration(ctx, metrics.StatusTagKey(err), start)
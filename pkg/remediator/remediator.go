@@ -22,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/remediator/conflict"
 	"kpt.dev/configsync/pkg/remediator/queue"
@@ -30,6 +31,7 @@ import (
 	"kpt.dev/configsync/pkg/status"
 	syncerreconcile "kpt.dev/configsync/pkg/syncer/reconcile"
 	"kpt.dev/configsync/pkg/syncer/reconcile/fight"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Remediator knows how to keep the state of a Kubernetes cluster in sync with
@@ -77,6 +79,10 @@ type Interface interface {
 	// UpdateWatches starts and stops server-side watches based upon the given map
 	// of GVKs which should be watched.
 	UpdateWatches(context.Context, map[schema.GroupVersionKind]struct{}) status.MultiError
+	// Refresh restarts the watches of the types of the given objects and
+	// clears the cached fight errors of the objects, so that their current
+	// state is remediated again.
+	Refresh(context.Context, []client.Object) status.MultiError
 	// ManagementConflict returns true if one of the watchers noticed a management conflict.
 	ManagementConflict() bool
 	// ConflictErrors returns the errors the remediator encounters.
@@ -118,9 +124,10 @@ func New(scope declared.Scope, syncName string, cfg *rest.Config, applier syncer
 }
 
 // Start the Remediator's asynchronous reconcile workers.
+// If paused is true, the workers are not started until Resume is called.
 // Returns a done channel that will be closed after the context is cancelled and
 // all the workers have exited.
-func (r *Remediator) Start(ctx context.Context) <-chan struct{} {
+func (r *Remediator) Start(ctx context.Context, paused bool) <-chan struct{} {
 	r.lifecycleMux.Lock()
 	defer r.lifecycleMux.Unlock()
 
@@ -130,8 +137,17 @@ func (r *Remediator) Start(ctx context.Context) <-chan struct{} {
 
 	klog.V(1).Info("Remediator starting...")
 	r.parentContext = ctx
-	r.startWorkers()
-	klog.V(3).Info("Remediator started")
+	if paused {
+		// No workers to stop or wait for until Resume is called.
+		doneCh := make(chan struct{})
+		close(doneCh)
+		r.doneCh = doneCh
+		r.stopFn = func() {}
+		klog.V(3).Info("Remediator started paused")
+	} else {
+		r.startWorkers()
+		klog.V(3).Info("Remediator started")
+	}
 
 	doneCh := make(chan struct{})
 	go func() {
@@ -200,6 +216,16 @@ func (r *Remediator) UpdateWatches(ctx context.Context, gvks map[schema.GroupVer
 	return r.watchMgr.UpdateWatches(ctx, gvks)
 }
 
+// Refresh implements Interface.
+func (r *Remediator) Refresh(ctx context.Context, objs []client.Object) status.MultiError {
+	gvks := make(map[schema.GroupVersionKind]struct{})
+	for _, obj := range objs {
+		gvks[obj.GetObjectKind().GroupVersionKind()] = struct{}{}
+		r.fightHandler.RemoveFightError(core.IDOf(obj))
+	}
+	return r.watchMgr.RestartWatches(ctx, gvks)
+}
+
 // ManagementConflict implements Interface.
 func (r *Remediator) ManagementConflict() bool {
 	return r.watchMgr.ManagementConflict()
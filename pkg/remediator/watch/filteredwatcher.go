@@ -38,6 +38,7 @@ import (
 	"kpt.dev/configsync/pkg/remediator/conflict"
 	"kpt.dev/configsync/pkg/remediator/queue"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/differ"
 	"kpt.dev/configsync/pkg/util/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -510,6 +511,20 @@ func (w *filteredWatcher) shouldProcess(object client.Object) bool {
 		return false
 	}
 
+	if differ.HandedOff(decl, object) {
+		// The declaration hands off the management to the current manager, so
+		// it is not a conflict.
+		return false
+	}
+
+	if differ.HandoffPending(decl, object) {
+		// The current manager hands off the management to this reconciler,
+		// whose applier claims the object on its next apply, so it is not a
+		// conflict.
+		w.conflictHandler.RemoveConflictError(gvknn)
+		return false
+	}
+
 	if !diff.CanManage(w.scope, w.syncName, object, diff.OperationManage) {
 		w.SetManagementConflict(object, commit)
 		return false
@@ -32,6 +32,7 @@ import (
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff/difftest"
 	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/remediator/queue"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/syncer/syncertest"
@@ -64,6 +65,9 @@ func TestFilteredWatcher(t *testing.T) {
 	managedByOtherDeployment := fake.DeploymentObject(core.Name("not-declared"),
 		syncertest.ManagementEnabled, difftest.ManagedBy("other", "other-rs"))
 	deploymentForRoot := fake.DeploymentObject(core.Name("managed-by-root"), difftest.ManagedBy(declared.RootReconciler, "any-rs"))
+	deploymentToHandoff := fake.DeploymentObject(core.Name("handoff"), difftest.ManagedBy(scope, syncName))
+	deploymentHandedOff := fake.DeploymentObject(core.Name("handoff"), difftest.ManagedBy(declared.RootReconciler, "any-rs"),
+		core.Annotation(metadata.ResourceHandoffKey, declared.ResourceManager(scope, syncName)))
 
 	testCases := []struct {
 		name     string
@@ -173,6 +177,22 @@ func TestFilteredWatcher(t *testing.T) {
 			}},
 			want: nil,
 		},
+		{
+			name: "Filter events for declared resource handed off to this reconciler",
+			declared: []client.Object{
+				deploymentToHandoff,
+			},
+			watches: [][]action{{
+				{
+					event: watch.Modified,
+					obj:   deploymentHandedOff,
+				},
+				{
+					stopRun: true,
+				},
+			}},
+			want: nil,
+		},
 		{
 			name: "Filter events for declared resource with different GVK",
 			declared: []client.Object{
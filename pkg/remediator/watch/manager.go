@@ -171,6 +171,33 @@ func (m *Manager) UpdateWatches(ctx context.Context, gvkMap map[schema.GroupVers
 	return errs
 }
 
+// RestartWatches restarts the watchers of the given GVKs which are currently
+// watched, so that the current state of their objects is listed again and
+// queued for remediation. The management conflict errors of the GVKs are
+// cleared, and reported again if they persist.
+//
+// This function is threadsafe.
+func (m *Manager) RestartWatches(ctx context.Context, gvkMap map[schema.GroupVersionKind]struct{}) status.MultiError {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	klog.V(3).Infof("RestartWatches(%v)", gvkMap)
+
+	var errs status.MultiError
+	for gvk := range gvkMap {
+		if _, isWatched := m.watcherMap[gvk]; !isWatched {
+			// UpdateWatches starts the watcher after the next apply.
+			continue
+		}
+		m.stopWatcher(gvk)
+		if err := m.startWatcher(ctx, gvk); err != nil {
+			errs = status.Append(errs, err)
+			m.needsUpdate = true
+		}
+	}
+	return errs
+}
+
 // watchedGVKs returns a list of all GroupVersionKinds currently being watched.
 func (m *Manager) watchedGVKs() []schema.GroupVersionKind {
 	var gvks []schema.GroupVersionKind
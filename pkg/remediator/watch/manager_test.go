@@ -161,6 +161,40 @@ func TestManager_Update(t *testing.T) {
 	}
 }
 
+func TestManager_RestartWatches(t *testing.T) {
+	options := &Options{
+		watcherFactory: testRunnables(map[schema.GroupVersionKind]bool{}),
+	}
+	m, err := NewManager(":test", "rs", nil, nil, &declared.Resources{}, options, fake.NewConflictHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleWatcher := fakeRunnable()
+	m.watcherMap = map[schema.GroupVersionKind]Runnable{
+		kinds.Namespace(): fakeRunnable(),
+		kinds.Role():      roleWatcher,
+	}
+
+	err = m.RestartWatches(context.Background(), map[schema.GroupVersionKind]struct{}{
+		kinds.Role():        {},
+		kinds.RoleBinding(): {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Types which are not watched are not started.
+	want := []schema.GroupVersionKind{kinds.Namespace(), kinds.Role()}
+	if diff := cmp.Diff(want, m.watchedGVKs(), cmpopts.SortSlices(sortGVKs)); diff != "" {
+		t.Error(diff)
+	}
+	if m.watcherMap[kinds.Role()] == roleWatcher {
+		t.Error("got the same Role watcher, want a new watcher")
+	}
+	if m.NeedsUpdate() {
+		t.Error("got NeedsUpdate() = true, want false")
+	}
+}
+
 func sortGVKs(l, r schema.GroupVersionKind) bool {
 	return l.String() < r.String()
 }
@@ -0,0 +1,115 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/kinds"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeClient is a client.Client which stores ResourceGroups as unstructured
+// objects, like the API server does with the fields of the CRD schema. The
+// fake client of syncertest converts them to typed ResourceGroups, which
+// drops the status written by Config Sync.
+type fakeClient struct {
+	client.Client
+	objs map[client.ObjectKey]*unstructured.Unstructured
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) *fakeClient {
+	c := &fakeClient{objs: map[client.ObjectKey]*unstructured.Unstructured{}}
+	for _, obj := range objs {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		require.NoError(t, err)
+		u := &unstructured.Unstructured{Object: content}
+		u.SetGroupVersionKind(kinds.ResourceGroup())
+		c.objs[client.ObjectKeyFromObject(obj)] = u
+	}
+	return c
+}
+
+// Get implements client.Client.
+func (c *fakeClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	stored, found := c.objs[key]
+	if !found {
+		return apierrors.NewNotFound(kinds.ResourceGroup().GroupVersion().WithResource("resourcegroups").GroupResource(), key.Name)
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.Object = stored.DeepCopy().Object
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(stored.DeepCopy().Object, obj)
+}
+
+// Update implements client.Client. It only updates the metadata and the spec.
+func (c *fakeClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	return c.update(obj, "metadata", "spec")
+}
+
+// Status implements client.Client.
+func (c *fakeClient) Status() client.SubResourceWriter {
+	return &fakeStatusWriter{client: c}
+}
+
+// update replaces the fields of the stored object, and fails with a conflict
+// if the resource version of obj is not the stored one.
+func (c *fakeClient) update(obj client.Object, fields ...string) error {
+	key := client.ObjectKeyFromObject(obj)
+	stored, found := c.objs[key]
+	if !found {
+		return apierrors.NewNotFound(kinds.ResourceGroup().GroupVersion().WithResource("resourcegroups").GroupResource(), key.Name)
+	}
+	if obj.GetResourceVersion() != stored.GetResourceVersion() {
+		return apierrors.NewConflict(kinds.ResourceGroup().GroupVersion().WithResource("resourcegroups").GroupResource(), key.Name, nil)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if field == "metadata" {
+			continue
+		}
+		value, found := content[field]
+		if found {
+			stored.Object[field] = runtime.DeepCopyJSONValue(value)
+		} else {
+			delete(stored.Object, field)
+		}
+	}
+	if len(fields) > 1 {
+		stored.SetAnnotations(obj.GetAnnotations())
+	}
+	stored.SetResourceVersion(stored.GetResourceVersion() + "1")
+	obj.SetResourceVersion(stored.GetResourceVersion())
+	return nil
+}
+
+type fakeStatusWriter struct {
+	client.SubResourceWriter
+	client *fakeClient
+}
+
+// Update implements client.SubResourceWriter. It only updates the status.
+func (w *fakeStatusWriter) Update(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+	return w.client.update(obj, "status")
+}
@@ -12,7 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package runner
+// Package controllers runs the ResourceGroup controllers of the
+// resource-group-controller, extended for the ResourceGroups of Config Sync.
+package controllers
 
 import (
 	"flag"
@@ -32,16 +34,21 @@ import (
 	"kpt.dev/resourcegroup/controllers/root"
 	"kpt.dev/resourcegroup/controllers/typeresolver"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
-	// +kubebuilder:scaffold:imports
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
-var (
-	scheme   = runtime.NewScheme()
-	setupLog = ctrl.Log.WithName("setup")
-)
+var setupLog = ctrl.Log.WithName("setup")
 
 // Run starts all controllers and returns an integer exit code.
+//
+// It runs the Root and ResourceGroup controllers of kpt.dev/resourcegroup,
+// like its runner does, with two extensions for Config Sync:
+//   - The ResourceGroup controller keeps the status fields and conditions
+//     written by Config Sync when it updates the status of a ResourceGroup.
+//   - The Shard controller aggregates the status of the shards of a sharded
+//     inventory in its first ResourceGroup.
 func Run() int {
 	if err := run(); err != nil {
 		setupLog.Error(err, "exiting")
@@ -51,11 +58,9 @@ func Run() int {
 }
 
 func run() error {
+	scheme := runtime.NewScheme()
 	_ = clientgoscheme.AddToScheme(scheme)
-
 	_ = v1alpha1.AddToScheme(scheme)
-	// +kubebuilder:scaffold:scheme
-
 	_ = apiextensionsv1.AddToScheme(scheme)
 
 	log.InitFlags()
@@ -80,12 +85,12 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("failed to register the OC Agent exporter: %w", err)
 	}
-
 	defer func() {
 		if err := oce.Stop(); err != nil {
 			setupLog.Error(err, "Unable to stop the OC Agent exporter")
 		}
 	}()
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
@@ -98,15 +103,10 @@ func run() error {
 	}
 
 	logger := ctrl.Log.WithName("controllers")
-
-	for _, group := range []string{root.KptGroup} {
-		if err := registerControllersForGroup(mgr, logger, group); err != nil {
-			return fmt.Errorf("failed to register controllers for group %s: %w", group, err)
-		}
+	if err := registerControllers(mgr, logger); err != nil {
+		return fmt.Errorf("failed to register controllers for group %s: %w", root.KptGroup, err)
 	}
 
-	// +kubebuilder:scaffold:builder
-
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		return fmt.Errorf("failed to start controller-manager: %w", err)
@@ -114,7 +114,7 @@ func run() error {
 	return nil
 }
 
-func registerControllersForGroup(mgr ctrl.Manager, logger logr.Logger, group string) error {
+func registerControllers(mgr ctrl.Manager, logger logr.Logger) error {
 	// channel is watched by ResourceGroup controller.
 	// The Root controller pushes events to it and
 	// the ResourceGroup controller consumes events.
@@ -127,15 +127,40 @@ func registerControllersForGroup(mgr ctrl.Manager, logger logr.Logger, group str
 	}
 	resolver.Refresh()
 
-	setupLog.Info("adding the Root controller for group " + group)
+	// The controllers of kpt.dev/resourcegroup write the status from typed
+	// ResourceGroups, which have no field for the status written by Config
+	// Sync, so they get a client which keeps it.
+	statusMgr := &statusManager{
+		Manager: mgr,
+		client:  &statusClient{Client: mgr.GetClient()},
+	}
+
+	setupLog.Info("adding the Root controller for group " + root.KptGroup)
 	resMap := resourcemap.NewResourceMap()
-	if err := root.NewController(mgr, channel, logger.WithName("Root"), resolver, group, resMap); err != nil {
-		return fmt.Errorf("unable to create the root controller for group %s: %w", group, err)
+	if err := root.NewController(statusMgr, channel, logger.WithName("Root"), resolver, root.KptGroup, resMap); err != nil {
+		return fmt.Errorf("unable to create the root controller: %w", err)
+	}
+
+	setupLog.Info("adding the ResourceGroup controller for group " + root.KptGroup)
+	if err := resourcegroup.NewRGController(statusMgr, channel, logger.WithName(v1alpha1.ResourceGroupKind), resolver, resMap, resourcegroup.DefaultDuration); err != nil {
+		return fmt.Errorf("unable to create the ResourceGroup controller: %w", err)
 	}
 
-	setupLog.Info("adding the ResourceGroup controller for group " + group)
-	if err := resourcegroup.NewRGController(mgr, channel, logger.WithName(v1alpha1.ResourceGroupKind), resolver, resMap, resourcegroup.DefaultDuration); err != nil {
-		return fmt.Errorf("unable to create the ResourceGroup controller %s: %w", group, err)
+	setupLog.Info("adding the Shard controller")
+	shardController := &ShardController{Client: mgr.GetClient()}
+	if err := shardController.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to create the Shard controller: %w", err)
 	}
 	return nil
 }
+
+// statusManager is a manager.Manager which returns a statusClient.
+type statusManager struct {
+	manager.Manager
+	client client.Client
+}
+
+// GetClient implements manager.Manager.
+func (m *statusManager) GetClient() client.Client {
+	return m.client
+}
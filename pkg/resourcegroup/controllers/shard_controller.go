@@ -0,0 +1,247 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// shardsReconciledReason is the reason of the ShardsReconciled condition
+	// once all the shards are reconciled.
+	shardsReconciledReason = "ShardsReconciled"
+	// shardFailedReason is the reason of the ShardsReconciled condition if a
+	// shard is stalled or missing.
+	shardFailedReason = "ShardFailed"
+	// shardInProgressReason is the reason of the ShardsReconciled condition
+	// while a shard is being reconciled.
+	shardInProgressReason = "ShardInProgress"
+)
+
+// ShardController aggregates the status of the shards of a sharded inventory
+// in the status of its first ResourceGroup, which records the number of
+// shards in the ResourceGroupShardsKey annotation.
+//
+// The ResourceGroup controller reconciles each shard as a separate
+// ResourceGroup. The ShardController lists the status of the other shards in
+// the ShardStatusesField of the first ResourceGroup, and sets its
+// ShardsReconciled condition, so that the first ResourceGroup reports the
+// status of the whole inventory. The events of the other shards are mapped to
+// the first ResourceGroup.
+type ShardController struct {
+	Client client.Client
+}
+
+// SetupWithManager registers the ShardController with the manager.
+func (c *ShardController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("Shard").
+		Watches(&source.Kind{Type: &v1alpha1.ResourceGroup{}},
+			handler.EnqueueRequestsFromMapFunc(inventoryRequests)).
+		Complete(c)
+}
+
+// inventoryRequests maps the events of a ResourceGroup to itself and, if it
+// may be a shard of another ResourceGroup, to that ResourceGroup.
+func inventoryRequests(obj client.Object) []reconcile.Request {
+	requests := []reconcile.Request{
+		{NamespacedName: client.ObjectKeyFromObject(obj)},
+	}
+	if name, _, found := resourcegroup.ParseShardName(obj.GetName()); found {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name},
+		})
+	}
+	return requests
+}
+
+// Reconcile updates the shard statuses of the ResourceGroup. It removes them
+// once the inventory is no longer sharded.
+func (c *ShardController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	rg := &v1alpha1.ResourceGroup{}
+	if err := c.Client.Get(ctx, req.NamespacedName, rg); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if rg.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	_, sharded := rg.GetAnnotations()[metadata.ResourceGroupShardsKey]
+	if !sharded && findCondition(rg.Status.Conditions, resourcegroup.ShardsReconciledCondition) == nil {
+		// Neither sharded, nor previously sharded.
+		return reconcile.Result{}, nil
+	}
+
+	// The typed ResourceGroup has no field for the shard statuses.
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(kinds.ResourceGroup())
+	if err := c.Client.Get(ctx, req.NamespacedName, live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	updated := live.DeepCopy()
+	if count := resourcegroup.ShardCount(live); count > 1 {
+		statuses, err := c.shardStatuses(ctx, rg, count)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := setShardStatuses(updated, statuses); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else if err := removeShardStatuses(updated); err != nil {
+		return reconcile.Result{}, err
+	}
+	if apiequality.Semantic.DeepEqual(live.Object, updated.Object) {
+		return reconcile.Result{}, nil
+	}
+	if err := c.Client.Status().Update(ctx, updated); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update the shard statuses of ResourceGroup %s: %w", req.NamespacedName, err)
+	}
+	klog.V(3).Infof("Updated the shard statuses of ResourceGroup %s", req.NamespacedName)
+	return reconcile.Result{}, nil
+}
+
+// shardStatuses returns the status of the shards of the inventory, other than
+// the first one.
+func (c *ShardController) shardStatuses(ctx context.Context, rg *v1alpha1.ResourceGroup, count int) ([]resourcegroup.ShardStatus, error) {
+	var statuses []resourcegroup.ShardStatus
+	for i := 1; i < count; i++ {
+		name := resourcegroup.ShardName(rg.Name, i)
+		shard := &v1alpha1.ResourceGroup{}
+		err := c.Client.Get(ctx, types.NamespacedName{Namespace: rg.Namespace, Name: name}, shard)
+		switch {
+		case apierrors.IsNotFound(err):
+			statuses = append(statuses, resourcegroup.ShardStatus{Name: name, Status: v1alpha1.NotFound})
+			continue
+		case err != nil:
+			return nil, err
+		case shard.Labels[common.InventoryLabel] != rg.Labels[common.InventoryLabel]:
+			// A ResourceGroup of another inventory is not a shard.
+			statuses = append(statuses, resourcegroup.ShardStatus{Name: name, Status: v1alpha1.NotFound})
+			continue
+		}
+		statuses = append(statuses, shardStatus(shard))
+	}
+	return statuses, nil
+}
+
+// shardStatus returns the status of a shard, computed from its conditions like
+// the status of any other ResourceGroup.
+func shardStatus(shard *v1alpha1.ResourceGroup) resourcegroup.ShardStatus {
+	s := resourcegroup.ShardStatus{
+		Name:          shard.Name,
+		ResourceCount: len(shard.Spec.Resources),
+	}
+	for _, res := range shard.Status.ResourceStatuses {
+		if res.Status == v1alpha1.Current {
+			s.CurrentCount++
+		}
+	}
+	switch {
+	case isConditionTrue(shard.Status.Conditions, string(v1alpha1.Stalled)):
+		s.Status = v1alpha1.Failed
+	case isConditionTrue(shard.Status.Conditions, string(v1alpha1.Reconciling)),
+		shard.Status.ObservedGeneration != shard.Generation:
+		s.Status = v1alpha1.InProgress
+	default:
+		s.Status = v1alpha1.Current
+	}
+	return s
+}
+
+// setShardStatuses sets the shard statuses and the ShardsReconciled condition
+// of the first shard.
+func setShardStatuses(rg *unstructured.Unstructured, statuses []resourcegroup.ShardStatus) error {
+	var value []interface{}
+	for i := range statuses {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&statuses[i])
+		if err != nil {
+			return err
+		}
+		value = append(value, content)
+	}
+	if err := unstructured.SetNestedSlice(rg.Object, value, "status", resourcegroup.ShardStatusesField); err != nil {
+		return err
+	}
+
+	condition := v1alpha1.Condition{
+		Type:   resourcegroup.ShardsReconciledCondition,
+		Status: v1alpha1.TrueConditionStatus,
+		Reason: shardsReconciledReason,
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.Status == v1alpha1.Current {
+			continue
+		}
+		pending = append(pending, fmt.Sprintf("%s (%s)", s.Name, s.Status))
+		condition.Status = v1alpha1.FalseConditionStatus
+		if s.Status == v1alpha1.Failed || s.Status == v1alpha1.NotFound {
+			condition.Reason = shardFailedReason
+		} else if condition.Reason != shardFailedReason {
+			condition.Reason = shardInProgressReason
+		}
+	}
+	if len(pending) > 0 {
+		condition.Message = "The following shards are not reconciled: " + strings.Join(pending, ", ")
+	}
+	return resourcegroup.SetCondition(rg, condition)
+}
+
+// removeShardStatuses removes the shard statuses and the ShardsReconciled
+// condition of a ResourceGroup which is no longer sharded.
+func removeShardStatuses(rg *unstructured.Unstructured) error {
+	unstructured.RemoveNestedField(rg.Object, "status", resourcegroup.ShardStatusesField)
+	return resourcegroup.SetCondition(rg, v1alpha1.Condition{Type: resourcegroup.ShardsReconciledCondition})
+}
+
+// findCondition returns the condition of the specified type, if any.
+func findCondition(conditions []v1alpha1.Condition, conditionType string) *v1alpha1.Condition {
+	for i, c := range conditions {
+		if string(c.Type) == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// isConditionTrue returns true if the condition of the specified type is True.
+func isConditionTrue(conditions []v1alpha1.Condition, conditionType string) bool {
+	c := findCondition(conditions, conditionType)
+	return c != nil && c.Status == v1alpha1.TrueConditionStatus
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace   = "config-management-system"
+	testInventoryID = "config-management-system_root-sync"
+)
+
+// newResourceGroup returns a reconciled ResourceGroup of the test inventory,
+// with a Current status for each of the named resources.
+func newResourceGroup(name string, resources ...string) *v1alpha1.ResourceGroup {
+	rg := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{common.InventoryLabel: testInventoryID},
+		},
+	}
+	for _, r := range resources {
+		meta := v1alpha1.ObjMetadata{
+			Namespace: "shop",
+			Name:      r,
+			GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"},
+		}
+		rg.Spec.Resources = append(rg.Spec.Resources, meta)
+		rg.Status.ResourceStatuses = append(rg.Status.ResourceStatuses, v1alpha1.ResourceStatus{
+			ObjMetadata: meta,
+			Status:      v1alpha1.Current,
+		})
+	}
+	rg.Status.Conditions = []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.FalseConditionStatus},
+	}
+	return rg
+}
+
+func getShardStatuses(t *testing.T, c client.Client, name string) ([]interface{}, []interface{}) {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(kinds.ResourceGroup())
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: name}, u))
+	statuses, _, err := unstructured.NestedSlice(u.Object, "status", resourcegroup.ShardStatusesField)
+	require.NoError(t, err)
+	var shardConditions []interface{}
+	conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	require.NoError(t, err)
+	for _, c := range conditions {
+		if c.(map[string]interface{})["type"] == resourcegroup.ShardsReconciledCondition {
+			shardConditions = append(shardConditions, c)
+		}
+	}
+	return statuses, shardConditions
+}
+
+func TestShardController(t *testing.T) {
+	ctx := context.Background()
+	rg := newResourceGroup("root-sync", "a")
+	rg.Annotations = map[string]string{metadata.ResourceGroupShardsKey: "3"}
+	shard1 := newResourceGroup(resourcegroup.ShardName("root-sync", 1), "b", "c")
+	shard2 := newResourceGroup(resourcegroup.ShardName("root-sync", 2), "d")
+	shard2.Status.Conditions[0].Status = v1alpha1.TrueConditionStatus
+	shard2.Status.ResourceStatuses[0].Status = v1alpha1.InProgress
+	fakeClient := newFakeClient(t, rg, shard1, shard2)
+	c := &ShardController{Client: fakeClient}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "root-sync"}}
+
+	_, err := c.Reconcile(ctx, req)
+	require.NoError(t, err)
+	statuses, conditions := getShardStatuses(t, fakeClient, "root-sync")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "root-sync-shard-1", "status": "Current", "resourceCount": int64(2), "currentCount": int64(2)},
+		map[string]interface{}{"name": "root-sync-shard-2", "status": "InProgress", "resourceCount": int64(1), "currentCount": int64(0)},
+	}, statuses)
+	require.Len(t, conditions, 1)
+	condition := conditions[0].(map[string]interface{})
+	assert.Equal(t, "False", condition["status"])
+	assert.Equal(t, shardInProgressReason, condition["reason"])
+	assert.Equal(t, "The following shards are not reconciled: root-sync-shard-2 (InProgress)", condition["message"])
+
+	// The statuses are only written when they change.
+	resourceVersion := fakeClient.objs[req.NamespacedName].GetResourceVersion()
+	_, err = c.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, resourceVersion, fakeClient.objs[req.NamespacedName].GetResourceVersion())
+
+	// The last shard is reconciled.
+	shard2 = newResourceGroup(resourcegroup.ShardName("root-sync", 2), "d")
+	live := &v1alpha1.ResourceGroup{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(shard2), live))
+	live.Status = shard2.Status
+	require.NoError(t, fakeClient.Status().Update(ctx, live))
+	_, err = c.Reconcile(ctx, req)
+	require.NoError(t, err)
+	_, conditions = getShardStatuses(t, fakeClient, "root-sync")
+	require.Len(t, conditions, 1)
+	condition = conditions[0].(map[string]interface{})
+	assert.Equal(t, "True", condition["status"])
+	assert.Equal(t, shardsReconciledReason, condition["reason"])
+
+	// The inventory is no longer sharded.
+	require.NoError(t, fakeClient.Get(ctx, req.NamespacedName, live))
+	live.Annotations = nil
+	require.NoError(t, fakeClient.Update(ctx, live))
+	_, err = c.Reconcile(ctx, req)
+	require.NoError(t, err)
+	statuses, conditions = getShardStatuses(t, fakeClient, "root-sync")
+	assert.Empty(t, statuses)
+	assert.Empty(t, conditions)
+}
+
+func TestShardController_MissingShard(t *testing.T) {
+	ctx := context.Background()
+	rg := newResourceGroup("root-sync", "a")
+	rg.Annotations = map[string]string{metadata.ResourceGroupShardsKey: "2"}
+	fakeClient := newFakeClient(t, rg)
+	c := &ShardController{Client: fakeClient}
+
+	_, err := c.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "root-sync"}})
+	require.NoError(t, err)
+	statuses, conditions := getShardStatuses(t, fakeClient, "root-sync")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "root-sync-shard-1", "status": "NotFound", "resourceCount": int64(0), "currentCount": int64(0)},
+	}, statuses)
+	require.Len(t, conditions, 1)
+	assert.Equal(t, shardFailedReason, conditions[0].(map[string]interface{})["reason"])
+}
+
+func TestInventoryRequests(t *testing.T) {
+	shard := newResourceGroup(resourcegroup.ShardName("root-sync", 2))
+	assert.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "root-sync-shard-2"}},
+		{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "root-sync"}},
+	}, inventoryRequests(shard))
+	assert.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "root-sync"}},
+	}, inventoryRequests(newResourceGroup("root-sync")))
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/root"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// statusClient is a client.Client which keeps the status fields and
+// conditions written by Config Sync when the status of a typed ResourceGroup
+// is updated. The typed ResourceGroup has no field for them, so updating its
+// status would otherwise remove them.
+type statusClient struct {
+	client.Client
+}
+
+// Status implements client.Client.
+func (c *statusClient) Status() client.SubResourceWriter {
+	return &statusWriter{SubResourceWriter: c.Client.Status(), client: c.Client}
+}
+
+// statusWriter is the status writer of a statusClient.
+type statusWriter struct {
+	client.SubResourceWriter
+	client client.Client
+}
+
+// Update implements client.SubResourceWriter. It updates the status of a
+// typed ResourceGroup as an unstructured object, with the status written by
+// Config Sync copied from the live ResourceGroup. The resource version of rg
+// is kept, so the update fails with a conflict if Config Sync updated the
+// ResourceGroup after rg was read.
+//
+// The status of ResourceGroups with the status disabled is cleared.
+func (w *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	rg, ok := obj.(*v1alpha1.ResourceGroup)
+	if !ok || rg.GetAnnotations()[root.DisableStatusKey] == root.DisableStatusValue {
+		return w.SubResourceWriter.Update(ctx, obj, opts...)
+	}
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(kinds.ResourceGroup())
+	if err := w.client.Get(ctx, client.ObjectKeyFromObject(rg), live); err != nil {
+		return err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rg)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(kinds.ResourceGroup())
+	if err := resourcegroup.KeepConfigSyncStatus(u, live); err != nil {
+		return err
+	}
+	if err := w.SubResourceWriter.Update(ctx, u, opts...); err != nil {
+		return err
+	}
+	// Return the updated ResourceGroup, like the typed client does.
+	*rg = v1alpha1.ResourceGroup{}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rg)
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/resourcegroup"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/root"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestStatusClient(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := newFakeClient(t, newResourceGroup("root-sync", "a"))
+	key := client.ObjectKey{Namespace: testNamespace, Name: "root-sync"}
+
+	// Config Sync writes its status.
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(kinds.ResourceGroup())
+	require.NoError(t, fakeClient.Get(ctx, key, u))
+	shardStatuses := []interface{}{map[string]interface{}{"name": "root-sync-shard-1", "status": "Current"}}
+	require.NoError(t, unstructured.SetNestedSlice(u.Object, shardStatuses, "status", resourcegroup.ShardStatusesField))
+	conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	require.NoError(t, err)
+	shardsReconciled := map[string]interface{}{"type": resourcegroup.ShardsReconciledCondition, "status": "True", "lastTransitionTime": nil}
+	conditions = append(conditions, shardsReconciled)
+	require.NoError(t, unstructured.SetNestedSlice(u.Object, conditions, "status", "conditions"))
+	require.NoError(t, fakeClient.Status().Update(ctx, u))
+
+	// The ResourceGroup controller writes its status.
+	c := &statusClient{Client: fakeClient}
+	rg := &v1alpha1.ResourceGroup{}
+	require.NoError(t, c.Get(ctx, key, rg))
+	rg.Status.Conditions = []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.TrueConditionStatus},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.FalseConditionStatus},
+	}
+	require.NoError(t, c.Status().Update(ctx, rg))
+	assert.Len(t, rg.Status.Conditions, 3)
+
+	require.NoError(t, fakeClient.Get(ctx, key, u))
+	got, _, err := unstructured.NestedSlice(u.Object, "status", resourcegroup.ShardStatusesField)
+	require.NoError(t, err)
+	assert.Equal(t, shardStatuses, got)
+	conditions, _, err = unstructured.NestedSlice(u.Object, "status", "conditions")
+	require.NoError(t, err)
+	require.Len(t, conditions, 3)
+	assert.Equal(t, "True", conditions[0].(map[string]interface{})["status"])
+	assert.Equal(t, shardsReconciled, conditions[2])
+
+	// A stale ResourceGroup is not written.
+	rg.ResourceVersion = "1"
+	err = c.Status().Update(ctx, rg)
+	assert.True(t, apierrors.IsConflict(err), "expected a conflict, got %v", err)
+
+	// The status is cleared if it is disabled.
+	require.NoError(t, c.Get(ctx, key, rg))
+	rg.Annotations = map[string]string{root.DisableStatusKey: root.DisableStatusValue}
+	require.NoError(t, c.Update(ctx, rg))
+	rg.Status = v1alpha1.ResourceGroupStatus{}
+	require.NoError(t, c.Status().Update(ctx, rg))
+	require.NoError(t, fakeClient.Get(ctx, key, u))
+	_, found, err := unstructured.NestedFieldNoCopy(u.Object, "status", resourcegroup.ShardStatusesField)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -16,12 +16,62 @@ package resourcegroup
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt/pkg/live"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
 	"sigs.k8s.io/cli-utils/pkg/common"
 )
 
+// shardSuffix separates the name of an inventory from the index of its shards.
+const shardSuffix = "-shard-"
+
+const (
+	// ShardStatusesField is the status field of the first shard of a sharded
+	// inventory which lists the status of the other shards.
+	ShardStatusesField = "shardStatuses"
+
+	// ShardsReconciledCondition is the condition type of the first shard of a
+	// sharded inventory which reports whether the other shards are reconciled.
+	ShardsReconciledCondition = "ShardsReconciled"
+
+	// ObjectSyncStatusesField is the status field of each ResourceGroup of an
+	// inventory which lists the sync status of its objects.
+	ObjectSyncStatusesField = "objectSyncStatuses"
+
+	// ObjectSyncStatusTruncatedCondition is the condition type of a
+	// ResourceGroup which reports whether the object sync statuses were
+	// truncated to fit in the ResourceGroup.
+	ObjectSyncStatusTruncatedCondition = "ObjectSyncStatusTruncated"
+)
+
+// ShardStatus is the status of a shard of a sharded inventory, listed in the
+// ShardStatusesField of its first ResourceGroup.
+type ShardStatus struct {
+	// Name is the name of the shard.
+	Name string `json:"name"`
+	// Status is Current once the shard is reconciled, InProgress while it is
+	// reconciled, Failed if it is stalled, and NotFound if it is missing.
+	Status v1alpha1.Status `json:"status"`
+	// ResourceCount is the number of resources of the shard.
+	ResourceCount int `json:"resourceCount"`
+	// CurrentCount is the number of resources of the shard which are Current.
+	CurrentCount int `json:"currentCount"`
+}
+
+// ConfigSyncStatusFields are the status fields of a ResourceGroup written by
+// Config Sync, rather than by the ResourceGroup controller.
+var ConfigSyncStatusFields = []string{ShardStatusesField, ObjectSyncStatusesField}
+
+// ConfigSyncConditionTypes are the condition types of a ResourceGroup written
+// by Config Sync, rather than by the ResourceGroup controller.
+var ConfigSyncConditionTypes = []string{ShardsReconciledCondition, ObjectSyncStatusTruncatedCondition}
+
 // Unstructured creates a ResourceGroup object
 func Unstructured(name, namespace, id string) *unstructured.Unstructured {
 	groupVersion := fmt.Sprintf("%s/%s", live.ResourceGroupGVK.Group, live.ResourceGroupGVK.Version)
@@ -43,3 +93,175 @@ func Unstructured(name, namespace, id string) *unstructured.Unstructured {
 	}
 	return inventoryObj
 }
+
+// ShardName returns the name of the i-th ResourceGroup of the inventory with
+// the given name. The first shard is the inventory ResourceGroup itself.
+func ShardName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s%s%d", name, shardSuffix, i)
+}
+
+// IsShardOf returns true if name is the name of a shard, other than the first
+// one, of the inventory with the given name.
+func IsShardOf(name, inventoryName string) bool {
+	i, err := strconv.Atoi(strings.TrimPrefix(name, inventoryName+shardSuffix))
+	return err == nil && i > 0 && ShardName(inventoryName, i) == name
+}
+
+// ParseShardName returns the name of the inventory and the index of the shard
+// with the given name, if it is the name of a shard other than the first one.
+// The inventory may not be sharded, since the name of an inventory may look
+// like the name of a shard.
+func ParseShardName(name string) (string, int, bool) {
+	i := strings.LastIndex(name, shardSuffix)
+	if i < 0 {
+		return "", 0, false
+	}
+	inventoryName := name[:i]
+	index, err := strconv.Atoi(name[i+len(shardSuffix):])
+	if err != nil || index < 1 || ShardName(inventoryName, index) != name {
+		return "", 0, false
+	}
+	return inventoryName, index, true
+}
+
+// ShardCount returns the number of ResourceGroups the inventory is split
+// across, according to the annotation of its first shard.
+func ShardCount(rg *unstructured.Unstructured) int {
+	count, err := strconv.Atoi(rg.GetAnnotations()[metadata.ResourceGroupShardsKey])
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// MergeShards returns a copy of the first shard of an inventory, with the
+// resources, resource statuses and object sync statuses of the other shards
+// appended, so that it can be read as an unsharded inventory.
+func MergeShards(rg *unstructured.Unstructured, shards []*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	merged := rg.DeepCopy()
+	for _, field := range [][]string{{"spec", "resources"}, {"status", "resourceStatuses"}, {"status", ObjectSyncStatusesField}} {
+		items, _, err := unstructured.NestedSlice(merged.Object, field...)
+		if err != nil {
+			return nil, err
+		}
+		found := len(items) > 0
+		for _, shard := range shards {
+			shardItems, shardFound, err := unstructured.NestedSlice(shard.Object, field...)
+			if err != nil {
+				return nil, err
+			}
+			found = found || shardFound
+			items = append(items, shardItems...)
+		}
+		if !found {
+			continue
+		}
+		if err := unstructured.SetNestedSlice(merged.Object, items, field...); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// KeepConfigSyncStatus copies the status fields and conditions written by
+// Config Sync from the live ResourceGroup to rg, so that updating the status
+// of rg does not remove them.
+func KeepConfigSyncStatus(rg, live *unstructured.Unstructured) error {
+	for _, field := range ConfigSyncStatusFields {
+		value, found, err := unstructured.NestedFieldNoCopy(live.Object, "status", field)
+		if err != nil {
+			return err
+		}
+		if !found {
+			unstructured.RemoveNestedField(rg.Object, "status", field)
+			continue
+		}
+		if err := unstructured.SetNestedField(rg.Object, runtime.DeepCopyJSONValue(value), "status", field); err != nil {
+			return err
+		}
+	}
+	liveConditions, _, err := unstructured.NestedSlice(live.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	conditions, _, err := unstructured.NestedSlice(rg.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	var kept []interface{}
+	for _, c := range conditions {
+		if !isConfigSyncCondition(c) {
+			kept = append(kept, c)
+		}
+	}
+	for _, c := range liveConditions {
+		if isConfigSyncCondition(c) {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		unstructured.RemoveNestedField(rg.Object, "status", "conditions")
+		return nil
+	}
+	return unstructured.SetNestedSlice(rg.Object, kept, "status", "conditions")
+}
+
+// isConfigSyncCondition returns true if the condition has one of the
+// ConfigSyncConditionTypes.
+func isConfigSyncCondition(c interface{}) bool {
+	m, ok := c.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	conditionType, _, _ := unstructured.NestedString(m, "type")
+	for _, t := range ConfigSyncConditionTypes {
+		if conditionType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCondition sets the condition of the ResourceGroup, replacing the
+// condition of the same type. The time of the last transition of the
+// condition is kept if its status does not change. The condition is removed if
+// its status is empty.
+func SetCondition(rg *unstructured.Unstructured, condition v1alpha1.Condition) error {
+	conditions, _, err := unstructured.NestedSlice(rg.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	var newConditions []interface{}
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok || m["type"] != string(condition.Type) {
+			newConditions = append(newConditions, c)
+			continue
+		}
+		if m["status"] == string(condition.Status) {
+			if t, ok := m["lastTransitionTime"].(string); ok {
+				if err := condition.LastTransitionTime.UnmarshalQueryParameter(t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if condition.Status != "" {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&condition)
+		if err != nil {
+			return err
+		}
+		newConditions = append(newConditions, content)
+	}
+	if len(newConditions) == 0 {
+		unstructured.RemoveNestedField(rg.Object, "status", "conditions")
+		return nil
+	}
+	return unstructured.SetNestedSlice(rg.Object, newConditions, "status", "conditions")
+}
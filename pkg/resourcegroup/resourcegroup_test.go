@@ -0,0 +1,147 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/metadata"
+)
+
+func TestIsShardOf(t *testing.T) {
+	testCases := []struct {
+		name string
+		want bool
+	}{
+		{name: "root-sync", want: false},
+		{name: "root-sync-shard-1", want: true},
+		{name: "root-sync-shard-12", want: true},
+		{name: "root-sync-shard-0", want: false},
+		{name: "root-sync-shard-01", want: false},
+		{name: "root-sync-shard-a", want: false},
+		{name: "other-shard-1", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsShardOf(tc.name, "root-sync"))
+		})
+	}
+}
+
+func TestMergeShards(t *testing.T) {
+	resource := func(name string) interface{} {
+		return map[string]interface{}{"group": "", "kind": "ConfigMap", "namespace": "shop", "name": name}
+	}
+	shard := func(name string, resources ...interface{}) *unstructured.Unstructured {
+		rg := Unstructured(name, "config-management-system", "config-management-system_root-sync")
+		require.NoError(t, unstructured.SetNestedSlice(rg.Object, resources, "spec", "resources"))
+		require.NoError(t, unstructured.SetNestedSlice(rg.Object, resources, "status", "resourceStatuses"))
+		require.NoError(t, unstructured.SetNestedSlice(rg.Object, resources, "status", ObjectSyncStatusesField))
+		return rg
+	}
+
+	rg := shard("root-sync", resource("a"))
+	rg.SetAnnotations(map[string]string{metadata.ResourceGroupShardsKey: "3"})
+	assert.Equal(t, 3, ShardCount(rg))
+	merged, err := MergeShards(rg, []*unstructured.Unstructured{
+		shard(ShardName("root-sync", 1), resource("b")),
+		shard(ShardName("root-sync", 2), resource("c"), resource("d")),
+	})
+	require.NoError(t, err)
+
+	want := []interface{}{resource("a"), resource("b"), resource("c"), resource("d")}
+	got, _, err := unstructured.NestedSlice(merged.Object, "spec", "resources")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	got, _, err = unstructured.NestedSlice(merged.Object, "status", "resourceStatuses")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	got, _, err = unstructured.NestedSlice(merged.Object, "status", ObjectSyncStatusesField)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// The first shard is not modified.
+	got, _, err = unstructured.NestedSlice(rg.Object, "spec", "resources")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestParseShardName(t *testing.T) {
+	testCases := []struct {
+		name          string
+		wantInventory string
+		wantIndex     int
+		wantFound     bool
+	}{
+		{name: "root-sync"},
+		{name: "root-sync-shard-1", wantInventory: "root-sync", wantIndex: 1, wantFound: true},
+		{name: "root-sync-shard-2-shard-12", wantInventory: "root-sync-shard-2", wantIndex: 12, wantFound: true},
+		{name: "root-sync-shard-0"},
+		{name: "root-sync-shard-01"},
+		{name: "root-sync-shard-a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inventoryName, i, found := ParseShardName(tc.name)
+			assert.Equal(t, tc.wantFound, found)
+			assert.Equal(t, tc.wantInventory, inventoryName)
+			assert.Equal(t, tc.wantIndex, i)
+		})
+	}
+}
+
+func TestKeepConfigSyncStatus(t *testing.T) {
+	condition := func(conditionType, status string) interface{} {
+		return map[string]interface{}{"type": conditionType, "status": status}
+	}
+	live := Unstructured("root-sync", "config-management-system", "config-management-system_root-sync")
+	require.NoError(t, unstructured.SetNestedSlice(live.Object, []interface{}{
+		condition("Reconciling", "True"),
+		condition(ShardsReconciledCondition, "False"),
+	}, "status", "conditions"))
+	shardStatuses := []interface{}{map[string]interface{}{"name": "root-sync-shard-1", "status": "InProgress"}}
+	require.NoError(t, unstructured.SetNestedSlice(live.Object, shardStatuses, "status", ShardStatusesField))
+
+	rg := Unstructured("root-sync", "config-management-system", "config-management-system_root-sync")
+	require.NoError(t, unstructured.SetNestedSlice(rg.Object, []interface{}{
+		condition("Reconciling", "False"),
+		condition("Stalled", "False"),
+	}, "status", "conditions"))
+	require.NoError(t, KeepConfigSyncStatus(rg, live))
+
+	got, _, err := unstructured.NestedSlice(rg.Object, "status", "conditions")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		condition("Reconciling", "False"),
+		condition("Stalled", "False"),
+		condition(ShardsReconciledCondition, "False"),
+	}, got)
+	got, _, err = unstructured.NestedSlice(rg.Object, "status", ShardStatusesField)
+	require.NoError(t, err)
+	assert.Equal(t, shardStatuses, got)
+
+	// Fields removed from the live ResourceGroup are removed.
+	unstructured.RemoveNestedField(live.Object, "status")
+	require.NoError(t, KeepConfigSyncStatus(rg, live))
+	_, found, err := unstructured.NestedSlice(rg.Object, "status", ShardStatusesField)
+	require.NoError(t, err)
+	assert.False(t, found)
+	got, _, err = unstructured.NestedSlice(rg.Object, "status", "conditions")
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
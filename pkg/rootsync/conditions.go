@@ -15,6 +15,9 @@
 package rootsync
 
 import (
+	"fmt"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
@@ -152,6 +155,56 @@ func SetReconcilerFinalizerFailure(rs *v1beta1.RootSync, errs status.MultiError)
 	return updated
 }
 
+// SetPaused sets the Paused condition to True.
+// Use RemoveCondition to remove this condition when the sync is resumed.
+func SetPaused(rs *v1beta1.RootSync, reason, message string) (updated bool) {
+	updated, _ = setCondition(rs, v1beta1.RootSyncPaused, metav1.ConditionTrue, reason, message, "", nil, nil, nil, now())
+	return updated
+}
+
+// SetTriggered sets the Triggered condition to True, recording the token of
+// the handled sync-trigger annotation.
+func SetTriggered(rs *v1beta1.RootSync, token string) (updated bool) {
+	updated, _ = setCondition(rs, v1beta1.RootSyncTriggered, metav1.ConditionTrue, "ResyncCompleted", triggeredMessage(token), "", nil, nil, nil, now())
+	return updated
+}
+
+// IsPaused returns true if the RootSync has the Paused condition set to True.
+func IsPaused(rs *v1beta1.RootSync) bool {
+	cond := GetCondition(rs.Status.Conditions, v1beta1.RootSyncPaused)
+	return cond != nil && cond.Status == metav1.ConditionTrue
+}
+
+// IsTriggered returns true if the reconciler has completed the resync
+// requested with the specified sync-trigger token.
+func IsTriggered(rs *v1beta1.RootSync, token string) bool {
+	cond := GetCondition(rs.Status.Conditions, v1beta1.RootSyncTriggered)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.Message == triggeredMessage(token)
+}
+
+func triggeredMessage(token string) string {
+	return fmt.Sprintf("Completed resync for trigger %q", token)
+}
+
+// SetReapplied sets the Reapplied condition to True, recording the token of
+// the handled reapply request and the number of reapplied objects.
+func SetReapplied(rs *v1beta1.RootSync, token string, count int) (updated bool) {
+	message := fmt.Sprintf("Reapplied %d objects%s", count, reappliedSuffix(token))
+	updated, _ = setCondition(rs, v1beta1.RootSyncReapplied, metav1.ConditionTrue, "ReapplyCompleted", message, "", nil, nil, nil, now())
+	return updated
+}
+
+// IsReapplied returns true if the reconciler has reapplied the objects
+// selected by the reapply request with the specified token.
+func IsReapplied(rs *v1beta1.RootSync, token string) bool {
+	cond := GetCondition(rs.Status.Conditions, v1beta1.RootSyncReapplied)
+	return cond != nil && cond.Status == metav1.ConditionTrue && strings.HasSuffix(cond.Message, reappliedSuffix(token))
+}
+
+func reappliedSuffix(token string) string {
+	return fmt.Sprintf(" for request %q", token)
+}
+
 // setCondition adds or updates the specified condition with a True status.
 // Returns whether the condition was updated (any change) or transitioned
 // (status change).
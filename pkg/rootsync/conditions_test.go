@@ -918,3 +918,36 @@ func TestConditionHasNoErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestSetPaused(t *testing.T) {
+	rs := &v1beta1.RootSync{}
+	assert.False(t, IsPaused(rs))
+	assert.True(t, SetPaused(rs, "SyncPaused", "paused"))
+	assert.True(t, IsPaused(rs))
+	assert.False(t, SetPaused(rs, "SyncPaused", "paused"))
+	assert.True(t, RemoveCondition(rs, v1beta1.RootSyncPaused))
+	assert.False(t, IsPaused(rs))
+}
+
+func TestSetTriggered(t *testing.T) {
+	rs := &v1beta1.RootSync{}
+	assert.False(t, IsTriggered(rs, "token-1"))
+	assert.True(t, SetTriggered(rs, "token-1"))
+	assert.True(t, IsTriggered(rs, "token-1"))
+	assert.False(t, IsTriggered(rs, "token-2"))
+	assert.True(t, SetTriggered(rs, "token-2"))
+	assert.False(t, IsTriggered(rs, "token-1"))
+	assert.True(t, IsTriggered(rs, "token-2"))
+}
+
+func TestSetReapplied(t *testing.T) {
+	rs := &v1beta1.RootSync{}
+	assert.False(t, IsReapplied(rs, "token-1"))
+	assert.True(t, SetReapplied(rs, "token-1", 3))
+	assert.True(t, IsReapplied(rs, "token-1"))
+	assert.False(t, IsReapplied(rs, "token-2"))
+	assert.Equal(t, `Reapplied 3 objects for request "token-1"`, GetCondition(rs.Status.Conditions, v1beta1.RootSyncReapplied).Message)
+	assert.True(t, SetReapplied(rs, "token-2", 0))
+	assert.False(t, IsReapplied(rs, "token-1"))
+	assert.True(t, IsReapplied(rs, "token-2"))
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AdmissionPolicyViolationErrorCode is the error code for declared objects
+// which a ValidatingAdmissionPolicy or a Gatekeeper constraint would deny.
+const AdmissionPolicyViolationErrorCode = "1076"
+
+// AdmissionPolicyWarningErrorCode is the error code for declared objects which
+// violate a ValidatingAdmissionPolicy or a Gatekeeper constraint that only
+// warns about its violations, or which a policy could not be evaluated for.
+// It does not block the sync.
+const AdmissionPolicyWarningErrorCode = "1077"
+
+var admissionPolicyViolationError = NewErrorBuilder(AdmissionPolicyViolationErrorCode)
+
+var admissionPolicyWarningError = NewErrorBuilder(AdmissionPolicyWarningErrorCode)
+
+// AdmissionPolicyViolationError reports that the API server would deny a
+// declared object because it violates a policy. The policy is described by its
+// kind and name, and the policyResources are the objects of the policy
+// declared in the source of truth, if any.
+func AdmissionPolicyViolationError(resource client.Object, policy, message string, policyResources ...client.Object) Error {
+	return admissionPolicyViolationError.
+		Sprintf("%s is denied by the %s: %s",
+			resource.GetObjectKind().GroupVersionKind().Kind, policy, message).
+		BuildWithResources(append([]client.Object{resource}, policyResources...)...)
+}
+
+// AdmissionPolicyWarningError reports that a declared object violates a
+// policy which only warns about, or audits, its violations, or that the policy
+// could not be evaluated before apply.
+func AdmissionPolicyWarningError(resource client.Object, policy, message string, policyResources ...client.Object) Error {
+	return admissionPolicyWarningError.
+		Sprintf("%s may be denied or audited by the %s: %s",
+			resource.GetObjectKind().GroupVersionKind().Kind, policy, message).
+		BuildWithResources(append([]client.Object{resource}, policyResources...)...)
+}
@@ -14,6 +14,8 @@
 
 package status
 
+import "sigs.k8s.io/controller-runtime/pkg/client"
+
 // InternalHydrationErrorCode is the error code for an internal Error related to the hydration process.
 const InternalHydrationErrorCode = "2015"
 
@@ -42,3 +44,9 @@ func HydrationError(code string, err error) Error {
 		return internalHydrationErrorBuilder.Wrap(err).Build()
 	}
 }
+
+// HydrationResourceError returns a user actionable hydration error associated
+// with the rendered resources.
+func HydrationResourceError(err error, resources ...client.Object) Error {
+	return actionableHydrationErrorBuilder.Wrap(err).BuildWithResources(resources...)
+}
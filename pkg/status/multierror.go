@@ -32,8 +32,9 @@ type MultiError interface {
 }
 
 var nonBlockingErrorCodes = map[string]struct{}{
-	UnknownKindErrorCode:         {},
-	EncodeDeclaredFieldErrorCode: {},
+	UnknownKindErrorCode:            {},
+	EncodeDeclaredFieldErrorCode:    {},
+	AdmissionPolicyWarningErrorCode: {},
 }
 
 // HasBlockingErrors return whether `errs` include any blocking errors.
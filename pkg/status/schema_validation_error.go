@@ -0,0 +1,33 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SchemaValidationErrorCode is the error code for declared objects that do
+// not match the schema of their kind.
+const SchemaValidationErrorCode = "1070"
+
+var schemaValidationError = NewErrorBuilder(SchemaValidationErrorCode)
+
+// SchemaValidationError reports that a declared object does not match the
+// schema of its kind, for example because of a misspelled field.
+func SchemaValidationError(resource client.Object, err error) Error {
+	return schemaValidationError.Wrap(err).
+		Sprintf("%s does not match its schema", resource.GetObjectKind().GroupVersionKind().Kind).
+		BuildWithResources(resource)
+}
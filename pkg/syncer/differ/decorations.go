@@ -37,6 +37,27 @@ func ManagementDisabled(obj client.Object) bool {
 	return core.GetAnnotation(obj, metadata.ResourceManagementKey) == metadata.ResourceManagementDisabled
 }
 
+// HandoffTarget returns the manager which the resource in the repo hands off its
+// management to, or an empty string if the management is not handed off.
+func HandoffTarget(obj client.Object) string {
+	return core.GetAnnotation(obj, metadata.ResourceHandoffKey)
+}
+
+// HandedOff returns true if the resource in the repo hands off its management
+// to the current manager of the resource on the API server.
+func HandedOff(declared, actual client.Object) bool {
+	target := HandoffTarget(declared)
+	return target != "" && target == core.GetAnnotation(actual, metadata.ResourceManagerKey)
+}
+
+// HandoffPending returns true if the resource on the API server is handed off
+// to the manager of the resource in the repo, which has not claimed it yet.
+func HandoffPending(declared, actual client.Object) bool {
+	manager := core.GetAnnotation(declared, metadata.ResourceManagerKey)
+	return manager != "" && HandoffTarget(actual) == manager &&
+		core.GetAnnotation(actual, metadata.ResourceManagerKey) != manager
+}
+
 // ManagedByConfigSync returns true if a resource is managed by Config Sync.
 //
 // A resource is managed by Config Sync if it meets the following two criteria:
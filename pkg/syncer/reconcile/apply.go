@@ -16,6 +16,7 @@ package reconcile
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -25,21 +26,14 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
-	"k8s.io/apimachinery/pkg/util/mergepatch"
-	"k8s.io/apimachinery/pkg/util/strategicpatch"
-	"k8s.io/client-go/discovery"
-	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/rest"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
 	"k8s.io/klog/v2"
-	"k8s.io/kubectl/pkg/util"
-	"k8s.io/kubectl/pkg/util/openapi"
 	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/conflictpolicy"
 	"kpt.dev/configsync/pkg/core"
-	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/metadata"
 	m "kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/status"
@@ -49,71 +43,57 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const noOpPatch = "{}"
-const rmCreationTimestampPatch = "{\"metadata\":{\"creationTimestamp\":null}}"
-
 // Applier updates a resource from its current state to its intended state using apply operations.
 type Applier interface {
 	Create(ctx context.Context, obj *unstructured.Unstructured) status.Error
 	Update(ctx context.Context, intendedState, currentState *unstructured.Unstructured) status.Error
-	// RemoveNomosMeta performs a PUT (rather than a PATCH) to ensure that labels and annotations are removed.
+	// RemoveNomosMeta removes the Config Sync metadata and releases the
+	// Config Sync field ownership, without changing any other fields.
 	RemoveNomosMeta(ctx context.Context, intent *unstructured.Unstructured, controller string) status.Error
 	Delete(ctx context.Context, obj *unstructured.Unstructured) status.Error
 	GetClient() client.Client
 }
 
-// clientApplier does apply operations on resources, client-side, using the same approach as running `kubectl apply`.
+// legacyFieldManagers are the field managers of the Update operations written
+// by older versions of the remediator, which used client-side patches and
+// updates. The fields they own are migrated to the Config Sync field manager
+// for server-side apply, so the fields removed from the source are pruned.
+var legacyFieldManagers = sets.New[string](
+	configsync.FieldManager,
+	// The default field manager, derived from the user agent of the reconciler.
+	"reconciler",
+)
+
+// clientApplier does apply operations on resources, using server-side apply
+// with the same field manager as the applier.
 type clientApplier struct {
-	dynamicClient    dynamic.Interface
-	discoveryClient  discovery.DiscoveryInterface
-	openAPIResources openapi.Resources
-	client           *syncerclient.Client
-	fights           fight.Detector
+	client *syncerclient.Client
+	fights fight.Detector
+	// conflictPolicy is the default policy for the fields owned by other
+	// field managers.
+	conflictPolicy configsync.ConflictPolicy
+	// ignoreDifferences are the fields of each kind which are neither applied
+	// nor remediated.
+	ignoreDifferences ignorefields.Rules
 }
 
 var _ Applier = &clientApplier{}
 
 // NewApplierForMultiRepo returns a new clientApplier for callers with multi repo feature enabled.
-func NewApplierForMultiRepo(cfg *rest.Config, client *syncerclient.Client) (Applier, error) {
-	return newApplier(cfg, client)
-}
-
-func newApplier(cfg *rest.Config, client *syncerclient.Client) (Applier, error) {
-	c, err := dynamic.NewForConfig(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	oa, err := openapi.NewOpenAPIParser(dc).Parse()
-	if err != nil {
-		return nil, err
-	}
-
+func NewApplierForMultiRepo(client *syncerclient.Client, conflictPolicy configsync.ConflictPolicy, ignoreDifferences ignorefields.Rules) Applier {
 	return &clientApplier{
-		dynamicClient:    c,
-		discoveryClient:  dc,
-		openAPIResources: oa,
-		client:           client,
-		fights:           fight.NewDetector(),
-	}, nil
+		client:            client,
+		fights:            fight.NewDetector(),
+		conflictPolicy:    conflictPolicy,
+		ignoreDifferences: ignoreDifferences,
+	}
 }
 
 // Create implements Applier.
 func (c *clientApplier) Create(ctx context.Context, intendedState *unstructured.Unstructured) status.Error {
 	var err status.Error
-	// APIService is handled specially by client-side apply due to
-	// https://github.com/kubernetes/kubernetes/issues/89264
-	if intendedState.GroupVersionKind().GroupKind() == kinds.APIService().GroupKind() {
-		err = c.create(ctx, intendedState)
-	} else {
-		if err1 := c.client.Patch(ctx, intendedState, client.Apply, client.FieldOwner(configsync.FieldManager)); err1 != nil {
-			err = status.ResourceWrap(err1, "unable to apply resource", intendedState)
-		}
+	if err1 := c.client.Patch(ctx, intendedState, client.Apply, client.FieldOwner(configsync.FieldManager)); err1 != nil {
+		err = applyError(err1, "unable to apply resource", intendedState)
 	}
 	metrics.Operations.WithLabelValues("create", metrics.StatusLabel(err)).Inc()
 	m.RecordApplyOperation(ctx, m.RemediatorController, "create", m.StatusTagKey(err))
@@ -134,11 +114,13 @@ func (c *clientApplier) Create(ctx context.Context, intendedState *unstructured.
 
 // Update implements Applier.
 func (c *clientApplier) Update(ctx context.Context, intendedState, currentState *unstructured.Unstructured) status.Error {
-	patch, err := c.update(ctx, intendedState, currentState)
+	diff, err := c.update(ctx, intendedState, currentState)
 	metrics.Operations.WithLabelValues("update", metrics.StatusLabel(err)).Inc()
 	m.RecordApplyOperation(ctx, m.RemediatorController, "update", m.StatusTagKey(err))
 
 	switch {
+	case isFieldManagerConflict(err):
+		return fieldManagerConflictError(err, intendedState)
 	case apierrors.IsConflict(err):
 		return syncerclient.ConflictUpdateOldVersion(err, intendedState)
 	case apierrors.IsNotFound(err):
@@ -147,15 +129,13 @@ func (c *clientApplier) Update(ctx context.Context, intendedState, currentState
 		return status.ResourceWrap(err, "unable to update resource", intendedState)
 	}
 
-	updated := !isNoOpPatch(patch)
-	if updated {
+	if diff != "" {
 		logFight, err := c.fights.DetectFight(time.Now(), intendedState)
 		if logFight {
-			diff := cmp.Diff(currentState, intendedState)
 			klog.Errorf("Fight detected on update of %s with difference %s", description(intendedState), diff)
 		}
 		if err == nil {
-			klog.V(3).Infof("The object %v was updated with the patch %v", core.GKNN(currentState), string(patch))
+			klog.V(3).Infof("The object %v was updated with the difference %v", core.GKNN(currentState), diff)
 		}
 		return err
 	}
@@ -166,14 +146,7 @@ func (c *clientApplier) Update(ctx context.Context, intendedState, currentState
 
 // RemoveNomosMeta implements Applier.
 func (c *clientApplier) RemoveNomosMeta(ctx context.Context, u *unstructured.Unstructured, controller string) status.Error {
-	var changed bool
-	_, err := c.client.Apply(ctx, u, func(obj client.Object) (client.Object, error) {
-		changed = metadata.RemoveConfigSyncMetadata(obj)
-		if !changed {
-			return obj, syncerclient.NoUpdateNeeded()
-		}
-		return obj, nil
-	})
+	changed, err := c.removeNomosMeta(ctx, u)
 	metrics.Operations.WithLabelValues("update", metrics.StatusLabel(err)).Inc()
 	m.RecordApplyOperation(ctx, controller, "update", m.StatusTagKey(err))
 
@@ -205,203 +178,211 @@ func (c *clientApplier) Delete(ctx context.Context, obj *unstructured.Unstructur
 	return err
 }
 
-// create creates the resource with the declared-config annotation set.
-func (c *clientApplier) create(ctx context.Context, obj *unstructured.Unstructured) status.Error {
-	// When multi-repo feature is enabled, use kubectl last-applied-annotation.
-	err := util.CreateApplyAnnotation(obj, unstructured.UnstructuredJSONScheme)
+// update applies the intended state with server-side apply, like the applier.
+// The conflicting fields owned by other field managers are resolved with the
+// conflict policy: they are forced or respected. With the fail policy, the
+// ownership is not forced, so the API server reports the conflicts.
+// Returns the difference between the current and intended state, or an empty
+// string if the object is already up to date.
+func (c *clientApplier) update(ctx context.Context, intendedState, currentState *unstructured.Unstructured) (string, error) {
+	if err := c.upgradeManagedFields(ctx, currentState.DeepCopy()); err != nil {
+		return "", err
+	}
+	// Apply the ignored fields with their current values, so that they are
+	// left unchanged, like the applier does.
+	intendedState = intendedState.DeepCopy()
+	if err := ignorefields.Preserve(intendedState, currentState, c.ignoreDifferences); err != nil {
+		return "", err
+	}
+	res, err := conflictpolicy.Resolve(intendedState, currentState, c.conflictPolicy)
 	if err != nil {
-		return status.ResourceWrap(err, "could not generate apply annotation on create", obj)
+		return "", err
 	}
-
-	return c.client.Create(ctx, obj)
-}
-
-// clientFor returns the client which may interact with the passed object.
-func (c *clientApplier) clientFor(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
-	gvk := obj.GroupVersionKind()
-	apiResource, rErr := c.resource(gvk)
-	if rErr != nil {
-		return nil, errors.Wrapf(rErr, "unable to get resource client for %q", gvk.String())
+	obj := res.Object
+	if obj == nil {
+		obj = intendedState
 	}
-
-	gvr := gvk.GroupVersion().WithResource(apiResource)
-	// If namespace is the empty string (as is the case for cluster-scoped resources), the
-	// client correctly returns itself.
-	return c.dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()), nil
-}
-
-// apply updates a resource using the same approach as running `kubectl apply`.
-func (c *clientApplier) update(ctx context.Context, intendedState, currentState *unstructured.Unstructured) ([]byte, error) {
-	if intendedState.GroupVersionKind().GroupKind() == kinds.APIService().GroupKind() {
-		return c.updateAPIService(ctx, intendedState, currentState)
+	opts := []client.PatchOption{client.FieldOwner(configsync.FieldManager)}
+	if res.Policy == configsync.ConflictPolicyForce {
+		opts = append(opts, client.ForceOwnership)
 	}
-	objCopy := intendedState.DeepCopy()
+	objCopy := obj.DeepCopy()
 	// Run the server-side apply dryrun first.
 	// If the returned object doesn't change, skip running server-side apply.
-	err := c.client.Patch(ctx, objCopy, client.Apply, client.FieldOwner(configsync.FieldManager), client.ForceOwnership, client.DryRunAll)
+	err = c.client.Patch(ctx, objCopy, client.Apply, append(opts, client.DryRunAll)...)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if equal(objCopy, currentState) {
-		return nil, nil
+		return "", nil
 	}
 
 	start := time.Now()
-	err = c.client.Patch(ctx, intendedState, client.Apply, client.FieldOwner(configsync.FieldManager), client.ForceOwnership)
+	err = c.client.Patch(ctx, obj, client.Apply, opts...)
 	duration := time.Since(start).Seconds()
 	metrics.APICallDuration.WithLabelValues("update", metrics.StatusLabel(err)).Observe(duration)
 	m.RecordAPICallDuration(ctx, "update", m.StatusTagKey(err), start)
-	return []byte(cmp.Diff(currentState, intendedState)), err
+	return cmp.Diff(currentState, obj), err
 }
 
-// updateAPIService updates APIService type resources.
-// APIService is handled specially by client-side apply due to
-// https://github.com/kubernetes/kubernetes/issues/89264
-func (c *clientApplier) updateAPIService(ctx context.Context, intendedState, currentState *unstructured.Unstructured) ([]byte, error) {
-	resourceDescription := description(intendedState)
-	// Serialize the current configuration of the object.
-	current, cErr := runtime.Encode(unstructured.UnstructuredJSONScheme, currentState)
-	if cErr != nil {
-		return nil, errors.Errorf("could not serialize current configuration from %v", currentState)
-	}
-
-	var previous []byte
-	var oErr error
-	// Retrieve the last applied configuration of the object from the annotation.
-	previous, oErr = util.GetOriginalConfiguration(currentState)
-	if oErr != nil {
-		return nil, errors.Errorf("could not retrieve original configuration from %v", currentState)
-	}
-	if previous == nil {
-		klog.Warningf("3-way merge patch for %s may be incorrect due to missing last-applied-configuration annotation.", resourceDescription)
-	}
-
-	var modified []byte
-	var mErr error
-
-	modified, mErr = util.GetModifiedConfiguration(intendedState, true, unstructured.UnstructuredJSONScheme)
-	if mErr != nil {
-		return nil, errors.Errorf("could not serialize intended configuration from %v", intendedState)
-	}
-
-	resourceClient, rErr := c.clientFor(intendedState)
-	if rErr != nil {
-		return nil, nil
-	}
-
-	name := intendedState.GetName()
-	gvk := intendedState.GroupVersionKind()
-	//TODO: Add unit tests for patch return value.
-	var err error
-
-	// Attempt a strategic patch first.
-	patch := c.calculateStrategic(resourceDescription, gvk, previous, modified, current)
-	// If patch is nil, it means we don't have access to the schema.
-	if patch != nil {
-		err = attemptPatch(ctx, resourceClient, name, types.StrategicMergePatchType, patch)
-		// UnsupportedMediaType error indicates an invalid strategic merge patch (always true for a
-		// custom resource), so we reset the patch and try again below.
-		if err != nil {
-			if apierrors.IsUnsupportedMediaType(err) {
-				patch = nil
-			} else {
-				klog.Warningf("strategic merge patch for %s failed: %v", resourceDescription, err)
-			}
+// removeNomosMeta removes the Config Sync metadata with server-side apply and
+// then releases the fields owned by Config Sync, so that other managers can
+// take them over without conflicts.
+// Returns whether the object was changed.
+func (c *clientApplier) removeNomosMeta(ctx context.Context, u *unstructured.Unstructured) (bool, status.Error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(u.GroupVersionKind())
+	if err := c.client.Get(ctx, client.ObjectKeyFromObject(u), obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, syncerclient.ConflictUpdateDoesNotExist(err, u)
 		}
+		return false, status.ResourceWrap(err, "failed to get object to update", u)
 	}
-
-	// If we weren't able to do a Strategic Merge, we fall back to JSON Merge Patch.
-	if patch == nil {
-		patch, err = c.calculateJSONMerge(previous, modified, current)
-		if err == nil {
-			err = attemptPatch(ctx, resourceClient, name, types.MergePatchType, patch)
-		}
+	if err := c.upgradeManagedFields(ctx, obj); err != nil {
+		return false, updateError(err, obj)
 	}
 
+	intent := applyConfiguration(obj)
+	if !metadata.RemoveConfigSyncMetadata(intent) {
+		return false, nil
+	}
+	// Apply the current state without the Config Sync metadata. The metadata
+	// is removed, unless it is also owned by another manager. The values of the
+	// other fields don't change, so they don't conflict with other managers.
+	start := time.Now()
+	err := c.client.Patch(ctx, intent, client.Apply, client.FieldOwner(configsync.FieldManager))
+	metrics.APICallDuration.WithLabelValues("update", metrics.StatusLabel(err)).Observe(time.Since(start).Seconds())
+	m.RecordAPICallDuration(ctx, "update", m.StatusTagKey(err), start)
 	if err != nil {
-		// Don't wrap this error. We care about it's type information, and the
-		// apierrors library doesn't properly recursively check for wrapped errors.
-		return nil, err
+		return false, updateError(err, obj)
 	}
-
-	if isNoOpPatch(patch) {
-		klog.V(3).Infof("Ignoring no-op patch %s for %q", patch, resourceDescription)
-	} else {
-		klog.Infof("Patched %s", resourceDescription)
-		klog.V(1).Infof("Patched with %s", patch)
+	if err := c.releaseManagedFields(ctx, intent); err != nil {
+		return true, updateError(err, obj)
 	}
-
-	return patch, nil
+	return true, nil
 }
 
-func (c *clientApplier) calculateStrategic(resourceDescription string, gvk schema.GroupVersionKind, previous, modified, current []byte) []byte {
-	// Try to use schema from OpenAPI Spec if possible.
-	gvkSchema := c.openAPIResources.LookupResource(gvk)
-	if gvkSchema == nil {
-		return nil
-	}
-	patchMeta := strategicpatch.PatchMetaFromOpenAPI{Schema: gvkSchema}
-	patch, err := strategicpatch.CreateThreeWayMergePatch(previous, modified, current, patchMeta, true)
+// upgradeManagedFields migrates the fields owned by the legacyFieldManagers to
+// the Config Sync field manager for server-side apply. The obj is updated with
+// the response, if migrated.
+func (c *clientApplier) upgradeManagedFields(ctx context.Context, obj *unstructured.Unstructured) error {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(obj, legacyFieldManagers, configsync.FieldManager)
 	if err != nil {
-		klog.Infof("strategic patch unavailable for %s (will use JSON patch instead): %v", resourceDescription, err)
+		return err
+	}
+	if patch == nil {
 		return nil
 	}
-	return patch
+	klog.V(3).Infof("Migrating the managed fields of the object %v to server-side apply", core.GKNN(obj))
+	return c.client.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, patch))
 }
 
-func (c *clientApplier) calculateJSONMerge(previous, modified, current []byte) ([]byte, error) {
-	preconditions := []mergepatch.PreconditionFunc{
-		mergepatch.RequireKeyUnchanged("apiVersion"),
-		mergepatch.RequireKeyUnchanged("kind"),
-		mergepatch.RequireMetadataKeyUnchanged("name"),
+// releaseManagedFields removes the Config Sync field manager from the managed
+// fields of the object, without changing the fields.
+func (c *clientApplier) releaseManagedFields(ctx context.Context, obj *unstructured.Unstructured) error {
+	managedFields := obj.GetManagedFields()
+	var filtered []metav1.ManagedFieldsEntry
+	for _, entry := range managedFields {
+		if entry.Manager == configsync.FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply && entry.Subresource == "" {
+			continue
+		}
+		filtered = append(filtered, entry)
 	}
-	return jsonmergepatch.CreateThreeWayJSONMergePatch(previous, modified, current, preconditions...)
+	if len(filtered) == len(managedFields) {
+		return nil
+	}
+	if len(filtered) == 0 {
+		// An empty list is ignored. A list with a single empty entry resets
+		// the managed fields.
+		filtered = []metav1.ManagedFieldsEntry{{}}
+	}
+	patch, err := json.Marshal([]map[string]interface{}{
+		{
+			"op":    "replace",
+			"path":  "/metadata/managedFields",
+			"value": filtered,
+		},
+		{
+			// Replace the resourceVersion to reject the patch with a conflict
+			// error, if the object has been changed.
+			"op":    "replace",
+			"path":  "/metadata/resourceVersion",
+			"value": obj.GetResourceVersion(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return c.client.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, patch))
 }
 
-// isNoOpPatch returns true if the given patch is a no-op that should be ignored.
-// TODO: Find a more elegant solution for ignoring noop-like patches.
-func isNoOpPatch(patch []byte) bool {
-	if patch == nil {
-		return true
-	}
-	p := string(patch)
-	return p == noOpPatch || p == rmCreationTimestampPatch
+// applyConfiguration returns a copy of the object, without the fields that
+// are set by the server and can't be applied. The resourceVersion is kept, to
+// reject the apply with a conflict error if the object has been changed.
+func applyConfiguration(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	u := obj.DeepCopy()
+	u.SetManagedFields(nil)
+	u.SetUID("")
+	u.SetGeneration(0)
+	u.SetCreationTimestamp(metav1.Time{})
+	u.SetSelfLink("")
+	unstructured.RemoveNestedField(u.Object, "status")
+	return u
 }
 
-// attemptPatch patches resClient with the given patch.
-// TODO: Add unit tests for noop logic.
-func attemptPatch(ctx context.Context, resClient dynamic.ResourceInterface, name string, patchType types.PatchType, patch []byte) error {
-	if isNoOpPatch(patch) {
-		return nil
+// updateError maps the error of an update to a status.Error.
+func updateError(err error, obj *unstructured.Unstructured) status.Error {
+	switch {
+	case isFieldManagerConflict(err):
+		return fieldManagerConflictError(err, obj)
+	case apierrors.IsConflict(err):
+		return syncerclient.ConflictUpdateOldVersion(err, obj)
+	case apierrors.IsNotFound(err):
+		return syncerclient.ConflictUpdateDoesNotExist(err, obj)
+	default:
+		return status.ResourceWrap(err, "unable to update resource", obj)
 	}
+}
 
-	if err := ctx.Err(); err != nil {
-		// We've already encountered an error, so do not attempt update.
-		return errors.Wrapf(err, "patch cancelled due to context error")
+// applyError maps the error of a server-side apply to a status.Error.
+func applyError(err error, msg string, obj *unstructured.Unstructured) status.Error {
+	if isFieldManagerConflict(err) {
+		return fieldManagerConflictError(err, obj)
 	}
-
-	start := time.Now()
-	_, err := resClient.Patch(ctx, name, patchType, patch, metav1.PatchOptions{})
-	duration := time.Since(start).Seconds()
-	metrics.APICallDuration.WithLabelValues("update", metrics.StatusLabel(err)).Observe(duration)
-	m.RecordAPICallDuration(ctx, "update", m.StatusTagKey(err), start)
-	return err
+	return status.ResourceWrap(err, msg, obj)
 }
 
-// resource retrieves the plural resource name for the GroupVersionKind.
-func (c *clientApplier) resource(gvk schema.GroupVersionKind) (string, error) {
-	apiResources, err := c.discoveryClient.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
-	if err != nil {
-		return "", errors.Wrapf(err, "could not look up %s using discovery API", gvk)
+// isFieldManagerConflict returns true if the error is a server-side apply
+// conflict with the fields owned by another field manager.
+func isFieldManagerConflict(err error) bool {
+	if !apierrors.IsConflict(err) {
+		return false
 	}
-
-	for _, r := range apiResources.APIResources {
-		if r.Kind == gvk.Kind {
-			return r.Name, nil
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return false
+	}
+	details := apiStatus.Status().Details
+	if details == nil {
+		return false
+	}
+	for _, cause := range details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			return true
 		}
 	}
+	return false
+}
 
-	return "", errors.Errorf("could not find plural resource name for %s", gvk)
+// fieldManagerConflictError indicates that the declared fields of the resource
+// are owned by another field manager.
+func fieldManagerConflictError(err error, resource client.Object) status.Error {
+	return status.ManagementConflictErrorBuilder.
+		Wrap(err).
+		Sprintf("The %q reconciler cannot apply fields owned by another field manager. "+
+			"Remove the fields from the declaration of this resource, or stop the other manager from updating them.",
+			resource.GetAnnotations()[metadata.ResourceManagerKey]).
+		BuildWithResources(resource)
 }
 
 func description(u *unstructured.Unstructured) string {
@@ -0,0 +1,84 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	syncerclient "kpt.dev/configsync/pkg/syncer/client"
+	"kpt.dev/configsync/pkg/syncer/reconcile"
+	"kpt.dev/configsync/pkg/syncer/syncertest"
+	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRemoveNomosMeta(t *testing.T) {
+	ctx := context.Background()
+	obj := fake.UnstructuredObject(kinds.ConfigMap(), core.Name("test"), core.Namespace("default"),
+		syncertest.ManagementEnabled, syncertest.TokenAnnotation,
+		core.Label("team", "a"), core.Annotation("note", "keep"))
+	c := syncerFake.NewClient(t, core.Scheme, obj)
+	applier := reconcile.NewApplierForMultiRepo(syncerclient.New(c, nil), configsync.ConflictPolicyForce, nil)
+
+	require.NoError(t, applier.RemoveNomosMeta(ctx, obj, "test"))
+
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(kinds.ConfigMap())
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(obj), actual))
+	assert.Equal(t, map[string]string{"team": "a"}, actual.GetLabels())
+	assert.Equal(t, map[string]string{"note": "keep"}, actual.GetAnnotations())
+
+	// No change if there is no Config Sync metadata
+	rv := actual.GetResourceVersion()
+	require.NoError(t, applier.RemoveNomosMeta(ctx, obj, "test"))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(obj), actual))
+	assert.Equal(t, rv, actual.GetResourceVersion())
+}
+
+func TestUpdate_IgnoredFields(t *testing.T) {
+	ctx := context.Background()
+	obj := fake.UnstructuredObject(kinds.ConfigMap(), core.Name("test"), core.Namespace("default"))
+	require.NoError(t, unstructured.SetNestedStringMap(obj.Object, map[string]string{"key": "a", "replicas": "3"}, "data"))
+	c := syncerFake.NewClient(t, core.Scheme)
+	applier := reconcile.NewApplierForMultiRepo(syncerclient.New(c, nil), configsync.ConflictPolicyForce, nil)
+	require.NoError(t, applier.Create(ctx, obj))
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(kinds.ConfigMap())
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(obj), current))
+
+	// The ignored field is removed from the declared object, but is left
+	// unchanged by the remediator.
+	intended := fake.UnstructuredObject(kinds.ConfigMap(), core.Name("test"), core.Namespace("default"),
+		core.Annotation(metadata.IgnoreFieldsAnnotationKey, ".data.replicas"))
+	require.NoError(t, unstructured.SetNestedStringMap(intended.Object, map[string]string{"key": "b"}, "data"))
+	require.NoError(t, applier.Update(ctx, intended, current))
+
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(kinds.ConfigMap())
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(obj), actual))
+	data, _, err := unstructured.NestedStringMap(actual.Object, "data")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "b", "replicas": "3"}, data)
+}
@@ -15,12 +15,23 @@
 package reconcile
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/status"
+	syncerclient "kpt.dev/configsync/pkg/syncer/client"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -73,3 +84,171 @@ func TestEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestIsFieldManagerConflict(t *testing.T) {
+	testcases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "not a conflict",
+			err:      apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "test"),
+			expected: false,
+		},
+		{
+			name:     "resource version conflict",
+			err:      apierrors.NewConflict(schema.GroupResource{Resource: "namespaces"}, "test", errors.New("the object has been modified")),
+			expected: false,
+		},
+		{
+			name: "field manager conflict",
+			err: apierrors.NewApplyConflict([]metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl"`, Field: ".metadata.labels.key"},
+			}, "Apply failed with 1 conflict"),
+			expected: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isFieldManagerConflict(tc.err))
+		})
+	}
+}
+
+func TestUpdateError(t *testing.T) {
+	obj := fake.UnstructuredObject(kinds.Namespace(), core.Name("test"))
+	conflict := apierrors.NewApplyConflict([]metav1.StatusCause{
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl"`, Field: ".metadata.labels.key"},
+	}, "Apply failed with 1 conflict")
+
+	err := updateError(conflict, obj)
+	assert.Equal(t, status.ManagementConflictErrorCode, err.Code())
+
+	err = updateError(apierrors.NewConflict(schema.GroupResource{Resource: "namespaces"}, "test", errors.New("the object has been modified")), obj)
+	assert.Equal(t, syncerclient.ResourceConflictCode, err.Code())
+}
+
+// patchRecorder is a client.Client that records patches, without sending them.
+type patchRecorder struct {
+	client.Client
+	patches []recordedPatch
+}
+
+type recordedPatch struct {
+	patchType types.PatchType
+	data      []byte
+}
+
+func (r *patchRecorder) Patch(_ context.Context, obj client.Object, patch client.Patch, _ ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	r.patches = append(r.patches, recordedPatch{patchType: patch.Type(), data: data})
+	return nil
+}
+
+// managedFieldsPatch decodes the managed fields and resourceVersion of a JSON
+// patch recorded by the patchRecorder.
+func managedFieldsPatch(t *testing.T, p recordedPatch) ([]metav1.ManagedFieldsEntry, string) {
+	t.Helper()
+	require.Equal(t, types.JSONPatchType, p.patchType)
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(p.data, &ops))
+	var managedFields []metav1.ManagedFieldsEntry
+	var resourceVersion string
+	for _, op := range ops {
+		require.Equal(t, "replace", op.Op)
+		switch op.Path {
+		case "/metadata/managedFields":
+			require.NoError(t, json.Unmarshal(op.Value, &managedFields))
+		case "/metadata/resourceVersion":
+			require.NoError(t, json.Unmarshal(op.Value, &resourceVersion))
+		default:
+			t.Fatalf("unexpected patch path %q", op.Path)
+		}
+	}
+	return managedFields, resourceVersion
+}
+
+func managedFieldsEntry(manager string, operation metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  operation,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+func TestUpgradeManagedFields(t *testing.T) {
+	obj := fake.UnstructuredObject(kinds.Namespace(), core.Name("test"), core.Label("team", "a"), core.Label("env", "prod"))
+	obj.SetResourceVersion("5")
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		managedFieldsEntry("reconciler", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:labels":{"f:team":{}}}}`),
+		managedFieldsEntry(configsync.FieldManager, metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{"f:env":{}}}}`),
+		managedFieldsEntry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:annotations":{}}}`),
+	})
+	recorder := &patchRecorder{}
+	applier := &clientApplier{client: syncerclient.New(recorder, nil)}
+
+	require.NoError(t, applier.upgradeManagedFields(context.Background(), obj))
+	require.Len(t, recorder.patches, 1)
+	managedFields, resourceVersion := managedFieldsPatch(t, recorder.patches[0])
+	assert.Equal(t, "5", resourceVersion)
+	require.Len(t, managedFields, 2)
+	assert.Equal(t, configsync.FieldManager, managedFields[0].Manager)
+	assert.Equal(t, metav1.ManagedFieldsOperationApply, managedFields[0].Operation)
+	assert.JSONEq(t, `{"f:metadata":{"f:labels":{"f:env":{},"f:team":{}}}}`, string(managedFields[0].FieldsV1.Raw))
+	assert.Equal(t, "kubectl-edit", managedFields[1].Manager)
+
+	// No patch if already migrated
+	recorder.patches = nil
+	obj.SetManagedFields(managedFields)
+	require.NoError(t, applier.upgradeManagedFields(context.Background(), obj))
+	assert.Empty(t, recorder.patches)
+}
+
+func TestReleaseManagedFields(t *testing.T) {
+	obj := fake.UnstructuredObject(kinds.Namespace(), core.Name("test"))
+	obj.SetResourceVersion("5")
+	recorder := &patchRecorder{}
+	applier := &clientApplier{client: syncerclient.New(recorder, nil)}
+
+	// No patch if not owned by Config Sync
+	kubectlEdit := managedFieldsEntry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:annotations":{}}}`)
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{kubectlEdit})
+	require.NoError(t, applier.releaseManagedFields(context.Background(), obj))
+	assert.Empty(t, recorder.patches)
+
+	// Config Sync field manager removed
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		managedFieldsEntry(configsync.FieldManager, metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{"f:env":{}}}}`),
+		kubectlEdit,
+	})
+	require.NoError(t, applier.releaseManagedFields(context.Background(), obj))
+	require.Len(t, recorder.patches, 1)
+	managedFields, resourceVersion := managedFieldsPatch(t, recorder.patches[0])
+	assert.Equal(t, "5", resourceVersion)
+	assert.Equal(t, []metav1.ManagedFieldsEntry{kubectlEdit}, managedFields)
+
+	// Managed fields reset, if only owned by Config Sync
+	recorder.patches = nil
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		managedFieldsEntry(configsync.FieldManager, metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{"f:env":{}}}}`),
+	})
+	require.NoError(t, applier.releaseManagedFields(context.Background(), obj))
+	require.Len(t, recorder.patches, 1)
+	managedFields, _ = managedFieldsPatch(t, recorder.patches[0])
+	assert.Equal(t, []metav1.ManagedFieldsEntry{{}}, managedFields)
+}
@@ -217,8 +217,8 @@ func (dc *DynamicClient) list(action clienttesting.Action) (bool, runtime.Object
 
 func (dc *DynamicClient) update(action clienttesting.Action) (bool, runtime.Object, error) {
 	updateAction := action.(clienttesting.UpdateAction)
-	if updateAction.GetSubresource() != "" {
-		// TODO: add support for subresource updates, if needed
+	if updateAction.GetSubresource() != "" && updateAction.GetSubresource() != "status" {
+		// TODO: add support for other subresource updates, if needed
 		return true, nil, errors.Errorf("fake.DynamicClient.update: resource=%q subresource=%q: not yet implemented",
 			updateAction.GetResource().Resource, updateAction.GetSubresource())
 	}
@@ -240,7 +240,11 @@ func (dc *DynamicClient) update(action clienttesting.Action) (bool, runtime.Obje
 			updateAction.GetResource(),
 			gvk, uObj.GroupVersionKind())
 	}
-	err = dc.storage.Update(context.Background(), uObj, &client.UpdateOptions{})
+	if updateAction.GetSubresource() != "" {
+		err = dc.storage.Subresource(updateAction.GetSubresource()).Update(context.Background(), uObj, &client.SubResourceUpdateOptions{})
+	} else {
+		err = dc.storage.Update(context.Background(), uObj, &client.UpdateOptions{})
+	}
 	return true, uObj, err
 }
 
@@ -261,6 +265,11 @@ func (dc *DynamicClient) patch(action clienttesting.Action) (bool, runtime.Objec
 	uObj.SetName(patchAction.GetName())
 	patch := client.RawPatch(patchAction.GetPatchType(), patchAction.GetPatch())
 	err = dc.storage.Patch(context.Background(), uObj, patch, &client.PatchOptions{})
+	if err != nil {
+		return true, nil, err
+	}
+	// Return the patched object, like the apiserver.
+	err = dc.storage.Get(context.Background(), gvk, client.ObjectKeyFromObject(uObj), uObj, &client.GetOptions{})
 	return true, uObj, err
 }
 
@@ -327,8 +336,7 @@ func (dc *DynamicClient) watch(action clienttesting.Action) (bool, watch.Interfa
 			ResourceVersion: restrictions.ResourceVersion,
 		},
 	}
-	exampleList := &unstructured.UnstructuredList{}
-	exampleList.SetGroupVersionKind(gvk)
+	exampleList := kinds.NewUnstructuredListForItemGVK(gvk)
 	watcher, err := dc.storage.Watch(context.Background(), exampleList, opts)
 	if err != nil {
 		return true, nil, err
@@ -836,8 +836,10 @@ func (ms *MemoryStorage) Patch(ctx context.Context, obj client.Object, patch cli
 	}
 	if found {
 		uObj.SetResourceVersion(cachedObj.GetResourceVersion())
+		uObj.SetUID(cachedObj.GetUID())
 	} else {
 		uObj.SetResourceVersion("0") // init to allow incrementing
+		initUID(uObj)
 	}
 	if err := incrementResourceVersion(uObj); err != nil {
 		return errors.Wrap(err, "failed to increment resourceVersion")
@@ -234,7 +234,13 @@ func (fw *Watcher) Start(ctx context.Context) <-chan struct{} {
 }
 
 func (fw *Watcher) handleEvents(ctx context.Context) {
-	lastSeenVersions := make(map[types.UID]int)
+	// The fake storage may give the same UID to several objects, so the
+	// versions are tracked by object key and UID.
+	type objectUID struct {
+		key types.NamespacedName
+		uid types.UID
+	}
+	lastSeenVersions := make(map[objectUID]int)
 
 	doneCh := ctx.Done()
 	defer close(fw.outCh)
@@ -265,7 +271,7 @@ func (fw *Watcher) handleEvents(ctx context.Context) {
 					})
 					continue
 				}
-				uid := obj.GetUID()
+				uid := objectUID{key: client.ObjectKeyFromObject(obj), uid: obj.GetUID()}
 				if event.Type == watch.Modified {
 					oldRV := lastSeenVersions[uid]
 					if newRV <= oldRV {
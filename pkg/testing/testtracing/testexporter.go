@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testtracing
+
+import (
+	"sync"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+// TestExporter keeps exported spans in memory to aid in testing.
+type TestExporter struct {
+	mux   sync.Mutex
+	spans []*trace.SpanData
+}
+
+var _ trace.Exporter = &TestExporter{}
+
+// ExportSpan records the span data.
+func (e *TestExporter) ExportSpan(sd *trace.SpanData) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.spans = append(e.spans, sd)
+}
+
+// Spans returns the spans exported so far, in the order they ended.
+func (e *TestExporter) Spans() []*trace.SpanData {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return append([]*trace.SpanData(nil), e.spans...)
+}
+
+// SpanNames returns the names of the spans exported so far, in the order they
+// ended.
+func (e *TestExporter) SpanNames() []string {
+	var names []string
+	for _, sd := range e.Spans() {
+		names = append(names, sd.Name)
+	}
+	return names
+}
+
+// Span returns the first exported span with the specified name, or nil if
+// not found.
+func (e *TestExporter) Span(name string) *trace.SpanData {
+	for _, sd := range e.Spans() {
+		if sd.Name == name {
+			return sd
+		}
+	}
+	return nil
+}
+
+// RegisterExporter registers a TestExporter which records every span, until
+// the test completes.
+func RegisterExporter(t *testing.T) *TestExporter {
+	e := &TestExporter{}
+	trace.RegisterExporter(e)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	t.Cleanup(func() {
+		trace.UnregisterExporter(e)
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+	})
+	return e
+}
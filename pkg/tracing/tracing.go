@@ -0,0 +1,161 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records trace spans for the steps of the parse-apply-watch
+// loop, the remediator and the hydration-controller.
+//
+// Spans are recorded with OpenCensus and exported to the otel-agent sidecar,
+// using the same OpenCensus protocol as the metrics, even if the metrics are
+// served to Prometheus. The otel-agent forwards them to the otel-collector,
+// along with the Config Sync resource attributes.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"contrib.go.opencensus.io/exporter/ocagent"
+	"go.opencensus.io/trace"
+	"k8s.io/klog/v2"
+)
+
+// Span names
+const (
+	// SpanParseRun is the root span of each parse-apply-watch loop.
+	SpanParseRun = "parse.run"
+	// SpanReadSourceCommit is the span for reading the source commit and sync
+	// directory written by git-sync, oci-sync or helm-sync.
+	SpanReadSourceCommit = "parse.readSourceCommit"
+	// SpanReadFromSource is the span for reading the source or hydrated files.
+	SpanReadFromSource = "parse.readFromSource"
+	// SpanParseHydrationState is the span for reading the hydrated output of
+	// the hydration-controller.
+	SpanParseHydrationState = "parse.parseHydrationState"
+	// SpanParseSource is the span for parsing and validating the source files.
+	SpanParseSource = "parse.parseSource"
+	// SpanValidateHierarchical is the span for the validation of a repository
+	// with the hierarchy source format.
+	SpanValidateHierarchical = "validate.Hierarchical"
+	// SpanValidateUnstructured is the span for the validation of a repository
+	// with the unstructured source format.
+	SpanValidateUnstructured = "validate.Unstructured"
+	// SpanUpdateWebhookConfiguration is the span for the update of the
+	// admission webhook configuration.
+	SpanUpdateWebhookConfiguration = "webhookconfiguration.Update"
+	// SpanUpdate is the span for the declare-apply-watch sequence.
+	SpanUpdate = "parse.update"
+	// SpanUpdateDeclared is the span for the update of the declared resources.
+	SpanUpdateDeclared = "declared.Update"
+	// SpanApply is the span for a cli-utils apply run.
+	SpanApply = "applier.Apply"
+	// SpanDestroy is the span for a cli-utils destroy run.
+	SpanDestroy = "applier.Destroy"
+	// SpanUpdateWatches is the span for the update of the remediator watches.
+	SpanUpdateWatches = "remediator.UpdateWatches"
+	// SpanRemediate is the span for the remediation of a single object.
+	SpanRemediate = "remediator.Remediate"
+	// SpanRender is the span for the rendering of a commit by the
+	// hydration-controller.
+	SpanRender = "hydrate.Render"
+)
+
+// Span attribute keys
+const (
+	// KeySyncName is the name of the RootSync or RepoSync.
+	KeySyncName = "configsync.sync.name"
+	// KeyReconciler is the name of the reconciler Deployment.
+	KeyReconciler = "configsync.reconciler"
+	// KeyCommit is the source commit being synced.
+	KeyCommit = "configsync.commit"
+	// KeyTrigger is what triggered the parse-apply-watch loop.
+	KeyTrigger = "configsync.trigger"
+	// KeyRenderingMessage is the rendering status message.
+	KeyRenderingMessage = "configsync.rendering.message"
+	// KeyFileCount is the number of source files read.
+	KeyFileCount = "configsync.file.count"
+	// KeyObjectCount is the number of objects parsed, declared or applied.
+	KeyObjectCount = "configsync.object.count"
+	// KeyGVKCount is the number of resource types watched.
+	KeyGVKCount = "configsync.gvk.count"
+	// KeyObjectID is the group, kind, namespace and name of an object.
+	KeyObjectID = "configsync.object.id"
+	// KeyOperation is the remediator operation.
+	KeyOperation = "configsync.operation"
+	// KeyActionGroup is the name of a cli-utils action group.
+	KeyActionGroup = "configsync.action_group"
+	// KeyAction is the action of a cli-utils action group: Apply, Prune,
+	// Delete, Wait, etc.
+	KeyAction = "configsync.action"
+)
+
+// StartSpan starts a new span as a child of the span in the context, if any,
+// with the specified attributes.
+func StartSpan(ctx context.Context, name string, attrs ...trace.Attribute) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, name)
+	if len(attrs) > 0 {
+		span.AddAttributes(attrs...)
+	}
+	return ctx, span
+}
+
+// EndSpan ends the span, with an error status if err is not nil.
+func EndSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeUnknown,
+			Message: err.Error(),
+		})
+	}
+	span.End()
+}
+
+// TaskSpanName returns the name of the span for a cli-utils action group,
+// for example "applier.apply-0" or "applier.wait-1".
+func TaskSpanName(groupName string) string {
+	return fmt.Sprintf("applier.%s", groupName)
+}
+
+// Register registers the trace exporter and configures the sampling
+// probability, between 0 and 1. No spans are recorded if the probability is 0.
+func Register(exporter trace.Exporter, samplingProbability float64) {
+	if samplingProbability <= 0 {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return
+	}
+	klog.Infof("Exporting traces with sampling probability %v", samplingProbability)
+	trace.RegisterExporter(exporter)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(samplingProbability)})
+}
+
+// RegisterExporter registers the metrics exporter as a trace exporter, if it
+// supports traces. Otherwise, e.g. with the Prometheus exporter, the traces are
+// exported to the otel-agent sidecar by a separate OC Agent exporter, which is
+// returned so that the caller can stop it. No exporter is created if the
+// sampling probability is 0.
+func RegisterExporter(exporter interface{}, samplingProbability float64) (*ocagent.Exporter, error) {
+	if te, ok := exporter.(trace.Exporter); ok {
+		Register(te, samplingProbability)
+		return nil, nil
+	}
+	if samplingProbability <= 0 {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return nil, nil
+	}
+	oce, err := ocagent.NewExporter(ocagent.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OC Agent trace exporter: %w", err)
+	}
+	Register(oce, samplingProbability)
+	return oce, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+	"kpt.dev/configsync/pkg/testing/testtracing"
+)
+
+func TestEndSpan(t *testing.T) {
+	exporter := testtracing.RegisterExporter(t)
+
+	ctx, parent := StartSpan(context.Background(), SpanParseRun,
+		trace.StringAttribute(KeyCommit, "abc123"))
+	_, child := StartSpan(ctx, SpanParseSource,
+		trace.Int64Attribute(KeyObjectCount, 3))
+	EndSpan(child, errors.New("parse error"))
+	EndSpan(parent, nil)
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	require.Equal(t, SpanParseSource, spans[0].Name)
+	require.Equal(t, map[string]interface{}{KeyObjectCount: int64(3)}, spans[0].Attributes)
+	require.Equal(t, trace.Status{Code: trace.StatusCodeUnknown, Message: "parse error"}, spans[0].Status)
+	require.Equal(t, SpanParseRun, spans[1].Name)
+	require.Equal(t, map[string]interface{}{KeyCommit: "abc123"}, spans[1].Attributes)
+	require.Equal(t, trace.Status{}, spans[1].Status)
+	require.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+}
+
+func TestRegisterExporter(t *testing.T) {
+	exporter := testtracing.RegisterExporter(t)
+
+	// Exporters which do not support traces disable sampling, if no traces
+	// are recorded.
+	te, err := RegisterExporter(struct{}{}, 0)
+	require.NoError(t, err)
+	require.Nil(t, te)
+	_, span := StartSpan(context.Background(), SpanRemediate)
+	EndSpan(span, nil)
+	require.Empty(t, exporter.Spans())
+
+	// Otherwise, the traces are exported by an OC Agent exporter.
+	te, err = RegisterExporter(struct{}{}, 1)
+	require.NoError(t, err)
+	require.NotNil(t, te)
+	trace.UnregisterExporter(te)
+	require.NoError(t, te.Stop())
+
+	// A sampling probability of 0 disables sampling.
+	Register(exporter, 0)
+	_, span = StartSpan(context.Background(), SpanRemediate)
+	EndSpan(span, nil)
+	require.Empty(t, exporter.Spans())
+
+	// Exporters which support traces are registered.
+	te, err = RegisterExporter(exporter, 1)
+	require.NoError(t, err)
+	require.Nil(t, te)
+	_, span = StartSpan(context.Background(), SpanRemediate)
+	EndSpan(span, nil)
+	require.Equal(t, []string{SpanRemediate}, exporter.SpanNames())
+}
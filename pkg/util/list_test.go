@@ -0,0 +1,57 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitList(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "trims spaces and drops empty items",
+			input: " a, b ,,c, ",
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "keeps commas in quotes",
+			input: `.data["a,b"], .spec.replicas`,
+			want:  []string{`.data["a,b"]`, ".spec.replicas"},
+		},
+		{
+			name:  "keeps escaped quotes in quotes",
+			input: `.data["a\",b"],.spec`,
+			want:  []string{`.data["a\",b"]`, ".spec"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, SplitList(tc.input)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
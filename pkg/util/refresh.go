@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RefreshSignal is the signal sent by the reconciler to the other containers
+// of its Pod, which share its process namespace, to fetch and render the
+// source again right away, when a resync is requested with the sync-trigger
+// annotation. git-sync handles it with `--sync-on-signal`.
+const RefreshSignal = syscall.SIGHUP
+
+// RefreshProcesses are the names of the executables which handle the
+// RefreshSignal.
+var RefreshProcesses = []string{"git-sync", "oci-sync", "helm-sync", "hydration-controller"}
+
+// SignalRefresh sends the RefreshSignal to the RefreshProcesses listed in
+// procRoot, usually /proc. It returns the number of signaled processes.
+func SignalRefresh(procRoot string) (int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(procRoot, entry.Name(), "cmdline"))
+		if err != nil {
+			// The process exited, or belongs to another user.
+			continue
+		}
+		name := filepath.Base(string(bytes.SplitN(cmdline, []byte{0}, 2)[0]))
+		if !isRefreshProcess(name) {
+			continue
+		}
+		process, err := os.FindProcess(pid)
+		if err == nil {
+			err = process.Signal(RefreshSignal)
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to signal %s (pid: %d): %w", name, pid, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func isRefreshProcess(name string) bool {
+	for _, p := range RefreshProcesses {
+		if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyRefresh returns a channel which receives the RefreshSignal.
+func NotifyRefresh() <-chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, RefreshSignal)
+	return ch
+}
+
+// WaitOrRefresh waits for the specified duration, or until the RefreshSignal
+// is received on refresh.
+func WaitOrRefresh(d time.Duration, refresh <-chan os.Signal) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-refresh:
+	}
+}
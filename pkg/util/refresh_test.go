@@ -0,0 +1,56 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalRefresh(t *testing.T) {
+	procRoot := t.TempDir()
+	writeProcess := func(pid int, cmdline string) {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644))
+	}
+
+	cmd := exec.Command("sleep", "60")
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	// The sleep process pretends to be git-sync.
+	writeProcess(cmd.Process.Pid, "/git-sync\x00--root=/repo/source\x00")
+	// Other processes, and the process itself, are not signaled.
+	writeProcess(os.Getpid(), "/reconciler\x00")
+	require.NoError(t, os.Mkdir(filepath.Join(procRoot, "self"), 0755))
+
+	count, err := SignalRefresh(procRoot)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	status := exitErr.Sys().(syscall.WaitStatus)
+	assert.Equal(t, RefreshSignal, status.Signal())
+}
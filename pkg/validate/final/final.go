@@ -15,6 +15,8 @@
 package final
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/validate/final/validate"
@@ -37,3 +39,11 @@ func Validation(objs []ast.FileObject) status.MultiError {
 	}
 	return errs
 }
+
+// AdmissionPolicies evaluates the ValidatingAdmissionPolicies and the
+// Gatekeeper constraints of the cluster, and the ones declared in the given
+// FileObjects, against the FileObjects. This should be called on the objects
+// as they are applied, after the visitors.
+func AdmissionPolicies(objs []ast.FileObject, clusterObjs []*unstructured.Unstructured, enforcement configsync.PolicyEnforcement) status.MultiError {
+	return validate.AdmissionPolicies(objs, clusterObjs, enforcement)
+}
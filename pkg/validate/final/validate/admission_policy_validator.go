@@ -0,0 +1,76 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/admissionpolicy"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AdmissionPolicies verifies that the ValidatingAdmissionPolicies and the
+// Gatekeeper constraints of the cluster, and the ones declared with the
+// objects, would not deny the objects. clusterObjs are the policies, the policy
+// bindings, the ConstraintTemplates, the constraints and the Namespaces of the
+// cluster. The declared objects replace the ones of the cluster with the same
+// name.
+//
+// With the deny enforcement, the violations of the policies which deny the
+// objects are blocking errors. The other violations, and the policies which
+// could not be evaluated, are reported as non-blocking warnings. With the warn
+// enforcement, all the violations are warnings, and with the disabled
+// enforcement, the policies are not evaluated.
+func AdmissionPolicies(objs []ast.FileObject, clusterObjs []*unstructured.Unstructured, enforcement configsync.PolicyEnforcement) status.MultiError {
+	if enforcement == configsync.PolicyEnforcementDisabled {
+		return nil
+	}
+	policyObjs := make([]*unstructured.Unstructured, 0, len(clusterObjs)+len(objs))
+	policyObjs = append(policyObjs, clusterObjs...)
+	// declared are the declared objects, so that the errors point to the
+	// files of the declared policies.
+	declared := make(map[*unstructured.Unstructured]ast.FileObject, len(objs))
+	for _, obj := range objs {
+		policyObjs = append(policyObjs, obj.Unstructured)
+		declared[obj.Unstructured] = obj
+	}
+	set, err := admissionpolicy.NewSet(policyObjs)
+	if err != nil {
+		return status.InternalWrap(err)
+	}
+	if set.Empty() {
+		return nil
+	}
+
+	var errs status.MultiError
+	for _, obj := range objs {
+		for _, v := range set.Evaluate(obj.Unstructured) {
+			var sources []client.Object
+			for _, source := range v.Sources {
+				if fileObj, found := declared[source]; found {
+					sources = append(sources, fileObj)
+				}
+			}
+			if v.Warning || enforcement == configsync.PolicyEnforcementWarn {
+				errs = status.Append(errs, status.AdmissionPolicyWarningError(obj, v.PolicyName(), v.Message, sources...))
+			} else {
+				errs = status.Append(errs, status.AdmissionPolicyViolationError(obj, v.PolicyName(), v.Message, sources...))
+			}
+		}
+	}
+	return errs
+}
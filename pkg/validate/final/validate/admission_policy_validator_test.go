@@ -0,0 +1,241 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/admissionpolicy"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	policyGVK  = schema.GroupVersionKind{Group: admissionpolicy.Group, Version: "v1", Kind: admissionpolicy.PolicyKind}
+	bindingGVK = schema.GroupVersionKind{Group: admissionpolicy.Group, Version: "v1", Kind: admissionpolicy.BindingKind}
+
+	templateGVK   = schema.GroupVersionKind{Group: admissionpolicy.TemplateGroup, Version: "v1", Kind: admissionpolicy.TemplateKind}
+	constraintGVK = schema.GroupVersionKind{Group: admissionpolicy.ConstraintGroup, Version: "v1beta1", Kind: "K8sHostNetwork"}
+)
+
+func hostNetworkPolicy() ast.FileObject {
+	obj := fake.UnstructuredAtPath(policyGVK, "cluster/policy.yaml", core.Name("no-host-network"))
+	_ = unstructured.SetNestedField(obj.Object, map[string]interface{}{
+		"matchConstraints": map[string]interface{}{
+			"resourceRules": []interface{}{
+				map[string]interface{}{
+					"apiGroups":   []interface{}{"apps"},
+					"apiVersions": []interface{}{"v1"},
+					"operations":  []interface{}{"CREATE", "UPDATE"},
+					"resources":   []interface{}{"deployments"},
+				},
+			},
+		},
+		"validations": []interface{}{
+			map[string]interface{}{
+				"expression": "!has(object.spec.template.spec.hostNetwork) || !object.spec.template.spec.hostNetwork",
+				"message":    "hostNetwork is not allowed",
+			},
+		},
+	}, "spec")
+	return obj
+}
+
+func hostNetworkBinding(action string) ast.FileObject {
+	obj := fake.UnstructuredAtPath(bindingGVK, "cluster/binding.yaml", core.Name("no-host-network"))
+	_ = unstructured.SetNestedField(obj.Object, map[string]interface{}{
+		"policyName":        "no-host-network",
+		"validationActions": []interface{}{action},
+	}, "spec")
+	return obj
+}
+
+func hostNetworkTemplate() ast.FileObject {
+	obj := fake.UnstructuredAtPath(templateGVK, "cluster/template.yaml", core.Name("k8shostnetwork"))
+	_ = unstructured.SetNestedField(obj.Object, map[string]interface{}{
+		"crd": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"names": map[string]interface{}{"kind": "K8sHostNetwork"},
+			},
+		},
+		"targets": []interface{}{
+			map[string]interface{}{
+				"target": "admission.k8s.gatekeeper.sh",
+				"rego": `package k8shostnetwork
+
+violation[{"msg": "hostNetwork is not allowed"}] {
+	input.review.object.spec.template.spec.hostNetwork
+}
+`,
+			},
+		},
+	}, "spec")
+	return obj
+}
+
+func hostNetworkConstraint(action string) ast.FileObject {
+	obj := fake.UnstructuredAtPath(constraintGVK, "cluster/constraint.yaml", core.Name("no-host-network"))
+	_ = unstructured.SetNestedField(obj.Object, map[string]interface{}{
+		"enforcementAction": action,
+	}, "spec")
+	return obj
+}
+
+func hostNetworkDeployment() ast.FileObject {
+	obj := fake.Deployment("namespaces/foo", core.Namespace("foo"))
+	_ = unstructured.SetNestedField(obj.Object, true, "spec", "template", "spec", "hostNetwork")
+	return obj
+}
+
+func TestAdmissionPolicies(t *testing.T) {
+	testCases := []struct {
+		name        string
+		objs        []ast.FileObject
+		clusterObjs []*unstructured.Unstructured
+		enforcement configsync.PolicyEnforcement
+		wantErrs    status.MultiError
+	}{
+		{
+			name: "no policy",
+			objs: []ast.FileObject{hostNetworkDeployment()},
+		},
+		{
+			name: "allowed by a declared policy",
+			objs: []ast.FileObject{
+				hostNetworkPolicy(),
+				hostNetworkBinding("Deny"),
+				fake.Deployment("namespaces/foo", core.Namespace("foo")),
+			},
+		},
+		{
+			name: "denied by a declared policy",
+			objs: []ast.FileObject{
+				hostNetworkPolicy(),
+				hostNetworkBinding("Deny"),
+				hostNetworkDeployment(),
+			},
+			wantErrs: fake.Errors(status.AdmissionPolicyViolationErrorCode),
+		},
+		{
+			name: "denied by a cluster policy",
+			objs: []ast.FileObject{hostNetworkDeployment()},
+			clusterObjs: []*unstructured.Unstructured{
+				hostNetworkPolicy().Unstructured,
+				hostNetworkBinding("Deny").Unstructured,
+			},
+			wantErrs: fake.Errors(status.AdmissionPolicyViolationErrorCode),
+		},
+		{
+			name: "declared binding replaces the cluster binding",
+			objs: []ast.FileObject{
+				hostNetworkBinding("Warn"),
+				hostNetworkDeployment(),
+			},
+			clusterObjs: []*unstructured.Unstructured{
+				hostNetworkPolicy().Unstructured,
+				hostNetworkBinding("Deny").Unstructured,
+			},
+			wantErrs: fake.Errors(status.AdmissionPolicyWarningErrorCode),
+		},
+		{
+			name: "deny enforcement",
+			objs: []ast.FileObject{
+				hostNetworkPolicy(),
+				hostNetworkBinding("Deny"),
+				hostNetworkDeployment(),
+			},
+			enforcement: configsync.PolicyEnforcementDeny,
+			wantErrs:    fake.Errors(status.AdmissionPolicyViolationErrorCode),
+		},
+		{
+			name: "warn enforcement",
+			objs: []ast.FileObject{
+				hostNetworkPolicy(),
+				hostNetworkBinding("Deny"),
+				hostNetworkDeployment(),
+			},
+			enforcement: configsync.PolicyEnforcementWarn,
+			wantErrs:    fake.Errors(status.AdmissionPolicyWarningErrorCode),
+		},
+		{
+			name: "disabled enforcement",
+			objs: []ast.FileObject{
+				hostNetworkPolicy(),
+				hostNetworkBinding("Deny"),
+				hostNetworkDeployment(),
+			},
+			enforcement: configsync.PolicyEnforcementDisabled,
+		},
+		{
+			name: "denied by a declared Gatekeeper constraint",
+			objs: []ast.FileObject{
+				hostNetworkTemplate(),
+				hostNetworkConstraint(""),
+				hostNetworkDeployment(),
+			},
+			wantErrs: fake.Errors(status.AdmissionPolicyViolationErrorCode),
+		},
+		{
+			name: "dryrun Gatekeeper constraint of the cluster",
+			objs: []ast.FileObject{hostNetworkDeployment()},
+			clusterObjs: []*unstructured.Unstructured{
+				hostNetworkTemplate().Unstructured,
+				hostNetworkConstraint("dryrun").Unstructured,
+			},
+			wantErrs: fake.Errors(status.AdmissionPolicyWarningErrorCode),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := AdmissionPolicies(tc.objs, tc.clusterObjs, tc.enforcement)
+			if !errors.Is(errs, tc.wantErrs) {
+				t.Errorf("got AdmissionPolicies() error %v, want %v", errs, tc.wantErrs)
+			}
+		})
+	}
+}
+
+func TestAdmissionPolicies_DeclaredPolicyResources(t *testing.T) {
+	template := hostNetworkTemplate()
+	constraint := hostNetworkConstraint("")
+	deployment := hostNetworkDeployment()
+	errs := AdmissionPolicies([]ast.FileObject{template, constraint, deployment}, nil, "")
+	if errs == nil || len(errs.Errors()) != 1 {
+		t.Fatalf("got AdmissionPolicies() error %v, want a violation", errs)
+	}
+	err, ok := errs.Errors()[0].(status.ResourceError)
+	if !ok {
+		t.Fatalf("got AdmissionPolicies() error %T, want a ResourceError", errs.Errors()[0])
+	}
+	if !strings.Contains(err.Error(), `K8sHostNetwork "no-host-network"`) {
+		t.Errorf("got AdmissionPolicies() error %q, want it to name the constraint", err.Error())
+	}
+	// The declared constraint and template are reported with the object, so
+	// that the error points to their files.
+	want := []client.Object{deployment, constraint, template}
+	if diff := cmp.Diff(want, err.Resources()); diff != "" {
+		t.Errorf("got the resources of the error with diff (-want +got):\n%s", diff)
+	}
+}
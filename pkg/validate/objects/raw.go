@@ -17,7 +17,9 @@ package objects
 import (
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/klog/v2"
+	configsyncv1beta1 "kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/customresources"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
@@ -42,6 +44,16 @@ type Raw struct {
 	BuildScoper       utildiscovery.BuildScoperFunc
 	Converter         *declared.ValueConverter
 	AllowUnknownKinds bool
+	// RepoSyncPolicy limits what the objects of a namespace repo may declare.
+	// It is nil if there is no limit.
+	RepoSyncPolicy *configsyncv1beta1.RepoSyncPolicy
+	// IgnoreDifferences are the ignored fields of each kind, from the
+	// ignoreDifferences override of the RootSync or RepoSync.
+	IgnoreDifferences ignorefields.Rules
+	// Validated returns true if the object passed validation before and is
+	// unchanged since. The validators of a single object skip such objects.
+	// All the objects are validated if it is nil.
+	Validated func(obj ast.FileObject) bool
 }
 
 // Scoped builds a Scoped collection of objects from the Raw objects.
@@ -81,13 +93,22 @@ func (r *Raw) Scoped() (*Scoped, status.MultiError) {
 }
 
 // VisitAllRaw returns a RawVisitor which will call the given ObjectVisitor on
-// every FileObject in the Raw objects.
+// every FileObject in the Raw objects, except the already validated ones.
 func VisitAllRaw(visit ObjectVisitor) RawVisitor {
 	return func(r *Raw) status.MultiError {
 		var errs status.MultiError
 		for _, obj := range r.Objects {
+			if r.IsValidated(obj) {
+				continue
+			}
 			errs = status.Append(errs, visit(obj))
 		}
 		return errs
 	}
 }
+
+// IsValidated returns true if the object passed validation before and is
+// unchanged since.
+func (r *Raw) IsValidated(obj ast.FileObject) bool {
+	return r.Validated != nil && r.Validated(obj)
+}
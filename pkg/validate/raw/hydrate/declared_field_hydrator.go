@@ -22,20 +22,28 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/customresources"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/validate/objects"
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
 
 // DeclaredFields hydrates the given Raw objects by annotating each object with
 // its fields that are declared in Git. This annotation is what enables the
 // Config Sync admission controller webhook to protect these declared fields
 // from being changed by another controller or user.
+//
+// Encoding the declared fields validates the objects against the schemas from
+// the API server, so objects which do not match the schema of their kind are
+// reported as SchemaValidationErrors.
 func DeclaredFields(objs *objects.Raw) status.MultiError {
 	if objs.Converter == nil {
 		klog.Warning("Skipping declared field hydration. This should only happen for offline executions of nomos vet/hydrate/init.")
@@ -43,7 +51,7 @@ func DeclaredFields(objs *objects.Raw) status.MultiError {
 	}
 
 	var errs status.MultiError
-	needRefresh := false
+	var failed []ast.FileObject
 	for _, obj := range objs.Objects {
 		fields, err := encodeDeclaredFields(objs.Converter, obj.Unstructured)
 		if err != nil {
@@ -53,24 +61,44 @@ func DeclaredFields(objs *objects.Raw) status.MultiError {
 				// No schema checking involved.
 				errs = status.Append(errs, err)
 			default:
-				errs = status.Append(errs, status.EncodeDeclaredFieldError(obj.Unstructured, err))
-				// This error could be due to an out of date schema.
-				// So the converter needs to be refreshed.
-				needRefresh = true
+				failed = append(failed, obj)
 			}
 		}
 		core.SetAnnotation(obj, metadata.DeclaredFieldsKey, string(fields))
 	}
+	if len(failed) == 0 {
+		return errs
+	}
 
-	if needRefresh {
-		// Refresh the converter so that the new schema of types can be used in the next loop of parsing/validating.
-		// If the error returned by `encodeDeclaredFields` is due to the
-		// out of date schema in the Converter, it will be gone in the next loop of hydration/validation.
-		klog.Info("Got error from encoding declared fields. It might be due to an out of date schemas. Refreshing the schemas from the discovery client")
-		if err := objs.Converter.Refresh(); err != nil {
-			// No special handling for the error here.
-			// If Refresh function fails, the next loop of hydration/validation will trigger it again.
-			klog.Warningf("failed to refresh the schemas %v", err)
+	// The errors could be due to out of date schemas in the Converter, so
+	// refresh them and try again before reporting the errors.
+	klog.Info("Got error from encoding declared fields. It might be due to an out of date schemas. Refreshing the schemas from the discovery client")
+	refreshErr := objs.Converter.Refresh()
+	if refreshErr != nil {
+		// No special handling for the error here.
+		// If Refresh function fails, the next loop of hydration/validation will trigger it again.
+		klog.Warningf("failed to refresh the schemas %v", refreshErr)
+	}
+	// Objects of kinds declared by a CRD in the repo are validated against the
+	// CRD in the repo instead, as the schema on the cluster may be different.
+	declaredCRDs, _ := customresources.GetCRDs(objs.Objects)
+	declaredKinds := make(map[schema.GroupKind]bool, len(declaredCRDs))
+	for _, crd := range declaredCRDs {
+		declaredKinds[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = true
+	}
+	for _, obj := range failed {
+		fields, err := encodeDeclaredFields(objs.Converter, obj.Unstructured)
+		core.SetAnnotation(obj, metadata.DeclaredFieldsKey, string(fields))
+		if err == nil {
+			continue
+		}
+		var validationErrs typed.ValidationErrors
+		if refreshErr == nil && errors.As(err, &validationErrs) &&
+			!declaredKinds[obj.GetObjectKind().GroupVersionKind().GroupKind()] {
+			// The object does not match the up-to-date schema of its kind.
+			errs = status.Append(errs, status.SchemaValidationError(obj, err))
+		} else {
+			errs = status.Append(errs, status.EncodeDeclaredFieldError(obj.Unstructured, err))
 		}
 	}
 	return errs
@@ -21,8 +21,11 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"kpt.dev/configsync/pkg/testing/openapitest"
 	"kpt.dev/configsync/pkg/validate/objects"
@@ -190,3 +193,36 @@ func TestDeclaredFields(t *testing.T) {
 		})
 	}
 }
+
+func TestDeclaredFieldsSchemaValidation(t *testing.T) {
+	converter, err := openapitest.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := fake.UnstructuredObject(kinds.Deployment(), core.Name("hello"), core.Namespace("world"))
+	deployment.Object["spec"] = map[string]interface{}{
+		"replica": int64(3),
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "hello", "image": "hello"},
+				},
+			},
+		},
+	}
+	objs := &objects.Raw{
+		Converter: converter,
+		Objects:   []ast.FileObject{fake.FileObject(deployment, "namespaces/world/deployment.yaml")},
+	}
+
+	errs := DeclaredFields(objs)
+	if errs == nil || len(errs.Errors()) != 1 {
+		t.Fatalf("Got DeclaredFields() error %v, want 1 error", errs)
+	}
+	if code := errs.Errors()[0].Code(); code != status.SchemaValidationErrorCode {
+		t.Errorf("Got error code %s, want %s", code, status.SchemaValidationErrorCode)
+	}
+	if !status.HasBlockingErrors(errs) {
+		t.Errorf("Got non-blocking error %v, want blocking error", errs)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"kpt.dev/configsync/pkg/ignorefields"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+// IgnoreFields removes the ignored fields from the given Raw objects: the
+// fields listed in the `configsync.gke.io/ignore-fields` annotation of each
+// object, and the fields of its kind in the ignoreDifferences override of the
+// RootSync or RepoSync. Since the fields are removed before the declared
+// fields are encoded, they are neither applied, nor protected by the admission
+// webhook, nor remediated.
+func IgnoreFields(objs *objects.Raw) status.MultiError {
+	var errs status.MultiError
+	for _, obj := range objs.Objects {
+		if err := ignorefields.Remove(obj.Unstructured, objs.IgnoreDifferences); err != nil {
+			// The annotation was already validated, so this is unexpected.
+			errs = status.Append(errs, status.InternalErrorBuilder.Wrap(err).BuildWithResources(obj))
+		}
+	}
+	return errs
+}
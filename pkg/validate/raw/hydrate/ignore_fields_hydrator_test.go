@@ -0,0 +1,89 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/ignorefields"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+func deploymentWithSpec(name string, spec map[string]interface{}, opts ...core.MetaMutator) ast.FileObject {
+	obj := fake.UnstructuredAtPath(kinds.Deployment(), "namespaces/bookstore/"+name+".yaml", opts...)
+	obj.Unstructured.Object["spec"] = spec
+	return obj
+}
+
+func TestIgnoreFields(t *testing.T) {
+	rules, err := ignorefields.NewRules([]v1beta1.IgnoreDifference{
+		{Group: "apps", Kind: "Deployment", JSONPaths: []string{".spec.replicas"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := func() map[string]interface{} {
+		return map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": "web:v1"},
+						map[string]interface{}{"name": "proxy", "image": "proxy:v1"},
+					},
+				},
+			},
+		}
+	}
+	annotated := core.Annotation(metadata.IgnoreFieldsAnnotationKey, `.spec.template.spec.containers[name="web"].image`)
+
+	objs := &objects.Raw{
+		Objects: []ast.FileObject{
+			deploymentWithSpec("web", spec(), core.Name("web"), annotated),
+			fake.ClusterRoleAtPath("cluster/clusterrole.yaml", core.Name("reader")),
+		},
+		IgnoreDifferences: rules,
+	}
+
+	wantSpec := spec()
+	delete(wantSpec, "replicas")
+	containers, _, _ := unstructured.NestedSlice(wantSpec, "template", "spec", "containers")
+	delete(containers[0].(map[string]interface{}), "image")
+	if err := unstructured.SetNestedSlice(wantSpec, containers, "template", "spec", "containers"); err != nil {
+		t.Fatal(err)
+	}
+	want := &objects.Raw{
+		Objects: []ast.FileObject{
+			deploymentWithSpec("web", wantSpec, core.Name("web"), annotated),
+			fake.ClusterRoleAtPath("cluster/clusterrole.yaml", core.Name("reader")),
+		},
+		IgnoreDifferences: rules,
+	}
+
+	if err := IgnoreFields(objs); err != nil {
+		t.Errorf("Got IgnoreFields() error %v, want nil", err)
+	}
+	if diff := cmp.Diff(want.Objects, objs.Objects, ast.CompareFileObject); diff != "" {
+		t.Error(diff)
+	}
+}
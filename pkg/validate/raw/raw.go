@@ -44,12 +44,16 @@ func Hierarchical(objs *objects.Raw) status.MultiError {
 		objects.VisitAllRaw(validate.Directory),
 		objects.VisitAllRaw(validate.HNCLabels),
 		objects.VisitAllRaw(validate.ManagementAnnotation),
+		objects.VisitAllRaw(validate.Handoff(objs.ReconcilerName)),
+		objects.VisitAllRaw(validate.ConflictPolicy),
+		objects.VisitAllRaw(validate.IgnoreFields),
 		objects.VisitAllRaw(validate.IllegalCRD),
 		objects.VisitAllRaw(validate.CRDName),
 		objects.VisitAllRaw(validate.RootSync),
 		objects.VisitAllRaw(validate.RepoSync),
 		objects.VisitAllRaw(validate.SelfReconcile(objs.ReconcilerName)),
 		validate.DisallowedFields,
+		validate.Schemas,
 		validate.RemovedCRDs,
 		validate.ClusterSelectorsForHierarchical,
 		validate.Repo,
@@ -61,14 +65,15 @@ func Hierarchical(objs *objects.Raw) status.MultiError {
 		return errs
 	}
 
-	// First we annotate all objects with their declared fields. It is crucial
-	// that we do this step before any other hydration so that we capture the
-	// object exactly as it is declared in Git. Next we set missing namespaces on
-	// objects in namespace directories since cluster selection relies on
-	// namespace if a namespace gets filtered out. Then we perform cluster
-	// selection so that we can filter out irrelevant objects before trying to
-	// modify them.
+	// First we remove the ignored fields and annotate all objects with their
+	// declared fields. It is crucial that we do this step before any other
+	// hydration so that we capture the object exactly as it is declared in Git.
+	// Next we set missing namespaces on objects in namespace directories since
+	// cluster selection relies on namespace if a namespace gets filtered out.
+	// Then we perform cluster selection so that we can filter out irrelevant
+	// objects before trying to modify them.
 	hydrators := []objects.RawVisitor{
+		hydrate.IgnoreFields,
 		hydrate.DeclaredFields,
 		hydrate.DeclaredVersion,
 		hydrate.ObjectNamespaces,
@@ -98,14 +103,19 @@ func Unstructured(objs *objects.Raw) status.MultiError {
 		objects.VisitAllRaw(validate.Name),
 		objects.VisitAllRaw(validate.Namespace),
 		objects.VisitAllRaw(validate.ManagementAnnotation),
+		objects.VisitAllRaw(validate.Handoff(objs.ReconcilerName)),
+		objects.VisitAllRaw(validate.ConflictPolicy),
+		objects.VisitAllRaw(validate.IgnoreFields),
 		objects.VisitAllRaw(validate.IllegalCRD),
 		objects.VisitAllRaw(validate.CRDName),
 		objects.VisitAllRaw(validate.RootSync),
 		objects.VisitAllRaw(validate.RepoSync),
 		objects.VisitAllRaw(validate.SelfReconcile(objs.ReconcilerName)),
 		validate.DisallowedFields,
+		validate.Schemas,
 		validate.RemovedCRDs,
 		validate.ClusterSelectorsForUnstructured,
+		validate.RepoSyncPolicy,
 	}
 	for _, validator := range validators {
 		errs = status.Append(errs, validator(objs))
@@ -114,11 +124,13 @@ func Unstructured(objs *objects.Raw) status.MultiError {
 		return errs
 	}
 
-	// First we annotate all objects with their declared fields. It is crucial
-	// that we do this step before any other hydration so that we capture the
-	// object exactly as it is declared in Git. Then we perform cluster selection
-	// so that we can filter out irrelevant objects before trying to modify them.
+	// First we remove the ignored fields and annotate all objects with their
+	// declared fields. It is crucial that we do this step before any other
+	// hydration so that we capture the object exactly as it is declared in Git.
+	// Then we perform cluster selection so that we can filter out irrelevant
+	// objects before trying to modify them.
 	hydrators := []objects.RawVisitor{
+		hydrate.IgnoreFields,
 		hydrate.DeclaredFields,
 		hydrate.DeclaredVersion,
 		hydrate.ClusterSelectors,
@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConflictPolicy checks that the conflict-policy annotation of the given
+// object, if any, is a valid conflict policy.
+func ConflictPolicy(obj ast.FileObject) status.Error {
+	value, found := obj.GetAnnotations()[metadata.ConflictPolicyAnnotationKey]
+	if !found {
+		return nil
+	}
+	switch configsync.ConflictPolicy(value) {
+	case configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect, configsync.ConflictPolicyFail:
+		return nil
+	default:
+		return IllegalConflictPolicyError(obj, value)
+	}
+}
+
+// IllegalConflictPolicyErrorCode is the error code for
+// IllegalConflictPolicyError.
+const IllegalConflictPolicyErrorCode = "1073"
+
+var illegalConflictPolicyErrorBuilder = status.NewErrorBuilder(IllegalConflictPolicyErrorCode)
+
+// IllegalConflictPolicyError reports that the conflict-policy annotation of an
+// object is invalid.
+func IllegalConflictPolicyError(resource client.Object, value string) status.Error {
+	return illegalConflictPolicyErrorBuilder.
+		Sprintf("Config has invalid conflict policy annotation %s=%s. The value must be one of %q, %q, or %q.",
+			metadata.ConflictPolicyAnnotationKey, value,
+			configsync.ConflictPolicyForce, configsync.ConflictPolicyRespect, configsync.ConflictPolicyFail).
+		BuildWithResources(resource)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestConflictPolicy(t *testing.T) {
+	testCases := []struct {
+		name string
+		obj  ast.FileObject
+		want status.Error
+	}{
+		{
+			name: "no conflict policy annotation",
+			obj:  fake.Deployment("namespaces/bookstore"),
+		},
+		{
+			name: "force",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.ConflictPolicyAnnotationKey, "force")),
+		},
+		{
+			name: "respect",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.ConflictPolicyAnnotationKey, "respect")),
+		},
+		{
+			name: "fail",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.ConflictPolicyAnnotationKey, "fail")),
+		},
+		{
+			name: "invalid value",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.ConflictPolicyAnnotationKey, "Force")),
+			want: fake.Error(IllegalConflictPolicyErrorCode),
+		},
+		{
+			name: "empty value",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.ConflictPolicyAnnotationKey, "")),
+			want: fake.Error(IllegalConflictPolicyErrorCode),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ConflictPolicy(tc.obj)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got ConflictPolicy() error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
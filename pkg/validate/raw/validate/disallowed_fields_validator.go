@@ -26,6 +26,9 @@ import (
 func DisallowedFields(objs *objects.Raw) status.MultiError {
 	var errs status.MultiError
 	for _, obj := range objs.Objects {
+		if objs.IsValidated(obj) {
+			continue
+		}
 		if len(obj.GetOwnerReferences()) > 0 {
 			errs = status.Append(errs, syntax.IllegalFieldsInConfigError(obj, id.OwnerReference))
 		}
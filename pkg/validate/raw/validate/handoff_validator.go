@@ -0,0 +1,69 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/differ"
+	"kpt.dev/configsync/pkg/validate/objects"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Handoff checks that the handoff annotation of the given object names the
+// manager of another RootSync, or of a RepoSync in the namespace of the object.
+func Handoff(reconcilerName string) objects.ObjectVisitor {
+	return func(obj ast.FileObject) status.Error {
+		target, found := obj.GetAnnotations()[metadata.ResourceHandoffKey]
+		if !found {
+			return nil
+		}
+		if differ.ManagementDisabled(obj) {
+			return IllegalHandoffError(obj, target, "management must not be disabled on an object which is handed off")
+		}
+		scope, name := declared.ManagerScopeAndName(target)
+		if scope == "" || declared.ValidateScope(string(scope)) != nil || len(validation.IsDNS1123Subdomain(name)) > 0 {
+			return IllegalHandoffError(obj, target, "the value must be the manager of a RootSync or RepoSync, in the format of the configsync.gke.io/manager annotation")
+		}
+		targetReconciler := core.RootReconcilerName(name)
+		if scope != declared.RootReconciler {
+			if string(scope) != obj.GetNamespace() {
+				return IllegalHandoffError(obj, target, "a RepoSync may only manage objects in its namespace")
+			}
+			targetReconciler = core.NsReconcilerName(string(scope), name)
+		}
+		if targetReconciler == reconcilerName {
+			return IllegalHandoffError(obj, target, "the object must be handed off to another RootSync or RepoSync")
+		}
+		return nil
+	}
+}
+
+// IllegalHandoffErrorCode is the error code for IllegalHandoffError.
+const IllegalHandoffErrorCode = "1072"
+
+var illegalHandoffErrorBuilder = status.NewErrorBuilder(IllegalHandoffErrorCode)
+
+// IllegalHandoffError reports that the handoff annotation of an object is
+// invalid.
+func IllegalHandoffError(resource client.Object, value, reason string) status.Error {
+	return illegalHandoffErrorBuilder.
+		Sprintf("Config has invalid handoff annotation %s=%s: %s.", metadata.ResourceHandoffKey, value, reason).
+		BuildWithResources(resource)
+}
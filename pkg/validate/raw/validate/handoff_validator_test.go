@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/syncertest"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestHandoff(t *testing.T) {
+	rootReconciler := core.RootReconcilerName(configsync.RootSyncName)
+	bookstoreReconciler := core.NsReconcilerName("bookstore", configsync.RepoSyncName)
+
+	testCases := []struct {
+		name       string
+		reconciler string
+		obj        ast.FileObject
+		want       status.Error
+	}{
+		{
+			name:       "no handoff annotation",
+			reconciler: rootReconciler,
+			obj:        fake.Role(core.Namespace("bookstore")),
+		},
+		{
+			name:       "root repo hands off to RepoSync in the namespace",
+			reconciler: rootReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), core.Annotation(metadata.ResourceHandoffKey, "bookstore")),
+		},
+		{
+			name:       "root repo hands off to another RootSync",
+			reconciler: rootReconciler,
+			obj:        fake.ClusterRole(core.Annotation(metadata.ResourceHandoffKey, ":root_other-sync")),
+		},
+		{
+			name:       "namespace repo hands off to RootSync",
+			reconciler: bookstoreReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), core.Annotation(metadata.ResourceHandoffKey, ":root")),
+		},
+		{
+			name:       "namespace repo hands off to another RepoSync in the namespace",
+			reconciler: bookstoreReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), core.Annotation(metadata.ResourceHandoffKey, "bookstore_other-sync")),
+		},
+		{
+			name:       "hands off to RepoSync in another namespace",
+			reconciler: rootReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), core.Annotation(metadata.ResourceHandoffKey, "videostore")),
+			want:       fake.Error(IllegalHandoffErrorCode),
+		},
+		{
+			name:       "hands off to itself",
+			reconciler: bookstoreReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), core.Annotation(metadata.ResourceHandoffKey, "bookstore")),
+			want:       fake.Error(IllegalHandoffErrorCode),
+		},
+		{
+			name:       "invalid manager",
+			reconciler: rootReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), core.Annotation(metadata.ResourceHandoffKey, "Bookstore!")),
+			want:       fake.Error(IllegalHandoffErrorCode),
+		},
+		{
+			name:       "hands off an object with management disabled",
+			reconciler: rootReconciler,
+			obj:        fake.Role(core.Namespace("bookstore"), syncertest.ManagementDisabled, core.Annotation(metadata.ResourceHandoffKey, "bookstore")),
+			want:       fake.Error(IllegalHandoffErrorCode),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Handoff(tc.reconciler)(tc.obj)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got Handoff() error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/ignorefields"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IgnoreFields checks that the ignore-fields annotation of the given object, if
+// any, lists valid paths of fields which can be ignored.
+func IgnoreFields(obj ast.FileObject) status.Error {
+	if _, err := ignorefields.ParseAnnotation(obj.Unstructured); err != nil {
+		return IllegalIgnoreFieldsError(obj, err)
+	}
+	return nil
+}
+
+// IgnoreDifferences checks that the ignoreDifferences override of a
+// RootSync/RepoSync lists valid paths of fields which can be ignored.
+func IgnoreDifferences(diffs []v1beta1.IgnoreDifference, rs client.Object) status.Error {
+	if _, err := ignorefields.NewRules(diffs); err != nil {
+		kind := rs.GetObjectKind().GroupVersionKind().Kind
+		return invalidSyncBuilder.
+			Sprintf("%ss must specify valid paths in spec.override.ignoreDifferences: %v", kind, err).
+			BuildWithResources(rs)
+	}
+	return nil
+}
+
+// IllegalIgnoreFieldsErrorCode is the error code for IllegalIgnoreFieldsError.
+const IllegalIgnoreFieldsErrorCode = "1074"
+
+var illegalIgnoreFieldsErrorBuilder = status.NewErrorBuilder(IllegalIgnoreFieldsErrorCode)
+
+// IllegalIgnoreFieldsError reports that the ignore-fields annotation of an
+// object is invalid.
+func IllegalIgnoreFieldsError(resource client.Object, err error) status.Error {
+	return illegalIgnoreFieldsErrorBuilder.
+		Sprintf("Config has invalid %s annotation: %v. The value must be a comma-separated list of field paths, like %q.",
+			metadata.IgnoreFieldsAnnotationKey, err, ".spec.replicas").
+		BuildWithResources(resource)
+}
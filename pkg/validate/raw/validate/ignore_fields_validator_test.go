@@ -0,0 +1,104 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestIgnoreFields(t *testing.T) {
+	testCases := []struct {
+		name string
+		obj  ast.FileObject
+		want status.Error
+	}{
+		{
+			name: "no ignore-fields annotation",
+			obj:  fake.Deployment("namespaces/bookstore"),
+		},
+		{
+			name: "single path",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.IgnoreFieldsAnnotationKey, ".spec.replicas")),
+		},
+		{
+			name: "multiple paths",
+			obj: fake.Deployment("namespaces/bookstore", core.Annotation(metadata.IgnoreFieldsAnnotationKey,
+				`spec.replicas, .spec.template.spec.containers[name="web"].image, .metadata.labels["app.kubernetes.io/version"]`)),
+		},
+		{
+			name: "invalid path",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.IgnoreFieldsAnnotationKey, ".spec..replicas")),
+			want: fake.Error(IllegalIgnoreFieldsErrorCode),
+		},
+		{
+			name: "identifying field",
+			obj:  fake.Deployment("namespaces/bookstore", core.Annotation(metadata.IgnoreFieldsAnnotationKey, ".metadata.name")),
+			want: fake.Error(IllegalIgnoreFieldsErrorCode),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := IgnoreFields(tc.obj)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got IgnoreFields() error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreDifferences(t *testing.T) {
+	testCases := []struct {
+		name  string
+		diffs []v1beta1.IgnoreDifference
+		want  status.Error
+	}{
+		{
+			name: "no ignored fields",
+		},
+		{
+			name:  "valid paths",
+			diffs: []v1beta1.IgnoreDifference{{Group: "apps", Kind: "Deployment", JSONPaths: []string{".spec.replicas"}}},
+		},
+		{
+			name:  "invalid path",
+			diffs: []v1beta1.IgnoreDifference{{Group: "apps", Kind: "Deployment", JSONPaths: []string{".spec[0]"}}},
+			want:  fake.Error(InvalidSyncCode),
+		},
+		{
+			name:  "missing kind",
+			diffs: []v1beta1.IgnoreDifference{{JSONPaths: []string{".spec.replicas"}}},
+			want:  fake.Error(InvalidSyncCode),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := IgnoreDifferences(tc.diffs, fake.RootSyncObjectV1Beta1(configsync.RootSyncName))
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got IgnoreDifferences() error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
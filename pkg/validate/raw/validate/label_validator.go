@@ -23,7 +23,7 @@ import (
 
 // IsInvalidLabel returns true if the label cannot be declared by users.
 func IsInvalidLabel(k string) bool {
-	return csmetadata.HasConfigSyncPrefix(k)
+	return csmetadata.HasConfigSyncPrefix(k) && k != csmetadata.RepoSyncPolicyLabel
 }
 
 // Labels verifies that the given object does not have any invalid labels.
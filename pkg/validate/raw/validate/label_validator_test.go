@@ -47,6 +47,10 @@ func TestLabels(t *testing.T) {
 			name: "legal label",
 			obj:  fake.Role(core.Label(legalLabel, "a")),
 		},
+		{
+			name: "RepoSyncPolicy label",
+			obj:  fake.Namespace("namespaces/foo", core.Label(csmetadata.RepoSyncPolicyLabel, "tenants")),
+		},
 		{
 			name:    "illegal ConfigManagement label",
 			obj:     fake.Role(core.Label(cmLabel, "a")),
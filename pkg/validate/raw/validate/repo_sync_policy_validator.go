@@ -0,0 +1,222 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/validate/objects"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RepoSyncPolicy verifies that the objects declared in a namespace repo stay
+// within the limits of the RepoSyncPolicy referenced by the Namespace.
+func RepoSyncPolicy(objs *objects.Raw) status.MultiError {
+	policy := objs.RepoSyncPolicy
+	if policy == nil {
+		return nil
+	}
+	var errs status.MultiError
+	var totalBytes int64
+	for _, obj := range objs.Objects {
+		gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+		if !kindAllowed(policy.Spec, gk) {
+			errs = status.Append(errs, RepoSyncPolicyKindError(policy.Name, obj))
+		}
+		for _, field := range policy.Spec.ForbiddenFields {
+			for _, path := range setFields(obj.Object, strings.Split(field, "."), "") {
+				errs = status.Append(errs, RepoSyncPolicyFieldError(policy.Name, obj, path))
+			}
+		}
+		data, err := json.Marshal(obj.Object)
+		if err != nil {
+			errs = status.Append(errs, status.ResourceWrap(err, "failed to encode the object", obj))
+			continue
+		}
+		totalBytes += int64(len(data))
+	}
+	if limit := policy.Spec.MaxObjects; limit != nil && int64(len(objs.Objects)) > *limit {
+		errs = status.Append(errs, RepoSyncPolicyObjectCountError(policy.Name, int64(len(objs.Objects)), *limit))
+	}
+	if limit := policy.Spec.MaxTotalBytes; limit != nil && totalBytes > *limit {
+		errs = status.Append(errs, RepoSyncPolicyObjectBytesError(policy.Name, totalBytes, *limit))
+	}
+	return errs
+}
+
+// kindAllowed returns true if the policy allows declaring objects of the kind.
+func kindAllowed(spec v1beta1.RepoSyncPolicySpec, gk schema.GroupKind) bool {
+	for _, denied := range spec.DeniedKinds {
+		if kindMatches(denied, gk) {
+			return false
+		}
+	}
+	if len(spec.AllowedKinds) == 0 {
+		return true
+	}
+	for _, allowed := range spec.AllowedKinds {
+		if kindMatches(allowed, gk) {
+			return true
+		}
+	}
+	return false
+}
+
+func kindMatches(pattern metav1.GroupKind, gk schema.GroupKind) bool {
+	return pattern.Group == gk.Group && (pattern.Kind == "*" || pattern.Kind == gk.Kind)
+}
+
+// setFields returns the paths of the fields matching the path elements which
+// are set to a value other than null, false, 0 or empty. Lists are traversed
+// implicitly, so the elements apply to each of their items.
+func setFields(value interface{}, elements []string, prefix string) []string {
+	if list, ok := value.([]interface{}); ok {
+		var paths []string
+		for i, item := range list {
+			paths = append(paths, setFields(item, elements, fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+		return paths
+	}
+	if len(elements) == 0 {
+		if isZero(value) {
+			return nil
+		}
+		return []string{prefix}
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	if elements[0] != "*" {
+		child, found := m[elements[0]]
+		if !found {
+			return nil
+		}
+		return setFields(child, elements[1:], join(elements[0]))
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var paths []string
+	for _, key := range keys {
+		paths = append(paths, setFields(m[key], elements[1:], join(key))...)
+	}
+	return paths
+}
+
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// RepoSyncPolicyErrorCode is the error code for objects in a namespace repo
+// which exceed the limits of the RepoSyncPolicy of the Namespace.
+const RepoSyncPolicyErrorCode = "1071"
+
+var repoSyncPolicyErrorBuilder = status.NewErrorBuilder(RepoSyncPolicyErrorCode)
+
+// RepoSyncPolicyKindError reports that the RepoSyncPolicy does not allow
+// declaring objects of the kind of the resource.
+func RepoSyncPolicyKindError(policy string, resource client.Object) status.Error {
+	gk := resource.GetObjectKind().GroupVersionKind().GroupKind()
+	return repoSyncPolicyErrorBuilder.
+		Sprintf("the RepoSyncPolicy %q does not allow declaring %s objects", policy, gk).
+		BuildWithResources(resource)
+}
+
+// RepoSyncPolicyFieldError reports that the resource sets a field forbidden by
+// the RepoSyncPolicy.
+func RepoSyncPolicyFieldError(policy string, resource client.Object, field string) status.Error {
+	return repoSyncPolicyErrorBuilder.
+		Sprintf("the RepoSyncPolicy %q forbids setting the field %s", policy, field).
+		BuildWithResources(resource)
+}
+
+// RepoSyncPolicyObjectCountError reports that the repo declares more objects
+// than the RepoSyncPolicy allows.
+func RepoSyncPolicyObjectCountError(policy string, count, limit int64) status.Error {
+	return repoSyncPolicyErrorBuilder.
+		Sprintf("the repo declares %d objects, more than the %d objects allowed by the RepoSyncPolicy %q", count, limit, policy).
+		Build()
+}
+
+// RepoSyncPolicyObjectBytesError reports that the objects declared in the repo
+// are larger than the RepoSyncPolicy allows.
+func RepoSyncPolicyObjectBytesError(policy string, size, limit int64) status.Error {
+	return repoSyncPolicyErrorBuilder.
+		Sprintf("the objects declared in the repo total %d bytes, more than the %d bytes allowed by the RepoSyncPolicy %q", size, limit, policy).
+		Build()
+}
+
+// RepoSyncPolicyNamespaceError reports that a Namespace references a
+// RepoSyncPolicy which does not allow the Namespace.
+func RepoSyncPolicyNamespaceError(policy, namespace string) status.Error {
+	return repoSyncPolicyErrorBuilder.
+		Sprintf("the Namespace %q references the RepoSyncPolicy %q, which does not list it in spec.namespaces", namespace, policy).
+		Build()
+}
+
+// RepoSyncPolicyNotFoundError reports that a Namespace references a
+// RepoSyncPolicy which does not exist.
+func RepoSyncPolicyNotFoundError(policy, namespace string) status.Error {
+	return repoSyncPolicyErrorBuilder.
+		Sprintf("the Namespace %q references the RepoSyncPolicy %q, which does not exist", namespace, policy).
+		Build()
+}
+
+// IsRepoSyncPolicyAllowed returns true if the RepoSyncPolicy may be referenced
+// by the Namespace.
+func IsRepoSyncPolicyAllowed(policy *v1beta1.RepoSyncPolicy, namespace string) bool {
+	if len(policy.Spec.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range policy.Spec.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
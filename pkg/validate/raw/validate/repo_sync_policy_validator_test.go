@@ -0,0 +1,187 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+func policy(spec v1beta1.RepoSyncPolicySpec) *v1beta1.RepoSyncPolicy {
+	return &v1beta1.RepoSyncPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+		Spec:       spec,
+	}
+}
+
+// podWithContainers returns a Pod with a container for each of the values of
+// securityContext.privileged.
+func podWithContainers(t *testing.T, privileged ...bool) ast.FileObject {
+	pod := fake.Unstructured(kinds.Pod(), core.Name("pod"), core.Namespace("foo"))
+	var containers []interface{}
+	for _, p := range privileged {
+		containers = append(containers, map[string]interface{}{
+			"name":            "main",
+			"securityContext": map[string]interface{}{"privileged": p},
+		})
+	}
+	if err := unstructured.SetNestedSlice(pod.Object, containers, "spec", "containers"); err != nil {
+		t.Fatal(err)
+	}
+	return pod
+}
+
+func TestRepoSyncPolicy(t *testing.T) {
+	rbacRoles := metav1.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "Role"}
+	rbacAll := metav1.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "*"}
+	privilegedField := "spec.containers.securityContext.privileged"
+
+	testCases := []struct {
+		name     string
+		policy   *v1beta1.RepoSyncPolicy
+		objs     func(t *testing.T) []ast.FileObject
+		wantErrs status.MultiError
+	}{
+		{
+			name: "no policy",
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), podWithContainers(t, true)}
+			},
+		},
+		{
+			name:   "empty policy allows everything",
+			policy: policy(v1beta1.RepoSyncPolicySpec{}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), podWithContainers(t, true)}
+			},
+		},
+		{
+			name:   "allowed kind",
+			policy: policy(v1beta1.RepoSyncPolicySpec{AllowedKinds: []metav1.GroupKind{rbacRoles}}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role()}
+			},
+		},
+		{
+			name:   "kind not allowed",
+			policy: policy(v1beta1.RepoSyncPolicySpec{AllowedKinds: []metav1.GroupKind{rbacRoles}}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), fake.RoleBinding()}
+			},
+			wantErrs: RepoSyncPolicyKindError("tenants", fake.RoleBinding()),
+		},
+		{
+			name:   "kind wildcard allows the group",
+			policy: policy(v1beta1.RepoSyncPolicySpec{AllowedKinds: []metav1.GroupKind{rbacAll}}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), fake.RoleBinding()}
+			},
+		},
+		{
+			name: "denied kind takes precedence",
+			policy: policy(v1beta1.RepoSyncPolicySpec{
+				AllowedKinds: []metav1.GroupKind{rbacAll},
+				DeniedKinds:  []metav1.GroupKind{{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}},
+			}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), fake.RoleBinding()}
+			},
+			wantErrs: RepoSyncPolicyKindError("tenants", fake.RoleBinding()),
+		},
+		{
+			name:   "forbidden field unset or false",
+			policy: policy(v1beta1.RepoSyncPolicySpec{ForbiddenFields: []string{privilegedField}}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), podWithContainers(t, false)}
+			},
+		},
+		{
+			name:   "forbidden field set in a list item",
+			policy: policy(v1beta1.RepoSyncPolicySpec{ForbiddenFields: []string{privilegedField}}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{podWithContainers(t, false, true)}
+			},
+			wantErrs: RepoSyncPolicyFieldError("tenants", podWithContainers(t, false, true), "spec.containers[1].securityContext.privileged"),
+		},
+		{
+			name:   "forbidden field with wildcard",
+			policy: policy(v1beta1.RepoSyncPolicySpec{ForbiddenFields: []string{"spec.*.securityContext.privileged"}}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{podWithContainers(t, true)}
+			},
+			wantErrs: RepoSyncPolicyFieldError("tenants", podWithContainers(t, true), "spec.containers[0].securityContext.privileged"),
+		},
+		{
+			name:   "too many objects",
+			policy: policy(v1beta1.RepoSyncPolicySpec{MaxObjects: pointer.Int64(1)}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), fake.RoleBinding()}
+			},
+			wantErrs: RepoSyncPolicyObjectCountError("tenants", 2, 1),
+		},
+		{
+			name:   "objects within the size limit",
+			policy: policy(v1beta1.RepoSyncPolicySpec{MaxObjects: pointer.Int64(2), MaxTotalBytes: pointer.Int64(1 << 20)}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role(), fake.RoleBinding()}
+			},
+		},
+		{
+			name:   "objects too large",
+			policy: policy(v1beta1.RepoSyncPolicySpec{MaxTotalBytes: pointer.Int64(10)}),
+			objs: func(t *testing.T) []ast.FileObject {
+				return []ast.FileObject{fake.Role()}
+			},
+			wantErrs: RepoSyncPolicyObjectBytesError("tenants", 0, 10),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := &objects.Raw{
+				Objects:        tc.objs(t),
+				RepoSyncPolicy: tc.policy,
+			}
+			errs := RepoSyncPolicy(objs)
+			if !errors.Is(errs, tc.wantErrs) {
+				t.Errorf("got RepoSyncPolicy() error %v, want %v", errs, tc.wantErrs)
+			}
+		})
+	}
+}
+
+func TestIsRepoSyncPolicyAllowed(t *testing.T) {
+	if !IsRepoSyncPolicyAllowed(policy(v1beta1.RepoSyncPolicySpec{}), "foo") {
+		t.Error("got a policy without namespaces not allowed, want allowed")
+	}
+	p := policy(v1beta1.RepoSyncPolicySpec{Namespaces: []string{"foo", "bar"}})
+	if !IsRepoSyncPolicyAllowed(p, "bar") {
+		t.Error("got a listed namespace not allowed, want allowed")
+	}
+	if IsRepoSyncPolicyAllowed(p, "baz") {
+		t.Error("got an unlisted namespace allowed, want not allowed")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/customresources"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+// Schemas verifies that objects match the schema of their kind, so that
+// misspelled or mistyped fields are reported before anything is applied.
+//
+// Objects of kinds declared by a CRD in the repo are validated against the
+// openAPIV3Schema of that CRD, since the cluster may not have the CRD yet or
+// may have an older version of it. Other objects are validated against the
+// schemas from the API server while hydrating their declared fields, or
+// against the types bundled into the binary if there is no API server to
+// talk to (eg nomos vet --no-api-server-check).
+func Schemas(objs *objects.Raw) status.MultiError {
+	// Malformed CRDs are reported when the objects are scoped.
+	crds, _ := customresources.GetCRDs(objs.Objects)
+	crdSchemas := make(map[schema.GroupKind]*v1beta1.CustomResourceDefinition, len(crds))
+	for _, crd := range crds {
+		crdSchemas[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = crd
+	}
+
+	// The objects which passed validation before are validated again only if
+	// the CRD of their kind changed.
+	var changedCRDObjs []ast.FileObject
+	for _, obj := range objs.Objects {
+		if obj.GetObjectKind().GroupVersionKind().GroupKind() == kinds.CustomResourceDefinition() && !objs.IsValidated(obj) {
+			changedCRDObjs = append(changedCRDObjs, obj)
+		}
+	}
+	changedCRDs, _ := customresources.GetCRDs(changedCRDObjs)
+	changedSchemas := make(map[schema.GroupKind]bool, len(changedCRDs))
+	for _, crd := range changedCRDs {
+		changedSchemas[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = true
+	}
+
+	var errs status.MultiError
+	for _, obj := range objs.Objects {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if objs.IsValidated(obj) && !changedSchemas[gvk.GroupKind()] {
+			continue
+		}
+		var err error
+		if crd, found := crdSchemas[gvk.GroupKind()]; found {
+			err = validateCRDSchema(obj, crd)
+		} else if objs.Converter == nil {
+			err = validateBundledSchema(obj)
+		}
+		if err != nil {
+			errs = status.Append(errs, status.SchemaValidationError(obj, err))
+		}
+	}
+	return errs
+}
+
+// validateBundledSchema validates the object against its Go type in the
+// scheme. Objects of kinds unknown to the scheme are not validated.
+func validateBundledSchema(obj ast.FileObject) error {
+	typed, err := core.Scheme.New(obj.GetObjectKind().GroupVersionKind())
+	if err != nil {
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(obj.Unstructured.Object, typed, true)
+}
+
+// validateCRDSchema validates the object against the schema of its version in
+// the CRD. Objects of versions without a schema are not validated.
+func validateCRDSchema(obj ast.FileObject, crd *v1beta1.CustomResourceDefinition) error {
+	var props *v1beta1.JSONSchemaProps
+	if crd.Spec.Validation != nil {
+		props = crd.Spec.Validation.OpenAPIV3Schema
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Name == obj.GetObjectKind().GroupVersionKind().Version && v.Schema != nil {
+			props = v.Schema.OpenAPIV3Schema
+		}
+	}
+	if props == nil {
+		return nil
+	}
+	// CRDs must opt out of preserving unknown fields in v1beta1.
+	v := schemaValidator{
+		preserveUnknownFields: crd.Spec.PreserveUnknownFields == nil || *crd.Spec.PreserveUnknownFields,
+	}
+	content := obj.Unstructured.UnstructuredContent()
+	for _, field := range sortedKeys(content) {
+		switch field {
+		case "apiVersion", "kind", "metadata":
+			// Validated by other validators and by the API server.
+			continue
+		}
+		v.validateField("."+field, field, content[field], props)
+	}
+	return utilerrors.NewAggregate(v.errs)
+}
+
+// schemaValidator collects the differences between a value and its
+// structural schema.
+type schemaValidator struct {
+	// preserveUnknownFields is true if unknown fields are preserved in the
+	// whole object, rather than only where the schema allows them.
+	preserveUnknownFields bool
+	errs                  []error
+}
+
+func (v *schemaValidator) errorf(path, format string, a ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, a...)))
+}
+
+// validateField validates the value of the named field of an object with the
+// given schema.
+func (v *schemaValidator) validateField(path, name string, value interface{}, parent *v1beta1.JSONSchemaProps) {
+	if prop, found := parent.Properties[name]; found {
+		v.validate(path, value, &prop)
+		return
+	}
+	if parent.AdditionalProperties != nil && parent.AdditionalProperties.Allows {
+		v.validate(path, value, parent.AdditionalProperties.Schema)
+		return
+	}
+	preserved := v.preserveUnknownFields ||
+		(parent.XPreserveUnknownFields != nil && *parent.XPreserveUnknownFields)
+	if !preserved {
+		v.errorf(path, "field not declared in schema")
+	}
+}
+
+func (v *schemaValidator) validate(path string, value interface{}, props *v1beta1.JSONSchemaProps) {
+	if props == nil || value == nil {
+		return
+	}
+	if props.XIntOrString {
+		switch value.(type) {
+		case string, int64, int, float64:
+		default:
+			v.errorf(path, "expected integer or string, got %T", value)
+		}
+		return
+	}
+
+	switch props.Type {
+	case "object":
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "expected object, got %T", value)
+			return
+		}
+		for _, field := range sortedKeys(m) {
+			if props.XEmbeddedResource && (field == "apiVersion" || field == "kind" || field == "metadata") {
+				continue
+			}
+			v.validateField(path+"."+field, field, m[field], props)
+		}
+	case "array":
+		s, ok := value.([]interface{})
+		if !ok {
+			v.errorf(path, "expected array, got %T", value)
+			return
+		}
+		if props.Items == nil {
+			return
+		}
+		for i, item := range s {
+			itemProps := props.Items.Schema
+			if itemProps == nil && i < len(props.Items.JSONSchemas) {
+				itemProps = &props.Items.JSONSchemas[i]
+			}
+			v.validate(fmt.Sprintf("%s[%d]", path, i), item, itemProps)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			v.errorf(path, "expected string, got %T", value)
+		}
+	case "integer":
+		switch n := value.(type) {
+		case int64, int:
+		case float64:
+			if n != math.Trunc(n) {
+				v.errorf(path, "expected integer, got %v", n)
+			}
+		default:
+			v.errorf(path, "expected integer, got %T", value)
+		}
+	case "number":
+		switch value.(type) {
+		case int64, int, float64:
+		default:
+			v.errorf(path, "expected number, got %T", value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.errorf(path, "expected boolean, got %T", value)
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
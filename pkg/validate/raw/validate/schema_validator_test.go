@@ -0,0 +1,183 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"kpt.dev/configsync/pkg/testing/openapitest"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+var anvilGVK = schema.GroupVersionKind{Group: "acme.com", Version: "v1", Kind: "Anvil"}
+
+func anvilCRD(preserveUnknownFields bool) ast.FileObject {
+	crd := fake.CustomResourceDefinitionV1Object(core.Name("anvils.acme.com"))
+	crd.Spec.Group = anvilGVK.Group
+	crd.Spec.Names = apiextensionsv1.CustomResourceDefinitionNames{Plural: "anvils", Kind: anvilGVK.Kind}
+	crd.Spec.Scope = apiextensionsv1.NamespaceScoped
+	crd.Spec.Versions = []apiextensionsv1.CustomResourceDefinitionVersion{{
+		Name:    anvilGVK.Version,
+		Served:  true,
+		Storage: true,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"spec": {
+						Type:                   "object",
+						XPreserveUnknownFields: pointer.Bool(preserveUnknownFields),
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"lbs": {Type: "integer"},
+							"tags": {
+								Type:  "array",
+								Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+							},
+							"labels": {
+								Type:                 "object",
+								AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+	return fake.FileObject(crd, "cluster/crd.yaml")
+}
+
+func anvil(spec map[string]interface{}) ast.FileObject {
+	u := fake.UnstructuredObject(anvilGVK, core.Name("heavy"), core.Namespace("foo"))
+	u.Object["spec"] = spec
+	return fake.FileObject(u, "namespaces/foo/anvil.yaml")
+}
+
+func deploymentWithSpec(spec map[string]interface{}) ast.FileObject {
+	u := fake.UnstructuredObject(kinds.Deployment(), core.Name("hello"), core.Namespace("foo"))
+	u.Object["spec"] = spec
+	return fake.FileObject(u, "namespaces/foo/deployment.yaml")
+}
+
+func TestSchemas(t *testing.T) {
+	converter, err := openapitest.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	misspelledDeployment := deploymentWithSpec(map[string]interface{}{"replica": int64(3)})
+
+	testCases := []struct {
+		name     string
+		objs     *objects.Raw
+		wantErrs []string
+	}{
+		{
+			name: "valid Deployment passes",
+			objs: &objects.Raw{
+				Objects: []ast.FileObject{
+					deploymentWithSpec(map[string]interface{}{"replicas": int64(3)}),
+				},
+			},
+		},
+		{
+			name: "misspelled Deployment field fails with bundled schemas",
+			objs: &objects.Raw{
+				Objects: []ast.FileObject{misspelledDeployment},
+			},
+			wantErrs: []string{`unknown field "spec.replica"`},
+		},
+		{
+			name: "bundled schemas are not used with the API server",
+			objs: &objects.Raw{
+				Converter: converter,
+				Objects:   []ast.FileObject{misspelledDeployment},
+			},
+		},
+		{
+			name: "unknown kind without CRD passes",
+			objs: &objects.Raw{
+				Objects: []ast.FileObject{
+					anvil(map[string]interface{}{"weight": "heavy"}),
+				},
+			},
+		},
+		{
+			name: "valid custom resource passes",
+			objs: &objects.Raw{
+				Objects: []ast.FileObject{
+					anvilCRD(false),
+					anvil(map[string]interface{}{
+						"lbs":    int64(100),
+						"tags":   []interface{}{"steel"},
+						"labels": map[string]interface{}{"acme.com/color": "black"},
+					}),
+				},
+			},
+		},
+		{
+			name: "invalid custom resource fails",
+			objs: &objects.Raw{
+				Converter: converter,
+				Objects: []ast.FileObject{
+					anvilCRD(false),
+					anvil(map[string]interface{}{
+						"lb":     int64(100),
+						"tags":   []interface{}{int64(1)},
+						"labels": map[string]interface{}{"acme.com/color": true},
+					}),
+				},
+			},
+			wantErrs: []string{
+				".spec.labels.acme.com/color: expected string, got bool",
+				".spec.lb: field not declared in schema",
+				".spec.tags[0]: expected string, got int64",
+			},
+		},
+		{
+			name: "unknown fields pass if preserved",
+			objs: &objects.Raw{
+				Objects: []ast.FileObject{
+					anvilCRD(true),
+					anvil(map[string]interface{}{"lbs": int64(100), "color": "black"}),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := Schemas(tc.objs)
+			if len(tc.wantErrs) == 0 {
+				assert.Nil(t, errs)
+				return
+			}
+			if assert.NotNil(t, errs) {
+				assert.Len(t, errs.Errors(), 1)
+				assert.Equal(t, status.SchemaValidationErrorCode, errs.Errors()[0].Code())
+				for _, want := range tc.wantErrs {
+					assert.Contains(t, errs.Error(), want)
+				}
+			}
+		})
+	}
+}
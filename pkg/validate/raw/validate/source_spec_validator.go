@@ -123,6 +123,34 @@ func GitSpec(git *v1beta1.Git, rs client.Object) status.Error {
 		}
 	}
 
+	// Check the trusted keys are specified when the commit signatures are verified.
+	if git.Verification != nil {
+		if git.Verification.SecretRef == nil || git.Verification.SecretRef.Name == "" {
+			return MissingGitVerificationSecretRef(rs)
+		}
+		switch git.Verification.Policy {
+		case "", v1beta1.HeadSigned, v1beta1.AllCommitsSigned:
+		default:
+			return InvalidGitVerificationPolicy(rs)
+		}
+	}
+
+	// Check the commit status reports can be delivered.
+	if git.CommitStatus != nil {
+		switch git.CommitStatus.Provider {
+		case v1beta1.GitHubProvider, v1beta1.GitLabProvider:
+			if git.CommitStatus.SecretRef == nil || git.CommitStatus.SecretRef.Name == "" {
+				return MissingGitCommitStatusSecretRef(rs)
+			}
+		case v1beta1.WebhookProvider:
+			if git.CommitStatus.URL == "" {
+				return MissingGitCommitStatusURL(rs)
+			}
+		default:
+			return InvalidGitCommitStatusProvider(rs)
+		}
+	}
+
 	return nil
 }
 
@@ -292,6 +320,55 @@ func MissingSecretRef(o client.Object) status.Error {
 		BuildWithResources(o)
 }
 
+// MissingGitVerificationSecretRef reports that a RootSync/RepoSync declares
+// spec.git.verification without the secret of the trusted keys.
+func MissingGitVerificationSecretRef(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss which specify spec.git.verification must also specify spec.git.verification.secretRef", kind).
+		BuildWithResources(o)
+}
+
+// InvalidGitVerificationPolicy reports that a RootSync/RepoSync doesn't use
+// a valid spec.git.verification.policy.
+func InvalidGitVerificationPolicy(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.git.verification.policy as either %q or %q",
+			kind, v1beta1.HeadSigned, v1beta1.AllCommitsSigned).
+		BuildWithResources(o)
+}
+
+// InvalidGitCommitStatusProvider reports that a RootSync/RepoSync doesn't use
+// a valid spec.git.commitStatus.provider.
+func InvalidGitCommitStatusProvider(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.git.commitStatus.provider as one of %q, %q or %q",
+			kind, v1beta1.GitHubProvider, v1beta1.GitLabProvider, v1beta1.WebhookProvider).
+		BuildWithResources(o)
+}
+
+// MissingGitCommitStatusSecretRef reports that a RootSync/RepoSync reports
+// the commit statuses to GitHub or GitLab without the secret of the token.
+func MissingGitCommitStatusSecretRef(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss which specify spec.git.commitStatus.provider as %q or %q must also specify spec.git.commitStatus.secretRef",
+			kind, v1beta1.GitHubProvider, v1beta1.GitLabProvider).
+		BuildWithResources(o)
+}
+
+// MissingGitCommitStatusURL reports that a RootSync/RepoSync reports the
+// commit statuses to a webhook without its URL.
+func MissingGitCommitStatusURL(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss which specify spec.git.commitStatus.provider as %q must also specify spec.git.commitStatus.url",
+			kind, v1beta1.WebhookProvider).
+		BuildWithResources(o)
+}
+
 // InvalidGCPSAEmail reports that a RepoSync/RootSync Resource doesn't have the
 //
 //	correct gcp service account suffix.
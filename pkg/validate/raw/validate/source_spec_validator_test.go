@@ -68,6 +68,24 @@ func gcpSAEmail(email string) func(sync *v1beta1.RepoSync) {
 	}
 }
 
+func verification(secretName string, policy v1beta1.GitVerificationPolicy) func(sync *v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Verification = &v1beta1.GitVerification{Policy: policy}
+		if secretName != "" {
+			sync.Spec.Verification.SecretRef = &v1beta1.SecretReference{Name: secretName}
+		}
+	}
+}
+
+func commitStatus(provider v1beta1.GitProvider, url, secretName string) func(sync *v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.CommitStatus = &v1beta1.GitCommitStatus{Provider: provider, URL: url}
+		if secretName != "" {
+			sync.Spec.CommitStatus.SecretRef = &v1beta1.SecretReference{Name: secretName}
+		}
+	}
+}
+
 func missingRepo(rs *v1beta1.RepoSync) {
 	rs.Spec.Repo = ""
 }
@@ -211,6 +229,43 @@ func TestValidateRepoSyncSpec(t *testing.T) {
 			obj:     repoSyncWithGit(auth(configsync.AuthSSH)),
 			wantErr: fake.Error(InvalidSyncCode),
 		},
+		{
+			name: "valid git verification",
+			obj:  repoSyncWithGit(auth(configsync.AuthNone), verification("keys", v1beta1.AllCommitsSigned)),
+		},
+		{
+			name:    "missing git verification secret",
+			obj:     repoSyncWithGit(auth(configsync.AuthNone), verification("", v1beta1.HeadSigned)),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name:    "invalid git verification policy",
+			obj:     repoSyncWithGit(auth(configsync.AuthNone), verification("keys", "SomeSigned")),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name: "valid github commit status",
+			obj:  repoSyncWithGit(auth(configsync.AuthNone), commitStatus(v1beta1.GitHubProvider, "", "token")),
+		},
+		{
+			name: "valid webhook commit status without secret",
+			obj:  repoSyncWithGit(auth(configsync.AuthNone), commitStatus(v1beta1.WebhookProvider, "https://example.com/hook", "")),
+		},
+		{
+			name:    "missing gitlab commit status secret",
+			obj:     repoSyncWithGit(auth(configsync.AuthNone), commitStatus(v1beta1.GitLabProvider, "", "")),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name:    "missing webhook commit status url",
+			obj:     repoSyncWithGit(auth(configsync.AuthNone), commitStatus(v1beta1.WebhookProvider, "", "token")),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name:    "invalid commit status provider",
+			obj:     repoSyncWithGit(auth(configsync.AuthNone), commitStatus("bitbucket", "", "token")),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
 		{
 			name:    "invalid GCP serviceaccount email",
 			obj:     repoSyncWithGit(auth(configsync.AuthGCPServiceAccount), gcpSAEmail("invalid_gcp_sa@gserviceaccount.com")),
@@ -16,7 +16,11 @@ package validate
 
 import (
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/api/configsync"
+	configsyncv1beta1 "kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/ignorefields"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/status"
@@ -71,6 +75,27 @@ type Options struct {
 	// Visitors is a list of optional visitor functions which can be used to
 	// inject additional validation or hydration steps on the final objects.
 	Visitors []VisitorFunc
+	// RepoSyncPolicy is the RepoSyncPolicy referenced by the Namespace of a
+	// namespace reconciler. It limits what the repo may declare. It is nil if
+	// the Namespace does not reference a RepoSyncPolicy.
+	RepoSyncPolicy *configsyncv1beta1.RepoSyncPolicy
+	// IgnoreDifferences are the fields of each kind which the reconciler
+	// neither applies nor remediates.
+	IgnoreDifferences ignorefields.Rules
+	// Validated returns true if the object passed validation before and is
+	// unchanged since, so that the validation of a single object can be
+	// skipped. The validation which spans several objects always runs on all
+	// of them. All the objects are validated if it is nil.
+	Validated func(obj ast.FileObject) bool
+	// AdmissionPolicies are the ValidatingAdmissionPolicies, the
+	// ValidatingAdmissionPolicyBindings, the Gatekeeper ConstraintTemplates
+	// and constraints, and the Namespaces of the cluster. The policies, and
+	// the ones declared in the repo, are evaluated against the final objects
+	// so that the objects they deny are reported before apply.
+	AdmissionPolicies []*unstructured.Unstructured
+	// PolicyEnforcement specifies how the violations of the admission policies
+	// are reported. The violations block the sync if it is empty.
+	PolicyEnforcement configsync.PolicyEnforcement
 }
 
 // Hierarchical validates and hydrates the given FileObjects from a structured,
@@ -92,6 +117,9 @@ func Hierarchical(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 		BuildScoper:       opts.BuildScoper,
 		Converter:         opts.Converter,
 		AllowUnknownKinds: opts.AllowUnknownKinds,
+		RepoSyncPolicy:    opts.RepoSyncPolicy,
+		IgnoreDifferences: opts.IgnoreDifferences,
+		Validated:         opts.Validated,
 	}
 
 	// nonBlockingErrs tracks the errors which do not block the apply stage
@@ -150,6 +178,14 @@ func Hierarchical(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 		}
 	}
 
+	// Last we evaluate the admission policies against the objects as they are
+	// applied, so that the objects they deny do not fail the apply.
+	policyErrs := final.AdmissionPolicies(finalObjects, opts.AdmissionPolicies, opts.PolicyEnforcement)
+	if status.HasBlockingErrors(policyErrs) {
+		return nil, status.Append(nonBlockingErrs, policyErrs)
+	}
+	nonBlockingErrs = status.Append(nonBlockingErrs, policyErrs)
+
 	return finalObjects, nonBlockingErrs
 }
 
@@ -172,6 +208,9 @@ func Unstructured(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 		BuildScoper:       opts.BuildScoper,
 		Converter:         opts.Converter,
 		AllowUnknownKinds: opts.AllowUnknownKinds,
+		RepoSyncPolicy:    opts.RepoSyncPolicy,
+		IgnoreDifferences: opts.IgnoreDifferences,
+		Validated:         opts.Validated,
 	}
 
 	// nonBlockingErrs tracks the errors which do not block the apply stage
@@ -220,5 +259,13 @@ func Unstructured(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 		}
 	}
 
+	// Last we evaluate the admission policies against the objects as they are
+	// applied, so that the objects they deny do not fail the apply.
+	policyErrs := final.AdmissionPolicies(finalObjects, opts.AdmissionPolicies, opts.PolicyEnforcement)
+	if status.HasBlockingErrors(policyErrs) {
+		return nil, status.Append(nonBlockingErrs, policyErrs)
+	}
+	nonBlockingErrs = status.Append(nonBlockingErrs, policyErrs)
+
 	return finalObjects, nonBlockingErrs
 }
@@ -1219,6 +1219,53 @@ func TestUnstructured(t *testing.T) {
 			},
 			wantErrs: fake.Errors(nonhierarchical.NameCollisionErrorCode),
 		},
+		{
+			name: "validated duplicate objects fails",
+			options: Options{
+				Validated: func(ast.FileObject) bool { return true },
+			},
+			objs: []ast.FileObject{
+				fake.Role(
+					core.Name("alice"),
+					core.Namespace("shipping")),
+				fake.Role(
+					core.Name("alice"),
+					core.Namespace("shipping")),
+			},
+			wantErrs: fake.Errors(nonhierarchical.NameCollisionErrorCode),
+		},
+		{
+			name: "validated object skips the validation of a single object",
+			options: Options{
+				ReconcilerName: "root-reconciler",
+				Validated:      func(ast.FileObject) bool { return true },
+			},
+			objs: []ast.FileObject{
+				fake.RoleAtPath("role.yaml",
+					core.Namespace("foo"),
+					core.Annotation(csmetadata.ResourceManagementKey, "invalid")),
+			},
+			want: []ast.FileObject{
+				fake.RoleAtPath("role.yaml",
+					core.Namespace("foo"),
+					core.Annotation(csmetadata.ResourceManagementKey, "invalid"),
+					core.Label(csmetadata.DeclaredVersionLabel, "v1"),
+					core.Annotation(csmetadata.DeclaredFieldsKey, `{"f:metadata":{"f:annotations":{"f:configmanagement.gke.io/managed":{}},"f:labels":{}},"f:rules":{}}`),
+					core.Annotation(csmetadata.SourcePathAnnotationKey, dir+"/role.yaml")),
+			},
+		},
+		{
+			name: "changed object fails the validation of a single object",
+			options: Options{
+				Validated: func(ast.FileObject) bool { return false },
+			},
+			objs: []ast.FileObject{
+				fake.RoleAtPath("role.yaml",
+					core.Namespace("foo"),
+					core.Annotation(csmetadata.ResourceManagementKey, "invalid")),
+			},
+			wantErrs: fake.Errors(nonhierarchical.IllegalManagementAnnotationErrorCode),
+		},
 		{
 			name: "removing CRD while in-use fails",
 			options: Options{
@@ -29,6 +29,11 @@ const ShortName = "admission-webhook"
 // 2) The .name of every ValidatingWebhook in the ValidatingWebhookConfiguration.
 const Name = ShortName + "." + configsync.GroupName
 
+// PolicyName is the name of the optional ConfigMap, in the
+// config-management-system namespace, which configures the audit mode and the
+// break-glass users and groups of the webhook.
+const PolicyName = ShortName + "-policy"
+
 // ServingPath is the path the webhook is served.
 const ServingPath = "/" + ShortName
 
@@ -0,0 +1,148 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/util"
+	"kpt.dev/configsync/pkg/webhook/configuration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// policyModeKey is the key of the admission Mode in the policy ConfigMap.
+	policyModeKey = "mode"
+	// breakGlassUsersKey is the key of the comma-separated list of users
+	// allowed to modify managed objects in the policy ConfigMap.
+	breakGlassUsersKey = "breakGlassUsers"
+	// breakGlassGroupsKey is the key of the comma-separated list of groups
+	// allowed to modify managed objects in the policy ConfigMap.
+	breakGlassGroupsKey = "breakGlassGroups"
+	// breakGlassExpirationKey is the key of the RFC 3339 time at which the
+	// break-glass window ends in the policy ConfigMap.
+	breakGlassExpirationKey = "breakGlassExpiration"
+)
+
+// Mode is how the webhook handles the requests it would deny.
+type Mode string
+
+const (
+	// EnforceMode denies the requests which modify managed objects. This is the
+	// default.
+	EnforceMode = Mode("enforce")
+	// AuditMode allows the requests which modify managed objects, and returns
+	// the reason they would have been denied as an admission warning.
+	AuditMode = Mode("audit")
+)
+
+// Policy configures how the webhook handles the requests it would deny. It is
+// read from the configuration.PolicyName ConfigMap, if it exists.
+type Policy struct {
+	// Mode is how the webhook handles the requests it would deny.
+	Mode Mode
+	// BreakGlassUsers are the users allowed to modify managed objects until
+	// BreakGlassExpiration.
+	BreakGlassUsers []string
+	// BreakGlassGroups are the groups whose members are allowed to modify
+	// managed objects until BreakGlassExpiration.
+	BreakGlassGroups []string
+	// BreakGlassExpiration is the time at which the break-glass window ends.
+	BreakGlassExpiration time.Time
+}
+
+// defaultPolicy is the Policy used when the policy ConfigMap does not exist.
+var defaultPolicy = Policy{Mode: EnforceMode}
+
+// breakGlass returns true if the user is allowed to modify managed objects at
+// the given time.
+func (p Policy) breakGlass(userInfo authenticationv1.UserInfo, now time.Time) bool {
+	if !now.Before(p.BreakGlassExpiration) {
+		return false
+	}
+	for _, user := range p.BreakGlassUsers {
+		if user == userInfo.Username {
+			return true
+		}
+	}
+	for _, group := range p.BreakGlassGroups {
+		for _, userGroup := range userInfo.Groups {
+			if group == userGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getPolicy returns the Policy of the webhook. If the policy ConfigMap does not
+// exist or is invalid, it returns the default Policy, which denies all the
+// requests modifying managed objects.
+func getPolicy(ctx context.Context, reader client.Reader) Policy {
+	if reader == nil {
+		return defaultPolicy
+	}
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: configuration.PolicyName}
+	if err := reader.Get(ctx, key, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to get the admission webhook policy ConfigMap %s: %v", key, err)
+		}
+		return defaultPolicy
+	}
+	policy, err := parsePolicy(cm)
+	if err != nil {
+		klog.Errorf("Invalid admission webhook policy ConfigMap %s: %v", key, err)
+		return defaultPolicy
+	}
+	return policy
+}
+
+// parsePolicy parses the Policy from the data of the policy ConfigMap.
+func parsePolicy(cm *corev1.ConfigMap) (Policy, error) {
+	policy := Policy{Mode: Mode(cm.Data[policyModeKey])}
+	switch policy.Mode {
+	case "":
+		policy.Mode = EnforceMode
+	case EnforceMode, AuditMode:
+	default:
+		return Policy{}, fmt.Errorf("%s must be %q or %q, got %q", policyModeKey, EnforceMode, AuditMode, policy.Mode)
+	}
+
+	policy.BreakGlassUsers = util.SplitList(cm.Data[breakGlassUsersKey])
+	policy.BreakGlassGroups = util.SplitList(cm.Data[breakGlassGroupsKey])
+	if len(policy.BreakGlassUsers) == 0 && len(policy.BreakGlassGroups) == 0 {
+		return policy, nil
+	}
+	// The break-glass window must be time-boxed.
+	expiration, found := cm.Data[breakGlassExpirationKey]
+	if !found {
+		return Policy{}, fmt.Errorf("%s is required when %s or %s is set", breakGlassExpirationKey, breakGlassUsersKey, breakGlassGroupsKey)
+	}
+	t, err := time.Parse(time.RFC3339, expiration)
+	if err != nil {
+		return Policy{}, errors.Wrapf(err, "%s must be an RFC 3339 time", breakGlassExpirationKey)
+	}
+	policy.BreakGlassExpiration = t
+	return policy, nil
+}
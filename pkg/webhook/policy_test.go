@@ -0,0 +1,261 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	csmetadata "kpt.dev/configsync/pkg/metadata"
+	syncerFake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"kpt.dev/configsync/pkg/webhook/configuration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var expiration = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func policyConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: configsync.ControllerNamespace,
+			Name:      configuration.PolicyName,
+		},
+		Data: data,
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    map[string]string
+		want    Policy
+		wantErr bool
+	}{
+		{
+			name: "empty ConfigMap enforces",
+			want: Policy{Mode: EnforceMode},
+		},
+		{
+			name: "audit mode",
+			data: map[string]string{policyModeKey: "audit"},
+			want: Policy{Mode: AuditMode},
+		},
+		{
+			name:    "invalid mode",
+			data:    map[string]string{policyModeKey: "warn"},
+			wantErr: true,
+		},
+		{
+			name: "break-glass users and groups",
+			data: map[string]string{
+				breakGlassUsersKey:      "alice@acme.com, carol@acme.com",
+				breakGlassGroupsKey:     "sre@acme.com",
+				breakGlassExpirationKey: "2024-01-01T12:00:00Z",
+			},
+			want: Policy{
+				Mode:                 EnforceMode,
+				BreakGlassUsers:      []string{"alice@acme.com", "carol@acme.com"},
+				BreakGlassGroups:     []string{"sre@acme.com"},
+				BreakGlassExpiration: expiration,
+			},
+		},
+		{
+			name: "break-glass without expiration",
+			data: map[string]string{
+				breakGlassUsersKey: "alice@acme.com",
+			},
+			wantErr: true,
+		},
+		{
+			name: "break-glass with invalid expiration",
+			data: map[string]string{
+				breakGlassUsersKey:      "alice@acme.com",
+				breakGlassExpirationKey: "tomorrow",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parsePolicy(policyConfigMap(tc.data))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("got parsePolicy() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got parsePolicy() error = %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPolicy_BreakGlass(t *testing.T) {
+	policy := Policy{
+		Mode:                 EnforceMode,
+		BreakGlassUsers:      []string{"alice@acme.com"},
+		BreakGlassGroups:     []string{"sre@acme.com"},
+		BreakGlassExpiration: expiration,
+	}
+	alice := authenticationv1.UserInfo{Username: "alice@acme.com"}
+	sre := authenticationv1.UserInfo{Username: "carol@acme.com", Groups: []string{"sre@acme.com"}}
+
+	testCases := []struct {
+		name string
+		user authenticationv1.UserInfo
+		now  time.Time
+		want bool
+	}{
+		{
+			name: "listed user before expiration",
+			user: alice,
+			now:  expiration.Add(-time.Minute),
+			want: true,
+		},
+		{
+			name: "listed group before expiration",
+			user: sre,
+			now:  expiration.Add(-time.Minute),
+			want: true,
+		},
+		{
+			name: "listed user at expiration",
+			user: alice,
+			now:  expiration,
+			want: false,
+		},
+		{
+			name: "unlisted user before expiration",
+			user: bob(),
+			now:  expiration.Add(-time.Minute),
+			want: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := policy.breakGlass(tc.user, tc.now); got != tc.want {
+				t.Errorf("got breakGlass() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidator_HandleWithPolicy(t *testing.T) {
+	managedRole := fake.RoleObject(
+		core.Namespace("videostore"),
+		core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+		core.Annotation(csmetadata.ResourceIDKey, "rbac.authorization.k8s.io_role_videostore_default-name"),
+		core.Annotation(csmetadata.ResourceManagerKey, repoSyncManagerAnnotation("videostore", repoSyncName)))
+	// Modifying the manager annotation is denied to users and to the other
+	// reconcilers.
+	modifiedRole := managedRole.DeepCopy()
+	core.SetAnnotation(modifiedRole, csmetadata.ResourceManagerKey, repoSyncManagerAnnotation("bookstore", repoSyncName))
+	breakGlassData := map[string]string{
+		breakGlassUsersKey:      "bob@acme.com",
+		breakGlassExpirationKey: "2024-01-01T12:00:00Z",
+	}
+
+	testCases := []struct {
+		name        string
+		policy      *corev1.ConfigMap
+		user        authenticationv1.UserInfo
+		now         time.Time
+		wantAllowed bool
+		wantWarning bool
+		wantEvent   bool
+	}{
+		{
+			name:        "no policy denies",
+			user:        bob(),
+			wantAllowed: false,
+		},
+		{
+			name:        "audit mode allows with a warning",
+			policy:      policyConfigMap(map[string]string{policyModeKey: "audit"}),
+			user:        bob(),
+			wantAllowed: true,
+			wantWarning: true,
+		},
+		{
+			name:        "break-glass user allows with a warning and an Event",
+			policy:      policyConfigMap(breakGlassData),
+			user:        bob(),
+			now:         expiration.Add(-time.Hour),
+			wantAllowed: true,
+			wantWarning: true,
+			wantEvent:   true,
+		},
+		{
+			name:        "expired break-glass denies",
+			policy:      policyConfigMap(breakGlassData),
+			user:        bob(),
+			now:         expiration.Add(time.Hour),
+			wantAllowed: false,
+		},
+		{
+			name: "Config Sync service account cannot break glass",
+			policy: policyConfigMap(map[string]string{
+				breakGlassUsersKey:      configSyncNamespaceReconciler("bookstore", repoSyncName).Username,
+				breakGlassExpirationKey: "2024-01-01T12:00:00Z",
+			}),
+			user:        configSyncNamespaceReconciler("bookstore", repoSyncName),
+			now:         expiration.Add(-time.Hour),
+			wantAllowed: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var objs []client.Object
+			if tc.policy != nil {
+				objs = append(objs, tc.policy)
+			}
+			recorder := record.NewFakeRecorder(10)
+			v := validatorForTest(t)
+			v.reader = syncerFake.NewClient(t, core.Scheme, objs...)
+			v.recorder = recorder
+			v.now = func() time.Time { return tc.now }
+
+			req := request(managedRole, modifiedRole)
+			req.UserInfo = tc.user
+
+			resp := v.Handle(context.Background(), req)
+			if resp.Allowed != tc.wantAllowed {
+				t.Errorf("got Handle() allowed = %t, want %t", resp.Allowed, tc.wantAllowed)
+			}
+			if gotWarning := len(resp.Warnings) > 0; gotWarning != tc.wantWarning {
+				t.Errorf("got Handle() warnings %v, want warning: %t", resp.Warnings, tc.wantWarning)
+			}
+			if gotEvent := len(recorder.Events) > 0; gotEvent != tc.wantEvent {
+				t.Errorf("got Event recorded: %t, want %t", gotEvent, tc.wantEvent)
+			}
+		})
+	}
+}
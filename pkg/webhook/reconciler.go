@@ -0,0 +1,179 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/api/configmanagement"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/importer"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/syncer/differ"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// validateReconciler returns an error if the Config Sync reconciler is not
+// allowed to make the request. It complements diff.ValidateManager, which
+// validates the manager of the object.
+//
+//   - A reconciler can only create objects it manages.
+//   - A RepoSync reconciler can only manage objects in the namespace of its
+//     RepoSync.
+//   - A RepoSync reconciler can not bind roles to RepoSync reconcilers.
+func validateReconciler(reconciler string, op admissionv1.Operation, oldObj, newObj client.Object) error {
+	if op == admissionv1.Delete || newObj == nil {
+		// diff.ValidateManager already validates the manager of deleted objects.
+		return nil
+	}
+	if op == admissionv1.Create {
+		if err := validateCreateManager(reconciler, newObj); err != nil {
+			return err
+		}
+	}
+	if !isNsReconciler(reconciler) {
+		return nil
+	}
+	if err := validateNamespaceScope(reconciler, newObj); err != nil {
+		return err
+	}
+	return validateRoleBinding(reconciler, oldObj, newObj)
+}
+
+// claimsHandoff returns true if the reconciler updates an object which its
+// current manager hands off to the reconciler with the handoff annotation. Only
+// the manager of an object can set the annotation on it.
+func claimsHandoff(reconciler string, op admissionv1.Operation, oldObj client.Object) bool {
+	if op != admissionv1.Update || oldObj == nil {
+		return false
+	}
+	target := differ.HandoffTarget(oldObj)
+	if target == "" {
+		return false
+	}
+	targetReconciler, _ := diff.ReconcilerName(target)
+	return targetReconciler == reconciler
+}
+
+// validateCreateManager returns an error if the manager annotation of the new
+// object is not the reconciler creating it.
+func validateCreateManager(reconciler string, newObj client.Object) error {
+	manager := getManager(newObj)
+	if manager == "" || reconciler == importer.Name {
+		return nil
+	}
+	if managerReconciler, _ := diff.ReconcilerName(manager); managerReconciler != reconciler {
+		return fmt.Errorf("config sync %q can not create object %q managed by config sync %q",
+			reconciler, core.IDOf(newObj), managerReconciler)
+	}
+	return nil
+}
+
+// validateNamespaceScope returns an error if the RepoSync reconciler sets the
+// manager annotation of an object to another reconciler, or to itself on an
+// object outside of the namespace of its RepoSync.
+func validateNamespaceScope(reconciler string, newObj client.Object) error {
+	manager := getManager(newObj)
+	if manager == "" {
+		// The annotation is removed, or was never set.
+		return nil
+	}
+	managerReconciler, scope := diff.ReconcilerName(manager)
+	if managerReconciler != reconciler {
+		return fmt.Errorf("config sync %q can not set the manager of object %q to config sync %q",
+			reconciler, core.IDOf(newObj), managerReconciler)
+	}
+	if string(scope) != newObj.GetNamespace() {
+		return fmt.Errorf("config sync %q can not manage object %q outside of namespace %q",
+			reconciler, core.IDOf(newObj), scope)
+	}
+	return nil
+}
+
+// validateRoleBinding returns an error if the RepoSync reconciler adds a
+// RepoSync reconciler to the subjects of a RoleBinding or ClusterRoleBinding,
+// which would escalate the permissions of that reconciler.
+func validateRoleBinding(reconciler string, oldObj, newObj client.Object) error {
+	gk := newObj.GetObjectKind().GroupVersionKind().GroupKind()
+	if gk != kinds.RoleBinding().GroupKind() && gk != kinds.ClusterRoleBinding().GroupKind() {
+		return nil
+	}
+	newSubjects, err := bindingSubjects(newObj)
+	if err != nil {
+		return err
+	}
+	oldSubjects, err := bindingSubjects(oldObj)
+	if err != nil {
+		return err
+	}
+	existing := make(map[rbacv1.Subject]bool)
+	for _, subject := range oldSubjects {
+		existing[subject] = true
+	}
+	for _, subject := range newSubjects {
+		if isNsReconcilerSubject(subject) && !existing[subject] {
+			return fmt.Errorf("config sync %q can not bind roles to %s %q, which includes RepoSync reconcilers, in %s %q",
+				reconciler, subject.Kind, subject.Name, gk.Kind, core.IDOf(newObj))
+		}
+	}
+	return nil
+}
+
+// bindingSubjects returns the subjects of the RoleBinding or
+// ClusterRoleBinding, which may be typed or unstructured.
+func bindingSubjects(obj client.Object) ([]rbacv1.Subject, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %q", core.IDOf(obj))
+	}
+	// RoleBindings and ClusterRoleBindings have the same subjects field.
+	binding := &rbacv1.RoleBinding{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, binding); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the subjects of %q", core.IDOf(obj))
+	}
+	return binding.Subjects, nil
+}
+
+// isNsReconciler returns true if the reconciler is a RepoSync reconciler.
+func isNsReconciler(reconciler string) bool {
+	return strings.HasPrefix(reconciler, core.NsReconcilerPrefix)
+}
+
+// isNsReconcilerSubject returns true if the subject includes the ServiceAccount
+// of a RepoSync reconciler, either as a ServiceAccount, as the User of the
+// ServiceAccount, or as one of the Groups of all ServiceAccounts.
+func isNsReconcilerSubject(subject rbacv1.Subject) bool {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		return subject.Namespace == configmanagement.ControllerNamespace &&
+			isNsReconciler(subject.Name)
+	case rbacv1.UserKind:
+		return strings.HasPrefix(subject.Name, saNamespaceGroupPrefix) &&
+			isNsReconciler(strings.TrimPrefix(subject.Name, saNamespaceGroupPrefix))
+	case rbacv1.GroupKind:
+		return subject.Name == saGroup || subject.Name == saNamespaceGroup
+	default:
+		return false
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/api/configmanagement"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	csmetadata "kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func setSubjects(subjects ...rbacv1.Subject) core.MetaMutator {
+	return func(o client.Object) {
+		switch binding := o.(type) {
+		case *rbacv1.RoleBinding:
+			binding.Subjects = subjects
+		case *rbacv1.ClusterRoleBinding:
+			binding.Subjects = subjects
+		}
+	}
+}
+
+func nsReconcilerSubject(ns, rsName string) rbacv1.Subject {
+	return rbacv1.Subject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Namespace: configmanagement.ControllerNamespace,
+		Name:      core.NsReconcilerName(ns, rsName),
+	}
+}
+
+func groupSubject(name string) rbacv1.Subject {
+	return rbacv1.Subject{
+		Kind:     rbacv1.GroupKind,
+		APIGroup: rbacv1.GroupName,
+		Name:     name,
+	}
+}
+
+func userSubject(name string) rbacv1.Subject {
+	return rbacv1.Subject{
+		Kind:     rbacv1.UserKind,
+		APIGroup: rbacv1.GroupName,
+		Name:     name,
+	}
+}
+
+// rawRequest returns the request with the objects encoded as raw JSON, as
+// they are sent by the API server.
+func rawRequest(t *testing.T, oldObj, newObj client.Object) admission.Request {
+	req := request(oldObj, newObj)
+	req.OldObject = rawExtension(t, oldObj)
+	req.Object = rawExtension(t, newObj)
+	return req
+}
+
+func rawExtension(t *testing.T, obj client.Object) runtime.RawExtension {
+	if obj == nil {
+		return runtime.RawExtension{}
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.RawExtension{Raw: raw}
+}
+
+func TestValidator_HandleReconcilerRules(t *testing.T) {
+	rootManager := declared.ResourceManager(declared.RootReconciler, rootSyncName)
+	bookstoreManager := declared.ResourceManager("bookstore", repoSyncName)
+	videostoreManager := declared.ResourceManager("videostore", repoSyncName)
+	bookstoreReconciler := configSyncNamespaceReconciler("bookstore", repoSyncName)
+
+	testCases := []struct {
+		name   string
+		oldObj client.Object
+		newObj client.Object
+		user   authenticationv1.UserInfo
+		raw    bool
+		deny   metav1.StatusReason
+	}{
+		// Namespace scope of RepoSync reconcilers
+		{
+			name:   "RepoSync reconciler creates object in its namespace",
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+		},
+		{
+			name:   "RepoSync reconciler creates object in another namespace",
+			newObj: fake.RoleObject(core.Namespace("videostore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonForbidden,
+		},
+		{
+			name:   "RepoSync reconciler adopts unmanaged object in another namespace",
+			oldObj: fake.RoleObject(core.Namespace("videostore")),
+			newObj: fake.RoleObject(core.Namespace("videostore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonForbidden,
+		},
+		{
+			name:   "RepoSync reconciler adopts unmanaged object in its namespace",
+			oldObj: fake.RoleObject(core.Namespace("bookstore")),
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+		},
+		{
+			name:   "RepoSync reconciler sets the manager of unmanaged object to another reconciler",
+			oldObj: fake.RoleObject(core.Namespace("videostore")),
+			newObj: fake.RoleObject(core.Namespace("videostore"), core.Annotation(csmetadata.ResourceManagerKey, videostoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonUnauthorized,
+		},
+		{
+			name: "RepoSync reconciler claims object handed off to it by a RootSync reconciler",
+			oldObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, rootManager),
+				core.Annotation(csmetadata.ResourceHandoffKey, bookstoreManager)),
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+		},
+		{
+			name: "RepoSync reconciler claims object handed off to another RepoSync reconciler",
+			oldObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, rootManager),
+				core.Annotation(csmetadata.ResourceHandoffKey, videostoreManager)),
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonUnauthorized,
+		},
+		{
+			name: "RepoSync reconciler claims object handed off to it in another namespace",
+			oldObj: fake.RoleObject(core.Namespace("videostore"), core.Annotation(csmetadata.ResourceManagerKey, rootManager),
+				core.Annotation(csmetadata.ResourceHandoffKey, bookstoreManager)),
+			newObj: fake.RoleObject(core.Namespace("videostore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonForbidden,
+		},
+		{
+			name:   "RepoSync reconciler creates cluster-scoped object",
+			newObj: fake.ClusterRoleObject(core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonForbidden,
+		},
+		{
+			name:   "RepoSync reconciler removes its manager annotation",
+			oldObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			newObj: fake.RoleObject(core.Namespace("bookstore")),
+			user:   bookstoreReconciler,
+		},
+		// Manager annotation of created objects
+		{
+			name:   "RootSync reconciler creates object it manages",
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, rootManager)),
+			user:   configSyncRootReconciler(rootSyncName),
+		},
+		{
+			name:   "RootSync reconciler creates object managed by a RepoSync reconciler",
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   configSyncRootReconciler(rootSyncName),
+			deny:   metav1.StatusReasonForbidden,
+		},
+		{
+			name:   "RepoSync reconciler creates object managed by another RepoSync reconciler",
+			newObj: fake.RoleObject(core.Namespace("videostore"), core.Annotation(csmetadata.ResourceManagerKey, videostoreManager)),
+			user:   bookstoreReconciler,
+			deny:   metav1.StatusReasonUnauthorized,
+		},
+		{
+			name:   "Bob creates object with a manager annotation",
+			newObj: fake.RoleObject(core.Namespace("bookstore"), core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager)),
+			user:   bob(),
+			deny:   metav1.StatusReasonUnauthorized,
+		},
+		{
+			name:   "Bob creates object without a manager annotation",
+			newObj: fake.RoleObject(core.Namespace("bookstore")),
+			user:   bob(),
+		},
+		// Permission escalation of RepoSync reconcilers
+		{
+			name: "RepoSync reconciler binds roles to itself",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(nsReconcilerSubject("bookstore", repoSyncName))),
+			user: bookstoreReconciler,
+			deny: metav1.StatusReasonForbidden,
+		},
+		{
+			name: "RepoSync reconciler binds roles to another RepoSync reconciler",
+			oldObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(userSubject("alice@acme.com"))),
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(userSubject("alice@acme.com"), nsReconcilerSubject("videostore", repoSyncName))),
+			user: bookstoreReconciler,
+			deny: metav1.StatusReasonForbidden,
+		},
+		{
+			name: "RepoSync reconciler binds roles to another RepoSync reconciler with raw objects",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(nsReconcilerSubject("videostore", repoSyncName))),
+			user: bookstoreReconciler,
+			raw:  true,
+			deny: metav1.StatusReasonForbidden,
+		},
+		{
+			name: "RepoSync reconciler binds roles to all ServiceAccounts",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(groupSubject("system:serviceaccounts"))),
+			user: bookstoreReconciler,
+			deny: metav1.StatusReasonForbidden,
+		},
+		{
+			name: "RepoSync reconciler binds roles to the ServiceAccounts of config-management-system",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(groupSubject("system:serviceaccounts:config-management-system"))),
+			user: bookstoreReconciler,
+			deny: metav1.StatusReasonForbidden,
+		},
+		{
+			name: "RepoSync reconciler binds roles to the user of a RepoSync reconciler",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(userSubject("system:serviceaccount:config-management-system:"+core.NsReconcilerName("videostore", repoSyncName)))),
+			user: bookstoreReconciler,
+			deny: metav1.StatusReasonForbidden,
+		},
+		{
+			name: "RepoSync reconciler binds roles to the ServiceAccounts of its namespace",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(groupSubject("system:serviceaccounts:bookstore"))),
+			user: bookstoreReconciler,
+		},
+		{
+			name: "RepoSync reconciler binds roles to the user of another ServiceAccount",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(userSubject("system:serviceaccount:config-management-system:reconciler-manager"))),
+			user: bookstoreReconciler,
+		},
+		{
+			name: "RepoSync reconciler binds roles to a user",
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(userSubject("alice@acme.com"))),
+			user: bookstoreReconciler,
+		},
+		{
+			name: "RepoSync reconciler updates RoleBinding without new subjects",
+			oldObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				setSubjects(nsReconcilerSubject("bookstore", repoSyncName))),
+			newObj: fake.RoleBindingObject(core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagerKey, bookstoreManager),
+				core.Label("team", "books"),
+				setSubjects(nsReconcilerSubject("bookstore", repoSyncName))),
+			user: bookstoreReconciler,
+		},
+		{
+			name: "RootSync reconciler binds cluster roles to a RepoSync reconciler",
+			newObj: fake.ClusterRoleBindingObject(
+				core.Annotation(csmetadata.ResourceManagerKey, rootManager),
+				setSubjects(nsReconcilerSubject("bookstore", repoSyncName))),
+			user: configSyncRootReconciler(rootSyncName),
+		},
+	}
+
+	v := validatorForTest(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var req admission.Request
+			if tc.raw {
+				req = rawRequest(t, tc.oldObj, tc.newObj)
+			} else {
+				req = request(tc.oldObj, tc.newObj)
+			}
+			req.UserInfo = tc.user
+
+			resp := v.Handle(context.Background(), req)
+			if resp.Allowed {
+				if tc.deny != "" {
+					t.Errorf("got Handle() response allowed, want denied %q", tc.deny)
+				}
+			} else if tc.deny == "" {
+				t.Errorf("got Handle() response denied %q (%s), want allowed", resp.Result.Reason, resp.Result.Message)
+			} else if tc.deny != resp.Result.Reason {
+				t.Errorf("got Handle() response denied %q (%s), want denied %q", resp.Result.Reason, resp.Result.Message, tc.deny)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package webhook
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt/pkg/live"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -23,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/resourcegroup"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -72,6 +74,13 @@ func fromConfigSync(req admission.Request) (bool, error) {
 		return false, err
 	}
 
-	hasInventoryLabel := labels[common.InventoryLabel] == applier.InventoryID(name, namespace)
-	return hasInventoryLabel, nil
+	id := labels[common.InventoryLabel]
+	if id == applier.InventoryID(name, namespace) {
+		return true, nil
+	}
+	// The other shards of a sharded inventory have the inventory ID of the
+	// first one.
+	inventoryName := strings.TrimPrefix(id, namespace+"_")
+	isShard := id == applier.InventoryID(inventoryName, namespace) && resourcegroup.IsShardOf(name, inventoryName)
+	return isShard, nil
 }
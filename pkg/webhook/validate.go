@@ -17,13 +17,18 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
@@ -44,6 +49,8 @@ func AddValidator(mgr manager.Manager) error {
 	if err != nil {
 		return err
 	}
+	handler.reader = mgr.GetClient()
+	handler.recorder = mgr.GetEventRecorderFor(configuration.ShortName)
 	mgr.GetWebhookServer().Register(configuration.ServingPath, &webhook.Admission{
 		Handler: handler,
 	})
@@ -54,6 +61,12 @@ func AddValidator(mgr manager.Manager) error {
 // requests and admits or denies them.
 type Validator struct {
 	differ *ObjectDiffer
+	// reader reads the policy ConfigMap. If nil, the default Policy is used.
+	reader client.Reader
+	// recorder records an Event for each use of break-glass.
+	recorder record.EventRecorder
+	// now returns the current time. Defaults to time.Now.
+	now func() time.Time
 }
 
 var _ admission.Handler = &Validator{}
@@ -68,11 +81,65 @@ func handler(cfg *rest.Config) (*Validator, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Validator{&ObjectDiffer{vc}}, nil
+	return &Validator{differ: &ObjectDiffer{vc}}, nil
 }
 
 // Handle implements admission.Handler
-func (v *Validator) Handle(_ context.Context, req admission.Request) admission.Response {
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := v.handle(req)
+	if resp.Allowed {
+		return resp
+	}
+	return v.applyPolicy(ctx, req, resp)
+}
+
+// applyPolicy allows the denied request if the user is allowed to break glass
+// or if the webhook is in audit mode.
+func (v *Validator) applyPolicy(ctx context.Context, req admission.Request, denied admission.Response) admission.Response {
+	policy := getPolicy(ctx, v.reader)
+	message := denied.Result.Message
+	// Break-glass is for people. Config Sync service accounts are never allowed
+	// to break glass.
+	if !isConfigSyncSA(req.UserInfo) && policy.breakGlass(req.UserInfo, v.currentTime()) {
+		klog.InfoS("Admission request allowed by break-glass",
+			"user", req.UserInfo.Username,
+			"groups", req.UserInfo.Groups,
+			"operation", req.Operation,
+			"kind", req.Kind.Kind,
+			"namespace", req.Namespace,
+			"name", req.Name,
+			"expiration", policy.BreakGlassExpiration.Format(time.RFC3339),
+			"denial", message)
+		if v.recorder != nil {
+			v.recorder.Eventf(objectReference(req), corev1.EventTypeWarning, "BreakGlass",
+				"%s used break-glass to %s a managed object, which would have been denied: %s",
+				req.UserInfo.Username, strings.ToLower(string(req.Operation)), message)
+		}
+		return allow().WithWarnings(fmt.Sprintf("Allowed by break-glass until %s: %s",
+			policy.BreakGlassExpiration.Format(time.RFC3339), message))
+	}
+	if policy.Mode == AuditMode {
+		klog.InfoS("Admission request allowed in audit mode",
+			"user", req.UserInfo.Username,
+			"operation", req.Operation,
+			"kind", req.Kind.Kind,
+			"namespace", req.Namespace,
+			"name", req.Name,
+			"denial", message)
+		return allow().WithWarnings(fmt.Sprintf("Config Sync would deny this request: %s", message))
+	}
+	return denied
+}
+
+func (v *Validator) currentTime() time.Time {
+	if v.now == nil {
+		return time.Now()
+	}
+	return v.now()
+}
+
+// handle allows or denies the admission request.
+func (v *Validator) handle(req admission.Request) admission.Response {
 	// An admission request for a sub-resource (such as a Scale) will not include
 	// the full parent for us to validate until the admission chain is fixed:
 	// https://github.com/kubernetes/enhancements/pull/1600
@@ -96,10 +163,17 @@ func (v *Validator) Handle(_ context.Context, req admission.Request) admission.R
 		manager := objectManager(oldObj, newObj)
 		id := objectID(oldObj, newObj)
 		// TODO: validate managed=enabled?
-		err = diff.ValidateManager(username, manager, id, req.Operation)
-		if err != nil {
+		// A reconciler may claim an object which its manager hands off to it.
+		if !claimsHandoff(username, req.Operation, oldObj) {
+			err = diff.ValidateManager(username, manager, id, req.Operation)
+			if err != nil {
+				klog.Error(err.Error())
+				return deny(metav1.StatusReasonUnauthorized, err.Error())
+			}
+		}
+		if err := validateReconciler(username, req.Operation, oldObj, newObj); err != nil {
 			klog.Error(err.Error())
-			return deny(metav1.StatusReasonUnauthorized, err.Error())
+			return deny(metav1.StatusReasonForbidden, err.Error())
 		}
 		return allow()
 	}
@@ -128,6 +202,10 @@ func (v *Validator) handleCreate(newObj client.Object, username string) admissio
 		klog.Errorf("%s is not authorized to create managed resource %q", username, core.GKNN(newObj))
 		return deny(metav1.StatusReasonUnauthorized, fmt.Sprintf("%s is not authorized to create managed resource %q", username, core.GKNN(newObj)))
 	}
+	if manager := getManager(newObj); manager != "" {
+		klog.Errorf("%s is not authorized to create resource %q managed by config sync %q", username, core.GKNN(newObj), manager)
+		return deny(metav1.StatusReasonUnauthorized, fmt.Sprintf("%s is not authorized to create resource %q managed by config sync %q", username, core.GKNN(newObj), manager))
+	}
 	return allow()
 }
 
@@ -256,6 +334,16 @@ func getManager(obj client.Object) string {
 	return annotations[csmetadata.ResourceManagerKey]
 }
 
+// objectReference returns a reference to the object of the admission request.
+func objectReference(req admission.Request) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: schema.GroupVersion{Group: req.Kind.Group, Version: req.Kind.Version}.String(),
+		Kind:       req.Kind.Kind,
+		Namespace:  req.Namespace,
+		Name:       req.Name,
+	}
+}
+
 func allow() admission.Response {
 	return admission.Allowed("")
 }
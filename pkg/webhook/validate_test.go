@@ -542,6 +542,25 @@ func TestValidator_Handle(t *testing.T) {
 			user: bob(),
 			deny: metav1.StatusReasonUnauthorized,
 		},
+		{
+			name: "Bob deletes a ResourceGroup shard generated by ConfigSync",
+			oldObj: fake.ResourceGroupObject(
+				core.Name("repo-sync-shard-1"),
+				core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Label(common.InventoryLabel, applier.InventoryID("repo-sync", "bookstore"))),
+			user: bob(),
+			deny: metav1.StatusReasonUnauthorized,
+		},
+		{
+			name: "Bob creates a ResourceGroup with the inventory ID of another ResourceGroup",
+			newObj: fake.ResourceGroupObject(
+				core.Name("repo-sync-copy"),
+				core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Label(common.InventoryLabel, applier.InventoryID("repo-sync", "bookstore"))),
+			user: bob(),
+		},
 		{
 			name: "Bob creates an independent ResourceGroup",
 			newObj: fake.ResourceGroupObject(